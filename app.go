@@ -0,0 +1,236 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"docs_organiser/internal/config"
+	"docs_organiser/internal/logging"
+	"docs_organiser/internal/pipeline"
+	"docs_organiser/internal/storage"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// app is what a command runs with. Commands start from the configuration and open the
+// storage and pipeline when they need them; what they open is closed when they return.
+type app struct {
+	cfg *config.Config
+	// command is the path of the command below the root, such as "reorganize plan".
+	command string
+	// stdout receives the command's output; with JSON output os.Stdout is stderr.
+	stdout io.Writer
+	events *pipeline.EventWriter
+
+	ctx context.Context
+	// hard is cancelled by a second interrupt or SIGTERM, with errInterruptedAgain.
+	hard  context.Context
+	store *storage.BadgerStore
+	p     *pipeline.Pipeline
+	// extractorExts are the extensions of the configured extractors and extractor plugins.
+	extractorExts []string
+
+	closers []func()
+}
+
+// action returns a cobra Run function that loads the configuration from flags, sets up
+// output and logging, and calls run.
+func action(flags *pflag.FlagSet, run func(a *app, args []string)) func(*cobra.Command, []string) {
+	return func(cmd *cobra.Command, args []string) {
+		a := newApp(flags)
+		defer a.close()
+		a.command = strings.TrimPrefix(strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()), " ")
+		run(a, args)
+	}
+}
+
+// newApp loads the configuration and sets up output and logging.
+func newApp(flags *pflag.FlagSet) *app {
+	// Load configuration using Viper
+	cfg, err := config.Load(flags)
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+	a := &app{cfg: cfg, stdout: os.Stdout}
+
+	// JSON output owns stdout; everything meant for people goes to stderr instead
+	switch cfg.Output {
+	case "", "text":
+	case "json":
+		a.events = pipeline.NewEventWriter(os.Stdout)
+		os.Stdout = os.Stderr
+	default:
+		log.Fatalf("Invalid configuration: unknown output %q (want text or json)", cfg.Output)
+	}
+
+	level := logging.LevelNormal
+	switch {
+	case cfg.Quiet && cfg.Verbose:
+		log.Fatalf("Invalid configuration: quiet and verbose are mutually exclusive")
+	case cfg.Quiet:
+		level = logging.LevelQuiet
+	case cfg.Verbose:
+		level = logging.LevelVerbose
+	}
+	if cfg.LogFile != "" {
+		logFile, err := logging.OpenRotating(cfg.LogFile, int64(cfg.LogMaxSizeMB)<<20, cfg.LogMaxBackups)
+		if err != nil {
+			log.Fatalf("Failed to open log file: %v", err)
+		}
+		a.onClose(func() { logFile.Close() })
+		logging.Setup(level, logFile, false)
+	} else {
+		// Log lines erase the progress line they would otherwise run into
+		logging.Setup(level, os.Stderr, a.events == nil && isTerminal(os.Stdout) && isTerminal(os.Stderr))
+	}
+	if cfg.Quiet {
+		// The banner and progress line go to stdout; warnings and summaries are logged
+		if devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0); err == nil {
+			os.Stdout = devNull
+		}
+	}
+	return a
+}
+
+// asJSON reports whether command output is JSON.
+func (a *app) asJSON() bool {
+	return a.events != nil
+}
+
+// onClose runs f when the command returns, before what was opened earlier is closed.
+func (a *app) onClose(f func()) {
+	a.closers = append(a.closers, f)
+}
+
+// close closes what the command opened, last first.
+func (a *app) close() {
+	for i := len(a.closers) - 1; i >= 0; i-- {
+		a.closers[i]()
+	}
+	a.closers = nil
+}
+
+// exit closes what the command opened and exits with code.
+func (a *app) exit(code int) {
+	a.close()
+	os.Exit(code)
+}
+
+// errInterruptedAgain is the cause of the hard stop context's cancellation.
+var errInterruptedAgain = errors.New("interrupted again")
+
+// context returns a context cancelled by the first interrupt or SIGTERM. Until it is first
+// called, those signals end the process as usual.
+func (a *app) context() context.Context {
+	if a.ctx == nil {
+		// One registration for both signals, so a second one arriving right after the
+		// first is not lost
+		signals := make(chan os.Signal, 2)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		ctx, cancel := context.WithCancel(context.Background())
+		hard, cancelHard := context.WithCancelCause(context.Background())
+		done := make(chan struct{})
+		go func() {
+			for _, stop := range []func(){cancel, func() { cancelHard(errInterruptedAgain) }} {
+				select {
+				case <-signals:
+					stop()
+				case <-done:
+					return
+				}
+			}
+		}()
+		a.ctx, a.hard = ctx, hard
+		a.onClose(func() {
+			signal.Stop(signals)
+			close(done)
+			cancel()
+			cancelHard(nil)
+		})
+	}
+	return a.ctx
+}
+
+// hardStop returns a context cancelled by a second interrupt or SIGTERM, for the work the
+// first one lets finish, and reports that cancellation when it happens.
+func (a *app) hardStop() context.Context {
+	a.context()
+	go func() {
+		<-a.hard.Done()
+		if context.Cause(a.hard) == errInterruptedAgain {
+			fmt.Println("\n[!] Cancelling in-flight files...")
+		}
+	}()
+	return a.hard
+}
+
+// openStore opens the storage and applies the settings persisted in it, saving the
+// configuration as those settings the first time.
+func (a *app) openStore() *storage.BadgerStore {
+	if a.store != nil {
+		return a.store
+	}
+	cfg := a.cfg
+	store, err := storage.NewBadgerStore(cfg.DBPath)
+	if err != nil {
+		log.Fatalf("Failed to initialize storage: %v", err)
+	}
+	a.store = store
+	a.onClose(func() { store.Close() })
+
+	// Load persistent settings
+	var persisted config.Config
+	exists, err := store.Load(cfg.SettingsKey(), &persisted)
+	if err != nil {
+		log.Printf("[!] Failed to load persistent settings: %v", err)
+	}
+	if exists {
+		cfg.SourceDir = persisted.SourceDir
+		cfg.DestDir = persisted.DestDir
+		cfg.AllowedModels = persisted.AllowedModels
+		cfg.DefaultModelName = persisted.DefaultModelName
+		cfg.Workers = persisted.Workers
+		cfg.ExtractLimit = persisted.ExtractLimit
+		cfg.Categories = persisted.Categories
+		fmt.Println("[+] Persistent settings loaded from Badger KV.")
+	} else {
+		// Initial save of defaults
+		if err := store.Save(cfg.SettingsKey(), cfg); err != nil {
+			log.Printf("[!] Failed to save initial settings: %v", err)
+		}
+	}
+	cfg.ApplyProfile()
+	return store
+}
+
+// preflight reports whether the model server is ready to classify, up front; each run
+// checks again before scanning. Commands that only move or look up files skip it.
+func (a *app) preflight() {
+	p, ctx := a.p, a.context()
+	if p.NoLLM {
+		fmt.Println("[*] No-LLM mode: classifying by file name patterns and keywords")
+	} else if p.Classifier != nil {
+		fmt.Printf("[*] Classifying with plugin %s: %s\n", p.Classifier.Name, strings.Join(p.Classifier.Command, " "))
+	} else if model, err := p.AI.Preflight(ctx); err != nil {
+		log.Printf("[!] Warning: %v", err)
+	} else {
+		fmt.Printf("[+] Model server ready: %s\n", model)
+		if a.cfg.AutoContext {
+			fmt.Printf("[+] Context window: %d tokens\n", p.AI.AutoSizeContext(ctx, model))
+		}
+	}
+}
+
+// isTerminal reports whether f is a character device such as a terminal.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"context"
+	"log"
+
+	"docs_organiser/internal/pipeline"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+func newApplyCSVCommand(flags *pflag.FlagSet) *cobra.Command {
+	return &cobra.Command{
+		Use:     "apply-csv <decisions.csv>",
+		Aliases: []string{"apply"},
+		Short:   "Move files into the categories a CSV lists, without classifying them",
+		Args:    cobra.ExactArgs(1),
+		Run:     action(flags, runApply),
+	}
+}
+
+// runApply moves the files listed in the decisions CSV named by args. A signal stops it
+// after the file being moved.
+func runApply(a *app, args []string) {
+	p := a.openPipeline()
+	if p.SourceDir == "" || p.DestDir == "" {
+		log.Fatalf("No source/destination configured; set src and dst in the config file")
+	}
+	decisions, err := pipeline.LoadDecisions(args[0])
+	if err != nil {
+		log.Fatalf("Failed to load decisions: %v", err)
+	}
+	if err := p.Apply(a.context(), decisions); err != nil && err != context.Canceled {
+		log.Fatalf("Apply failed: %v", err)
+	}
+	p.Webhook.Wait()
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"docs_organiser/internal/buildinfo"
+	"docs_organiser/internal/config"
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// newRootCommand builds the command tree. Every command shares the configuration
+// flags; without a command the dashboard is served, as before subcommands existed.
+// Each command is defined next to its handler, in the file named after it.
+func newRootCommand() *cobra.Command {
+	flags := config.Flags()
+	serve := newServeCommand(flags)
+
+	root := &cobra.Command{
+		Use:   "docs_organiser",
+		Short: "Organise documents into category folders with a local language model",
+		Long: `docs_organiser reads documents from a source folder, asks an OpenAI-compatible
+model server which category each belongs to, and moves them into category
+folders under the destination with clean names.
+
+Settings come from flags, DOCS_ environment variables, and config.yaml, in that
+order of precedence. Without a command, the dashboard is served.`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		Version:      buildinfo.Get().String(),
+		Run:          serve.Run,
+	}
+	root.SetVersionTemplate("docs_organiser {{.Version}}\n")
+	root.SetGlobalNormalizationFunc(config.NormalizeFlagName)
+	root.PersistentFlags().AddFlagSet(flags)
+	registerCompletions(root)
+
+	root.AddCommand(
+		serve,
+		newOrganizeCommand(flags),
+		newPlanCommand(flags),
+		newDaemonCommand(flags),
+		newIMAPCommand(flags),
+		newEvalCommand(flags),
+		newStatsCommand(flags),
+		newReportCommand(flags),
+		newUndoCommand(flags),
+		newSearchCommand(flags),
+		newSuggestCategoriesCommand(flags),
+		newApplyCSVCommand(flags),
+		newReorganizeCommand(flags),
+		newConfigCommand(flags),
+		&cobra.Command{
+			Use:   "version",
+			Short: "Print the version, commit, build date, and Go version",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, _ []string) error {
+				info := buildinfo.Get()
+				if output, _ := flags.GetString("output"); output == "json" {
+					return json.NewEncoder(cmd.OutOrStdout()).Encode(info)
+				}
+				_, err := fmt.Fprintf(cmd.OutOrStdout(), "docs_organiser %s\n", info)
+				return err
+			},
+		},
+		newInstallServiceCommand(flags),
+	)
+	return root
+}
+
+// registerCompletions teaches the generated shell completions the values of enum flags
+// and which flags take files or directories.
+func registerCompletions(root *cobra.Command) {
+	values := map[string][]string{
+		"output":          {"text", "json"},
+		"collisions":      {"hash", "sequence", "skip", "overwrite", "newest"},
+		"naming":          {"rename", "keep", "prefix"},
+		"near_duplicates": {"off", "flag", "group"},
+		"layout":          {"flat", "mirror"},
+		"order":           {"walk", "smallest", "largest", "oldest", "newest"},
+		"collision_hash":  {"sha256", "xxhash", "partial"},
+		"truncation":      {"map_reduce", "salience", "middle_extraction", "sliding_window"},
+		"sidecar":         {"json", "yaml"},
+	}
+	for name, choices := range values {
+		_ = root.RegisterFlagCompletionFunc(name, cobra.FixedCompletions(choices, cobra.ShellCompDirectiveNoFileComp))
+	}
+	for _, name := range []string{"config", "taxonomy_file"} {
+		_ = root.MarkPersistentFlagFilename(name, "yaml", "yml")
+	}
+	for _, name := range []string{"failures_file", "retry_from", "queue_file"} {
+		_ = root.MarkPersistentFlagFilename(name, "json")
+	}
+	for _, name := range []string{"db_path", "notes_dir"} {
+		_ = root.MarkPersistentFlagDirname(name)
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+
+	"docs_organiser/internal/ai"
+	"docs_organiser/internal/config"
+	"docs_organiser/internal/configcheck"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+func newConfigCommand(flags *pflag.FlagSet) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Create or check the configuration",
+	}
+	cmd.AddCommand(
+		&cobra.Command{
+			Use:   "init",
+			Short: "Ask for the basic settings and write a commented config file",
+			Args:  cobra.NoArgs,
+			Run: action(flags, func(a *app, _ []string) {
+				// init runs before there is a usable configuration, so it needs no storage
+				if err := runConfigInit(a.cfg); err != nil {
+					log.Fatalf("Config init failed: %v", err)
+				}
+			}),
+		},
+		&cobra.Command{
+			Use:   "validate",
+			Short: "Check folders, model server, tokenizer, categories, and templates",
+			Args:  cobra.NoArgs,
+			Run: action(flags, func(a *app, _ []string) {
+				a.openStore()
+				if !runConfigValidate(a.context(), a.cfg) {
+					a.exit(1)
+				}
+			}),
+		},
+	)
+	return cmd
+}
+
+// runConfigValidate checks cfg and reports whether every check passed.
+func runConfigValidate(ctx context.Context, cfg *config.Config) bool {
+	fmt.Printf("[*] Validating configuration (%s)\n", cfg.ConfigFile)
+	results := configcheck.Run(ctx, cfg)
+	configcheck.WriteText(os.Stdout, results)
+	return configcheck.Failed(results) == 0
+}
+
+// runConfigInit asks for the basic settings and writes them to the config file path.
+func runConfigInit(cfg *config.Config) error {
+	wizard := &config.Wizard{
+		In:  os.Stdin,
+		Out: os.Stdout,
+		Models: func(apiURL string) ([]string, error) {
+			return ai.ListModels(context.Background(), apiURL)
+		},
+	}
+
+	path := cfg.ConfigFile
+	if _, err := os.Stat(path); err == nil {
+		overwrite, err := wizard.Confirm(fmt.Sprintf("%s already exists. Overwrite it?", path))
+		if err != nil {
+			return err
+		}
+		if !overwrite {
+			fmt.Println("[*] Left the existing config file unchanged.")
+			return nil
+		}
+	}
+	answers, err := wizard.Run()
+	if err != nil {
+		return err
+	}
+	if err := config.WriteConfig(path, answers); err != nil {
+		return err
+	}
+	fmt.Printf("[+] Wrote %s. Check it with: docs_organiser config validate -config %s\n", path, path)
+	return nil
+}
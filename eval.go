@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+
+	"docs_organiser/internal/eval"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+func newEvalCommand(flags *pflag.FlagSet) *cobra.Command {
+	return &cobra.Command{
+		Use:   "eval <labels.csv|labels.json>",
+		Short: "Classify labeled files without moving them and report accuracy",
+		Args:  cobra.ExactArgs(1),
+		Run:   action(flags, runEval),
+	}
+}
+
+// runEval classifies every labeled file without moving it and writes an accuracy report
+// to stdout, as JSON with JSON output.
+func runEval(a *app, args []string) {
+	p := a.openPipeline()
+	a.preflight()
+	ctx := a.context()
+	labels, err := eval.LoadLabels(args[0])
+	if err != nil {
+		log.Fatalf("Failed to load labels: %v", err)
+	}
+	// Without a configured or discoverable taxonomy, offer the model the labeled categories
+	if len(p.AI.GetCategories()) == 0 && p.DestDir == "" {
+		categories := eval.Categories(labels)
+		if !slices.ContainsFunc(categories, func(c string) bool { return strings.EqualFold(c, "Misc") }) {
+			categories = append(categories, "Misc")
+		}
+		p.AI.SetCategories(categories)
+	}
+	if err := p.Prepare(ctx); err != nil {
+		log.Fatalf("Failed to start evaluation: %v", err)
+	}
+
+	fmt.Printf("[*] Classifying %d labeled file(s)...\n", len(labels))
+	results := make([]eval.Result, len(labels))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for range max(p.Workers, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				rec := p.Classify(ctx, labels[i].File)
+				results[i] = eval.Result{File: labels[i].File, Expected: labels[i].Category}
+				if rec.Status != "" {
+					results[i].Error = rec.Error
+				} else {
+					results[i].Predicted = rec.Category
+				}
+			}
+		}()
+	}
+	for i := range labels {
+		if ctx.Err() != nil {
+			break
+		}
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+	if ctx.Err() != nil {
+		log.Fatalf("Evaluation interrupted")
+	}
+
+	report := eval.Evaluate(results)
+	if a.asJSON() {
+		err = json.NewEncoder(a.stdout).Encode(report)
+	} else {
+		err = report.WriteText(a.stdout)
+	}
+	if err != nil {
+		log.Fatalf("Failed to write report: %v", err)
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"docs_organiser/internal/audit"
+	"docs_organiser/internal/mailbox"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+func newIMAPCommand(flags *pflag.FlagSet) *cobra.Command {
+	return &cobra.Command{
+		Use:   "imap",
+		Short: "Organise the attachments of messages in an IMAP mailbox",
+		Args:  cobra.NoArgs,
+		Run:   action(flags, runIMAP),
+	}
+}
+
+// runIMAP organises mailbox attachments once, or every imap_poll_interval until interrupted.
+func runIMAP(a *app, _ []string) {
+	p := a.openPipeline()
+	a.preflight()
+	ctx, cfg := a.context(), a.cfg
+	opts := mailbox.Options{
+		Addr:            cfg.IMAPAddr,
+		Security:        cfg.IMAPSecurity,
+		Username:        cfg.IMAPUsername,
+		Password:        cfg.IMAPPassword,
+		Folder:          cfg.IMAPFolder,
+		ProcessedFolder: cfg.IMAPProcessedFolder,
+		From:            cfg.IMAPFrom,
+		Subject:         cfg.IMAPSubject,
+		Extensions:      cfg.IMAPExtensions,
+		MaxSize:         int64(cfg.IMAPMaxMessageMB) << 20,
+	}
+	if len(opts.Extensions) == 0 {
+		opts.Extensions = append([]string{".pdf"}, a.extractorExts...)
+	}
+	if err := opts.Validate(); err != nil {
+		log.Fatalf("Invalid IMAP configuration: %v", err)
+	}
+	if p.DestDir == "" {
+		log.Fatalf("No destination configured; set one in the dashboard before ingesting mail")
+	}
+	if err := p.Prepare(ctx); err != nil {
+		log.Fatalf("Failed to start IMAP ingestion: %v", err)
+	}
+	defer p.Webhook.Wait()
+
+	// An attachment only counts as handled once it has been moved into the destination
+	handle := func(ctx context.Context, path string) error {
+		rec, err := p.ProcessFile(ctx, path)
+		if err != nil {
+			return err
+		}
+		if rec.Status != audit.StatusMoved {
+			return fmt.Errorf("%s: %s", rec.Status, rec.Error)
+		}
+		return nil
+	}
+
+	for {
+		fmt.Printf("[*] Checking %s/%s for attachments (%s)...\n", opts.Addr, cfg.IMAPFolder, strings.Join(opts.Extensions, ", "))
+		res, err := mailbox.Ingest(ctx, opts, handle)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("[!] IMAP ingestion failed: %v", err)
+		}
+		fmt.Printf("[+] Organised %d attachment(s) from %d message(s), %d failed\n", res.Attachments, res.Messages, res.Failed)
+
+		if cfg.IMAPPollInterval <= 0 {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(cfg.IMAPPollInterval):
+		}
+	}
+}
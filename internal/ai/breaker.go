@@ -0,0 +1,147 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// ErrServerDown is returned for requests that waited for the circuit breaker to close
+// until their context ended.
+var ErrServerDown = errors.New("model server is down")
+
+// breaker stops requests to model servers that are unreachable: after threshold
+// consecutive connection failures it opens, requests wait instead of failing, and the
+// servers are probed every interval until one answers again.
+type breaker struct {
+	threshold int
+	interval  time.Duration
+	probe     func(context.Context) error
+	onChange  func(open bool)
+
+	mu       sync.Mutex
+	failures int
+	closed   chan struct{} // nil while closed; closed when an open breaker closes
+}
+
+// SetCircuitBreaker makes the engine stop sending requests after threshold consecutive
+// ones failed to reach a model server, and probe the servers every probeInterval until
+// one answers. Meanwhile requests wait. onChange, when set, is called as the breaker
+// opens and closes, e.g. to hold the pipeline's workers. A threshold of 0 disables it.
+func (e *MLXEngine) SetCircuitBreaker(threshold int, probeInterval time.Duration, onChange func(open bool)) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if threshold <= 0 {
+		e.breaker = nil
+		return
+	}
+	e.breaker = &breaker{
+		threshold: threshold,
+		interval:  probeInterval,
+		onChange:  onChange,
+		probe: func(ctx context.Context) error {
+			_, err := e.Preflight(ctx)
+			return err
+		},
+	}
+}
+
+func (e *MLXEngine) circuit() *breaker {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.breaker
+}
+
+// wait blocks while the breaker is open. It returns ErrServerDown if ctx ends first.
+func (b *breaker) wait(ctx context.Context) error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	closed := b.closed
+	b.mu.Unlock()
+	if closed == nil {
+		return nil
+	}
+	select {
+	case <-closed:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("%w: %w", ErrServerDown, ctx.Err())
+	}
+}
+
+// record counts the outcome of a request: connection failures add up to the threshold,
+// anything else shows a server is reachable and resets the count.
+func (b *breaker) record(err error) {
+	if b == nil {
+		return
+	}
+	if err != nil && isConnectionError(err) {
+		b.fail(err)
+		return
+	}
+	b.mu.Lock()
+	b.failures = 0
+	b.mu.Unlock()
+}
+
+// fail counts a request that could not reach any server, opening the breaker at the threshold.
+func (b *breaker) fail(err error) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	b.failures++
+	trip := b.failures >= b.threshold && b.closed == nil
+	if trip {
+		b.closed = make(chan struct{})
+	}
+	b.mu.Unlock()
+
+	if trip {
+		log.Printf("[!] Model server unreachable for %d requests in a row (%v); holding files until it answers again", b.failures, err)
+		if b.onChange != nil {
+			b.onChange(true)
+		}
+		go b.probeUntilUp()
+	}
+}
+
+// probeUntilUp checks the servers every interval and closes the breaker once one answers.
+func (b *breaker) probeUntilUp() {
+	for {
+		time.Sleep(b.interval)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		err := b.probe(ctx)
+		cancel()
+		if err == nil {
+			break
+		}
+		log.Printf("[*] Model server still down: %v", err)
+	}
+
+	b.mu.Lock()
+	close(b.closed)
+	b.closed, b.failures = nil, 0
+	b.mu.Unlock()
+	log.Printf("[+] Model server is back; resuming")
+	if b.onChange != nil {
+		b.onChange(false)
+	}
+}
+
+// isConnectionError reports whether err means the server could not be reached at all,
+// rather than answering slowly or with an error.
+func isConnectionError(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) ||
+		errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
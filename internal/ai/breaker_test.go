@@ -0,0 +1,83 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestBreaker(t *testing.T) {
+	refused := &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}
+
+	var up atomic.Bool
+	changes := make(chan bool, 2)
+	b := &breaker{
+		threshold: 3,
+		interval:  10 * time.Millisecond,
+		onChange:  func(open bool) { changes <- open },
+		probe: func(context.Context) error {
+			if !up.Load() {
+				return refused
+			}
+			return nil
+		},
+	}
+
+	// Errors from a reachable server reset the count
+	b.record(refused)
+	b.record(refused)
+	b.record(errors.New("500 Internal Server Error"))
+	b.record(refused)
+	if err := b.wait(context.Background()); err != nil {
+		t.Fatalf("breaker opened before the threshold: %v", err)
+	}
+
+	b.record(fmt.Errorf("post: %w", refused))
+	b.record(refused)
+	if open := <-changes; !open {
+		t.Fatal("expected the breaker to open")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	if err := b.wait(ctx); !errors.Is(err, ErrServerDown) {
+		t.Fatalf("wait() on an open breaker = %v, want ErrServerDown", err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- b.wait(context.Background()) }()
+	up.Store(true)
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("wait() after the server came back = %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("breaker did not close after a successful probe")
+	}
+	if open := <-changes; open {
+		t.Fatal("expected the breaker to report closing")
+	}
+}
+
+func TestIsConnectionError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{&net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}, true},
+		{fmt.Errorf("read: %w", syscall.ECONNRESET), true},
+		{context.DeadlineExceeded, false},
+		{errors.New("status code 503"), false},
+	}
+	for _, tt := range tests {
+		if got := isConnectionError(tt.err); got != tt.want {
+			t.Errorf("isConnectionError(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
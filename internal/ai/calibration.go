@@ -0,0 +1,75 @@
+package ai
+
+import (
+	"math"
+	"regexp"
+	"strings"
+)
+
+// choiceLogprobs is the OpenAI-compatible per-token log probability of a response.
+type choiceLogprobs struct {
+	Content []tokenLogprob `json:"content"`
+}
+
+type tokenLogprob struct {
+	Token   string  `json:"token"`
+	Logprob float64 `json:"logprob"`
+}
+
+// SetLogprobs asks the backend for token log probabilities. Where they are returned, the
+// confidence score becomes the probability the model gave the category's tokens, and the
+// self-reported confidence_score is kept in the metadata for comparison.
+func (e *MLXEngine) SetLogprobs(enabled bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.logprobs = enabled
+}
+
+var categoryValue = regexp.MustCompile(`"category"\s*:\s*"([^"]*)"`)
+
+// categoryProbability returns the joint probability of the tokens spelling the category
+// value in the response, or false when the value can't be located in the tokens.
+func categoryProbability(tokens []tokenLogprob, category string) (float64, bool) {
+	var text strings.Builder
+	starts := make([]int, len(tokens))
+	for i, t := range tokens {
+		starts[i] = text.Len()
+		text.WriteString(t.Token)
+	}
+
+	// The accepted object isn't necessarily the first one in the response, and the
+	// name may have been resolved to a full path ("Taxes" -> "Finance/Taxes")
+	for _, m := range categoryValue.FindAllStringSubmatchIndex(text.String(), -1) {
+		start, end := m[2], m[3]
+		value := strings.Trim(strings.TrimSpace(text.String()[start:end]), "/")
+		if value == "" || !(strings.EqualFold(value, category) || hasSuffixFold(category, "/"+value)) {
+			continue
+		}
+		var sum float64
+		for i, t := range tokens {
+			if starts[i] < end && starts[i]+len(t.Token) > start {
+				sum += t.Logprob
+			}
+		}
+		return math.Exp(sum), true
+	}
+	return 0, false
+}
+
+// calibrate replaces the self-reported confidence with the category's token probability
+// when the response carried log probabilities.
+func calibrate(result *AnalysisResult, logprobs *choiceLogprobs) {
+	if logprobs == nil || len(logprobs.Content) == 0 {
+		return
+	}
+	p, ok := categoryProbability(logprobs.Content, result.Category)
+	if !ok {
+		return
+	}
+	result.reportedConfidence = result.ConfidenceScore
+	result.ConfidenceScore = math.Round(p*1000) / 1000
+}
+
+func hasSuffixFold(s, suffix string) bool {
+	return len(s) >= len(suffix) && strings.EqualFold(s[len(s)-len(suffix):], suffix)
+}
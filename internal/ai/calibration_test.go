@@ -0,0 +1,88 @@
+package ai
+
+import (
+	"context"
+	"docs_organiser/internal/config"
+	"math"
+	"testing"
+)
+
+// tokens splits a response into the given pieces, each with probability p.
+func tokens(p float64, pieces ...string) []tokenLogprob {
+	out := make([]tokenLogprob, len(pieces))
+	for i, piece := range pieces {
+		out[i] = tokenLogprob{Token: piece, Logprob: math.Log(p)}
+	}
+	return out
+}
+
+func TestCategoryProbability(t *testing.T) {
+	tests := []struct {
+		name     string
+		tokens   []tokenLogprob
+		category string
+		want     float64
+		wantOK   bool
+	}{
+		{"single token", append(tokens(1, `{"category": "`), append(tokens(0.6, `Finance`), tokens(1, `", "title": "x"}`)...)...), "Finance", 0.6, true},
+		{"multi token", append(append(tokens(1, `{"category":"`), tokens(0.5, `Fin`, `ance`)...), tokens(1, `"}`)...), "Finance", 0.25, true},
+		{"token spans the quote", append(tokens(1, `{"category": "`), tokens(0.8, `Finance",`)...), "Finance", 0.8, true},
+		{"resolved sub-folder", append(append(tokens(1, `{"category": "`), tokens(0.9, `taxes`)...), tokens(1, `"}`)...), "Finance/Taxes", 0.9, true},
+		{"skips other objects", append(tokens(0.1, `{"category": "Work"} `), append(tokens(1, `{"category": "`), append(tokens(0.7, `Finance`), tokens(1, `"}`)...)...)...), "Finance", 0.7, true},
+		{"category not in tokens", tokens(1, `{"title": "x"}`), "Finance", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := categoryProbability(tt.tokens, tt.category)
+			if ok != tt.wantOK || math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("categoryProbability = %v, %v; want %v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestCategorize_Logprobs(t *testing.T) {
+	tokenizer := testTokenizer(t)
+	content := `{"category": "Finance", "title": "Invoice", "confidence_score": 0.95}`
+	respond := func(logprobs *choiceLogprobs) *MockLLMClient {
+		return &MockLLMClient{Responses: []*chatResponse{{Choices: []choice{{
+			Message:  message{Content: content},
+			Logprobs: logprobs,
+		}}}}}
+	}
+
+	tests := []struct {
+		name           string
+		mock           *MockLLMClient
+		wantConfidence float64
+		wantReported   float64
+	}{
+		{"calibrated", respond(&choiceLogprobs{Content: append(tokens(1, `{"category": "`), append(tokens(0.42, "Finance"), tokens(1, `", "title": "Invoice", "confidence_score": 0.95}`)...)...)}), 0.42, 0.95},
+		{"backend without logprobs", respond(nil), 0.95, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := withRouter(&MLXEngine{
+				llm:             tt.mock,
+				models:          []config.ModelDefinition{{Name: "test-model", URL: "http://mock-api.com/v1"}},
+				ctxMgr:          NewContextManager(tokenizer, 4096),
+				validCategories: []string{"Finance", "Misc"},
+			})
+			engine.SetLogprobs(true)
+
+			result, err := engine.Categorize(context.Background(), "invoice")
+			if err != nil {
+				t.Fatalf("Categorize: %v", err)
+			}
+			if !tt.mock.Requests[0].Logprobs {
+				t.Error("logprobs were not requested")
+			}
+			if result.Analysis.ConfidenceScore != tt.wantConfidence {
+				t.Errorf("ConfidenceScore = %v, want %v", result.Analysis.ConfidenceScore, tt.wantConfidence)
+			}
+			if result.Metadata.ReportedConfidence != tt.wantReported {
+				t.Errorf("ReportedConfidence = %v, want %v", result.Metadata.ReportedConfidence, tt.wantReported)
+			}
+		})
+	}
+}
@@ -0,0 +1,168 @@
+package ai
+
+import (
+	"context"
+	"docs_organiser/internal/taxonomy"
+	"fmt"
+	"slices"
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// SetCategoryDescriptions adds descriptions for categories by path, matched
+// case-insensitively. Taxonomy descriptions take precedence where both exist.
+func (e *MLXEngine) SetCategoryDescriptions(descriptions map[string]string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.descriptions = make(map[string]string, len(descriptions))
+	for path, d := range descriptions {
+		if d = strings.TrimSpace(d); d != "" {
+			e.descriptions[strings.ToLower(strings.Trim(path, "/"))] = d
+		}
+	}
+}
+
+// SetCategoryAliases maps names the model may answer with, such as "Bills", to the
+// categories they stand for, such as "Finance", so near misses are filed there instead
+// of failing validation. Names match ignoring case; an alias applies only when its
+// category is one of the valid categories.
+func (e *MLXEngine) SetCategoryAliases(aliases map[string]string) error {
+	m := make(map[string]string, len(aliases))
+	for alias, category := range aliases {
+		key := aliasKey(alias)
+		category = strings.Trim(strings.TrimSpace(category), "/")
+		if key == "" || category == "" {
+			return fmt.Errorf("invalid category alias %q -> %q", alias, category)
+		}
+		m[key] = category
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.aliases = m
+	return nil
+}
+
+// resolveAlias returns the category of categories that name is an alias of.
+func (e *MLXEngine) resolveAlias(name string, categories []string) (string, bool) {
+	e.mu.RLock()
+	category, ok := e.aliases[aliasKey(name)]
+	e.mu.RUnlock()
+	if !ok {
+		return "", false
+	}
+	i := slices.IndexFunc(categories, func(c string) bool {
+		return strings.EqualFold(norm.NFC.String(c), norm.NFC.String(category))
+	})
+	if i < 0 {
+		return "", false
+	}
+	return categories[i], true
+}
+
+// aliasKey normalizes an alias for lookup.
+func aliasKey(name string) string {
+	return strings.ToLower(norm.NFC.String(strings.Trim(strings.TrimSpace(name), "/")))
+}
+
+// categoryGuide describes categories for the system prompt, one line each, indented by
+// depth, keeping whole lines while they fit in budget tokens. Categories without a
+// description or examples are left out. dropped counts lines that didn't fit.
+func (e *MLXEngine) categoryGuide(categories []string, budget int) (guide string, dropped int) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	var entries []taxonomy.Entry
+	if e.taxonomy != nil {
+		for _, entry := range e.taxonomy.Entries() {
+			if slices.Contains(categories, entry.Path) {
+				entries = append(entries, entry)
+			}
+		}
+	} else {
+		for _, c := range categories {
+			entries = append(entries, taxonomy.Entry{Path: c, Depth: strings.Count(c, "/")})
+		}
+	}
+
+	const header = "\nCategory guide:"
+	used := e.ctxMgr.tokenizer.CountTokens(header)
+	var b strings.Builder
+	for _, entry := range entries {
+		if entry.Description == "" {
+			entry.Description = e.descriptions[strings.ToLower(entry.Path)]
+		}
+		if entry.Description == "" && len(entry.Examples) == 0 {
+			continue
+		}
+		line := "\n" + strings.Repeat("  ", entry.Depth) + "- " + entry.Path
+		if entry.Description != "" {
+			line += ": " + entry.Description
+		}
+		if len(entry.Examples) > 0 {
+			line += " (e.g. " + strings.Join(entry.Examples, ", ") + ")"
+		}
+		if n := e.ctxMgr.tokenizer.CountTokens(line); used+n <= budget {
+			used += n
+			b.WriteString(line)
+		} else {
+			dropped++
+		}
+	}
+	if b.Len() == 0 {
+		return "", dropped
+	}
+	return header + b.String(), dropped
+}
+
+// SetTwoStage enables coarse-to-fine classification for nested categories: the model
+// first picks a top-level folder, then a second prompt offers only that folder's subtree.
+func (e *MLXEngine) SetTwoStage(enabled bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.twoStage = enabled
+}
+
+// topLevel returns the distinct first path segments of categories, in order.
+func topLevel(categories []string) []string {
+	var top []string
+	for _, c := range categories {
+		first, _, _ := strings.Cut(c, "/")
+		if !slices.Contains(top, first) {
+			top = append(top, first)
+		}
+	}
+	return top
+}
+
+// subtree returns the categories at or below folder.
+func subtree(categories []string, folder string) []string {
+	var out []string
+	for _, c := range categories {
+		if c == folder || strings.HasPrefix(c, folder+"/") {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// classifyTwoStage picks a top-level folder first and then a category within it, so each
+// prompt lists far fewer choices. When the second pass fails, the first pass's folder
+// is kept if it is a category itself.
+func (e *MLXEngine) classifyTwoStage(ctx context.Context, modelName string, categories []string, userPrompt string, temperature float64, metadata *CategorizationMetadata) (*AnalysisResult, error) {
+	coarse, err := e.classify(ctx, modelName, topLevel(categories), userPrompt, temperature, metadata)
+	if err != nil {
+		return nil, err
+	}
+	metadata.CoarseCategory = coarse.Category
+
+	fine := subtree(categories, coarse.Category)
+	if len(fine) == 1 && fine[0] == coarse.Category {
+		return coarse, nil
+	}
+	result, err := e.classify(ctx, modelName, fine, userPrompt, temperature, metadata)
+	if err != nil && slices.Contains(categories, coarse.Category) {
+		return coarse, nil
+	}
+	return result, err
+}
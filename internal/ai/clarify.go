@@ -0,0 +1,139 @@
+package ai
+
+import (
+	"context"
+	"docs_organiser/internal/logging"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// Clarification records the follow-up prompt that chose between the two most likely
+// categories of a document classified with middling confidence.
+type Clarification struct {
+	// Candidates are the first answer and the runner-up, in that order.
+	Candidates []string `json:"candidates"`
+	Category   string   `json:"category"`
+	Reasoning  string   `json:"reasoning"`
+}
+
+// SetClarification enables the clarification pass for answers with a confidence score of
+// at least min and below max. max <= 0 disables it.
+func (e *MLXEngine) SetClarification(min, max float64) error {
+	if max > 0 && (min < 0 || min >= max || max > 1) {
+		return fmt.Errorf("clarify_min_confidence (%v) must be at least 0 and below clarify_max_confidence (%v), which must not exceed 1", min, max)
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.clarifyMin, e.clarifyMax = min, max
+	return nil
+}
+
+// clarify asks the model to choose between result's category and the runner-up when the
+// confidence falls in the configured gray zone. The runner-up is the second most voted
+// category, or else the model's answer among the remaining categories. Failures keep
+// result, which is also returned when the first answer is confirmed.
+func (e *MLXEngine) clarify(ctx context.Context, modelName string, categories []string, userPrompt string, temperature float64, result *AnalysisResult, metadata *CategorizationMetadata) *AnalysisResult {
+	e.mu.RLock()
+	lo, hi := e.clarifyMin, e.clarifyMax
+	e.mu.RUnlock()
+	if hi <= 0 || result.ConfidenceScore < lo || result.ConfidenceScore >= hi {
+		return result
+	}
+
+	var alternative *AnalysisResult
+	runnerUp := runnerUpVote(metadata.Votes, result.Category)
+	if runnerUp == "" {
+		others := slices.DeleteFunc(slices.Clone(categories), func(c string) bool { return c == result.Category })
+		if len(others) == 0 {
+			return result
+		}
+		alt, err := e.classify(ctx, modelName, others, userPrompt, temperature, metadata)
+		if err != nil {
+			logging.Verbosef("[*] No runner-up to clarify %s: %v", result.Category, err)
+			return result
+		}
+		alternative, runnerUp = alt, alt.Category
+	}
+
+	candidates := []string{result.Category, runnerUp}
+	category, reasoning, err := e.choose(ctx, modelName, candidates, userPrompt, temperature, metadata)
+	if err != nil {
+		logging.Verbosef("[*] Clarification between %s and %s failed: %v", candidates[0], candidates[1], err)
+		return result
+	}
+	metadata.Clarification = &Clarification{Candidates: candidates, Category: category, Reasoning: reasoning}
+	logging.Verbosef("[*] Clarified %s or %s as %s: %s", candidates[0], candidates[1], category, reasoning)
+	if category == result.Category {
+		return result
+	}
+	if alternative != nil {
+		return alternative
+	}
+	changed := *result
+	changed.Category = category
+	return &changed
+}
+
+// runnerUpVote returns the category with the most votes other than winner (the first in
+// sort order on ties), or "" when no other category got a vote.
+func runnerUpVote(votes map[string]int, winner string) string {
+	var runnerUp string
+	for _, c := range slices.Sorted(maps.Keys(votes)) {
+		if c != winner && (runnerUp == "" || votes[c] > votes[runnerUp]) {
+			runnerUp = c
+		}
+	}
+	return runnerUp
+}
+
+// choose sends the clarification prompt and returns the chosen one of candidates and the
+// model's justification.
+func (e *MLXEngine) choose(ctx context.Context, modelName string, candidates []string, userPrompt string, temperature float64, metadata *CategorizationMetadata) (string, string, error) {
+	systemPrompt := fmt.Sprintf(`You are an intelligent file organization assistant. The document could belong to either of two categories: %q or %q.
+Decide which one fits the document better and justify the choice in one or two sentences.
+Return a SINGLE JSON object: {"category": "one of the two categories", "reasoning": "why it fits better than the other"}
+Do NOT return markdown. Do NOT return extra text.`, candidates[0], candidates[1])
+
+	e.mu.RLock()
+	sampling := e.sampling
+	e.mu.RUnlock()
+	metadata.Attempts++
+	resp, err := e.chat(ctx, chatRequest{
+		Model:       modelName,
+		Messages:    []message{{Role: "system", Content: systemPrompt}, {Role: "user", Content: userPrompt}},
+		Temperature: temperature,
+		TopP:        sampling.TopP,
+		MaxTokens:   e.maxTokens(),
+		Stop:        sampling.Stop,
+	})
+	if err != nil {
+		return "", "", err
+	}
+	if len(resp.Choices) == 0 {
+		return "", "", fmt.Errorf("empty response from model")
+	}
+	metadata.PromptTokens += resp.Usage.PromptTokens
+	metadata.ResponseTokens += resp.Usage.CompletionTokens
+	metadata.TotalTokens += resp.Usage.TotalTokens
+
+	content := resp.Choices[0].Message.Content
+	for _, object := range extractJSONObjects(cleanJSON(content)) {
+		var answer struct {
+			Category  string `json:"category"`
+			Reasoning string `json:"reasoning"`
+		}
+		if json.Unmarshal([]byte(object), &answer) != nil {
+			continue
+		}
+		for _, c := range candidates {
+			if fuzzyKey(c) == fuzzyKey(answer.Category) {
+				return c, strings.TrimSpace(answer.Reasoning), nil
+			}
+		}
+	}
+	metadata.FailedResponses = append(metadata.FailedResponses, truncateForLog(content, maxDebugResponseBytes))
+	return "", "", fmt.Errorf("the answer names neither category: %s", truncateForLog(content, maxDebugResponseBytes))
+}
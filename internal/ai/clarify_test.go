@@ -0,0 +1,124 @@
+package ai
+
+import (
+	"context"
+	"docs_organiser/internal/config"
+	"strings"
+	"testing"
+)
+
+func TestCategorize_Clarification(t *testing.T) {
+	tokenizer := testTokenizer(t)
+	reply := func(content string) *chatResponse {
+		return &chatResponse{Choices: []choice{{Message: message{Content: content}}}}
+	}
+	answer := func(category, title string, confidence string) *chatResponse {
+		return reply(`{"category": "` + category + `", "title": "` + title + `", "confidence_score": ` + confidence + `}`)
+	}
+
+	tests := []struct {
+		name           string
+		votes          int
+		responses      []*chatResponse
+		wantCategory   string
+		wantTitle      string
+		wantCandidates string // "" when no clarification is recorded
+		wantRequests   int
+	}{
+		{
+			name:         "confident answer",
+			responses:    []*chatResponse{answer("Work", "Memo", "0.9")},
+			wantCategory: "Work", wantTitle: "Memo", wantRequests: 1,
+		},
+		{
+			name: "runner-up chosen",
+			responses: []*chatResponse{
+				answer("Work", "Memo", "0.5"),
+				answer("Finance", "Consulting_Invoice", "0.4"),
+				reply(`{"category": "finance", "reasoning": "It asks for payment."}`),
+			},
+			wantCategory: "Finance", wantTitle: "Consulting_Invoice", wantCandidates: "Work,Finance", wantRequests: 3,
+		},
+		{
+			name: "first answer confirmed",
+			responses: []*chatResponse{
+				answer("Work", "Memo", "0.5"),
+				answer("Finance", "Consulting_Invoice", "0.4"),
+				reply(`Sure! {"category": "Work", "reasoning": "It is an internal memo."}`),
+			},
+			wantCategory: "Work", wantTitle: "Memo", wantCandidates: "Work,Finance", wantRequests: 3,
+		},
+		{
+			name: "unusable choice",
+			responses: []*chatResponse{
+				answer("Work", "Memo", "0.5"),
+				answer("Finance", "Consulting_Invoice", "0.4"),
+				reply(`{"category": "Misc", "reasoning": "Neither."}`),
+			},
+			wantCategory: "Work", wantTitle: "Memo", wantRequests: 3,
+		},
+		{
+			name:  "runner-up from votes",
+			votes: 3,
+			responses: []*chatResponse{
+				answer("Work", "Memo", "0.5"),
+				answer("Finance", "Invoice", "0.5"),
+				answer("Work", "Memo_2", "0.5"),
+				reply(`{"category": "Finance", "reasoning": "It asks for payment."}`),
+			},
+			wantCategory: "Finance", wantTitle: "Memo", wantCandidates: "Work,Finance", wantRequests: 4,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := &MockLLMClient{Responses: tt.responses}
+			engine := withRouter(&MLXEngine{
+				llm:             mock,
+				models:          []config.ModelDefinition{{Name: "test-model", URL: "http://mock-api.com/v1"}},
+				ctxMgr:          NewContextManager(tokenizer, 4096),
+				validCategories: []string{"Finance", "Work", "Misc"},
+				sampling:        defaultSampling,
+			})
+			engine.SetVoting(tt.votes, 0.7)
+			if err := engine.SetClarification(0.3, 0.7); err != nil {
+				t.Fatal(err)
+			}
+
+			result, err := engine.Categorize(context.Background(), "invoice for consulting work")
+			if err != nil {
+				t.Fatalf("Categorize: %v", err)
+			}
+			if result.Analysis.Category != tt.wantCategory || result.Analysis.Title != tt.wantTitle {
+				t.Errorf("got %s/%s, want %s/%s", result.Analysis.Category, result.Analysis.Title, tt.wantCategory, tt.wantTitle)
+			}
+			if len(mock.Requests) != tt.wantRequests {
+				t.Errorf("%d requests, want %d", len(mock.Requests), tt.wantRequests)
+			}
+			c := result.Metadata.Clarification
+			switch {
+			case tt.wantCandidates == "" && c != nil:
+				t.Errorf("unexpected clarification %+v", c)
+			case tt.wantCandidates != "" && c == nil:
+				t.Error("clarification was not recorded")
+			case c != nil:
+				if got := strings.Join(c.Candidates, ","); got != tt.wantCandidates || c.Category != tt.wantCategory || c.Reasoning == "" {
+					t.Errorf("clarification = %+v, want candidates %s and category %s with reasoning", c, tt.wantCandidates, tt.wantCategory)
+				}
+			}
+		})
+	}
+}
+
+func TestSetClarification(t *testing.T) {
+	engine := &MLXEngine{}
+	for _, r := range [][2]float64{{0, 0}, {0.3, 0.7}, {0, 1}, {0.9, 0}} {
+		if err := engine.SetClarification(r[0], r[1]); err != nil {
+			t.Errorf("SetClarification(%v, %v): %v", r[0], r[1], err)
+		}
+	}
+	for _, r := range [][2]float64{{0.7, 0.3}, {0.5, 0.5}, {-0.1, 0.5}, {0.3, 1.5}} {
+		if err := engine.SetClarification(r[0], r[1]); err == nil {
+			t.Errorf("SetClarification(%v, %v) should fail", r[0], r[1])
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// defaultMapWorkers is how many chunks are summarized at once when requests are unbounded.
+const defaultMapWorkers = 4
+
+// SetMaxConcurrentRequests bounds the requests in flight to the model servers across
+// all pipeline workers and summarization; n <= 0 removes the bound.
+func (e *MLXEngine) SetMaxConcurrentRequests(n int) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if n > 0 {
+		e.requests = make(chan struct{}, n)
+	} else {
+		e.requests = nil
+	}
+}
+
+// chat sends req once a request slot is free, to the server of its model unless it
+// names one.
+func (e *MLXEngine) chat(ctx context.Context, req chatRequest) (*chatResponse, error) {
+	if req.APIURL == "" {
+		req.APIURL = e.GetURLForModel(req.Model)
+	}
+	e.mu.RLock()
+	slots := e.requests
+	debug := e.debugLLM
+	b := e.breaker
+	e.mu.RUnlock()
+	if err := b.wait(ctx); err != nil {
+		return nil, err
+	}
+	if slots != nil {
+		select {
+		case slots <- struct{}{}:
+			defer func() { <-slots }()
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	var n int64
+	if debug {
+		n = logRequest(req)
+	}
+	start := time.Now()
+	resp, err := e.llm.CreateChatCompletion(ctx, req)
+	if debug {
+		logResponse(n, resp, err, time.Since(start))
+	}
+	b.record(err)
+	return resp, err
+}
+
+// mapChunks summarizes chunks with a bounded pool of workers, at most the request limit,
+// and returns the summaries in chunk order. The first failure cancels the remaining chunks.
+func (e *MLXEngine) mapChunks(ctx context.Context, chunks []string, modelName, apiURL string) ([]string, error) {
+	e.mu.RLock()
+	workers := cap(e.requests)
+	e.mu.RUnlock()
+	if workers == 0 {
+		workers = defaultMapWorkers
+	}
+	workers = min(workers, len(chunks))
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	summaries := make([]string, len(chunks))
+	var (
+		wg       sync.WaitGroup
+		failOnce sync.Once
+		mapErr   error
+	)
+	next := make(chan int)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				summary, err := e.summarizeChunk(ctx, chunks[i], i+1, len(chunks), modelName, apiURL)
+				if err != nil {
+					failOnce.Do(func() {
+						mapErr = fmt.Errorf("failed to summarize chunk %d/%d: %w", i+1, len(chunks), err)
+						cancel()
+					})
+					continue
+				}
+				summaries[i] = summary
+			}
+		}()
+	}
+
+feed:
+	for i := range chunks {
+		select {
+		case next <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(next)
+	wg.Wait()
+
+	if mapErr != nil {
+		return nil, mapErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return summaries, nil
+}
@@ -0,0 +1,130 @@
+package ai
+
+import (
+	"context"
+	"docs_organiser/internal/config"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// echoClient answers each summarization request with the part number it was asked
+// about, after a short delay, and tracks how many requests overlap.
+type echoClient struct {
+	inFlight, peak atomic.Int32
+	failPart       string
+}
+
+var partNumber = regexp.MustCompile(`document part \((\d+)/\d+\)`)
+
+func (c *echoClient) CreateChatCompletion(ctx context.Context, req chatRequest) (*chatResponse, error) {
+	n := c.inFlight.Add(1)
+	defer c.inFlight.Add(-1)
+	for {
+		peak := c.peak.Load()
+		if n <= peak || c.peak.CompareAndSwap(peak, n) {
+			break
+		}
+	}
+	select {
+	case <-time.After(20 * time.Millisecond):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	part := partNumber.FindStringSubmatch(req.Messages[1].Content)[1]
+	if part == c.failPart {
+		return nil, fmt.Errorf("server error")
+	}
+	return &chatResponse{Choices: []choice{{Message: message{Content: "part " + part}}}}, nil
+}
+
+func (c *echoClient) Endpoint() string { return "" }
+
+func TestMapChunks(t *testing.T) {
+	tokenizer := testTokenizer(t)
+	chunks := make([]string, 10)
+	want := make([]string, 10)
+	for i := range chunks {
+		chunks[i] = fmt.Sprintf("chunk %d", i+1)
+		want[i] = fmt.Sprintf("part %d", i+1)
+	}
+
+	tests := []struct {
+		name     string
+		limit    int
+		wantPeak int32
+	}{
+		{"default pool", 0, defaultMapWorkers},
+		{"request limit", 2, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &echoClient{}
+			engine := &MLXEngine{llm: client, ctxMgr: NewContextManager(tokenizer, 4096)}
+			engine.SetMaxConcurrentRequests(tt.limit)
+
+			got, err := engine.mapChunks(context.Background(), chunks, "test-model", "")
+			if err != nil {
+				t.Fatalf("mapChunks: %v", err)
+			}
+			if strings.Join(got, ",") != strings.Join(want, ",") {
+				t.Errorf("summaries = %v, want %v", got, want)
+			}
+			if peak := client.peak.Load(); peak != tt.wantPeak {
+				t.Errorf("peak concurrency = %d, want %d", peak, tt.wantPeak)
+			}
+		})
+	}
+
+	t.Run("failure", func(t *testing.T) {
+		engine := &MLXEngine{llm: &echoClient{failPart: "3"}, ctxMgr: NewContextManager(tokenizer, 4096)}
+		if _, err := engine.mapChunks(context.Background(), chunks, "test-model", ""); err == nil || !strings.Contains(err.Error(), "chunk 3/10") {
+			t.Errorf("mapChunks error = %v, want chunk 3/10 to fail", err)
+		}
+	})
+}
+
+func TestChat_ServerPerModel(t *testing.T) {
+	var misrouted atomic.Int32
+	server := func(model string) *httptest.Server {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var req chatRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Model != model {
+				misrouted.Add(1)
+			}
+			json.NewEncoder(w).Encode(chatResponse{Choices: []choice{{Message: message{Content: "OK"}}}})
+		}))
+		t.Cleanup(srv.Close)
+		return srv
+	}
+	a, b := server("a"), server("b")
+	engine := &MLXEngine{
+		llm:    &NetLLMClient{client: http.DefaultClient},
+		models: []config.ModelDefinition{{Name: "a", URL: a.URL + "/v1"}, {Name: "b", URL: b.URL + "/v1"}},
+	}
+
+	// Requests for both models in flight at once each reach their own server
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		for _, model := range []string{"a", "b"} {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if _, err := engine.WarmUp(context.Background(), model); err != nil {
+					t.Errorf("WarmUp(%s): %v", model, err)
+				}
+			}()
+		}
+	}
+	wg.Wait()
+	if n := misrouted.Load(); n > 0 {
+		t.Errorf("%d requests reached the other model's server", n)
+	}
+}
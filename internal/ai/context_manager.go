@@ -1,5 +1,11 @@
 package ai
 
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
 // TruncationStrategy defines how to shorten text to fit within a token budget.
 type TruncationStrategy string
 
@@ -7,6 +13,7 @@ const (
 	StrategySlidingWindow    TruncationStrategy = "sliding_window"
 	StrategyMiddleExtraction TruncationStrategy = "middle_extraction"
 	StrategyMapReduce        TruncationStrategy = "map_reduce" // Placeholder for complex summarization
+	StrategySalience         TruncationStrategy = "salience"   // Paragraphs ranked by category keywords (see TruncateSalient)
 )
 
 // ContextManager handles token budgeting and truncation logic.
@@ -19,6 +26,9 @@ type ContextManager struct {
 	examplesBudgetPct float64
 	contentBudgetPct  float64
 	outputBudgetPct   float64
+
+	// chunkOverlap is how many tokens of whole sentences each chunk repeats from the previous one
+	chunkOverlap int
 }
 
 // NewContextManager creates a new ContextManager with default budget allocation.
@@ -101,20 +111,103 @@ func (cm *ContextManager) IsExceedingHardLimit(text string) bool {
 	return cm.tokenizer.CountTokens(text) > cm.maxTokens
 }
 
-// Chunk splits text into slices that each fit within chunkSize tokens.
+// SetChunkOverlap makes each chunk start with up to tokens worth of whole sentences from
+// the end of the previous chunk, so context isn't lost at the seams.
+func (cm *ContextManager) SetChunkOverlap(tokens int) {
+	cm.chunkOverlap = max(tokens, 0)
+}
+
+// chunkPiece is a sentence or line of a document, or a token slice of one too long for a chunk.
+type chunkPiece struct {
+	text      string
+	tokens    int
+	paragraph bool // ends a paragraph
+}
+
+// Chunk splits text into slices that each fit within chunkSize tokens. Chunks end on
+// sentence boundaries, preferring paragraph breaks, and only sentences longer than a
+// chunk are cut at raw token offsets. Consecutive chunks overlap by whole sentences
+// (see SetChunkOverlap), at most half a chunk.
 func (cm *ContextManager) Chunk(text string, chunkSize int) []string {
-	tokens := cm.tokenizer.encoding.Encode(text, nil, nil)
-	if len(tokens) <= chunkSize {
+	if cm.tokenizer.CountTokens(text) <= chunkSize {
 		return []string{text}
 	}
 
+	var pieces []chunkPiece
+	for _, unit := range splitSentences(text) {
+		tokens := cm.tokenizer.encoding.Encode(unit, nil, nil)
+		paragraph := strings.Count(unit[len(strings.TrimRightFunc(unit, unicode.IsSpace)):], "\n") >= 2
+		if len(tokens) <= chunkSize {
+			pieces = append(pieces, chunkPiece{unit, len(tokens), paragraph})
+			continue
+		}
+		for i := 0; i < len(tokens); i += chunkSize {
+			end := min(i+chunkSize, len(tokens))
+			pieces = append(pieces, chunkPiece{cm.tokenizer.encoding.Decode(tokens[i:end]), end - i, paragraph && end == len(tokens)})
+		}
+	}
+
+	overlap := min(cm.chunkOverlap, chunkSize/2)
 	var chunks []string
-	for i := 0; i < len(tokens); i += chunkSize {
-		end := i + chunkSize
-		if end > len(tokens) {
-			end = len(tokens)
+	for start := 0; start < len(pieces); {
+		end, used, breakEnd, breakUsed := start, 0, -1, 0
+		for end < len(pieces) && used+pieces[end].tokens <= chunkSize {
+			used += pieces[end].tokens
+			end++
+			if pieces[end-1].paragraph {
+				breakEnd, breakUsed = end, used
+			}
 		}
-		chunks = append(chunks, cm.tokenizer.encoding.Decode(tokens[i:end]))
+		// End at the last paragraph break instead when that still fills half the chunk
+		if end < len(pieces) && breakEnd > start && breakUsed >= chunkSize/2 {
+			end = breakEnd
+		}
+
+		var b strings.Builder
+		for _, p := range pieces[start:end] {
+			b.WriteString(p.text)
+		}
+		chunks = append(chunks, b.String())
+		if end == len(pieces) {
+			break
+		}
+
+		// Step back over whole pieces for the overlap, always moving forward
+		next, carried := end, 0
+		for next-1 > start && carried+pieces[next-1].tokens <= overlap {
+			next--
+			carried += pieces[next].tokens
+		}
+		start = next
 	}
 	return chunks
 }
+
+// splitSentences splits text after sentence-ending punctuation and line breaks. Each
+// part keeps its trailing whitespace, so the parts join back into text.
+func splitSentences(text string) []string {
+	var parts []string
+	start := 0
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		i += size
+		next, _ := utf8.DecodeRuneInString(text[i:])
+		if r != '\n' && !strings.ContainsRune("。！？", r) &&
+			!(strings.ContainsRune(".!?", r) && i < len(text) && unicode.IsSpace(next)) {
+			continue
+		}
+		for i < len(text) {
+			r, size := utf8.DecodeRuneInString(text[i:])
+			if !unicode.IsSpace(r) {
+				break
+			}
+			i += size
+		}
+		parts = append(parts, text[start:i])
+		start = i
+	}
+	if start < len(text) {
+		parts = append(parts, text[start:])
+	}
+	return parts
+}
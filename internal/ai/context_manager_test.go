@@ -43,7 +43,7 @@ func TestContextManager_Truncate(t *testing.T) {
 }
 
 func TestContextManager_Budgets(t *testing.T) {
-	tokenizer, _ := NewTokenizer("cl100k_base")
+	tokenizer := testTokenizer(t)
 	cm := NewContextManager(tokenizer, 1000)
 
 	s, e, c, o := cm.GetBudgets()
@@ -61,3 +61,70 @@ func TestContextManager_Budgets(t *testing.T) {
 		t.Errorf("expected output budget 100, got %d", o)
 	}
 }
+
+func TestSplitSentences(t *testing.T) {
+	text := "First sentence. Second one!  Third?\nA line\n\nNew paragraph 3.5 stays. 終わり。次"
+	want := []string{"First sentence. ", "Second one!  ", "Third?\n", "A line\n\n", "New paragraph 3.5 stays. ", "終わり。", "次"}
+	got := splitSentences(text)
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("splitSentences = %q, want %q", got, want)
+	}
+}
+
+func TestContextManager_Chunk(t *testing.T) {
+	tokenizer := testTokenizer(t)
+	cm := NewContextManager(tokenizer, 1000)
+
+	var paragraphs []string
+	for p := 0; p < 6; p++ {
+		var sentences []string
+		for s := 0; s < 5; s++ {
+			sentences = append(sentences, "Paragraph "+string(rune('A'+p))+" sentence "+string(rune('a'+s))+" talks about invoices and taxes.")
+		}
+		paragraphs = append(paragraphs, strings.Join(sentences, " "))
+	}
+	text := strings.Join(paragraphs, "\n\n")
+
+	t.Run("boundaries", func(t *testing.T) {
+		chunks := cm.Chunk(text, 60)
+		if len(chunks) < 2 {
+			t.Fatalf("expected several chunks, got %d", len(chunks))
+		}
+		if strings.Join(chunks, "") != text {
+			t.Error("chunks without overlap should join back into the text")
+		}
+		for i, c := range chunks {
+			if n := tokenizer.CountTokens(c); n > 60+2 {
+				t.Errorf("chunk %d has %d tokens, limit 60", i, n)
+			}
+			if !strings.HasSuffix(strings.TrimSpace(c), ".") {
+				t.Errorf("chunk %d ends mid-sentence: %q", i, c)
+			}
+		}
+	})
+
+	t.Run("overlap", func(t *testing.T) {
+		cm.SetChunkOverlap(15)
+		defer cm.SetChunkOverlap(0)
+		chunks := cm.Chunk(text, 60)
+		for i := 1; i < len(chunks); i++ {
+			prev := splitSentences(chunks[i-1])
+			if last := prev[len(prev)-1]; !strings.HasPrefix(chunks[i], last) {
+				t.Errorf("chunk %d does not repeat the previous chunk's last sentence %q", i, last)
+			}
+		}
+	})
+
+	t.Run("long sentence", func(t *testing.T) {
+		long := strings.Repeat("word ", 300)
+		chunks := cm.Chunk(long, 50)
+		if strings.Join(chunks, "") != long {
+			t.Error("raw token chunks should join back into the text")
+		}
+		for i, c := range chunks {
+			if n := tokenizer.CountTokens(c); n > 50 {
+				t.Errorf("chunk %d has %d tokens, limit 50", i, n)
+			}
+		}
+	})
+}
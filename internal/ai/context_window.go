@@ -0,0 +1,129 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// numCtxPattern finds "num_ctx <n>" in Ollama's Modelfile parameter listing.
+var numCtxPattern = regexp.MustCompile(`(?m)^\s*num_ctx\s+(\d+)`)
+
+// DetectContextWindow asks the server behind apiURL for the runtime context length of model.
+// It understands vLLM (max_model_len in /v1/models), llama.cpp (/props n_ctx), and Ollama
+// (num_ctx from /api/show). It returns the size and the backend it came from.
+func (e *MLXEngine) DetectContextWindow(ctx context.Context, model, apiURL string) (int, string, error) {
+	base := strings.TrimRight(apiURL, "/")
+	root := strings.TrimSuffix(base, "/v1")
+
+	probes := []struct {
+		source string
+		probe  func() (int, error)
+	}{
+		{"vllm", func() (int, error) { return e.probeVLLM(ctx, base, model) }},
+		{"llama.cpp", func() (int, error) { return e.probeLlamaCpp(ctx, root) }},
+		{"ollama", func() (int, error) { return e.probeOllama(ctx, root, model) }},
+	}
+
+	for _, p := range probes {
+		if n, err := p.probe(); err == nil && n > 0 {
+			return n, p.source, nil
+		}
+	}
+	return 0, "", fmt.Errorf("server at %s does not report a context window for %s", apiURL, model)
+}
+
+// AutoSizeContext adopts the context window reported by the server for model, falling back
+// to the configured size when the backend doesn't expose one. It returns the window in effect.
+func (e *MLXEngine) AutoSizeContext(ctx context.Context, model string) int {
+	n, source, err := e.DetectContextWindow(ctx, model, e.GetURLForModel(model))
+	if err != nil {
+		e.SetContextWindow(e.configuredContext)
+		return e.ContextWindow()
+	}
+	log.Printf("[*] Context window for %s detected from %s: %d tokens", model, source, n)
+	e.SetContextWindow(n)
+	return n
+}
+
+// SetContextWindow changes the token budget used for prompts. Call only between runs.
+func (e *MLXEngine) SetContextWindow(tokens int) {
+	if tokens > 0 {
+		e.ctxMgr.maxTokens = tokens
+	}
+}
+
+func (e *MLXEngine) probeVLLM(ctx context.Context, base, model string) (int, error) {
+	var data struct {
+		Data []struct {
+			ID          string `json:"id"`
+			MaxModelLen int    `json:"max_model_len"`
+		} `json:"data"`
+	}
+	if err := e.getJSON(ctx, "GET", base+"/models", nil, &data); err != nil {
+		return 0, err
+	}
+	for _, m := range data.Data {
+		if m.ID == model {
+			return m.MaxModelLen, nil
+		}
+	}
+	return 0, fmt.Errorf("model not listed")
+}
+
+func (e *MLXEngine) probeLlamaCpp(ctx context.Context, root string) (int, error) {
+	var props struct {
+		DefaultGenerationSettings struct {
+			NCtx int `json:"n_ctx"`
+		} `json:"default_generation_settings"`
+	}
+	if err := e.getJSON(ctx, "GET", root+"/props", nil, &props); err != nil {
+		return 0, err
+	}
+	return props.DefaultGenerationSettings.NCtx, nil
+}
+
+// probeOllama only trusts an explicit num_ctx: model_info's context_length is the trained
+// maximum, while Ollama serves requests with a much smaller default window.
+func (e *MLXEngine) probeOllama(ctx context.Context, root, model string) (int, error) {
+	var show struct {
+		Parameters string `json:"parameters"`
+	}
+	body, _ := json.Marshal(map[string]string{"model": model})
+	if err := e.getJSON(ctx, "POST", root+"/api/show", body, &show); err != nil {
+		return 0, err
+	}
+	m := numCtxPattern.FindStringSubmatch(show.Parameters)
+	if m == nil {
+		return 0, fmt.Errorf("num_ctx not set")
+	}
+	return strconv.Atoi(m[1])
+}
+
+func (e *MLXEngine) getJSON(ctx context.Context, method, url string, body []byte, target interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := e.httpClient(5 * time.Second).Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("status %d from %s", resp.StatusCode, url)
+	}
+	return json.NewDecoder(resp.Body).Decode(target)
+}
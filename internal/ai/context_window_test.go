@@ -0,0 +1,97 @@
+package ai
+
+import (
+	"context"
+	"docs_organiser/internal/config"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDetectContextWindow(t *testing.T) {
+	tests := []struct {
+		name       string
+		handler    http.HandlerFunc
+		want       int
+		wantSource string
+		wantErr    bool
+	}{
+		{
+			name: "vLLM max_model_len",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/v1/models" {
+					w.Write([]byte(`{"data": [{"id": "m", "max_model_len": 32768}]}`))
+					return
+				}
+				http.NotFound(w, r)
+			},
+			want: 32768, wantSource: "vllm",
+		},
+		{
+			name: "llama.cpp props",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/v1/models":
+					w.Write([]byte(`{"data": [{"id": "m"}]}`))
+				case "/props":
+					w.Write([]byte(`{"default_generation_settings": {"n_ctx": 8192}}`))
+				default:
+					http.NotFound(w, r)
+				}
+			},
+			want: 8192, wantSource: "llama.cpp",
+		},
+		{
+			name: "Ollama num_ctx",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/api/show" {
+					w.Write([]byte(`{"parameters": "stop \"<|eot_id|>\"\nnum_ctx 16384\n", "model_info": {"llama.context_length": 131072}}`))
+					return
+				}
+				http.NotFound(w, r)
+			},
+			want: 16384, wantSource: "ollama",
+		},
+		{
+			name: "Ollama without num_ctx falls back",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/api/show" {
+					w.Write([]byte(`{"parameters": "", "model_info": {"llama.context_length": 131072}}`))
+					return
+				}
+				http.NotFound(w, r)
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(tt.handler)
+			defer srv.Close()
+
+			e := &MLXEngine{}
+			got, source, err := e.DetectContextWindow(context.Background(), "m", srv.URL+"/v1")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DetectContextWindow() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want || source != tt.wantSource {
+				t.Errorf("DetectContextWindow() = %d (%s), want %d (%s)", got, source, tt.want, tt.wantSource)
+			}
+		})
+	}
+}
+
+func TestAutoSizeContext_FallsBack(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+
+	e := &MLXEngine{
+		models:            []config.ModelDefinition{{Name: "m", URL: srv.URL + "/v1"}},
+		ctxMgr:            &ContextManager{maxTokens: 32768},
+		configuredContext: 4096,
+	}
+	if got := e.AutoSizeContext(context.Background(), "m"); got != 4096 {
+		t.Errorf("AutoSizeContext() = %d, want configured 4096", got)
+	}
+}
@@ -0,0 +1,76 @@
+package ai
+
+import (
+	"container/list"
+	"hash/maphash"
+	"sync"
+)
+
+// countKey identifies a text by its hash and length, so large documents aren't kept
+// in memory just to remember their token count.
+type countKey struct {
+	hash   uint64
+	length int
+}
+
+// countCache is a least-recently-used cache of token counts.
+type countCache struct {
+	mu      sync.Mutex
+	seed    maphash.Seed
+	size    int
+	order   *list.List // front is most recently used
+	entries map[countKey]*list.Element
+}
+
+type countEntry struct {
+	key   countKey
+	count int
+}
+
+func newCountCache(size int) *countCache {
+	return &countCache{
+		seed:    maphash.MakeSeed(),
+		size:    size,
+		order:   list.New(),
+		entries: make(map[countKey]*list.Element, size),
+	}
+}
+
+func (c *countCache) key(text string) countKey {
+	return countKey{maphash.String(c.seed, text), len(text)}
+}
+
+func (c *countCache) get(key countKey) (int, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[key]
+	if !ok {
+		return 0, false
+	}
+	c.order.MoveToFront(el)
+	return el.Value.(*countEntry).count, true
+}
+
+// clear forgets every count.
+func (c *countCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.order.Init()
+	c.entries = make(map[countKey]*list.Element, c.size)
+}
+
+func (c *countCache) put(key countKey, count int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[key]; ok {
+		el.Value.(*countEntry).count = count
+		c.order.MoveToFront(el)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&countEntry{key, count})
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*countEntry).key)
+	}
+}
@@ -0,0 +1,60 @@
+package ai
+
+import (
+	"docs_organiser/internal/privacy"
+	"log"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// maxDebugPromptBytes caps how much of each prompt message is written to debug logs.
+const maxDebugPromptBytes = 1000
+
+// debugRequests numbers the requests logged with SetDebugLLM, so the request and
+// response lines of concurrent workers can be matched.
+var debugRequests atomic.Int64
+
+// SetDebugLLM enables logging of every model request and response: each prompt message
+// and the raw answer, truncated and with emails, phone numbers, IDs, and card numbers
+// masked. Headers are never logged. Validation errors are logged as with SetDebug.
+func (e *MLXEngine) SetDebugLLM(enabled bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.debugLLM = enabled
+}
+
+// sanitizeForLog masks PII in s and truncates it to limit bytes.
+func sanitizeForLog(s string, limit int) string {
+	s, _ = privacy.Redact(s)
+	return truncateForLog(s, limit)
+}
+
+// logRequest logs req and returns its number for logResponse.
+func logRequest(req chatRequest) int64 {
+	n := debugRequests.Add(1)
+	var b strings.Builder
+	for _, m := range req.Messages {
+		b.WriteString("\n  ")
+		b.WriteString(m.Role)
+		b.WriteString(": ")
+		b.WriteString(sanitizeForLog(m.Content, maxDebugPromptBytes))
+	}
+	log.Printf("[DEBUG] LLM request #%d to %s (temperature %.2f):%s", n, req.Model, req.Temperature, b.String())
+	return n
+}
+
+// logResponse logs the outcome of request n.
+func logResponse(n int64, resp *chatResponse, err error, elapsed time.Duration) {
+	elapsed = elapsed.Round(time.Millisecond)
+	switch {
+	case err != nil:
+		log.Printf("[DEBUG] LLM request #%d failed after %s: %v", n, elapsed, err)
+	case len(resp.Choices) == 0:
+		log.Printf("[DEBUG] LLM response #%d after %s: no choices", n, elapsed)
+	default:
+		log.Printf("[DEBUG] LLM response #%d after %s (%d prompt + %d completion tokens): %q", n, elapsed,
+			resp.Usage.PromptTokens, resp.Usage.CompletionTokens,
+			sanitizeForLog(resp.Choices[0].Message.Content, maxDebugResponseBytes))
+	}
+}
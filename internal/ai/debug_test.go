@@ -0,0 +1,45 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"testing"
+)
+
+func TestDebugLLM(t *testing.T) {
+	var buf bytes.Buffer
+	prev := log.Writer()
+	log.SetOutput(&buf)
+	defer log.SetOutput(prev)
+
+	mock := &MockLLMClient{
+		Responses: []*chatResponse{{Choices: []choice{{Message: message{Content: `{"category": "Finance", "contact": "jane@example.com"}`}}}}, nil},
+		Errors:    []error{nil, errors.New("server error (status 500)")},
+	}
+	e := &MLXEngine{llm: mock}
+	e.SetDebugLLM(true)
+
+	req := chatRequest{Model: "m", Messages: []message{
+		{Role: "system", Content: "Classify."},
+		{Role: "user", Content: "Invoice for jane@example.com " + strings.Repeat("x", 5000)},
+	}}
+	if _, err := e.chat(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := e.chat(context.Background(), req); err == nil {
+		t.Fatal("expected the mock error")
+	}
+
+	out := buf.String()
+	for _, want := range []string{"LLM request #", "system: Classify.", "user: Invoice for [EMAIL]", "...(truncated)", `\"category\": \"Finance\"`, "status 500"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("debug log lacks %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "jane@example.com") || strings.Contains(out, strings.Repeat("x", maxDebugPromptBytes)) {
+		t.Errorf("debug log is not sanitized:\n%s", out)
+	}
+}
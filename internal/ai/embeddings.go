@@ -0,0 +1,144 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// embedBatchSize is how many texts are sent in one embeddings request.
+const embedBatchSize = 16
+
+// Embed returns the embedding vector of each of texts from the OpenAI-compatible
+// /embeddings endpoint, and the model that made them. model is an embedding model served
+// by one of the configured servers; "" uses the classification model, which many servers
+// can also embed with.
+func (e *MLXEngine) Embed(ctx context.Context, model string, texts []string) ([][]float64, string, error) {
+	apiURL := e.GetURLForModel(model)
+	if model == "" || apiURL == "" {
+		name, url := e.selectBestModel(ctx)
+		if url == "" {
+			return nil, "", fmt.Errorf("no model available")
+		}
+		if model == "" {
+			model = name
+		}
+		apiURL = url
+	}
+	endpoint := strings.TrimSuffix(strings.TrimRight(apiURL, "/"), "/chat/completions") + "/embeddings"
+
+	vectors := make([][]float64, 0, len(texts))
+	client := e.httpClient(2 * time.Minute)
+	for start := 0; start < len(texts); start += embedBatchSize {
+		batch := texts[start:min(start+embedBatchSize, len(texts))]
+		body, err := json.Marshal(map[string]any{"model": model, "input": batch})
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to marshal request: %w", err)
+		}
+		req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewReader(body))
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to create request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to send request: %w", err)
+		}
+		var decoded struct {
+			Data []struct {
+				Index     int       `json:"index"`
+				Embedding []float64 `json:"embedding"`
+			} `json:"data"`
+		}
+		if resp.StatusCode != http.StatusOK {
+			msg, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			return nil, "", fmt.Errorf("embeddings from %s: server error (status %d): %s", model, resp.StatusCode, msg)
+		}
+		err = json.NewDecoder(resp.Body).Decode(&decoded)
+		resp.Body.Close()
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to decode embeddings: %w", err)
+		}
+		if len(decoded.Data) != len(batch) {
+			return nil, "", fmt.Errorf("embeddings from %s: got %d vectors for %d texts", model, len(decoded.Data), len(batch))
+		}
+		out := make([][]float64, len(batch))
+		for _, d := range decoded.Data {
+			if d.Index < 0 || d.Index >= len(batch) || len(d.Embedding) == 0 {
+				return nil, "", fmt.Errorf("embeddings from %s: invalid vector at index %d", model, d.Index)
+			}
+			out[d.Index] = d.Embedding
+		}
+		vectors = append(vectors, out...)
+	}
+	return vectors, model, nil
+}
+
+// ClusterName is the category the model proposes for a group of similar documents.
+type ClusterName struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Examples    []string `json:"examples"`
+}
+
+// NameCluster asks the model for a folder name, a one-sentence description, and a few
+// example document types for the documents whose excerpts are given. taken lists the
+// names of other clusters, which the model is asked not to reuse.
+func (e *MLXEngine) NameCluster(ctx context.Context, excerpts, taken []string) (*ClusterName, error) {
+	userPrompt := "Documents:\n\n" + strings.Join(excerpts, "\n\n---\n\n")
+	modelName, _, err := e.selectModelFor(ctx, userPrompt)
+	if err != nil {
+		return nil, err
+	}
+	systemPrompt := `You are an intelligent file organization assistant. The documents below were grouped together because they are similar.
+Propose the folder they should be filed in. Return a SINGLE JSON object:
+{"name": "Folder_Name", "description": "One sentence saying which documents belong here", "examples": ["2 to 4 short document types"]}
+The name is 1 to 3 words without slashes, like Invoices, Bank_Statements, or Medical.
+Do NOT return markdown. Do NOT return extra text.`
+	if len(taken) > 0 {
+		systemPrompt += "\nOther folders are already named: " + strings.Join(taken, ", ") + ". Choose a different name."
+	}
+
+	e.mu.RLock()
+	sampling := e.sampling
+	e.mu.RUnlock()
+	_, _, contentBudget, _ := e.ctxMgr.GetBudgets()
+	userPrompt = e.ctxMgr.Truncate(userPrompt, contentBudget, StrategySlidingWindow)
+
+	var lastErr error
+	for attempt := 0; attempt <= maxRetries; attempt++ {
+		resp, err := e.chat(ctx, chatRequest{
+			Model:       modelName,
+			Messages:    []message{{Role: "system", Content: systemPrompt}, {Role: "user", Content: userPrompt}},
+			Temperature: sampling.Temperature,
+			TopP:        sampling.TopP,
+			MaxTokens:   e.outputBudget(),
+			Stop:        sampling.Stop,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if len(resp.Choices) == 0 {
+			lastErr = fmt.Errorf("empty response from model")
+			continue
+		}
+		content := resp.Choices[0].Message.Content
+		lastErr = fmt.Errorf("no folder name in the answer: %s", truncateForLog(content, maxDebugResponseBytes))
+		for _, object := range extractJSONObjects(cleanJSON(content)) {
+			var name ClusterName
+			if json.Unmarshal([]byte(object), &name) != nil || strings.TrimSpace(name.Name) == "" {
+				continue
+			}
+			name.Name = SanitizeFilename(name.Name)
+			name.Description = strings.TrimSpace(name.Description)
+			return &name, nil
+		}
+	}
+	return nil, lastErr
+}
@@ -0,0 +1,170 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Invoice holds the billing details read from a receipt or invoice by ExtractInvoice.
+// Details the document does not state are left empty.
+type Invoice struct {
+	Vendor        string  `json:"vendor,omitempty" yaml:"vendor,omitempty"`
+	Amount        float64 `json:"amount,omitempty" yaml:"amount,omitempty"`
+	Currency      string  `json:"currency,omitempty" yaml:"currency,omitempty"`
+	InvoiceNumber string  `json:"invoice_number,omitempty" yaml:"invoice_number,omitempty"`
+	DueDate       string  `json:"due_date,omitempty" yaml:"due_date,omitempty"`
+}
+
+// invoicePrompt asks for the billing details as a single JSON object.
+const invoicePrompt = `You extract billing details from receipts and invoices. Return a SINGLE JSON object:
+{"vendor": "Company that issued the document", "amount": 0.00, "currency": "ISO 4217 code", "invoice_number": "...", "due_date": "YYYY-MM-DD"}
+amount is the total to pay, as a number. Use null for details the document does not state.
+Do NOT return extra fields. Do NOT return markdown. Do NOT return extra text.`
+
+// ExtractInvoice reads the vendor, total, invoice number, and due date of a receipt or
+// invoice in a second request after classification. Invalid answers are sent back for
+// correction like classification answers.
+func (e *MLXEngine) ExtractInvoice(ctx context.Context, text string) (*Invoice, error) {
+	modelName, _, err := e.selectModelFor(ctx, text)
+	if err != nil {
+		return nil, err
+	}
+	// Totals sit at the end of a document and the issuer at the start; keep both
+	_, _, contentBudget, _ := e.ctxMgr.GetBudgets()
+	text = e.ctxMgr.Truncate(text, contentBudget, StrategyMiddleExtraction)
+
+	messages := []message{
+		{Role: "system", Content: invoicePrompt},
+		{Role: "user", Content: "Document text snippet:\n" + text},
+	}
+	var lastErr error
+	for attempt := 0; attempt <= maxRetries; attempt++ {
+		if lastErr != nil {
+			messages = append(messages[:2],
+				message{Role: "assistant", Content: "Previous attempt failed validation."},
+				message{Role: "user", Content: fmt.Sprintf("Your previous response was invalid: %v. Please provide a strictly valid JSON object following the schema.", lastErr)})
+		}
+		resp, err := e.chat(ctx, chatRequest{Model: modelName, Messages: messages, Temperature: 0, MaxTokens: e.outputBudget()})
+		if err != nil {
+			lastErr = err
+			if ctx.Err() != nil {
+				break
+			}
+			continue
+		}
+		if len(resp.Choices) == 0 {
+			lastErr = fmt.Errorf("empty response from model")
+			continue
+		}
+		invoice, err := parseInvoice(resp.Choices[0].Message.Content)
+		if err == nil {
+			return invoice, nil
+		}
+		lastErr = err
+	}
+	return nil, fmt.Errorf("failed to extract invoice details using model %s: %w", modelName, lastErr)
+}
+
+// invoiceResponse is the model's answer before validation; any field may be null.
+type invoiceResponse struct {
+	Vendor        *string `json:"vendor"`
+	Amount        any     `json:"amount"`
+	Currency      *string `json:"currency"`
+	InvoiceNumber any     `json:"invoice_number"`
+	DueDate       *string `json:"due_date"`
+}
+
+// parseInvoice reads the first JSON object of a response that decodes as invoice details.
+// Amounts given as strings ("1,234.50 EUR") are accepted; due dates must be ISO-8601.
+func parseInvoice(content string) (*Invoice, error) {
+	var raw invoiceResponse
+	decodeErr := fmt.Errorf("no JSON object found in response")
+	for _, candidate := range extractJSONObjects(normalizePunctuation(cleanJSON(content))) {
+		var r invoiceResponse
+		dec := json.NewDecoder(strings.NewReader(candidate))
+		dec.DisallowUnknownFields()
+		if decodeErr = dec.Decode(&r); decodeErr == nil {
+			raw = r
+			break
+		}
+	}
+	if decodeErr != nil {
+		return nil, fmt.Errorf("invalid JSON or unexpected fields: %w", decodeErr)
+	}
+
+	invoice := &Invoice{
+		Vendor:        strings.TrimSpace(deref(raw.Vendor)),
+		Currency:      strings.ToUpper(strings.TrimSpace(deref(raw.Currency))),
+		InvoiceNumber: strings.TrimSpace(scalarString(raw.InvoiceNumber)),
+	}
+	switch v := raw.Amount.(type) {
+	case nil:
+	case float64:
+		invoice.Amount = v
+	case string:
+		amount, err := parseAmount(v)
+		if err != nil {
+			return nil, err
+		}
+		invoice.Amount = amount
+	default:
+		return nil, fmt.Errorf("invalid amount %v: want a number", v)
+	}
+	if invoice.Amount < 0 {
+		return nil, fmt.Errorf("invalid amount %v: want the total to pay", invoice.Amount)
+	}
+	if due := strings.TrimSpace(deref(raw.DueDate)); due != "" {
+		date, err := parseDocumentDate(due)
+		if err != nil {
+			return nil, fmt.Errorf("invalid due_date %q: want YYYY-MM-DD or null", due)
+		}
+		invoice.DueDate = date
+	}
+	if *invoice == (Invoice{}) {
+		return nil, fmt.Errorf("no invoice details found")
+	}
+	return invoice, nil
+}
+
+// parseAmount reads an amount written as text, ignoring currency symbols, codes, and
+// thousands separators ("$1,234.50", "1.234,50 EUR").
+func parseAmount(s string) (float64, error) {
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' || r == '.' || r == ',' || r == '-' {
+			return r
+		}
+		return -1
+	}, s)
+	// The last separator is the decimal point when two digits or fewer follow it
+	if i := strings.LastIndexAny(digits, ".,"); i >= 0 && len(digits)-i-1 <= 2 {
+		digits = strings.NewReplacer(".", "", ",", "").Replace(digits[:i]) + "." + digits[i+1:]
+	} else {
+		digits = strings.NewReplacer(".", "", ",", "").Replace(digits)
+	}
+	amount, err := strconv.ParseFloat(digits, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q: want a number", s)
+	}
+	return amount, nil
+}
+
+// scalarString renders a JSON string or number (invoice numbers come as either).
+func scalarString(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return ""
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,71 @@
+package ai
+
+import (
+	"context"
+	"docs_organiser/internal/config"
+	"strings"
+	"testing"
+)
+
+func TestParseInvoice(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    Invoice
+		wantErr bool
+	}{
+		{"complete", `{"vendor": "ACME Corp", "amount": 1234.5, "currency": "eur", "invoice_number": "INV-42", "due_date": "2024-04-30"}`,
+			Invoice{Vendor: "ACME Corp", Amount: 1234.5, Currency: "EUR", InvoiceNumber: "INV-42", DueDate: "2024-04-30"}, false},
+		{"nulls and prose", "Here you go:\n```json\n{\"vendor\": \"Cafe Blue\", \"amount\": \"$12.80\", \"currency\": \"USD\", \"invoice_number\": null, \"due_date\": null}\n```",
+			Invoice{Vendor: "Cafe Blue", Amount: 12.8, Currency: "USD"}, false},
+		{"european amount, numeric invoice number", `{"vendor": "Stadtwerke", "amount": "1.234,50 EUR", "currency": "EUR", "invoice_number": 20240117, "due_date": null}`,
+			Invoice{Vendor: "Stadtwerke", Amount: 1234.5, Currency: "EUR", InvoiceNumber: "20240117"}, false},
+		{"thousands separator only", `{"vendor": "X", "amount": "1,234", "currency": null, "invoice_number": null, "due_date": null}`,
+			Invoice{Vendor: "X", Amount: 1234}, false},
+		{"invalid due date", `{"vendor": "X", "amount": 1, "due_date": "next Friday"}`, Invoice{}, true},
+		{"invalid amount", `{"vendor": "X", "amount": "twelve"}`, Invoice{}, true},
+		{"unknown field", `{"vendor": "X", "iban": "DE00"}`, Invoice{}, true},
+		{"nothing found", `{"vendor": null, "amount": null}`, Invoice{}, true},
+		{"no JSON", `I could not find an invoice.`, Invoice{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseInvoice(tt.content)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && *got != tt.want {
+				t.Errorf("parseInvoice() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractInvoice(t *testing.T) {
+	tokenizer := testTokenizer(t)
+	mock := &MockLLMClient{Responses: []*chatResponse{
+		{Choices: []choice{{Message: message{Content: `{"vendor": "ACME", "amount": 10, "due_date": "soon"}`}}}},
+		{Choices: []choice{{Message: message{Content: `{"vendor": "ACME", "amount": 10, "due_date": null}`}}}},
+	}}
+	engine := withRouter(&MLXEngine{
+		llm:    mock,
+		models: []config.ModelDefinition{{Name: "test-model", URL: "http://mock-api.com/v1"}},
+		ctxMgr: NewContextManager(tokenizer, 4096),
+		// A classification limit too small for the invoice JSON
+		sampling: Sampling{MaxTokens: 16},
+	})
+
+	got, err := engine.ExtractInvoice(context.Background(), "ACME invoice, total 10.00")
+	if err != nil {
+		t.Fatalf("ExtractInvoice: %v", err)
+	}
+	if *got != (Invoice{Vendor: "ACME", Amount: 10}) {
+		t.Errorf("ExtractInvoice() = %+v", *got)
+	}
+	if len(mock.Requests) != 2 || !strings.Contains(mock.Requests[1].Messages[len(mock.Requests[1].Messages)-1].Content, "due_date") {
+		t.Errorf("expected the invalid due date to be sent back for correction, got %d requests", len(mock.Requests))
+	}
+	if _, _, _, output := engine.ctxMgr.GetBudgets(); mock.Requests[0].MaxTokens != output {
+		t.Errorf("max_tokens = %d, want the output budget %d", mock.Requests[0].MaxTokens, output)
+	}
+}
@@ -0,0 +1,63 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+)
+
+// FileInfo describes a file without its content, for classifying from its name alone.
+type FileInfo struct {
+	Name     string
+	Folder   string // name of the folder the file was found in, if any
+	Size     int64
+	Modified time.Time
+	// Metadata holds document properties such as a PDF's Title and Author.
+	Metadata map[string]string
+}
+
+// prompt renders the file details for the model.
+func (f FileInfo) prompt() string {
+	var b strings.Builder
+	b.WriteString("The document text was not read. Classify it from its file details:\n")
+	fmt.Fprintf(&b, "File name: %s\n", f.Name)
+	if f.Folder != "" {
+		fmt.Fprintf(&b, "Source folder: %s\n", f.Folder)
+	}
+	if f.Size > 0 {
+		fmt.Fprintf(&b, "Size: %d bytes\n", f.Size)
+	}
+	if !f.Modified.IsZero() {
+		fmt.Fprintf(&b, "Modified: %s\n", f.Modified.Format(time.DateOnly))
+	}
+	for _, key := range slices.Sorted(maps.Keys(f.Metadata)) {
+		if value := strings.TrimSpace(f.Metadata[key]); value != "" {
+			fmt.Fprintf(&b, "%s: %s\n", key, value)
+		}
+	}
+	b.WriteString("If these details do not identify the document's kind, return a low confidence_score.")
+	return b.String()
+}
+
+// CategorizeFileInfo classifies a document from its file name, folder, and metadata with a
+// prompt of a few dozen tokens. Callers compare the confidence against a threshold and
+// fall back to Categorize on the extracted text when it is too low.
+func (e *MLXEngine) CategorizeFileInfo(ctx context.Context, info FileInfo) (*CategorizationResult, error) {
+	startTime := time.Now()
+	userPrompt := info.prompt()
+
+	modelName, _, err := e.selectModelFor(ctx, userPrompt)
+	if err != nil {
+		return nil, err
+	}
+	metadata := &CategorizationMetadata{
+		Model:          modelName,
+		TruncationType: "none",
+		Language:       DetectLanguage(info.Name),
+		FastPath:       true,
+	}
+	return e.decide(ctx, startTime, modelName, userPrompt, metadata)
+}
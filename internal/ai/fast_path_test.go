@@ -0,0 +1,92 @@
+package ai
+
+import (
+	"context"
+	"docs_organiser/internal/config"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCategorizeFileInfo(t *testing.T) {
+	tokenizer := testTokenizer(t)
+	mock := &MockLLMClient{Responses: []*chatResponse{{Choices: []choice{{Message: message{
+		Content: `{"category": "Finance", "title": "Bank Statement March", "confidence_score": 0.85}`,
+	}}}}}}
+	engine := withRouter(&MLXEngine{
+		llm:             mock,
+		models:          []config.ModelDefinition{{Name: "test-model", URL: "http://mock-api.com/v1"}},
+		ctxMgr:          NewContextManager(tokenizer, 4096),
+		validCategories: []string{"Finance", "Work", "Misc"},
+	})
+
+	result, err := engine.CategorizeFileInfo(context.Background(), FileInfo{
+		Name:     "2024-03 Kontoauszug.pdf",
+		Folder:   "Bank",
+		Size:     48213,
+		Modified: time.Date(2024, 4, 2, 9, 0, 0, 0, time.UTC),
+		Metadata: map[string]string{"Title": "Statement of account", "Author": ""},
+	})
+	if err != nil {
+		t.Fatalf("CategorizeFileInfo: %v", err)
+	}
+	if result.Analysis.Category != "Finance" || !result.Metadata.FastPath {
+		t.Errorf("got category %q, fast path %v", result.Analysis.Category, result.Metadata.FastPath)
+	}
+
+	prompt := mock.Requests[0].Messages[len(mock.Requests[0].Messages)-1].Content
+	for _, want := range []string{"File name: 2024-03 Kontoauszug.pdf", "Source folder: Bank", "Modified: 2024-04-02", "Title: Statement of account"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("prompt is missing %q:\n%s", want, prompt)
+		}
+	}
+	if strings.Contains(prompt, "Author") {
+		t.Errorf("prompt lists empty metadata:\n%s", prompt)
+	}
+}
+
+func TestCategorizeSource(t *testing.T) {
+	tokenizer := testTokenizer(t)
+	respond := func() *MockLLMClient {
+		return &MockLLMClient{Responses: []*chatResponse{{Choices: []choice{{Message: message{
+			Content: `{"category": "Finance", "title": "HDFC Statement March 2024", "confidence_score": 0.9}`,
+		}}}}}}
+	}
+
+	tests := []struct {
+		name    string
+		source  Source
+		want    []string
+		notWant []string
+	}{
+		{"name and directory", Source{Name: "HDFC_Statement_Mar2024.pdf", Dir: "Bank/2024"},
+			[]string{"Original file name: HDFC_Statement_Mar2024.pdf\n", "Source directory: Bank/2024\n", "the text decides", "Document text snippet:\nStatement"}, nil},
+		{"top of the source", Source{Name: "scan.pdf"}, []string{"Original file name: scan.pdf"}, []string{"Source directory"}},
+		{"unknown", Source{}, []string{"Document text snippet:"}, []string{"Original file name", "hints"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := respond()
+			engine := withRouter(&MLXEngine{
+				llm:             mock,
+				models:          []config.ModelDefinition{{Name: "test-model", URL: "http://mock-api.com/v1"}},
+				ctxMgr:          NewContextManager(tokenizer, 4096),
+				validCategories: []string{"Finance", "Misc"},
+			})
+			if _, err := engine.CategorizeSource(context.Background(), tt.source, "Statement of account"); err != nil {
+				t.Fatalf("CategorizeSource: %v", err)
+			}
+			prompt := mock.Requests[0].Messages[len(mock.Requests[0].Messages)-1].Content
+			for _, want := range tt.want {
+				if !strings.Contains(prompt, want) {
+					t.Errorf("prompt is missing %q:\n%s", want, prompt)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(prompt, notWant) {
+					t.Errorf("prompt should not contain %q:\n%s", notWant, prompt)
+				}
+			}
+		})
+	}
+}
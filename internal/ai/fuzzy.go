@@ -0,0 +1,122 @@
+package ai
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// fuzzyCategory matches a category the model misspelled or embellished to the one of
+// categories it most likely means, as a last resort before rejecting the answer:
+//
+//   - the same words, ignoring case and separators ("personal_docs" is "Personal Docs");
+//   - a small edit distance from a category or its last folder ("Finanse" is "Finance");
+//   - all the words of a category or its last folder ("Personal Docs" is "Personal").
+//
+// Each step must find a single best category; ambiguous names are not matched.
+func fuzzyCategory(name string, categories []string) (string, bool) {
+	key := fuzzyKey(name)
+	if key == "" {
+		return "", false
+	}
+	keys := make([]string, len(categories))
+	for i, c := range categories {
+		keys[i] = fuzzyKey(c)
+	}
+
+	if i, ok := bestMatch(categories, func(i int) int {
+		if keys[i] == key || lastFolder(keys[i]) == key {
+			return 1
+		}
+		return 0
+	}); ok {
+		return categories[i], true
+	}
+
+	maxDist := min(max(len([]rune(key))/4, 1), 3)
+	if i, ok := bestMatch(categories, func(i int) int {
+		d := min(levenshtein(key, keys[i]), levenshtein(key, lastFolder(keys[i])))
+		if d > maxDist {
+			return 0
+		}
+		return maxDist + 1 - d // closer is better
+	}); ok {
+		return categories[i], true
+	}
+
+	words := strings.FieldsFunc(key, func(r rune) bool { return r == ' ' || r == '/' })
+	if i, ok := bestMatch(categories, func(i int) int {
+		return max(containedWords(words, keys[i]), containedWords(words, lastFolder(keys[i])))
+	}); ok {
+		return categories[i], true
+	}
+	return "", false
+}
+
+// bestMatch returns the index of the single category with the highest positive score.
+func bestMatch(categories []string, score func(int) int) (int, bool) {
+	bestIndex, bestScore, tied := -1, 0, false
+	for i := range categories {
+		switch s := score(i); {
+		case s > bestScore:
+			bestIndex, bestScore, tied = i, s, false
+		case s == bestScore && s > 0:
+			tied = true
+		}
+	}
+	return bestIndex, bestIndex >= 0 && !tied
+}
+
+// fuzzyKey normalizes a category for comparison: NFC, lowercase, with underscores,
+// hyphens, and runs of spaces as single spaces, and no spaces around slashes.
+func fuzzyKey(s string) string {
+	s = strings.ToLower(norm.NFC.String(s))
+	folders := strings.Split(s, "/")
+	for i, f := range folders {
+		folders[i] = strings.Join(strings.FieldsFunc(f, func(r rune) bool {
+			return unicode.IsSpace(r) || r == '_' || r == '-'
+		}), " ")
+	}
+	return strings.Trim(strings.Join(folders, "/"), "/")
+}
+
+// lastFolder returns the last folder of a category key.
+func lastFolder(key string) string {
+	return key[strings.LastIndex(key, "/")+1:]
+}
+
+// containedWords returns the number of words of key when all of them are among words,
+// and 0 otherwise.
+func containedWords(words []string, key string) int {
+	want := strings.FieldsFunc(key, func(r rune) bool { return r == ' ' || r == '/' })
+	for _, w := range want {
+		if !slices.Contains(words, w) {
+			return 0
+		}
+	}
+	return len(want)
+}
+
+// levenshtein returns the edit distance between a and b in runes.
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
@@ -0,0 +1,57 @@
+package ai
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestFuzzyCategory(t *testing.T) {
+	categories := []string{"Personal", "Finance", "Finance/Tax Returns", "Receipts", "Work", "Work/Receipts", "Misc"}
+	tests := []struct {
+		name string
+		want string // "" for no match
+	}{
+		{"personal", "Personal"},
+		{"Personal Docs", "Personal"},
+		{"Personal_Documents", "Personal"},
+		{"Finanse", "Finance"},
+		{"finance / tax-returns", "Finance/Tax Returns"},
+		{"Tax Return", "Finance/Tax Returns"},
+		{"Finance Tax Returns", "Finance/Tax Returns"},
+		{"Receipt", ""},          // Receipts and Work/Receipts
+		{"Finance and Work", ""}, // both Finance and Work
+		{"Travel", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		got, ok := fuzzyCategory(tt.name, categories)
+		if tt.want == "" {
+			if ok {
+				t.Errorf("fuzzyCategory(%q) = %q, want no match", tt.name, got)
+			}
+			continue
+		}
+		if !ok || got != tt.want {
+			t.Errorf("fuzzyCategory(%q) = %q, %v; want %q", tt.name, got, ok, tt.want)
+		}
+	}
+}
+
+func TestParseAndValidateFuzzy(t *testing.T) {
+	engine := testEngine(t)
+	engine.SetCategories([]string{"Personal", "Finance", "Misc"})
+
+	for category, want := range map[string]string{"personal": "Personal", "Personal Docs": "Personal", "Finanse": "Finance"} {
+		got, err := engine.parseAndValidate(fmt.Sprintf(`{"category": %q, "title": "Doc", "confidence_score": 0.9}`, category))
+		if err != nil {
+			t.Errorf("category %q rejected: %v", category, err)
+			continue
+		}
+		if got.Category != want {
+			t.Errorf("category %q resolved to %q, want %q", category, got.Category, want)
+		}
+	}
+	if _, err := engine.parseAndValidate(`{"category": "Travel", "title": "Doc", "confidence_score": 0.9}`); err == nil {
+		t.Error("an unrelated category should still be rejected")
+	}
+}
@@ -0,0 +1,152 @@
+package ai
+
+import (
+	"fmt"
+	"math"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// HeuristicModel is the model name recorded for classifications made without a model server.
+const HeuristicModel = "heuristic"
+
+// defaultKeywords seed the offline classifier for DefaultCategories.
+var defaultKeywords = map[string][]string{
+	"Personal":  {"letter", "family", "birthday", "wedding", "personal", "passport"},
+	"Work":      {"meeting", "agenda", "employee", "employer", "colleague", "minutes", "memo"},
+	"Finance":   {"invoice", "bank", "statement", "payment", "balance", "account", "salary", "loan", "tax", "iban"},
+	"Health":    {"patient", "medical", "doctor", "hospital", "prescription", "diagnosis", "clinic", "vaccination"},
+	"Education": {"course", "university", "student", "lecture", "exam", "certificate", "school", "assignment"},
+	"Technical": {"software", "api", "server", "install", "configuration", "manual", "specification", "code"},
+	"Travel":    {"flight", "boarding", "hotel", "booking", "itinerary", "reservation", "airline", "train"},
+	"Legal":     {"agreement", "contract", "court", "clause", "attorney", "license", "liability", "plaintiff"},
+	"Projects":  {"project", "milestone", "roadmap", "proposal", "deliverable", "timeline", "scope"},
+	"Receipts":  {"receipt", "subtotal", "purchase", "order", "paid", "vat", "cashier", "refund"},
+}
+
+// heuristicRules are the user's keyword dictionaries and file name patterns, keyed by
+// lowercase category path.
+type heuristicRules struct {
+	keywords map[string][]string
+	patterns map[string][]*regexp.Regexp
+}
+
+// SetHeuristicRules configures the offline classifier: keywords per category add to
+// built-in dictionaries and the category vocabulary, and a file name matching one of a
+// category's patterns (regular expressions) is filed there outright. Keys match
+// category paths case-insensitively.
+func (e *MLXEngine) SetHeuristicRules(keywords, filenamePatterns map[string][]string) error {
+	rules := &heuristicRules{
+		keywords: make(map[string][]string, len(keywords)),
+		patterns: make(map[string][]*regexp.Regexp, len(filenamePatterns)),
+	}
+	for category, words := range keywords {
+		key := strings.ToLower(strings.Trim(category, "/"))
+		rules.keywords[key] = append(rules.keywords[key], words...)
+	}
+	for category, patterns := range filenamePatterns {
+		key := strings.ToLower(strings.Trim(category, "/"))
+		for _, p := range patterns {
+			re, err := regexp.Compile(p)
+			if err != nil {
+				return fmt.Errorf("invalid file name pattern %q for %s: %w", p, category, err)
+			}
+			rules.patterns[key] = append(rules.patterns[key], re)
+		}
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.heuristics = rules
+	return nil
+}
+
+// ClassifyHeuristic files a document without a model: a matching file name pattern wins,
+// otherwise each category is scored by how often its keywords (configured, built-in, and
+// the words of its name, description, and examples) occur in the text and file name.
+// The title is the cleaned original name. Like Categorize, it returns the Misc fallback
+// with an error when nothing matches.
+func (e *MLXEngine) ClassifyHeuristic(name, text string) (*CategorizationResult, error) {
+	categories := e.GetCategories()
+	e.mu.RLock()
+	rules := e.heuristics
+	e.mu.RUnlock()
+	if rules == nil {
+		rules = &heuristicRules{}
+	}
+
+	ext := filepath.Ext(name)
+	analysis := &AnalysisResult{Category: "Misc", Title: SanitizeFilename(strings.TrimSuffix(name, ext))}
+	metadata := &CategorizationMetadata{Model: HeuristicModel, TruncationType: "none"}
+	result := &CategorizationResult{Analysis: analysis, Metadata: metadata}
+
+	for _, c := range categories {
+		for _, re := range rules.patterns[strings.ToLower(c)] {
+			if re.MatchString(name) {
+				analysis.Category, analysis.ConfidenceScore = c, 0.9
+				metadata.Success = true
+				return result, nil
+			}
+		}
+	}
+
+	counts := make(map[string]int)
+	for _, w := range salienceWords(strings.TrimSuffix(name, ext) + "\n" + text) {
+		counts[w]++
+	}
+	scores := make([]float64, len(categories))
+	var best int
+	var total float64
+	for i, c := range categories {
+		for term := range e.categoryTerms(c, rules) {
+			if n := counts[term]; n > 0 {
+				scores[i] += math.Log1p(float64(n))
+			}
+		}
+		total += scores[i]
+		// Ties go to the more specific category
+		if scores[i] > scores[best] || scores[i] == scores[best] && strings.Count(c, "/") > strings.Count(categories[best], "/") {
+			best = i
+		}
+	}
+	if len(categories) == 0 || scores[best] == 0 {
+		analysis.ConfidenceScore = 0
+		return result, fmt.Errorf("no keywords matched any category")
+	}
+
+	analysis.Category = categories[best]
+	analysis.ConfidenceScore = math.Round(scores[best]/total*100) / 100
+	metadata.Success = true
+	return result, nil
+}
+
+// categoryTerms collects the normalized keywords for category: configured keywords,
+// built-in ones for its last path segment, and the words of its path, description,
+// and examples.
+func (e *MLXEngine) categoryTerms(category string, rules *heuristicRules) map[string]bool {
+	sources := append([]string{category}, rules.keywords[strings.ToLower(category)]...)
+	segments := strings.Split(category, "/")
+	sources = append(sources, defaultKeywords[segments[len(segments)-1]]...)
+
+	e.mu.RLock()
+	if e.taxonomy != nil {
+		for _, entry := range e.taxonomy.Entries() {
+			if entry.Path == category {
+				sources = append(sources, entry.Description)
+				sources = append(sources, entry.Examples...)
+			}
+		}
+	}
+	sources = append(sources, e.descriptions[strings.ToLower(category)])
+	e.mu.RUnlock()
+
+	terms := make(map[string]bool)
+	for _, s := range sources {
+		for _, w := range salienceWords(s) {
+			if !stopWords[w] {
+				terms[w] = true
+			}
+		}
+	}
+	return terms
+}
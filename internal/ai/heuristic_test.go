@@ -0,0 +1,53 @@
+package ai
+
+import "testing"
+
+func TestClassifyHeuristic(t *testing.T) {
+	engine := testEngine(t)
+	engine.SetCategories([]string{"Finance", "Finance/Taxes", "Travel", "Work", "Misc"})
+	if err := engine.SetHeuristicRules(
+		map[string][]string{"work": {"standup"}},
+		map[string][]string{"Travel": {`(?i)^boarding[-_ ]pass`}},
+	); err != nil {
+		t.Fatalf("SetHeuristicRules: %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		file         string
+		text         string
+		wantCategory string
+		wantErr      bool
+	}{
+		{"file name pattern", "Boarding_Pass_LH123.pdf", "Nothing else to see.", "Travel", false},
+		{"built-in keywords", "scan001.pdf", "Bank statement. Closing balance and payments for the account.", "Finance", false},
+		{"category name in text", "scan002.pdf", "Tax return 2023. Taxes withheld.", "Finance/Taxes", false},
+		{"configured keywords", "notes.txt", "Standup notes: standup moved to Tuesday.", "Work", false},
+		{"keywords in file name", "hotel-booking.txt", "Confirmation 55XJ.", "Travel", false},
+		{"no match", "blob.txt", "Lorem ipsum dolor sit amet.", "Misc", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := engine.ClassifyHeuristic(tt.file, tt.text)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if result.Analysis.Category != tt.wantCategory {
+				t.Errorf("category = %q, want %q", result.Analysis.Category, tt.wantCategory)
+			}
+			if result.Metadata.Model != HeuristicModel {
+				t.Errorf("model = %q, want %q", result.Metadata.Model, HeuristicModel)
+			}
+			if !tt.wantErr && (result.Analysis.ConfidenceScore <= 0 || result.Analysis.ConfidenceScore > 1) {
+				t.Errorf("confidence = %v, want (0, 1]", result.Analysis.ConfidenceScore)
+			}
+		})
+	}
+}
+
+func TestSetHeuristicRules_InvalidPattern(t *testing.T) {
+	engine := testEngine(t)
+	if err := engine.SetHeuristicRules(nil, map[string][]string{"Travel": {"("}}); err == nil {
+		t.Error("expected an error for an invalid pattern")
+	}
+}
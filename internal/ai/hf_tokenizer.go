@@ -0,0 +1,593 @@
+package ai
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/dlclark/regexp2"
+	"golang.org/x/text/unicode/norm"
+)
+
+// gpt2Pattern is the word split the ByteLevel pre-tokenizer applies when use_regex is set.
+const gpt2Pattern = `'s|'t|'re|'ve|'m|'ll|'d| ?\p{L}+| ?\p{N}+| ?[^\s\p{L}\p{N}]+|\s+(?!\S)|\s+`
+
+// hfTokenizer is a byte-pair-encoding tokenizer loaded from a HuggingFace tokenizer.json.
+// It covers the byte-level (GPT-2, Llama 3, Qwen) and SentencePiece-style (Llama 2,
+// Mistral) BPE models local LLMs ship with; other model types are rejected on load.
+type hfTokenizer struct {
+	vocab  map[string]int
+	tokens map[int]string
+	ranks  map[[2]string]int
+	unk    int // -1 without an unknown token
+
+	normalizers   []func(string) string
+	preTokenizers []func([]string) []string
+	added         []string // non-special added tokens, longest first
+	addedIDs      map[string]int
+
+	byteLevel    bool
+	spaceMark    string // SentencePiece's "▁" for metaspace models
+	byteFallback bool
+	ignoreMerges bool
+
+	mu    sync.Mutex
+	cache map[string][]int // word -> ids
+}
+
+// maxWordCache bounds the per-word cache; it is cleared when full.
+const maxWordCache = 50000
+
+type hfFile struct {
+	AddedTokens []struct {
+		ID      int    `json:"id"`
+		Content string `json:"content"`
+		Special bool   `json:"special"`
+	} `json:"added_tokens"`
+	Normalizer   *hfComponent `json:"normalizer"`
+	PreTokenizer *hfComponent `json:"pre_tokenizer"`
+	Model        struct {
+		Type                    string          `json:"type"`
+		Vocab                   json.RawMessage `json:"vocab"`
+		Merges                  json.RawMessage `json:"merges"`
+		UnkToken                *string         `json:"unk_token"`
+		ByteFallback            bool            `json:"byte_fallback"`
+		IgnoreMerges            bool            `json:"ignore_merges"`
+		ContinuingSubwordPrefix *string         `json:"continuing_subword_prefix"`
+		EndOfWordSuffix         *string         `json:"end_of_word_suffix"`
+	} `json:"model"`
+}
+
+// hfComponent holds the fields of any normalizer or pre-tokenizer; Type selects which apply.
+type hfComponent struct {
+	Type          string        `json:"type"`
+	Normalizers   []hfComponent `json:"normalizers"`
+	Pretokenizers []hfComponent `json:"pretokenizers"`
+	Pattern       struct {
+		String *string `json:"String"`
+		Regex  *string `json:"Regex"`
+	} `json:"pattern"`
+	Content          string `json:"content"`
+	Prepend          string `json:"prepend"`
+	Behavior         string `json:"behavior"`
+	Invert           bool   `json:"invert"`
+	AddPrefixSpace   *bool  `json:"add_prefix_space"`
+	UseRegex         *bool  `json:"use_regex"`
+	Replacement      string `json:"replacement"`
+	PrependScheme    string `json:"prepend_scheme"`
+	Split            *bool  `json:"split"`
+	IndividualDigits bool   `json:"individual_digits"`
+	StripLeft        bool   `json:"strip_left"`
+	StripRight       bool   `json:"strip_right"`
+}
+
+// loadHFTokenizer reads a tokenizer.json file.
+func loadHFTokenizer(path string) (*hfTokenizer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var f hfFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("invalid tokenizer.json: %w", err)
+	}
+	if f.Model.Type != "BPE" && !(f.Model.Type == "" && f.Model.Merges != nil) {
+		return nil, fmt.Errorf("unsupported model type %q (only BPE tokenizers are supported)", f.Model.Type)
+	}
+	if nonEmpty(f.Model.ContinuingSubwordPrefix) || nonEmpty(f.Model.EndOfWordSuffix) {
+		return nil, fmt.Errorf("BPE models with subword prefixes or suffixes are not supported")
+	}
+	var vocab map[string]int
+	if err := json.Unmarshal(f.Model.Vocab, &vocab); err != nil {
+		return nil, fmt.Errorf("invalid vocab: %w", err)
+	}
+
+	t := &hfTokenizer{
+		vocab:        vocab,
+		tokens:       make(map[int]string, len(vocab)+len(f.AddedTokens)),
+		ranks:        make(map[[2]string]int),
+		unk:          -1,
+		byteFallback: f.Model.ByteFallback,
+		ignoreMerges: f.Model.IgnoreMerges,
+		addedIDs:     make(map[string]int),
+		cache:        make(map[string][]int),
+	}
+	for token, id := range t.vocab {
+		t.tokens[id] = token
+	}
+	for _, a := range f.AddedTokens {
+		t.tokens[a.ID] = a.Content
+		if !a.Special {
+			t.added = append(t.added, a.Content)
+			t.addedIDs[a.Content] = a.ID
+		}
+	}
+	sort.Slice(t.added, func(i, j int) bool { return len(t.added[i]) > len(t.added[j]) })
+	if f.Model.UnkToken != nil {
+		if id, ok := t.vocab[*f.Model.UnkToken]; ok {
+			t.unk = id
+		}
+	}
+
+	// Merges are "left right" strings in older files and [left, right] pairs in newer ones
+	var merges []string
+	var pairs [][2]string
+	if err := json.Unmarshal(f.Model.Merges, &merges); err == nil {
+		for _, m := range merges {
+			left, right, ok := strings.Cut(m, " ")
+			if !ok {
+				return nil, fmt.Errorf("invalid merge %q", m)
+			}
+			pairs = append(pairs, [2]string{left, right})
+		}
+	} else if err := json.Unmarshal(f.Model.Merges, &pairs); err != nil {
+		return nil, fmt.Errorf("invalid merges: %w", err)
+	}
+	for rank, p := range pairs {
+		if _, ok := t.ranks[p]; !ok {
+			t.ranks[p] = rank
+		}
+	}
+
+	if f.Normalizer != nil {
+		if err := t.addNormalizer(*f.Normalizer); err != nil {
+			return nil, err
+		}
+	}
+	if f.PreTokenizer != nil {
+		if err := t.addPreTokenizer(*f.PreTokenizer); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+func nonEmpty(s *string) bool { return s != nil && *s != "" }
+
+func (t *hfTokenizer) addNormalizer(c hfComponent) error {
+	switch c.Type {
+	case "Sequence":
+		for _, n := range c.Normalizers {
+			if err := t.addNormalizer(n); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "Prepend":
+		if strings.Contains(c.Prepend, "▁") {
+			t.spaceMark = "▁"
+		}
+		t.normalizers = append(t.normalizers, func(s string) string {
+			if s == "" {
+				return s
+			}
+			return c.Prepend + s
+		})
+	case "Replace":
+		re, err := c.pattern()
+		if err != nil {
+			return err
+		}
+		if c.Content == "▁" {
+			t.spaceMark = "▁"
+		}
+		t.normalizers = append(t.normalizers, func(s string) string {
+			out, err := re.Replace(s, c.Content, -1, -1)
+			if err != nil {
+				return s
+			}
+			return out
+		})
+	case "NFC":
+		t.normalizers = append(t.normalizers, norm.NFC.String)
+	case "NFD":
+		t.normalizers = append(t.normalizers, norm.NFD.String)
+	case "NFKC":
+		t.normalizers = append(t.normalizers, norm.NFKC.String)
+	case "NFKD":
+		t.normalizers = append(t.normalizers, norm.NFKD.String)
+	case "Lowercase":
+		t.normalizers = append(t.normalizers, strings.ToLower)
+	case "Strip":
+		t.normalizers = append(t.normalizers, func(s string) string {
+			if c.StripLeft {
+				s = strings.TrimLeftFunc(s, unicode.IsSpace)
+			}
+			if c.StripRight {
+				s = strings.TrimRightFunc(s, unicode.IsSpace)
+			}
+			return s
+		})
+	default:
+		return fmt.Errorf("unsupported normalizer %q", c.Type)
+	}
+	return nil
+}
+
+func (t *hfTokenizer) addPreTokenizer(c hfComponent) error {
+	switch c.Type {
+	case "Sequence":
+		for _, p := range c.Pretokenizers {
+			if err := t.addPreTokenizer(p); err != nil {
+				return err
+			}
+		}
+	case "Split":
+		re, err := c.pattern()
+		if err != nil {
+			return err
+		}
+		t.preTokenizers = append(t.preTokenizers, eachPiece(func(s string) []string {
+			return splitPattern(re, s, c.Behavior, c.Invert)
+		}))
+	case "ByteLevel":
+		t.byteLevel = true
+		addPrefix := c.AddPrefixSpace != nil && *c.AddPrefixSpace
+		useRegex := c.UseRegex == nil || *c.UseRegex
+		re := regexp2.MustCompile(gpt2Pattern, regexp2.None)
+		t.preTokenizers = append(t.preTokenizers, func(pieces []string) []string {
+			var out []string
+			for i, p := range pieces {
+				if addPrefix && i == 0 && !strings.HasPrefix(p, " ") {
+					p = " " + p
+				}
+				words := []string{p}
+				if useRegex {
+					words = splitPattern(re, p, "Isolated", false)
+				}
+				for _, w := range words {
+					out = append(out, byteLevelEncode(w))
+				}
+			}
+			return out
+		})
+	case "Metaspace":
+		mark := c.Replacement
+		if mark == "" {
+			mark = "▁"
+		}
+		t.spaceMark = mark
+		scheme := c.PrependScheme
+		if scheme == "" {
+			scheme = "always"
+			if c.AddPrefixSpace != nil && !*c.AddPrefixSpace {
+				scheme = "never"
+			}
+		}
+		split := c.Split == nil || *c.Split
+		t.preTokenizers = append(t.preTokenizers, func(pieces []string) []string {
+			var out []string
+			for i, p := range pieces {
+				p = strings.ReplaceAll(p, " ", mark)
+				if (scheme == "always" || scheme == "first" && i == 0) && p != "" && !strings.HasPrefix(p, mark) {
+					p = mark + p
+				}
+				if split {
+					out = append(out, splitBeforeMark(p, mark)...)
+				} else {
+					out = append(out, p)
+				}
+			}
+			return out
+		})
+	case "Digits":
+		pattern := `\p{N}+`
+		if c.IndividualDigits {
+			pattern = `\p{N}`
+		}
+		re := regexp2.MustCompile(pattern, regexp2.None)
+		t.preTokenizers = append(t.preTokenizers, eachPiece(func(s string) []string {
+			return splitPattern(re, s, "Isolated", false)
+		}))
+	case "Whitespace":
+		re := regexp2.MustCompile(`\w+|[^\w\s]+`, regexp2.None)
+		t.preTokenizers = append(t.preTokenizers, eachPiece(func(s string) []string {
+			return splitPattern(re, s, "Isolated", true)
+		}))
+	case "WhitespaceSplit":
+		t.preTokenizers = append(t.preTokenizers, eachPiece(strings.Fields))
+	default:
+		return fmt.Errorf("unsupported pre-tokenizer %q", c.Type)
+	}
+	return nil
+}
+
+// pattern compiles a component's String or Regex pattern.
+func (c hfComponent) pattern() (*regexp2.Regexp, error) {
+	switch {
+	case c.Pattern.Regex != nil:
+		re, err := regexp2.Compile(*c.Pattern.Regex, regexp2.None)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s pattern: %w", c.Type, err)
+		}
+		return re, nil
+	case c.Pattern.String != nil:
+		return regexp2.MustCompile(regexp2.Escape(*c.Pattern.String), regexp2.None), nil
+	}
+	return nil, fmt.Errorf("%s without a pattern", c.Type)
+}
+
+func eachPiece(split func(string) []string) func([]string) []string {
+	return func(pieces []string) []string {
+		var out []string
+		for _, p := range pieces {
+			out = append(out, split(p)...)
+		}
+		return out
+	}
+}
+
+// splitPattern splits s around the matches of re. behavior is a HuggingFace split
+// behavior; with invert set the matches are the pieces and the gaps the delimiters.
+func splitPattern(re *regexp2.Regexp, s, behavior string, invert bool) []string {
+	runes := []rune(s)
+	type span struct {
+		start, end int
+		delimiter  bool
+	}
+	var spans []span
+	prev := 0
+	m, _ := re.FindRunesMatch(runes)
+	for m != nil {
+		if m.Length > 0 {
+			if m.Index > prev {
+				spans = append(spans, span{prev, m.Index, invert})
+			}
+			spans = append(spans, span{m.Index, m.Index + m.Length, !invert})
+			prev = m.Index + m.Length
+		}
+		m, _ = re.FindNextMatch(m)
+	}
+	if prev < len(runes) {
+		spans = append(spans, span{prev, len(runes), invert})
+	}
+
+	var out []string
+	pending := "" // delimiter waiting to be merged into the next piece
+	for _, sp := range spans {
+		text := string(runes[sp.start:sp.end])
+		switch {
+		case !sp.delimiter:
+			out = append(out, pending+text)
+			pending = ""
+		case behavior == "Removed":
+		case behavior == "MergedWithPrevious" && len(out) > 0:
+			out[len(out)-1] += text
+		case behavior == "MergedWithNext":
+			pending += text
+		default: // Isolated, Contiguous
+			out = append(out, text)
+		}
+	}
+	if pending != "" {
+		out = append(out, pending)
+	}
+	return out
+}
+
+// splitBeforeMark starts a new piece at every run of mark, as SentencePiece words do.
+func splitBeforeMark(s, mark string) []string {
+	var out []string
+	start := 0
+	for i := len(mark); i < len(s); {
+		j := strings.Index(s[i:], mark)
+		if j < 0 {
+			break
+		}
+		i += j
+		if !strings.HasSuffix(s[:i], mark) {
+			out = append(out, s[start:i])
+			start = i
+		}
+		i += len(mark)
+	}
+	return append(out, s[start:])
+}
+
+// Encode returns the token ids for text. Special added tokens are encoded as plain text,
+// like tiktoken does when they are not explicitly allowed; both arguments are ignored.
+func (t *hfTokenizer) Encode(text string, _ []string, _ []string) []int {
+	var ids []int
+	for _, part := range t.splitAdded(text) {
+		if id, ok := t.addedIDs[part]; ok {
+			ids = append(ids, id)
+			continue
+		}
+		for _, n := range t.normalizers {
+			part = n(part)
+		}
+		pieces := []string{part}
+		for _, p := range t.preTokenizers {
+			pieces = p(pieces)
+		}
+		if len(t.preTokenizers) == 0 && t.spaceMark != "" {
+			// SentencePiece-style files without a pre-tokenizer: BPE per word keeps long texts fast
+			pieces = splitBeforeMark(part, t.spaceMark)
+		}
+		for _, p := range pieces {
+			ids = append(ids, t.word(p)...)
+		}
+	}
+	return ids
+}
+
+// splitAdded separates non-special added tokens from the text around them.
+func (t *hfTokenizer) splitAdded(text string) []string {
+	if len(t.added) == 0 {
+		return []string{text}
+	}
+	var parts []string
+	for text != "" {
+		at, token := -1, ""
+		for _, a := range t.added {
+			if i := strings.Index(text, a); i >= 0 && (at < 0 || i < at) {
+				at, token = i, a
+			}
+		}
+		if at < 0 {
+			break
+		}
+		if at > 0 {
+			parts = append(parts, text[:at])
+		}
+		parts = append(parts, token)
+		text = text[at+len(token):]
+	}
+	if text != "" {
+		parts = append(parts, text)
+	}
+	return parts
+}
+
+// word applies the BPE merges to one pre-tokenized piece.
+func (t *hfTokenizer) word(w string) []int {
+	if w == "" {
+		return nil
+	}
+	t.mu.Lock()
+	ids, ok := t.cache[w]
+	t.mu.Unlock()
+	if ok {
+		return ids
+	}
+
+	if id, ok := t.vocab[w]; ok && t.ignoreMerges {
+		ids = []int{id}
+	} else {
+		ids = t.merge(w)
+	}
+
+	t.mu.Lock()
+	if len(t.cache) >= maxWordCache {
+		clear(t.cache)
+	}
+	t.cache[w] = ids
+	t.mu.Unlock()
+	return ids
+}
+
+func (t *hfTokenizer) merge(w string) []int {
+	var symbols []string
+	for _, r := range w {
+		symbols = append(symbols, string(r))
+	}
+	for len(symbols) > 1 {
+		best, bestRank := -1, 0
+		for i := 0; i+1 < len(symbols); i++ {
+			if rank, ok := t.ranks[[2]string{symbols[i], symbols[i+1]}]; ok && (best < 0 || rank < bestRank) {
+				best, bestRank = i, rank
+			}
+		}
+		if best < 0 {
+			break
+		}
+		symbols[best] += symbols[best+1]
+		symbols = append(symbols[:best+1], symbols[best+2:]...)
+	}
+
+	var ids []int
+	for _, s := range symbols {
+		if id, ok := t.vocab[s]; ok {
+			ids = append(ids, id)
+			continue
+		}
+		if t.byteFallback {
+			for _, b := range []byte(s) {
+				if id, ok := t.vocab[fmt.Sprintf("<0x%02X>", b)]; ok {
+					ids = append(ids, id)
+				}
+			}
+			continue
+		}
+		if t.unk >= 0 {
+			ids = append(ids, t.unk)
+		}
+	}
+	return ids
+}
+
+// Decode turns token ids back into text.
+func (t *hfTokenizer) Decode(ids []int) string {
+	var b strings.Builder
+	for _, id := range ids {
+		token := t.tokens[id]
+		switch {
+		case t.byteLevel:
+			b.WriteString(token)
+		case t.byteFallback && len(token) == 6 && strings.HasPrefix(token, "<0x") && strings.HasSuffix(token, ">"):
+			if v, err := strconv.ParseUint(token[3:5], 16, 8); err == nil {
+				b.WriteByte(byte(v))
+			}
+		case t.spaceMark != "":
+			b.WriteString(strings.ReplaceAll(token, t.spaceMark, " "))
+		default:
+			b.WriteString(token)
+		}
+	}
+	if t.byteLevel {
+		return byteLevelDecode(b.String())
+	}
+	return b.String()
+}
+
+// byteToRune is GPT-2's reversible mapping of bytes to printable characters.
+var byteToRune, runeToByte = func() ([256]rune, map[rune]byte) {
+	var table [256]rune
+	inverse := make(map[rune]byte, 256)
+	n := 0
+	for b := 0; b < 256; b++ {
+		if b >= '!' && b <= '~' || b >= 0xA1 && b <= 0xAC || b >= 0xAE && b <= 0xFF {
+			table[b] = rune(b)
+		} else {
+			table[b] = rune(256 + n)
+			n++
+		}
+		inverse[table[b]] = byte(b)
+	}
+	return table, inverse
+}()
+
+func byteLevelEncode(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		b.WriteRune(byteToRune[s[i]])
+	}
+	return b.String()
+}
+
+func byteLevelDecode(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if v, ok := runeToByte[r]; ok {
+			b.WriteByte(v)
+		} else {
+			b.WriteRune(r) // added tokens are stored verbatim
+		}
+	}
+	return b.String()
+}
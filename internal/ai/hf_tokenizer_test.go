@@ -0,0 +1,135 @@
+package ai
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// byteLevelJSON is a GPT-2 style tokenizer covering "hello world", "hold", and "é".
+const byteLevelJSON = `{
+  "added_tokens": [{"id": 100, "content": "<|eot|>", "special": true}, {"id": 101, "content": "[[x]]", "special": false}],
+  "normalizer": null,
+  "pre_tokenizer": {"type": "ByteLevel", "add_prefix_space": false, "use_regex": true},
+  "model": {
+    "type": "BPE",
+    "vocab": {"h": 0, "e": 1, "l": 2, "o": 3, "Ġ": 4, "w": 5, "r": 6, "d": 7, "he": 8, "ll": 9, "hell": 10,
+      "hello": 11, "Ġw": 12, "or": 13, "Ġwor": 14, "ld": 15, "Ġworld": 16, "Ã": 17, "©": 18, "<": 19, "|": 20, ">": 21, "t": 22},
+    "merges": [["h", "e"], ["l", "l"], ["he", "ll"], ["hell", "o"], ["Ġ", "w"], ["o", "r"], ["Ġw", "or"], ["l", "d"], ["Ġwor", "ld"]]
+  }
+}`
+
+// llama3JSON is byteLevelJSON with Llama 3's regex split in front of the byte mapping.
+var llama3JSON = strings.Replace(byteLevelJSON,
+	`"pre_tokenizer": {"type": "ByteLevel", "add_prefix_space": false, "use_regex": true}`,
+	`"pre_tokenizer": {"type": "Sequence", "pretokenizers": [
+		{"type": "Split", "pattern": {"Regex": "(?i:'s|'t|'re|'ve|'m|'ll|'d)|[^\\r\\n\\p{L}\\p{N}]?\\p{L}+|\\p{N}{1,3}| ?[^\\s\\p{L}\\p{N}]+[\\r\\n]*|\\s*[\\r\\n]+|\\s+(?!\\S)|\\s+"}, "behavior": "Isolated", "invert": false},
+		{"type": "ByteLevel", "add_prefix_space": false, "use_regex": false}
+	]}`, 1)
+
+// metaspaceJSON is a Llama 2 style tokenizer with byte fallback.
+const metaspaceJSON = `{
+  "normalizer": {"type": "Sequence", "normalizers": [
+    {"type": "Prepend", "prepend": "▁"},
+    {"type": "Replace", "pattern": {"String": " "}, "content": "▁"}
+  ]},
+  "pre_tokenizer": null,
+  "model": {
+    "type": "BPE",
+    "unk_token": "<unk>",
+    "byte_fallback": true,
+    "vocab": {"<unk>": 0, "<0xC3>": 1, "<0xA9>": 2, "▁": 3, "h": 4, "i": 5, "▁h": 6, "▁hi": 7},
+    "merges": ["▁ h", "▁h i"]
+  }
+}`
+
+func writeTokenizer(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "tokenizer.json")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestHFTokenizer(t *testing.T) {
+	tests := []struct {
+		name       string
+		json       string
+		text       string
+		want       []int
+		wantDecode string
+	}{
+		{"byte-level words", byteLevelJSON, "hello world", []int{11, 16}, "hello world"},
+		{"byte-level partial merges", byteLevelJSON, "hold", []int{0, 3, 15}, "hold"},
+		{"byte-level multi-byte", byteLevelJSON, "é", []int{17, 18}, "é"},
+		{"added token", byteLevelJSON, "hello[[x]]world", []int{11, 101, 5, 13, 15}, "hello[[x]]world"},
+		{"special token is text", byteLevelJSON, "<|eot|>", []int{19, 20, 1, 3, 22, 20, 21}, "<|eot|>"},
+		{"regex split", llama3JSON, "hello world", []int{11, 16}, "hello world"},
+		{"regex split partial merges", llama3JSON, "hold  hello", []int{0, 3, 15, 4, 4, 11}, "hold  hello"},
+		{"metaspace", metaspaceJSON, "hi hi", []int{7, 7}, " hi hi"},
+		{"byte fallback", metaspaceJSON, "hi é", []int{7, 3, 1, 2}, " hi é"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tok, err := NewTokenizer(writeTokenizer(t, tt.json))
+			if err != nil {
+				t.Fatalf("NewTokenizer: %v", err)
+			}
+			got := tok.encoding.Encode(tt.text, nil, nil)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Encode(%q) = %v, want %v", tt.text, got, tt.want)
+			}
+			if n := tok.CountTokens(tt.text); n != len(tt.want) {
+				t.Errorf("CountTokens(%q) = %d, want %d", tt.text, n, len(tt.want))
+			}
+			if decoded := tok.encoding.Decode(got); decoded != tt.wantDecode {
+				t.Errorf("Decode = %q, want %q", decoded, tt.wantDecode)
+			}
+		})
+	}
+}
+
+func TestHFTokenizerUnsupported(t *testing.T) {
+	tests := []struct {
+		name, json, wantErr string
+	}{
+		{"unigram", `{"model": {"type": "Unigram", "vocab": []}}`, "unsupported model type"},
+		{"normalizer", `{"normalizer": {"type": "BertNormalizer"}, "model": {"type": "BPE", "vocab": {}, "merges": []}}`, "unsupported normalizer"},
+		{"not json", `vocab`, "invalid tokenizer.json"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewTokenizer(writeTokenizer(t, tt.json))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("NewTokenizer error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSplitPattern(t *testing.T) {
+	comma := ","
+	c := hfComponent{Type: "Split"}
+	c.Pattern.String = &comma
+	pattern, err := c.pattern()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		behavior string
+		want     []string
+	}{
+		{"Isolated", []string{"a", ",", "b", ",", "c"}},
+		{"Removed", []string{"a", "b", "c"}},
+		{"MergedWithPrevious", []string{"a,", "b,", "c"}},
+		{"MergedWithNext", []string{"a", ",b", ",c"}},
+	}
+	for _, tt := range tests {
+		if got := splitPattern(pattern, "a,b,c", tt.behavior, false); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: splitPattern = %q, want %q", tt.behavior, got, tt.want)
+		}
+	}
+}
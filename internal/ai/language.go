@@ -0,0 +1,141 @@
+package ai
+
+import (
+	"strings"
+	"unicode"
+)
+
+// languageSampleRunes bounds how much text is inspected for language detection.
+const languageSampleRunes = 4000
+
+// LanguageEnglish is returned for English text and is the language no prompt hint is added for.
+const LanguageEnglish = "English"
+
+// scriptLanguages maps non-Latin scripts to the language reported for them.
+var scriptLanguages = []struct {
+	table    *unicode.RangeTable
+	language string
+}{
+	{unicode.Hangul, "Korean"},
+	{unicode.Cyrillic, "Russian"},
+	{unicode.Arabic, "Arabic"},
+	{unicode.Hebrew, "Hebrew"},
+	{unicode.Greek, "Greek"},
+	{unicode.Devanagari, "Hindi"},
+	{unicode.Thai, "Thai"},
+}
+
+// stopwords holds frequent function words used to tell Latin-script languages apart.
+var stopwords = map[string][]string{
+	LanguageEnglish: {"the", "and", "of", "to", "is", "in", "for", "with", "this", "that", "you", "are"},
+	"German":        {"der", "die", "das", "und", "ist", "nicht", "mit", "für", "ein", "eine", "sie", "auf"},
+	"French":        {"le", "la", "les", "et", "est", "des", "une", "pour", "dans", "pas", "vous", "avec"},
+	"Spanish":       {"el", "la", "los", "las", "y", "es", "una", "para", "con", "por", "del", "que"},
+	"Italian":       {"il", "di", "che", "e", "per", "una", "sono", "della", "con", "non", "gli", "nel"},
+	"Portuguese":    {"o", "os", "as", "e", "uma", "para", "com", "não", "do", "da", "em", "que"},
+	"Dutch":         {"de", "het", "een", "en", "van", "is", "niet", "met", "voor", "op", "zijn", "dat"},
+}
+
+// DetectLanguage guesses the dominant language of text. It uses Unicode scripts for
+// non-Latin writing systems and stopword frequency for Latin ones, returning "" when unsure.
+func DetectLanguage(text string) string {
+	var letters, latin, han, kana int
+	scripts := make(map[string]int)
+
+	n := 0
+	for _, r := range text {
+		if n >= languageSampleRunes {
+			break
+		}
+		n++
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		switch {
+		case unicode.Is(unicode.Latin, r):
+			latin++
+		case unicode.Is(unicode.Han, r):
+			han++
+		case unicode.Is(unicode.Hiragana, r) || unicode.Is(unicode.Katakana, r):
+			kana++
+		default:
+			for _, s := range scriptLanguages {
+				if unicode.Is(s.table, r) {
+					scripts[s.language]++
+					break
+				}
+			}
+		}
+	}
+
+	if letters == 0 {
+		return ""
+	}
+
+	// Non-Latin scripts win if they make up a meaningful share of the letters
+	threshold := letters * 3 / 10
+	if kana > 0 && han+kana > threshold {
+		return "Japanese"
+	}
+	if han > threshold {
+		return "Chinese"
+	}
+	best, bestCount := "", 0
+	for lang, count := range scripts {
+		if count > bestCount {
+			best, bestCount = lang, count
+		}
+	}
+	if bestCount > threshold {
+		return best
+	}
+	if latin == 0 {
+		return ""
+	}
+
+	return detectLatinLanguage(text)
+}
+
+// detectLatinLanguage picks the Latin-script language whose stopwords occur most often.
+func detectLatinLanguage(text string) string {
+	sample := text
+	if len(sample) > languageSampleRunes*2 {
+		sample = sample[:languageSampleRunes*2]
+	}
+
+	counts := make(map[string]int)
+	for _, word := range strings.FieldsFunc(strings.ToLower(sample), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	}) {
+		counts[word]++
+	}
+
+	best, bestScore, runnerUp := "", 0, 0
+	for lang, words := range stopwords {
+		score := 0
+		for _, w := range words {
+			score += counts[w]
+		}
+		if score > bestScore {
+			best, bestScore, runnerUp = lang, score, bestScore
+		} else if score > runnerUp {
+			runnerUp = score
+		}
+	}
+
+	// Require a few hits and a clear margin before committing to an answer
+	if bestScore < 3 || bestScore*4 < runnerUp*5 {
+		return ""
+	}
+	return best
+}
+
+// languageInstruction returns the system prompt addition for documents in language, if any.
+func languageInstruction(language string) string {
+	if language == "" || language == LanguageEnglish {
+		return ""
+	}
+	return "\nThe document is written in " + language + ". Still choose the category exactly as listed above, " +
+		"and write the title in English (or transliterate it to Latin letters) using only ASCII characters."
+}
@@ -0,0 +1,45 @@
+package ai
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDetectLanguage(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"English", "This is the invoice for the services provided to you and the team in March.", "English"},
+		{"German", "Die Rechnung ist für die Leistungen, die wir mit der Firma und für das Team erbracht haben.", "German"},
+		{"French", "Voici la facture pour les services et les produits dans le mois de mars, avec des remarques.", "French"},
+		{"Spanish", "La factura de los servicios para el cliente y las tareas del mes, con el detalle que se pidió.", "Spanish"},
+		{"Japanese", "これは三月の請求書です。ご確認ください。", "Japanese"},
+		{"Chinese", "这是三月份的发票，请查收。", "Chinese"},
+		{"Korean", "이것은 3월 청구서입니다. 확인 부탁드립니다.", "Korean"},
+		{"Russian", "Это счёт за услуги, оказанные в марте.", "Russian"},
+		{"Numbers only", "12345 67890", ""},
+		{"Too little signal", "Invoice 42", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DetectLanguage(tt.text); got != tt.want {
+				t.Errorf("DetectLanguage() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLanguageInstruction(t *testing.T) {
+	if got := languageInstruction(LanguageEnglish); got != "" {
+		t.Errorf("expected no instruction for English, got %q", got)
+	}
+	if got := languageInstruction(""); got != "" {
+		t.Errorf("expected no instruction for unknown language, got %q", got)
+	}
+	if got := languageInstruction("German"); !strings.Contains(got, "German") || !strings.Contains(got, "ASCII") {
+		t.Errorf("unexpected instruction for German: %q", got)
+	}
+}
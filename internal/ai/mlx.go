@@ -4,14 +4,22 @@ import (
 	"bytes"
 	"context"
 	"docs_organiser/internal/config"
+	"docs_organiser/internal/logging"
 	"docs_organiser/internal/observability"
+	"docs_organiser/internal/storage"
+	"docs_organiser/internal/taxonomy"
 	"encoding/json"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
+	"slices"
 	"strings"
 	"sync"
+	"text/template"
 	"time"
+
+	"golang.org/x/text/unicode/norm"
 )
 
 // LLMClient defines the interface for interacting with any LLM server.
@@ -20,7 +28,8 @@ type LLMClient interface {
 	Endpoint() string
 }
 
-// NetLLMClient is the standard HTTP implementation of LLMClient.
+// NetLLMClient is the standard HTTP implementation of LLMClient. Requests go to the
+// server named in their APIURL, or to apiURL when they name none.
 type NetLLMClient struct {
 	client *http.Client
 	apiURL string
@@ -32,7 +41,11 @@ func (c *NetLLMClient) CreateChatCompletion(ctx context.Context, req chatRequest
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.apiURL, bytes.NewBuffer(jsonBody))
+	endpoint := c.apiURL
+	if req.APIURL != "" {
+		endpoint = chatCompletionsURL(req.APIURL)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewBuffer(jsonBody))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -69,7 +82,33 @@ type MLXEngine struct {
 	ctxMgr           *ContextManager
 	router           *ModelRouter
 	validCategories  []string
-	mu               sync.RWMutex
+	taxonomy         *taxonomy.Taxonomy
+	descriptions     map[string]string          // lowercase category path -> description
+	aliases          map[string]string          // lowercase NFC name -> category it stands for
+	profiles         map[string]categoryProfile // taxonomy path -> extra rules
+	twoStage         bool
+	votes            int // samples per classification; <= 1 disables voting
+	voteTemperature  float64
+	clarifyMin       float64 // clarification gray zone: clarifyMin <= confidence < clarifyMax
+	clarifyMax       float64 // <= 0 disables the clarification pass
+	sampling         Sampling
+	systemTemplate   *template.Template // replaces the built-in classification prompt
+	logprobs         bool
+	summaryCache     storage.Store
+	truncation       TruncationStrategy // for documents over the content budget; map_reduce when empty
+	heuristics       *heuristicRules
+	debug            bool
+	debugLLM         bool // log every request and response (see SetDebugLLM)
+	breaker          *breaker
+	describe         bool
+	transport        *http.Transport
+	// configuredContext is the user's context window, restored when auto-detection fails
+	configuredContext int
+	headers           map[string]string
+	headersMu         sync.RWMutex
+	mu                sync.RWMutex
+	// requests bounds the requests in flight when set (see SetMaxConcurrentRequests)
+	requests chan struct{}
 }
 
 // CategorizationMetadata holds telemetry and usage data for a request.
@@ -80,8 +119,27 @@ type CategorizationMetadata struct {
 	ResponseTokens int           `json:"response_tokens"`
 	TotalTokens    int           `json:"total_tokens"`
 	TruncationType string        `json:"truncation_type"`
+	SummaryCached  bool          `json:"summary_cached,omitempty"`
+	Language       string        `json:"language,omitempty"`
 	Attempts       int           `json:"attempts"`
-	Success        bool          `json:"success"`
+	// CoarseCategory is the top-level folder chosen by the first pass of two-stage classification.
+	CoarseCategory string `json:"coarse_category,omitempty"`
+	// Votes counts the categories returned by self-consistency samples; Agreement is the
+	// winning category's share of all samples, failed ones included.
+	Votes     map[string]int `json:"votes,omitempty"`
+	Agreement float64        `json:"agreement,omitempty"`
+	// Clarification is the follow-up choice between the two likeliest categories of an
+	// answer in the gray zone (see SetClarification).
+	Clarification *Clarification `json:"clarification,omitempty"`
+	// FastPath marks a classification made from the file name and metadata alone.
+	FastPath bool `json:"fast_path,omitempty"`
+	// ReportedConfidence is the model's own confidence_score when Analysis.ConfidenceScore
+	// was replaced by the probability of the category tokens (see SetLogprobs).
+	ReportedConfidence   float64 `json:"reported_confidence,omitempty"`
+	CalibratedConfidence float64 `json:"calibrated_confidence,omitempty"`
+	Success              bool    `json:"success"`
+	// FailedResponses holds the (truncated) raw output of attempts that could not be parsed.
+	FailedResponses []string `json:"failed_responses,omitempty"`
 }
 
 // CategorizationResult combines the AI response with metadata.
@@ -95,8 +153,23 @@ type AnalysisResult struct {
 	Category        string  `json:"category"`
 	Title           string  `json:"title"`
 	ConfidenceScore float64 `json:"confidence_score"`
+	// Tags and Summary are only requested when descriptions are enabled (see SetDescribe).
+	Tags    []string `json:"tags,omitempty"`
+	Summary string   `json:"summary,omitempty"`
+	// DocumentDate is the issue date stated in the document, as YYYY-MM-DD, if any.
+	DocumentDate string `json:"document_date,omitempty"`
+
+	// reportedConfidence keeps the model's confidence_score once ConfidenceScore is calibrated.
+	reportedConfidence float64
 }
 
+// maxTags bounds how many tags are kept from a response.
+const maxTags = 8
+
+// describeInstruction asks for the optional descriptive fields.
+const describeInstruction = "\nAlso include \"tags\": an array of up to 5 short lowercase keywords, " +
+	"and \"summary\": one or two sentences describing the document."
+
 // DefaultCategories defines the fallback destination folders.
 var DefaultCategories = []string{
 	"Personal", "Work", "Finance", "Health", "Education", "Technical",
@@ -109,6 +182,13 @@ type chatRequest struct {
 	Messages    []message `json:"messages"`
 	Stream      bool      `json:"stream"`
 	Temperature float64   `json:"temperature"`
+	TopP        float64   `json:"top_p,omitempty"`
+	MaxTokens   int       `json:"max_tokens,omitempty"`
+	Stop        []string  `json:"stop,omitempty"`
+	Logprobs    bool      `json:"logprobs,omitempty"`
+	// APIURL is the base URL of the server the request is sent to; chat fills it in
+	// from the model when empty.
+	APIURL string `json:"-"`
 }
 
 type message struct {
@@ -129,7 +209,8 @@ type Usage struct {
 }
 
 type choice struct {
-	Message message `json:"message"`
+	Message  message         `json:"message"`
+	Logprobs *choiceLogprobs `json:"logprobs,omitempty"`
 }
 
 // NewMLXEngine creates a new instance of the engine.
@@ -149,14 +230,15 @@ func NewMLXEngine(apiURL string, allowedModels []config.ModelDefinition, maxToke
 	}
 
 	engine := &MLXEngine{
-		llm: &NetLLMClient{
-			client: &http.Client{
-				Timeout: 60 * time.Second,
-			},
-		},
 		models:          allowedModels,
 		ctxMgr:          ctxMgr,
 		validCategories: DefaultCategories,
+		sampling:        defaultSampling,
+		transport:       http.DefaultTransport.(*http.Transport).Clone(),
+	}
+	engine.configuredContext = ctxMgr.maxTokens
+	engine.llm = &NetLLMClient{
+		client: engine.httpClient(60 * time.Second),
 	}
 	engine.router = NewModelRouter(engine)
 	return engine, nil
@@ -171,6 +253,17 @@ func (e *MLXEngine) SetCategories(categories []string) {
 	}
 }
 
+// SetTaxonomy restricts categories to the taxonomy's paths and adds its descriptions
+// and category profiles to the prompt. Answers naming a unique sub-folder ("Taxes") are
+// mapped to its full path.
+func (e *MLXEngine) SetTaxonomy(t *taxonomy.Taxonomy) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.taxonomy = t
+	e.profiles = profilesFrom(t)
+	e.validCategories = t.Paths()
+}
+
 // GetCategories returns the current list of allowed categories.
 func (e *MLXEngine) GetCategories() []string {
 	e.mu.RLock()
@@ -178,11 +271,46 @@ func (e *MLXEngine) GetCategories() []string {
 	return e.validCategories
 }
 
+// SetDebug enables logging of raw model responses that fail validation.
+func (e *MLXEngine) SetDebug(enabled bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.debug = enabled
+}
+
+// SetDescribe makes categorization also ask for tags and a short summary,
+// at the cost of a longer response.
+func (e *MLXEngine) SetDescribe(enabled bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.describe = enabled
+}
+
 // ContextWindow returns the maximum tokens allowed for the current context.
 func (e *MLXEngine) ContextWindow() int {
 	return e.ctxMgr.maxTokens
 }
 
+// SetTruncationStrategy chooses how documents over the content budget are shortened:
+// map_reduce (summarize with the model), salience, middle_extraction, or sliding_window.
+func (e *MLXEngine) SetTruncationStrategy(strategy string) error {
+	s := TruncationStrategy(strategy)
+	switch s {
+	case "", StrategyMapReduce, StrategySalience, StrategyMiddleExtraction, StrategySlidingWindow:
+	default:
+		return fmt.Errorf("unknown truncation strategy %q (use map_reduce, salience, middle_extraction, or sliding_window)", strategy)
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.truncation = s
+	return nil
+}
+
+// SetChunkOverlap sets how many tokens of whole sentences consecutive summarization chunks share.
+func (e *MLXEngine) SetChunkOverlap(tokens int) {
+	e.ctxMgr.SetChunkOverlap(tokens)
+}
+
 // SetDefaultModel sets the preferred model to use if available.
 func (e *MLXEngine) SetDefaultModel(name string) {
 	e.mu.Lock()
@@ -213,6 +341,16 @@ func (e *MLXEngine) GetURLForModel(name string) string {
 
 // GetAvailableModelsForURL probes a specific endpoint for active models.
 func (e *MLXEngine) GetAvailableModelsForURL(ctx context.Context, apiURL string) ([]string, error) {
+	return listModels(ctx, e.httpClient(5*time.Second), apiURL)
+}
+
+// ListModels lists the models an endpoint serves, without an engine's TLS settings or
+// headers; it suits callers that have no engine, such as the config wizard.
+func ListModels(ctx context.Context, apiURL string) ([]string, error) {
+	return listModels(ctx, &http.Client{Timeout: 5 * time.Second}, apiURL)
+}
+
+func listModels(ctx context.Context, client *http.Client, apiURL string) ([]string, error) {
 	if apiURL == "" {
 		return nil, fmt.Errorf("empty API URL")
 	}
@@ -222,7 +360,6 @@ func (e *MLXEngine) GetAvailableModelsForURL(ctx context.Context, apiURL string)
 		return nil, err
 	}
 
-	client := &http.Client{Timeout: 5 * time.Second}
 	resp, err := client.Do(req)
 	if err != nil {
 		return nil, err
@@ -249,6 +386,109 @@ func (e *MLXEngine) GetAvailableModelsForURL(ctx context.Context, apiURL string)
 	return models, nil
 }
 
+// Preflight verifies that a configured model is reachable and loaded before a run starts,
+// returning the model that will be used. If no configured model is loaded but an endpoint
+// serves exactly one model, that model is adopted as the default.
+func (e *MLXEngine) Preflight(ctx context.Context) (string, error) {
+	e.mu.RLock()
+	models := append([]config.ModelDefinition(nil), e.models...)
+	defaultModel := e.defaultModelName
+	e.mu.RUnlock()
+
+	// Check the default model first
+	for i, m := range models {
+		if m.Name == defaultModel && i > 0 {
+			models[0], models[i] = models[i], models[0]
+			break
+		}
+	}
+
+	var problems []string
+	var adopt *config.ModelDefinition
+	for _, m := range models {
+		available, err := e.GetAvailableModelsForURL(ctx, m.URL)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s unreachable: %v", m.URL, err))
+			continue
+		}
+		for _, avail := range available {
+			if avail == m.Name {
+				return m.Name, nil
+			}
+		}
+		problems = append(problems, fmt.Sprintf("model %q not loaded at %s (available: %s)", m.Name, m.URL, strings.Join(available, ", ")))
+		if len(available) == 1 && adopt == nil {
+			adopt = &config.ModelDefinition{Name: available[0], URL: m.URL}
+		}
+	}
+
+	if adopt != nil {
+		log.Printf("[*] Configured model not loaded; using the only model served at %s: %s", adopt.URL, adopt.Name)
+		e.mu.Lock()
+		e.models = append([]config.ModelDefinition{*adopt}, e.models...)
+		e.defaultModelName = adopt.Name
+		e.mu.Unlock()
+		return adopt.Name, nil
+	}
+
+	if len(problems) == 0 {
+		return "", fmt.Errorf("no models configured")
+	}
+	return "", fmt.Errorf("no configured model is available: %s", strings.Join(problems, "; "))
+}
+
+// ReleaseResources drops pooled connections and the cached token counts and, when
+// unloadModels is set, asks Ollama-compatible servers to evict the configured models from
+// memory (keep_alive: 0). All are re-acquired transparently by the next request. Cached
+// summaries are kept: they live in the database, to survive retries and resumed runs.
+func (e *MLXEngine) ReleaseResources(ctx context.Context, unloadModels bool) {
+	if net, ok := e.llm.(*NetLLMClient); ok {
+		net.client.CloseIdleConnections()
+	}
+	e.mu.RLock()
+	models, ctxMgr := e.models, e.ctxMgr
+	e.mu.RUnlock()
+	if ctxMgr != nil && ctxMgr.tokenizer != nil {
+		ctxMgr.tokenizer.ResetCounts()
+	}
+	if !unloadModels {
+		return
+	}
+
+	for _, m := range models {
+		if err := e.unloadModel(ctx, m); err != nil {
+			log.Printf("[!] Failed to unload model %s: %v", m.Name, err)
+		}
+	}
+}
+
+// unloadModel sends a keep_alive: 0 request to the native Ollama API behind an OpenAI-compatible URL.
+func (e *MLXEngine) unloadModel(ctx context.Context, m config.ModelDefinition) error {
+	baseURL := strings.TrimSuffix(strings.TrimRight(m.URL, "/"), "/v1")
+	body, err := json.Marshal(map[string]interface{}{"model": m.Name, "keep_alive": 0})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", baseURL+"/api/generate", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := e.httpClient(10 * time.Second)
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unload request to %s: status %d", baseURL, resp.StatusCode)
+	}
+	return nil
+}
+
 func (e *MLXEngine) selectBestModel(ctx context.Context) (string, string) {
 	e.mu.RLock()
 	models := e.models
@@ -292,26 +532,42 @@ func (e *MLXEngine) selectBestModel(ctx context.Context) (string, string) {
 	return "", ""
 }
 
+// Source describes where a document was found: its original file name and the directory
+// it was in, relative to the source root ("" at the top).
+type Source struct {
+	Name string
+	Dir  string
+}
+
+// prompt renders the source as context ahead of the document text, or "" when unknown.
+func (s Source) prompt() string {
+	if s.Name == "" && s.Dir == "" {
+		return ""
+	}
+	var b strings.Builder
+	if s.Name != "" {
+		fmt.Fprintf(&b, "Original file name: %s\n", s.Name)
+	}
+	if s.Dir != "" {
+		fmt.Fprintf(&b, "Source directory: %s\n", s.Dir)
+	}
+	b.WriteString("These are hints; when they disagree with the document text, the text decides.\n\n")
+	return b.String()
+}
+
 // Categorize analyzes the text and returns a folder category and cleaned filename.
 func (e *MLXEngine) Categorize(ctx context.Context, text string) (*CategorizationResult, error) {
-	startTime := time.Now()
-
-	// 1. Classify Task Complexity
-	complexity, _ := e.router.ClassifyTask(ctx, text)
+	return e.CategorizeSource(ctx, Source{}, text)
+}
 
-	// 2. Select the Best Model for this task
-	modelName, apiURL := e.router.SelectBestModel(ctx, complexity)
-	if modelName == "" {
-		return nil, fmt.Errorf("no model available")
-	}
+// CategorizeSource is Categorize with the document's file name and source directory in
+// the prompt, since names like HDFC_Statement_Mar2024.pdf often say what a document is.
+func (e *MLXEngine) CategorizeSource(ctx context.Context, source Source, text string) (*CategorizationResult, error) {
+	startTime := time.Now()
 
-	// For NetLLMClient, we need to inject the specific URL for this request
-	if net, ok := e.llm.(*NetLLMClient); ok {
-		fullURL := strings.TrimRight(apiURL, "/")
-		if !strings.HasSuffix(fullURL, "/chat/completions") {
-			fullURL += "/chat/completions"
-		}
-		net.apiURL = fullURL
+	modelName, apiURL, err := e.selectModelFor(ctx, text)
+	if err != nil {
+		return nil, err
 	}
 
 	metadata := &CategorizationMetadata{
@@ -320,34 +576,162 @@ func (e *MLXEngine) Categorize(ctx context.Context, text string) (*Categorizatio
 		Success:        false,
 	}
 
-	systemPrompt := fmt.Sprintf(`You are an intelligent file organization assistant. Analyze the document text and return a SINGLE JSON object.
-Required format: {"category": "Specific_Category_Name", "title": "Clean_Filename_No_Ext", "confidence_score": 0.0-1.0}
-Strictly choose category from: %s
-Nested paths like "Parent/Child" are valid if they exist in the list above.
-Required confidence_score: a float between 0.0 and 1.0.
-Do NOT return extra fields. Do NOT return markdown. Do NOT return extra text.`, strings.Join(e.validCategories, ", "))
+	// Non-English documents tend to get titles the filename sanitizer can't keep, so ask for English/ASCII
+	metadata.Language = DetectLanguage(text)
 
-	systemBudget, _, contentBudget, _ := e.ctxMgr.GetBudgets()
-	systemPrompt = e.ctxMgr.Truncate(systemPrompt, systemBudget, StrategySlidingWindow)
-	// We don't record sliding window for system prompt as it's static/small usually
+	e.mu.RLock()
+	categories := e.validCategories
+	truncation := e.truncation
+	e.mu.RUnlock()
 
+	_, _, contentBudget, _ := e.ctxMgr.GetBudgets()
 	currentTokens := e.ctxMgr.tokenizer.CountTokens(text)
 	if currentTokens > contentBudget {
-		metadata.TruncationType = string(StrategyMapReduce)
-		summary, err := e.MapReduceSummarize(ctx, text, contentBudget, modelName, apiURL)
-		if err != nil {
-			metadata.TruncationType = string(StrategyMiddleExtraction)
-			text = e.ctxMgr.Truncate(text, contentBudget, StrategyMiddleExtraction)
-		} else {
-			text = summary
+		logging.Verbosef("[*] Document is %d tokens, over the %d-token content budget; shortening with %s", currentTokens, contentBudget, truncation)
+		switch truncation {
+		case "", StrategyMapReduce:
+			metadata.TruncationType = string(StrategyMapReduce)
+			summary, cached, err := e.cachedSummarize(ctx, text, contentBudget, modelName, apiURL)
+			metadata.SummaryCached = cached
+			if err != nil {
+				metadata.TruncationType = string(StrategyMiddleExtraction)
+				text = e.ctxMgr.Truncate(text, contentBudget, StrategyMiddleExtraction)
+			} else {
+				text = summary
+			}
+		case StrategySalience:
+			metadata.TruncationType = string(StrategySalience)
+			text = e.ctxMgr.TruncateSalient(text, contentBudget, e.categoryVocabulary(categories))
+		default:
+			metadata.TruncationType = string(truncation)
+			text = e.ctxMgr.Truncate(text, contentBudget, truncation)
 		}
 		observability.TruncationEventsTotal.WithLabelValues(modelName, metadata.TruncationType).Inc()
 	}
 
-	userPrompt := fmt.Sprintf("Document text snippet:\n%s", text)
+	userPrompt := fmt.Sprintf("%sDocument text snippet:\n%s", source.prompt(), text)
+	return e.decide(ctx, startTime, modelName, userPrompt, metadata)
+}
 
+// selectModelFor picks the model for text through the router and returns it with the
+// URL of its server.
+func (e *MLXEngine) selectModelFor(ctx context.Context, text string) (modelName, apiURL string, err error) {
+	b := e.circuit()
+	if err := b.wait(ctx); err != nil {
+		return "", "", err
+	}
+	// 1. Classify Task Complexity
+	complexity, _ := e.router.ClassifyTask(ctx, text)
+	// 2. Select the Best Model for this task
+	modelName, apiURL = e.router.SelectBestModel(ctx, complexity)
+	if modelName == "" {
+		err := fmt.Errorf("no model available")
+		b.fail(err)
+		return "", "", err
+	}
+	b.record(nil)
+	return modelName, apiURL, nil
+}
+
+// chatCompletionsURL returns the chat completions endpoint of the server at apiURL.
+func chatCompletionsURL(apiURL string) string {
+	fullURL := strings.TrimRight(apiURL, "/")
+	if !strings.HasSuffix(fullURL, "/chat/completions") {
+		fullURL += "/chat/completions"
+	}
+	return fullURL
+}
+
+// WarmUp sends model a prompt of a few tokens, so that the server loads or compiles it
+// now rather than while the first document's request times out. It returns how long the
+// answer took.
+func (e *MLXEngine) WarmUp(ctx context.Context, model string) (time.Duration, error) {
+	start := time.Now()
+	resp, err := e.chat(ctx, chatRequest{
+		Model:     model,
+		Messages:  []message{{Role: "user", Content: "Reply with OK."}},
+		MaxTokens: 4,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("warm-up request to %s failed: %w", model, err)
+	}
+	if len(resp.Choices) == 0 {
+		return 0, fmt.Errorf("warm-up request to %s got no answer", model)
+	}
+	return time.Since(start), nil
+}
+
+// decide classifies the document described by userPrompt with modelName, using two-stage
+// classification and voting when enabled, and fills in metadata.
+func (e *MLXEngine) decide(ctx context.Context, startTime time.Time, modelName, userPrompt string, metadata *CategorizationMetadata) (*CategorizationResult, error) {
+	e.mu.RLock()
+	categories, twoStage := e.validCategories, e.twoStage
+	votes, voteTemperature := e.votes, e.voteTemperature
+	temperature := e.sampling.Temperature
+	e.mu.RUnlock()
+
+	sample := func(temperature float64) (*AnalysisResult, error) {
+		if twoStage && len(topLevel(categories)) < len(categories) {
+			return e.classifyTwoStage(ctx, modelName, categories, userPrompt, temperature, metadata)
+		}
+		return e.classify(ctx, modelName, categories, userPrompt, temperature, metadata)
+	}
+
+	var result *AnalysisResult
+	var err error
+	if votes > 1 {
+		result, err = vote(votes, voteTemperature, sample, metadata)
+	} else {
+		result, err = sample(temperature)
+	}
+	if err == nil {
+		result = e.clarify(ctx, modelName, categories, userPrompt, temperature, result, metadata)
+	}
+
+	metadata.Latency = time.Since(startTime)
+	if err != nil {
+		// Escalation fallback path
+		return &CategorizationResult{
+			Analysis: &AnalysisResult{
+				Category:        "Misc",
+				Title:           "Unknown_Doc",
+				ConfidenceScore: 0.0,
+			},
+			Metadata: metadata,
+		}, fmt.Errorf("failed to get valid structured output after %d retries using model %s: %w", maxRetries, modelName, err)
+	}
+	if result.reportedConfidence > 0 {
+		metadata.ReportedConfidence = result.reportedConfidence
+		metadata.CalibratedConfidence = result.ConfidenceScore
+	}
+	metadata.Success = true
+	observability.LLMRequestDuration.WithLabelValues(modelName, "categorization").Observe(metadata.Latency.Seconds())
+	return &CategorizationResult{
+		Analysis: result,
+		Metadata: metadata,
+	}, nil
+}
+
+// maxRetries is how many times an invalid answer is sent back to the model for correction.
+const maxRetries = 2
+
+// defaultTemperature keeps single-sample classification close to deterministic.
+const defaultTemperature = 0.1
+
+// classify asks the model to choose one of categories for the document in userPrompt,
+// feeding validation errors back for up to maxRetries corrections. Usage and failed
+// responses are accumulated into metadata.
+func (e *MLXEngine) classify(ctx context.Context, modelName string, categories []string, userPrompt string, temperature float64, metadata *CategorizationMetadata) (*AnalysisResult, error) {
+	systemPrompt, err := e.systemPrompt(categories, metadata.Language)
+	if err != nil {
+		return nil, err
+	}
+
+	e.mu.RLock()
+	sampling, logprobs := e.sampling, e.logprobs
+	debug := e.debug || e.debugLLM
+	e.mu.RUnlock()
 	var lastErr error
-	maxRetries := 2
 
 	// Correction loop
 	for attempt := 0; attempt <= maxRetries; attempt++ {
@@ -373,10 +757,18 @@ Do NOT return extra fields. Do NOT return markdown. Do NOT return extra text.`,
 			Model:       modelName,
 			Messages:    messages,
 			Stream:      false,
-			Temperature: 0.1,
+			Temperature: temperature,
+			TopP:        sampling.TopP,
+			MaxTokens:   e.maxTokens(),
+			Stop:        sampling.Stop,
+			Logprobs:    logprobs,
 		}
 
-		chatResp, err := e.llm.CreateChatCompletion(ctx, reqBody)
+		if logging.Verbose() {
+			log.Printf("[*] Prompt to %s (attempt %d): %d system + %d user tokens", modelName, metadata.Attempts,
+				e.ctxMgr.tokenizer.CountTokens(systemPrompt), e.ctxMgr.tokenizer.CountTokens(userPrompt))
+		}
+		chatResp, err := e.chat(ctx, reqBody)
 		if err == nil && len(chatResp.Choices) > 0 {
 			metadata.PromptTokens += chatResp.Usage.PromptTokens
 			metadata.ResponseTokens += chatResp.Usage.CompletionTokens
@@ -388,41 +780,74 @@ Do NOT return extra fields. Do NOT return markdown. Do NOT return extra text.`,
 			observability.LLMTokensTotal.WithLabelValues(modelName, "total").Add(float64(chatResp.Usage.TotalTokens))
 
 			content := chatResp.Choices[0].Message.Content
-			result, parseErr := e.parseAndValidate(content)
+			result, parseErr := e.parseAndValidateFor(content, categories)
 			if parseErr == nil {
-				metadata.Success = true
-				metadata.Latency = time.Since(startTime)
-				observability.LLMRequestDuration.WithLabelValues(modelName, "categorization").Observe(metadata.Latency.Seconds())
-				return &CategorizationResult{
-					Analysis: result,
-					Metadata: metadata,
-				}, nil
+				calibrate(result, chatResp.Choices[0].Logprobs)
+				return result, nil
 			}
 			lastErr = parseErr
+			metadata.FailedResponses = append(metadata.FailedResponses, truncateForLog(content, maxDebugResponseBytes))
 			observability.ErrorsTotal.WithLabelValues("parsing").Inc()
+			logging.Verbosef("[*] Attempt %d by %s rejected: %v", metadata.Attempts, modelName, parseErr)
+			if debug {
+				log.Printf("[DEBUG] Unparseable response from %s (attempt %d): %v\nRaw response: %q",
+					modelName, metadata.Attempts, parseErr, truncateForLog(content, maxDebugResponseBytes))
+			}
 		} else {
+			if err == nil {
+				err = fmt.Errorf("empty response from model")
+			}
 			lastErr = err
+			logging.Verbosef("[*] Attempt %d by %s failed: %v", metadata.Attempts, modelName, err)
 			if strings.Contains(err.Error(), "connection") || strings.Contains(err.Error(), "timeout") {
 				observability.ErrorsTotal.WithLabelValues("connection").Inc()
 			}
 		}
 	}
-
-	metadata.Latency = time.Since(startTime)
-	// Escalation fallback path
-	return &CategorizationResult{
-		Analysis: &AnalysisResult{
-			Category:        "Misc",
-			Title:           "Unknown_Doc",
-			ConfidenceScore: 0.0,
-		},
-		Metadata: metadata,
-	}, fmt.Errorf("failed to get valid structured output after %d retries using model %s: %w", maxRetries, modelName, lastErr)
+	return nil, lastErr
 }
 
 func (e *MLXEngine) parseAndValidate(content string) (*AnalysisResult, error) {
+	return e.parseAndValidateFor(content, e.GetCategories())
+}
+
+// parseAndValidateFor extracts the analysis from a response, allowing only categories.
+func (e *MLXEngine) parseAndValidateFor(content string, categories []string) (*AnalysisResult, error) {
 	content = cleanJSON(content)
 
+	// Small models often wrap the object in prose (sometimes in the document's language)
+	// or emit full-width punctuation, so try every embedded object before giving up.
+	var decodeErr, validationErr error
+	for _, text := range []string{content, normalizePunctuation(content)} {
+		for _, candidate := range extractJSONObjects(text) {
+			result, err := decodeAnalysis(candidate)
+			if err != nil {
+				if decodeErr == nil {
+					decodeErr = err
+				}
+				continue
+			}
+			if err := e.validateAnalysis(result, categories); err != nil {
+				if validationErr == nil {
+					validationErr = err
+				}
+				continue
+			}
+			return result, nil
+		}
+	}
+
+	if validationErr != nil {
+		return nil, validationErr
+	}
+	if decodeErr != nil {
+		return nil, decodeErr
+	}
+	return nil, fmt.Errorf("no JSON object found in response")
+}
+
+// decodeAnalysis strictly decodes a single JSON object into an AnalysisResult.
+func decodeAnalysis(content string) (*AnalysisResult, error) {
 	// Use decoder with DisallowUnknownFields for strict validation
 	var result AnalysisResult
 	dec := json.NewDecoder(strings.NewReader(content))
@@ -438,39 +863,122 @@ func (e *MLXEngine) parseAndValidate(content string) (*AnalysisResult, error) {
 		return nil, fmt.Errorf("trailing data after JSON object")
 	}
 
+	return &result, nil
+}
+
+// validateAnalysis checks required fields and the category enum, then sanitizes the result in place.
+func (e *MLXEngine) validateAnalysis(result *AnalysisResult, categories []string) error {
 	// Required fields validation
 	if result.Category == "" {
-		return nil, fmt.Errorf("missing required field: category")
+		return fmt.Errorf("missing required field: category")
 	}
 	if result.Title == "" {
-		return nil, fmt.Errorf("missing required field: title")
+		return fmt.Errorf("missing required field: title")
 	}
 	if result.ConfidenceScore <= 0 {
 		// Even if provided, if it's 0 it might be missing or explicitly low
 		// We'll treat <= 0 as invalid per requirements "Required confidence_score"
-		return nil, fmt.Errorf("missing or invalid confidence_score: %v", result.ConfidenceScore)
+		return fmt.Errorf("missing or invalid confidence_score: %v", result.ConfidenceScore)
 	}
 
-	// Enum validation
-	valid := false
-	for _, c := range e.validCategories {
-		if result.Category == c {
-			valid = true
-			break
+	e.mu.RLock()
+	describe := e.describe
+	e.mu.RUnlock()
+	if !describe && (result.Tags != nil || result.Summary != "") {
+		return fmt.Errorf("unexpected fields: tags and summary were not requested")
+	}
+
+	// Aliases such as "Bills" for "Finance" canonicalize near misses before the enum check
+	if category, ok := e.resolveAlias(result.Category, categories); ok {
+		result.Category = category
+	}
+
+	// Enum validation; "Café" matches whether either side is composed or decomposed
+	i := slices.IndexFunc(categories, func(c string) bool { return sameName(c, result.Category) })
+	if i < 0 && e.taxonomy == nil {
+		// "finance" is the existing "Finance" folder on case-insensitive file systems
+		i = slices.IndexFunc(categories, func(c string) bool {
+			return strings.EqualFold(norm.NFC.String(c), norm.NFC.String(result.Category))
+		})
+	}
+	valid := i >= 0
+	if valid {
+		result.Category = categories[i]
+	}
+	if !valid && e.taxonomy != nil {
+		// Small models often answer with just the sub-folder or the wrong case
+		if path, ok := e.taxonomy.Resolve(result.Category); ok && slices.Contains(categories, path) {
+			result.Category, valid = path, true
 		}
 	}
 	if !valid {
-		return nil, fmt.Errorf("invalid category: %s (must be one of %v)", result.Category, e.validCategories)
+		// "Personal Docs" or "Finanse" cost a retry otherwise
+		if category, ok := fuzzyCategory(result.Category, categories); ok {
+			logging.Verbosef("[*] Matched category %q to %s", result.Category, category)
+			result.Category, valid = category, true
+		}
+	}
+	if !valid {
+		return fmt.Errorf("invalid category: %s (must be one of %v)", result.Category, categories)
 	}
 
-	result.Category = SanitizeCategory(result.Category)
+	if result.DocumentDate != "" {
+		date, err := parseDocumentDate(result.DocumentDate)
+		if err != nil {
+			return err
+		}
+		result.DocumentDate = date
+	}
+
+	// Title rules apply to the file name the title becomes
 	result.Title = SanitizeFilename(result.Title)
+	if err := e.checkProfile(result); err != nil {
+		return err
+	}
 
-	return &result, nil
+	result.Category = SanitizeCategory(result.Category)
+	result.Tags = cleanTags(result.Tags)
+	result.Summary = strings.TrimSpace(result.Summary)
+
+	return nil
+}
+
+// documentDateLayouts are the ISO-8601 forms accepted for document_date.
+var documentDateLayouts = []string{time.DateOnly, time.RFC3339, "2006-01-02T15:04:05", "2006-01-02T15:04"}
+
+// parseDocumentDate validates an ISO-8601 document date and returns it as YYYY-MM-DD.
+func parseDocumentDate(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	for _, layout := range documentDateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.Format(time.DateOnly), nil
+		}
+	}
+	return "", fmt.Errorf("invalid document_date %q: want an ISO-8601 date (YYYY-MM-DD) or no document_date", s)
+}
+
+// cleanTags trims tags and drops empty and duplicate (case-insensitive) entries.
+func cleanTags(tags []string) []string {
+	var cleaned []string
+	seen := make(map[string]bool)
+	for _, t := range tags {
+		t = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(t), "#"))
+		if t == "" || seen[strings.ToLower(t)] {
+			continue
+		}
+		seen[strings.ToLower(t)] = true
+		cleaned = append(cleaned, t)
+		if len(cleaned) == maxTags {
+			break
+		}
+	}
+	return cleaned
 }
 
 // SanitizeCategory removes dangerous characters but allows forward slashes for nested paths.
+// Like SanitizeFilename, it returns the name in NFC.
 func SanitizeCategory(s string) string {
+	s = norm.NFC.String(s)
 	// Allow / but sanitize other path characters
 	s = strings.ReplaceAll(s, "\\", "_")
 	s = strings.ReplaceAll(s, ":", "_")
@@ -483,8 +991,7 @@ func SanitizeCategory(s string) string {
 
 	var builder strings.Builder
 	for _, r := range s {
-		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') ||
-			r == '_' || r == '-' || r == '.' || r == ' ' || r == '/' {
+		if nameRune(r) || r == '/' {
 			builder.WriteRune(r)
 		} else {
 			builder.WriteRune('_')
@@ -507,8 +1014,10 @@ func SanitizeCategory(s string) string {
 	return result
 }
 
-// SanitizeFilename removes dangerous characters from AI-generated strings
+// SanitizeFilename removes dangerous characters from AI-generated strings. The result is
+// normalized to NFC, so the same title never yields two differently encoded names.
 func SanitizeFilename(s string) string {
+	s = norm.NFC.String(s)
 	// 1. Initial cleanup: replace common path separators and problematic characters
 	s = strings.ReplaceAll(s, "/", "_")
 	s = strings.ReplaceAll(s, "\\", "_")
@@ -520,13 +1029,12 @@ func SanitizeFilename(s string) string {
 	s = strings.ReplaceAll(s, ">", "_")
 	s = strings.ReplaceAll(s, "|", "_")
 
-	// 2. Filter characters: allow alphanumeric, space, underscore, hyphen, and dot.
+	// 2. Filter characters: allow letters, digits, space, underscore, hyphen, and dot.
 	// Everything else becomes an underscore.
 	var builder strings.Builder
 	for _, r := range s {
-		// Basic Alphanumeric + a few safe symbols
-		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') ||
-			r == '_' || r == '-' || r == '.' || r == ' ' {
+		// Letters, digits, and a few safe symbols
+		if nameRune(r) {
 			builder.WriteRune(r)
 		} else {
 			// Catch-all for any other character (unicode slashes, control chars, etc.)
@@ -561,14 +1069,10 @@ func (e *MLXEngine) MapReduceSummarize(ctx context.Context, text string, limit i
 	chunkSize := int(float64(contentBudget) * 0.8)
 	chunks := e.ctxMgr.Chunk(text, chunkSize)
 
-	// 2. Map: Summarize each chunk
-	var summaries []string
-	for i, chunk := range chunks {
-		summary, err := e.summarizeChunk(ctx, chunk, i+1, len(chunks), modelName, apiURL)
-		if err != nil {
-			return "", fmt.Errorf("failed to summarize chunk %d/%d: %w", i+1, len(chunks), err)
-		}
-		summaries = append(summaries, summary)
+	// 2. Map: Summarize the chunks concurrently, keeping their order
+	summaries, err := e.mapChunks(ctx, chunks, modelName, apiURL)
+	if err != nil {
+		return "", err
 	}
 
 	// 3. Reduce: Combined and recursively summarize if needed
@@ -584,15 +1088,6 @@ func (e *MLXEngine) MapReduceSummarize(ctx context.Context, text string, limit i
 }
 
 func (e *MLXEngine) summarizeChunk(ctx context.Context, text string, index, total int, modelName, apiURL string) (string, error) {
-	// Ensure URL is set for the client
-	if net, ok := e.llm.(*NetLLMClient); ok {
-		fullURL := strings.TrimRight(apiURL, "/")
-		if !strings.HasSuffix(fullURL, "/chat/completions") {
-			fullURL += "/chat/completions"
-		}
-		net.apiURL = fullURL
-	}
-
 	prompt := fmt.Sprintf("Summarize the following document part (%d/%d). Keep key technical details, names, and core topics relevant for categorization:\n\n%s", index, total, text)
 
 	reqBody := chatRequest{
@@ -603,9 +1098,11 @@ func (e *MLXEngine) summarizeChunk(ctx context.Context, text string, index, tota
 		},
 		Stream:      false,
 		Temperature: 0.1,
+		MaxTokens:   e.outputBudget(),
+		APIURL:      apiURL,
 	}
 
-	chatResp, err := e.llm.CreateChatCompletion(ctx, reqBody)
+	chatResp, err := e.chat(ctx, reqBody)
 	if err != nil {
 		return "", err
 	}
@@ -617,7 +1114,7 @@ func (e *MLXEngine) summarizeChunk(ctx context.Context, text string, index, tota
 	return strings.TrimSpace(chatResp.Choices[0].Message.Content), nil
 }
 
-// cleanJSON attempts to extract the valid JSON object
+// cleanJSON strips markdown fences and common LLM control tokens around the response.
 func cleanJSON(s string) string {
 	// 1. Remove markdown code blocks
 	s = strings.TrimSpace(s)
@@ -628,13 +1125,5 @@ func cleanJSON(s string) string {
 	// 2. Remove common LLM suffixes
 	s = strings.ReplaceAll(s, "<|eot_id|>", "")
 
-	// 3. Find first '{' and last '}'
-	start := strings.Index(s, "{")
-	end := strings.LastIndex(s, "}")
-
-	if start != -1 && end != -1 && end > start {
-		s = s[start : end+1]
-	}
-
 	return strings.TrimSpace(s)
 }
@@ -1,15 +1,15 @@
 package ai
 
 import (
-	"docs_organiser/internal/config"
+	"docs_organiser/internal/taxonomy"
 	"fmt"
+	"slices"
+	"strings"
 	"testing"
 )
 
 func TestParseAndValidate(t *testing.T) {
-	engine, _ := NewMLXEngine("http://localhost:8080/v1", []config.ModelDefinition{
-		{Name: "test-model", URL: "http://localhost:8080/v1"},
-	}, 4096, "cl100k_base")
+	engine := testEngine(t)
 	engine.SetCategories([]string{"Personal", "Work", "Work/Projects", "Finance"})
 
 	tests := []struct {
@@ -34,6 +34,34 @@ func TestParseAndValidate(t *testing.T) {
 			wantErr: false,
 		},
 
+		// Multilingual prose and full-width punctuation
+		{
+			name:    "Japanese prose around JSON",
+			content: `こちらが結果です：{"category": "Finance", "title": "Invoice", "confidence_score": 0.9}。ご確認ください。`,
+			wantErr: false,
+		},
+		{
+			name:    "French prose with braces in title",
+			content: `Voici le résultat : {"category": "Work", "title": "Plan {v2}", "confidence_score": 0.9} Merci !`,
+			wantErr: false,
+		},
+		{
+			name:    "Full-width punctuation",
+			content: `｛"category"："Work"，"title"："Report"，"confidence_score"：0.8｝`,
+			wantErr: false,
+		},
+		{
+			name:    "Typographic quotes",
+			content: `{“category”: “Work”, “title”: “Report”, “confidence_score”: 0.7}`,
+			wantErr: false,
+		},
+		{
+			name:    "Leading junk object then valid object",
+			content: `Résumé {catégorie inconnue} → {"category": "Personal", "title": "Diary", "confidence_score": 0.6}`,
+			wantErr: false,
+		},
+		{name: "Prose without JSON", content: `这是一份财务文件。`, wantErr: true},
+
 		// Missing Fields
 		{name: "Missing category", content: `{"title": "Title", "confidence_score": 0.9}`, wantErr: true},
 		{name: "Missing title", content: `{"category": "AI", "confidence_score": 0.9}`, wantErr: true},
@@ -48,7 +76,8 @@ func TestParseAndValidate(t *testing.T) {
 		// Wrong Enum
 		{name: "Invalid category 'Unknown'", content: `{"category": "Unknown", "title": "Title", "confidence_score": 0.9}`, wantErr: true},
 		{name: "Invalid category 'Random'", content: `{"category": "Random", "title": "Title", "confidence_score": 0.9}`, wantErr: true},
-		{name: "Lowercase category (strict)", content: `{"category": "personal", "title": "Title", "confidence_score": 0.9}`, wantErr: true},
+		{name: "Lowercase category maps to the existing folder", content: `{"category": "personal", "title": "Title", "confidence_score": 0.9}`, wantErr: false},
+		{name: "Lowercase nested category", content: `{"category": "work/projects", "title": "Title", "confidence_score": 0.9}`, wantErr: false},
 
 		// Invalid JSON
 		{name: "Malformed JSON (no closing brace)", content: `{"category": "AI", "title": "Title", "confidence_score": 0.9`, wantErr: true},
@@ -105,6 +134,218 @@ func TestParseAndValidate(t *testing.T) {
 			if !tt.wantErr && got == nil {
 				t.Error("parseAndValidate() returned nil but no error")
 			}
+			if !tt.wantErr && !slices.Contains(engine.GetCategories(), got.Category) {
+				t.Errorf("category %q is not one of the configured folders", got.Category)
+			}
 		})
 	}
 }
+
+func TestParseAndValidateDescribe(t *testing.T) {
+	engine := testEngine(t)
+	engine.SetCategories([]string{"Finance"})
+	content := `{"category": "Finance", "title": "Invoice", "confidence_score": 0.9, "tags": [" invoice", "#ACME", "acme", ""], "summary": " March invoice from ACME. "}`
+
+	if _, err := engine.parseAndValidate(content); err == nil {
+		t.Error("tags and summary accepted without SetDescribe")
+	}
+
+	engine.SetDescribe(true)
+	got, err := engine.parseAndValidate(content)
+	if err != nil {
+		t.Fatalf("parseAndValidate() error = %v", err)
+	}
+	if fmt.Sprint(got.Tags) != "[invoice ACME]" || got.Summary != "March invoice from ACME." {
+		t.Errorf("got tags %q, summary %q", got.Tags, got.Summary)
+	}
+
+	// The descriptive fields stay optional
+	if _, err := engine.parseAndValidate(`{"category": "Finance", "title": "Invoice", "confidence_score": 0.9}`); err != nil {
+		t.Errorf("response without tags rejected: %v", err)
+	}
+}
+
+func TestParseAndValidateUnicodeNames(t *testing.T) {
+	engine := testEngine(t)
+	engine.SetCategories([]string{"Cafe\u0301s", "Finance"}) // discovered from a macOS folder, decomposed
+
+	for _, category := range []string{"Caf\u00e9s", "Cafe\u0301s", "caf\u00e9s"} {
+		got, err := engine.parseAndValidate(fmt.Sprintf(`{"category": %q, "title": "Men\u0303u Cre\u0300me Bru\u0302le\u0301e", "confidence_score": 0.9}`, category))
+		if err != nil {
+			t.Errorf("category %+q rejected: %v", category, err)
+			continue
+		}
+		if got.Category != "Caf\u00e9s" || got.Title != "Me\u00f1u Cr\u00e8me Br\u00fbl\u00e9e" {
+			t.Errorf("category %+q: got %+q / %+q, want both in NFC", category, got.Category, got.Title)
+		}
+	}
+}
+
+func TestParseAndValidateDocumentDate(t *testing.T) {
+	engine := testEngine(t)
+	engine.SetCategories([]string{"Finance"})
+
+	tests := []struct {
+		date    string
+		want    string
+		wantErr bool
+	}{
+		{"2024-03-31", "2024-03-31", false},
+		{" 2024-03-31T10:15:00Z ", "2024-03-31", false},
+		{"2024-03-31T10:15", "2024-03-31", false},
+		{"31/03/2024", "", true},
+		{"2024-02-30", "", true},
+		{"March 2024", "", true},
+	}
+	for _, tt := range tests {
+		got, err := engine.parseAndValidate(fmt.Sprintf(`{"category": "Finance", "title": "Statement", "confidence_score": 0.9, "document_date": %q}`, tt.date))
+		if (err != nil) != tt.wantErr {
+			t.Errorf("document_date %q: err = %v, wantErr %v", tt.date, err, tt.wantErr)
+			continue
+		}
+		if err == nil && got.DocumentDate != tt.want {
+			t.Errorf("document_date %q = %q, want %q", tt.date, got.DocumentDate, tt.want)
+		}
+	}
+}
+
+func TestParseAndValidateTaxonomy(t *testing.T) {
+	engine := testEngine(t)
+	engine.SetTaxonomy(&taxonomy.Taxonomy{Categories: []taxonomy.Category{
+		{Name: "Finance", Description: "Money in and out.", Children: []taxonomy.Category{{Name: "Taxes", Examples: []string{"tax returns"}}}},
+		{Name: "Receipts"},
+	}})
+
+	tests := []struct {
+		category string
+		want     string
+		wantErr  bool
+	}{
+		{"Finance/Taxes", "Finance/Taxes", false},
+		{"Taxes", "Finance/Taxes", false},
+		{"receipts", "Receipts", false},
+		{"Misc", "Misc", false},
+		{"Travel", "", true},
+	}
+	for _, tt := range tests {
+		got, err := engine.parseAndValidate(fmt.Sprintf(`{"category": %q, "title": "Doc", "confidence_score": 0.9}`, tt.category))
+		if (err != nil) != tt.wantErr {
+			t.Errorf("category %q: error = %v, wantErr %v", tt.category, err, tt.wantErr)
+			continue
+		}
+		if err == nil && got.Category != tt.want {
+			t.Errorf("category %q resolved to %q, want %q", tt.category, got.Category, tt.want)
+		}
+	}
+
+}
+
+func TestParseAndValidateAliases(t *testing.T) {
+	engine := testEngine(t)
+	engine.SetCategories([]string{"Finance", "Finance/Tax", "Misc"})
+	if err := engine.SetCategoryAliases(map[string]string{"bills": "Finance", "Taxes": "finance/tax", "Trips": "Travel"}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		category string
+		want     string
+		wantErr  bool
+	}{
+		{"Bills", "Finance", false},
+		{"TAXES", "Finance/Tax", false},
+		{"Finance", "Finance", false},
+		{"Trips", "", true}, // Travel is not a category
+		{"Receipts", "", true},
+	}
+	for _, tt := range tests {
+		got, err := engine.parseAndValidate(fmt.Sprintf(`{"category": %q, "title": "Doc", "confidence_score": 0.9}`, tt.category))
+		if (err != nil) != tt.wantErr {
+			t.Errorf("category %q: error = %v, wantErr %v", tt.category, err, tt.wantErr)
+			continue
+		}
+		if err == nil && got.Category != tt.want {
+			t.Errorf("category %q resolved to %q, want %q", tt.category, got.Category, tt.want)
+		}
+	}
+
+	if err := engine.SetCategoryAliases(map[string]string{"Bills": " "}); err == nil {
+		t.Error("an alias without a category should be rejected")
+	}
+}
+
+func TestCategoryProfiles(t *testing.T) {
+	engine := testEngine(t)
+	engine.SetTaxonomy(&taxonomy.Taxonomy{Categories: []taxonomy.Category{
+		{Name: "Legal", Prompt: "Use the formal title of the document."},
+		{Name: "Receipts", Prompt: "Title the receipt Vendor_YYYY-MM-DD.", TitlePattern: `^[A-Za-z0-9]+_\d{4}-\d{2}-\d{2}$`, RequireDate: true},
+		{Name: "Work"},
+	}})
+
+	rules := engine.profileRules(engine.GetCategories())
+	want := "\nRules for documents in these categories:\n- Legal: Use the formal title of the document." +
+		"\n- Receipts: Title the receipt Vendor_YYYY-MM-DD. The title must match the regular expression ^[A-Za-z0-9]+_\\d{4}-\\d{2}-\\d{2}$. document_date is required."
+	if rules != want {
+		t.Errorf("profileRules() = %q, want %q", rules, want)
+	}
+	if rules := engine.profileRules([]string{"Work", "Misc"}); rules != "" {
+		t.Errorf("profileRules() without profiles = %q, want nothing", rules)
+	}
+
+	tests := []struct {
+		name, response, wantErr string
+	}{
+		{"matching title", `{"category": "Receipts", "title": "Tesco_2024-03-01", "confidence_score": 0.9, "document_date": "2024-03-01"}`, ""},
+		{"title breaks the pattern", `{"category": "Receipts", "title": "Groceries", "confidence_score": 0.9, "document_date": "2024-03-01"}`, "Title the receipt Vendor_YYYY-MM-DD"},
+		{"missing required date", `{"category": "Receipts", "title": "Tesco_2024-03-01", "confidence_score": 0.9}`, "required for category Receipts"},
+		{"prompt only", `{"category": "Legal", "title": "anything", "confidence_score": 0.9}`, ""},
+		{"no profile", `{"category": "Work", "title": "Notes", "confidence_score": 0.9}`, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := engine.parseAndValidate(tt.response)
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCategoryGuide(t *testing.T) {
+	engine := testEngine(t)
+	engine.SetCategories([]string{"Finance", "Finance/Taxes", "Receipts", "Misc"})
+	engine.SetCategoryDescriptions(map[string]string{
+		"receipts": "Proof of a single purchase.",
+		"finance":  "Banking and bills, not shop receipts.",
+		"Travel":   "Not an allowed category, so not shown.",
+	})
+
+	guide, dropped := engine.categoryGuide(engine.GetCategories(), 1000)
+	want := "\nCategory guide:\n- Finance: Banking and bills, not shop receipts.\n- Receipts: Proof of a single purchase."
+	if guide != want || dropped != 0 {
+		t.Errorf("categoryGuide() = %q, %d; want %q, 0", guide, dropped, want)
+	}
+
+	// Taxonomy descriptions win; configured ones fill the gaps
+	engine.SetTaxonomy(&taxonomy.Taxonomy{Categories: []taxonomy.Category{
+		{Name: "Finance", Description: "Money in and out.", Children: []taxonomy.Category{{Name: "Taxes", Examples: []string{"tax returns"}}}},
+		{Name: "Receipts"},
+	}})
+	guide, _ = engine.categoryGuide(engine.GetCategories(), 1000)
+	want = "\nCategory guide:\n- Finance: Money in and out.\n  - Finance/Taxes (e.g. tax returns)\n- Receipts: Proof of a single purchase."
+	if guide != want {
+		t.Errorf("categoryGuide() = %q, want %q", guide, want)
+	}
+
+	// Whole lines are dropped once the budget is spent
+	guide, dropped = engine.categoryGuide(engine.GetCategories(), 15)
+	if dropped == 0 || !strings.HasPrefix(guide, "\nCategory guide:\n- Finance") || strings.Contains(guide, "Receipts") {
+		t.Errorf("categoryGuide(15) = %q, %d dropped", guide, dropped)
+	}
+	if guide, dropped = engine.categoryGuide(engine.GetCategories(), 0); guide != "" || dropped != 3 {
+		t.Errorf("categoryGuide(0) = %q, %d dropped; want nothing", guide, dropped)
+	}
+}
@@ -35,6 +35,16 @@ func TestSanitizeFilename(t *testing.T) {
 			"Title\u2215WithSlash", // Division slash
 			"Title_WithSlash",
 		},
+		{
+			"Accented letters are kept in NFC",
+			"Cre\u0300me Bru\u0302le\u0301e",
+			"Cr\u00e8me Br\u00fbl\u00e9e",
+		},
+		{
+			"Other scripts are kept",
+			"\u8acb\u6c42\u66f8 2024",
+			"\u8acb\u6c42\u66f8 2024",
+		},
 		{
 			"Leading/trailing dots and spaces",
 			"  .Hidden File.  ",
@@ -66,3 +76,19 @@ func TestSanitizeFilename(t *testing.T) {
 		})
 	}
 }
+
+func TestTransliterate(t *testing.T) {
+	tests := []struct{ input, want string }{
+		{"Caf\u00e9", "Cafe"},
+		{"Cafe\u0301", "Cafe"},
+		{"R\u00e9sum\u00e9_M\u00fcller", "Resume_Muller"},
+		{"Stra\u00dfe \u00c6r\u00f8", "Strasse AEro"},
+		{"\u0141\u00f3d\u017a/Faktury", "Lodz/Faktury"},
+		{"\u8acb\u6c42\u66f8 2024", "___ 2024"},
+	}
+	for _, tt := range tests {
+		if got := Transliterate(tt.input); got != tt.want {
+			t.Errorf("Transliterate(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
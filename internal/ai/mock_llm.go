@@ -3,6 +3,8 @@ package ai
 import (
 	"context"
 	"fmt"
+	"strings"
+	"sync"
 )
 
 // MockLLMClient allows for deterministic testing of the orchestration layer.
@@ -10,9 +12,20 @@ type MockLLMClient struct {
 	Responses []*chatResponse
 	Errors    []error
 	CallCount int
+	// Requests records every request received, for assertions on the prompts.
+	Requests []chatRequest
+	mu       sync.Mutex
 }
 
+// CreateChatCompletion answers the router's complexity pass with "simple" without
+// recording or counting it, so that Responses and Requests cover only the calls under test.
 func (m *MockLLMClient) CreateChatCompletion(ctx context.Context, req chatRequest) (*chatResponse, error) {
+	if len(req.Messages) > 0 && strings.HasPrefix(req.Messages[0].Content, complexityPrompt) {
+		return &chatResponse{Choices: []choice{{Message: message{Role: "assistant", Content: "simple"}}}}, nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Requests = append(m.Requests, req)
 	if m.CallCount >= len(m.Responses) && m.CallCount >= len(m.Errors) {
 		return nil, fmt.Errorf("no more mock responses configured")
 	}
@@ -0,0 +1,47 @@
+package ai
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// asciiFolds spells letters that don't decompose into an ASCII base and accents.
+var asciiFolds = map[rune]string{
+	'ß': "ss", 'æ': "ae", 'Æ': "AE", 'œ': "oe", 'Œ': "OE", 'ø': "o", 'Ø': "O",
+	'ł': "l", 'Ł': "L", 'đ': "d", 'Đ': "D", 'ð': "d", 'Ð': "D", 'þ': "th", 'Þ': "Th",
+	'ı': "i",
+}
+
+// nameRune reports whether r is kept in file and folder names: letters, digits, and the
+// combining marks that NFC leaves in some scripts. Anything else becomes an underscore.
+func nameRune(r rune) bool {
+	return r == '_' || r == '-' || r == '.' || r == ' ' || unicode.In(r, unicode.L, unicode.M, unicode.Nd)
+}
+
+// sameName reports whether a and b are the same name once normalized to NFC, the way
+// macOS (which stores names decomposed) and Linux (which stores what it is given) differ.
+func sameName(a, b string) bool {
+	return norm.NFC.String(a) == norm.NFC.String(b)
+}
+
+// Transliterate spells s in ASCII: accents are dropped ("Café" becomes "Cafe"), a few
+// letters are written out ("Straße" becomes "Strasse"), and characters without an ASCII
+// form become underscores.
+func Transliterate(s string) string {
+	var b strings.Builder
+	for _, r := range norm.NFD.String(s) {
+		switch {
+		case r < unicode.MaxASCII:
+			b.WriteRune(r)
+		case unicode.Is(unicode.Mn, r):
+			// accent of the preceding letter
+		case asciiFolds[r] != "":
+			b.WriteString(asciiFolds[r])
+		default:
+			b.WriteRune('_')
+		}
+	}
+	return b.String()
+}
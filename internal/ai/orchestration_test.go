@@ -3,11 +3,18 @@ package ai
 import (
 	"context"
 	"docs_organiser/internal/config"
+	"strings"
 	"testing"
 )
 
+// withRouter gives e the model router NewMLXEngine would.
+func withRouter(e *MLXEngine) *MLXEngine {
+	e.router = NewModelRouter(e)
+	return e
+}
+
 func TestCategorize_Robustness(t *testing.T) {
-	tokenizer, _ := NewTokenizer("cl100k_base")
+	tokenizer := testTokenizer(t)
 	ctxMgr := NewContextManager(tokenizer, 4096)
 
 	t.Run("Success on first attempt", func(t *testing.T) {
@@ -20,12 +27,12 @@ func TestCategorize_Robustness(t *testing.T) {
 			},
 		}
 
-		engine := &MLXEngine{
+		engine := withRouter(&MLXEngine{
 			llm:             mock,
 			models:          []config.ModelDefinition{{Name: "test-model", URL: "http://mock-api.com/v1"}},
 			ctxMgr:          ctxMgr,
 			validCategories: []string{"Work", "Personal"},
-		}
+		})
 
 		result, err := engine.Categorize(context.Background(), "some text")
 		if err != nil {
@@ -57,12 +64,12 @@ func TestCategorize_Robustness(t *testing.T) {
 			},
 		}
 
-		engine := &MLXEngine{
+		engine := withRouter(&MLXEngine{
 			llm:             mock,
 			models:          []config.ModelDefinition{{Name: "test-model", URL: "http://mock-api.com/v1"}},
 			ctxMgr:          ctxMgr,
 			validCategories: []string{"Work", "Personal"},
-		}
+		})
 
 		result, err := engine.Categorize(context.Background(), "some text")
 		if err != nil {
@@ -77,3 +84,161 @@ func TestCategorize_Robustness(t *testing.T) {
 		}
 	})
 }
+
+func TestCategorize_TwoStage(t *testing.T) {
+	tokenizer := testTokenizer(t)
+	categories := []string{"Finance", "Finance/Taxes", "Finance/Insurance", "Work", "Work/Projects", "Misc"}
+	answer := func(category string) *chatResponse {
+		return &chatResponse{Choices: []choice{{Message: message{
+			Content: `{"category": "` + category + `", "title": "Tax Return", "confidence_score": 0.9}`,
+		}}}}
+	}
+	newEngine := func(mock *MockLLMClient) *MLXEngine {
+		return withRouter(&MLXEngine{
+			llm:             mock,
+			models:          []config.ModelDefinition{{Name: "test-model", URL: "http://mock-api.com/v1"}},
+			ctxMgr:          NewContextManager(tokenizer, 4096),
+			validCategories: categories,
+			twoStage:        true,
+		})
+	}
+
+	tests := []struct {
+		name      string
+		responses []*chatResponse
+		want      string
+		wantCalls int
+	}{
+		{"coarse then fine", []*chatResponse{answer("Finance"), answer("Finance/Taxes")}, "Finance/Taxes", 2},
+		{"leaf folder needs no second pass", []*chatResponse{answer("Misc")}, "Misc", 1},
+		{"second pass fails, coarse kept", []*chatResponse{answer("Finance"), answer("Work"), answer("Work"), answer("Work")}, "Finance", 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := &MockLLMClient{Responses: tt.responses}
+			result, err := newEngine(mock).Categorize(context.Background(), "2023 income tax return")
+			if err != nil {
+				t.Fatalf("Categorize: %v", err)
+			}
+			if result.Analysis.Category != tt.want || mock.CallCount != tt.wantCalls {
+				t.Errorf("category %q after %d calls, want %q after %d", result.Analysis.Category, mock.CallCount, tt.want, tt.wantCalls)
+			}
+			if result.Metadata.Attempts != tt.wantCalls {
+				t.Errorf("Attempts = %d, want %d", result.Metadata.Attempts, tt.wantCalls)
+			}
+		})
+	}
+
+	// Each prompt only lists the choices of its stage
+	mock := &MockLLMClient{Responses: []*chatResponse{answer("Finance"), answer("Finance/Taxes")}}
+	result, _ := newEngine(mock).Categorize(context.Background(), "2023 income tax return")
+	if result.Metadata.CoarseCategory != "Finance" {
+		t.Errorf("CoarseCategory = %q, want Finance", result.Metadata.CoarseCategory)
+	}
+	for i, want := range []string{"Finance, Work, Misc\n", "Finance, Finance/Taxes, Finance/Insurance\n"} {
+		if prompt := mock.Requests[i].Messages[0].Content; !strings.Contains(prompt, "choose category from: "+want) {
+			t.Errorf("stage %d prompt lists the wrong categories:\n%s", i+1, prompt)
+		}
+	}
+}
+
+func TestCategorize_Voting(t *testing.T) {
+	tokenizer := testTokenizer(t)
+	answer := func(category, title string) *chatResponse {
+		return &chatResponse{Choices: []choice{{Message: message{
+			Content: `{"category": "` + category + `", "title": "` + title + `", "confidence_score": 0.8}`,
+		}}}}
+	}
+	invalid := &chatResponse{Choices: []choice{{Message: message{Content: "not json"}}}}
+
+	tests := []struct {
+		name          string
+		responses     []*chatResponse
+		wantCategory  string
+		wantTitle     string
+		wantAgreement float64
+	}{
+		{"majority wins", []*chatResponse{answer("Work", "Memo"), answer("Finance", "Invoice"), answer("Finance", "Invoice_2")}, "Finance", "Invoice", 2.0 / 3},
+		{"tie goes to first", []*chatResponse{answer("Work", "Memo"), answer("Finance", "Invoice"), invalid, invalid, invalid}, "Work", "Memo", 1.0 / 3},
+		{"unanimous", []*chatResponse{answer("Finance", "Invoice"), answer("Finance", "Bill"), answer("Finance", "Receipt")}, "Finance", "Invoice", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := &MockLLMClient{Responses: tt.responses}
+			engine := withRouter(&MLXEngine{
+				llm:             mock,
+				models:          []config.ModelDefinition{{Name: "test-model", URL: "http://mock-api.com/v1"}},
+				ctxMgr:          NewContextManager(tokenizer, 4096),
+				validCategories: []string{"Finance", "Work", "Misc"},
+			})
+			engine.SetVoting(3, 0.7)
+
+			result, err := engine.Categorize(context.Background(), "invoice for consulting work")
+			if err != nil {
+				t.Fatalf("Categorize: %v", err)
+			}
+			if result.Analysis.Category != tt.wantCategory || result.Analysis.Title != tt.wantTitle {
+				t.Errorf("got %s/%s, want %s/%s", result.Analysis.Category, result.Analysis.Title, tt.wantCategory, tt.wantTitle)
+			}
+			if result.Metadata.Agreement != tt.wantAgreement {
+				t.Errorf("Agreement = %v, want %v", result.Metadata.Agreement, tt.wantAgreement)
+			}
+			for _, req := range mock.Requests {
+				if req.Temperature != 0.7 {
+					t.Errorf("sample temperature = %v, want 0.7", req.Temperature)
+				}
+			}
+		})
+	}
+}
+
+func TestCategorize_Sampling(t *testing.T) {
+	tokenizer := testTokenizer(t)
+	answer := &chatResponse{Choices: []choice{{Message: message{Content: `{"category": "Work", "title": "Memo", "confidence_score": 0.9}`}}}}
+
+	newEngine := func() (*MLXEngine, *MockLLMClient) {
+		mock := &MockLLMClient{Responses: []*chatResponse{answer}}
+		return withRouter(&MLXEngine{
+			llm:             mock,
+			models:          []config.ModelDefinition{{Name: "test-model", URL: "http://mock-api.com/v1"}},
+			ctxMgr:          NewContextManager(tokenizer, 4096),
+			validCategories: []string{"Work", "Misc"},
+			sampling:        defaultSampling,
+		}), mock
+	}
+
+	t.Run("defaults", func(t *testing.T) {
+		engine, mock := newEngine()
+		if _, err := engine.Categorize(context.Background(), "meeting notes"); err != nil {
+			t.Fatalf("Categorize: %v", err)
+		}
+		_, _, _, output := engine.ctxMgr.GetBudgets()
+		req := mock.Requests[0]
+		if req.Temperature != defaultTemperature || req.TopP != 0 || req.MaxTokens != output || req.Stop != nil {
+			t.Errorf("request sampling = %v/%v/%v/%v, want %v/0/%v/nil", req.Temperature, req.TopP, req.MaxTokens, req.Stop, defaultTemperature, output)
+		}
+	})
+
+	t.Run("configured", func(t *testing.T) {
+		engine, mock := newEngine()
+		if err := engine.SetSampling(Sampling{Temperature: 0.3, TopP: 0.9, MaxTokens: 200, Stop: []string{"\n\n"}}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := engine.Categorize(context.Background(), "meeting notes"); err != nil {
+			t.Fatalf("Categorize: %v", err)
+		}
+		req := mock.Requests[0]
+		if req.Temperature != 0.3 || req.TopP != 0.9 || req.MaxTokens != 200 || len(req.Stop) != 1 || req.Stop[0] != "\n\n" {
+			t.Errorf("request sampling = %v/%v/%v/%q, want 0.3/0.9/200/[\"\\n\\n\"]", req.Temperature, req.TopP, req.MaxTokens, req.Stop)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		engine, _ := newEngine()
+		for _, s := range []Sampling{{Temperature: -0.1}, {Temperature: 2.5}, {TopP: 1.5}, {MaxTokens: -1}} {
+			if err := engine.SetSampling(s); err == nil {
+				t.Errorf("SetSampling(%+v) should fail", s)
+			}
+		}
+	})
+}
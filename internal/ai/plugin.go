@@ -0,0 +1,49 @@
+package ai
+
+import (
+	"context"
+	"docs_organiser/internal/plugin"
+	"fmt"
+	"time"
+)
+
+// ClassifyWithPlugin files a document with a classifier plugin instead of the model. The
+// plugin's answer is validated like the model's: its category must be one of the
+// categories, and its title is sanitized. Tags and summary are kept only when
+// descriptions are enabled. Like Categorize, it returns the Misc fallback with an error
+// when the plugin fails or its answer is invalid.
+func (e *MLXEngine) ClassifyWithPlugin(ctx context.Context, pl plugin.Plugin, path string, source Source, text string) (*CategorizationResult, error) {
+	start := time.Now()
+	categories := e.GetCategories()
+	metadata := &CategorizationMetadata{Model: "plugin:" + pl.Name, TruncationType: "none", Attempts: 1}
+	fallback := &CategorizationResult{
+		Analysis: &AnalysisResult{Category: "Misc", Title: "Unknown_Doc"},
+		Metadata: metadata,
+	}
+
+	resp, err := pl.Classify(ctx, plugin.ClassifyRequest{
+		Path: path, Name: source.Name, Dir: source.Dir, Text: text, Categories: categories,
+	})
+	metadata.Latency = time.Since(start)
+	if err != nil {
+		return fallback, err
+	}
+
+	analysis := &AnalysisResult{
+		Category:        resp.Category,
+		Title:           resp.Title,
+		ConfidenceScore: resp.ConfidenceScore,
+		DocumentDate:    resp.DocumentDate,
+	}
+	e.mu.RLock()
+	describe := e.describe
+	e.mu.RUnlock()
+	if describe {
+		analysis.Tags, analysis.Summary = resp.Tags, resp.Summary
+	}
+	if err := e.validateAnalysis(analysis, categories); err != nil {
+		return fallback, fmt.Errorf("plugin %s: %w", pl.Name, err)
+	}
+	metadata.Success = true
+	return &CategorizationResult{Analysis: analysis, Metadata: metadata}, nil
+}
@@ -0,0 +1,62 @@
+package ai
+
+import (
+	"context"
+	"docs_organiser/internal/config"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPreflight(t *testing.T) {
+	serve := func(body string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/v1/models" {
+				http.NotFound(w, r)
+				return
+			}
+			w.Write([]byte(body))
+		}))
+	}
+
+	single := serve(`{"data": [{"id": "llama3.2:1b"}]}`)
+	defer single.Close()
+	multi := serve(`{"data": [{"id": "a"}, {"id": "b"}]}`)
+	defer multi.Close()
+
+	t.Run("Configured model loaded", func(t *testing.T) {
+		e := &MLXEngine{models: []config.ModelDefinition{{Name: "llama3.2:1b", URL: single.URL + "/v1"}}}
+		got, err := e.Preflight(context.Background())
+		if err != nil || got != "llama3.2:1b" {
+			t.Fatalf("Preflight() = %q, %v", got, err)
+		}
+	})
+
+	t.Run("Only available model adopted", func(t *testing.T) {
+		e := &MLXEngine{models: []config.ModelDefinition{{Name: "missing-model", URL: single.URL + "/v1"}}}
+		got, err := e.Preflight(context.Background())
+		if err != nil || got != "llama3.2:1b" {
+			t.Fatalf("Preflight() = %q, %v", got, err)
+		}
+		if name, _ := e.selectBestModel(context.Background()); name != "llama3.2:1b" {
+			t.Errorf("expected adopted model to be selected, got %q", name)
+		}
+	})
+
+	t.Run("Ambiguous server reports clear error", func(t *testing.T) {
+		e := &MLXEngine{models: []config.ModelDefinition{{Name: "missing-model", URL: multi.URL + "/v1"}}}
+		_, err := e.Preflight(context.Background())
+		if err == nil || !strings.Contains(err.Error(), "not loaded") {
+			t.Fatalf("expected not-loaded error, got %v", err)
+		}
+	})
+
+	t.Run("Unreachable server", func(t *testing.T) {
+		e := &MLXEngine{models: []config.ModelDefinition{{Name: "m", URL: "http://127.0.0.1:1/v1"}}}
+		_, err := e.Preflight(context.Background())
+		if err == nil || !strings.Contains(err.Error(), "unreachable") {
+			t.Fatalf("expected unreachable error, got %v", err)
+		}
+	})
+}
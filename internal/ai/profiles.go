@@ -0,0 +1,79 @@
+package ai
+
+import (
+	"docs_organiser/internal/taxonomy"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// categoryProfile holds the extra rules a taxonomy category sets for its documents.
+type categoryProfile struct {
+	prompt       string
+	titlePattern *regexp.Regexp
+	requireDate  bool
+}
+
+// profilesFrom collects the profiles of t's categories by path. Invalid title patterns
+// are rejected by taxonomy.Load, so they are skipped here.
+func profilesFrom(t *taxonomy.Taxonomy) map[string]categoryProfile {
+	profiles := make(map[string]categoryProfile)
+	for _, entry := range t.Entries() {
+		if !entry.HasProfile() {
+			continue
+		}
+		p := categoryProfile{prompt: entry.Prompt, requireDate: entry.RequireDate}
+		if entry.TitlePattern != "" {
+			p.titlePattern, _ = regexp.Compile(entry.TitlePattern)
+		}
+		profiles[entry.Path] = p
+	}
+	return profiles
+}
+
+// profileRules lists the rules of categories that have a profile, for the system prompt.
+func (e *MLXEngine) profileRules(categories []string) string {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	var b strings.Builder
+	for _, c := range categories {
+		p, ok := e.profiles[c]
+		if !ok {
+			continue
+		}
+		var rules []string
+		if p.prompt != "" {
+			rules = append(rules, p.prompt)
+		}
+		if p.titlePattern != nil {
+			rules = append(rules, fmt.Sprintf("The title must match the regular expression %s.", p.titlePattern))
+		}
+		if p.requireDate {
+			rules = append(rules, "document_date is required.")
+		}
+		b.WriteString("\n- " + c + ": " + strings.Join(rules, " "))
+	}
+	if b.Len() == 0 {
+		return ""
+	}
+	return "\nRules for documents in these categories:" + b.String()
+}
+
+// checkProfile validates an answer against the profile of its category.
+func (e *MLXEngine) checkProfile(result *AnalysisResult) error {
+	p, ok := e.profiles[result.Category]
+	if !ok {
+		return nil
+	}
+	if p.titlePattern != nil && !p.titlePattern.MatchString(result.Title) {
+		if p.prompt != "" {
+			return fmt.Errorf("title %q does not follow the rules for %s: %s", result.Title, result.Category, p.prompt)
+		}
+		return fmt.Errorf("title %q for category %s must match %s", result.Title, result.Category, p.titlePattern)
+	}
+	if p.requireDate && result.DocumentDate == "" {
+		return fmt.Errorf("missing document_date: it is required for category %s", result.Category)
+	}
+	return nil
+}
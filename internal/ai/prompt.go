@@ -0,0 +1,116 @@
+package ai
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"text/template"
+)
+
+// PromptData is what a system prompt template is executed with.
+type PromptData struct {
+	// Categories the model must choose from.
+	Categories []string
+	// CategoryList is Categories joined with ", ", as in the built-in prompt.
+	CategoryList string
+	// Language is the instruction to title the document in its own language, or "" for English.
+	Language string
+	// Describe is set when tags and a summary are asked for.
+	Describe bool
+	// DescribeInstruction is the built-in instruction asking for tags and a summary.
+	DescribeInstruction string
+	// Descriptions lists the category descriptions that fit the examples budget.
+	Descriptions string
+	// Rules lists the category rules of the taxonomy.
+	Rules string
+}
+
+// LoadSystemPrompt parses the text/template file at path that replaces the built-in
+// classification prompt. The template is tried once with sample data so that unknown
+// fields and missing categories are reported before any document is processed.
+func LoadSystemPrompt(path string) (*template.Template, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("system prompt: %w", err)
+	}
+	tmpl, err := template.New(path).Funcs(template.FuncMap{"join": strings.Join}).Parse(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("system prompt: %w", err)
+	}
+	sample := []string{"Sample_Category_A", "Sample_Category_B"}
+	out, err := executePrompt(tmpl, PromptData{Categories: sample, CategoryList: strings.Join(sample, ", ")})
+	if err != nil {
+		return nil, err
+	}
+	if !strings.Contains(out, sample[0]) || !strings.Contains(out, sample[1]) {
+		return nil, fmt.Errorf("system prompt %s must list the categories, e.g. with {{.CategoryList}}", path)
+	}
+	return tmpl, nil
+}
+
+// SetSystemPrompt replaces the built-in classification prompt with tmpl; nil restores it.
+func (e *MLXEngine) SetSystemPrompt(tmpl *template.Template) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.systemTemplate = tmpl
+}
+
+// systemPrompt builds the classification system prompt for categories: the built-in one,
+// or the configured template.
+func (e *MLXEngine) systemPrompt(categories []string, language string) (string, error) {
+	e.mu.RLock()
+	tmpl, debug, describe := e.systemTemplate, e.debug, e.describe
+	e.mu.RUnlock()
+
+	systemBudget, examplesBudget, _, _ := e.ctxMgr.GetBudgets()
+	// Category descriptions use the examples budget, so they never crowd out the instructions
+	guide, dropped := e.categoryGuide(categories, examplesBudget)
+	if dropped > 0 && debug {
+		log.Printf("[DEBUG] %d category descriptions did not fit the %d-token examples budget", dropped, examplesBudget)
+	}
+	// Category rules are configured by the user and are never dropped
+	rules := e.profileRules(categories)
+
+	if tmpl != nil {
+		prompt, err := executePrompt(tmpl, PromptData{
+			Categories:          categories,
+			CategoryList:        strings.Join(categories, ", "),
+			Language:            strings.TrimSpace(languageInstruction(language)),
+			Describe:            describe,
+			DescribeInstruction: strings.TrimSpace(describeInstruction),
+			Descriptions:        strings.TrimSpace(guide),
+			Rules:               strings.TrimSpace(rules),
+		})
+		if err != nil {
+			return "", err
+		}
+		return e.ctxMgr.Truncate(prompt, systemBudget+examplesBudget, StrategySlidingWindow), nil
+	}
+
+	prompt := fmt.Sprintf(`You are an intelligent file organization assistant. Analyze the document text and return a SINGLE JSON object.
+Required format: {"category": "Specific_Category_Name", "title": "Clean_Filename_No_Ext", "confidence_score": 0.0-1.0}
+Strictly choose category from: %s
+Nested paths like "Parent/Child" are valid if they exist in the list above.
+Required confidence_score: a float between 0.0 and 1.0.
+Optional "document_date": the date the document was issued (statement, invoice, or letter date) as YYYY-MM-DD; omit it if no such date is stated.
+Do NOT return extra fields. Do NOT return markdown. Do NOT return extra text.`, strings.Join(categories, ", "))
+
+	prompt += languageInstruction(language)
+	if describe {
+		prompt += describeInstruction
+	}
+	prompt = e.ctxMgr.Truncate(prompt, systemBudget, StrategySlidingWindow)
+	// We don't record sliding window for system prompt as it's static/small usually
+	return prompt + guide + rules, nil
+}
+
+// executePrompt renders tmpl with data.
+func executePrompt(tmpl *template.Template, data PromptData) (string, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("system prompt: %w", err)
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,89 @@
+package ai
+
+import (
+	"context"
+	"docs_organiser/internal/config"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writePrompt(t *testing.T, src string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "prompt.tmpl")
+	if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadSystemPrompt(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		wantErr string
+	}{
+		{name: "category list", src: "Choose from: {{.CategoryList}}"},
+		{name: "ranged categories", src: "{{range .Categories}}- {{.}}\n{{end}}"},
+		{name: "join", src: `{{join .Categories " | "}}`},
+		{name: "syntax error", src: "{{.CategoryList", wantErr: "unclosed action"},
+		{name: "unknown field", src: "{{.CategoryList}} {{.Folders}}", wantErr: "Folders"},
+		{name: "no categories", src: "Sort the document.", wantErr: "must list the categories"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadSystemPrompt(writePrompt(t, tt.src))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("LoadSystemPrompt: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("LoadSystemPrompt error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+	if _, err := LoadSystemPrompt(filepath.Join(t.TempDir(), "missing.tmpl")); err == nil {
+		t.Error("LoadSystemPrompt of a missing file should fail")
+	}
+}
+
+func TestCategorize_SystemPromptFile(t *testing.T) {
+	tokenizer := testTokenizer(t)
+	mock := &MockLLMClient{Responses: []*chatResponse{
+		{Choices: []choice{{Message: message{Content: `{"category": "Work", "title": "Memo", "confidence_score": 0.9}`}}}},
+	}}
+	engine := withRouter(&MLXEngine{
+		llm:             mock,
+		models:          []config.ModelDefinition{{Name: "test-model", URL: "http://mock-api.com/v1"}},
+		ctxMgr:          NewContextManager(tokenizer, 4096),
+		validCategories: []string{"Work", "Misc"},
+		sampling:        defaultSampling,
+	})
+	engine.SetCategoryDescriptions(map[string]string{"Work": "Anything from the office"})
+	tmpl, err := LoadSystemPrompt(writePrompt(t, `Answer in JSON with category, title, and confidence_score.
+Categories: {{.CategoryList}}
+{{- if .Descriptions}}
+{{.Descriptions}}{{end}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	engine.SetSystemPrompt(tmpl)
+
+	result, err := engine.Categorize(context.Background(), "meeting notes")
+	if err != nil {
+		t.Fatalf("Categorize: %v", err)
+	}
+	if result.Analysis.Category != "Work" {
+		t.Errorf("Category = %q, want Work", result.Analysis.Category)
+	}
+	system := mock.Requests[0].Messages[0].Content
+	if !strings.HasPrefix(system, "Answer in JSON") || !strings.Contains(system, "Categories: Work, Misc") || !strings.Contains(system, "Anything from the office") {
+		t.Errorf("system prompt = %q, want the rendered template", system)
+	}
+	if strings.Contains(system, "intelligent file organization assistant") {
+		t.Errorf("system prompt still contains the built-in prompt: %q", system)
+	}
+}
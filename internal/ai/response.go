@@ -0,0 +1,92 @@
+package ai
+
+import "strings"
+
+const (
+	// maxJSONCandidates bounds how many embedded objects are tried per response.
+	maxJSONCandidates = 16
+	// maxDebugResponseBytes caps how much of a raw response is written to debug logs.
+	maxDebugResponseBytes = 2000
+)
+
+// fullWidthReplacer maps full-width and typographic punctuation (common in CJK and
+// European model output) to the ASCII characters JSON requires.
+var fullWidthReplacer = strings.NewReplacer(
+	"｛", "{",
+	"｝", "}",
+	"［", "[",
+	"］", "]",
+	"：", ":",
+	"，", ",",
+	"＂", `"`,
+	"“", `"`,
+	"”", `"`,
+	"„", `"`,
+	"«", `"`,
+	"»", `"`,
+	" ", " ",
+	"　", " ",
+)
+
+// normalizePunctuation rewrites full-width punctuation to its ASCII equivalent.
+func normalizePunctuation(s string) string {
+	return fullWidthReplacer.Replace(s)
+}
+
+// extractJSONObjects returns every balanced {...} span in s, outermost first.
+// Braces inside JSON strings are ignored so titles like "{draft}" don't break matching.
+func extractJSONObjects(s string) []string {
+	var candidates []string
+	for start := 0; start < len(s) && len(candidates) < maxJSONCandidates; start++ {
+		if s[start] != '{' {
+			continue
+		}
+		if end := matchingBrace(s, start); end != -1 {
+			candidates = append(candidates, s[start:end+1])
+		}
+	}
+	return candidates
+}
+
+// matchingBrace returns the index of the '}' closing the '{' at start, or -1.
+func matchingBrace(s string, start int) int {
+	depth := 0
+	inString := false
+	escaped := false
+
+	for i := start; i < len(s); i++ {
+		c := s[i]
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+
+		switch c {
+		case '"':
+			inString = true
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// truncateForLog shortens s to at most limit bytes for logging, marking the cut.
+func truncateForLog(s string, limit int) string {
+	if len(s) <= limit {
+		return s
+	}
+	return s[:limit] + "...(truncated)"
+}
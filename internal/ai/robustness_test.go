@@ -8,7 +8,7 @@ import (
 )
 
 func TestCategorize_MalformedOutputRobustness(t *testing.T) {
-	tokenizer, _ := NewTokenizer("cl100k_base")
+	tokenizer := testTokenizer(t)
 	ctxMgr := NewContextManager(tokenizer, 4096)
 	validCats := []string{"Work", "Personal", "Finance"}
 
@@ -84,12 +84,12 @@ func TestCategorize_MalformedOutputRobustness(t *testing.T) {
 				},
 			}
 
-			engine := &MLXEngine{
+			engine := withRouter(&MLXEngine{
 				llm:             mock,
 				models:          []config.ModelDefinition{{Name: "test-model", URL: "http://mock-api.com/v1/chat/completions"}},
 				ctxMgr:          ctxMgr,
 				validCategories: validCats,
-			}
+			})
 
 			result, err := engine.Categorize(context.Background(), "test text")
 
@@ -14,6 +14,9 @@ const (
 	ComplexityComplex TaskComplexity = "complex"
 )
 
+// complexityPrompt opens the prompt of the classification pass.
+const complexityPrompt = `Analyze the document snippet and determine if it requires "simple" or "complex" reasoning for categorization.`
+
 // ModelRouter determines the best model path for a document.
 type ModelRouter struct {
 	engine *MLXEngine
@@ -34,7 +37,7 @@ func (r *ModelRouter) ClassifyTask(ctx context.Context, text string) (TaskComple
 	// Truncate text for a quick classification pass (e.g., first 500 tokens)
 	snippet := r.engine.ctxMgr.Truncate(text, 500, StrategySlidingWindow)
 
-	prompt := fmt.Sprintf(`Analyze the document snippet and determine if it requires "simple" or "complex" reasoning for categorization.
+	prompt := fmt.Sprintf(complexityPrompt+`
 Simple: Standard receipts, clear invoices, brief letters, simple markdown/txt files.
 Complex: Technical papers, multi-page legal contracts, unstructured notes, or documents with ambiguous context.
 
@@ -50,8 +53,7 @@ Document Snippet:
 		Temperature: 0.0, // Strict deterministic output
 	}
 
-	// We'd use the engine's internal client here (simplified for draft)
-	resp, err := r.engine.llm.CreateChatCompletion(ctx, req)
+	resp, err := r.engine.chat(ctx, req)
 	if err != nil {
 		return ComplexitySimple, err
 	}
@@ -0,0 +1,161 @@
+package ai
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// salienceGap marks where paragraphs were left out by salience extraction.
+const salienceGap = "\n[... omitted ...]\n"
+
+var paragraphBreak = regexp.MustCompile(`\n\s*\n`)
+
+// stopWords are left out of category vocabularies; they say nothing about a document's kind.
+var stopWords = map[string]bool{
+	"the": true, "and": true, "for": true, "with": true, "not": true, "from": true, "this": true,
+	"that": true, "are": true, "was": true, "our": true, "your": true, "any": true, "all": true,
+	"other": true, "such": true, "into": true, "about": true, "misc": true, "etc": true,
+}
+
+// TruncateSalient keeps the paragraphs that score highest for the vocabulary terms
+// (TF-IDF, with document frequencies taken over the text's own paragraphs) within limit
+// tokens, in their original order. The first paragraph, which usually names the
+// document, is kept when it fits. Without vocabulary it falls back to middle extraction.
+func (cm *ContextManager) TruncateSalient(text string, limit int, vocabulary []string) string {
+	if cm.tokenizer.CountTokens(text) <= limit {
+		return text
+	}
+	terms := make(map[string]bool)
+	for _, v := range vocabulary {
+		for _, w := range salienceWords(v) {
+			if !stopWords[w] {
+				terms[w] = true
+			}
+		}
+	}
+	paragraphs := splitParagraphs(text)
+	if len(terms) == 0 || len(paragraphs) < 2 {
+		return cm.Truncate(text, limit, StrategyMiddleExtraction)
+	}
+
+	// Term frequencies per paragraph, and in how many paragraphs each term occurs
+	tf := make([]map[string]int, len(paragraphs))
+	lengths := make([]int, len(paragraphs))
+	df := make(map[string]int)
+	for i, p := range paragraphs {
+		tf[i] = make(map[string]int)
+		words := salienceWords(p)
+		lengths[i] = len(words)
+		for _, w := range words {
+			if terms[w] {
+				if tf[i][w] == 0 {
+					df[w]++
+				}
+				tf[i][w]++
+			}
+		}
+	}
+	scores := make([]float64, len(paragraphs))
+	for i := range paragraphs {
+		for w, n := range tf[i] {
+			idf := math.Log(float64(len(paragraphs))/float64(df[w])) + 1
+			scores[i] += float64(n) / math.Sqrt(float64(lengths[i])) * idf
+		}
+	}
+
+	order := make([]int, len(paragraphs))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order[1:], func(a, b int) bool { return scores[order[1+a]] > scores[order[1+b]] })
+
+	gapTokens := cm.tokenizer.CountTokens(salienceGap)
+	keep := make([]bool, len(paragraphs))
+	used := 0
+	for _, i := range order {
+		if i > 0 && scores[i] == 0 {
+			break
+		}
+		if n := cm.tokenizer.CountTokens(paragraphs[i]) + gapTokens; used+n <= limit {
+			keep[i] = true
+			used += n
+		}
+	}
+	if used == 0 {
+		return cm.Truncate(text, limit, StrategyMiddleExtraction)
+	}
+
+	var b strings.Builder
+	for i, p := range paragraphs {
+		switch {
+		case keep[i]:
+			if b.Len() > 0 && keep[i-1] {
+				b.WriteString("\n\n")
+			}
+			b.WriteString(p)
+		case i == 0 || keep[i-1]:
+			b.WriteString(salienceGap)
+		}
+	}
+	return b.String()
+}
+
+// splitParagraphs splits text on blank lines, or on line breaks when it has none
+// (text extracted from PDFs often lacks blank lines).
+func splitParagraphs(text string) []string {
+	parts := paragraphBreak.Split(text, -1)
+	if len(parts) < 2 {
+		parts = strings.Split(text, "\n")
+	}
+	var out []string
+	for _, p := range parts {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// salienceWords lowercases text into words of three or more letters, with plural
+// endings trimmed so "invoices" matches "invoice".
+func salienceWords(text string) []string {
+	var words []string
+	for _, w := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	}) {
+		if len([]rune(w)) < 3 {
+			continue
+		}
+		switch {
+		case strings.HasSuffix(w, "ies") && len(w) > 4:
+			w = strings.TrimSuffix(w, "ies") + "y"
+		case strings.HasSuffix(w, "xes"):
+			w = strings.TrimSuffix(w, "es")
+		case strings.HasSuffix(w, "s") && !strings.HasSuffix(w, "ss"):
+			w = strings.TrimSuffix(w, "s")
+		}
+		words = append(words, w)
+	}
+	return words
+}
+
+// categoryVocabulary returns the text describing categories (their path segments,
+// descriptions, and example document types) for salience scoring.
+func (e *MLXEngine) categoryVocabulary(categories []string) []string {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	vocabulary := append([]string(nil), categories...)
+	if e.taxonomy != nil {
+		for _, entry := range e.taxonomy.Entries() {
+			vocabulary = append(vocabulary, entry.Description)
+			vocabulary = append(vocabulary, entry.Examples...)
+		}
+	}
+	for _, d := range e.descriptions {
+		vocabulary = append(vocabulary, d)
+	}
+	return vocabulary
+}
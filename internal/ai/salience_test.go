@@ -0,0 +1,59 @@
+package ai
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSalienceWords(t *testing.T) {
+	got := salienceWords("Invoices, TAXES and expenses: a policy; 2024 companies")
+	want := []string{"invoice", "tax", "and", "expense", "policy", "2024", "company"}
+	if !slices.Equal(got, want) {
+		t.Errorf("salienceWords = %q, want %q", got, want)
+	}
+}
+
+func TestTruncateSalient(t *testing.T) {
+	tokenizer := testTokenizer(t)
+	cm := NewContextManager(tokenizer, 4096)
+
+	filler := strings.Repeat("The weather was pleasant and the meeting ran long. ", 8)
+	text := strings.Join([]string{
+		"ACME Corp quarterly statement",
+		filler,
+		"Invoice number 4411: amount due for consulting, tax included. Pay this invoice by March.",
+		filler,
+		filler,
+		"Tax summary for the invoice above.",
+		filler,
+	}, "\n\n")
+	vocabulary := []string{"Finance/Invoices", "Finance/Taxes", "Travel", "Misc"}
+
+	got := cm.TruncateSalient(text, 80, vocabulary)
+	if n := tokenizer.CountTokens(got); n > 80 {
+		t.Errorf("result has %d tokens, limit 80", n)
+	}
+	for _, want := range []string{"ACME Corp quarterly statement", "Invoice number 4411", "Tax summary"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("result is missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "weather") {
+		t.Errorf("result kept filler:\n%s", got)
+	}
+	if strings.Index(got, "Invoice number") > strings.Index(got, "Tax summary") {
+		t.Error("paragraphs are out of document order")
+	}
+	if !strings.Contains(got, "[... omitted ...]") {
+		t.Error("expected an omission marker")
+	}
+
+	// Without matching vocabulary it falls back to middle extraction
+	if got := cm.TruncateSalient(text, 80, nil); !strings.Contains(got, "[... content extracted ...]") {
+		t.Errorf("expected middle extraction without vocabulary, got:\n%s", got)
+	}
+	if got := cm.TruncateSalient("short", 80, vocabulary); got != "short" {
+		t.Errorf("text within the limit changed: %q", got)
+	}
+}
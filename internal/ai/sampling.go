@@ -0,0 +1,54 @@
+package ai
+
+import "fmt"
+
+// Sampling tunes the generation parameters sent with requests.
+type Sampling struct {
+	// Temperature of single-sample classification; voting samples use the vote temperature.
+	Temperature float64
+	// TopP is the nucleus sampling probability of classification requests; 0 leaves the
+	// server's default.
+	TopP float64
+	// MaxTokens limits each response; 0 uses the output budget of the context window.
+	MaxTokens int
+	// Stop sequences end classification responses early.
+	Stop []string
+}
+
+// defaultSampling is used until SetSampling is called.
+var defaultSampling = Sampling{Temperature: defaultTemperature}
+
+// SetSampling sets the generation parameters of requests.
+func (e *MLXEngine) SetSampling(s Sampling) error {
+	switch {
+	case s.Temperature < 0 || s.Temperature > 2:
+		return fmt.Errorf("temperature must be between 0 and 2, got %v", s.Temperature)
+	case s.TopP < 0 || s.TopP > 1:
+		return fmt.Errorf("top_p must be between 0 and 1 (0 for the server default), got %v", s.TopP)
+	case s.MaxTokens < 0:
+		return fmt.Errorf("max_tokens must not be negative")
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.sampling = s
+	return nil
+}
+
+// maxTokens returns the response limit sent with classification requests: the configured
+// one, or the output budget.
+func (e *MLXEngine) maxTokens() int {
+	e.mu.RLock()
+	limit := e.sampling.MaxTokens
+	e.mu.RUnlock()
+	if limit > 0 {
+		return limit
+	}
+	return e.outputBudget()
+}
+
+// outputBudget returns the response tokens the context manager reserves, the limit of
+// summaries, extraction, and other requests max_tokens doesn't apply to.
+func (e *MLXEngine) outputBudget() int {
+	_, _, _, output := e.ctxMgr.GetBudgets()
+	return output
+}
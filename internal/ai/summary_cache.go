@@ -0,0 +1,55 @@
+package ai
+
+import (
+	"context"
+	"crypto/sha256"
+	"docs_organiser/internal/storage"
+	"encoding/hex"
+	"fmt"
+	"log"
+)
+
+// SetSummaryCache keeps MapReduce summaries in store, so classifying the same large
+// document again (retries, resumed runs) doesn't repeat the summarization calls.
+func (e *MLXEngine) SetSummaryCache(store storage.Store) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.summaryCache = store
+}
+
+// summaryKey identifies a summary by the document text, the token budget it was
+// reduced to, and the model that wrote it.
+func summaryKey(text string, limit int, modelName string) string {
+	sum := sha256.Sum256([]byte(text))
+	return fmt.Sprintf("summary:%s:%d:%s", hex.EncodeToString(sum[:]), limit, modelName)
+}
+
+// cachedSummarize is MapReduceSummarize through the summary cache. cached reports a hit.
+// Cache failures are logged and fall through to summarizing.
+func (e *MLXEngine) cachedSummarize(ctx context.Context, text string, limit int, modelName, apiURL string) (summary string, cached bool, err error) {
+	e.mu.RLock()
+	store := e.summaryCache
+	e.mu.RUnlock()
+	if store == nil {
+		summary, err = e.MapReduceSummarize(ctx, text, limit, modelName, apiURL)
+		return summary, false, err
+	}
+
+	key := summaryKey(text, limit, modelName)
+	found, err := store.Load(key, &summary)
+	if err != nil {
+		log.Printf("[!] Failed to read cached summary: %v", err)
+	}
+	if found && err == nil {
+		return summary, true, nil
+	}
+
+	summary, err = e.MapReduceSummarize(ctx, text, limit, modelName, apiURL)
+	if err != nil {
+		return "", false, err
+	}
+	if err := store.Save(key, summary); err != nil {
+		log.Printf("[!] Failed to cache summary: %v", err)
+	}
+	return summary, false, nil
+}
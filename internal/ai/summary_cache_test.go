@@ -0,0 +1,51 @@
+package ai
+
+import (
+	"context"
+	"docs_organiser/internal/storage"
+	"strings"
+	"testing"
+)
+
+func TestCachedSummarize(t *testing.T) {
+	store, err := storage.NewBadgerStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewBadgerStore: %v", err)
+	}
+	defer store.Close()
+
+	tokenizer := testTokenizer(t)
+	var responses []*chatResponse
+	for i := 0; i < 50; i++ {
+		responses = append(responses, &chatResponse{Choices: []choice{{Message: message{Content: "short summary"}}}})
+	}
+	mock := &MockLLMClient{Responses: responses}
+	engine := &MLXEngine{llm: mock, ctxMgr: NewContextManager(tokenizer, 512)}
+	engine.SetSummaryCache(store)
+
+	text := strings.Repeat("The quarterly report covers revenue, costs, and hiring plans. ", 200)
+	first, cached, err := engine.cachedSummarize(context.Background(), text, 200, "model-a", "")
+	if err != nil || cached {
+		t.Fatalf("first call = cached %v, err %v; want a fresh summary", cached, err)
+	}
+	calls := mock.CallCount
+	if calls == 0 {
+		t.Fatal("expected the first call to summarize with the model")
+	}
+
+	second, cached, err := engine.cachedSummarize(context.Background(), text, 200, "model-a", "")
+	if err != nil || !cached || second != first || mock.CallCount != calls {
+		t.Errorf("second call = %q, cached %v, err %v, %d model calls; want the cached summary and no calls",
+			second, cached, err, mock.CallCount-calls)
+	}
+
+	// A different budget or model is summarized again
+	for _, tc := range []struct {
+		limit int
+		model string
+	}{{150, "model-a"}, {200, "model-b"}} {
+		if _, cached, _ := engine.cachedSummarize(context.Background(), text, tc.limit, tc.model, ""); cached {
+			t.Errorf("limit %d, model %s: got a cached summary", tc.limit, tc.model)
+		}
+	}
+}
@@ -2,25 +2,61 @@ package ai
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/pkoukk/tiktoken-go"
 )
 
-// Tokenizer wraps the tiktoken library for counting tokens.
+// encoding is implemented by tiktoken encodings and HuggingFace tokenizers.
+type encoding interface {
+	Encode(text string, allowedSpecial []string, disallowedSpecial []string) []int
+	Decode(tokens []int) string
+}
+
+// Tokenizer counts tokens with a tiktoken encoding or a model's own tokenizer.
 type Tokenizer struct {
-	encoding *tiktoken.Tiktoken
+	encoding encoding
+	counts   *countCache
 }
 
-// NewTokenizer creates a new Tokenizer using the specified encoding (e.g., cl100k_base).
+// NewTokenizer creates a new Tokenizer using the specified encoding (e.g., cl100k_base),
+// or the HuggingFace tokenizer.json at that path, so budgets match the model's vocabulary.
 func NewTokenizer(encodingName string) (*Tokenizer, error) {
+	if strings.HasSuffix(strings.ToLower(encodingName), ".json") {
+		enc, err := loadHFTokenizer(encodingName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load tokenizer %s: %w", encodingName, err)
+		}
+		return &Tokenizer{encoding: enc, counts: newCountCache(countCacheSize)}, nil
+	}
 	enc, err := tiktoken.GetEncoding(encodingName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get encoding '%s': %w", encodingName, err)
 	}
-	return &Tokenizer{encoding: enc}, nil
+	return &Tokenizer{encoding: enc, counts: newCountCache(countCacheSize)}, nil
+}
+
+// countCacheSize is how many token counts are remembered. The same system prompts,
+// category guides, and boilerplate are counted again for every file.
+const countCacheSize = 4096
+
+// ResetCounts forgets the remembered token counts, freeing their memory.
+func (t *Tokenizer) ResetCounts() {
+	if t.counts != nil {
+		t.counts.clear()
+	}
 }
 
 // CountTokens returns the number of tokens in the given text.
 func (t *Tokenizer) CountTokens(text string) int {
-	return len(t.encoding.Encode(text, nil, nil))
+	if t.counts == nil || text == "" {
+		return len(t.encoding.Encode(text, nil, nil))
+	}
+	key := t.counts.key(text)
+	if n, ok := t.counts.get(key); ok {
+		return n
+	}
+	n := len(t.encoding.Encode(text, nil, nil))
+	t.counts.put(key, n)
+	return n
 }
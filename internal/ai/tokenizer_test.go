@@ -1,6 +1,7 @@
 package ai
 
 import (
+	"docs_organiser/internal/config"
 	"testing"
 )
 
@@ -69,3 +70,62 @@ func TestTokenizer_CountTokens(t *testing.T) {
 		})
 	}
 }
+
+func TestCountCache(t *testing.T) {
+	c := newCountCache(2)
+	a, b, d := c.key("a"), c.key("b"), c.key("d")
+	c.put(a, 1)
+	c.put(b, 2)
+	if _, ok := c.get(a); !ok { // a is now the most recently used
+		t.Fatal("expected a to be cached")
+	}
+	c.put(d, 3) // evicts b
+	if _, ok := c.get(b); ok {
+		t.Error("expected b to be evicted")
+	}
+	for key, want := range map[countKey]int{a: 1, d: 3} {
+		if n, ok := c.get(key); !ok || n != want {
+			t.Errorf("get = %d, %v; want %d", n, ok, want)
+		}
+	}
+	c.clear()
+	if _, ok := c.get(a); ok || c.order.Len() != 0 {
+		t.Errorf("clear kept %d entries", c.order.Len())
+	}
+	c.put(b, 2)
+	if n, ok := c.get(b); !ok || n != 2 {
+		t.Errorf("get after clear = %d, %v; want 2", n, ok)
+	}
+
+	// Cached counts match fresh ones
+	tokenizer := testTokenizer(t)
+	for i := 0; i < 2; i++ {
+		if n := tokenizer.CountTokens("Hello, world!"); n != 4 {
+			t.Errorf("CountTokens pass %d = %d, want 4", i+1, n)
+		}
+	}
+}
+
+// testTokenizer returns the cl100k_base tokenizer, skipping the test when its vocabulary
+// can't be loaded (it is downloaded on first use, so offline runs without a cache fail).
+func testTokenizer(t *testing.T) *Tokenizer {
+	t.Helper()
+	tokenizer, err := NewTokenizer("cl100k_base")
+	if err != nil {
+		t.Skipf("cl100k_base tokenizer unavailable: %v", err)
+	}
+	return tokenizer
+}
+
+// testEngine returns an engine with one model at localhost, skipping the test like
+// testTokenizer when the cl100k_base vocabulary can't be loaded.
+func testEngine(t *testing.T) *MLXEngine {
+	t.Helper()
+	engine, err := NewMLXEngine("http://localhost:8080/v1", []config.ModelDefinition{
+		{Name: "test-model", URL: "http://localhost:8080/v1"},
+	}, 4096, "cl100k_base")
+	if err != nil {
+		t.Skipf("cl100k_base tokenizer unavailable: %v", err)
+	}
+	return engine
+}
@@ -0,0 +1,159 @@
+package ai
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+// TLSOptions configure HTTPS connections to model servers, e.g. behind an internal TLS proxy.
+type TLSOptions struct {
+	// CAFile is a PEM bundle trusted in addition to the system roots.
+	CAFile string
+	// CertFile and KeyFile enable mutual TLS with a client certificate.
+	CertFile string
+	KeyFile  string
+	// InsecureSkipVerify disables server certificate verification. Use only for testing.
+	InsecureSkipVerify bool
+}
+
+func (o TLSOptions) tlsConfig() (*tls.Config, error) {
+	cfg := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: o.InsecureSkipVerify,
+	}
+
+	if o.CAFile != "" {
+		pem, err := os.ReadFile(o.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA bundle %s", o.CAFile)
+		}
+		cfg.RootCAs = pool
+	}
+
+	if o.CertFile != "" || o.KeyFile != "" {
+		if o.CertFile == "" || o.KeyFile == "" {
+			return nil, fmt.Errorf("client certificate and key must be configured together")
+		}
+		cert, err := tls.LoadX509KeyPair(o.CertFile, o.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	return cfg, nil
+}
+
+// ConfigureTLS applies TLS options to every request the engine makes.
+func (e *MLXEngine) ConfigureTLS(opts TLSOptions) error {
+	tlsCfg, err := opts.tlsConfig()
+	if err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.transport.TLSClientConfig = tlsCfg
+	return nil
+}
+
+// TransportOptions tune the connections to model servers. Zero values keep Go's defaults,
+// except MaxIdleConnsPerHost, whose default of 2 makes most of many concurrent workers
+// open a new connection for every request.
+type TransportOptions struct {
+	// MaxIdleConnsPerHost is how many idle keep-alive connections are kept to each server.
+	MaxIdleConnsPerHost int
+	// IdleConnTimeout closes keep-alive connections that have been idle this long.
+	IdleConnTimeout time.Duration
+	// DisableKeepAlives opens a new connection for every request.
+	DisableKeepAlives bool
+	// RequestTimeout bounds each chat completion request (60 seconds by default).
+	RequestTimeout time.Duration
+}
+
+// ConfigureTransport applies connection options to every request the engine makes.
+func (e *MLXEngine) ConfigureTransport(opts TransportOptions) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if opts.MaxIdleConnsPerHost > 0 {
+		e.transport.MaxIdleConnsPerHost = opts.MaxIdleConnsPerHost
+		e.transport.MaxIdleConns = max(e.transport.MaxIdleConns, opts.MaxIdleConnsPerHost)
+	}
+	if opts.IdleConnTimeout > 0 {
+		e.transport.IdleConnTimeout = opts.IdleConnTimeout
+	}
+	e.transport.DisableKeepAlives = opts.DisableKeepAlives
+	if net, ok := e.llm.(*NetLLMClient); ok && opts.RequestTimeout > 0 {
+		net.client.Timeout = opts.RequestTimeout
+	}
+}
+
+// SetProxy routes all AI requests through the given HTTP(S) proxy. An empty URL keeps
+// the default behavior of honoring HTTP_PROXY/HTTPS_PROXY/NO_PROXY.
+func (e *MLXEngine) SetProxy(proxyURL string) error {
+	if proxyURL == "" {
+		return nil
+	}
+	u, err := url.Parse(proxyURL)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return fmt.Errorf("invalid proxy URL %q", proxyURL)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.transport.Proxy = http.ProxyURL(u)
+	return nil
+}
+
+// SetHeaders adds extra headers (e.g. X-API-Key or gateway tokens) to every AI request.
+func (e *MLXEngine) SetHeaders(headers map[string]string) {
+	e.headersMu.Lock()
+	defer e.headersMu.Unlock()
+	e.headers = headers
+}
+
+// httpClient returns a client sharing the engine's transport with the given timeout.
+func (e *MLXEngine) httpClient(timeout time.Duration) *http.Client {
+	client := &http.Client{Timeout: timeout}
+	if e.transport != nil {
+		client.Transport = &engineRoundTripper{engine: e}
+	}
+	return client
+}
+
+// engineRoundTripper applies the engine's extra headers before delegating to its transport.
+type engineRoundTripper struct {
+	engine *MLXEngine
+}
+
+func (rt *engineRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	// headersMu rather than mu: requests are issued while callers hold mu for reading
+	rt.engine.headersMu.RLock()
+	headers := rt.engine.headers
+	rt.engine.headersMu.RUnlock()
+
+	if len(headers) > 0 {
+		req = req.Clone(req.Context())
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+	}
+	return rt.engine.transport.RoundTrip(req)
+}
+
+// CloseIdleConnections lets http.Client.CloseIdleConnections reach the shared transport.
+func (rt *engineRoundTripper) CloseIdleConnections() {
+	rt.engine.transport.CloseIdleConnections()
+}
@@ -0,0 +1,119 @@
+package ai
+
+import (
+	"context"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestConfigureTLS(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data": [{"id": "test-model"}]}`))
+	}))
+	defer srv.Close()
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	if err := os.WriteFile(caFile, certPEM, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	newEngine := func() *MLXEngine {
+		return &MLXEngine{transport: http.DefaultTransport.(*http.Transport).Clone()}
+	}
+
+	t.Run("Untrusted certificate fails", func(t *testing.T) {
+		if _, err := newEngine().GetAvailableModelsForURL(context.Background(), srv.URL); err == nil {
+			t.Fatal("expected TLS verification error")
+		}
+	})
+
+	t.Run("Custom CA bundle", func(t *testing.T) {
+		e := newEngine()
+		if err := e.ConfigureTLS(TLSOptions{CAFile: caFile}); err != nil {
+			t.Fatalf("ConfigureTLS() error = %v", err)
+		}
+		models, err := e.GetAvailableModelsForURL(context.Background(), srv.URL)
+		if err != nil || len(models) != 1 || models[0] != "test-model" {
+			t.Fatalf("GetAvailableModelsForURL() = %v, %v", models, err)
+		}
+	})
+
+	t.Run("Insecure skip verify", func(t *testing.T) {
+		e := newEngine()
+		if err := e.ConfigureTLS(TLSOptions{InsecureSkipVerify: true}); err != nil {
+			t.Fatalf("ConfigureTLS() error = %v", err)
+		}
+		if _, err := e.GetAvailableModelsForURL(context.Background(), srv.URL); err != nil {
+			t.Fatalf("GetAvailableModelsForURL() error = %v", err)
+		}
+	})
+
+	t.Run("Invalid options", func(t *testing.T) {
+		if err := newEngine().ConfigureTLS(TLSOptions{CertFile: "client.pem"}); err == nil {
+			t.Error("expected error when key is missing")
+		}
+		if err := newEngine().ConfigureTLS(TLSOptions{CAFile: filepath.Join(t.TempDir(), "missing.pem")}); err == nil {
+			t.Error("expected error for missing CA bundle")
+		}
+	})
+}
+
+func TestProxyAndHeaders(t *testing.T) {
+	var gotKey, gotVia string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// A forward proxy receives the absolute target URL
+		gotVia = r.URL.String()
+		gotKey = r.Header.Get("X-API-Key")
+		w.Write([]byte(`{"data": [{"id": "gateway-model"}]}`))
+	}))
+	defer proxy.Close()
+
+	e := &MLXEngine{transport: http.DefaultTransport.(*http.Transport).Clone()}
+	if err := e.SetProxy(proxy.URL); err != nil {
+		t.Fatalf("SetProxy() error = %v", err)
+	}
+	e.SetHeaders(map[string]string{"x-api-key": "secret"})
+
+	models, err := e.GetAvailableModelsForURL(context.Background(), "http://inference.internal/v1")
+	if err != nil {
+		t.Fatalf("GetAvailableModelsForURL() error = %v", err)
+	}
+	if len(models) != 1 || models[0] != "gateway-model" {
+		t.Errorf("unexpected models: %v", models)
+	}
+	if gotVia != "http://inference.internal/v1/models" {
+		t.Errorf("request not routed through proxy, got %q", gotVia)
+	}
+	if gotKey != "secret" {
+		t.Errorf("expected X-API-Key header, got %q", gotKey)
+	}
+
+	if err := e.SetProxy("::not a url"); err == nil {
+		t.Error("expected error for invalid proxy URL")
+	}
+}
+
+func TestConfigureTransport(t *testing.T) {
+	e := &MLXEngine{transport: http.DefaultTransport.(*http.Transport).Clone()}
+	e.llm = &NetLLMClient{client: e.httpClient(60 * time.Second)}
+
+	e.ConfigureTransport(TransportOptions{MaxIdleConnsPerHost: 200, IdleConnTimeout: 5 * time.Minute, RequestTimeout: 3 * time.Minute})
+	if e.transport.MaxIdleConnsPerHost != 200 || e.transport.MaxIdleConns < 200 || e.transport.IdleConnTimeout != 5*time.Minute {
+		t.Errorf("transport = %d idle per host, %d idle, %s timeout", e.transport.MaxIdleConnsPerHost, e.transport.MaxIdleConns, e.transport.IdleConnTimeout)
+	}
+	if got := e.llm.(*NetLLMClient).client.Timeout; got != 3*time.Minute {
+		t.Errorf("request timeout = %s, want 3m", got)
+	}
+
+	// Zero values keep what is set
+	e.ConfigureTransport(TransportOptions{DisableKeepAlives: true})
+	if e.transport.MaxIdleConnsPerHost != 200 || !e.transport.DisableKeepAlives || e.llm.(*NetLLMClient).client.Timeout != 3*time.Minute {
+		t.Error("zero options reset the transport")
+	}
+}
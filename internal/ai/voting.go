@@ -0,0 +1,45 @@
+package ai
+
+import "fmt"
+
+// SetVoting enables self-consistency voting: each document is classified samples times at
+// temperature and the majority category wins. samples <= 1 disables voting.
+func (e *MLXEngine) SetVoting(samples int, temperature float64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.votes = samples
+	e.voteTemperature = temperature
+}
+
+// vote calls sample n times and returns the first answer for the category most samples
+// agree on; ties go to the category that was returned first. Failed samples count
+// against agreement, and only fail the vote when every sample failed.
+func vote(n int, temperature float64, sample func(temperature float64) (*AnalysisResult, error), metadata *CategorizationMetadata) (*AnalysisResult, error) {
+	votes := make(map[string]int)
+	var answers []*AnalysisResult // first answer per category, in order of appearance
+	var lastErr error
+	for i := 0; i < n; i++ {
+		result, err := sample(temperature)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if votes[result.Category] == 0 {
+			answers = append(answers, result)
+		}
+		votes[result.Category]++
+	}
+	if len(answers) == 0 {
+		return nil, fmt.Errorf("all %d samples failed: %w", n, lastErr)
+	}
+
+	winner := answers[0]
+	for _, answer := range answers[1:] {
+		if votes[answer.Category] > votes[winner.Category] {
+			winner = answer
+		}
+	}
+	metadata.Votes = votes
+	metadata.Agreement = float64(votes[winner.Category]) / float64(n)
+	return winner, nil
+}
@@ -0,0 +1,247 @@
+// Package aitest provides an OpenAI-compatible mock model server, so the classification
+// flow can be tested end to end without a real model.
+package aitest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"docs_organiser/internal/ai"
+	"docs_organiser/internal/config"
+)
+
+// Reply is one scripted answer to a chat completion request.
+type Reply struct {
+	// Content is the assistant message returned to the client.
+	Content string
+	// Status, when set to anything but 200, fails the request with that HTTP status.
+	Status int
+	// Delay holds the response back; a request cancelled while waiting gets no answer.
+	Delay time.Duration
+}
+
+// Category replies with a well-formed classification.
+func Category(category, title string, confidence float64) Reply {
+	body, _ := json.Marshal(map[string]any{"category": category, "title": title, "confidence_score": confidence})
+	return Reply{Content: string(body)}
+}
+
+// Failure replies with an HTTP error.
+func Failure(status int) Reply {
+	return Reply{Status: status}
+}
+
+// Message is one chat message of a request.
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// Request is a chat completion request received by the server.
+type Request struct {
+	Model       string    `json:"model"`
+	Messages    []Message `json:"messages"`
+	Temperature float64   `json:"temperature"`
+}
+
+// System returns the system prompt of the request.
+func (r Request) System() string {
+	for _, m := range r.Messages {
+		if m.Role == "system" {
+			return m.Content
+		}
+	}
+	return ""
+}
+
+// User returns the last user message of the request: the document prompt, or the
+// correction asked for after an invalid answer.
+func (r Request) User() string {
+	for i := len(r.Messages) - 1; i >= 0; i-- {
+		if r.Messages[i].Role == "user" {
+			return r.Messages[i].Content
+		}
+	}
+	return ""
+}
+
+// Server is a mock model server serving a single model at URL+"/v1". Scripted replies
+// are used in order; once they run out, Respond answers (by default with an error).
+// The router's complexity probes are always answered "simple" and are not recorded.
+type Server struct {
+	*httptest.Server
+	Model string
+
+	mu       sync.Mutex
+	script   []Reply
+	respond  func(Request) Reply
+	embed    func(string) []float64
+	requests []Request
+}
+
+// NewServer starts a server for model that is closed when the test ends.
+func NewServer(t testing.TB, model string) *Server {
+	s := &Server{Model: model}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/models", s.models)
+	mux.HandleFunc("POST /v1/chat/completions", s.chat)
+	mux.HandleFunc("POST /v1/embeddings", s.embeddings)
+	s.Server = httptest.NewServer(mux)
+	t.Cleanup(s.Close)
+	return s
+}
+
+// APIURL returns the OpenAI-compatible base URL, as configured in api_url.
+func (s *Server) APIURL() string {
+	return s.URL + "/v1"
+}
+
+// Script queues replies for the next requests, in order.
+func (s *Server) Script(replies ...Reply) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.script = append(s.script, replies...)
+}
+
+// Respond sets how requests are answered once the script is exhausted. It may be
+// called from several goroutines at once.
+func (s *Server) Respond(fn func(Request) Reply) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.respond = fn
+}
+
+// Embed sets how embeddings requests are answered: fn returns the vector of each input.
+// Without it, embeddings requests fail.
+func (s *Server) Embed(fn func(input string) []float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.embed = fn
+}
+
+// Requests returns the chat completion requests received so far.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// Engine returns an AI engine that classifies into categories using this server.
+func (s *Server) Engine(t testing.TB, categories ...string) *ai.MLXEngine {
+	return Engine(t, s.APIURL(), []config.ModelDefinition{{Name: s.Model, URL: s.APIURL()}}, categories...)
+}
+
+// Engine returns an AI engine for models, served at apiURL unless they name their own,
+// that classifies into categories. It skips the test when the cl100k_base tokenizer
+// can't be loaded: its vocabulary is downloaded on first use, so offline runs without a
+// cache can't build an engine.
+func Engine(t testing.TB, apiURL string, models []config.ModelDefinition, categories ...string) *ai.MLXEngine {
+	t.Helper()
+	engine, err := ai.NewMLXEngine(apiURL, models, 4096, "cl100k_base")
+	if err != nil {
+		t.Skipf("cl100k_base tokenizer unavailable: %v", err)
+	}
+	engine.SetCategories(categories)
+	return engine
+}
+
+func (s *Server) models(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, map[string]any{"data": []map[string]string{{"id": s.Model}}})
+}
+
+func (s *Server) chat(w http.ResponseWriter, r *http.Request) {
+	var req Request
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if isRoutingProbe(req) {
+		writeCompletion(w, req, "simple")
+		return
+	}
+
+	s.mu.Lock()
+	s.requests = append(s.requests, req)
+	var reply Reply
+	switch {
+	case len(s.script) > 0:
+		reply, s.script = s.script[0], s.script[1:]
+	case s.respond != nil:
+		respond := s.respond
+		s.mu.Unlock()
+		reply = respond(req)
+		s.mu.Lock()
+	default:
+		reply = Reply{Status: http.StatusInternalServerError, Content: "aitest: no reply scripted"}
+	}
+	s.mu.Unlock()
+
+	if reply.Delay > 0 {
+		select {
+		case <-time.After(reply.Delay):
+		case <-r.Context().Done():
+			return
+		}
+	}
+	if reply.Status != 0 && reply.Status != http.StatusOK {
+		http.Error(w, reply.Content, reply.Status)
+		return
+	}
+
+	writeCompletion(w, req, reply.Content)
+}
+
+func (s *Server) embeddings(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Input []string `json:"input"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.mu.Lock()
+	embed := s.embed
+	s.mu.Unlock()
+	if embed == nil {
+		http.Error(w, "aitest: no embeddings configured", http.StatusInternalServerError)
+		return
+	}
+	data := make([]map[string]any, len(req.Input))
+	for i, input := range req.Input {
+		data[i] = map[string]any{"index": i, "embedding": embed(input)}
+	}
+	writeJSON(w, map[string]any{"data": data})
+}
+
+// isRoutingProbe reports whether req asks whether a document is simple or complex.
+func isRoutingProbe(req Request) bool {
+	return req.System() == "" && strings.Contains(req.User(), `Return ONLY the word "simple" or "complex"`)
+}
+
+// writeCompletion answers req with content, counting words as tokens.
+func writeCompletion(w http.ResponseWriter, req Request, content string) {
+	prompt := 0
+	for _, m := range req.Messages {
+		prompt += len(strings.Fields(m.Content))
+	}
+	completion := len(strings.Fields(content))
+	writeJSON(w, map[string]any{
+		"model":   req.Model,
+		"choices": []map[string]any{{"message": Message{Role: "assistant", Content: content}}},
+		"usage":   map[string]int{"prompt_tokens": prompt, "completion_tokens": completion, "total_tokens": prompt + completion},
+	})
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		http.Error(w, fmt.Sprintf("aitest: %v", err), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,58 @@
+package aitest
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestServer(t *testing.T) {
+	srv := NewServer(t, "mock-model")
+	engine := srv.Engine(t, "Finance", "Travel", "Misc")
+
+	if model, err := engine.Preflight(context.Background()); err != nil || model != "mock-model" {
+		t.Fatalf("Preflight() = %q, %v", model, err)
+	}
+
+	// The scripted replies come first: an invalid answer, then its correction
+	srv.Script(Reply{Content: "I think it is an invoice."}, Category("Finance", "Invoice_ACME", 0.9))
+	srv.Respond(func(r Request) Reply {
+		if strings.Contains(r.User(), "Boarding") {
+			return Category("Travel", "Boarding_Pass", 0.8)
+		}
+		return Failure(503)
+	})
+
+	tests := []struct {
+		name         string
+		text         string
+		wantCategory string
+		wantErr      bool
+	}{
+		{"corrected after an invalid answer", "Invoice from ACME", "Finance", false},
+		{"answered by the responder", "Boarding pass LH123", "Travel", false},
+		{"server failure", "Something else", "Misc", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := engine.Categorize(context.Background(), tt.text)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if result.Analysis.Category != tt.wantCategory {
+				t.Errorf("category = %q, want %q", result.Analysis.Category, tt.wantCategory)
+			}
+		})
+	}
+
+	requests := srv.Requests()
+	if len(requests) != 2+1+3 {
+		t.Fatalf("got %d requests, want 6 (two for the correction, one, then three failed attempts)", len(requests))
+	}
+	if !strings.Contains(requests[1].User(), "invalid") {
+		t.Errorf("correction request user prompt = %q, want the validation error fed back", requests[1].User())
+	}
+	if !strings.Contains(requests[0].System(), "Finance, Travel, Misc") {
+		t.Errorf("system prompt does not list the categories: %q", requests[0].System())
+	}
+}
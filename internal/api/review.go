@@ -0,0 +1,204 @@
+package api
+
+import (
+	"docs_organiser/internal/audit"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// maxRecentDecisions is how many decisions the review log keeps besides those awaiting review.
+const maxRecentDecisions = 200
+
+// Review states of a decision.
+const (
+	reviewPending    = "pending"
+	reviewApproved   = "approved"
+	reviewOverridden = "overridden"
+	reviewUndone     = "undone"
+)
+
+// decision is a file outcome as the dashboard shows it.
+type decision struct {
+	ID          int       `json:"id"`
+	Time        time.Time `json:"time"`
+	Source      string    `json:"source"`
+	Status      string    `json:"status"`
+	Category    string    `json:"category,omitempty"`
+	Title       string    `json:"title,omitempty"`
+	Destination string    `json:"destination,omitempty"`
+	Confidence  float64   `json:"confidence"`
+	Fallback    bool      `json:"fallback,omitempty"`
+	Error       string    `json:"error,omitempty"`
+	// Review is empty for decisions that need none.
+	Review string `json:"review,omitempty"`
+
+	record audit.Record
+}
+
+// reviewLog keeps the recent decisions of the pipeline for the dashboard. Moves below
+// the confidence threshold, and fallbacks, await review until they are approved,
+// overridden, or undone; they are kept however many decisions follow.
+type reviewLog struct {
+	threshold float64
+
+	mu        sync.Mutex
+	nextID    int
+	decisions []*decision // oldest first
+}
+
+func newReviewLog(threshold float64) *reviewLog {
+	return &reviewLog{threshold: threshold}
+}
+
+// follow adds the outcome of every file p processes until results is closed.
+func (l *reviewLog) follow(results <-chan audit.Record) {
+	for rec := range results {
+		l.add(rec)
+	}
+}
+
+func (l *reviewLog) add(rec audit.Record) {
+	if rec.Time.IsZero() {
+		rec.Time = time.Now()
+	}
+	d := &decision{record: rec}
+	d.refresh()
+	if c := rec.Classification; c != nil && c.Analysis != nil {
+		d.Confidence = c.Analysis.ConfidenceScore
+	}
+	if rec.Status == audit.StatusMoved && rec.Photo == nil && (rec.Fallback || d.Confidence < l.threshold) {
+		d.Review = reviewPending
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.nextID++
+	d.ID = l.nextID
+	l.decisions = append(l.decisions, d)
+	// Drop the oldest decisions that don't await review
+	for excess := len(l.decisions) - maxRecentDecisions; excess > 0; excess-- {
+		i := slices.IndexFunc(l.decisions, func(d *decision) bool { return d.Review != reviewPending })
+		if i < 0 {
+			break
+		}
+		l.decisions = slices.Delete(l.decisions, i, i+1)
+	}
+}
+
+// refresh copies the record's fields into d.
+func (d *decision) refresh() {
+	rec := d.record
+	d.Time, d.Source, d.Status = rec.Time, rec.Source, rec.Status
+	d.Category, d.Title, d.Destination = rec.Category, rec.Title, rec.Destination
+	d.Fallback, d.Error = rec.Fallback, rec.Error
+}
+
+// snapshot returns copies of the recent decisions, newest first, and of those awaiting review.
+func (l *reviewLog) snapshot() (recent, pending []decision) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for i := len(l.decisions) - 1; i >= 0; i-- {
+		d := *l.decisions[i]
+		recent = append(recent, d)
+		if d.Review == reviewPending {
+			pending = append(pending, d)
+		}
+	}
+	return recent, pending
+}
+
+// get returns a copy of the decision with id.
+func (l *reviewLog) get(id int) (decision, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, d := range l.decisions {
+		if d.ID == id {
+			return *d, true
+		}
+	}
+	return decision{}, false
+}
+
+// resolve records the review of the decision with id and the record it resulted in.
+func (l *reviewLog) resolve(id int, review string, rec audit.Record) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, d := range l.decisions {
+		if d.ID == id {
+			d.Review, d.record = review, rec
+			d.refresh()
+			return
+		}
+	}
+}
+
+func (s *Server) handleGetReview(w http.ResponseWriter, r *http.Request) {
+	recent, pending := s.review.snapshot()
+	if recent == nil {
+		recent = []decision{}
+	}
+	if pending == nil {
+		pending = []decision{}
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"threshold": s.review.threshold,
+		"recent":    recent,
+		"pending":   pending,
+	})
+}
+
+// handleReviewAction approves, overrides, or undoes the decision named in the path.
+func (s *Server) handleReviewAction(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid decision id", http.StatusBadRequest)
+		return
+	}
+	d, ok := s.review.get(id)
+	if !ok {
+		http.Error(w, "decision not found", http.StatusNotFound)
+		return
+	}
+	if d.Review == reviewUndone {
+		http.Error(w, "the move was already undone", http.StatusConflict)
+		return
+	}
+
+	rec := d.record
+	review := r.PathValue("action")
+	switch review {
+	case "approve":
+		review = reviewApproved
+	case "override":
+		var req struct {
+			Category string `json:"category"`
+			Title    string `json:"title"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Category == "" {
+			http.Error(w, "override needs a category", http.StatusBadRequest)
+			return
+		}
+		if rec, err = s.pipeline.Override(r.Context(), rec, req.Category, req.Title); err != nil {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		review = reviewOverridden
+	case "undo":
+		if rec, err = s.pipeline.Undo(r.Context(), rec); err != nil {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		review = reviewUndone
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	s.review.resolve(id, review, rec)
+
+	d, _ = s.review.get(id)
+	json.NewEncoder(w).Encode(d)
+}
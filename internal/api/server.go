@@ -7,6 +7,7 @@ import (
 	"docs_organiser/internal/storage"
 	"encoding/json"
 	"fmt"
+	"log"
 	"net/http"
 	"sync"
 )
@@ -15,17 +16,25 @@ type Server struct {
 	cfg        *config.Config
 	pipeline   *pipeline.Pipeline
 	store      storage.Store
+	review     *reviewLog
 	mu         sync.RWMutex
 	currentCtx context.Context
 	cancelFunc context.CancelFunc
+	runDone    chan struct{}
+	httpServer *http.Server
+	closed     bool
 }
 
 func NewServer(cfg *config.Config, p *pipeline.Pipeline, store storage.Store) *Server {
-	return &Server{
+	s := &Server{
 		cfg:      cfg,
 		pipeline: p,
 		store:    store,
+		review:   newReviewLog(cfg.ReviewConfidence),
 	}
+	results, _ := p.Subscribe(256)
+	go s.review.follow(results)
+	return s
 }
 
 type StartRequest struct {
@@ -46,6 +55,8 @@ func (s *Server) Start() error {
 	mux.HandleFunc("POST /v1/pipeline/resume", s.handleResume)
 	mux.HandleFunc("GET /v1/pipeline/status", s.handleStatus)
 	mux.HandleFunc("GET /v1/config", s.handleGetConfig)
+	mux.HandleFunc("GET /v1/review", s.handleGetReview)
+	mux.HandleFunc("POST /v1/review/{id}/{action}", s.handleReviewAction)
 
 	mux.HandleFunc("GET /v1/models", s.handleGetModels)
 	mux.HandleFunc("POST /v1/models", s.handleAddModel)
@@ -62,10 +73,81 @@ func (s *Server) Start() error {
 		Handler: mux,
 	}
 
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return http.ErrServerClosed
+	}
+	s.httpServer = server
+	s.mu.Unlock()
+
 	fmt.Printf("[+] App Server started at http://localhost:%d\n", s.cfg.ServerPort)
 	return server.ListenAndServe()
 }
 
+// StartPipeline begins a run with the current settings, as the dashboard's start button does.
+func (s *Server) StartPipeline() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.cancelFunc != nil {
+		return fmt.Errorf("pipeline already running")
+	}
+	s.startRunLocked()
+	return nil
+}
+
+// startRunLocked launches the pipeline in the background. s.mu must be held.
+func (s *Server) startRunLocked() {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	s.currentCtx = ctx
+	s.cancelFunc = cancel
+	s.runDone = done
+
+	go func() {
+		defer close(done)
+		defer func() {
+			s.mu.Lock()
+			s.cancelFunc = nil
+			s.mu.Unlock()
+		}()
+		if err := s.pipeline.Run(ctx); err != nil && err != context.Canceled {
+			log.Printf("[!] Pipeline run failed: %v", err)
+		}
+	}()
+}
+
+// Shutdown stops accepting requests and drains any active run: no new files are started
+// and those in progress finish. If ctx ends first, the run is cancelled, interrupting them.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	s.closed = true
+	cancel, done, server := s.cancelFunc, s.runDone, s.httpServer
+	s.mu.Unlock()
+
+	if cancel != nil {
+		s.pipeline.Drain()
+		// Paused workers only notice the drain once woken
+		s.pipeline.Resume()
+	}
+
+	var err error
+	if server != nil {
+		err = server.Shutdown(ctx)
+	}
+	if done != nil {
+		select {
+		case <-done:
+		case <-ctx.Done():
+			cancel()
+			<-done
+			return ctx.Err()
+		}
+	}
+	return err
+}
+
 func (s *Server) handleStart(w http.ResponseWriter, r *http.Request) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -88,6 +170,12 @@ func (s *Server) handleStart(w http.ResponseWriter, r *http.Request) {
 		s.pipeline.DestDir = req.DestDir
 	}
 	if req.Model != "" {
+		if s.cfg.LocalOnly {
+			if err := config.EnsureLoopback(req.ModelURL); err != nil {
+				http.Error(w, "local-only mode: "+err.Error(), http.StatusForbidden)
+				return
+			}
+		}
 		s.pipeline.SetModel(req.Model, req.ModelURL)
 	}
 	if req.Workers > 0 {
@@ -99,21 +187,10 @@ func (s *Server) handleStart(w http.ResponseWriter, r *http.Request) {
 
 	// Persist changes if any were made via request
 	if req.SourceDir != "" || req.DestDir != "" || req.Model != "" || req.Workers > 0 || req.Limit > 0 {
-		_ = s.store.Save("user_settings", s.cfg)
+		_ = s.store.Save(s.cfg.SettingsKey(), s.cfg)
 	}
 
-	ctx, cancel := context.WithCancel(context.Background())
-	s.currentCtx = ctx
-	s.cancelFunc = cancel
-
-	go func() {
-		defer func() {
-			s.mu.Lock()
-			s.cancelFunc = nil
-			s.mu.Unlock()
-		}()
-		_ = s.pipeline.Run(ctx)
-	}()
+	s.startRunLocked()
 
 	w.WriteHeader(http.StatusAccepted)
 	json.NewEncoder(w).Encode(map[string]string{"status": "started"})
@@ -209,6 +286,13 @@ func (s *Server) handleAddModel(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if s.cfg.LocalOnly {
+		if err := config.EnsureLoopback(req.URL); err != nil {
+			http.Error(w, "local-only mode: "+err.Error(), http.StatusForbidden)
+			return
+		}
+	}
+
 	// Check if exists
 	exists := false
 	for _, m := range s.cfg.AllowedModels {
@@ -224,14 +308,14 @@ func (s *Server) handleAddModel(w http.ResponseWriter, r *http.Request) {
 			URL:  req.URL,
 		})
 		s.pipeline.AI.SetAllowedModels(s.cfg.AllowedModels)
-		_ = s.store.Save("user_settings", s.cfg)
+		_ = s.store.Save(s.cfg.SettingsKey(), s.cfg)
 	}
 
 	// If it's the first model, set it as default
 	if len(s.cfg.AllowedModels) == 1 {
 		s.cfg.DefaultModelName = s.cfg.AllowedModels[0].Name
 		s.pipeline.AI.SetDefaultModel(s.cfg.DefaultModelName)
-		_ = s.store.Save("user_settings", s.cfg)
+		_ = s.store.Save(s.cfg.SettingsKey(), s.cfg)
 	}
 
 	w.WriteHeader(http.StatusCreated)
@@ -263,7 +347,7 @@ func (s *Server) handleSetDefaultModel(w http.ResponseWriter, r *http.Request) {
 	s.mu.Lock()
 	s.cfg.DefaultModelName = req.Name
 	s.pipeline.AI.SetDefaultModel(req.Name)
-	_ = s.store.Save("user_settings", s.cfg)
+	_ = s.store.Save(s.cfg.SettingsKey(), s.cfg)
 	s.mu.Unlock()
 
 	w.WriteHeader(http.StatusOK)
@@ -293,7 +377,7 @@ func (s *Server) handleRemoveModel(w http.ResponseWriter, r *http.Request) {
 		s.pipeline.AI.SetDefaultModel(s.cfg.DefaultModelName)
 	}
 	s.pipeline.AI.SetAllowedModels(s.cfg.AllowedModels)
-	_ = s.store.Save("user_settings", s.cfg)
+	_ = s.store.Save(s.cfg.SettingsKey(), s.cfg)
 
 	w.WriteHeader(http.StatusOK)
 }
@@ -0,0 +1,104 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"docs_organiser/internal/ai"
+	"docs_organiser/internal/exif"
+)
+
+// Record statuses.
+const (
+	StatusMoved            = "moved"
+	StatusExtractionFailed = "extraction_failed"
+	StatusMoveFailed       = "move_failed"
+	StatusCancelled        = "cancelled"
+	// StatusSkipped means the destination name was taken and the collision policy kept the existing file.
+	StatusSkipped = "skipped"
+	// StatusClassified means the file was classified in classify-only mode and left in place.
+	StatusClassified = "classified"
+	// StatusUndone means a reviewer moved the organised file back to its source.
+	StatusUndone = "undone"
+	// StatusVetoed means the routing script vetoed the decision and the file stayed in the source.
+	StatusVetoed = "vetoed"
+)
+
+// Extraction summarizes the text extraction step for a file.
+type Extraction struct {
+	Chars      int           `json:"chars"`
+	Duration   time.Duration `json:"duration"`
+	Redactions int           `json:"redactions,omitempty"`
+}
+
+// Record is one line of the audit log, describing everything that happened to a single file.
+type Record struct {
+	Time           time.Time                `json:"time"`
+	Source         string                   `json:"source"`
+	Status         string                   `json:"status"`
+	Extraction     Extraction               `json:"extraction"`
+	Classification *ai.CategorizationResult `json:"classification,omitempty"`
+	// Fallback is set when classification failed and the file was routed to Misc (or,
+	// with Unprocessed, to the dead-letter folder).
+	Fallback bool `json:"fallback,omitempty"`
+	// Unprocessed is set when the file could not be extracted or classified and was moved
+	// to the dead-letter folder instead; Error holds the last failure.
+	Unprocessed bool `json:"unprocessed,omitempty"`
+	// Photo holds the EXIF data of images routed by photo templates rather than the model.
+	Photo       *exif.Info `json:"photo,omitempty"`
+	Category    string     `json:"category,omitempty"`
+	Title       string     `json:"title,omitempty"`
+	Destination string     `json:"destination,omitempty"`
+	Error       string     `json:"error,omitempty"`
+	// Duplicate is set when the destination already held identical content, so the
+	// source was removed rather than stored twice; Destination is the existing file.
+	Duplicate bool `json:"duplicate,omitempty"`
+	// NearDuplicateOf is the organised file whose text this file's nearly repeats, such as
+	// an earlier scan or version of the same document (see near_duplicates).
+	NearDuplicateOf string `json:"near_duplicate_of,omitempty"`
+	// Overridden is set when a reviewer moved the file to another category or name after
+	// it was organised; Category, Title, and Destination are the reviewer's.
+	Overridden bool `json:"overridden,omitempty"`
+	// Invoice holds the billing details read from receipts and invoices, when enabled.
+	Invoice *ai.Invoice `json:"invoice,omitempty"`
+}
+
+// Logger appends records as JSON lines. It is safe for concurrent use.
+type Logger struct {
+	mu  sync.Mutex
+	f   *os.File
+	enc *json.Encoder
+}
+
+// Open opens (or creates) the audit log at path in append-only mode.
+func Open(path string) (*Logger, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create audit log directory: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	return &Logger{f: f, enc: json.NewEncoder(f)}, nil
+}
+
+// Write appends a record, stamping the time if unset.
+func (l *Logger) Write(rec Record) error {
+	if rec.Time.IsZero() {
+		rec.Time = time.Now()
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.enc.Encode(rec)
+}
+
+// Close closes the underlying file.
+func (l *Logger) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.f.Close()
+}
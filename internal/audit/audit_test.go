@@ -0,0 +1,221 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"docs_organiser/internal/ai"
+)
+
+func TestLoggerAppendsJSONLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "audit.jsonl")
+
+	records := []Record{
+		{
+			Source:     "/src/a.pdf",
+			Status:     StatusMoved,
+			Extraction: Extraction{Chars: 120},
+			Classification: &ai.CategorizationResult{
+				Analysis: &ai.AnalysisResult{Category: "Finance", Title: "Invoice"},
+				Metadata: &ai.CategorizationMetadata{Attempts: 1, Success: true},
+			},
+			Category:    "Finance",
+			Title:       "Invoice.pdf",
+			Destination: "/dst/Finance/Invoice.pdf",
+		},
+		{Source: "/src/b.pdf", Status: StatusExtractionFailed, Error: "no text"},
+	}
+
+	// Two sessions to verify the file is appended to, not truncated
+	for _, rec := range records {
+		l, err := Open(path)
+		if err != nil {
+			t.Fatalf("Open: %v", err)
+		}
+		if err := l.Write(rec); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+		if err := l.Close(); err != nil {
+			t.Fatalf("Close: %v", err)
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var got []Record
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var rec Record
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			t.Fatalf("line %q is not valid JSON: %v", scanner.Text(), err)
+		}
+		got = append(got, rec)
+	}
+
+	if len(got) != len(records) {
+		t.Fatalf("got %d records, want %d", len(got), len(records))
+	}
+	for i, rec := range got {
+		if rec.Time.IsZero() {
+			t.Errorf("record %d has no timestamp", i)
+		}
+		if rec.Source != records[i].Source || rec.Status != records[i].Status {
+			t.Errorf("record %d = %s/%s, want %s/%s", i, rec.Source, rec.Status, records[i].Source, records[i].Status)
+		}
+	}
+	if got[0].Classification == nil || got[0].Classification.Metadata.Attempts != 1 {
+		t.Errorf("classification not round-tripped: %+v", got[0].Classification)
+	}
+}
+
+func TestLoggerConcurrentWrites(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	l, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = l.Write(Record{Source: "file", Status: StatusMoved})
+		}()
+	}
+	wg.Wait()
+	l.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := 0
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		if !json.Valid(scanner.Bytes()) {
+			t.Fatalf("interleaved line: %q", scanner.Text())
+		}
+		lines++
+	}
+	if lines != 50 {
+		t.Errorf("got %d lines, want 50", lines)
+	}
+}
+
+func TestCSVLogger(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.csv")
+	records := []Record{
+		{
+			Source: "/src/a, b.pdf",
+			Status: StatusClassified,
+			Classification: &ai.CategorizationResult{
+				Analysis: &ai.AnalysisResult{Category: "Finance", Title: "Invoice", ConfidenceScore: 0.875},
+			},
+			Category: "Finance",
+			Title:    "Invoice.pdf",
+		},
+		{Source: "/src/c.pdf", Status: StatusExtractionFailed, Error: "no text"},
+	}
+
+	// Two sessions: the header is only written to a new file
+	for _, rec := range records {
+		l, err := OpenCSV(path)
+		if err != nil {
+			t.Fatalf("OpenCSV: %v", err)
+		}
+		if err := l.Write(rec); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+		l.Close()
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	rows, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid CSV: %v", err)
+	}
+	want := [][]string{
+		csvHeader,
+		{"/src/a, b.pdf", "Finance", "Invoice.pdf", "0.88", StatusClassified, "", "false", ""},
+		{"/src/c.pdf", "", "", "", StatusExtractionFailed, "", "false", "no text"},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("rows = %q, want %q", rows, want)
+	}
+}
+
+func TestReadFileAndSummarize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	l, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	for i, rec := range []Record{
+		{Source: "/src/a.pdf", Status: StatusMoved, Category: "Finance", Destination: "/dst/Finance/a.pdf"},
+		{Source: "/src/b.pdf", Status: StatusMoved, Category: "Misc", Destination: "/dst/Misc/b.pdf", Fallback: true},
+		{Source: "/src/c.pdf", Status: StatusExtractionFailed, Error: "no text"},
+		// A reviewer moved b.pdf, then the next run organised d.pdf
+		{Source: "/src/b.pdf", Status: StatusMoved, Category: "Work", Destination: "/dst/Work/b.pdf", Fallback: true, Overridden: true},
+		{Source: "/src/d.pdf", Status: StatusMoved, Category: "Finance", Destination: "/dst/Finance/d.pdf", Duplicate: true},
+	} {
+		rec.Time = start.Add(time.Duration(i) * time.Hour)
+		if err := l.Write(rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+	l.Close()
+
+	records, err := ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if len(records) != 5 {
+		t.Fatalf("read %d records, want 5", len(records))
+	}
+	latest := Latest(records)
+	var sources []string
+	for _, rec := range latest {
+		sources = append(sources, filepath.Base(rec.Source))
+	}
+	if want := []string{"a.pdf", "c.pdf", "b.pdf", "d.pdf"}; !reflect.DeepEqual(sources, want) {
+		t.Errorf("Latest = %v, want %v", sources, want)
+	}
+	if recent := Since(records, start.Add(3*time.Hour)); len(recent) != 2 {
+		t.Errorf("Since kept %d records, want 2", len(recent))
+	}
+
+	r := Summarize(records)
+	want := Report{
+		Files:      4,
+		Statuses:   map[string]int{StatusMoved: 3, StatusExtractionFailed: 1},
+		Categories: map[string]int{"Finance": 2, "Work": 1},
+		Fallbacks:  1, Duplicates: 1, Overridden: 1,
+		Failures: []Failure{{Source: "/src/c.pdf", Status: StatusExtractionFailed, Error: "no text"}},
+	}
+	if !reflect.DeepEqual(r, want) {
+		t.Errorf("Summarize = %+v, want %+v", r, want)
+	}
+	var buf bytes.Buffer
+	if err := r.WriteText(&buf); err != nil || !strings.Contains(buf.String(), "/src/c.pdf (extraction_failed): no text") {
+		t.Errorf("WriteText = %q, %v; want the failure listed", buf.String(), err)
+	}
+}
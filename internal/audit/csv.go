@@ -0,0 +1,62 @@
+package audit
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+)
+
+// csvHeader names the columns of a results CSV.
+var csvHeader = []string{"file", "category", "title", "confidence", "status", "destination", "fallback", "error"}
+
+// CSVLogger appends one row per record to a CSV file, for spreadsheets and other tools.
+// It is safe for concurrent use.
+type CSVLogger struct {
+	mu sync.Mutex
+	f  *os.File
+	w  *csv.Writer
+}
+
+// OpenCSV opens (or creates) the results CSV at path in append-only mode, writing the
+// header row to a new file.
+func OpenCSV(path string) (*CSVLogger, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create results directory: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open results CSV: %w", err)
+	}
+	l := &CSVLogger{f: f, w: csv.NewWriter(f)}
+	if info, err := f.Stat(); err == nil && info.Size() == 0 {
+		l.w.Write(csvHeader)
+		l.w.Flush()
+	}
+	return l, nil
+}
+
+// Write appends the row of rec.
+func (l *CSVLogger) Write(rec Record) error {
+	var confidence string
+	if c := rec.Classification; c != nil && c.Analysis != nil && !rec.Fallback {
+		confidence = strconv.FormatFloat(c.Analysis.ConfidenceScore, 'f', 2, 64)
+	}
+	row := []string{rec.Source, rec.Category, rec.Title, confidence, rec.Status, rec.Destination,
+		strconv.FormatBool(rec.Fallback), rec.Error}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.w.Write(row)
+	l.w.Flush()
+	return l.w.Error()
+}
+
+// Close closes the underlying file.
+func (l *CSVLogger) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.f.Close()
+}
@@ -0,0 +1,61 @@
+package audit
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// ReadFile reads the records of the audit log at path, oldest first.
+func ReadFile(path string) ([]Record, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer f.Close()
+
+	var records []Record
+	dec := json.NewDecoder(f)
+	for {
+		var rec Record
+		if err := dec.Decode(&rec); errors.Is(err, io.EOF) {
+			return records, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("corrupt audit log %s after %d records: %w", path, len(records), err)
+		}
+		records = append(records, rec)
+	}
+}
+
+// Latest returns the last record of each file in records, which are oldest first, in the
+// order of those last records. A reviewer's override or undo thus replaces the move.
+func Latest(records []Record) []Record {
+	last := make(map[string]int, len(records))
+	for i, rec := range records {
+		last[rec.Source] = i
+	}
+	var latest []Record
+	for i, rec := range records {
+		if last[rec.Source] == i {
+			latest = append(latest, rec)
+		}
+	}
+	return latest
+}
+
+// Since returns the records stamped at or after cutoff; a zero cutoff keeps them all.
+func Since(records []Record, cutoff time.Time) []Record {
+	if cutoff.IsZero() {
+		return records
+	}
+	var kept []Record
+	for _, rec := range records {
+		if !rec.Time.Before(cutoff) {
+			kept = append(kept, rec)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,95 @@
+package audit
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"text/tabwriter"
+)
+
+// Failure is a file that was not organised, and why.
+type Failure struct {
+	Source string `json:"source"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Report summarizes what happened to the files of an audit log.
+type Report struct {
+	Files int `json:"files"`
+	// Statuses counts the files by the status of their last record.
+	Statuses map[string]int `json:"statuses"`
+	// Categories counts the moved files by the category they were filed into.
+	Categories     map[string]int `json:"categories"`
+	Fallbacks      int            `json:"fallbacks"`
+	Unprocessed    int            `json:"unprocessed"`
+	Duplicates     int            `json:"duplicates"`
+	NearDuplicates int            `json:"near_duplicates"`
+	Overridden     int            `json:"overridden"`
+	Failures       []Failure      `json:"failures"`
+}
+
+// Summarize reports on the last record of every file in records, which are oldest first.
+func Summarize(records []Record) Report {
+	r := Report{Statuses: map[string]int{}, Categories: map[string]int{}, Failures: []Failure{}}
+	for _, rec := range Latest(records) {
+		r.Files++
+		r.Statuses[rec.Status]++
+		switch rec.Status {
+		case StatusMoved:
+			r.Categories[rec.Category]++
+		case StatusExtractionFailed, StatusMoveFailed:
+			r.Failures = append(r.Failures, Failure{Source: rec.Source, Status: rec.Status, Error: rec.Error})
+		}
+		if rec.Fallback {
+			r.Fallbacks++
+		}
+		if rec.Unprocessed {
+			r.Unprocessed++
+		}
+		if rec.Duplicate {
+			r.Duplicates++
+		}
+		if rec.NearDuplicateOf != "" {
+			r.NearDuplicates++
+		}
+		if rec.Overridden {
+			r.Overridden++
+		}
+	}
+	return r
+}
+
+// WriteText prints the report as tables of statuses and categories, followed by the files
+// that failed.
+func (r Report) WriteText(w io.Writer) error {
+	fmt.Fprintf(w, "%d files: %d fell back to Misc, %d unprocessed, %d duplicates, %d near-duplicates, %d overridden\n\n",
+		r.Files, r.Fallbacks, r.Unprocessed, r.Duplicates, r.NearDuplicates, r.Overridden)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "Status\tFiles")
+	for _, status := range slices.Sorted(maps.Keys(r.Statuses)) {
+		fmt.Fprintf(tw, "%s\t%d\n", status, r.Statuses[status])
+	}
+	if len(r.Categories) > 0 {
+		fmt.Fprintln(tw, "\t")
+		fmt.Fprintln(tw, "Category\tFiles")
+		for _, category := range slices.Sorted(maps.Keys(r.Categories)) {
+			fmt.Fprintf(tw, "%s\t%d\n", category, r.Categories[category])
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if len(r.Failures) > 0 {
+		fmt.Fprintf(w, "\nFailed:\n")
+	}
+	for _, f := range r.Failures {
+		if _, err := fmt.Fprintf(w, "  %s (%s): %s\n", f.Source, f.Status, f.Error); err != nil {
+			return err
+		}
+	}
+	return nil
+}
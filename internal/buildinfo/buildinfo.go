@@ -0,0 +1,90 @@
+// Package buildinfo reports the version a binary was built from. Release builds set
+// the variables with -ldflags "-X docs_organiser/internal/buildinfo.Version=v1.2.3 ...";
+// other builds fall back to the VCS stamp the Go toolchain embeds.
+package buildinfo
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+)
+
+// Set at link time; empty when not.
+var (
+	Version string // semantic version, e.g. v1.2.3
+	Commit  string // VCS revision
+	Date    string // build date, RFC 3339
+)
+
+// Info describes the running binary.
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	Date      string `json:"date,omitempty"`
+	Modified  bool   `json:"modified,omitempty"` // built from a tree with uncommitted changes
+	GoVersion string `json:"go_version"`
+	Platform  string `json:"platform"`
+}
+
+// Get returns the link-time values, completed from the embedded build information.
+func Get() Info {
+	info := Info{
+		Version:   Version,
+		Commit:    Commit,
+		Date:      Date,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+	}
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		info = fromBuildInfo(info, bi)
+	}
+	if info.Version == "" {
+		info.Version = "dev"
+	}
+	return info
+}
+
+// fromBuildInfo fills the fields the linker left empty from bi.
+func fromBuildInfo(info Info, bi *debug.BuildInfo) Info {
+	if info.Version == "" && bi.Main.Version != "" && bi.Main.Version != "(devel)" {
+		info.Version = bi.Main.Version // installed with go install module@version
+	}
+	if bi.GoVersion != "" {
+		info.GoVersion = bi.GoVersion
+	}
+	for _, s := range bi.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			if info.Commit == "" {
+				info.Commit = s.Value
+			}
+		case "vcs.time":
+			if info.Date == "" {
+				info.Date = s.Value
+			}
+		case "vcs.modified":
+			info.Modified = s.Value == "true"
+		}
+	}
+	return info
+}
+
+// String formats the information on one line, e.g.
+// "v1.2.3 (commit 0a1b2c3d4e5f, built 2024-05-01T10:00:00Z, go1.24.1 linux/amd64)".
+func (i Info) String() string {
+	s := i.Version + " ("
+	if i.Commit != "" {
+		commit := i.Commit
+		if len(commit) > 12 {
+			commit = commit[:12]
+		}
+		if i.Modified {
+			commit += "-dirty"
+		}
+		s += "commit " + commit + ", "
+	}
+	if i.Date != "" {
+		s += "built " + i.Date + ", "
+	}
+	return s + fmt.Sprintf("%s %s)", i.GoVersion, i.Platform)
+}
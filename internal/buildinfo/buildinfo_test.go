@@ -0,0 +1,42 @@
+package buildinfo
+
+import (
+	"runtime/debug"
+	"testing"
+)
+
+func TestFromBuildInfo(t *testing.T) {
+	bi := &debug.BuildInfo{
+		GoVersion: "go1.24.1",
+		Main:      debug.Module{Version: "(devel)"},
+		Settings: []debug.BuildSetting{
+			{Key: "vcs.revision", Value: "0a1b2c3d4e5f67890a1b2c3d4e5f67890a1b2c3d"},
+			{Key: "vcs.time", Value: "2024-05-01T10:00:00Z"},
+			{Key: "vcs.modified", Value: "true"},
+		},
+	}
+
+	got := fromBuildInfo(Info{Platform: "linux/amd64"}, bi)
+	want := "(commit 0a1b2c3d4e5f-dirty, built 2024-05-01T10:00:00Z, go1.24.1 linux/amd64)"
+	if got.Version != "" || got.String() != " "+want {
+		t.Errorf("from VCS stamp: %+v, String() = %q", got, got.String())
+	}
+
+	// Link-time values win over the VCS stamp
+	got = fromBuildInfo(Info{Version: "v1.2.3", Commit: "abc1234", Date: "2024-06-01", Platform: "darwin/arm64"}, bi)
+	if s := got.String(); s != "v1.2.3 (commit abc1234-dirty, built 2024-06-01, go1.24.1 darwin/arm64)" {
+		t.Errorf("String() = %q", s)
+	}
+
+	// go install module@version records the module version
+	bi = &debug.BuildInfo{GoVersion: "go1.24.1", Main: debug.Module{Version: "v1.4.0"}}
+	if got := fromBuildInfo(Info{}, bi); got.Version != "v1.4.0" || got.Commit != "" {
+		t.Errorf("from module version: %+v", got)
+	}
+}
+
+func TestGet(t *testing.T) {
+	if info := Get(); info.Version == "" || info.GoVersion == "" || info.Platform == "" {
+		t.Errorf("Get() = %+v, want version, Go version, and platform set", info)
+	}
+}
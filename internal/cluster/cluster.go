@@ -0,0 +1,159 @@
+// Package cluster groups document embeddings by cosine similarity, to propose
+// categories for a corpus that has none yet.
+package cluster
+
+import (
+	"math"
+	"math/rand/v2"
+	"slices"
+)
+
+// maxIterations bounds the refinement rounds of KMeans.
+const maxIterations = 100
+
+// Cluster is a group of similar vectors.
+type Cluster struct {
+	// Members are indexes into the clustered vectors, the closest to the centroid first.
+	Members []int
+}
+
+// DefaultK suggests a number of clusters for n documents: about the square root of n/2,
+// between 2 and 12.
+func DefaultK(n int) int {
+	return min(max(int(math.Round(math.Sqrt(float64(n)/2))), 2), 12)
+}
+
+// KMeans partitions vectors into at most k clusters by cosine similarity (spherical
+// k-means with k-means++ seeding). Empty clusters are dropped and the rest are ordered by
+// size, largest first. The same seed gives the same clusters.
+func KMeans(vectors [][]float64, k int, seed uint64) []Cluster {
+	points := make([][]float64, len(vectors))
+	for i, v := range vectors {
+		points[i] = normalize(v)
+	}
+	k = min(k, len(points))
+	if k <= 0 {
+		return nil
+	}
+
+	centroids := seedCentroids(points, k, rand.New(rand.NewPCG(seed, seed^0x9e3779b97f4a7c15)))
+	assign := make([]int, len(points))
+	for i := range assign {
+		assign[i] = -1
+	}
+	for range maxIterations {
+		changed := false
+		for i, p := range points {
+			if c := nearest(p, centroids); c != assign[i] {
+				assign[i], changed = c, true
+			}
+		}
+		if !changed {
+			break
+		}
+		for c := range centroids {
+			sum := make([]float64, len(centroids[c]))
+			n := 0
+			for i, p := range points {
+				if assign[i] == c {
+					for j := range sum {
+						sum[j] += p[j]
+					}
+					n++
+				}
+			}
+			if n > 0 { // an empty cluster keeps its centroid
+				centroids[c] = normalize(sum)
+			}
+		}
+	}
+
+	clusters := make([]Cluster, k)
+	for i, c := range assign {
+		clusters[c].Members = append(clusters[c].Members, i)
+	}
+	clusters = slices.DeleteFunc(clusters, func(c Cluster) bool { return len(c.Members) == 0 })
+	for c := range clusters {
+		centroid := centroids[assign[clusters[c].Members[0]]]
+		slices.SortStableFunc(clusters[c].Members, func(a, b int) int {
+			return -cmpFloat(dot(points[a], centroid), dot(points[b], centroid))
+		})
+	}
+	slices.SortStableFunc(clusters, func(a, b Cluster) int { return len(b.Members) - len(a.Members) })
+	return clusters
+}
+
+// seedCentroids picks k of points as initial centroids, each with a probability growing
+// with its squared distance from the centroids picked before (k-means++).
+func seedCentroids(points [][]float64, k int, r *rand.Rand) [][]float64 {
+	centroids := [][]float64{slices.Clone(points[r.IntN(len(points))])}
+	dist := make([]float64, len(points))
+	for len(centroids) < k {
+		var total float64
+		for i, p := range points {
+			d := 1 - dot(p, centroids[nearest(p, centroids)])
+			dist[i] = d * d
+			total += dist[i]
+		}
+		next := 0
+		if total > 0 {
+			target := r.Float64() * total
+			for i, d := range dist {
+				if target -= d; target <= 0 {
+					next = i
+					break
+				}
+			}
+		} else {
+			next = r.IntN(len(points)) // all points coincide with a centroid
+		}
+		centroids = append(centroids, slices.Clone(points[next]))
+	}
+	return centroids
+}
+
+// nearest returns the index of the centroid most similar to p.
+func nearest(p []float64, centroids [][]float64) int {
+	best, bestSim := 0, math.Inf(-1)
+	for c, centroid := range centroids {
+		if sim := dot(p, centroid); sim > bestSim {
+			best, bestSim = c, sim
+		}
+	}
+	return best
+}
+
+// normalize returns v scaled to unit length; the zero vector is returned as is.
+func normalize(v []float64) []float64 {
+	var sum float64
+	for _, x := range v {
+		sum += x * x
+	}
+	out := slices.Clone(v)
+	if sum == 0 {
+		return out
+	}
+	norm := math.Sqrt(sum)
+	for i := range out {
+		out[i] /= norm
+	}
+	return out
+}
+
+func dot(a, b []float64) float64 {
+	var sum float64
+	for i := range min(len(a), len(b)) {
+		sum += a[i] * b[i]
+	}
+	return sum
+}
+
+func cmpFloat(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,61 @@
+package cluster
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestKMeans(t *testing.T) {
+	// Three directions with a little noise; vector length doesn't matter for cosine similarity
+	vectors := [][]float64{
+		{1, 0.1, 0}, {2, 0, 0.1}, {5, 0.2, 0.1}, {1, 0, 0},
+		{0, 1, 0.1}, {0.1, 3, 0}, {0, 1, 0},
+		{0, 0.1, 1}, {0.1, 0, 2},
+	}
+	for seed := range uint64(5) {
+		clusters := KMeans(vectors, 3, seed)
+		if len(clusters) != 3 {
+			t.Fatalf("seed %d: %d clusters, want 3", seed, len(clusters))
+		}
+		var groups [][]int
+		for _, c := range clusters {
+			members := slices.Sorted(slices.Values(c.Members))
+			groups = append(groups, members)
+		}
+		want := [][]int{{0, 1, 2, 3}, {4, 5, 6}, {7, 8}}
+		if !slices.EqualFunc(groups, want, slices.Equal) {
+			t.Errorf("seed %d: clusters = %v, want %v", seed, groups, want)
+		}
+	}
+}
+
+func TestKMeans_Small(t *testing.T) {
+	if got := KMeans(nil, 3, 1); got != nil {
+		t.Errorf("KMeans(nil) = %v, want nil", got)
+	}
+	clusters := KMeans([][]float64{{1, 0}, {0, 1}}, 5, 1)
+	if len(clusters) != 2 {
+		t.Errorf("%d clusters for 2 vectors, want 2", len(clusters))
+	}
+	// Identical vectors can't be separated; the empty clusters are dropped
+	clusters = KMeans([][]float64{{1, 1}, {1, 1}, {2, 2}}, 2, 1)
+	if len(clusters) != 1 || len(clusters[0].Members) != 3 {
+		t.Errorf("clusters of identical vectors = %v, want one of 3", clusters)
+	}
+}
+
+func TestDefaultK(t *testing.T) {
+	for n, want := range map[int]int{1: 2, 8: 2, 50: 5, 200: 10, 5000: 12} {
+		if got := DefaultK(n); got != want {
+			t.Errorf("DefaultK(%d) = %d, want %d", n, got, want)
+		}
+	}
+}
+
+func TestKMeans_MembersByCloseness(t *testing.T) {
+	vectors := [][]float64{{1, 0.5}, {1, 0}, {1, 0.2}, {1, -0.1}}
+	clusters := KMeans(vectors, 1, 1)
+	if got, want := clusters[0].Members, []int{2, 1, 3, 0}; !slices.Equal(got, want) {
+		t.Errorf("members = %v, want %v", got, want)
+	}
+}
@@ -3,7 +3,9 @@ package config
 import (
 	"fmt"
 	"runtime"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
@@ -15,15 +17,254 @@ type ModelDefinition struct {
 	Status string `json:"status"`
 }
 
+// PluginDefinition registers an external program speaking the plugin protocol (see
+// package plugin): an extractor for Extensions, or the classifier.
+type PluginDefinition struct {
+	Name       string   `mapstructure:"name" json:"name"`
+	Type       string   `mapstructure:"type" json:"type"` // "extractor" or "classifier"
+	Command    []string `mapstructure:"command" json:"command"`
+	Extensions []string `mapstructure:"extensions" json:"extensions,omitempty"` // extractors only, without the dot
+}
+
 type Config struct {
 	// Infra Settings (Loaded from YAML/Env)
 	APIURL         string `mapstructure:"api" json:"api"`
 	MetricsEnabled bool   `mapstructure:"metrics_enabled" json:"metrics_enabled"`
 	MetricsPort    int    `mapstructure:"metrics_port" json:"metrics_port"`
 	ServerPort     int    `mapstructure:"server_port" json:"server_port"`
+	GRPCPort       int    `mapstructure:"grpc_port" json:"grpc_port"`
 	Encoding       string `mapstructure:"encoding" json:"encoding"`
 	ContextWindow  int    `mapstructure:"ctx" json:"ctx"`
+	AutoContext    bool   `mapstructure:"auto_ctx" json:"auto_ctx"`
+	WarmUp         bool   `mapstructure:"warm_up" json:"warm_up"`
 	DBPath         string `mapstructure:"db_path" json:"db_path"`
+	PIDFile        string `mapstructure:"pid_file" json:"pid_file"`
+	Schedule       string `mapstructure:"schedule" json:"schedule"`
+	ConfigFile     string `mapstructure:"-" json:"-"` // path passed via -config
+	Profile        string `mapstructure:"profile" json:"profile"`
+	Debug          bool   `mapstructure:"debug" json:"debug"`
+	DebugLLM       bool   `mapstructure:"debug_llm" json:"debug_llm"`
+
+	// Idle Resource Release
+	IdleTimeout     time.Duration `mapstructure:"idle_timeout" json:"idle_timeout"`
+	IdleUnloadModel bool          `mapstructure:"idle_unload_model" json:"idle_unload_model"`
+
+	// Scanner Settings
+	Include   []string `mapstructure:"include" json:"include"`
+	Exclude   []string `mapstructure:"exclude" json:"exclude"`
+	NewerThan string   `mapstructure:"newer_than" json:"newer_than"`
+	Since     string   `mapstructure:"since" json:"since"`
+
+	// What happens when the destination name is taken: hash, sequence, skip, overwrite, or newest
+	Collisions string `mapstructure:"collisions" json:"collisions"`
+	// Hash behind the collision suffix: sha256, xxhash, or partial (first/last MB and size)
+	CollisionHash string `mapstructure:"collision_hash" json:"collision_hash"`
+	PartialHashMB int    `mapstructure:"partial_hash_mb" json:"partial_hash_mb"`
+
+	// File names of classified documents: rename, keep, or prefix
+	Naming string `mapstructure:"naming" json:"naming"`
+	// Folders under each category: flat, or mirror to keep the source subfolders
+	Layout string `mapstructure:"layout" json:"layout"`
+	// Category (or category prefix) -> destination root used instead of dst
+	CategoryDestinations map[string]string `mapstructure:"category_destinations" json:"category_destinations"`
+
+	// Memory in MB that the files being processed may use together (0 = no budget)
+	MemoryLimitMB int `mapstructure:"memory_limit_mb" json:"memory_limit_mb"`
+
+	// Limits on copies to another volume (0 = none): MB/s and reads per second
+	IOLimitMB  float64 `mapstructure:"io_limit_mb" json:"io_limit_mb"`
+	IOLimitOps int     `mapstructure:"io_limit_ops" json:"io_limit_ops"`
+
+	// Free space in MB to keep on a local destination volume
+	DiskReserveMB int `mapstructure:"disk_reserve_mb" json:"disk_reserve_mb"`
+
+	// Byte limit on generated file names (0 = none)
+	MaxFilenameBytes int `mapstructure:"max_filename_bytes" json:"max_filename_bytes"`
+
+	// Spell generated folder and file names in ASCII ("Café" becomes "Cafe")
+	ASCIINames bool `mapstructure:"ascii_names" json:"ascii_names"`
+
+	// Remove source directories emptied by a run
+	RemoveEmptyDirs bool `mapstructure:"remove_empty_dirs" json:"remove_empty_dirs"`
+	// Move files that failed extraction this many times (and unclassifiable ones) to _Unprocessed
+	UnprocessedAfter int `mapstructure:"unprocessed_after" json:"unprocessed_after"`
+
+	// Log levels: quiet keeps warnings, errors, and run summaries; verbose adds per-file details
+	Quiet   bool `mapstructure:"quiet" json:"quiet"`
+	Verbose bool `mapstructure:"verbose" json:"verbose"`
+
+	// Log file (replaces stderr for log lines), rotated at a size limit
+	LogFile       string `mapstructure:"log_file" json:"log_file"`
+	LogMaxSizeMB  int    `mapstructure:"log_max_size_mb" json:"log_max_size_mb"`
+	LogMaxBackups int    `mapstructure:"log_max_backups" json:"log_max_backups"`
+
+	// Progress output: text (terminal progress line) or json (newline-delimited events on stdout)
+	Output string `mapstructure:"output" json:"output"`
+
+	// Trial runs: stop after max_files, or process a random sample of the source
+	MaxFiles int `mapstructure:"max_files" json:"max_files"`
+	Sample   int `mapstructure:"sample" json:"sample"`
+	// Order in which scanned files are processed: walk, smallest, largest, oldest, newest
+	Order string `mapstructure:"order" json:"order"`
+
+	// Traversal Settings
+	MaxDepth       int  `mapstructure:"max_depth" json:"max_depth"`
+	FollowSymlinks bool `mapstructure:"follow_symlinks" json:"follow_symlinks"`
+
+	// Extraction Settings
+	PDFToTextFallback bool                `mapstructure:"pdftotext_fallback" json:"pdftotext_fallback"`
+	Extractors        map[string][]string `mapstructure:"extractors" json:"extractors"`         // extension (no dot) -> command
+	ExtractLimits     map[string]int      `mapstructure:"extract_limits" json:"extract_limits"` // extension (no dot) -> chars, overriding limit
+
+	// Plugins: external extractors and classifiers speaking JSON over stdin/stdout
+	Plugins       []PluginDefinition `mapstructure:"plugins" json:"plugins"` // config file only
+	PluginTimeout time.Duration      `mapstructure:"plugin_timeout" json:"plugin_timeout"`
+
+	// Category Taxonomy (replaces the flat category list when set)
+	TaxonomyFile         string            `mapstructure:"taxonomy_file" json:"taxonomy_file"`
+	CategoryDescriptions map[string]string `mapstructure:"category_descriptions" json:"category_descriptions"`
+	CategoryAliases      map[string]string `mapstructure:"category_aliases" json:"category_aliases"` // name the model answers with -> category
+	TwoStage             bool              `mapstructure:"two_stage" json:"two_stage"`
+	SystemPromptFile     string            `mapstructure:"system_prompt_file" json:"system_prompt_file"`
+
+	// Category Suggestions (suggest-categories command)
+	SuggestSamples  int    `mapstructure:"suggest_samples" json:"suggest_samples"`
+	SuggestClusters int    `mapstructure:"suggest_clusters" json:"suggest_clusters"` // 0 = from the sample size
+	EmbeddingModel  string `mapstructure:"embedding_model" json:"embedding_model"`
+
+	// Semantic Search: embed organised files into the store for search --semantic
+	SemanticIndex bool `mapstructure:"semantic_index" json:"semantic_index"`
+	SearchLimit   int  `mapstructure:"search_limit" json:"search_limit"`
+
+	// Near-Duplicates: simhash fingerprints of organised documents
+	NearDuplicates        string `mapstructure:"near_duplicates" json:"near_duplicates"` // off, flag, group
+	NearDuplicateDistance int    `mapstructure:"near_duplicate_distance" json:"near_duplicate_distance"`
+
+	// Sampling Parameters of classification requests
+	Temperature float64  `mapstructure:"temperature" json:"temperature"`
+	TopP        float64  `mapstructure:"top_p" json:"top_p"`
+	MaxTokens   int      `mapstructure:"max_tokens" json:"max_tokens"` // 0 = the output budget of ctx
+	Stop        []string `mapstructure:"stop" json:"stop"`
+
+	// Self-consistency Voting
+	Votes           int     `mapstructure:"votes" json:"votes"`
+	VoteTemperature float64 `mapstructure:"vote_temperature" json:"vote_temperature"`
+
+	// Clarification Pass for answers with a confidence in [min, max); max 0 disables it
+	ClarifyMinConfidence float64 `mapstructure:"clarify_min_confidence" json:"clarify_min_confidence"`
+	ClarifyMaxConfidence float64 `mapstructure:"clarify_max_confidence" json:"clarify_max_confidence"`
+
+	// Confidence Calibration
+	Logprobs bool `mapstructure:"logprobs" json:"logprobs"`
+
+	// Offline Classification (file name patterns and keyword dictionaries, no model server)
+	NoLLM            bool                `mapstructure:"no_llm" json:"no_llm"`
+	Keywords         map[string][]string `mapstructure:"keywords" json:"keywords"`                   // category -> keywords
+	FilenamePatterns map[string][]string `mapstructure:"filename_patterns" json:"filename_patterns"` // category -> regular expressions
+
+	// Classify from file name and metadata first; read the text below this confidence (0 = off)
+	FastPath float64 `mapstructure:"fast_path" json:"fast_path"`
+
+	// Starlark script that may override or veto each classification
+	RoutingScript string `mapstructure:"routing_script" json:"routing_script"`
+
+	// Requests in flight to the model servers (0 = unlimited)
+	MaxConcurrentRequests int `mapstructure:"max_concurrent_requests" json:"max_concurrent_requests"`
+
+	// Long Documents
+	Truncation   string `mapstructure:"truncation" json:"truncation"`
+	ChunkOverlap int    `mapstructure:"chunk_overlap" json:"chunk_overlap"`
+
+	// S3-compatible storage (credentials come from AWS_ACCESS_KEY_ID / AWS_SECRET_ACCESS_KEY)
+	S3Endpoint  string `mapstructure:"s3_endpoint" json:"s3_endpoint"`
+	S3Region    string `mapstructure:"s3_region" json:"s3_region"`
+	S3PathStyle bool   `mapstructure:"s3_path_style" json:"s3_path_style"`
+
+	// IMAP Attachment Ingestion (the imap command; the password is a secret, so never serialized)
+	IMAPAddr            string        `mapstructure:"imap_addr" json:"imap_addr"`
+	IMAPSecurity        string        `mapstructure:"imap_security" json:"imap_security"`
+	IMAPUsername        string        `mapstructure:"imap_username" json:"imap_username"`
+	IMAPPassword        string        `mapstructure:"imap_password" json:"-"`
+	IMAPFolder          string        `mapstructure:"imap_folder" json:"imap_folder"`
+	IMAPProcessedFolder string        `mapstructure:"imap_processed_folder" json:"imap_processed_folder"`
+	IMAPFrom            []string      `mapstructure:"imap_from" json:"imap_from"`
+	IMAPSubject         string        `mapstructure:"imap_subject" json:"imap_subject"`
+	IMAPExtensions      []string      `mapstructure:"imap_extensions" json:"imap_extensions"`
+	IMAPMaxMessageMB    int           `mapstructure:"imap_max_message_mb" json:"imap_max_message_mb"`
+	IMAPPollInterval    time.Duration `mapstructure:"imap_poll_interval" json:"imap_poll_interval"`
+
+	// TLS Settings for model endpoints
+	TLSCAFile             string `mapstructure:"tls_ca_file" json:"tls_ca_file"`
+	TLSCertFile           string `mapstructure:"tls_cert_file" json:"tls_cert_file"`
+	TLSKeyFile            string `mapstructure:"tls_key_file" json:"tls_key_file"`
+	TLSInsecureSkipVerify bool   `mapstructure:"tls_insecure_skip_verify" json:"tls_insecure_skip_verify"`
+
+	// Proxy and extra headers for model endpoints (headers may hold secrets, so never serialized)
+	ProxyURL string            `mapstructure:"proxy_url" json:"proxy_url"`
+	Headers  map[string]string `mapstructure:"headers" json:"-"`
+
+	// Connection pooling and timeouts for model endpoints
+	HTTPMaxIdlePerHost int           `mapstructure:"http_max_idle_per_host" json:"http_max_idle_per_host"`
+	HTTPIdleTimeout    time.Duration `mapstructure:"http_idle_timeout" json:"http_idle_timeout"`
+	HTTPKeepAlive      bool          `mapstructure:"http_keep_alive" json:"http_keep_alive"`
+	RequestTimeout     time.Duration `mapstructure:"request_timeout" json:"request_timeout"`
+	// Hold files after this many requests in a row could not reach a model server
+	CircuitBreaker       int           `mapstructure:"circuit_breaker" json:"circuit_breaker"`
+	CircuitProbeInterval time.Duration `mapstructure:"circuit_probe_interval" json:"circuit_probe_interval"`
+
+	// Privacy Settings
+	RedactPII bool `mapstructure:"redact_pii" json:"redact_pii"`
+	LocalOnly bool `mapstructure:"local_only" json:"local_only"`
+
+	// Audit Settings
+	AuditLog string `mapstructure:"audit_log" json:"audit_log"`
+	// One CSV row per file, and whether files are only classified, not moved
+	ResultsCSV   string `mapstructure:"results_csv" json:"results_csv"`
+	ClassifyOnly bool   `mapstructure:"classify_only" json:"classify_only"`
+	// Failed files of the last run, and the list that organize retries instead of scanning
+	FailuresFile string `mapstructure:"failures_file" json:"failures_file"`
+	RetryFrom    string `mapstructure:"retry_from" json:"retry_from"`
+	// Files held back while the model server is unreachable, and whether organize processes them
+	QueueFile    string `mapstructure:"queue_file" json:"queue_file"`
+	ProcessQueue bool   `mapstructure:"process_queue" json:"process_queue"`
+
+	// Knowledge-base Output
+	NotesDir    string `mapstructure:"notes_dir" json:"notes_dir"`
+	Sidecar     string `mapstructure:"sidecar" json:"sidecar"`
+	PDFMetadata bool   `mapstructure:"pdf_metadata" json:"pdf_metadata"`
+	// Categories whose documents get a second request for vendor, amount, number, and due date
+	InvoiceCategories []string `mapstructure:"invoice_categories" json:"invoice_categories"`
+
+	// Photo Routing (EXIF)
+	PhotoPath string `mapstructure:"photo_path" json:"photo_path"`
+	PhotoName string `mapstructure:"photo_name" json:"photo_name"`
+
+	// Document Routing (templates over category, title, and document date)
+	DocumentPath string `mapstructure:"document_path" json:"document_path"`
+	DocumentName string `mapstructure:"document_name" json:"document_name"`
+
+	// Webhook Notifications
+	WebhookURLs          []string `mapstructure:"webhook_urls" json:"webhook_urls"`
+	WebhookEvents        []string `mapstructure:"webhook_events" json:"webhook_events"`
+	WebhookMinConfidence float64  `mapstructure:"webhook_min_confidence" json:"webhook_min_confidence"`
+
+	// Dashboard Review: moves below this confidence await review
+	ReviewConfidence float64 `mapstructure:"review_confidence" json:"review_confidence"`
+
+	// End-of-run Summaries (webhook URLs and SMTP password are secrets, so never serialized)
+	SlackWebhookURL   string   `mapstructure:"slack_webhook_url" json:"-"`
+	DiscordWebhookURL string   `mapstructure:"discord_webhook_url" json:"-"`
+	SMTPHost          string   `mapstructure:"smtp_host" json:"smtp_host"`
+	SMTPPort          int      `mapstructure:"smtp_port" json:"smtp_port"`
+	SMTPUsername      string   `mapstructure:"smtp_username" json:"smtp_username"`
+	SMTPPassword      string   `mapstructure:"smtp_password" json:"-"`
+	EmailFrom         string   `mapstructure:"email_from" json:"email_from"`
+	EmailTo           []string `mapstructure:"email_to" json:"email_to"`
+
+	// Hooks: commands run before and after each run, without a shell
+	PreRunHook  []string      `mapstructure:"pre_run_hook" json:"pre_run_hook"`
+	PostRunHook []string      `mapstructure:"post_run_hook" json:"post_run_hook"`
+	HookTimeout time.Duration `mapstructure:"hook_timeout" json:"hook_timeout"`
 
 	// User Settings (Managed via UI/API, initialized to defaults)
 	SourceDir        string            `mapstructure:"-" json:"src"`
@@ -33,9 +274,211 @@ type Config struct {
 	Workers          int               `mapstructure:"-" json:"workers"`
 	ExtractLimit     int               `mapstructure:"-" json:"limit"`
 	Categories       []string          `mapstructure:"-" json:"categories"`
+
+	// User settings pinned by the config file or the selected profile (see ApplyProfile)
+	ProfileSettings ProfileSettings `mapstructure:"-" json:"-"`
+}
+
+// ProfileSettings are the user settings set in the config file, at the top level or in
+// the selected profile. They take precedence over the settings saved from the dashboard.
+type ProfileSettings struct {
+	SourceDir  string   `mapstructure:"src"`
+	DestDir    string   `mapstructure:"dst"`
+	Categories []string `mapstructure:"categories"`
+}
+
+// ApplyProfile overrides the user settings with those the config file sets.
+func (c *Config) ApplyProfile() {
+	if s := c.ProfileSettings.SourceDir; s != "" {
+		c.SourceDir = s
+	}
+	if s := c.ProfileSettings.DestDir; s != "" {
+		c.DestDir = s
+	}
+	if len(c.ProfileSettings.Categories) > 0 {
+		c.Categories = c.ProfileSettings.Categories
+	}
+}
+
+// SettingsKey is the storage key of the user settings, kept apart per profile so that
+// switching profiles doesn't carry one job's folders into another.
+func (c *Config) SettingsKey() string {
+	if c.Profile == "" {
+		return "user_settings"
+	}
+	return "user_settings/" + c.Profile
+}
+
+// selectProfile merges the profiles.<name> section of v over its top-level settings, so
+// flags and environment variables still win.
+func selectProfile(v *viper.Viper, name string) error {
+	profiles := v.GetStringMap("profiles")
+	section, ok := profiles[strings.ToLower(name)].(map[string]interface{})
+	if !ok {
+		names := make([]string, 0, len(profiles))
+		for n := range profiles {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		if len(names) == 0 {
+			return fmt.Errorf("unknown profile %q: the config file defines no profiles", name)
+		}
+		return fmt.Errorf("unknown profile %q (defined: %s)", name, strings.Join(names, ", "))
+	}
+	if err := v.MergeConfigMap(section); err != nil {
+		return fmt.Errorf("failed to apply profile %q: %w", name, err)
+	}
+	return nil
+}
+
+// NormalizeFlagName lets flags be spelled with dashes too: --retry-from is --retry_from.
+func NormalizeFlagName(_ *pflag.FlagSet, name string) pflag.NormalizedName {
+	return pflag.NormalizedName(strings.ReplaceAll(name, "-", "_"))
+}
+
+// Flags defines the command-line flags of every setting that can also come from the
+// config file or the environment. Load reads them once they have been parsed.
+func Flags() *pflag.FlagSet {
+	fs := pflag.NewFlagSet("docs_organiser", pflag.ContinueOnError)
+	fs.SetNormalizeFunc(NormalizeFlagName)
+	fs.String("api", "http://localhost:8080/v1", "URL of the MLX server")
+	fs.Bool("metrics_enabled", true, "Enable Prometheus metrics")
+	fs.Int("metrics_port", 8081, "Port for Prometheus metrics")
+	fs.Int("server_port", 8090, "Port for the app server")
+	fs.Int("grpc_port", 0, "Port for the gRPC service (0 disables)")
+	fs.String("db_path", "data/badger", "Path to Badger KV database")
+	fs.String("pid_file", "data/docs_organiser.pid", "PID/lock file used in daemon mode")
+	fs.String("schedule", "", "Cron expression for periodic runs in daemon mode (e.g. \"0 2 * * *\" or @daily)")
+	fs.Bool("auto_ctx", true, "Use the context window reported by the model server, falling back to ctx")
+	fs.Bool("warm_up", true, "Send the model a tiny request before each run, so loading it doesn't time out the first file")
+	fs.Bool("debug", false, "Log raw model responses that fail validation")
+	fs.Bool("debug_llm", false, "Log every model request and response (truncated, PII masked) and validation errors")
+	fs.Duration("idle_timeout", 0, "Release connections and caches after this long without a pipeline run (0 disables)")
+	fs.Bool("idle_unload_model", false, "Also ask the model server to unload models when idle (Ollama keep_alive)")
+	fs.String("taxonomy_file", "", "YAML file defining the category tree with descriptions and example document types")
+	fs.StringToString("category_descriptions", nil, "Descriptions shown to the model per category (e.g. Receipts=\"Proof of a single purchase\")")
+	fs.StringToString("category_aliases", nil, "Category names the model may answer with, mapped to the categories they stand for (e.g. Bills=Finance)")
+	fs.Bool("two_stage", false, "Classify nested categories in two passes: top-level folder first, then within it")
+	fs.String("system_prompt_file", "", "text/template file that replaces the built-in classification prompt")
+	fs.Int("suggest_samples", 200, "Source files suggest-categories reads, picked at random")
+	fs.Int("suggest_clusters", 0, "Categories suggest-categories proposes (0 = from the sample size)")
+	fs.String("embedding_model", "", "Model suggest-categories and semantic search embed documents with (empty = the classification model)")
+	fs.Bool("semantic_index", false, "Embed every organised file into the database for search --semantic")
+	fs.Int("search_limit", 10, "Files search lists at most")
+	fs.String("near_duplicates", "off", "Documents whose text nearly repeats an organised one: off, flag (note it in the audit log and run summary), or group (also file them beside it)")
+	fs.Int("near_duplicate_distance", 6, "Bits of the 64-bit simhash in which near-duplicates may differ")
+	fs.Int("votes", 1, "Classify each document this many times and keep the majority category (1 = off)")
+	fs.Float64("vote_temperature", 0.7, "Sampling temperature for voting samples")
+	fs.Float64("clarify_min_confidence", 0.3, "Lowest confidence score that gets a clarification pass")
+	fs.Float64("clarify_max_confidence", 0, "Confidence score below which the model chooses again between the two likeliest categories (0 disables)")
+	fs.Float64("temperature", 0.1, "Sampling temperature of classification requests")
+	fs.Float64("top_p", 0, "Nucleus sampling probability of classification requests (0 = server default)")
+	fs.Int("max_tokens", 0, "Maximum tokens of each response (0 = the output budget, 10% of ctx)")
+	fs.StringSlice("stop", nil, "Stop sequences that end classification responses")
+	fs.Bool("no_llm", false, "Classify by file name patterns and keyword dictionaries only, without a model server")
+	fs.Float64("fast_path", 0, "Classify from file name, folder, and metadata first; extract the text only below this confidence (0 disables)")
+	fs.String("routing_script", "", "Starlark script whose route(doc) may override or veto each document's category and file name")
+	fs.Int("max_concurrent_requests", 0, "Maximum requests in flight to the model servers, across workers and summarization (0 = unlimited)")
+	fs.String("truncation", "map_reduce", "How documents over the content budget are shortened: map_reduce, salience, middle_extraction, or sliding_window")
+	fs.Int("chunk_overlap", 64, "Tokens of whole sentences each summarization chunk repeats from the previous one")
+	fs.Bool("logprobs", false, "Request token log probabilities and derive confidence from the category tokens")
+	fs.StringSlice("include", nil, "Glob patterns of files to process (replaces the default .pdf/.txt/.md whitelist)")
+	fs.StringSlice("exclude", nil, "Glob patterns of files or directories to skip while scanning")
+	fs.String("newer_than", "", "Only process files modified within this age (e.g. 30d, 2w, 36h)")
+	fs.String("since", "", "Only process files modified on or after this date (YYYY-MM-DD or RFC 3339)")
+	fs.String("collisions", "hash", "When the destination name is taken: hash (append content hash), sequence (_1, _2), skip, overwrite, or newest")
+	fs.String("collision_hash", "sha256", "Hash behind the collision suffix: sha256, xxhash (faster), or partial (size plus the first and last partial_hash_mb)")
+	fs.Int("partial_hash_mb", 4, "MB read at each end of a file by the partial collision hash")
+	fs.Bool("quiet", false, "Only print warnings, errors, and run summaries")
+	fs.Bool("verbose", false, "Also log per-file decisions, prompt sizes, and retry reasons")
+	fs.String("log_file", "", "Write log lines to this file instead of stderr, rotating it at log_max_size_mb (empty disables)")
+	fs.Int("log_max_size_mb", 10, "Size in MB at which the log file is rotated (0 = never)")
+	fs.Int("log_max_backups", 5, "Rotated log files to keep")
+	fs.String("output", "text", "Progress output: text, or json for newline-delimited progress and result events on stdout")
+	fs.String("naming", "rename", "File names of classified documents: rename (the model's title), keep (the original name), or prefix (title_original)")
+	fs.String("layout", "flat", "Folders under each category: flat, or mirror to keep each file's source subfolder (Work/ClientA/contract.pdf)")
+	fs.StringToString("category_destinations", nil, "Destination roots for categories and their sub-categories instead of dst (e.g. Finance=/Volumes/Vault)")
+	fs.Int("memory_limit_mb", 0, "Memory budget in MB for the files being processed: large files wait for each other (0 = none)")
+	fs.Float64("io_limit_mb", 0, "Limit copies to another volume (NAS, synced drive) to this many MB per second (0 = no limit)")
+	fs.Int("io_limit_ops", 0, "Limit copies to another volume to this many reads of up to 64 KB per second (0 = no limit)")
+	fs.Int("disk_reserve_mb", 100, "Free space in MB to keep on the destination volume; a run stops when a copy would go below it")
+	fs.Int("max_filename_bytes", 255, "Shorten generated file names to this many bytes, keeping the extension and room for a collision suffix (0 = no limit)")
+	fs.Bool("ascii_names", false, "Transliterate generated folder and file names to ASCII, dropping accents (Café becomes Cafe)")
+	fs.Bool("remove_empty_dirs", false, "Remove source directories left empty after their files are organised (the source root is kept)")
+	fs.Int("unprocessed_after", 0, "Move files whose extraction failed this many times, and files the model cannot classify, to _Unprocessed in the destination (0 disables)")
+	fs.Int("max_files", 0, "Stop each run after this many files (0 = no limit)")
+	fs.Int("sample", 0, "Process this many files picked at random from the whole source (0 = all)")
+	fs.String("order", "walk", "Order of processing: walk (as scanned), smallest, largest, oldest, or newest first")
+	fs.Int("max_depth", 0, "Maximum directory depth to scan below the source (0 = unlimited, 1 = top level only)")
+	fs.Bool("follow_symlinks", false, "Descend into symlinked directories while scanning")
+	fs.Bool("pdftotext_fallback", false, "Retry unreadable PDFs with poppler's pdftotext when installed")
+	fs.Duration("plugin_timeout", 2*time.Minute, "Time a plugin may take for one file before it is stopped")
+	fs.Bool("redact_pii", false, "Mask emails, phone numbers, national IDs, and card numbers before sending text to the model")
+	fs.Bool("local_only", false, "Refuse to use any model endpoint that is not on a loopback address")
+	fs.String("audit_log", "", "Append a JSONL record per processed file to this path (empty disables)")
+	fs.String("results_csv", "", "Append a CSV row per processed file (file, category, title, confidence, status) to this path (empty disables)")
+	fs.Bool("classify_only", false, "Classify files and record the decisions in audit_log and results_csv without moving them")
+	fs.String("failures_file", "", "Write the files that failed in each run, with the reasons, to this JSON file (empty disables)")
+	fs.String("retry_from", "", "Organize only the files listed in this failures file instead of scanning the source")
+	fs.String("queue_file", "", "When the model server is unreachable, list the pending files in this JSON file instead of failing them (empty disables)")
+	fs.Bool("process_queue", false, "Organize only the files listed in queue_file, once the model server is back")
+	fs.String("notes_dir", "", "Write a markdown note (frontmatter, tags, summary, link) per organised file into this vault directory")
+	fs.String("sidecar", "", "Write a json or yaml metadata file next to each organised file (empty disables)")
+	fs.String("photo_path", "", "Route images by EXIF data into this folder template, e.g. Photos/{{year}}/{{month}} (empty disables)")
+	fs.String("photo_name", "", "File name template for routed images, e.g. {{date}} {{time}} (empty keeps the original name)")
+	fs.String("document_path", "", "Folder template for classified documents, e.g. {{category}}/{{year}} (empty keeps the category)")
+	fs.String("document_name", "", "File name template for classified documents, e.g. {{date}} {{title}} (empty keeps the title)")
+	fs.StringSlice("invoice_categories", nil, "Read vendor, amount, invoice number, and due date of documents in these categories, e.g. Receipts,Finance")
+	fs.Bool("pdf_metadata", false, "Stamp the AI title, category, and keywords into organised PDFs' document info and XMP metadata")
+	fs.StringSlice("webhook_urls", nil, "URLs that receive a JSON POST for pipeline events")
+	fs.StringSlice("webhook_events", nil, "Webhook events to send: run_completed, file_failed, low_confidence (default all)")
+	fs.Float64("webhook_min_confidence", 0.5, "Confidence score below which a low_confidence webhook event fires")
+	fs.Float64("review_confidence", 0.5, "Confidence score below which the dashboard lists a move for review (fallbacks always are)")
+	fs.String("slack_webhook_url", "", "Slack incoming webhook that receives the end-of-run summary")
+	fs.String("discord_webhook_url", "", "Discord webhook that receives the end-of-run summary")
+	fs.String("smtp_host", "", "SMTP server used to email the end-of-run summary")
+	fs.Int("smtp_port", 587, "SMTP server port")
+	fs.String("smtp_username", "", "SMTP username (enables PLAIN auth)")
+	fs.String("smtp_password", "", "SMTP password")
+	fs.String("email_from", "", "Sender address for summary emails")
+	fs.StringSlice("email_to", nil, "Recipients of summary emails")
+	fs.StringSlice("pre_run_hook", nil, "Command and arguments run before each run; the run is skipped when it fails (e.g. mount-backup,/mnt/docs)")
+	fs.StringSlice("post_run_hook", nil, "Command and arguments run after each run, with the run_completed event JSON on stdin")
+	fs.Duration("hook_timeout", 10*time.Minute, "Time a pre- or post-run hook may take before it is stopped")
+	fs.String("s3_endpoint", "", "S3-compatible endpoint for s3:// source/destination (default AWS)")
+	fs.String("s3_region", "", "S3 region (defaults to AWS_REGION or us-east-1)")
+	fs.Bool("s3_path_style", false, "Use path-style S3 addressing (implied by s3_endpoint)")
+	fs.String("imap_addr", "", "IMAP server (host:port) whose attachments the imap command organises")
+	fs.String("imap_security", "tls", "IMAP connection security: tls, starttls, or none")
+	fs.String("imap_username", "", "IMAP username")
+	fs.String("imap_password", "", "IMAP password (or app password)")
+	fs.String("imap_folder", "INBOX", "IMAP folder to read")
+	fs.String("imap_processed_folder", "", "Move processed messages to this folder instead of flagging them")
+	fs.StringSlice("imap_from", nil, "Only ingest messages whose sender contains one of these strings (e.g. @vendor.com)")
+	fs.String("imap_subject", "", "Only ingest messages whose subject contains this string")
+	fs.StringSlice("imap_extensions", nil, "Attachment types to ingest (default .pdf plus extensions with a configured extractor)")
+	fs.Int("imap_max_message_mb", 50, "Skip messages larger than this many megabytes (0 = unlimited)")
+	fs.Duration("imap_poll_interval", 0, "Check the mailbox again after this long (0 = check once and exit)")
+	fs.String("tls_ca_file", "", "PEM CA bundle to trust for HTTPS model endpoints")
+	fs.String("tls_cert_file", "", "Client certificate (PEM) for mutual TLS with model endpoints")
+	fs.String("tls_key_file", "", "Client private key (PEM) for mutual TLS with model endpoints")
+	fs.Bool("tls_insecure_skip_verify", false, "Skip TLS certificate verification for model endpoints (insecure)")
+	fs.String("proxy_url", "", "HTTP(S) proxy for all AI requests (defaults to HTTP_PROXY/HTTPS_PROXY)")
+	fs.StringToString("headers", nil, "Extra HTTP headers for all AI requests (e.g. X-API-Key=secret)")
+	fs.Int("http_max_idle_per_host", 32, "Idle keep-alive connections kept open to each model server")
+	fs.Duration("http_idle_timeout", 90*time.Second, "Close keep-alive connections to model servers after this long idle")
+	fs.Bool("http_keep_alive", true, "Reuse connections to model servers between requests")
+	fs.Duration("request_timeout", 60*time.Second, "Timeout of each classification and summarization request")
+	fs.Int("circuit_breaker", 5, "Hold files after this many requests in a row could not reach a model server, until one answers again (0 disables)")
+	fs.Duration("circuit_probe_interval", 15*time.Second, "How often a tripped circuit breaker checks whether the model server is back")
+	fs.String("profile", "", "Named profile from the profiles section of the config file to run")
+	fs.String("config", "config.yaml", "Path to YAML configuration file")
+	return fs
 }
 
-func LoadConfig() (*Config, error) {
+// Load builds the configuration from the parsed flags in fs, DOCS_ environment variables,
+// and the config file, in that order of precedence.
+func Load(fs *pflag.FlagSet) (*Config, error) {
 	// 1. Set Infrastructure Defaults
 	viper.SetDefault("api", "http://localhost:8080/v1")
 	viper.SetDefault("metrics_enabled", true)
@@ -43,6 +486,7 @@ func LoadConfig() (*Config, error) {
 	viper.SetDefault("server_port", 8090)
 	viper.SetDefault("encoding", "cl100k_base")
 	viper.SetDefault("ctx", 4096)
+	viper.SetDefault("auto_ctx", true)
 	viper.SetDefault("db_path", "data/badger")
 
 	// User Defaults (These will not be loaded from YAML)
@@ -57,28 +501,21 @@ func LoadConfig() (*Config, error) {
 	defaultWorkers := 5
 	defaultLimit := 0 // 0 means "Auto"
 
-	// 2. Define Flags for Infra
-	pflag.String("api", "http://localhost:8080/v1", "URL of the MLX server")
-	pflag.Bool("metrics_enabled", true, "Enable Prometheus metrics")
-	pflag.Int("metrics_port", 8081, "Port for Prometheus metrics")
-	pflag.Int("server_port", 8090, "Port for the app server")
-	pflag.String("db_path", "data/badger", "Path to Badger KV database")
-	configPath := pflag.String("config", "config.yaml", "Path to YAML configuration file")
-	pflag.Parse()
-
-	// 3. Bind Flags to Viper
-	if err := viper.BindPFlags(pflag.CommandLine); err != nil {
+	configPath, _ := fs.GetString("config")
+
+	// 2. Bind Flags to Viper
+	if err := viper.BindPFlags(fs); err != nil {
 		return nil, fmt.Errorf("failed to bind flags: %w", err)
 	}
 
-	// 4. Environment variables (Prefix DOCS_)
+	// 3. Environment variables (Prefix DOCS_)
 	viper.SetEnvPrefix("DOCS")
 	viper.SetEnvKeyReplacer(strings.NewReplacer("-", "_", ".", "_"))
 	viper.AutomaticEnv()
 
-	// 5. Load Configuration File (Infra Only)
-	if *configPath != "" {
-		viper.SetConfigFile(*configPath)
+	// 4. Load Configuration File (Infra Only)
+	if configPath != "" {
+		viper.SetConfigFile(configPath)
 		if err := viper.ReadInConfig(); err != nil {
 			if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
 				// We don't fail if config is missing, just use env/defaults
@@ -87,6 +524,17 @@ func LoadConfig() (*Config, error) {
 		}
 	}
 
+	// 5. A named profile overrides the top-level settings of the file
+	if name := viper.GetString("profile"); name != "" {
+		if err := selectProfile(viper.GetViper(), name); err != nil {
+			return nil, err
+		}
+	}
+	var profile ProfileSettings
+	if err := viper.Unmarshal(&profile); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
+	}
+
 	// 6. Unmarshal into struct
 	var cfg Config
 	if err := viper.Unmarshal(&cfg); err != nil {
@@ -102,6 +550,9 @@ func LoadConfig() (*Config, error) {
 	cfg.Workers = defaultWorkers
 	cfg.ExtractLimit = defaultLimit
 	cfg.Categories = []string{} // Initialized empty for auto-discovery
+	cfg.ConfigFile = configPath
+	cfg.Profile = strings.ToLower(cfg.Profile) // profile names are case-insensitive, like all keys
+	cfg.ProfileSettings = profile
 
 	return &cfg, nil
 }
@@ -0,0 +1,85 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+const profilesYAML = `
+workers: 2
+exclude: ["*.tmp"]
+profiles:
+  scans:
+    src: /home/me/Scans
+    dst: /home/me/Documents
+    categories: [Receipts, Taxes]
+    taxonomy_file: scans.yaml
+  downloads:
+    src: /home/me/Downloads
+    exclude: ["*.part"]
+`
+
+func TestSelectProfile(t *testing.T) {
+	load := func(t *testing.T) *viper.Viper {
+		t.Helper()
+		v := viper.New()
+		v.SetConfigType("yaml")
+		if err := v.ReadConfig(strings.NewReader(profilesYAML)); err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+
+	v := load(t)
+	if err := selectProfile(v, "Scans"); err != nil {
+		t.Fatalf("selectProfile: %v", err)
+	}
+	var settings ProfileSettings
+	if err := v.Unmarshal(&settings); err != nil {
+		t.Fatal(err)
+	}
+	want := ProfileSettings{SourceDir: "/home/me/Scans", DestDir: "/home/me/Documents", Categories: []string{"Receipts", "Taxes"}}
+	if !reflect.DeepEqual(settings, want) {
+		t.Errorf("settings = %+v, want %+v", settings, want)
+	}
+	if got := v.GetString("taxonomy_file"); got != "scans.yaml" {
+		t.Errorf("taxonomy_file = %q, want the profile's", got)
+	}
+	if got := v.GetStringSlice("exclude"); !reflect.DeepEqual(got, []string{"*.tmp"}) {
+		t.Errorf("exclude = %v, want the top-level value kept", got)
+	}
+
+	v = load(t)
+	if err := selectProfile(v, "downloads"); err != nil {
+		t.Fatalf("selectProfile: %v", err)
+	}
+	if got := v.GetStringSlice("exclude"); !reflect.DeepEqual(got, []string{"*.part"}) {
+		t.Errorf("exclude = %v, want the profile's", got)
+	}
+	if got := v.GetInt("workers"); got != 2 {
+		t.Errorf("workers = %d, want the top-level value", got)
+	}
+
+	if err := selectProfile(load(t), "photos"); err == nil || !strings.Contains(err.Error(), "defined: downloads, scans") {
+		t.Errorf("unknown profile error = %v", err)
+	}
+}
+
+func TestApplyProfile(t *testing.T) {
+	cfg := Config{SourceDir: "/saved/src", DestDir: "/saved/dst", Categories: []string{"Work"}}
+	cfg.ProfileSettings = ProfileSettings{DestDir: "/profile/dst"}
+	cfg.ApplyProfile()
+	if cfg.SourceDir != "/saved/src" || cfg.DestDir != "/profile/dst" || !reflect.DeepEqual(cfg.Categories, []string{"Work"}) {
+		t.Errorf("ApplyProfile() = %s, %s, %v", cfg.SourceDir, cfg.DestDir, cfg.Categories)
+	}
+
+	if got := (&Config{}).SettingsKey(); got != "user_settings" {
+		t.Errorf("SettingsKey() = %q", got)
+	}
+	if got := (&Config{Profile: "scans"}).SettingsKey(); got != "user_settings/scans" {
+		t.Errorf("SettingsKey() = %q", got)
+	}
+}
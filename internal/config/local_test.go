@@ -0,0 +1,26 @@
+package config
+
+import "testing"
+
+func TestEnsureLoopback(t *testing.T) {
+	tests := []struct {
+		url     string
+		wantErr bool
+	}{
+		{"http://127.0.0.1:8080/v1", false},
+		{"http://[::1]:11434/v1", false},
+		{"http://localhost:8080/v1", false},
+		{"http://10.0.0.5:8080/v1", true},
+		{"https://8.8.8.8/v1", true},
+		{"not a url at all", true},
+		{"http://:8080", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			if err := EnsureLoopback(tt.url); (err != nil) != tt.wantErr {
+				t.Errorf("EnsureLoopback(%q) error = %v, wantErr %v", tt.url, err, tt.wantErr)
+			}
+		})
+	}
+}
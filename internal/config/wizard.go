@@ -0,0 +1,200 @@
+package config
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Backends offered by the init wizard, with the URL their servers listen on by default.
+var Backends = []struct {
+	Name, URL string
+}{
+	{"mlx", "http://localhost:8080/v1"},
+	{"ollama", "http://localhost:11434/v1"},
+	{"llama.cpp", "http://localhost:8080/v1"},
+}
+
+// WizardAnswers are the settings collected by Wizard.Run.
+type WizardAnswers struct {
+	SourceDir  string
+	DestDir    string
+	APIURL     string
+	Categories []string // empty: discover from the destination's folders
+}
+
+// Wizard asks for the settings a first run needs and writes them as a commented
+// config file.
+type Wizard struct {
+	In  io.Reader
+	Out io.Writer
+	// Models lists the models served at an API URL, to confirm the backend is up (optional)
+	Models func(apiURL string) ([]string, error)
+
+	scanner *bufio.Scanner
+}
+
+// Run asks the questions in order. Answers that can be checked, such as the source
+// directory, are asked again until they are valid.
+func (w *Wizard) Run() (WizardAnswers, error) {
+	if w.scanner == nil {
+		w.scanner = bufio.NewScanner(w.In)
+	}
+	var a WizardAnswers
+	var err error
+
+	fmt.Fprintln(w.Out, "Answer each question, or press Enter to accept the [default].")
+	for {
+		if a.SourceDir, err = w.askPath("Folder to organise (source)"); err != nil {
+			return a, err
+		}
+		if info, statErr := os.Stat(a.SourceDir); statErr != nil || !info.IsDir() {
+			fmt.Fprintf(w.Out, "  %s is not a folder; try again.\n", a.SourceDir)
+			continue
+		}
+		break
+	}
+	for {
+		if a.DestDir, err = w.askPath("Folder to move organised files into (destination)"); err != nil {
+			return a, err
+		}
+		if a.DestDir == a.SourceDir {
+			fmt.Fprintln(w.Out, "  The destination must differ from the source; try again.")
+			continue
+		}
+		if _, statErr := os.Stat(a.DestDir); errors.Is(statErr, os.ErrNotExist) {
+			fmt.Fprintf(w.Out, "  %s does not exist yet; it will be created on the first run.\n", a.DestDir)
+		}
+		break
+	}
+
+	names := make([]string, len(Backends))
+	for i, b := range Backends {
+		names[i] = b.Name
+	}
+	backend, err := w.ask(fmt.Sprintf("Model backend (%s, or a server URL)", strings.Join(names, ", ")), Backends[0].Name, false)
+	if err != nil {
+		return a, err
+	}
+	a.APIURL = backend
+	for _, b := range Backends {
+		if strings.EqualFold(backend, b.Name) {
+			a.APIURL = b.URL
+		}
+	}
+	if w.Models != nil {
+		if models, err := w.Models(a.APIURL); err != nil {
+			fmt.Fprintf(w.Out, "  Could not reach %s (%v); start the server before the first run.\n", a.APIURL, err)
+		} else {
+			fmt.Fprintf(w.Out, "  %s serves: %s\n", a.APIURL, strings.Join(models, ", "))
+		}
+	}
+
+	categories, err := w.ask("Categories, comma-separated (empty: use the destination's folders)", "", true)
+	if err != nil {
+		return a, err
+	}
+	for _, c := range strings.Split(categories, ",") {
+		if c = strings.Trim(strings.TrimSpace(c), "/"); c != "" {
+			a.Categories = append(a.Categories, c)
+		}
+	}
+	return a, nil
+}
+
+// Confirm asks a yes/no question, defaulting to no.
+func (w *Wizard) Confirm(question string) (bool, error) {
+	if w.scanner == nil {
+		w.scanner = bufio.NewScanner(w.In)
+	}
+	answer, err := w.ask(question+" (y/N)", "n", false)
+	if err != nil {
+		return false, err
+	}
+	answer = strings.ToLower(answer)
+	return answer == "y" || answer == "yes", nil
+}
+
+// ask prints question and returns the trimmed answer, def for an empty one, or "" when
+// optional. Required questions are repeated until answered; the end of input answers
+// with the default, and is an error when there is none.
+func (w *Wizard) ask(question, def string, optional bool) (string, error) {
+	prompt := question + ": "
+	if def != "" {
+		prompt = fmt.Sprintf("%s [%s]: ", question, def)
+	}
+	for {
+		fmt.Fprint(w.Out, prompt)
+		if !w.scanner.Scan() {
+			fmt.Fprintln(w.Out)
+			if err := w.scanner.Err(); err != nil {
+				return "", err
+			}
+			if def != "" || optional {
+				return def, nil
+			}
+			return "", fmt.Errorf("no answer to %q", question)
+		}
+		if answer := strings.TrimSpace(w.scanner.Text()); answer != "" {
+			return answer, nil
+		}
+		if def != "" || optional {
+			return def, nil
+		}
+	}
+}
+
+// askPath asks for a folder and returns it as an absolute path, expanding a leading ~.
+func (w *Wizard) askPath(question string) (string, error) {
+	answer, err := w.ask(question, "", false)
+	if err != nil {
+		return "", err
+	}
+	if answer == "~" || strings.HasPrefix(answer, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			answer = filepath.Join(home, answer[1:])
+		}
+	}
+	return filepath.Abs(answer)
+}
+
+// WriteConfig writes answers as a commented config file at path.
+func WriteConfig(path string, a WizardAnswers) error {
+	quote := func(v interface{}) string {
+		b, _ := json.Marshal(v) // JSON strings and arrays are valid YAML
+		return string(b)
+	}
+
+	var b strings.Builder
+	b.WriteString("# Docs Organiser Configuration\n")
+	b.WriteString("# Written by `docs_organiser config init`; config.yaml.example lists every option.\n\n")
+	b.WriteString("# Files are read from src (recursively) and moved into category folders under dst\n")
+	fmt.Fprintf(&b, "src: %s\n", quote(a.SourceDir))
+	fmt.Fprintf(&b, "dst: %s\n\n", quote(a.DestDir))
+	b.WriteString("# OpenAI-compatible model server: MLX LM, Ollama, llama.cpp, vLLM, ...\n")
+	fmt.Fprintf(&b, "api: %s\n\n", quote(a.APIURL))
+	b.WriteString("# Categories the model chooses from; without this list, the folders in dst are used\n")
+	if len(a.Categories) > 0 {
+		fmt.Fprintf(&b, "categories: %s\n", quote(a.Categories))
+	} else {
+		b.WriteString("# categories: [\"Finance\", \"Receipts\", \"Work\"]\n")
+	}
+	b.WriteString(`
+# Try a run on a few files first
+# max_files: 20
+
+# Keep a JSONL record of every decision, to review a run later
+# audit_log: "data/audit.jsonl"
+`)
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+	return os.WriteFile(path, []byte(b.String()), 0644)
+}
@@ -0,0 +1,76 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestWizard(t *testing.T) {
+	src := t.TempDir()
+	dst := filepath.Join(t.TempDir(), "sorted")
+	input := strings.Join([]string{
+		"y",                        // overwrite
+		"",                         // required: asked again
+		filepath.Join(src, "nope"), // not a folder: asked again
+		src,
+		src, // same as the source: asked again
+		dst,
+		"ollama",
+		" Finance, Receipts/ ,, Work ",
+	}, "\n") + "\n"
+
+	var out strings.Builder
+	var probed string
+	w := &Wizard{In: strings.NewReader(input), Out: &out, Models: func(apiURL string) ([]string, error) {
+		probed = apiURL
+		return nil, errors.New("connection refused")
+	}}
+	if ok, err := w.Confirm("Overwrite?"); err != nil || !ok {
+		t.Fatalf("Confirm() = %v, %v", ok, err)
+	}
+	a, err := w.Run()
+	if err != nil {
+		t.Fatalf("Run: %v\n%s", err, out.String())
+	}
+	want := WizardAnswers{SourceDir: src, DestDir: dst, APIURL: "http://localhost:11434/v1", Categories: []string{"Finance", "Receipts", "Work"}}
+	if !reflect.DeepEqual(a, want) {
+		t.Errorf("answers = %+v, want %+v", a, want)
+	}
+	if probed != want.APIURL || !strings.Contains(out.String(), "Could not reach") || !strings.Contains(out.String(), "will be created") {
+		t.Errorf("unexpected output for %q:\n%s", probed, out.String())
+	}
+
+	// The written file is read back as the same settings
+	path := filepath.Join(t.TempDir(), "conf", "config.yaml")
+	if err := WriteConfig(path, a); err != nil {
+		t.Fatalf("WriteConfig: %v", err)
+	}
+	v := viper.New()
+	v.SetConfigFile(path)
+	if err := v.ReadInConfig(); err != nil {
+		t.Fatalf("reading the written config: %v", err)
+	}
+	var settings ProfileSettings
+	if err := v.Unmarshal(&settings); err != nil {
+		t.Fatal(err)
+	}
+	if settings.SourceDir != src || settings.DestDir != dst || !reflect.DeepEqual(settings.Categories, want.Categories) || v.GetString("api") != want.APIURL {
+		t.Errorf("written config read back as %+v, api %q", settings, v.GetString("api"))
+	}
+	if data, _ := os.ReadFile(path); !strings.HasPrefix(string(data), "# Docs Organiser Configuration") {
+		t.Errorf("written config lacks its header:\n%s", data)
+	}
+}
+
+func TestWizard_EndOfInput(t *testing.T) {
+	w := &Wizard{In: strings.NewReader(""), Out: &strings.Builder{}}
+	if _, err := w.Run(); err == nil {
+		t.Error("Run() with no input succeeded, want an error for the missing source")
+	}
+}
@@ -0,0 +1,474 @@
+// Package configcheck validates a configuration before a long run starts: folders, model
+// endpoints, tokenizer, categories, and templates. Every problem comes with a hint on how
+// to fix it, so a typo is caught in seconds instead of hours into a run.
+package configcheck
+
+import (
+	"context"
+	"docs_organiser/internal/ai"
+	"docs_organiser/internal/config"
+	"docs_organiser/internal/fileops"
+	"docs_organiser/internal/pipeline"
+	"docs_organiser/internal/remote"
+	"docs_organiser/internal/schedule"
+	"docs_organiser/internal/script"
+	"docs_organiser/internal/taxonomy"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// endpointTimeout bounds the reachability check of each model endpoint.
+const endpointTimeout = 10 * time.Second
+
+// Result is the outcome of one check.
+type Result struct {
+	Check  string // what was checked, e.g. "Destination directory"
+	Detail string // what was found, for checks that passed
+	Err    error
+	Hint   string // how to fix Err
+}
+
+// OK reports whether the check passed.
+func (r Result) OK() bool {
+	return r.Err == nil
+}
+
+// Run checks cfg and returns one result per check, in a fixed order.
+func Run(ctx context.Context, cfg *config.Config) []Result {
+	results := []Result{
+		checkSource(cfg.SourceDir),
+		checkDestination(cfg.DestDir),
+	}
+	for _, category := range slices.Sorted(maps.Keys(cfg.CategoryDestinations)) {
+		r := checkDestination(cfg.CategoryDestinations[category])
+		r.Check = "Destination for " + category
+		results = append(results, r)
+	}
+	results = append(results, checkEncoding(cfg.Encoding))
+	results = append(results, checkModels(ctx, cfg))
+	for _, def := range cfg.Plugins {
+		results = append(results, checkPlugin(def))
+	}
+	results = append(results, checkCategories(cfg))
+	if cfg.SystemPromptFile != "" {
+		results = append(results, checkSystemPrompt(cfg.SystemPromptFile))
+	}
+	if cfg.RoutingScript != "" {
+		results = append(results, checkScript(cfg.RoutingScript))
+	}
+	results = append(results, checkTemplates(cfg)...)
+	results = append(results, checkOptions(cfg)...)
+	return results
+}
+
+// Failed counts the results that did not pass.
+func Failed(results []Result) int {
+	n := 0
+	for _, r := range results {
+		if !r.OK() {
+			n++
+		}
+	}
+	return n
+}
+
+// WriteText prints results with the log prefixes used elsewhere, followed by a verdict.
+func WriteText(w io.Writer, results []Result) {
+	for _, r := range results {
+		if r.OK() {
+			fmt.Fprintf(w, "[+] %s: %s\n", r.Check, r.Detail)
+			continue
+		}
+		fmt.Fprintf(w, "[!] %s: %v\n", r.Check, r.Err)
+		if r.Hint != "" {
+			fmt.Fprintf(w, "    Fix: %s\n", r.Hint)
+		}
+	}
+	if n := Failed(results); n > 0 {
+		fmt.Fprintf(w, "[!] %d of %d checks failed\n", n, len(results))
+	} else {
+		fmt.Fprintf(w, "[+] Configuration is valid (%d checks passed)\n", len(results))
+	}
+}
+
+func checkSource(dir string) Result {
+	r := Result{Check: "Source directory"}
+	switch {
+	case dir == "":
+		r.Err = errors.New("not set")
+		r.Hint = "set src in a profile, or choose the source folder in the dashboard"
+	case remote.IsRemote(dir):
+		r.Detail = dir + " (remote, not checked)"
+	default:
+		info, err := os.Stat(dir)
+		switch {
+		case err != nil:
+			r.Err = err
+			r.Hint = "check the path for typos, or mount the drive it lives on"
+		case !info.IsDir():
+			r.Err = fmt.Errorf("%s is not a directory", dir)
+			r.Hint = "point src at the folder that contains the files, not a file"
+		default:
+			if _, err := os.ReadDir(dir); err != nil {
+				r.Err = err
+				r.Hint = "grant the user running docs_organiser read access to the folder"
+			} else if ignore, err := pipeline.LoadIgnoreFile(filepath.Join(dir, pipeline.IgnoreFileName)); err != nil {
+				r.Err = err
+				r.Hint = "fix or remove the pattern; the file uses .gitignore syntax"
+			} else if ignore != nil {
+				r.Detail = dir + " (readable, with " + pipeline.IgnoreFileName + ")"
+			} else {
+				r.Detail = dir + " (readable)"
+			}
+		}
+	}
+	return r
+}
+
+func checkDestination(dir string) Result {
+	r := Result{Check: "Destination directory"}
+	switch {
+	case dir == "":
+		r.Err = errors.New("not set")
+		r.Hint = "set dst in a profile, or choose the destination folder in the dashboard"
+		return r
+	case remote.IsRemote(dir):
+		r.Detail = dir + " (remote, not checked)"
+		return r
+	}
+
+	// A missing destination is created on the first move, so its nearest existing
+	// ancestor is what has to be writable
+	target, created := dir, false
+	for {
+		info, err := os.Stat(target)
+		if err == nil {
+			if !info.IsDir() {
+				r.Err = fmt.Errorf("%s is not a directory", target)
+				r.Hint = "point dst at a folder, or remove the file in its way"
+				return r
+			}
+			break
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			r.Err = err
+			r.Hint = "check the permissions of the folders above dst"
+			return r
+		}
+		parent := filepath.Dir(target)
+		if parent == target {
+			r.Err = err
+			return r
+		}
+		target, created = parent, true
+	}
+
+	probe, err := os.CreateTemp(target, ".docs_organiser-check-*")
+	if err != nil {
+		r.Err = fmt.Errorf("%s is not writable: %w", target, err)
+		r.Hint = "grant the user running docs_organiser write access, or choose another dst"
+		return r
+	}
+	probe.Close()
+	os.Remove(probe.Name())
+	if created {
+		r.Detail = dir + " (will be created)"
+	} else {
+		r.Detail = dir + " (writable)"
+	}
+	return r
+}
+
+// checkPlugin checks that a plugin's program can be found; the plugin is not run.
+func checkPlugin(def config.PluginDefinition) Result {
+	r := Result{Check: "Plugin " + def.Name}
+	if len(def.Command) == 0 {
+		r.Err = errors.New("no command")
+		return r
+	}
+	path, err := exec.LookPath(def.Command[0])
+	if err != nil {
+		r.Err = err
+		r.Hint = "install the plugin, or give the full path of its program in command"
+		return r
+	}
+	r.Detail = def.Type + ": " + path
+	return r
+}
+
+func checkEncoding(encoding string) Result {
+	r := Result{Check: "Tokenizer"}
+	if _, err := ai.NewTokenizer(encoding); err != nil {
+		r.Err = err
+		r.Hint = "use a tiktoken encoding such as cl100k_base or o200k_base, or the path of the model's tokenizer.json"
+		return r
+	}
+	r.Detail = encoding
+	return r
+}
+
+func checkModels(ctx context.Context, cfg *config.Config) Result {
+	r := Result{Check: "Model server"}
+	if cfg.NoLLM {
+		r.Detail = "not used (no_llm)"
+		return r
+	}
+	if _, classifier, err := pipeline.ParsePlugins(cfg.Plugins, cfg.PluginTimeout); err == nil && classifier != nil {
+		r.Detail = "not used (classifier plugin " + classifier.Name + ")"
+		return r
+	}
+	if cfg.LocalOnly {
+		urls := []string{cfg.APIURL}
+		for _, m := range cfg.AllowedModels {
+			urls = append(urls, m.URL)
+		}
+		for _, u := range urls {
+			if err := config.EnsureLoopback(u); err != nil {
+				r.Err = fmt.Errorf("local_only: %w", err)
+				r.Hint = "run the model server on this machine, or turn off local_only"
+				return r
+			}
+		}
+	}
+	engine, err := ai.NewMLXEngine(cfg.APIURL, cfg.AllowedModels, cfg.ContextWindow, "cl100k_base")
+	if err != nil {
+		r.Err = err
+		return r
+	}
+	if err := engine.ConfigureTLS(ai.TLSOptions{
+		CAFile:             cfg.TLSCAFile,
+		CertFile:           cfg.TLSCertFile,
+		KeyFile:            cfg.TLSKeyFile,
+		InsecureSkipVerify: cfg.TLSInsecureSkipVerify,
+	}); err != nil {
+		r.Err = err
+		r.Hint = "check tls_ca_file, tls_cert_file, and tls_key_file"
+		return r
+	}
+	if err := engine.SetProxy(cfg.ProxyURL); err != nil {
+		r.Err = err
+		r.Hint = "proxy_url must be an http:// or https:// URL"
+		return r
+	}
+	engine.SetHeaders(cfg.Headers)
+	if cfg.DefaultModelName != "" {
+		engine.SetDefaultModel(cfg.DefaultModelName)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, endpointTimeout)
+	defer cancel()
+	model, err := engine.Preflight(ctx)
+	if err != nil {
+		r.Err = err
+		r.Hint = "start the model server (see \"Starting the AI Server\" in the README) or correct api and the model names"
+		return r
+	}
+	r.Detail = model + " is loaded"
+	return r
+}
+
+// checkScript loads the routing script, which runs its top level but not route.
+func checkSystemPrompt(path string) Result {
+	r := Result{Check: "System prompt"}
+	if _, err := ai.LoadSystemPrompt(path); err != nil {
+		r.Err = err
+		r.Hint = "the file must be a valid text/template that lists the categories, e.g. with {{.CategoryList}}"
+		return r
+	}
+	r.Detail = path
+	return r
+}
+
+func checkScript(path string) Result {
+	r := Result{Check: "Routing script"}
+	if _, err := script.Load(path); err != nil {
+		r.Err = err
+		r.Hint = "the script must be valid Starlark and define route(doc)"
+		return r
+	}
+	r.Detail = path
+	return r
+}
+
+func checkCategories(cfg *config.Config) Result {
+	r := Result{Check: "Categories"}
+	if cfg.TaxonomyFile != "" {
+		tax, err := taxonomy.Load(cfg.TaxonomyFile)
+		if err != nil {
+			r.Err = err
+			r.Hint = "fix the taxonomy file; taxonomy.yaml.example shows the format"
+			return r
+		}
+		if err := checkAliases(cfg.CategoryAliases, tax.Paths()); err != nil {
+			r.Err = err
+			r.Hint = "point each of category_aliases at a category path of the taxonomy"
+			return r
+		}
+		r.Detail = fmt.Sprintf("%d from %s", len(tax.Paths()), cfg.TaxonomyFile)
+		return r
+	}
+	if len(cfg.Categories) == 0 {
+		r.Detail = "discovered from the destination's folders"
+		return r
+	}
+	if err := validateCategoryNames(cfg.Categories); err != nil {
+		r.Err = err
+		r.Hint = "use folder names of letters, digits, spaces, _ - and ., with / between nested folders"
+		return r
+	}
+	if err := checkAliases(cfg.CategoryAliases, cfg.Categories); err != nil {
+		r.Err = err
+		r.Hint = "point each of category_aliases at one of the categories"
+		return r
+	}
+	r.Detail = fmt.Sprintf("%d configured", len(cfg.Categories))
+	return r
+}
+
+// checkAliases rejects aliases of categories that don't exist, which would never apply.
+func checkAliases(aliases map[string]string, categories []string) error {
+	for _, alias := range slices.Sorted(maps.Keys(aliases)) {
+		target := strings.Trim(strings.TrimSpace(aliases[alias]), "/")
+		if !slices.ContainsFunc(categories, func(c string) bool { return strings.EqualFold(c, target) }) {
+			return fmt.Errorf("category_aliases.%s points to %q, which is not a category", alias, aliases[alias])
+		}
+	}
+	return nil
+}
+
+// validateCategoryNames rejects names that would be filed under a different folder than
+// written (SanitizeCategory would change them) or that collide on case-insensitive file systems.
+func validateCategoryNames(categories []string) error {
+	seen := make(map[string]string)
+	for _, c := range categories {
+		for _, part := range strings.Split(c, "/") {
+			if strings.TrimSpace(part) == "" || part == "." || part == ".." {
+				return fmt.Errorf("category %q has an empty or relative folder name", c)
+			}
+		}
+		if clean := ai.SanitizeCategory(c); clean != c {
+			return fmt.Errorf("category %q would be filed as %q", c, clean)
+		}
+		if prev, ok := seen[strings.ToLower(c)]; ok {
+			return fmt.Errorf("categories %q and %q differ only in case", prev, c)
+		}
+		seen[strings.ToLower(c)] = c
+	}
+	return nil
+}
+
+func checkTemplates(cfg *config.Config) []Result {
+	var results []Result
+	if cfg.PhotoPath != "" {
+		r := Result{Check: "Photo templates", Detail: cfg.PhotoPath}
+		if r.Err = (pipeline.PhotoRouting{Path: cfg.PhotoPath, Name: cfg.PhotoName}).Validate(); r.Err != nil {
+			r.Hint = "photo_path and photo_name may only use the variables listed under Photo Routing in the README"
+		}
+		results = append(results, r)
+	}
+	if cfg.DocumentPath != "" || cfg.DocumentName != "" {
+		r := Result{Check: "Document templates", Detail: strings.Trim(cfg.DocumentPath+" "+cfg.DocumentName, " ")}
+		if r.Err = (pipeline.DocumentRouting{Path: cfg.DocumentPath, Name: cfg.DocumentName}).Validate(); r.Err != nil {
+			r.Hint = "document_path and document_name may only use the variables listed under Document Dates in the README"
+		}
+		results = append(results, r)
+	}
+	if cfg.Schedule != "" {
+		r := Result{Check: "Schedule", Detail: cfg.Schedule}
+		if _, r.Err = schedule.Parse(cfg.Schedule); r.Err != nil {
+			r.Hint = "use a five-field cron expression such as \"0 2 * * *\", or @daily"
+		}
+		results = append(results, r)
+	}
+	return results
+}
+
+func checkOptions(cfg *config.Config) []Result {
+	r := Result{Check: "Options", Detail: "collisions, naming, layout, filters, truncation, and rules are valid"}
+	var problems []string
+	add := func(err error) {
+		if err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+
+	_, err := fileops.ParseCollisionPolicy(cfg.Collisions)
+	add(err)
+	_, err = fileops.ParseHashScheme(cfg.CollisionHash)
+	add(err)
+	if cfg.PartialHashMB < 0 {
+		add(errors.New("partial_hash_mb must not be negative"))
+	}
+	add(fileops.ValidateNameLimit(cfg.MaxFilenameBytes))
+	if cfg.MemoryLimitMB < 0 {
+		add(errors.New("memory_limit_mb must not be negative"))
+	}
+	if cfg.IOLimitMB < 0 || cfg.IOLimitOps < 0 {
+		add(errors.New("io_limit_mb and io_limit_ops must not be negative"))
+	}
+	if cfg.HTTPMaxIdlePerHost < 0 || cfg.HTTPIdleTimeout < 0 || cfg.RequestTimeout < 0 {
+		add(errors.New("http_max_idle_per_host, http_idle_timeout, and request_timeout must not be negative"))
+	}
+	if cfg.CircuitBreaker < 0 || (cfg.CircuitBreaker > 0 && cfg.CircuitProbeInterval <= 0) {
+		add(errors.New("circuit_breaker must not be negative, and circuit_probe_interval must be positive"))
+	}
+	if cfg.UnprocessedAfter < 0 {
+		add(errors.New("unprocessed_after must not be negative"))
+	}
+	if cfg.DiskReserveMB < 0 {
+		add(errors.New("disk_reserve_mb must not be negative"))
+	}
+	if cfg.HookTimeout < 0 {
+		add(errors.New("hook_timeout must not be negative"))
+	}
+	_, err = pipeline.ParseNamingMode(cfg.Naming)
+	add(err)
+	_, err = pipeline.ParseNearDuplicateMode(cfg.NearDuplicates)
+	add(err)
+	add(pipeline.ValidateNearDuplicateDistance(cfg.NearDuplicateDistance))
+	_, err = pipeline.ParseLayout(cfg.Layout)
+	add(err)
+	_, err = pipeline.ParseDestinations(cfg.CategoryDestinations)
+	add(err)
+	_, _, err = pipeline.ParsePlugins(cfg.Plugins, cfg.PluginTimeout)
+	add(err)
+	_, err = pipeline.ParseJobOrder(cfg.Order)
+	add(err)
+	if cfg.RetryFrom != "" {
+		_, err = pipeline.LoadFailures(cfg.RetryFrom)
+		add(err)
+	}
+	if cfg.ProcessQueue && (cfg.QueueFile == "" || cfg.RetryFrom != "") {
+		add(errors.New("process_queue needs queue_file, and can't be combined with retry_from"))
+	}
+	_, err = pipeline.ParseModifiedAfter(cfg.NewerThan, cfg.Since, time.Now())
+	add(err)
+	add(pipeline.ScanFilter{Include: cfg.Include, Exclude: cfg.Exclude}.Validate())
+	add(pipeline.ValidateSidecarFormat(cfg.Sidecar))
+	for ext, limit := range cfg.ExtractLimits {
+		if limit <= 0 {
+			add(fmt.Errorf("extract_limits.%s must be a positive number of characters", ext))
+		}
+	}
+	if engine, err := ai.NewMLXEngine(cfg.APIURL, cfg.AllowedModels, cfg.ContextWindow, "cl100k_base"); err == nil {
+		add(engine.SetTruncationStrategy(cfg.Truncation))
+		add(engine.SetHeuristicRules(cfg.Keywords, cfg.FilenamePatterns))
+		add(engine.SetCategoryAliases(cfg.CategoryAliases))
+		add(engine.SetClarification(cfg.ClarifyMinConfidence, cfg.ClarifyMaxConfidence))
+		add(engine.SetSampling(ai.Sampling{Temperature: cfg.Temperature, TopP: cfg.TopP, MaxTokens: cfg.MaxTokens, Stop: cfg.Stop}))
+	}
+
+	if len(problems) > 0 {
+		r.Err = errors.New(strings.Join(problems, "; "))
+		r.Hint = "see the Configuration Options table in the README for the accepted values"
+	}
+	return []Result{r}
+}
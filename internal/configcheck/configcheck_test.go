@@ -0,0 +1,102 @@
+package configcheck
+
+import (
+	"bytes"
+	"context"
+	"docs_organiser/internal/aitest"
+	"docs_organiser/internal/config"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func validConfig(t *testing.T) *config.Config {
+	t.Helper()
+	server := aitest.NewServer(t, "test-model")
+	return &config.Config{
+		APIURL:        server.APIURL(),
+		AllowedModels: []config.ModelDefinition{{Name: "test-model", URL: server.APIURL()}},
+		ContextWindow: 4096,
+		Encoding:      "cl100k_base",
+		SourceDir:     t.TempDir(),
+		DestDir:       filepath.Join(t.TempDir(), "sorted"),
+		Categories:    []string{"Finance", "Finance/Taxes", "Receipts"},
+		Collisions:    "hash",
+		DocumentPath:  "{{category}}/{{year}}",
+	}
+}
+
+func TestRun_Valid(t *testing.T) {
+	results := Run(context.Background(), validConfig(t))
+	if n := Failed(results); n != 0 {
+		var out bytes.Buffer
+		WriteText(&out, results)
+		t.Fatalf("%d checks failed:\n%s", n, out.String())
+	}
+	var out bytes.Buffer
+	WriteText(&out, results)
+	for _, want := range []string{"(will be created)", "test-model is loaded", "3 configured", "Configuration is valid"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output lacks %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestRun_Problems(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "file.txt")
+	if err := os.WriteFile(file, []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(*config.Config)
+		check   string
+		wantErr string
+	}{
+		{"missing source", func(c *config.Config) { c.SourceDir = filepath.Join(c.SourceDir, "nope") }, "Source directory", "no such file"},
+		{"unset source", func(c *config.Config) { c.SourceDir = "" }, "Source directory", "not set"},
+		{"destination is a file", func(c *config.Config) { c.DestDir = file }, "Destination directory", "not a directory"},
+		{"unknown encoding", func(c *config.Config) { c.Encoding = "cl200k_nope" }, "Tokenizer", ""},
+		{"unreachable server", func(c *config.Config) {
+			c.AllowedModels = []config.ModelDefinition{{Name: "test-model", URL: "http://127.0.0.1:1/v1"}}
+		}, "Model server", "unreachable"},
+		{"category with backslash", func(c *config.Config) { c.Categories = []string{`Finance\Taxes`} }, "Categories", "would be filed as"},
+		{"categories differing in case", func(c *config.Config) { c.Categories = []string{"Work", "work"} }, "Categories", "differ only in case"},
+		{"empty path segment", func(c *config.Config) { c.Categories = []string{"Finance//Taxes"} }, "Categories", "empty or relative"},
+		{"missing taxonomy", func(c *config.Config) { c.TaxonomyFile = file + ".yaml" }, "Categories", "no such file"},
+		{"alias of an unknown category", func(c *config.Config) { c.CategoryAliases = map[string]string{"bills": "Bills"} }, "Categories", "not a category"},
+		{"unknown template variable", func(c *config.Config) { c.DocumentPath = "{{category}}/{{vendor}}" }, "Document templates", "vendor"},
+		{"invalid schedule", func(c *config.Config) { c.Schedule = "every day" }, "Schedule", ""},
+		{"unknown collision policy", func(c *config.Config) { c.Collisions = "rename" }, "Options", "rename"},
+		{"near-duplicate distance out of range", func(c *config.Config) { c.NearDuplicateDistance = 40 }, "Options", "near_duplicate_distance"},
+		{"invalid truncation", func(c *config.Config) { c.Truncation = "shorten" }, "Options", "shorten"},
+		{"temperature out of range", func(c *config.Config) { c.Temperature = 3 }, "Options", "temperature must be between 0 and 2"},
+		{"empty clarification zone", func(c *config.Config) { c.ClarifyMinConfidence, c.ClarifyMaxConfidence = 0.6, 0.5 }, "Options", "clarify_min_confidence"},
+		{"missing system prompt", func(c *config.Config) { c.SystemPromptFile = filepath.Join(t.TempDir(), "prompt.tmpl") }, "System prompt", "no such file"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig(t)
+			tt.modify(cfg)
+			results := Run(context.Background(), cfg)
+			if n := Failed(results); n != 1 {
+				var out bytes.Buffer
+				WriteText(&out, results)
+				t.Fatalf("%d checks failed, want 1:\n%s", n, out.String())
+			}
+			for _, r := range results {
+				if r.OK() {
+					continue
+				}
+				if r.Check != tt.check || !strings.Contains(r.Err.Error(), tt.wantErr) {
+					t.Errorf("failed check = %s: %v; want %s containing %q", r.Check, r.Err, tt.check, tt.wantErr)
+				}
+				if r.Hint == "" {
+					t.Errorf("%s failed without a hint", r.Check)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !unix
+
+package daemon
+
+import "os"
+
+// PauseSignals is empty where SIGUSR1 doesn't exist; pause from the dashboard or terminal instead.
+func PauseSignals() []os.Signal {
+	return nil
+}
@@ -0,0 +1,13 @@
+//go:build unix
+
+package daemon
+
+import (
+	"os"
+	"syscall"
+)
+
+// PauseSignals are the signals that toggle pausing the pipeline.
+func PauseSignals() []os.Signal {
+	return []os.Signal{syscall.SIGUSR1}
+}
@@ -0,0 +1,69 @@
+package daemon
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// PIDFile is an exclusively locked file holding the daemon's process ID.
+// The lock is released automatically if the process dies, so stale files never block a restart.
+type PIDFile struct {
+	path string
+	f    *os.File
+}
+
+// AcquirePIDFile locks path and writes the current PID to it, failing if another
+// instance already holds the lock.
+func AcquirePIDFile(path string) (*PIDFile, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create PID file directory: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open PID file: %w", err)
+	}
+
+	if err := lockFile(f); err != nil {
+		f.Close()
+		if pid, readErr := ReadPID(path); readErr == nil {
+			return nil, fmt.Errorf("another instance is already running (pid %d)", pid)
+		}
+		return nil, fmt.Errorf("another instance holds %s: %w", path, err)
+	}
+
+	if err := f.Truncate(0); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if _, err := f.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"), 0); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &PIDFile{path: path, f: f}, nil
+}
+
+// Release removes the PID file and drops the lock.
+func (p *PIDFile) Release() error {
+	removeErr := os.Remove(p.path)
+	closeErr := p.f.Close()
+	if removeErr != nil {
+		return removeErr
+	}
+	return closeErr
+}
+
+// ReadPID returns the process ID recorded in the PID file at path.
+func ReadPID(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return 0, fmt.Errorf("malformed PID file %s", path)
+	}
+	return pid, nil
+}
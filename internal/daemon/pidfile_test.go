@@ -0,0 +1,42 @@
+//go:build unix
+
+package daemon
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPIDFileLocking(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "run", "organiser.pid")
+
+	first, err := AcquirePIDFile(path)
+	if err != nil {
+		t.Fatalf("first acquire: %v", err)
+	}
+	if pid, err := ReadPID(path); err != nil || pid != os.Getpid() {
+		t.Errorf("ReadPID = %d, %v; want %d", pid, err, os.Getpid())
+	}
+
+	if _, err := AcquirePIDFile(path); err == nil {
+		t.Fatal("second acquire should fail while the lock is held")
+	}
+
+	if err := first.Release(); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("PID file still exists after release: %v", err)
+	}
+
+	// A stale file left behind by a crashed process does not block a restart
+	if err := os.WriteFile(path, []byte("999999\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	again, err := AcquirePIDFile(path)
+	if err != nil {
+		t.Fatalf("acquire over stale file: %v", err)
+	}
+	again.Release()
+}
@@ -0,0 +1,147 @@
+package daemon
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// ServiceName identifies the service in systemd and launchd.
+const (
+	ServiceName  = "docs-organiser"
+	LaunchdLabel = "com.docs-organiser.daemon"
+)
+
+// ServiceOptions describe how the service manager should launch the daemon.
+type ServiceOptions struct {
+	Executable string
+	Args       []string
+	WorkingDir string
+	// LogPath receives stdout/stderr under launchd; systemd uses the journal.
+	LogPath string
+}
+
+var systemdTemplate = template.Must(template.New("systemd").Funcs(template.FuncMap{"quote": systemdQuote, "specifiers": systemdEscapeSpecifiers}).Parse(`[Unit]
+Description=Docs Organiser daemon
+After=network-online.target
+
+[Service]
+Type=simple
+WorkingDirectory={{specifiers .WorkingDir}}
+ExecStart={{quote .Executable}}{{range .Args}} {{quote .}}{{end}}
+Restart=on-failure
+RestartSec=10
+# SIGTERM triggers a graceful drain; give in-flight files time to finish
+KillSignal=SIGTERM
+TimeoutStopSec=150
+
+[Install]
+WantedBy=default.target
+`))
+
+var launchdTemplate = template.Must(template.New("launchd").Funcs(template.FuncMap{"xml": xmlEscape}).Parse(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>Label</key>
+	<string>{{xml .Label}}</string>
+	<key>ProgramArguments</key>
+	<array>
+		<string>{{xml .Executable}}</string>
+{{- range .Args}}
+		<string>{{xml .}}</string>
+{{- end}}
+	</array>
+	<key>WorkingDirectory</key>
+	<string>{{xml .WorkingDir}}</string>
+	<key>RunAtLoad</key>
+	<true/>
+	<key>KeepAlive</key>
+	<dict>
+		<key>SuccessfulExit</key>
+		<false/>
+	</dict>
+	<key>ExitTimeOut</key>
+	<integer>150</integer>
+	<key>StandardOutPath</key>
+	<string>{{xml .LogPath}}</string>
+	<key>StandardErrorPath</key>
+	<string>{{xml .LogPath}}</string>
+</dict>
+</plist>
+`))
+
+// SystemdUnit renders a systemd user unit that starts the daemon at login.
+func SystemdUnit(opts ServiceOptions) (string, error) {
+	var b bytes.Buffer
+	err := systemdTemplate.Execute(&b, opts)
+	return b.String(), err
+}
+
+// LaunchdPlist renders a launchd agent that starts the daemon at login.
+func LaunchdPlist(opts ServiceOptions) (string, error) {
+	var b bytes.Buffer
+	err := launchdTemplate.Execute(&b, struct {
+		ServiceOptions
+		Label string
+	}{opts, LaunchdLabel})
+	return b.String(), err
+}
+
+// InstallService writes the service definition for goos into the user's home directory
+// and returns its path along with the commands that enable it.
+func InstallService(goos string, opts ServiceOptions) (path string, next []string, err error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", nil, err
+	}
+
+	var content string
+	switch goos {
+	case "linux":
+		path = filepath.Join(home, ".config", "systemd", "user", ServiceName+".service")
+		content, err = SystemdUnit(opts)
+		next = []string{
+			"systemctl --user daemon-reload",
+			"systemctl --user enable --now " + ServiceName,
+		}
+	case "darwin":
+		path = filepath.Join(home, "Library", "LaunchAgents", LaunchdLabel+".plist")
+		content, err = LaunchdPlist(opts)
+		next = []string{"launchctl load -w " + path}
+	default:
+		return "", nil, fmt.Errorf("service installation is not supported on %s", goos)
+	}
+	if err != nil {
+		return "", nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", nil, err
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return "", nil, err
+	}
+	return path, next, nil
+}
+
+// systemdQuote quotes a word for ExecStart and other systemd directives when needed.
+func systemdQuote(s string) string {
+	if s != "" && !strings.ContainsAny(s, " \t\"'\\$%") {
+		return s
+	}
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", "$$", "%", "%%")
+	return `"` + r.Replace(s) + `"`
+}
+
+// systemdEscapeSpecifiers escapes "%" in directives that take a literal path.
+func systemdEscapeSpecifiers(s string) string {
+	return strings.ReplaceAll(s, "%", "%%")
+}
+
+func xmlEscape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;", "'", "&apos;").Replace(s)
+}
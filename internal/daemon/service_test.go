@@ -0,0 +1,61 @@
+package daemon
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestServiceDefinitions(t *testing.T) {
+	opts := ServiceOptions{
+		Executable: "/opt/docs organiser/docs_organiser",
+		Args:       []string{"daemon", "--config", "/home/me/50%/config.yaml"},
+		WorkingDir: "/home/me/organiser",
+		LogPath:    "/home/me/organiser/data/docs_organiser.log",
+	}
+
+	tests := []struct {
+		name   string
+		render func(ServiceOptions) (string, error)
+		want   []string
+	}{
+		{
+			name:   "systemd",
+			render: SystemdUnit,
+			want: []string{
+				`ExecStart="/opt/docs organiser/docs_organiser" daemon --config "/home/me/50%%/config.yaml"`,
+				"WorkingDirectory=/home/me/organiser",
+				"WantedBy=default.target",
+			},
+		},
+		{
+			name:   "launchd",
+			render: LaunchdPlist,
+			want: []string{
+				"<string>" + LaunchdLabel + "</string>",
+				"<string>/opt/docs organiser/docs_organiser</string>",
+				"<string>daemon</string>",
+				"<key>RunAtLoad</key>\n\t<true/>",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.render(opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("missing %q in:\n%s", want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestInstallServiceUnsupportedOS(t *testing.T) {
+	if _, _, err := InstallService("plan9", ServiceOptions{}); err == nil {
+		t.Error("expected an error for an unsupported OS")
+	}
+}
@@ -0,0 +1,102 @@
+package eval
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadLabels(t *testing.T) {
+	dir := t.TempDir()
+	want := []Label{
+		{File: filepath.Join(dir, "a.pdf"), Category: "Finance"},
+		{File: "/abs/b.txt", Category: "Finance/Taxes"},
+	}
+
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		want    []Label
+		wantErr bool
+	}{
+		{"csv with header", "labels.csv", "file,category\na.pdf,Finance\n/abs/b.txt,/Finance/Taxes/\n", want, false},
+		{"csv without header", "labels.csv", "a.pdf, Finance\n/abs/b.txt,Finance/Taxes\n", want, false},
+		{"json array", "labels.json", `[{"file":"a.pdf","category":"Finance"},{"file":"/abs/b.txt","category":"Finance/Taxes"}]`, want, false},
+		{"json object", "labels.json", `{"/abs/b.txt":"Finance/Taxes","a.pdf":"Finance"}`, []Label{want[1], want[0]}, false},
+		{"missing category", "labels.csv", "a.pdf,\n", nil, true},
+		{"extra column", "labels.csv", "a.pdf,Finance,x\n", nil, true},
+		{"empty", "labels.json", `[]`, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.file)
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			got, err := LoadLabels(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("labels = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	report := Evaluate([]Result{
+		{File: "1", Expected: "Finance", Predicted: "Finance"},
+		{File: "2", Expected: "Finance", Predicted: "finance"},
+		{File: "3", Expected: "Finance", Predicted: "Work"},
+		{File: "4", Expected: "Work", Predicted: "Work"},
+		{File: "5", Expected: "Work", Error: "extraction failed"},
+	})
+
+	if report.Total != 5 || report.Correct != 3 || report.Failed != 1 {
+		t.Fatalf("total/correct/failed = %d/%d/%d, want 5/3/1", report.Total, report.Correct, report.Failed)
+	}
+	if report.Accuracy != 0.6 {
+		t.Errorf("accuracy = %v, want 0.6", report.Accuracy)
+	}
+	wantConfusion := map[string]map[string]int{
+		"Finance": {"Finance": 2, "Work": 1},
+		"Work":    {"Work": 1, noPrediction: 1},
+	}
+	if !reflect.DeepEqual(report.Confusion, wantConfusion) {
+		t.Errorf("confusion = %v, want %v", report.Confusion, wantConfusion)
+	}
+
+	wantStats := []CategoryStats{
+		{Category: "Finance", Support: 3, Predicted: 2, Correct: 2, Precision: 1, Recall: 2.0 / 3, F1: 0.8},
+		{Category: "Work", Support: 2, Predicted: 2, Correct: 1, Precision: 0.5, Recall: 0.5, F1: 0.5},
+	}
+	if len(report.Categories) != len(wantStats) {
+		t.Fatalf("categories = %+v, want %+v", report.Categories, wantStats)
+	}
+	for i, want := range wantStats {
+		got := report.Categories[i]
+		if got.Category != want.Category || got.Support != want.Support || got.Predicted != want.Predicted || got.Correct != want.Correct ||
+			!near(got.Precision, want.Precision) || !near(got.Recall, want.Recall) || !near(got.F1, want.F1) {
+			t.Errorf("categories[%d] = %+v, want %+v", i, got, want)
+		}
+	}
+
+	var out strings.Builder
+	if err := report.WriteText(&out); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"Accuracy: 60.0% (3/5 files correct, 1 could not be classified)", "3: expected Finance, got Work", "5: expected Work, got error: extraction failed"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("report missing %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func near(a, b float64) bool {
+	d := a - b
+	return d < 1e-9 && d > -1e-9
+}
@@ -0,0 +1,112 @@
+// Package eval measures classification against a labeled set of files: accuracy, a
+// confusion matrix, and per-category precision and recall.
+package eval
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Label is a file and the category it should be filed under.
+type Label struct {
+	File     string `json:"file"`
+	Category string `json:"category"`
+}
+
+// LoadLabels reads labels from a CSV file (file,category rows; a header row naming those
+// columns is skipped) or, for .json files, an array of {"file", "category"} objects or an
+// object mapping files to categories. Relative file paths are resolved against the
+// directory of the labels file.
+func LoadLabels(path string) ([]Label, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var labels []Label
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		labels, err = parseJSON(f)
+	} else {
+		labels, err = parseCSV(f)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid labels file %s: %w", path, err)
+	}
+	if len(labels) == 0 {
+		return nil, fmt.Errorf("labels file %s has no entries", path)
+	}
+
+	dir := filepath.Dir(path)
+	for i, l := range labels {
+		if l.File == "" || strings.Trim(l.Category, "/ ") == "" {
+			return nil, fmt.Errorf("labels file %s: entry %d needs both a file and a category", path, i+1)
+		}
+		if !filepath.IsAbs(l.File) {
+			labels[i].File = filepath.Join(dir, l.File)
+		}
+		labels[i].Category = strings.Trim(strings.TrimSpace(l.Category), "/")
+	}
+	return labels, nil
+}
+
+func parseCSV(r io.Reader) ([]Label, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 2
+	reader.TrimLeadingSpace = true
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) > 0 && strings.EqualFold(rows[0][0], "file") && strings.EqualFold(rows[0][1], "category") {
+		rows = rows[1:]
+	}
+	labels := make([]Label, len(rows))
+	for i, row := range rows {
+		labels[i] = Label{File: row[0], Category: row[1]}
+	}
+	return labels, nil
+}
+
+func parseJSON(r io.Reader) ([]Label, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var labels []Label
+	if err := json.Unmarshal(data, &labels); err == nil {
+		return labels, nil
+	}
+	var byFile map[string]string
+	if err := json.Unmarshal(data, &byFile); err != nil {
+		return nil, fmt.Errorf("want an array of {\"file\", \"category\"} objects or an object of file: category")
+	}
+	files := make([]string, 0, len(byFile))
+	for file := range byFile {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	for _, file := range files {
+		labels = append(labels, Label{File: file, Category: byFile[file]})
+	}
+	return labels, nil
+}
+
+// Categories returns the distinct expected categories, in order of first appearance.
+func Categories(labels []Label) []string {
+	seen := make(map[string]bool)
+	var categories []string
+	for _, l := range labels {
+		if key := strings.ToLower(l.Category); !seen[key] {
+			seen[key] = true
+			categories = append(categories, l.Category)
+		}
+	}
+	return categories
+}
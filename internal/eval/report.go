@@ -0,0 +1,184 @@
+package eval
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// noPrediction stands in for files that could not be classified at all.
+const noPrediction = "(none)"
+
+// Result is the outcome for one labeled file. Predicted is empty, and Error set, when
+// the file could not be classified.
+type Result struct {
+	File      string `json:"file"`
+	Expected  string `json:"expected"`
+	Predicted string `json:"predicted"`
+	Error     string `json:"error,omitempty"`
+}
+
+// Correct reports whether the prediction matches the label (case-insensitively).
+func (r Result) Correct() bool {
+	return r.Predicted != "" && strings.EqualFold(r.Expected, r.Predicted)
+}
+
+// CategoryStats scores one category. Precision is the share of files predicted as the
+// category that belong there; recall the share of its files that were found.
+type CategoryStats struct {
+	Category  string  `json:"category"`
+	Support   int     `json:"support"` // labeled files
+	Predicted int     `json:"predicted"`
+	Correct   int     `json:"correct"`
+	Precision float64 `json:"precision"`
+	Recall    float64 `json:"recall"`
+	F1        float64 `json:"f1"`
+}
+
+// Report summarizes an evaluation run.
+type Report struct {
+	Total      int             `json:"total"`
+	Correct    int             `json:"correct"`
+	Failed     int             `json:"failed"`
+	Accuracy   float64         `json:"accuracy"`
+	Categories []CategoryStats `json:"categories"`
+	// Confusion counts files by expected, then predicted category.
+	Confusion map[string]map[string]int `json:"confusion"`
+	Results   []Result                  `json:"results"`
+}
+
+// Evaluate scores results. Categories that differ only in case are counted together,
+// under the spelling of the first label (or prediction) that used them.
+func Evaluate(results []Result) Report {
+	names := make(map[string]string)
+	name := func(category string) string {
+		if category == "" {
+			return noPrediction
+		}
+		key := strings.ToLower(category)
+		if _, ok := names[key]; !ok {
+			names[key] = category
+		}
+		return names[key]
+	}
+	for _, r := range results {
+		name(r.Expected)
+	}
+
+	report := Report{Total: len(results), Confusion: make(map[string]map[string]int), Results: results}
+	stats := make(map[string]*CategoryStats)
+	statsFor := func(category string) *CategoryStats {
+		if stats[category] == nil {
+			stats[category] = &CategoryStats{Category: category}
+		}
+		return stats[category]
+	}
+	for _, r := range results {
+		expected, predicted := name(r.Expected), name(r.Predicted)
+		if report.Confusion[expected] == nil {
+			report.Confusion[expected] = make(map[string]int)
+		}
+		report.Confusion[expected][predicted]++
+		statsFor(expected).Support++
+		if r.Predicted == "" {
+			report.Failed++
+			continue
+		}
+		statsFor(predicted).Predicted++
+		if r.Correct() {
+			report.Correct++
+			statsFor(expected).Correct++
+		}
+	}
+
+	if report.Total > 0 {
+		report.Accuracy = float64(report.Correct) / float64(report.Total)
+	}
+	for _, s := range stats {
+		if s.Predicted > 0 {
+			s.Precision = float64(s.Correct) / float64(s.Predicted)
+		}
+		if s.Support > 0 {
+			s.Recall = float64(s.Correct) / float64(s.Support)
+		}
+		if s.Precision+s.Recall > 0 {
+			s.F1 = 2 * s.Precision * s.Recall / (s.Precision + s.Recall)
+		}
+		report.Categories = append(report.Categories, *s)
+	}
+	sort.Slice(report.Categories, func(i, j int) bool { return report.Categories[i].Category < report.Categories[j].Category })
+	return report
+}
+
+// WriteText prints the report as tables: overall accuracy, per-category scores, the
+// confusion matrix, and the misclassified files.
+func (r Report) WriteText(w io.Writer) error {
+	fmt.Fprintf(w, "Accuracy: %.1f%% (%d/%d files correct", r.Accuracy*100, r.Correct, r.Total)
+	if r.Failed > 0 {
+		fmt.Fprintf(w, ", %d could not be classified", r.Failed)
+	}
+	fmt.Fprint(w, ")\n\n")
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "Category\tFiles\tPredicted\tPrecision\tRecall\tF1")
+	for _, s := range r.Categories {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%.2f\t%.2f\t%.2f\n", s.Category, s.Support, s.Predicted, s.Precision, s.Recall, s.F1)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	// Columns are every predicted category, plus the expected ones so the diagonal is complete
+	var rows []string
+	columnSet := make(map[string]bool)
+	for expected, predictions := range r.Confusion {
+		rows = append(rows, expected)
+		columnSet[expected] = true
+		for predicted := range predictions {
+			columnSet[predicted] = true
+		}
+	}
+	sort.Strings(rows)
+	columns := make([]string, 0, len(columnSet))
+	for c := range columnSet {
+		columns = append(columns, c)
+	}
+	sort.Strings(columns)
+
+	fmt.Fprint(w, "\nConfusion matrix (rows: expected, columns: predicted)\n")
+	tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(tw, "\t%s\t\n", strings.Join(columns, "\t"))
+	for _, expected := range rows {
+		fmt.Fprint(tw, expected)
+		for _, predicted := range columns {
+			fmt.Fprintf(tw, "\t%d", r.Confusion[expected][predicted])
+		}
+		fmt.Fprint(tw, "\t\n")
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	var wrong []Result
+	for _, res := range r.Results {
+		if !res.Correct() {
+			wrong = append(wrong, res)
+		}
+	}
+	if len(wrong) == 0 {
+		return nil
+	}
+	fmt.Fprint(w, "\nMisclassified\n")
+	for _, res := range wrong {
+		got := res.Predicted
+		if got == "" {
+			got = "error: " + res.Error
+		}
+		if _, err := fmt.Fprintf(w, "  %s: expected %s, got %s\n", res.File, res.Expected, got); err != nil {
+			return err
+		}
+	}
+	return nil
+}
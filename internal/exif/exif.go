@@ -0,0 +1,296 @@
+// Package exif reads the capture date and camera from an image's EXIF metadata.
+// JPEG, PNG, and TIFF-based files (including DNG, NEF, CR2, and ARW raws) are supported.
+package exif
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ErrNoEXIF is returned for images without EXIF data.
+var ErrNoEXIF = errors.New("no EXIF data")
+
+// Extensions are the image types Read understands.
+var Extensions = []string{".jpg", ".jpeg", ".png", ".tif", ".tiff", ".dng", ".nef", ".cr2", ".arw"}
+
+// IsImage reports whether path has one of the supported extensions.
+func IsImage(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	for _, e := range Extensions {
+		if ext == e {
+			return true
+		}
+	}
+	return false
+}
+
+// Info is the subset of EXIF metadata used for routing.
+type Info struct {
+	// Time is when the photo was taken, as the camera's wall clock. It carries the
+	// recorded UTC offset when there is one and is UTC otherwise; zero when unknown.
+	Time  time.Time `json:"time,omitempty"`
+	Make  string    `json:"make,omitempty"`
+	Model string    `json:"model,omitempty"`
+}
+
+// Camera returns the camera name, prefixing the model with the make unless it already
+// includes it ("Canon EOS R5", "Apple iPhone 15", "NIKON Z 6").
+func (i Info) Camera() string {
+	if i.Make == "" || strings.HasPrefix(strings.ToLower(i.Model), strings.ToLower(firstWord(i.Make))) {
+		return i.Model
+	}
+	if i.Model == "" {
+		return i.Make
+	}
+	return firstWord(i.Make) + " " + i.Model
+}
+
+// firstWord trims corporate suffixes such as "NIKON CORPORATION" or "OLYMPUS IMAGING CORP.".
+func firstWord(s string) string {
+	if i := strings.IndexByte(s, ' '); i > 0 {
+		return s[:i]
+	}
+	return s
+}
+
+// Read returns the EXIF metadata of the image at path.
+func Read(path string) (Info, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Info{}, err
+	}
+	defer f.Close()
+
+	var header [8]byte
+	if _, err := io.ReadFull(f, header[:]); err != nil {
+		return Info{}, ErrNoEXIF
+	}
+	switch {
+	case header[0] == 0xFF && header[1] == 0xD8:
+		tiff, err := jpegEXIF(f)
+		if err != nil {
+			return Info{}, err
+		}
+		return parseTIFF(bytes.NewReader(tiff))
+	case bytes.Equal(header[:], []byte("\x89PNG\r\n\x1a\n")):
+		tiff, err := pngEXIF(f)
+		if err != nil {
+			return Info{}, err
+		}
+		return parseTIFF(bytes.NewReader(tiff))
+	case string(header[:4]) == "II*\x00" || string(header[:4]) == "MM\x00*":
+		return parseTIFF(f)
+	}
+	return Info{}, ErrNoEXIF
+}
+
+// maxSegment bounds the EXIF block read from JPEG and PNG files.
+const maxSegment = 1 << 20
+
+// jpegEXIF returns the TIFF data of the APP1 "Exif" segment, which precedes the image data.
+func jpegEXIF(f *os.File) ([]byte, error) {
+	if _, err := f.Seek(2, io.SeekStart); err != nil {
+		return nil, err
+	}
+	r := bufio.NewReader(f)
+	for {
+		var marker [4]byte
+		if _, err := io.ReadFull(r, marker[:]); err != nil || marker[0] != 0xFF {
+			return nil, ErrNoEXIF
+		}
+		// Start of scan: no metadata segments follow
+		if marker[1] == 0xDA {
+			return nil, ErrNoEXIF
+		}
+		length := int(binary.BigEndian.Uint16(marker[2:])) - 2
+		if length < 0 {
+			return nil, ErrNoEXIF
+		}
+		if marker[1] != 0xE1 {
+			if _, err := r.Discard(length); err != nil {
+				return nil, ErrNoEXIF
+			}
+			continue
+		}
+		seg := make([]byte, length)
+		if _, err := io.ReadFull(r, seg); err != nil {
+			return nil, ErrNoEXIF
+		}
+		// APP1 also carries XMP; only the Exif one is TIFF
+		if tiff, ok := bytes.CutPrefix(seg, []byte("Exif\x00\x00")); ok {
+			return tiff, nil
+		}
+	}
+}
+
+// pngEXIF returns the contents of the eXIf chunk.
+func pngEXIF(f *os.File) ([]byte, error) {
+	r := bufio.NewReader(f)
+	for {
+		var head [8]byte
+		if _, err := io.ReadFull(r, head[:]); err != nil {
+			return nil, ErrNoEXIF
+		}
+		length := int64(binary.BigEndian.Uint32(head[:4]))
+		switch string(head[4:]) {
+		case "eXIf":
+			if length > maxSegment {
+				return nil, fmt.Errorf("EXIF chunk too large")
+			}
+			data := make([]byte, length)
+			if _, err := io.ReadFull(r, data); err != nil {
+				return nil, ErrNoEXIF
+			}
+			return data, nil
+		case "IDAT", "IEND":
+			// Encoders may put eXIf after the image data, but rarely do; don't read the pixels
+			return nil, ErrNoEXIF
+		}
+		if _, err := r.Discard(int(length) + 4); err != nil {
+			return nil, ErrNoEXIF
+		}
+	}
+}
+
+// TIFF tags used for routing.
+const (
+	tagMake               = 0x010F
+	tagModel              = 0x0110
+	tagDateTime           = 0x0132
+	tagExifIFD            = 0x8769
+	tagDateTimeOriginal   = 0x9003
+	tagOffsetTimeOriginal = 0x9011
+)
+
+// parseTIFF reads IFD0 and the Exif sub-IFD of a TIFF structure.
+func parseTIFF(r io.ReaderAt) (Info, error) {
+	var header [8]byte
+	if _, err := r.ReadAt(header[:], 0); err != nil {
+		return Info{}, ErrNoEXIF
+	}
+	var order binary.ByteOrder
+	switch string(header[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return Info{}, ErrNoEXIF
+	}
+	if order.Uint16(header[2:]) != 42 {
+		return Info{}, ErrNoEXIF
+	}
+
+	t := tiff{r: r, order: order}
+	ifd0, err := t.ifd(order.Uint32(header[4:]))
+	if err != nil {
+		return Info{}, err
+	}
+	info := Info{Make: t.ascii(ifd0[tagMake]), Model: t.ascii(ifd0[tagModel])}
+
+	date, offset := t.ascii(ifd0[tagDateTime]), ""
+	if e, ok := ifd0[tagExifIFD]; ok {
+		if sub, err := t.ifd(t.long(e)); err == nil {
+			if original := t.ascii(sub[tagDateTimeOriginal]); original != "" {
+				date, offset = original, t.ascii(sub[tagOffsetTimeOriginal])
+			}
+		}
+	}
+	info.Time = parseDate(date, offset)
+
+	if info.Time.IsZero() && info.Make == "" && info.Model == "" {
+		return Info{}, ErrNoEXIF
+	}
+	return info, nil
+}
+
+// parseDate parses an EXIF "2006:01:02 15:04:05" timestamp with an optional "+02:00" offset.
+func parseDate(date, offset string) time.Time {
+	date = strings.TrimSpace(date)
+	if len(date) < 19 {
+		return time.Time{}
+	}
+	loc := time.UTC
+	if o, err := time.Parse("-07:00", strings.TrimSpace(offset)); err == nil {
+		_, secs := o.Zone()
+		loc = time.FixedZone("", secs)
+	}
+	t, err := time.ParseInLocation("2006:01:02 15:04:05", date[:19], loc)
+	if err != nil || t.Year() < 1900 {
+		// Cameras without a set clock write "0000:00:00 00:00:00"
+		return time.Time{}
+	}
+	return t
+}
+
+// entry is one raw IFD entry.
+type entry struct {
+	typ   uint16
+	count uint32
+	value [4]byte // the value itself when it fits, otherwise its offset
+}
+
+type tiff struct {
+	r     io.ReaderAt
+	order binary.ByteOrder
+}
+
+// maxEntries bounds the IFD size so corrupt offsets can't trigger huge reads.
+const maxEntries = 1000
+
+func (t tiff) ifd(offset uint32) (map[uint16]entry, error) {
+	var n [2]byte
+	if _, err := t.r.ReadAt(n[:], int64(offset)); err != nil {
+		return nil, ErrNoEXIF
+	}
+	count := int(t.order.Uint16(n[:]))
+	if count > maxEntries {
+		return nil, fmt.Errorf("corrupt EXIF directory")
+	}
+	buf := make([]byte, 12*count)
+	if _, err := t.r.ReadAt(buf, int64(offset)+2); err != nil {
+		return nil, ErrNoEXIF
+	}
+	entries := make(map[uint16]entry, count)
+	for i := 0; i < count; i++ {
+		b := buf[12*i:]
+		e := entry{typ: t.order.Uint16(b[2:]), count: t.order.Uint32(b[4:])}
+		copy(e.value[:], b[8:12])
+		entries[t.order.Uint16(b)] = e
+	}
+	return entries, nil
+}
+
+// long returns a LONG (or SHORT) entry's value.
+func (t tiff) long(e entry) uint32 {
+	if e.typ == 3 {
+		return uint32(t.order.Uint16(e.value[:]))
+	}
+	return t.order.Uint32(e.value[:])
+}
+
+// ascii returns an ASCII entry's value without trailing NULs and spaces.
+func (t tiff) ascii(e entry) string {
+	if e.typ != 2 || e.count == 0 || e.count > 256 {
+		return ""
+	}
+	data := e.value[:min(e.count, 4)]
+	if e.count > 4 {
+		data = make([]byte, e.count)
+		if _, err := t.r.ReadAt(data, int64(t.order.Uint32(e.value[:]))); err != nil {
+			return ""
+		}
+	}
+	if i := bytes.IndexByte(data, 0); i >= 0 {
+		data = data[:i]
+	}
+	return strings.TrimSpace(string(data))
+}
@@ -0,0 +1,170 @@
+package exif
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// buildTIFF encodes a TIFF header, IFD0 with Make and Model, and an Exif sub-IFD
+// with DateTimeOriginal (and OffsetTimeOriginal when offset is set).
+func buildTIFF(order binary.ByteOrder, maker, model, taken, offset string) []byte {
+	type field struct {
+		tag   uint16
+		value string
+	}
+	var data bytes.Buffer
+	if order == binary.LittleEndian {
+		data.WriteString("II")
+	} else {
+		data.WriteString("MM")
+	}
+	binary.Write(&data, order, uint16(42))
+	binary.Write(&data, order, uint32(8))
+
+	// Both directories are written first, then their string values
+	ifd0 := []field{{tagMake, maker}, {tagModel, model}}
+	sub := []field{{tagDateTimeOriginal, taken}}
+	if offset != "" {
+		sub = append(sub, field{tagOffsetTimeOriginal, offset})
+	}
+	ifdSize := func(n int) int { return 2 + 12*n + 4 }
+	subOffset := 8 + ifdSize(len(ifd0)+1)
+	valueOffset := subOffset + ifdSize(len(sub))
+
+	var values bytes.Buffer
+	writeIFD := func(fields []field, extra func()) {
+		n := len(fields)
+		if extra != nil {
+			n++
+		}
+		binary.Write(&data, order, uint16(n))
+		for _, f := range fields {
+			v := append([]byte(f.value), 0)
+			binary.Write(&data, order, f.tag)
+			binary.Write(&data, order, uint16(2))
+			binary.Write(&data, order, uint32(len(v)))
+			if len(v) <= 4 {
+				data.Write(append(v, make([]byte, 4-len(v))...))
+			} else {
+				binary.Write(&data, order, uint32(valueOffset+values.Len()))
+				values.Write(v)
+			}
+		}
+		if extra != nil {
+			extra()
+		}
+		binary.Write(&data, order, uint32(0))
+	}
+	writeIFD(ifd0, func() {
+		binary.Write(&data, order, uint16(tagExifIFD))
+		binary.Write(&data, order, uint16(4))
+		binary.Write(&data, order, uint32(1))
+		binary.Write(&data, order, uint32(subOffset))
+	})
+	writeIFD(sub, nil)
+	data.Write(values.Bytes())
+	return data.Bytes()
+}
+
+func jpegWith(tiff []byte) []byte {
+	var b bytes.Buffer
+	b.Write([]byte{0xFF, 0xD8})
+	// A JFIF segment and an XMP APP1 come first, as in camera and phone output
+	b.Write([]byte{0xFF, 0xE0, 0, 7, 'J', 'F', 'I', 'F', 0})
+	xmp := []byte("http://ns.adobe.com/xap/1.0/\x00<x:xmpmeta/>")
+	b.Write([]byte{0xFF, 0xE1})
+	binary.Write(&b, binary.BigEndian, uint16(len(xmp)+2))
+	b.Write(xmp)
+	if tiff != nil {
+		seg := append([]byte("Exif\x00\x00"), tiff...)
+		b.Write([]byte{0xFF, 0xE1})
+		binary.Write(&b, binary.BigEndian, uint16(len(seg)+2))
+		b.Write(seg)
+	}
+	b.Write([]byte{0xFF, 0xDA, 0, 2, 0xFF, 0xD9})
+	return b.Bytes()
+}
+
+func pngWith(tiff []byte) []byte {
+	var b bytes.Buffer
+	b.WriteString("\x89PNG\r\n\x1a\n")
+	chunk := func(typ string, data []byte) {
+		binary.Write(&b, binary.BigEndian, uint32(len(data)))
+		b.WriteString(typ)
+		b.Write(data)
+		b.Write([]byte{0, 0, 0, 0}) // CRC is not checked
+	}
+	chunk("IHDR", make([]byte, 13))
+	chunk("eXIf", tiff)
+	chunk("IEND", nil)
+	return b.Bytes()
+}
+
+func TestRead(t *testing.T) {
+	canon := buildTIFF(binary.LittleEndian, "Canon", "Canon EOS R5", "2024:03:05 14:30:00", "+02:00")
+	nikon := buildTIFF(binary.BigEndian, "NIKON CORPORATION", "NIKON Z 6", "2019:12:31 23:59:59", "")
+
+	tests := []struct {
+		name    string
+		file    string
+		data    []byte
+		want    Info
+		wantErr bool
+	}{
+		{"jpeg", "a.jpg", jpegWith(canon), Info{Time: time.Date(2024, 3, 5, 14, 30, 0, 0, time.FixedZone("", 2*3600)), Make: "Canon", Model: "Canon EOS R5"}, false},
+		{"png", "a.png", pngWith(nikon), Info{Time: time.Date(2019, 12, 31, 23, 59, 59, 0, time.UTC), Make: "NIKON CORPORATION", Model: "NIKON Z 6"}, false},
+		{"raw", "a.nef", nikon, Info{Time: time.Date(2019, 12, 31, 23, 59, 59, 0, time.UTC), Make: "NIKON CORPORATION", Model: "NIKON Z 6"}, false},
+		{"unset clock", "a.jpg", jpegWith(buildTIFF(binary.LittleEndian, "Sony", "ILCE-7M3", "0000:00:00 00:00:00", "")), Info{Make: "Sony", Model: "ILCE-7M3"}, false},
+		{"jpeg without exif", "a.jpg", jpegWith(nil), Info{}, true},
+		{"not an image", "a.jpg", []byte("hello world"), Info{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(path, tt.data, 0644); err != nil {
+				t.Fatal(err)
+			}
+			got, err := Read(path)
+			if tt.wantErr {
+				if !errors.Is(err, ErrNoEXIF) {
+					t.Errorf("Read = %+v, %v; want ErrNoEXIF", got, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Read: %v", err)
+			}
+			if !got.Time.Equal(tt.want.Time) || got.Time.Format(time.RFC3339) != tt.want.Time.Format(time.RFC3339) {
+				t.Errorf("Time = %v, want %v", got.Time, tt.want.Time)
+			}
+			if got.Make != tt.want.Make || got.Model != tt.want.Model {
+				t.Errorf("camera = %q/%q, want %q/%q", got.Make, got.Model, tt.want.Make, tt.want.Model)
+			}
+		})
+	}
+}
+
+func TestCamera(t *testing.T) {
+	tests := []struct {
+		info Info
+		want string
+	}{
+		{Info{Make: "Canon", Model: "Canon EOS R5"}, "Canon EOS R5"},
+		{Info{Make: "NIKON CORPORATION", Model: "NIKON Z 6"}, "NIKON Z 6"},
+		{Info{Make: "Apple", Model: "iPhone 15 Pro"}, "Apple iPhone 15 Pro"},
+		{Info{Make: "FUJIFILM"}, "FUJIFILM"},
+		{Info{Model: "X100V"}, "X100V"},
+		{Info{}, ""},
+	}
+	for _, tt := range tests {
+		if got := tt.info.Camera(); got != tt.want {
+			t.Errorf("%+v.Camera() = %q, want %q", tt.info, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package extractor
+
+import (
+	"bytes"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// decodeText converts the start of a text file to UTF-8. Byte order marks are honoured,
+// BOM-less UTF-16 is recognised by its NUL bytes, and valid UTF-8 is kept as is. Anything
+// else is Shift-JIS when it is structured like Japanese text, and windows-1252 (a superset
+// of the printable latin-1 range) otherwise. It returns the text and the charset used.
+func decodeText(b []byte) (string, string) {
+	if bytes.HasPrefix(b, []byte{0xEF, 0xBB, 0xBF}) {
+		return string(b[3:]), "utf-8"
+	}
+
+	var enc encoding.Encoding
+	var name string
+	order, isUTF16 := utf16Order(b)
+	switch {
+	case isUTF16:
+		// A UTF-16 code unit may have been cut by the read limit
+		b = b[:len(b)&^1]
+		enc, name = unicode.UTF16(order, unicode.UseBOM), "utf-16be"
+		if order == unicode.LittleEndian {
+			name = "utf-16le"
+		}
+	case utf8.Valid(trimPartialRune(b)):
+		return string(trimPartialRune(b)), "utf-8"
+	case looksShiftJIS(b):
+		enc, name = japanese.ShiftJIS, "shift_jis"
+	default:
+		enc, name = charmap.Windows1252, "windows-1252"
+	}
+
+	text, err := enc.NewDecoder().Bytes(b)
+	if err != nil {
+		return string(b), "utf-8"
+	}
+	return string(text), name
+}
+
+// utf16Order reports the byte order of UTF-16 text, from its byte order mark or, without
+// one, from mostly-ASCII text where every other byte is NUL.
+func utf16Order(b []byte) (unicode.Endianness, bool) {
+	switch {
+	case bytes.HasPrefix(b, []byte{0xFF, 0xFE}):
+		return unicode.LittleEndian, true
+	case bytes.HasPrefix(b, []byte{0xFE, 0xFF}):
+		return unicode.BigEndian, true
+	case len(b) < 4:
+		return unicode.BigEndian, false
+	}
+	var even, odd int
+	for i, c := range b {
+		if c == 0 {
+			if i%2 == 0 {
+				even++
+			} else {
+				odd++
+			}
+		}
+	}
+	half := len(b) / 2
+	switch {
+	case odd*10 >= half*7 && even*10 < half:
+		return unicode.LittleEndian, true
+	case even*10 >= half*7 && odd*10 < half:
+		return unicode.BigEndian, true
+	}
+	return unicode.BigEndian, false
+}
+
+// trimPartialRune drops an incomplete UTF-8 sequence at the end of b, where the read
+// limit may have cut a character in two.
+func trimPartialRune(b []byte) []byte {
+	for i := 1; i < utf8.UTFMax && i <= len(b); i++ {
+		if c := b[len(b)-i]; c < 0x80 {
+			break
+		} else if utf8.RuneStart(c) {
+			if !utf8.FullRune(b[len(b)-i:]) {
+				return b[:len(b)-i]
+			}
+			break
+		}
+	}
+	return b
+}
+
+// looksShiftJIS reports whether every non-ASCII byte of b forms a valid Shift-JIS
+// character and most double-byte characters are kana or have a high trail byte, which
+// windows-1252 text (accented letters followed by ASCII) rarely does.
+func looksShiftJIS(b []byte) bool {
+	var pairs, strong int
+	for i := 0; i < len(b); i++ {
+		c := b[i]
+		switch {
+		case c < 0x80, c >= 0xA1 && c <= 0xDF: // ASCII, half-width katakana
+		case c >= 0x81 && c <= 0x9F, c >= 0xE0 && c <= 0xEF:
+			if i+1 == len(b) {
+				return pairs > 0 && strong*2 >= pairs // cut by the read limit
+			}
+			trail := b[i+1]
+			if trail < 0x40 || trail == 0x7F || trail > 0xFC {
+				return false
+			}
+			pairs++
+			if trail >= 0x80 || c == 0x82 || c == 0x83 {
+				strong++
+			}
+			i++
+		default:
+			return false
+		}
+	}
+	return pairs > 0 && strong*2 >= pairs
+}
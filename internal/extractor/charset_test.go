@@ -0,0 +1,59 @@
+package extractor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/unicode"
+)
+
+func encode(t *testing.T, enc encoding.Encoding, s string) []byte {
+	t.Helper()
+	b, err := enc.NewEncoder().Bytes([]byte(s))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestDecodeText(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       []byte
+		want        string
+		wantCharset string
+	}{
+		{"ascii", []byte("Invoice 42"), "Invoice 42", "utf-8"},
+		{"utf-8", []byte("Rechnung für März"), "Rechnung für März", "utf-8"},
+		{"utf-8 with BOM", append([]byte{0xEF, 0xBB, 0xBF}, "Résumé"...), "Résumé", "utf-8"},
+		{"utf-8 cut mid-character", []byte("Größe")[:3], "Gr", "utf-8"},
+		{"utf-16le with BOM", encode(t, unicode.UTF16(unicode.LittleEndian, unicode.UseBOM), "Café invoice"), "Café invoice", "utf-16le"},
+		{"utf-16be without BOM", encode(t, unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM), "Bank statement"), "Bank statement", "utf-16be"},
+		{"latin-1", encode(t, charmap.ISO8859_1, "Café résumé à Zürich"), "Café résumé à Zürich", "windows-1252"},
+		{"windows-1252 quotes", encode(t, charmap.Windows1252, "“Hello” – naïve"), "“Hello” – naïve", "windows-1252"},
+		{"shift-jis", encode(t, japanese.ShiftJIS, "請求書 こんにちは カタカナ"), "請求書 こんにちは カタカナ", "shift_jis"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, charset := decodeText(tt.input)
+			if got != tt.want || charset != tt.wantCharset {
+				t.Errorf("decodeText() = %q, %s; want %q, %s", got, charset, tt.want, tt.wantCharset)
+			}
+		})
+	}
+}
+
+func TestExtractText_LegacyEncoding(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notes.txt")
+	if err := os.WriteFile(path, encode(t, charmap.Windows1252, "Überweisung für Müller"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ExtractText(path, 100)
+	if err != nil || got != "Überweisung für Müller" {
+		t.Errorf("ExtractText() = %q, %v", got, err)
+	}
+}
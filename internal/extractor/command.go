@@ -0,0 +1,101 @@
+package extractor
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// fileToken is replaced by the input path in external command arguments.
+const fileToken = "{file}"
+
+// extractWithCommand runs a user-configured extractor and returns its stdout.
+func (o Options) extractWithCommand(ctx context.Context, args []string, path string, limit int) (string, error) {
+	argv := make([]string, 0, len(args)+1)
+	substituted := false
+	for _, a := range args {
+		if strings.Contains(a, fileToken) {
+			a = strings.ReplaceAll(a, fileToken, path)
+			substituted = true
+		}
+		argv = append(argv, a)
+	}
+	if !substituted {
+		argv = append(argv, path)
+	}
+
+	text, err := o.runCommand(ctx, argv, limit)
+	if err != nil {
+		return "", fmt.Errorf("external extractor %s: %w", argv[0], err)
+	}
+	return text, nil
+}
+
+// extractWithPDFToText shells out to poppler's pdftotext, reading the text from stdout.
+func (o Options) extractWithPDFToText(ctx context.Context, path string, limit int) (string, error) {
+	if _, err := exec.LookPath("pdftotext"); err != nil {
+		return "", fmt.Errorf("pdftotext not installed")
+	}
+
+	text, err := o.runCommand(ctx, []string{"pdftotext", "-q", "-enc", "UTF-8", "-l", fmt.Sprint(maxPDFPages), path, "-"}, limit)
+	if err != nil {
+		return "", fmt.Errorf("pdftotext failed: %w", err)
+	}
+	return text, nil
+}
+
+// runCommand executes argv with the configured timeout and returns up to limit bytes of stdout.
+func (o Options) runCommand(ctx context.Context, argv []string, limit int) (string, error) {
+	timeout := o.CommandTimeout
+	if timeout <= 0 {
+		timeout = time.Minute
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	// Only the first limit bytes are kept; a huge document's text is never held in full
+	stdout, stderr := &cappedBuffer{max: limit}, &cappedBuffer{max: 4096}
+	cmd := exec.CommandContext(ctx, argv[0], argv[1:]...)
+	cmd.Stdout, cmd.Stderr = stdout, stderr
+
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%w: %s", err, truncate(msg, 200))
+		}
+		return "", err
+	}
+
+	text := stdout.String()
+	if strings.TrimSpace(text) == "" {
+		return "", fmt.Errorf("command returned no text")
+	}
+	return text, nil
+}
+
+// cappedBuffer keeps the first max bytes written to it and discards the rest, without
+// failing the writer.
+type cappedBuffer struct {
+	buf bytes.Buffer
+	max int
+}
+
+func (c *cappedBuffer) Write(p []byte) (int, error) {
+	if room := c.max - c.buf.Len(); room > 0 {
+		c.buf.Write(p[:min(len(p), room)])
+	}
+	return len(p), nil
+}
+
+func (c *cappedBuffer) String() string {
+	return c.buf.String()
+}
+
+func truncate(s string, limit int) string {
+	if len(s) > limit {
+		return s[:limit]
+	}
+	return s
+}
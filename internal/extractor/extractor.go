@@ -1,28 +1,125 @@
 package extractor
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
+
+	"docs_organiser/internal/logging"
+	"docs_organiser/internal/plugin"
 
 	"github.com/ledongthuc/pdf"
 )
 
+// maxPDFPages caps how many pages are read from a single PDF.
+const maxPDFPages = 50
+
+// Options tune how text is extracted. The zero value uses only the built-in Go extractors.
+type Options struct {
+	// PDFToText, when set, retries PDFs that the Go library cannot read (error, panic,
+	// or no text) with the poppler `pdftotext` binary, if it is installed.
+	PDFToText bool
+	// Commands maps a lowercase extension (without the dot) to an external command whose
+	// stdout is used as the extracted text. The token "{file}" in the arguments is replaced
+	// by the file path; if absent, the path is appended. Commands take precedence over
+	// the built-in extractors.
+	Commands map[string][]string
+	// CommandTimeout bounds external extractor processes. Defaults to one minute.
+	CommandTimeout time.Duration
+	// Plugins maps a lowercase extension (without the dot) to an extractor plugin, which
+	// takes precedence over Commands and the built-in extractors.
+	Plugins map[string]plugin.Plugin
+}
+
 // ExtractText extracts up to 'limit' characters of text from the file at 'path'.
 func ExtractText(path string, limit int) (string, error) {
+	return Options{}.Extract(context.Background(), path, limit)
+}
+
+// Extract extracts up to 'limit' characters of text from the file at 'path' using these options.
+func (o Options) Extract(ctx context.Context, path string, limit int) (string, error) {
 	ext := strings.ToLower(filepath.Ext(path))
 
+	if pl, ok := o.Plugins[strings.TrimPrefix(ext, ".")]; ok {
+		return pl.Extract(ctx, path, limit)
+	}
+	if args, ok := o.Commands[strings.TrimPrefix(ext, ".")]; ok && len(args) > 0 {
+		return o.extractWithCommand(ctx, args, path, limit)
+	}
+
 	switch ext {
 	case ".pdf":
-		return extractPDFText(path, limit)
+		text, err := extractPDFText(path, limit)
+		if o.PDFToText && (err != nil || strings.TrimSpace(text) == "") {
+			fallback, fbErr := o.extractWithPDFToText(ctx, path, limit)
+			if fbErr == nil {
+				return fallback, nil
+			}
+			if err != nil {
+				return "", fmt.Errorf("%w (pdftotext fallback: %v)", err, fbErr)
+			}
+			// Otherwise keep the (empty) Go result; the fallback had nothing better to offer
+		}
+		return text, err
+	case ".md", ".markdown":
+		return extractMarkdown(path, limit)
 	default:
-		// Fallback for .txt, .md, and others
+		// Fallback for .txt and others
 		return extractPlainText(path, limit)
 	}
 }
 
+// infoKeys are the document info entries read from PDFs.
+var infoKeys = []string{"Title", "Author", "Subject", "Keywords"}
+
+// Metadata returns the document properties of the file at path without reading its text:
+// the Title, Author, Subject, and Keywords of a PDF, or the Title, Tags, and Date of a
+// markdown document's frontmatter. Other formats have none.
+func Metadata(path string) (info map[string]string, err error) {
+	if isMarkdown(path) {
+		text, err := extractPlainText(path, maxFrontmatterBytes)
+		if err != nil {
+			return nil, err
+		}
+		info, _ := splitFrontmatter(text)
+		return info, nil
+	}
+	if !strings.EqualFold(filepath.Ext(path), ".pdf") {
+		return nil, nil
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("pdf library panicked while processing %s: %v", path, r)
+		}
+	}()
+
+	f, r, err := pdf.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return pdfInfo(r), nil
+}
+
+// pdfInfo reads the document info dictionary of r.
+func pdfInfo(r *pdf.Reader) map[string]string {
+	pInfo := r.Trailer().Key("Info")
+	if pInfo.IsNull() {
+		return nil
+	}
+	info := make(map[string]string)
+	for _, key := range infoKeys {
+		if val := pInfo.Key(key); !val.IsNull() {
+			info[key] = val.String()
+		}
+	}
+	return info
+}
+
 func extractPDFText(path string, limit int) (text string, err error) {
 	// Panic recovery for the pdf library which sometimes panics on malformed files
 	defer func() {
@@ -40,29 +137,21 @@ func extractPDFText(path string, limit int) (text string, err error) {
 	var content strings.Builder
 
 	// Step 1: Extract Metadata (Title, Author, etc.)
-	pInfo := r.Trailer().Key("Info")
-	if !pInfo.IsNull() {
-		infoKeys := []string{"Title", "Author", "Subject", "Keywords"}
+	if info := pdfInfo(r); len(info) > 0 {
 		metadataLines := []string{"[METADATA]"}
-		foundMetadata := false
 		for _, key := range infoKeys {
-			val := pInfo.Key(key)
-			if !val.IsNull() {
-				metadataLines = append(metadataLines, fmt.Sprintf("%s: %s", key, val.String()))
-				foundMetadata = true
+			if val, ok := info[key]; ok {
+				metadataLines = append(metadataLines, fmt.Sprintf("%s: %s", key, val))
 			}
 		}
-		if foundMetadata {
-			content.WriteString(strings.Join(metadataLines, "\n") + "\n\n[CONTENT]\n")
-		}
+		content.WriteString(strings.Join(metadataLines, "\n") + "\n\n[CONTENT]\n")
 	}
 
 	totalPage := r.NumPage()
 
 	// Limit to first 50 pages to avoid massive memory consumption on huge PDFs
-	maxPages := 50
-	if totalPage > maxPages {
-		totalPage = maxPages
+	if totalPage > maxPDFPages {
+		totalPage = maxPDFPages
 	}
 
 	for pageIndex := 1; pageIndex <= totalPage; pageIndex++ {
@@ -76,9 +165,8 @@ func extractPDFText(path string, limit int) (text string, err error) {
 			continue
 		}
 
-		content.WriteString(s)
-
-		// Check limit inside loop to exit early
+		// Keep no more than the limit, even of a single huge page
+		content.WriteString(s[:min(len(s), max(limit-content.Len(), 0))])
 		if content.Len() >= limit {
 			break
 		}
@@ -86,7 +174,7 @@ func extractPDFText(path string, limit int) (text string, err error) {
 
 	text = content.String()
 	if len(text) > limit {
-		text = text[:limit]
+		text = text[:limit] // the metadata alone exceeded it
 	}
 	return text, nil
 }
@@ -106,10 +194,14 @@ func extractPlainText(path string, limit int) (string, error) {
 	}
 
 	buf := make([]byte, limit)
-	n, err := f.Read(buf)
-	if err != nil && err != io.EOF {
+	n, err := io.ReadFull(f, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
 		return "", err
 	}
 
-	return string(buf[:n]), nil
+	text, charset := decodeText(buf[:n])
+	if charset != "utf-8" {
+		logging.Verbosef("[*] Decoded %s as %s", filepath.Base(path), charset)
+	}
+	return text, nil
 }
@@ -0,0 +1,101 @@
+package extractor
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestExtractText_PlainText(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notes.txt")
+	if err := os.WriteFile(path, []byte("hello world"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ExtractText(path, 5)
+	if err != nil {
+		t.Fatalf("ExtractText() error = %v", err)
+	}
+	if got != "hello" {
+		t.Errorf("ExtractText() = %q, want %q", got, "hello")
+	}
+}
+
+func TestExtract_PDFToTextFallback(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake pdftotext script requires a POSIX shell")
+	}
+
+	dir := t.TempDir()
+	broken := filepath.Join(dir, "broken.pdf")
+	if err := os.WriteFile(broken, []byte("not really a pdf"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Without the fallback the Go library error surfaces
+	if _, err := ExtractText(broken, 100); err == nil {
+		t.Fatal("expected error for malformed PDF without fallback")
+	}
+
+	// Missing binary: the original error is kept
+	t.Setenv("PATH", dir)
+	opts := Options{PDFToText: true}
+	if _, err := opts.Extract(context.Background(), broken, 100); err == nil || !strings.Contains(err.Error(), "pdftotext") {
+		t.Fatalf("expected error mentioning pdftotext, got %v", err)
+	}
+
+	// Fake binary on PATH rescues the file
+	script := "#!/bin/sh\necho 'Recovered invoice text'\n"
+	if err := os.WriteFile(filepath.Join(dir, "pdftotext"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	got, err := opts.Extract(context.Background(), broken, 9)
+	if err != nil {
+		t.Fatalf("Extract() with fallback error = %v", err)
+	}
+	if got != "Recovered" {
+		t.Errorf("Extract() = %q, want %q", got, "Recovered")
+	}
+}
+
+func TestExtract_CommandHook(t *testing.T) {
+	if _, err := exec.LookPath("echo"); err != nil {
+		t.Skip("echo not available")
+	}
+
+	path := filepath.Join(t.TempDir(), "letter.docx")
+	if err := os.WriteFile(path, []byte("binary"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"Placeholder substituted", []string{"echo", "converted", "{file}"}, "converted " + path + "\n"},
+		{"Path appended", []string{"echo", "converted"}, "converted " + path + "\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := Options{Commands: map[string][]string{"docx": tt.args}}
+			got, err := opts.Extract(context.Background(), path, 1000)
+			if err != nil {
+				t.Fatalf("Extract() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Extract() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	failing := Options{Commands: map[string][]string{"docx": {"false"}}}
+	if _, err := failing.Extract(context.Background(), path, 1000); err == nil {
+		t.Error("expected error from failing command")
+	}
+}
@@ -0,0 +1,107 @@
+package extractor
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// maxFrontmatterBytes is how much of a markdown file Metadata reads to find its frontmatter.
+const maxFrontmatterBytes = 64 << 10
+
+// frontmatterKeys are the markdown frontmatter fields passed on as document properties.
+var frontmatterKeys = []string{"Title", "Tags", "Date"}
+
+// isMarkdown reports whether the file at path is a markdown document.
+func isMarkdown(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".md" || ext == ".markdown"
+}
+
+// extractMarkdown reads a markdown document and, like PDF metadata, moves the title,
+// tags, and date of its YAML frontmatter into a [METADATA] header before the body.
+func extractMarkdown(path string, limit int) (string, error) {
+	text, err := extractPlainText(path, limit)
+	if err != nil {
+		return "", err
+	}
+	info, body := splitFrontmatter(text)
+	if len(info) == 0 {
+		return text, nil
+	}
+	lines := []string{"[METADATA]"}
+	for _, key := range frontmatterKeys {
+		if val, ok := info[key]; ok {
+			lines = append(lines, fmt.Sprintf("%s: %s", key, val))
+		}
+	}
+	return strings.Join(lines, "\n") + "\n\n[CONTENT]\n" + body, nil
+}
+
+// splitFrontmatter separates the YAML frontmatter of a markdown document (between "---"
+// lines at the very start) from its body, returning the frontmatter's title, tags, and
+// date under frontmatterKeys. Text without complete, valid frontmatter is returned as the body.
+func splitFrontmatter(text string) (map[string]string, string) {
+	rest, ok := strings.CutPrefix(text, "---\n")
+	if !ok {
+		if rest, ok = strings.CutPrefix(text, "---\r\n"); !ok {
+			return nil, text
+		}
+	}
+
+	var front, body string
+	for offset := 0; ; {
+		line, after, found := strings.Cut(rest[offset:], "\n")
+		if trimmed := strings.TrimRight(line, "\r"); trimmed == "---" || trimmed == "..." {
+			front, body = rest[:offset], after
+			break
+		}
+		if !found {
+			return nil, text
+		}
+		offset += len(line) + 1
+	}
+
+	var fields map[string]any
+	if err := yaml.Unmarshal([]byte(front), &fields); err != nil {
+		return nil, text
+	}
+	info := make(map[string]string)
+	for name, value := range fields {
+		for _, key := range frontmatterKeys {
+			if strings.EqualFold(name, key) {
+				if s := frontmatterValue(value); s != "" {
+					info[key] = s
+				}
+			}
+		}
+	}
+	return info, strings.TrimLeft(body, "\r\n")
+}
+
+// frontmatterValue renders a frontmatter field: lists are joined with commas and dates
+// without a time of day are written as YYYY-MM-DD.
+func frontmatterValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case []any:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			if s := frontmatterValue(item); s != "" {
+				items = append(items, s)
+			}
+		}
+		return strings.Join(items, ", ")
+	case time.Time:
+		if v.Equal(v.Truncate(24 * time.Hour)) {
+			return v.Format(time.DateOnly)
+		}
+		return v.Format(time.RFC3339)
+	default:
+		return strings.TrimSpace(fmt.Sprint(v))
+	}
+}
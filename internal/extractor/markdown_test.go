@@ -0,0 +1,64 @@
+package extractor
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSplitFrontmatter(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		wantInfo map[string]string
+		wantBody string
+	}{
+		{
+			"title, tag list, and date",
+			"---\ntitle: Dentist visit\ntags: [health, receipts]\ndate: 2024-03-05\nauthor: me\n---\n\nPaid 80 EUR.",
+			map[string]string{"Title": "Dentist visit", "Tags": "health, receipts", "Date": "2024-03-05"},
+			"Paid 80 EUR.",
+		},
+		{
+			"block list and CRLF",
+			"---\r\nTitle: Trip\r\ntags:\r\n  - travel\r\n  - japan\r\n...\r\nItinerary",
+			map[string]string{"Title": "Trip", "Tags": "travel, japan"},
+			"Itinerary",
+		},
+		{"no frontmatter", "# Heading\n---\nText", nil, "# Heading\n---\nText"},
+		{"unterminated", "---\ntitle: Cut by the limit", nil, "---\ntitle: Cut by the limit"},
+		{"invalid YAML", "---\ntitle: [unclosed\n---\nBody", nil, "---\ntitle: [unclosed\n---\nBody"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, body := splitFrontmatter(tt.text)
+			if len(info) != 0 || len(tt.wantInfo) != 0 {
+				if !reflect.DeepEqual(info, tt.wantInfo) {
+					t.Errorf("info = %v, want %v", info, tt.wantInfo)
+				}
+			}
+			if body != tt.wantBody {
+				t.Errorf("body = %q, want %q", body, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestExtractMarkdown(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "note.md")
+	if err := os.WriteFile(path, []byte("---\ntitle: Tax notes\ntags: taxes\n---\nDeductible items"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ExtractText(path, 1000)
+	want := "[METADATA]\nTitle: Tax notes\nTags: taxes\n\n[CONTENT]\nDeductible items"
+	if err != nil || got != want {
+		t.Errorf("ExtractText() = %q, %v; want %q", got, err, want)
+	}
+
+	info, err := Metadata(path)
+	if err != nil || !reflect.DeepEqual(info, map[string]string{"Title": "Tax notes", "Tags": "taxes"}) {
+		t.Errorf("Metadata() = %v, %v", info, err)
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !linux && !darwin
+
+package fileops
+
+import "errors"
+
+// cloneFile is unavailable here; moves across volumes copy the bytes.
+func cloneFile(src, dst string) error {
+	return errors.ErrUnsupported
+}
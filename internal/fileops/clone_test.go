@@ -0,0 +1,26 @@
+package fileops
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCloneFile(t *testing.T) {
+	dir := t.TempDir()
+	src, dst := filepath.Join(dir, "src.pdf"), filepath.Join(dir, "dst.pdf")
+	if err := os.WriteFile(src, []byte("%PDF-1.7 content"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cloneFile(src, dst); err != nil {
+		// tmpfs, ext4, and most CI file systems can't clone; nothing may be left behind
+		if _, statErr := os.Stat(dst); !os.IsNotExist(statErr) {
+			t.Errorf("failed clone left %s behind", dst)
+		}
+		t.Skipf("copy-on-write clones unsupported here: %v", err)
+	}
+	if got, _ := os.ReadFile(dst); string(got) != "%PDF-1.7 content" {
+		t.Errorf("clone holds %q", got)
+	}
+}
@@ -0,0 +1,47 @@
+package fileops
+
+import "fmt"
+
+// CollisionPolicy decides what happens when a file with the target name already exists
+// (and holds different content; identical files are never duplicated).
+type CollisionPolicy string
+
+const (
+	// CollisionHash keeps both, appending a short content hash to the new file's name.
+	CollisionHash CollisionPolicy = "hash"
+	// CollisionSequence keeps both, appending the first free "_1", "_2", ... suffix.
+	CollisionSequence CollisionPolicy = "sequence"
+	// CollisionSkip keeps the existing file and leaves the new one where it is.
+	CollisionSkip CollisionPolicy = "skip"
+	// CollisionOverwrite replaces the existing file.
+	CollisionOverwrite CollisionPolicy = "overwrite"
+	// CollisionNewest keeps whichever file was modified last; an older new file is left where it is.
+	CollisionNewest CollisionPolicy = "newest"
+)
+
+// ParseCollisionPolicy validates a configured policy; "" means CollisionHash.
+func ParseCollisionPolicy(s string) (CollisionPolicy, error) {
+	switch policy := CollisionPolicy(s); policy {
+	case "":
+		return CollisionHash, nil
+	case CollisionHash, CollisionSequence, CollisionSkip, CollisionOverwrite, CollisionNewest:
+		return policy, nil
+	}
+	return "", fmt.Errorf("unknown collision policy %q (want hash, sequence, skip, overwrite, or newest)", s)
+}
+
+// Outcome says what Move did with the source file.
+type Outcome int
+
+const (
+	// Moved means the file was written to a free name.
+	Moved Outcome = iota
+	// Replaced means the file overwrote an existing one.
+	Replaced
+	// Duplicate means identical content was already there; the source was removed.
+	Duplicate
+	// Skipped means the existing file was kept and the source left in place.
+	Skipped
+	// InPlace means the source already is the destination file; nothing was done.
+	InPlace
+)
@@ -0,0 +1,53 @@
+package fileops
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// ResolveFold returns rel with each folder and file name replaced by the existing entry
+// under root that matches it case-insensitively, so that "finance/invoice.pdf" lands in
+// an existing "Finance" folder and collides with an existing "Invoice.pdf". Case-insensitive
+// file systems (macOS, Windows) treat those as the same name, and a case-sensitive
+// destination that is later synced to one would end up with clashing copies. Names are
+// compared in NFC, so "Café" also finds a "Café" folder written decomposed by macOS.
+// Names that don't exist yet are kept as given.
+func ResolveFold(root, rel string) string {
+	parts := strings.Split(filepath.ToSlash(rel), "/")
+	dir := root
+	for i, part := range parts {
+		if part == "" || part == "." || part == ".." {
+			continue
+		}
+		name, ok := lookupFold(dir, part)
+		if !ok {
+			break
+		}
+		parts[i] = name
+		dir = filepath.Join(dir, name)
+	}
+	return filepath.FromSlash(strings.Join(parts, "/"))
+}
+
+// lookupFold returns the entry of dir named name, preferring an exact match over one
+// that differs only in case or Unicode normalization. ok is false when there is neither (or dir can't be read).
+func lookupFold(dir, name string) (string, bool) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", false
+	}
+	found, ok := "", false
+	want := norm.NFC.String(name)
+	for _, e := range entries {
+		switch {
+		case e.Name() == name:
+			return name, true
+		case !ok && strings.EqualFold(norm.NFC.String(e.Name()), want):
+			found, ok = e.Name(), true
+		}
+	}
+	return found, ok
+}
@@ -0,0 +1,72 @@
+package fileops
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestResolveFold(t *testing.T) {
+	root := t.TempDir()
+	mustWrite(t, filepath.Join(root, "Finance", "Taxes", "Return.pdf"), "x", time.Now())
+	mustWrite(t, filepath.Join(root, "work", "notes.txt"), "x", time.Now())
+	mustWrite(t, filepath.Join(root, "Cafe\u0301", "Menu.pdf"), "x", time.Now()) // decomposed, as macOS writes it
+
+	tests := []struct{ rel, want string }{
+		{"Finance", "Finance"},
+		{"finance", "Finance"},
+		{"FINANCE/taxes/return.pdf", "Finance/Taxes/Return.pdf"},
+		{"finance/Bills/invoice.pdf", "Finance/Bills/invoice.pdf"},
+		{"Work/Notes.txt", "work/notes.txt"},
+		{"Travel", "Travel"},
+		{"Caf\u00e9/Menu.pdf", "Cafe\u0301/Menu.pdf"},
+		{"CAF\u00c9", "Cafe\u0301"},
+	}
+	for _, tt := range tests {
+		if got := ResolveFold(root, tt.rel); got != filepath.FromSlash(tt.want) {
+			t.Errorf("ResolveFold(%q) = %q, want %q", tt.rel, got, tt.want)
+		}
+	}
+}
+
+func TestMove_CaseInsensitiveCollision(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name        string
+		policy      CollisionPolicy
+		incoming    string
+		wantName    string
+		wantOutcome Outcome
+	}{
+		{"identical content is a duplicate", CollisionHash, "old", "Invoice.pdf", Duplicate},
+		{"hash suffix", CollisionHash, "new", "Invoice_11507a0e.pdf", Moved},
+		{"sequence skips taken names in any case", CollisionSequence, "new", "Invoice_2.pdf", Moved},
+		{"overwrite keeps the existing name", CollisionOverwrite, "new", "Invoice.pdf", Replaced},
+		{"skip", CollisionSkip, "new", "Invoice.pdf", Skipped},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			dst := filepath.Join(dir, "dst")
+			mustWrite(t, filepath.Join(dst, "Invoice.pdf"), "old", now.Add(-time.Hour))
+			mustWrite(t, filepath.Join(dst, "INVOICE_1.pdf"), "taken", now)
+			src := filepath.Join(dir, "in.pdf")
+			mustWrite(t, src, tt.incoming, now)
+
+			got, outcome, err := Move(src, dst, "invoice.pdf", MoveOptions{Collisions: tt.policy})
+			if err != nil {
+				t.Fatalf("Move: %v", err)
+			}
+			if want := filepath.Join(dst, tt.wantName); got != want || outcome != tt.wantOutcome {
+				t.Errorf("Move = %s, %v; want %s, %v", got, outcome, want, tt.wantOutcome)
+			}
+			entries, _ := os.ReadDir(dst)
+			for _, e := range entries {
+				if e.Name() == "invoice.pdf" {
+					t.Errorf("a differently cased copy of Invoice.pdf was created")
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package fileops
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/cespare/xxhash/v2"
+)
+
+// HashScheme decides how the content hash in collision suffixes ("Invoice_3f2a9c1d.pdf")
+// is computed. Only the first 8 hex digits are used, so a fast hash serves as well as a
+// cryptographic one; duplicates are still detected by comparing the bytes.
+type HashScheme string
+
+const (
+	// HashSHA256 hashes the whole file with SHA-256.
+	HashSHA256 HashScheme = "sha256"
+	// HashXXH hashes the whole file with xxHash64, several times faster than SHA-256.
+	HashXXH HashScheme = "xxhash"
+	// HashPartial hashes the size and the first and last MoveOptions.PartialHashBytes
+	// with xxHash64, so multi-GB files are not read in full.
+	HashPartial HashScheme = "partial"
+)
+
+// DefaultPartialHashBytes is the span HashPartial reads at each end of a file.
+const DefaultPartialHashBytes = 4 << 20
+
+// ParseHashScheme validates a configured scheme; "" means HashSHA256.
+func ParseHashScheme(s string) (HashScheme, error) {
+	switch scheme := HashScheme(s); scheme {
+	case "":
+		return HashSHA256, nil
+	case HashSHA256, HashXXH, HashPartial:
+		return scheme, nil
+	}
+	return "", fmt.Errorf("unknown collision hash %q (want sha256, xxhash, or partial)", s)
+}
+
+// Sum returns the hex-encoded hash of the file at path. partial is the number of bytes
+// HashPartial reads at each end (DefaultPartialHashBytes when not above zero); files no
+// longer than twice that are hashed whole.
+func (s HashScheme) Sum(path string, partial int64) (string, error) {
+	switch s {
+	case HashXXH, HashPartial:
+	default:
+		return HashFile(path)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := xxhash.New()
+	if s == HashXXH {
+		if _, err := io.Copy(h, f); err != nil {
+			return "", err
+		}
+		return hex.EncodeToString(h.Sum(nil)), nil
+	}
+
+	if partial <= 0 {
+		partial = DefaultPartialHashBytes
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+	size := info.Size()
+	binary.Write(h, binary.LittleEndian, size)
+	if size <= 2*partial {
+		_, err = io.Copy(h, f)
+	} else if _, err = io.Copy(h, io.NewSectionReader(f, 0, partial)); err == nil {
+		_, err = io.Copy(h, io.NewSectionReader(f, size-partial, partial))
+	}
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,92 @@
+package fileops
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestHashScheme_Sum(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, content []byte) string {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, content, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	big := bytes.Repeat([]byte("0123456789abcdef"), 1024) // 16 KB
+	edited := bytes.Clone(big)
+	edited[8000] = 'X' // in the middle, outside the partial spans below
+	a, b := write("a", big), write("b", edited)
+	c := write("c", append(bytes.Clone(big), '!'))
+
+	sum := func(s HashScheme, path string) string {
+		t.Helper()
+		h, err := s.Sum(path, 1024)
+		if err != nil {
+			t.Fatalf("%s.Sum(%s): %v", s, path, err)
+		}
+		return h
+	}
+
+	if got, want := sum(HashSHA256, a), mustHash(t, a); got != want {
+		t.Errorf("sha256 = %s, want HashFile's %s", got, want)
+	}
+	if sum(HashXXH, a) == sum(HashXXH, b) {
+		t.Error("xxhash ignores a change in the middle")
+	}
+	if sum(HashPartial, a) != sum(HashPartial, b) {
+		t.Error("partial hash read the middle of the file")
+	}
+	if sum(HashPartial, a) == sum(HashPartial, c) {
+		t.Error("partial hash ignores the size and the last bytes")
+	}
+	// Files within twice the span are hashed whole
+	if h, _ := HashPartial.Sum(a, 16<<10); h == sum(HashPartial, b) {
+		t.Error("small file not hashed whole")
+	}
+
+	if _, err := ParseHashScheme("md5"); err == nil {
+		t.Error("unknown scheme accepted")
+	}
+}
+
+func TestMove_PartialHashCollision(t *testing.T) {
+	dir := t.TempDir()
+	dst := filepath.Join(dir, "dst")
+	mustWrite(t, filepath.Join(dst, "scan.pdf"), "first version", time.Now())
+	src := filepath.Join(dir, "in.pdf")
+	mustWrite(t, src, "third version", time.Now())
+
+	// An earlier file with the same partial hash took the suffixed name
+	hash, err := HashPartial.Sum(src, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hashed := "scan_" + hash[:8] + ".pdf"
+	mustWrite(t, filepath.Join(dst, hashed), "other version", time.Now())
+
+	got, outcome, err := Move(src, dst, "scan.pdf", MoveOptions{Hash: HashPartial, PartialHashBytes: 4})
+	if err != nil || outcome != Moved {
+		t.Fatalf("Move = %s, %v, %v", got, outcome, err)
+	}
+	if want := filepath.Join(dst, "scan_"+hash[:8]+"_1.pdf"); got != want {
+		t.Errorf("moved to %s, want %s", got, want)
+	}
+	if content, _ := os.ReadFile(filepath.Join(dst, hashed)); string(content) != "other version" {
+		t.Errorf("the file holding the suffixed name was replaced: %q", content)
+	}
+}
+
+func mustHash(t *testing.T, path string) string {
+	t.Helper()
+	h, err := HashFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return h
+}
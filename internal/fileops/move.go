@@ -1,59 +1,227 @@
 package fileops
 
 import (
+	"bytes"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"syscall"
 )
 
 // MoveFile moves a file from src to dst.
 // It handles cross-device moves by falling back to Copy+Delete.
 // It handles collisions by appending a content hash to the filename.
-func MoveFile(src, dstFolder string, newFilename string) error {
+// It returns the path the file was finally written to.
+func MoveFile(src, dstFolder string, newFilename string) (string, error) {
+	dstPath, _, err := Move(src, dstFolder, newFilename, MoveOptions{})
+	return dstPath, err
+}
+
+// MoveOptions configure Move. The zero value appends a SHA-256 suffix on collisions and
+// keeps no free space reserve.
+type MoveOptions struct {
+	// Collisions decides what happens when the name is taken by a different file.
+	Collisions CollisionPolicy
+	// Hash computes the CollisionHash suffix; PartialHashBytes is the span HashPartial
+	// reads at each end of the file.
+	Hash             HashScheme
+	PartialHashBytes int64
+	// Reserve is the free space, in bytes, a copy across volumes must leave.
+	Reserve int64
+	// Throttle, when set, limits the bandwidth and reads of copies across volumes.
+	Throttle *Throttle
+}
+
+// Move moves src to dstFolder/newFilename, resolving a name collision with opts.Collisions.
+// If the existing file has the same content, src is removed instead of creating a
+// duplicate, unless it is that file (InPlace). It returns the path that now holds the
+// file (the existing one for Duplicate and Skipped outcomes) and what was done. A move across volumes copies the
+// file, and first fails with ErrDiskFull unless opts.Reserve bytes stay free after it; a
+// copy replacing a file only takes its place once complete.
+func Move(src, dstFolder, newFilename string, opts MoveOptions) (string, Outcome, error) {
+	// An existing name in different case is the same name on case-insensitive file systems
+	newFilename = ResolveFold(dstFolder, newFilename)
 	dstPath := filepath.Join(dstFolder, newFilename)
 
 	// Ensure destination directory exists (including any subdirectories in newFilename)
 	if err := os.MkdirAll(filepath.Dir(dstPath), 0755); err != nil {
-		return fmt.Errorf("failed to create destination directory: %w", err)
+		return "", Moved, fmt.Errorf("failed to create destination directory: %w", err)
 	}
 
-	// Check for collision
-	if _, err := os.Stat(dstPath); err == nil {
-		// File exists, append hash
-		hash, err := getFileHash(src)
+	outcome := Moved
+	if existing, err := os.Stat(dstPath); err == nil {
+		incoming, err := os.Stat(src)
+		if err != nil {
+			return "", Moved, err
+		}
+		// Moving a file onto itself must not find it a duplicate of itself and remove it
+		if os.SameFile(incoming, existing) {
+			return dstPath, InPlace, nil
+		}
+		same, err := sameContent(src, dstPath, incoming, existing)
 		if err != nil {
-			return fmt.Errorf("failed to calculate hash for collision resolution: %w", err)
+			return "", Moved, fmt.Errorf("failed to compare with existing file: %w", err)
+		}
+		if same {
+			if err := os.Remove(src); err != nil {
+				return "", Duplicate, fmt.Errorf("failed to remove duplicate source file: %w", err)
+			}
+			return dstPath, Duplicate, nil
+		}
+
+		switch opts.Collisions {
+		case CollisionSkip:
+			return dstPath, Skipped, nil
+		case CollisionOverwrite:
+			outcome = Replaced
+		case CollisionNewest:
+			if !incoming.ModTime().After(existing.ModTime()) {
+				return dstPath, Skipped, nil
+			}
+			outcome = Replaced
+		case CollisionSequence:
+			if dstPath, err = freeSequencePath(dstFolder, newFilename); err != nil {
+				return "", Moved, err
+			}
+		default:
+			hash, err := opts.Hash.Sum(src, opts.PartialHashBytes)
+			if err != nil {
+				return "", Moved, fmt.Errorf("failed to calculate hash for collision resolution: %w", err)
+			}
+			ext := filepath.Ext(newFilename)
+			hashed := fmt.Sprintf("%s_%s%s", newFilename[:len(newFilename)-len(ext)], hash[:8], ext)
+			dstPath = filepath.Join(dstFolder, hashed)
+			// A partial hash can repeat for different files: never replace one
+			if taken, err := os.Stat(dstPath); err == nil {
+				if same, err := sameContent(src, dstPath, incoming, taken); err != nil || same {
+					if err == nil {
+						err = os.Remove(src)
+					}
+					return dstPath, Duplicate, err
+				}
+				if dstPath, err = freeSequencePath(dstFolder, hashed); err != nil {
+					return "", Moved, err
+				}
+			}
 		}
-		ext := filepath.Ext(newFilename)
-		name := newFilename[:len(newFilename)-len(ext)]
-		dstPath = filepath.Join(dstFolder, fmt.Sprintf("%s_%s%s", name, hash[:8], ext))
 	}
 
 	// Try atomic rename first
 	err := os.Rename(src, dstPath)
 	if err == nil {
-		return nil
+		return dstPath, outcome, nil
 	}
 
 	// If rename fails (likely cross-device), try Copy + Remove
 	// Check if it's a cross-device error or something else that permits retry
 	// os.Rename returns slightly different errors depending on OS, but generally we just try fallback.
 
-	if err := copyFile(src, dstPath); err != nil {
-		return fmt.Errorf("failed to copy file (fallback): %w", err)
+	// A replacement is copied beside the file it replaces and renamed over it, so that a
+	// failed copy leaves that file as it was
+	target := dstPath
+	if outcome == Replaced {
+		tmp, err := os.CreateTemp(filepath.Dir(dstPath), ".docs_organiser-*.tmp")
+		if err != nil {
+			return "", outcome, fmt.Errorf("failed to create temporary file: %w", err)
+		}
+		target = tmp.Name()
+		tmp.Close()
+		os.Remove(target) // cloneFile creates it afresh
+	}
+
+	// Within one btrfs, XFS, or APFS volume (different mounts or subvolumes), a
+	// copy-on-write clone is instant and shares the blocks; otherwise the bytes are copied
+	if cloneFile(src, target) != nil {
+		info, err := os.Stat(src)
+		if err != nil {
+			return "", outcome, err
+		}
+		if err := checkSpace(filepath.Dir(dstPath), info.Size(), opts.Reserve); err != nil {
+			return "", outcome, err
+		}
+		if err := copyFile(src, target, opts.Throttle); err != nil {
+			os.Remove(target) // don't leave a partial copy behind
+			if errors.Is(err, syscall.ENOSPC) {
+				err = fmt.Errorf("%w: %v", ErrDiskFull, err)
+			}
+			return "", outcome, fmt.Errorf("failed to copy file (fallback): %w", err)
+		}
+	}
+	if target != dstPath {
+		if err := os.Rename(target, dstPath); err != nil {
+			os.Remove(target)
+			return "", outcome, fmt.Errorf("failed to replace existing file: %w", err)
+		}
 	}
 
 	if err := os.Remove(src); err != nil {
-		return fmt.Errorf("failed to remove source file after copy: %w", err)
+		return "", outcome, fmt.Errorf("failed to remove source file after copy: %w", err)
 	}
 
-	return nil
+	return dstPath, outcome, nil
 }
 
-func copyFile(src, dst string) error {
+// sameContent reports whether the files at a and b, described by aInfo and bInfo, hold
+// the same bytes. Files of equal size are compared directly, stopping at the first
+// difference.
+func sameContent(a, b string, aInfo, bInfo os.FileInfo) (bool, error) {
+	if aInfo.Size() != bInfo.Size() {
+		return false, nil
+	}
+	fa, err := os.Open(a)
+	if err != nil {
+		return false, err
+	}
+	defer fa.Close()
+	fb, err := os.Open(b)
+	if err != nil {
+		return false, err
+	}
+	defer fb.Close()
+
+	bufA, bufB := make([]byte, 64<<10), make([]byte, 64<<10)
+	for {
+		na, errA := io.ReadFull(fa, bufA)
+		nb, errB := io.ReadFull(fb, bufB)
+		if na != nb || !bytes.Equal(bufA[:na], bufB[:nb]) {
+			return false, nil
+		}
+		if errA == io.EOF || errA == io.ErrUnexpectedEOF {
+			return errB == errA, nil
+		}
+		if errA != nil {
+			return false, errA
+		}
+		if errB != nil {
+			return false, errB
+		}
+	}
+}
+
+// freeSequencePath returns the first of "name_1.ext", "name_2.ext", ... not yet taken in
+// folder, in any case.
+func freeSequencePath(folder, filename string) (string, error) {
+	ext := filepath.Ext(filename)
+	name := filename[:len(filename)-len(ext)]
+	dir := filepath.Join(folder, filepath.Dir(name))
+	for i := 1; ; i++ {
+		candidate := fmt.Sprintf("%s_%d%s", filepath.Base(name), i, ext)
+		if _, taken := lookupFold(dir, candidate); !taken {
+			path := filepath.Join(dir, candidate)
+			if _, err := os.Lstat(path); os.IsNotExist(err) {
+				return path, nil
+			} else if err != nil {
+				return "", err
+			}
+		}
+	}
+}
+
+func copyFile(src, dst string, throttle *Throttle) error {
 	sourceFile, err := os.Open(src)
 	if err != nil {
 		return err
@@ -64,13 +232,17 @@ func copyFile(src, dst string) error {
 	if err != nil {
 		return err
 	}
-	defer destFile.Close()
 
-	_, err = io.Copy(destFile, sourceFile)
-	return err
+	if _, err := io.Copy(destFile, throttle.Reader(sourceFile)); err != nil {
+		destFile.Close()
+		return err
+	}
+	// A full volume may only report the error when the data is flushed
+	return destFile.Close()
 }
 
-func getFileHash(path string) (string, error) {
+// HashFile returns the hex-encoded SHA-256 of the file's content.
+func HashFile(path string) (string, error) {
 	f, err := os.Open(path)
 	if err != nil {
 		return "", err
@@ -0,0 +1,119 @@
+package fileops
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestMove_Collisions(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name        string
+		policy      CollisionPolicy
+		incoming    string
+		incomingAge time.Duration
+		wantName    string
+		wantOutcome Outcome
+		wantContent string // of wantName afterwards
+		wantSource  bool   // source still present
+	}{
+		{"identical content is not duplicated", CollisionSequence, "old", 0, "doc.txt", Duplicate, "old", false},
+		{"hash suffix", CollisionHash, "new", 0, "doc_11507a0e.txt", Moved, "new", false},
+		{"sequence suffix", CollisionSequence, "new", 0, "doc_2.txt", Moved, "new", false},
+		{"skip", CollisionSkip, "new", 0, "doc.txt", Skipped, "old", true},
+		{"overwrite", CollisionOverwrite, "new", 0, "doc.txt", Replaced, "new", false},
+		{"newest replaces older", CollisionNewest, "new", 0, "doc.txt", Replaced, "new", false},
+		{"newest keeps newer", CollisionNewest, "new", 2 * time.Hour, "doc.txt", Skipped, "old", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			dst := filepath.Join(dir, "dst")
+			mustWrite(t, filepath.Join(dst, "doc.txt"), "old", now.Add(-time.Hour))
+			mustWrite(t, filepath.Join(dst, "doc_1.txt"), "taken", now)
+			src := filepath.Join(dir, "in.txt")
+			mustWrite(t, src, tt.incoming, now.Add(-tt.incomingAge))
+
+			got, outcome, err := Move(src, dst, "doc.txt", MoveOptions{Collisions: tt.policy})
+			if err != nil {
+				t.Fatalf("Move: %v", err)
+			}
+			if want := filepath.Join(dst, tt.wantName); got != want || outcome != tt.wantOutcome {
+				t.Errorf("Move = %s, %v; want %s, %v", got, outcome, want, tt.wantOutcome)
+			}
+			if data, _ := os.ReadFile(got); string(data) != tt.wantContent {
+				t.Errorf("%s holds %q, want %q", tt.wantName, data, tt.wantContent)
+			}
+			if _, err := os.Stat(src); (err == nil) != tt.wantSource {
+				t.Errorf("source present = %v, want %v", err == nil, tt.wantSource)
+			}
+		})
+	}
+}
+
+func TestMove_OntoItself(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "Photos")
+	src := filepath.Join(dir, "a.jpg")
+	mustWrite(t, src, "pixels", time.Now())
+
+	for _, policy := range []CollisionPolicy{CollisionHash, CollisionSequence, CollisionOverwrite, CollisionNewest} {
+		got, outcome, err := Move(src, dir, "a.jpg", MoveOptions{Collisions: policy})
+		if err != nil || got != src || outcome != InPlace {
+			t.Errorf("%s: Move = %s, %v, %v; want %s, InPlace", policy, got, outcome, err, src)
+		}
+		if data, err := os.ReadFile(src); err != nil || string(data) != "pixels" {
+			t.Fatalf("%s: the file is gone or changed after moving it onto itself: %q, %v", policy, data, err)
+		}
+		if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+			t.Errorf("%s: %d files in the folder, want 1", policy, len(entries))
+		}
+	}
+}
+
+func TestMove_FailedReplaceKeepsExisting(t *testing.T) {
+	dir := t.TempDir()
+	dst := filepath.Join(dir, "dst")
+	existing := filepath.Join(dst, "doc.txt")
+	mustWrite(t, existing, "old", time.Now().Add(-time.Hour))
+	// A directory can be neither renamed over the file nor read, so the copy fails once started
+	src := filepath.Join(dir, "in")
+	if err := os.Mkdir(src, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, policy := range []CollisionPolicy{CollisionOverwrite, CollisionNewest} {
+		if _, _, err := Move(src, dst, "doc.txt", MoveOptions{Collisions: policy}); err == nil {
+			t.Fatalf("%s: Move succeeded, want a copy error", policy)
+		}
+		if data, err := os.ReadFile(existing); err != nil || string(data) != "old" {
+			t.Errorf("%s: the replaced file holds %q, %v after a failed copy; want %q", policy, data, err, "old")
+		}
+		if entries, _ := os.ReadDir(dst); len(entries) != 1 {
+			t.Errorf("%s: %d files in the folder, want 1", policy, len(entries))
+		}
+	}
+}
+
+func TestParseCollisionPolicy(t *testing.T) {
+	if p, err := ParseCollisionPolicy(""); err != nil || p != CollisionHash {
+		t.Errorf(`ParseCollisionPolicy("") = %q, %v`, p, err)
+	}
+	if _, err := ParseCollisionPolicy("rename"); err == nil {
+		t.Error("expected an error for an unknown policy")
+	}
+}
+
+func mustWrite(t *testing.T, path, content string, modTime time.Time) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
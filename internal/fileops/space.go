@@ -0,0 +1,24 @@
+package fileops
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrDiskFull reports that the destination volume has no room for a file, or would be
+// left with less than the configured reserve.
+var ErrDiskFull = errors.New("destination disk is full")
+
+// checkSpace returns ErrDiskFull when writing size bytes into dir would leave less than
+// reserve bytes free. Volumes whose free space can't be read pass.
+func checkSpace(dir string, size, reserve int64) error {
+	free, err := freeSpace(dir)
+	if err != nil {
+		return nil
+	}
+	if need := uint64(max(size, 0) + max(reserve, 0)); free < need {
+		return fmt.Errorf("%w: %d MB free, %d MB needed including the %d MB reserve",
+			ErrDiskFull, free>>20, need>>20, reserve>>20)
+	}
+	return nil
+}
@@ -0,0 +1,10 @@
+//go:build !(linux || darwin || freebsd)
+
+package fileops
+
+import "errors"
+
+// freeSpace is unavailable here; moves proceed without the free space check.
+func freeSpace(dir string) (uint64, error) {
+	return 0, errors.ErrUnsupported
+}
@@ -0,0 +1,19 @@
+package fileops
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCheckSpace(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := freeSpace(dir); err != nil {
+		t.Skipf("free space unavailable: %v", err)
+	}
+	if err := checkSpace(dir, 1024, 0); err != nil {
+		t.Errorf("checkSpace(1 KB) = %v", err)
+	}
+	if err := checkSpace(dir, 1024, 1<<60); !errors.Is(err, ErrDiskFull) {
+		t.Errorf("checkSpace with an exabyte reserve = %v, want ErrDiskFull", err)
+	}
+}
@@ -0,0 +1,105 @@
+package fileops
+
+import (
+	"io"
+	"sync"
+	"time"
+)
+
+// throttleChunk is the largest read a throttled copy issues, and so the unit counted
+// against the operations limit.
+const throttleChunk = 64 << 10
+
+// Throttle limits the bandwidth and I/O operations of file copies, across all the
+// copies sharing it, so that organising a folder on a NAS or synced drive leaves the
+// link usable. A nil Throttle doesn't limit.
+type Throttle struct {
+	bytesPerSec float64
+	opsPerSec   float64
+	chunk       int
+
+	mu   sync.Mutex
+	next time.Time // when the budget spent so far is paid off
+}
+
+// NewThrottle returns a throttle allowing bytesPerSec bytes and opsPerSec reads of up to
+// 64 KB per second; zero means no limit of that kind. It returns nil when neither is set.
+func NewThrottle(bytesPerSec int64, opsPerSec int) *Throttle {
+	if bytesPerSec <= 0 && opsPerSec <= 0 {
+		return nil
+	}
+	t := &Throttle{bytesPerSec: float64(bytesPerSec), opsPerSec: float64(opsPerSec), chunk: throttleChunk}
+	if bytesPerSec > 0 {
+		// Small enough for a steady stream at about ten reads per second
+		t.chunk = int(min(max(bytesPerSec/10, 4<<10), throttleChunk))
+	}
+	return t
+}
+
+// wait blocks until n more bytes, read in one operation, fit the limits.
+func (t *Throttle) wait(n int) {
+	var cost time.Duration
+	if t.bytesPerSec > 0 {
+		cost = time.Duration(float64(n) / t.bytesPerSec * float64(time.Second))
+	}
+	if t.opsPerSec > 0 {
+		cost = max(cost, time.Duration(float64(time.Second)/t.opsPerSec))
+	}
+
+	t.mu.Lock()
+	now := time.Now()
+	if t.next.Before(now) {
+		t.next = now // idle time is not saved up for a burst
+	}
+	start := t.next
+	t.next = start.Add(cost)
+	t.mu.Unlock()
+
+	time.Sleep(time.Until(start))
+}
+
+// Reader wraps r so that reading from it is throttled. A nil Throttle returns r.
+func (t *Throttle) Reader(r io.Reader) io.Reader {
+	if t == nil {
+		return r
+	}
+	return &throttledReader{r: r, t: t}
+}
+
+type throttledReader struct {
+	r io.Reader
+	t *Throttle
+}
+
+func (tr *throttledReader) Read(p []byte) (int, error) {
+	if len(p) > tr.t.chunk {
+		p = p[:tr.t.chunk]
+	}
+	n, err := tr.r.Read(p)
+	if n > 0 {
+		tr.t.wait(n)
+	}
+	return n, err
+}
+
+// WriteTo copies in chunks of the throttle's size, whatever buffer io.Copy would use.
+func (tr *throttledReader) WriteTo(w io.Writer) (int64, error) {
+	buf := make([]byte, tr.t.chunk)
+	var written int64
+	for {
+		n, err := tr.Read(buf)
+		if n > 0 {
+			m, werr := w.Write(buf[:n])
+			written += int64(m)
+			if werr != nil {
+				return written, werr
+			}
+		}
+		if err == io.EOF {
+			return written, nil
+		}
+		if err != nil {
+			return written, err
+		}
+	}
+}
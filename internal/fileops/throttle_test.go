@@ -0,0 +1,44 @@
+package fileops
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestThrottle(t *testing.T) {
+	if NewThrottle(0, 0) != nil {
+		t.Error("NewThrottle(0, 0) should not limit")
+	}
+	if r := bytes.NewReader(nil); (*Throttle)(nil).Reader(r) != r {
+		t.Error("a nil throttle wrapped the reader")
+	}
+
+	tests := []struct {
+		name        string
+		bytesPerSec int64
+		opsPerSec   int
+		size        int
+		min         time.Duration
+	}{
+		// 128 KB at 512 KB/s is 250 ms; the first chunk is not delayed
+		{"bandwidth", 512 << 10, 0, 128 << 10, 150 * time.Millisecond},
+		// Five 64 KB reads at 20 per second
+		{"operations", 0, 20, 5 * throttleChunk, 200 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			throttle := NewThrottle(tt.bytesPerSec, tt.opsPerSec)
+			start := time.Now()
+			n, err := io.Copy(io.Discard, throttle.Reader(bytes.NewReader(make([]byte, tt.size))))
+			elapsed := time.Since(start)
+			if err != nil || n != int64(tt.size) {
+				t.Fatalf("copied %d bytes, err %v", n, err)
+			}
+			if elapsed < tt.min || elapsed > 5*time.Second {
+				t.Errorf("copy took %s, want at least %s", elapsed, tt.min)
+			}
+		})
+	}
+}
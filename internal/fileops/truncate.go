@@ -0,0 +1,54 @@
+package fileops
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// collisionSuffixBytes is the length of the "_3f2a9c1d" suffix a hash collision adds;
+// TruncateName keeps room for it so that a suffixed name still fits.
+const collisionSuffixBytes = 9
+
+// MinNameBytes is the smallest accepted file name limit: room for a short title, the
+// collision suffix, and a typical extension.
+const MinNameBytes = 32
+
+// ValidateNameLimit checks a maximum file name length in bytes (0 = no limit).
+func ValidateNameLimit(max int) error {
+	if max != 0 && max < MinNameBytes {
+		return fmt.Errorf("max_filename_bytes must be 0 (no limit) or at least %d, got %d", MinNameBytes, max)
+	}
+	return nil
+}
+
+// TruncateName shortens the file name so that it, and the name a collision suffix would
+// make of it, fit in max bytes (0 = no limit). The extension is kept; the rest is cut at
+// a character boundary, at the last word break when one is close, and stripped of
+// trailing separators. Shorter names are returned unchanged.
+func TruncateName(name string, max int) string {
+	if max <= 0 || len(name)+collisionSuffixBytes <= max {
+		return name
+	}
+	ext := filepath.Ext(name)
+	if len(ext)*2 > max {
+		ext = "" // not an extension but a dot in a long title
+	}
+	stem := strings.TrimSuffix(name, ext)
+	cut := max - collisionSuffixBytes - len(ext)
+	if cut >= len(stem) {
+		return name
+	}
+	for cut > 0 && !utf8.RuneStart(stem[cut]) {
+		cut--
+	}
+	short := stem[:cut]
+	if i := strings.LastIndexAny(short, " _-"); i > 0 && i >= len(short)*3/4 {
+		short = short[:i]
+	}
+	if trimmed := strings.TrimRight(short, " _-."); trimmed != "" {
+		short = trimmed
+	}
+	return short + ext
+}
@@ -0,0 +1,43 @@
+package fileops
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTruncateName(t *testing.T) {
+	long := strings.Repeat("Quarterly Report ", 20) + ".pdf" // 344 bytes
+	tests := []struct {
+		name  string
+		input string
+		max   int
+		want  string
+	}{
+		{"short names are kept", "Invoice.pdf", 255, "Invoice.pdf"},
+		{"no limit", long, 0, long},
+		{"cut at a word break, extension kept", "Quarterly Report for ACME Corporation.pdf", 40, "Quarterly Report for ACME.pdf"},
+		{"cut inside a word without a nearby break", "Annual_Statement_of_Comprehensive_Income.pdf", 40, "Annual_Statement_of_Compreh.pdf"},
+		{"multi-byte letters are not split", "Überweisungsbestätigung_Grundsteuer.pdf", 32, "Überweisungsbestä.pdf"},
+		{"dot in a title without extension", "Release notes v1.2 and the changes since the last version", 40, "Release notes v1.2 and the"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := TruncateName(tt.input, tt.max)
+			if got != tt.want {
+				t.Errorf("TruncateName(%q, %d) = %q, want %q", tt.input, tt.max, got, tt.want)
+			}
+			if tt.max > 0 && len(got)+collisionSuffixBytes > tt.max {
+				t.Errorf("%q with a collision suffix exceeds %d bytes", got, tt.max)
+			}
+			if !utf8.ValidString(got) {
+				t.Errorf("%q is not valid UTF-8", got)
+			}
+		})
+	}
+
+	got := TruncateName(long, 255)
+	if len(got) > 255-collisionSuffixBytes || !strings.HasSuffix(got, ".pdf") {
+		t.Errorf("TruncateName(long, 255) = %q (%d bytes)", got, len(got))
+	}
+}
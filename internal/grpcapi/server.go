@@ -0,0 +1,166 @@
+package grpcapi
+
+import (
+	"context"
+	"docs_organiser/internal/audit"
+	"docs_organiser/internal/pipeline"
+	"docs_organiser/pkg/organiserpb"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// maxUploadBytes bounds the size of documents uploaded through SubmitDocument.
+	maxUploadBytes = 32 << 20
+	// streamBuffer is how many results a slow StreamResults client may lag behind before missing some.
+	streamBuffer = 64
+)
+
+// Server implements the Organiser gRPC service on top of a pipeline.
+type Server struct {
+	organiserpb.UnimplementedOrganiserServer
+
+	pipeline *pipeline.Pipeline
+	grpc     *grpc.Server
+}
+
+func NewServer(p *pipeline.Pipeline) *Server {
+	s := &Server{
+		pipeline: p,
+		grpc:     grpc.NewServer(grpc.MaxRecvMsgSize(maxUploadBytes + 1<<20)),
+	}
+	organiserpb.RegisterOrganiserServer(s.grpc, s)
+	// Reflection lets tools like grpcurl discover the service without the proto file
+	reflection.Register(s.grpc)
+	return s
+}
+
+// Start listens on port and serves until Stop is called.
+func (s *Server) Start(port int) error {
+	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		return err
+	}
+	return s.grpc.Serve(lis)
+}
+
+// Stop finishes in-flight requests and closes the listener.
+func (s *Server) Stop() {
+	s.grpc.GracefulStop()
+}
+
+func (s *Server) SubmitDocument(ctx context.Context, req *organiserpb.SubmitDocumentRequest) (*organiserpb.FileResult, error) {
+	var (
+		rec audit.Record
+		err error
+	)
+
+	switch src := req.Source.(type) {
+	case *organiserpb.SubmitDocumentRequest_Path:
+		rec, err = s.pipeline.SubmitFile(ctx, src.Path)
+	case *organiserpb.SubmitDocumentRequest_Upload:
+		rec, err = s.submitUpload(ctx, src.Upload)
+	default:
+		return nil, status.Error(codes.InvalidArgument, "either path or upload is required")
+	}
+
+	if err != nil {
+		var st interface{ GRPCStatus() *status.Status }
+		if errors.As(err, &st) {
+			return nil, err
+		}
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return toFileResult(rec), nil
+}
+
+// submitUpload stages the uploaded bytes in a temporary directory and processes them from there.
+func (s *Server) submitUpload(ctx context.Context, up *organiserpb.Upload) (audit.Record, error) {
+	name := filepath.Base(up.GetFilename())
+	if name == "." || name == string(filepath.Separator) || name == "" {
+		return audit.Record{}, status.Error(codes.InvalidArgument, "upload filename is required")
+	}
+	if len(up.GetContent()) > maxUploadBytes {
+		return audit.Record{}, status.Errorf(codes.ResourceExhausted, "upload exceeds %d bytes", maxUploadBytes)
+	}
+
+	dir, err := os.MkdirTemp("", "docs_organiser-upload-")
+	if err != nil {
+		return audit.Record{}, status.Errorf(codes.Internal, "failed to stage upload: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, up.GetContent(), 0644); err != nil {
+		return audit.Record{}, status.Errorf(codes.Internal, "failed to stage upload: %v", err)
+	}
+	return s.pipeline.ProcessFile(ctx, path)
+}
+
+func (s *Server) GetStatus(ctx context.Context, req *organiserpb.GetStatusRequest) (*organiserpb.Status, error) {
+	p := s.pipeline
+	return &organiserpb.Status{
+		Paused:         p.IsPaused(),
+		TotalFiles:     atomic.LoadInt32(&p.TotalFiles),
+		ProcessedFiles: atomic.LoadInt32(&p.ProcessedFiles),
+		FailedFiles:    atomic.LoadInt32(&p.FailedFiles),
+		ActiveWorkers:  atomic.LoadInt32(&p.ActiveWorkers),
+	}, nil
+}
+
+func (s *Server) StreamResults(req *organiserpb.StreamResultsRequest, stream grpc.ServerStreamingServer[organiserpb.FileResult]) error {
+	results, cancel := s.pipeline.Subscribe(streamBuffer)
+	defer cancel()
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case rec := <-results:
+			if err := stream.Send(toFileResult(rec)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+var outcomes = map[string]organiserpb.FileResult_Outcome{
+	audit.StatusMoved:            organiserpb.FileResult_OUTCOME_MOVED,
+	audit.StatusExtractionFailed: organiserpb.FileResult_OUTCOME_EXTRACTION_FAILED,
+	audit.StatusMoveFailed:       organiserpb.FileResult_OUTCOME_MOVE_FAILED,
+	audit.StatusCancelled:        organiserpb.FileResult_OUTCOME_CANCELLED,
+}
+
+func toFileResult(rec audit.Record) *organiserpb.FileResult {
+	res := &organiserpb.FileResult{
+		Source:      rec.Source,
+		Outcome:     outcomes[rec.Status],
+		Category:    rec.Category,
+		Title:       rec.Title,
+		Destination: rec.Destination,
+		Fallback:    rec.Fallback,
+		Error:       rec.Error,
+	}
+	if c := rec.Classification; c != nil {
+		if c.Analysis != nil {
+			res.Confidence = c.Analysis.ConfidenceScore
+		}
+		if c.Metadata != nil {
+			res.Model = c.Metadata.Model
+			res.Attempts = int32(c.Metadata.Attempts)
+		}
+	}
+	return res
+}
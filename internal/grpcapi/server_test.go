@@ -0,0 +1,118 @@
+package grpcapi
+
+import (
+	"context"
+	"docs_organiser/internal/pipeline"
+	"docs_organiser/pkg/organiserpb"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func newTestClient(t *testing.T, p *pipeline.Pipeline) organiserpb.OrganiserClient {
+	t.Helper()
+
+	lis := bufconn.Listen(1 << 20)
+	srv := NewServer(p)
+	go srv.grpc.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return organiserpb.NewOrganiserClient(conn)
+}
+
+func TestGetStatus(t *testing.T) {
+	p := pipeline.NewPipeline(t.TempDir(), t.TempDir(), nil, 1, 1000)
+	p.TotalFiles, p.ProcessedFiles, p.FailedFiles = 5, 3, 1
+	p.Pause()
+
+	client := newTestClient(t, p)
+	st, err := client.GetStatus(context.Background(), &organiserpb.GetStatusRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !st.Paused || st.TotalFiles != 5 || st.ProcessedFiles != 3 || st.FailedFiles != 1 {
+		t.Errorf("unexpected status: %+v", st)
+	}
+}
+
+func TestSubmitDocumentValidation(t *testing.T) {
+	src := t.TempDir()
+	outside := filepath.Join(t.TempDir(), "secret.txt")
+	if err := os.WriteFile(outside, []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	client := newTestClient(t, pipeline.NewPipeline(src, t.TempDir(), nil, 1, 1000))
+
+	tests := []struct {
+		name string
+		req  *organiserpb.SubmitDocumentRequest
+		want codes.Code
+	}{
+		{"no source", &organiserpb.SubmitDocumentRequest{}, codes.InvalidArgument},
+		{"missing file", &organiserpb.SubmitDocumentRequest{Source: &organiserpb.SubmitDocumentRequest_Path{Path: filepath.Join(src, "nope.pdf")}}, codes.NotFound},
+		{"outside source", &organiserpb.SubmitDocumentRequest{Source: &organiserpb.SubmitDocumentRequest_Path{Path: outside}}, codes.InvalidArgument},
+		{"upload without name", &organiserpb.SubmitDocumentRequest{Source: &organiserpb.SubmitDocumentRequest_Upload{Upload: &organiserpb.Upload{Content: []byte("x")}}}, codes.InvalidArgument},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := client.SubmitDocument(context.Background(), tt.req)
+			if got := status.Code(err); got != tt.want {
+				t.Errorf("code = %v, want %v (err: %v)", got, tt.want, err)
+			}
+		})
+	}
+}
+
+func TestStreamResultsReceivesSubmissions(t *testing.T) {
+	src := t.TempDir()
+	broken := filepath.Join(src, "broken.pdf")
+	if err := os.WriteFile(broken, []byte("not a pdf"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	client := newTestClient(t, pipeline.NewPipeline(src, t.TempDir(), nil, 1, 1000))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	stream, err := client.StreamResults(ctx, &organiserpb.StreamResultsRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Make sure the subscription is registered before submitting
+	time.Sleep(50 * time.Millisecond)
+
+	res, err := client.SubmitDocument(ctx, &organiserpb.SubmitDocumentRequest{
+		Source: &organiserpb.SubmitDocumentRequest_Path{Path: broken},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Outcome != organiserpb.FileResult_OUTCOME_EXTRACTION_FAILED || res.Error == "" {
+		t.Errorf("unexpected result: %+v", res)
+	}
+
+	streamed, err := stream.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if streamed.Source != res.Source || streamed.Outcome != res.Outcome {
+		t.Errorf("streamed %+v, want %+v", streamed, res)
+	}
+}
@@ -0,0 +1,85 @@
+// Package logging sets how much the standard logger prints: quiet keeps warnings,
+// errors, and run summaries; verbose adds per-file decisions, prompt sizes, and retry
+// reasons.
+package logging
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"os"
+	"sync"
+	"sync/atomic"
+)
+
+// Level selects how much is logged.
+type Level int32
+
+const (
+	// LevelQuiet drops informational ("[*]" and "[+]") lines.
+	LevelQuiet Level = -1
+	// LevelNormal logs what the organiser always has.
+	LevelNormal Level = 0
+	// LevelVerbose adds the lines logged with Verbosef.
+	LevelVerbose Level = 1
+)
+
+var (
+	level      atomic.Int32
+	summaryLog = log.New(os.Stderr, "", log.LstdFlags)
+)
+
+// infoPrefixes mark the lines LevelQuiet drops.
+var infoPrefixes = [][]byte{[]byte("[*]"), []byte("[+]")}
+
+// Setup sets the level and routes the standard logger to w. With clearLine, each line
+// first erases the terminal line, so log output doesn't run into the progress display.
+func Setup(l Level, w io.Writer, clearLine bool) {
+	level.Store(int32(l))
+	mu := new(sync.Mutex)
+	log.SetOutput(&writer{mu: mu, w: w, clearLine: clearLine})
+	summaryLog.SetOutput(&writer{mu: mu, w: w, clearLine: clearLine, summary: true})
+}
+
+// Verbose reports whether verbose lines are logged.
+func Verbose() bool {
+	return Level(level.Load()) >= LevelVerbose
+}
+
+// Verbosef logs like log.Printf, at LevelVerbose only.
+func Verbosef(format string, args ...any) {
+	if Verbose() {
+		log.Printf(format, args...)
+	}
+}
+
+// Summaryf logs like log.Printf at every level, for end-of-run summaries.
+func Summaryf(format string, args ...any) {
+	summaryLog.Printf(format, args...)
+}
+
+// writer applies the level to the standard logger's lines; log writes one line per call.
+type writer struct {
+	mu        *sync.Mutex // shared by the standard and summary loggers
+	w         io.Writer
+	clearLine bool
+	summary   bool
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	if Level(level.Load()) <= LevelQuiet && !w.summary {
+		for _, prefix := range infoPrefixes {
+			if bytes.Contains(p, prefix) {
+				return len(p), nil
+			}
+		}
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.clearLine {
+		if _, err := io.WriteString(w.w, "\r\x1b[K"); err != nil {
+			return 0, err
+		}
+	}
+	return w.w.Write(p)
+}
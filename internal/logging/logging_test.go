@@ -0,0 +1,54 @@
+package logging
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestLevels(t *testing.T) {
+	defer Setup(LevelNormal, os.Stderr, false)
+	flags := log.Flags()
+	defer log.SetFlags(flags)
+	log.SetFlags(0)
+
+	tests := []struct {
+		name  string
+		level Level
+		want  []string
+	}{
+		{"quiet", LevelQuiet, []string{"[!] warning", "Fatal-style message", "[+] Run finished"}},
+		{"normal", LevelNormal, []string{"[*] info", "[+] done", "[!] warning", "Fatal-style message", "[+] Run finished"}},
+		{"verbose", LevelVerbose, []string{"[*] info", "[+] done", "[!] warning", "Fatal-style message", "[*] prompt size", "[+] Run finished"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			Setup(tt.level, &buf, false)
+			summaryLog.SetFlags(0)
+			log.Print("[*] info")
+			log.Print("[+] done")
+			log.Print("[!] warning")
+			log.Print("Fatal-style message")
+			Verbosef("[*] prompt size")
+			Summaryf("[+] Run finished")
+
+			got := strings.Split(strings.TrimSpace(buf.String()), "\n")
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("logged %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClearLine(t *testing.T) {
+	defer Setup(LevelNormal, os.Stderr, false)
+	var buf bytes.Buffer
+	Setup(LevelNormal, &buf, true)
+	log.Print("[*] info")
+	if !strings.HasPrefix(buf.String(), "\r\x1b[K") {
+		t.Errorf("line %q does not clear the progress line first", buf.String())
+	}
+}
@@ -0,0 +1,59 @@
+package logging
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRotatingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "organiser.log")
+	r, err := OpenRotating(path, 20, 2)
+	if err != nil {
+		t.Fatalf("OpenRotating: %v", err)
+	}
+	defer r.Close()
+
+	// Each line is 10 bytes, so every file holds two
+	for _, line := range []string{"line 0001\n", "line 0002\n", "line 0003\n", "line 0004\n", "line 0005\n", "line 0006\n", "line 0007\n"} {
+		if _, err := r.Write([]byte(line)); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+
+	for file, want := range map[string]string{
+		path:        "line 0007\n",
+		path + ".1": "line 0005\nline 0006\n",
+		path + ".2": "line 0003\nline 0004\n",
+	} {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("ReadFile: %v", err)
+		}
+		if string(data) != want {
+			t.Errorf("%s = %q, want %q", filepath.Base(file), data, want)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("kept more than 2 backups")
+	}
+}
+
+func TestRotatingFile_Reopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "organiser.log")
+	if err := os.WriteFile(path, []byte(strings.Repeat("x", 15)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	r, err := OpenRotating(path, 20, 1)
+	if err != nil {
+		t.Fatalf("OpenRotating: %v", err)
+	}
+	defer r.Close()
+	if _, err := r.Write([]byte("0123456789")); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "0123456789" {
+		t.Errorf("existing size was not counted; file holds %q", data)
+	}
+}
@@ -0,0 +1,437 @@
+package mailbox
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/emersion/go-imap"
+	"github.com/emersion/go-imap/client"
+)
+
+// ProcessedKeyword flags messages whose attachments have all been organised.
+// Servers that do not allow custom keywords fall back to the \Seen flag.
+const ProcessedKeyword = "$DocsOrganised"
+
+// Options configure the IMAP account and which attachments are ingested.
+type Options struct {
+	Addr     string // host:port; port 993 (or 143 without TLS) is assumed when omitted
+	Security string // "tls" (default), "starttls", or "none"
+	Username string
+	Password string
+	Folder   string // mailbox to read, default INBOX
+
+	// ProcessedFolder, when set, receives processed messages instead of them being flagged.
+	ProcessedFolder string
+
+	// From and Subject select messages by case-insensitive substring; empty matches all.
+	From    []string
+	Subject string
+
+	// Extensions lists the attachment types to ingest (e.g. ".pdf"); other attachments are ignored.
+	Extensions []string
+
+	// MaxSize skips messages larger than this many bytes (0 = unlimited).
+	MaxSize int64
+}
+
+// Validate reports missing or unsupported settings.
+func (o Options) Validate() error {
+	if o.Addr == "" {
+		return fmt.Errorf("imap_addr is required")
+	}
+	if o.Username == "" {
+		return fmt.Errorf("imap_username is required")
+	}
+	switch o.Security {
+	case "", "tls", "starttls", "none":
+	default:
+		return fmt.Errorf("unknown imap_security %q (expected tls, starttls, or none)", o.Security)
+	}
+	if len(o.Extensions) == 0 {
+		return fmt.Errorf("no attachment extensions to ingest")
+	}
+	return nil
+}
+
+// Handler processes one attachment saved at path. Returning an error leaves the
+// message unmarked so it is retried on the next poll.
+type Handler func(ctx context.Context, path string) error
+
+// Result counts what one Ingest pass did.
+type Result struct {
+	Messages    int // messages marked as processed
+	Attachments int // attachments handled successfully
+	Failed      int // attachments whose handler failed
+}
+
+// Ingest saves the matching attachments of every unprocessed message in the folder,
+// passes each to handle, and marks a message processed once all of its attachments succeed.
+func Ingest(ctx context.Context, opts Options, handle Handler) (Result, error) {
+	var res Result
+
+	c, err := dial(opts)
+	if err != nil {
+		return res, err
+	}
+	defer c.Logout()
+
+	// The client has no context support, so cancellation closes the connection
+	stop := context.AfterFunc(ctx, func() { c.Terminate() })
+	defer stop()
+
+	if err := c.Login(opts.Username, opts.Password); err != nil {
+		return res, fmt.Errorf("imap login failed: %w", err)
+	}
+
+	folder := opts.Folder
+	if folder == "" {
+		folder = "INBOX"
+	}
+	status, err := c.Select(folder, false)
+	if err != nil {
+		return res, fmt.Errorf("failed to open mailbox %s: %w", folder, err)
+	}
+
+	marker := imap.SeenFlag
+	for _, f := range status.PermanentFlags {
+		if f == `\*` {
+			marker = ProcessedKeyword
+			break
+		}
+	}
+
+	criteria := imap.NewSearchCriteria()
+	criteria.WithoutFlags = []string{marker}
+	uids, err := c.UidSearch(criteria)
+	if err != nil {
+		return res, fmt.Errorf("failed to search %s: %w", folder, err)
+	}
+	if len(uids) == 0 {
+		return res, nil
+	}
+
+	candidates, err := fetchEnvelopes(c, uids)
+	if err != nil {
+		return res, err
+	}
+
+	for _, msg := range candidates {
+		if ctx.Err() != nil {
+			return res, ctx.Err()
+		}
+		if !opts.matches(msg.Envelope) {
+			continue
+		}
+		subject := msg.Envelope.Subject
+		if opts.MaxSize > 0 && int64(msg.Size) > opts.MaxSize {
+			log.Printf("[!] Skipping message %q: %d bytes exceeds the size limit", subject, msg.Size)
+			continue
+		}
+
+		handled, failed, err := ingestMessage(ctx, c, msg.Uid, opts, handle)
+		res.Attachments += handled
+		res.Failed += failed
+		if err != nil {
+			log.Printf("[!] Failed to read message %q: %v", subject, err)
+			continue
+		}
+		if failed > 0 {
+			log.Printf("[!] %d attachment(s) of %q failed; the message will be retried", failed, subject)
+			continue
+		}
+
+		if err := markProcessed(c, msg.Uid, opts.ProcessedFolder, marker); err != nil {
+			return res, fmt.Errorf("failed to mark message %q processed: %w", subject, err)
+		}
+		res.Messages++
+	}
+	return res, nil
+}
+
+func dial(opts Options) (*client.Client, error) {
+	addr := opts.Addr
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		port := "993"
+		if opts.Security == "starttls" || opts.Security == "none" {
+			port = "143"
+		}
+		addr = net.JoinHostPort(addr, port)
+	}
+	host, _, _ := net.SplitHostPort(addr)
+	tlsConfig := &tls.Config{ServerName: host}
+
+	var c *client.Client
+	var err error
+	switch opts.Security {
+	case "starttls":
+		if c, err = client.Dial(addr); err == nil {
+			if err = c.StartTLS(tlsConfig); err != nil {
+				c.Logout()
+			}
+		}
+	case "none":
+		c, err = client.Dial(addr)
+	default:
+		c, err = client.DialTLS(addr, tlsConfig)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", addr, err)
+	}
+	return c, nil
+}
+
+// fetchEnvelopes returns the envelope and size of each message, oldest first.
+func fetchEnvelopes(c *client.Client, uids []uint32) ([]*imap.Message, error) {
+	seq := new(imap.SeqSet)
+	seq.AddNum(uids...)
+
+	ch := make(chan *imap.Message, 16)
+	done := make(chan error, 1)
+	go func() {
+		done <- c.UidFetch(seq, []imap.FetchItem{imap.FetchUid, imap.FetchEnvelope, imap.FetchRFC822Size}, ch)
+	}()
+
+	var msgs []*imap.Message
+	for msg := range ch {
+		if msg.Envelope != nil {
+			msgs = append(msgs, msg)
+		}
+	}
+	if err := <-done; err != nil {
+		return nil, fmt.Errorf("failed to fetch messages: %w", err)
+	}
+	sort.Slice(msgs, func(i, j int) bool { return msgs[i].Uid < msgs[j].Uid })
+	return msgs, nil
+}
+
+func (o Options) matches(env *imap.Envelope) bool {
+	if o.Subject != "" && !strings.Contains(strings.ToLower(env.Subject), strings.ToLower(o.Subject)) {
+		return false
+	}
+	if len(o.From) == 0 {
+		return true
+	}
+	for _, addr := range env.From {
+		from := strings.ToLower(addr.PersonalName + " <" + addr.Address() + ">")
+		for _, f := range o.From {
+			if strings.Contains(from, strings.ToLower(f)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ingestMessage downloads one message, saves its matching attachments to a staging
+// directory, and hands them to handle.
+func ingestMessage(ctx context.Context, c *client.Client, uid uint32, opts Options, handle Handler) (handled, failed int, err error) {
+	seq := new(imap.SeqSet)
+	seq.AddNum(uid)
+	section := &imap.BodySectionName{Peek: true}
+
+	ch := make(chan *imap.Message, 1)
+	done := make(chan error, 1)
+	go func() {
+		done <- c.UidFetch(seq, []imap.FetchItem{section.FetchItem()}, ch)
+	}()
+	var body imap.Literal
+	for msg := range ch {
+		body = msg.GetBody(section)
+	}
+	if err := <-done; err != nil {
+		return 0, 0, err
+	}
+	if body == nil {
+		return 0, 0, fmt.Errorf("server returned no message body")
+	}
+
+	dir, err := os.MkdirTemp("", "docs-organiser-imap-*")
+	if err != nil {
+		return 0, 0, err
+	}
+	defer os.RemoveAll(dir)
+
+	paths, err := SaveAttachments(body, dir, opts.Extensions)
+	if err != nil {
+		return 0, 0, err
+	}
+	for _, p := range paths {
+		if err := handle(ctx, p); err != nil {
+			log.Printf("[!] Failed to organise attachment %s: %v", filepath.Base(p), err)
+			failed++
+		} else {
+			handled++
+		}
+	}
+	return handled, failed, nil
+}
+
+func markProcessed(c *client.Client, uid uint32, processedFolder, marker string) error {
+	seq := new(imap.SeqSet)
+	seq.AddNum(uid)
+	if processedFolder != "" {
+		return c.UidMove(seq, processedFolder)
+	}
+	flags := []interface{}{imap.SeenFlag}
+	if marker != imap.SeenFlag {
+		flags = append(flags, marker)
+	}
+	return c.UidStore(seq, imap.FormatFlagsOp(imap.AddFlags, true), flags, nil)
+}
+
+// SaveAttachments writes the attachments of the RFC 5322 message in r whose extension
+// is in extensions to dir, returning their paths. Forwarded messages are searched too.
+func SaveAttachments(r io.Reader, dir string, extensions []string) ([]string, error) {
+	msg, err := mail.ReadMessage(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse message: %w", err)
+	}
+
+	var paths []string
+	used := make(map[string]bool)
+	err = walkPart(textproto.MIMEHeader(msg.Header), msg.Body, func(name string, body io.Reader) error {
+		if !hasExtension(name, extensions) {
+			return nil
+		}
+		// Keep duplicate names within one message apart
+		target := name
+		for i := 2; used[strings.ToLower(target)]; i++ {
+			target = fmt.Sprintf("%d_%s", i, name)
+		}
+		used[strings.ToLower(target)] = true
+
+		p := filepath.Join(dir, target)
+		f, err := os.Create(p)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(f, body); err != nil {
+			f.Close()
+			return fmt.Errorf("failed to save attachment %s: %w", name, err)
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+		paths = append(paths, p)
+		return nil
+	})
+	return paths, err
+}
+
+// walkPart visits every named leaf part below a MIME entity with its decoded body.
+func walkPart(h textproto.MIMEHeader, body io.Reader, visit func(name string, body io.Reader) error) error {
+	mediaType, params, _ := mime.ParseMediaType(h.Get("Content-Type"))
+
+	switch {
+	case strings.HasPrefix(mediaType, "multipart/"):
+		mr := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := mr.NextRawPart()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("malformed multipart body: %w", err)
+			}
+			if err := walkPart(part.Header, part, visit); err != nil {
+				return err
+			}
+		}
+	case mediaType == "message/rfc822":
+		inner, err := mail.ReadMessage(decodeBody(h, body))
+		if err != nil {
+			return nil // an unreadable forwarded message has no usable attachments
+		}
+		return walkPart(textproto.MIMEHeader(inner.Header), inner.Body, visit)
+	}
+
+	name := attachmentName(h, params)
+	if name == "" {
+		return nil
+	}
+	return visit(name, decodeBody(h, body))
+}
+
+// attachmentName returns the safe base name of a part's file, or "" for inline text.
+func attachmentName(h textproto.MIMEHeader, typeParams map[string]string) string {
+	name := ""
+	if _, params, err := mime.ParseMediaType(h.Get("Content-Disposition")); err == nil {
+		name = params["filename"]
+	}
+	if name == "" {
+		name = typeParams["name"]
+	}
+
+	// Older clients send RFC 2047 encoded words instead of RFC 2231 parameters
+	dec := mime.WordDecoder{}
+	if decoded, err := dec.DecodeHeader(name); err == nil {
+		name = decoded
+	}
+
+	name = path.Base(strings.ReplaceAll(name, `\`, "/"))
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f {
+			return -1
+		}
+		return r
+	}, name)
+	if name == "." || name == "/" || name == ".." {
+		return ""
+	}
+	return strings.TrimSpace(name)
+}
+
+func decodeBody(h textproto.MIMEHeader, body io.Reader) io.Reader {
+	switch strings.ToLower(strings.TrimSpace(h.Get("Content-Transfer-Encoding"))) {
+	case "base64":
+		return base64.NewDecoder(base64.StdEncoding, &newlineStripper{r: body})
+	case "quoted-printable":
+		return quotedprintable.NewReader(body)
+	}
+	return body
+}
+
+func hasExtension(name string, extensions []string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	for _, e := range extensions {
+		if ext == "."+strings.ToLower(strings.TrimPrefix(e, ".")) {
+			return true
+		}
+	}
+	return false
+}
+
+// newlineStripper drops the whitespace some mailers put inside base64 bodies;
+// encoding/base64 only skips line breaks.
+type newlineStripper struct {
+	r io.Reader
+}
+
+func (s *newlineStripper) Read(p []byte) (int, error) {
+	n, err := s.r.Read(p)
+	out := p[:0]
+	for _, b := range p[:n] {
+		if b != '\r' && b != '\n' && b != ' ' && b != '\t' {
+			out = append(out, b)
+		}
+	}
+	if len(out) == 0 && err == nil && n > 0 {
+		return s.Read(p)
+	}
+	return len(out), err
+}
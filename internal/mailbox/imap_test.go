@@ -0,0 +1,263 @@
+package mailbox
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/emersion/go-imap"
+	"github.com/emersion/go-imap/backend"
+	"github.com/emersion/go-imap/backend/memory"
+	"github.com/emersion/go-imap/server"
+)
+
+const invoiceMessage = "From: Billing <billing@vendor.com>\r\n" +
+	"To: me@example.com\r\n" +
+	"Subject: Your invoice\r\n" +
+	"MIME-Version: 1.0\r\n" +
+	"Content-Type: multipart/mixed; boundary=outer\r\n" +
+	"\r\n" +
+	"--outer\r\n" +
+	"Content-Type: text/plain\r\n" +
+	"\r\n" +
+	"Please find your invoice attached.\r\n" +
+	"--outer\r\n" +
+	"Content-Type: application/pdf; name=\"invoice.pdf\"\r\n" +
+	"Content-Disposition: attachment; filename=\"invoice.pdf\"\r\n" +
+	"Content-Transfer-Encoding: base64\r\n" +
+	"\r\n" +
+	"JVBERi0xLjQK\r\n" +
+	"aW52b2ljZQ==\r\n" +
+	"--outer\r\n" +
+	"Content-Type: image/png\r\n" +
+	"Content-Disposition: inline; filename=\"logo.png\"\r\n" +
+	"Content-Transfer-Encoding: base64\r\n" +
+	"\r\n" +
+	"iVBORw0KGgo=\r\n" +
+	"--outer--\r\n"
+
+func TestSaveAttachments(t *testing.T) {
+	forwarded := "From: me@example.com\r\n" +
+		"Subject: Fwd: receipts\r\n" +
+		"Content-Type: multipart/mixed; boundary=a\r\n" +
+		"\r\n" +
+		"--a\r\n" +
+		"Content-Type: application/pdf\r\n" +
+		"Content-Disposition: attachment; filename=\"=?UTF-8?Q?Re=C3=A7u.pdf?=\"\r\n" +
+		"Content-Transfer-Encoding: quoted-printable\r\n" +
+		"\r\n" +
+		"receipt=3D1\r\n" +
+		"--a\r\n" +
+		"Content-Type: message/rfc822\r\n" +
+		"\r\n" +
+		"From: shop@example.com\r\n" +
+		"Content-Type: multipart/mixed; boundary=b\r\n" +
+		"\r\n" +
+		"--b\r\n" +
+		"Content-Type: application/pdf; name=\"../../etc/passwd.pdf\"\r\n" +
+		"\r\n" +
+		"inner\r\n" +
+		"--b\r\n" +
+		"Content-Type: application/pdf\r\n" +
+		"Content-Disposition: attachment; filename*=UTF-8''Re%C3%A7u.pdf\r\n" +
+		"\r\n" +
+		"second\r\n" +
+		"--b--\r\n" +
+		"--a--\r\n"
+
+	tests := []struct {
+		name    string
+		message string
+		want    map[string]string
+	}{
+		{"base64 attachment, other types skipped", invoiceMessage, map[string]string{"invoice.pdf": "%PDF-1.4\ninvoice"}},
+		{"encoded names, forwarded message, traversal", forwarded, map[string]string{
+			"Reçu.pdf":   "receipt=1",
+			"passwd.pdf": "inner",
+			"2_Reçu.pdf": "second",
+		}},
+		{"no attachments", "From: a@b.c\r\nSubject: hi\r\n\r\nhello\r\n", map[string]string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			paths, err := SaveAttachments(strings.NewReader(tt.message), dir, []string{"pdf"})
+			if err != nil {
+				t.Fatalf("SaveAttachments: %v", err)
+			}
+			got := make(map[string]string)
+			for _, p := range paths {
+				if filepath.Dir(p) != dir {
+					t.Errorf("attachment %s saved outside the staging directory", p)
+				}
+				data, _ := os.ReadFile(p)
+				got[filepath.Base(p)] = strings.TrimRight(string(data), "\r\n")
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for name, content := range tt.want {
+				if got[name] != content {
+					t.Errorf("%s = %q, want %q", name, got[name], content)
+				}
+			}
+		})
+	}
+}
+
+// startServer runs an in-memory IMAP server holding the given messages in INBOX.
+func startServer(t *testing.T, messages ...string) (string, *memory.Backend) {
+	t.Helper()
+	be := memory.New()
+	user, _ := be.Login(nil, "username", "password")
+	inbox, _ := user.GetMailbox("INBOX")
+	for _, m := range messages {
+		if err := inbox.CreateMessage(nil, time.Now(), bytes.NewBufferString(m)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := user.CreateMailbox("Processed"); err != nil {
+		t.Fatal(err)
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := server.New(movingBackend{be})
+	s.AllowInsecureAuth = true
+	go s.Serve(ln)
+	t.Cleanup(func() { s.Close() })
+	return ln.Addr().String(), be
+}
+
+// movingBackend adds MOVE support, which the memory backend lacks but the server advertises.
+type movingBackend struct{ *memory.Backend }
+
+type movingUser struct{ backend.User }
+
+type movingMailbox struct{ backend.Mailbox }
+
+func (b movingBackend) Login(info *imap.ConnInfo, username, password string) (backend.User, error) {
+	u, err := b.Backend.Login(info, username, password)
+	return movingUser{u}, err
+}
+
+func (u movingUser) GetMailbox(name string) (backend.Mailbox, error) {
+	m, err := u.User.GetMailbox(name)
+	return movingMailbox{m}, err
+}
+
+func (m movingMailbox) MoveMessages(uid bool, seqset *imap.SeqSet, dest string) error {
+	if err := m.CopyMessages(uid, seqset, dest); err != nil {
+		return err
+	}
+	if err := m.UpdateMessagesFlags(uid, seqset, imap.AddFlags, []string{imap.DeletedFlag}); err != nil {
+		return err
+	}
+	return m.Expunge()
+}
+
+func flagsOf(t *testing.T, be *memory.Backend, mailbox string) [][]string {
+	t.Helper()
+	user, _ := be.Login(nil, "username", "password")
+	mbox, err := user.GetMailbox(mailbox)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var flags [][]string
+	for _, m := range mbox.(*memory.Mailbox).Messages {
+		f := append([]string(nil), m.Flags...)
+		sort.Strings(f)
+		flags = append(flags, f)
+	}
+	return flags
+}
+
+func TestIngest(t *testing.T) {
+	other := strings.Replace(invoiceMessage, "billing@vendor.com", "friend@example.com", 1)
+	addr, be := startServer(t, invoiceMessage, other)
+	opts := Options{Addr: addr, Security: "none", Username: "username", Password: "password",
+		From: []string{"@vendor.com"}, Extensions: []string{".pdf"}}
+
+	// A failing handler leaves the message for the next poll
+	res, err := Ingest(context.Background(), opts, func(ctx context.Context, path string) error {
+		return errors.New("model unavailable")
+	})
+	if err != nil || res.Messages != 0 || res.Failed != 1 {
+		t.Fatalf("failing Ingest = %+v, %v", res, err)
+	}
+
+	var handled []string
+	res, err = Ingest(context.Background(), opts, func(ctx context.Context, path string) error {
+		data, err := os.ReadFile(path)
+		handled = append(handled, filepath.Base(path)+"="+string(data))
+		return err
+	})
+	if err != nil {
+		t.Fatalf("Ingest: %v", err)
+	}
+	if res.Messages != 1 || res.Attachments != 1 || len(handled) != 1 || handled[0] != "invoice.pdf=%PDF-1.4\ninvoice" {
+		t.Fatalf("Ingest = %+v, handled %q", res, handled)
+	}
+
+	// The default mailbox keeps the seed message; ours follow it
+	flags := flagsOf(t, be, "INBOX")
+	if want := []string{ProcessedKeyword, imap.SeenFlag}; !strings.EqualFold(strings.Join(flags[1], ","), strings.Join(want, ",")) {
+		t.Errorf("processed message flags = %v, want %v", flags[1], want)
+	}
+	if len(flags[2]) != 0 {
+		t.Errorf("unmatched message was flagged: %v", flags[2])
+	}
+
+	// Processed messages are not picked up again
+	res, err = Ingest(context.Background(), opts, func(ctx context.Context, path string) error {
+		t.Errorf("handled %s twice", path)
+		return nil
+	})
+	if err != nil || res.Messages != 0 {
+		t.Errorf("second Ingest = %+v, %v", res, err)
+	}
+}
+
+func TestIngestMovesToProcessedFolder(t *testing.T) {
+	addr, be := startServer(t, invoiceMessage)
+	opts := Options{Addr: addr, Security: "none", Username: "username", Password: "password",
+		Subject: "invoice", ProcessedFolder: "Processed", Extensions: []string{".pdf"}}
+
+	res, err := Ingest(context.Background(), opts, func(ctx context.Context, path string) error { return nil })
+	if err != nil || res.Messages != 1 {
+		t.Fatalf("Ingest = %+v, %v", res, err)
+	}
+	if n := len(flagsOf(t, be, "INBOX")); n != 1 {
+		t.Errorf("INBOX has %d messages, want only the seed message", n)
+	}
+	if n := len(flagsOf(t, be, "Processed")); n != 1 {
+		t.Errorf("Processed has %d messages, want 1", n)
+	}
+}
+
+func TestOptionsValidate(t *testing.T) {
+	valid := Options{Addr: "imap.example.com", Username: "me", Extensions: []string{".pdf"}}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("valid options rejected: %v", err)
+	}
+	for _, opts := range []Options{
+		{Username: "me", Extensions: []string{".pdf"}},
+		{Addr: "imap.example.com", Extensions: []string{".pdf"}},
+		{Addr: "imap.example.com", Username: "me", Security: "ssl", Extensions: []string{".pdf"}},
+		{Addr: "imap.example.com", Username: "me"},
+	} {
+		if err := opts.Validate(); err == nil {
+			t.Errorf("Validate(%+v) succeeded, want error", opts)
+		}
+	}
+}
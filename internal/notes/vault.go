@@ -0,0 +1,181 @@
+package notes
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// Note describes an organised document for its knowledge-base entry.
+type Note struct {
+	Title      string
+	Category   string
+	Tags       []string
+	Summary    string
+	File       string // final location: a local path or a remote URL
+	Source     string // where the document was found
+	Confidence float64
+	Created    time.Time
+	// Invoice, when set, holds the billing details of a receipt or invoice (see ai.Invoice).
+	Invoice any
+}
+
+// frontmatter is the YAML header of a note, in the order it is written.
+type frontmatter struct {
+	Title      string   `yaml:"title"`
+	Category   string   `yaml:"category"`
+	Tags       []string `yaml:"tags,omitempty"`
+	Summary    string   `yaml:"summary,omitempty"`
+	File       string   `yaml:"file"`
+	Source     string   `yaml:"source,omitempty"`
+	Confidence float64  `yaml:"confidence"`
+	Created    string   `yaml:"created"`
+	Invoice    any      `yaml:"invoice,omitempty"`
+}
+
+// Vault writes one markdown note per organised document into Dir, mirroring the
+// category folders, so the vault works as an index in Obsidian or any markdown tool.
+type Vault struct {
+	Dir string
+
+	mu sync.Mutex
+}
+
+// Write creates or refreshes the note for n.File and returns the note's path.
+func (v *Vault) Write(n Note) (string, error) {
+	fm := frontmatter{
+		Title:      n.Title,
+		Category:   n.Category,
+		Tags:       obsidianTags(n.Tags),
+		Summary:    n.Summary,
+		File:       n.File,
+		Source:     n.Source,
+		Confidence: n.Confidence,
+		Created:    n.Created.Format(time.RFC3339),
+		Invoice:    n.Invoice,
+	}
+	header, err := yaml.Marshal(fm)
+	if err != nil {
+		return "", err
+	}
+
+	var b bytes.Buffer
+	b.WriteString("---\n")
+	b.Write(header)
+	b.WriteString("---\n\n# " + n.Title + "\n\n")
+	if n.Summary != "" {
+		b.WriteString(n.Summary + "\n\n")
+	}
+	b.WriteString("[Open document](" + fileLink(n.File) + ")\n")
+
+	dir := filepath.Join(v.Dir, filepath.FromSlash(n.Category))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create note folder: %w", err)
+	}
+
+	// Serialize so two workers can't claim the same note name
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	path := notePath(dir, n.File)
+	if err := os.WriteFile(path, b.Bytes(), 0644); err != nil {
+		return "", fmt.Errorf("failed to write note: %w", err)
+	}
+	return path, nil
+}
+
+// notePath picks the note for file in dir: the document's base name, or a variant of it
+// when that note already describes a different document.
+func notePath(dir, file string) string {
+	base := file
+	if u, err := url.Parse(file); err == nil && len(u.Scheme) > 1 {
+		base = u.Path
+	}
+	name := filepath.Base(filepath.FromSlash(base))
+	ext := filepath.Ext(name)
+	stem := strings.TrimSuffix(name, ext)
+
+	candidates := []string{stem}
+	if ext != "" {
+		candidates = append(candidates, fmt.Sprintf("%s (%s)", stem, strings.TrimPrefix(ext, ".")))
+	}
+	for i := 0; ; i++ {
+		name := fmt.Sprintf("%s %d", stem, i-len(candidates)+2)
+		if i < len(candidates) {
+			name = candidates[i]
+		}
+		p := filepath.Join(dir, name+".md")
+		if owner, exists := noteFile(p); !exists || owner == file {
+			return p
+		}
+	}
+}
+
+// noteFile returns the file an existing note links to; ok is false when no note exists.
+func noteFile(path string) (file string, ok bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", false
+	}
+	rest, found := strings.CutPrefix(string(data), "---\n")
+	if !found {
+		return "", true
+	}
+	header, _, _ := strings.Cut(rest, "\n---\n")
+	var fm frontmatter
+	if err := yaml.Unmarshal([]byte(header), &fm); err != nil {
+		return "", true
+	}
+	return fm.File, true
+}
+
+// fileLink turns a local path into a file:// URL markdown tools can open; remote URLs are kept.
+func fileLink(file string) string {
+	if u, err := url.Parse(file); err == nil && len(u.Scheme) > 1 {
+		return file
+	}
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		abs = file
+	}
+	abs = filepath.ToSlash(abs)
+	if !strings.HasPrefix(abs, "/") {
+		abs = "/" + abs // Windows drive paths
+	}
+	return (&url.URL{Scheme: "file", Path: abs}).String()
+}
+
+// obsidianTags rewrites tags into the form Obsidian accepts: no spaces or punctuation
+// other than "-", "_", and "/", and not purely numeric.
+func obsidianTags(tags []string) []string {
+	var out []string
+	seen := make(map[string]bool)
+	for _, t := range tags {
+		t = strings.Map(func(r rune) rune {
+			switch {
+			case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_' || r == '/':
+				return r
+			case unicode.IsSpace(r):
+				return '-'
+			}
+			return -1
+		}, strings.TrimSpace(t))
+		t = strings.Trim(t, "-/")
+		if t == "" || strings.IndexFunc(t, func(r rune) bool { return !unicode.IsDigit(r) }) == -1 {
+			continue
+		}
+		if key := strings.ToLower(t); !seen[key] {
+			seen[key] = true
+			out = append(out, t)
+		}
+	}
+	return out
+}
@@ -0,0 +1,76 @@
+package notes
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVaultWrite(t *testing.T) {
+	v := &Vault{Dir: t.TempDir()}
+	created := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	path, err := v.Write(Note{
+		Title:      "ACME Invoice: March",
+		Category:   "Finance/Invoices",
+		Tags:       []string{"invoice", "acme corp", "2024", "q1!"},
+		Summary:    "Invoice #42 from ACME.",
+		File:       "/docs/Finance/Invoices/ACME Invoice.pdf",
+		Source:     "/inbox/scan.pdf",
+		Confidence: 0.9,
+		Created:    created,
+	})
+	if err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if want := filepath.Join(v.Dir, "Finance", "Invoices", "ACME Invoice.md"); path != want {
+		t.Errorf("path = %s, want %s", path, want)
+	}
+
+	data, _ := os.ReadFile(path)
+	got := string(data)
+	for _, want := range []string{
+		"---\ntitle: 'ACME Invoice: March'\ncategory: Finance/Invoices\n",
+		"tags:\n    - invoice\n    - acme-corp\n    - q1\n",
+		"summary: 'Invoice #42 from ACME.'\n",
+		"file: /docs/Finance/Invoices/ACME Invoice.pdf\n",
+		"created: \"2024-03-01T12:00:00Z\"\n---\n",
+		"# ACME Invoice: March\n\nInvoice #42 from ACME.\n",
+		"[Open document](file:///docs/Finance/Invoices/ACME%20Invoice.pdf)\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("note missing %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestVaultNoteNames(t *testing.T) {
+	v := &Vault{Dir: t.TempDir()}
+	write := func(file string) string {
+		t.Helper()
+		path, err := v.Write(Note{Title: "Report", Category: "Work", File: file})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return filepath.Base(path)
+	}
+
+	tests := []struct {
+		file string
+		want string
+	}{
+		{"/docs/Work/Report.pdf", "Report.md"},
+		{"/docs/Work/Report.txt", "Report (txt).md"},
+		{"/docs/Work/Report.pdf", "Report.md"}, // rewriting the same document reuses its note
+		{"/other/Work/Report.txt", "Report 2.md"},
+		{"s3://bucket/Work/Report.md", "Report (md).md"},
+		{"/third/Work/Report.txt", "Report 3.md"},
+	}
+	for _, tt := range tests {
+		if got := write(tt.file); got != tt.want {
+			t.Errorf("note for %s = %s, want %s", tt.file, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// DefaultHookTimeout bounds a hook command when Hook.Timeout is not set.
+const DefaultHookTimeout = 10 * time.Minute
+
+// hookOutputLimit is how much of a failed hook's output its error quotes.
+const hookOutputLimit = 500
+
+// Hook runs a command before or after each run, to slot the organiser into backup or
+// notification workflows. The command is run without a shell: Command[0] is the program
+// and the rest its arguments ("sh", "-c", "..." for shell syntax).
+type Hook struct {
+	Command []string
+	Timeout time.Duration
+}
+
+// Run runs the command, writing input to its stdin as JSON when not nil. It fails when
+// the command can't start, exits non-zero, or outlives its timeout; the error quotes the
+// end of the command's output.
+func (h Hook) Run(ctx context.Context, input any) error {
+	if len(h.Command) == 0 {
+		return fmt.Errorf("no hook command")
+	}
+	timeout := h.Timeout
+	if timeout <= 0 {
+		timeout = DefaultHookTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, h.Command[0], h.Command[1:]...)
+	if input != nil {
+		body, err := json.Marshal(input)
+		if err != nil {
+			return err
+		}
+		cmd.Stdin = bytes.NewReader(body)
+	}
+	var output bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &output, &output
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			err = fmt.Errorf("timed out after %s", timeout)
+		}
+		msg := strings.TrimSpace(output.String())
+		if len(msg) > hookOutputLimit {
+			msg = "..." + msg[len(msg)-hookOutputLimit:]
+		}
+		if msg != "" {
+			return fmt.Errorf("%s: %w: %s", h.Command[0], err, msg)
+		}
+		return fmt.Errorf("%s: %w", h.Command[0], err)
+	}
+	return nil
+}
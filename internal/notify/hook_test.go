@@ -0,0 +1,49 @@
+package notify
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHook(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "stdin.json")
+	tests := []struct {
+		name    string
+		hook    Hook
+		input   any
+		wantErr string
+	}{
+		{name: "input on stdin", hook: Hook{Command: []string{"sh", "-c", `cat > "$0"`, out}}, input: Event{Type: EventRunCompleted}},
+		{name: "no input", hook: Hook{Command: []string{"true"}}},
+		{name: "exit status", hook: Hook{Command: []string{"sh", "-c", "echo volume not mounted >&2; exit 3"}}, wantErr: "exit status 3: volume not mounted"},
+		{name: "missing program", hook: Hook{Command: []string{"/nonexistent/hook"}}, wantErr: "/nonexistent/hook"},
+		{name: "timeout", hook: Hook{Command: []string{"sleep", "5"}, Timeout: 50 * time.Millisecond}, wantErr: "timed out after 50ms"},
+		{name: "empty", hook: Hook{}, wantErr: "no hook command"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.hook.Run(context.Background(), tt.input)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Run: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Run error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"event":"run_completed"`) {
+		t.Errorf("hook stdin = %s", data)
+	}
+}
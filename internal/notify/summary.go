@@ -0,0 +1,188 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/smtp"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// discordMessageLimit is the maximum length of a Discord message.
+const discordMessageLimit = 2000
+
+// Channel delivers end-of-run summaries to a chat service or mailbox.
+type Channel interface {
+	Name() string
+	SendSummary(ctx context.Context, s RunSummary) error
+}
+
+// FormatSummary renders s as plain text suitable for chat messages and email bodies.
+func FormatSummary(s RunSummary) string {
+	var b strings.Builder
+
+	status := "finished"
+	if s.Cancelled {
+		status = "was cancelled"
+	}
+	fmt.Fprintf(&b, "Docs Organiser run %s after %s\n", status, s.Duration.Round(time.Second))
+	fmt.Fprintf(&b, "Source: %s\nDestination: %s\n", s.Source, s.Dest)
+	fmt.Fprintf(&b, "Organised %d of %d files, %d failed\n", s.Processed, s.Total, s.Failed)
+
+	if len(s.Categories) > 0 {
+		names := make([]string, 0, len(s.Categories))
+		for name := range s.Categories {
+			names = append(names, name)
+		}
+		sort.Slice(names, func(i, j int) bool {
+			if s.Categories[names[i]] != s.Categories[names[j]] {
+				return s.Categories[names[i]] > s.Categories[names[j]]
+			}
+			return names[i] < names[j]
+		})
+
+		b.WriteString("\nCategories:\n")
+		for _, name := range names {
+			fmt.Fprintf(&b, "  %s: %d\n", name, s.Categories[name])
+		}
+	}
+
+	if len(s.Failures) > 0 {
+		b.WriteString("\nFailures:\n")
+		for _, f := range s.Failures {
+			fmt.Fprintf(&b, "  %s: %s\n", filepath.Base(f.File), f.Error)
+		}
+		if s.OmittedFailures > 0 {
+			fmt.Fprintf(&b, "  ...and %d more\n", s.OmittedFailures)
+		}
+	}
+
+	if len(s.NearDuplicates) > 0 {
+		b.WriteString("\nNear-duplicates:\n")
+		for _, d := range s.NearDuplicates {
+			fmt.Fprintf(&b, "  %s ~ %s\n", filepath.Base(d.File), d.Of)
+		}
+		if s.OmittedNearDuplicates > 0 {
+			fmt.Fprintf(&b, "  ...and %d more\n", s.OmittedNearDuplicates)
+		}
+	}
+
+	return b.String()
+}
+
+// Slack posts summaries to a Slack incoming webhook.
+type Slack struct {
+	WebhookURL string
+}
+
+func (c Slack) Name() string { return "slack" }
+
+func (c Slack) SendSummary(ctx context.Context, s RunSummary) error {
+	return postJSON(ctx, c.WebhookURL, map[string]string{"text": "```\n" + FormatSummary(s) + "```"})
+}
+
+// Discord posts summaries to a Discord channel webhook.
+type Discord struct {
+	WebhookURL string
+}
+
+func (c Discord) Name() string { return "discord" }
+
+func (c Discord) SendSummary(ctx context.Context, s RunSummary) error {
+	text := FormatSummary(s)
+	// Leave room for the code fence
+	if limit := discordMessageLimit - 8; len(text) > limit {
+		text = text[:limit-3] + "..."
+	}
+	return postJSON(ctx, c.WebhookURL, map[string]string{"content": "```\n" + text + "```"})
+}
+
+// Email sends summaries over SMTP. STARTTLS is used when the server offers it.
+type Email struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+	To       []string
+}
+
+func (c Email) Name() string { return "email" }
+
+// Validate reports missing settings.
+func (c Email) Validate() error {
+	if c.Host == "" {
+		return fmt.Errorf("smtp host is required")
+	}
+	if c.From == "" {
+		return fmt.Errorf("sender address is required")
+	}
+	if len(c.To) == 0 {
+		return fmt.Errorf("at least one recipient is required")
+	}
+	return nil
+}
+
+func (c Email) SendSummary(ctx context.Context, s RunSummary) error {
+	subject := fmt.Sprintf("Docs Organiser: %d organised, %d failed", s.Processed, s.Failed)
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", c.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(c.To, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", subject)
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(FormatSummary(s), "\n", "\r\n"))
+
+	port := c.Port
+	if port == 0 {
+		port = 587
+	}
+	addr := c.Host + ":" + strconv.Itoa(port)
+
+	var auth smtp.Auth
+	if c.Username != "" {
+		auth = smtp.PlainAuth("", c.Username, c.Password, c.Host)
+	}
+
+	// net/smtp has no context support, so run it in the background and honour ctx
+	done := make(chan error, 1)
+	go func() { done <- smtp.SendMail(addr, auth, c.From, c.To, msg.Bytes()) }()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func postJSON(ctx context.Context, url string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{Timeout: deliveryTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("returned status %d", resp.StatusCode)
+	}
+	return nil
+}
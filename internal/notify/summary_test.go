@@ -0,0 +1,109 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormatSummary(t *testing.T) {
+	s := RunSummary{
+		Source:     "/in",
+		Dest:       "/out",
+		Total:      5,
+		Processed:  3,
+		Failed:     2,
+		Duration:   90 * time.Second,
+		Categories: map[string]int{"Misc": 1, "Finance": 2},
+		Failures: []FileFailure{
+			{File: "/in/scan.pdf", Error: "no text"},
+		},
+		OmittedFailures: 1,
+		NearDuplicates: []NearDuplicate{
+			{File: "/in/lease_v2.pdf", Of: "/out/Housing/Lease.pdf"},
+		},
+	}
+
+	got := FormatSummary(s)
+	for _, want := range []string{
+		"run finished after 1m30s",
+		"Organised 3 of 5 files, 2 failed",
+		"  Finance: 2\n  Misc: 1\n",
+		"  scan.pdf: no text",
+		"...and 1 more",
+		"Near-duplicates:\n  lease_v2.pdf ~ /out/Housing/Lease.pdf\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("summary missing %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestChatChannelsPostSummary(t *testing.T) {
+	tests := []struct {
+		name    string
+		channel func(url string) Channel
+		field   string
+	}{
+		{"slack", func(url string) Channel { return Slack{WebhookURL: url} }, "text"},
+		{"discord", func(url string) Channel { return Discord{WebhookURL: url} }, "content"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var payload map[string]string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				json.NewDecoder(r.Body).Decode(&payload)
+				w.WriteHeader(http.StatusNoContent)
+			}))
+			defer srv.Close()
+
+			ch := tt.channel(srv.URL)
+			if err := ch.SendSummary(context.Background(), RunSummary{Processed: 4, Total: 4}); err != nil {
+				t.Fatalf("SendSummary: %v", err)
+			}
+			if !strings.Contains(payload[tt.field], "Organised 4 of 4 files") {
+				t.Errorf("payload %q = %q", tt.field, payload[tt.field])
+			}
+		})
+	}
+}
+
+func TestDiscordTruncatesLongSummaries(t *testing.T) {
+	var payload map[string]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&payload)
+	}))
+	defer srv.Close()
+
+	failures := make([]FileFailure, 100)
+	for i := range failures {
+		failures[i] = FileFailure{File: strings.Repeat("x", 40) + ".pdf", Error: "extraction failed"}
+	}
+	if err := (Discord{WebhookURL: srv.URL}).SendSummary(context.Background(), RunSummary{Failures: failures}); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(payload["content"]); n > discordMessageLimit {
+		t.Errorf("content length %d exceeds Discord limit", n)
+	}
+}
+
+func TestEmailValidate(t *testing.T) {
+	valid := Email{Host: "smtp.example.com", From: "a@example.com", To: []string{"b@example.com"}}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	for _, e := range []Email{
+		{From: "a@example.com", To: []string{"b@example.com"}},
+		{Host: "smtp.example.com", To: []string{"b@example.com"}},
+		{Host: "smtp.example.com", From: "a@example.com"},
+	} {
+		if err := e.Validate(); err == nil {
+			t.Errorf("expected error for %+v", e)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// Event types delivered to webhooks.
+const (
+	EventRunCompleted  = "run_completed"
+	EventFileFailed    = "file_failed"
+	EventLowConfidence = "low_confidence"
+)
+
+// AllEvents lists every event type, in the order they are documented.
+var AllEvents = []string{EventRunCompleted, EventFileFailed, EventLowConfidence}
+
+// deliveryTimeout bounds a single webhook POST.
+const deliveryTimeout = 10 * time.Second
+
+// Event is the JSON body posted to webhooks.
+type Event struct {
+	Type string    `json:"event"`
+	Time time.Time `json:"time"`
+
+	// Per-file events
+	File       string  `json:"file,omitempty"`
+	Category   string  `json:"category,omitempty"`
+	Title      string  `json:"title,omitempty"`
+	Confidence float64 `json:"confidence,omitempty"`
+	Error      string  `json:"error,omitempty"`
+
+	// Run events
+	Run *RunSummary `json:"run,omitempty"`
+}
+
+// RunSummary describes a finished pipeline run.
+type RunSummary struct {
+	Source    string        `json:"source"`
+	Dest      string        `json:"dest"`
+	Total     int           `json:"total"`
+	Processed int           `json:"processed"`
+	Failed    int           `json:"failed"`
+	Duration  time.Duration `json:"duration"`
+	Cancelled bool          `json:"cancelled,omitempty"`
+
+	// Categories counts the files moved into each category during the run.
+	Categories map[string]int `json:"categories,omitempty"`
+	// Failures lists failed files, capped; OmittedFailures counts the rest.
+	Failures        []FileFailure `json:"failures,omitempty"`
+	OmittedFailures int           `json:"omitted_failures,omitempty"`
+	// NearDuplicates lists files whose text nearly matches an organised file, capped;
+	// OmittedNearDuplicates counts the rest.
+	NearDuplicates        []NearDuplicate `json:"near_duplicates,omitempty"`
+	OmittedNearDuplicates int             `json:"omitted_near_duplicates,omitempty"`
+}
+
+// NearDuplicate pairs a file with the organised file whose text it nearly repeats.
+type NearDuplicate struct {
+	File string `json:"file"`
+	Of   string `json:"of"`
+}
+
+// FileFailure identifies a file that could not be organised.
+type FileFailure struct {
+	File  string `json:"file"`
+	Error string `json:"error"`
+}
+
+// Webhook posts events as JSON to one or more URLs. Delivery is best-effort:
+// failures are logged and never interrupt the pipeline.
+type Webhook struct {
+	URLs []string
+	// LowConfidence is the confidence score below which a low_confidence event fires.
+	LowConfidence float64
+
+	events map[string]bool
+	client *http.Client
+	wg     sync.WaitGroup
+}
+
+// NewWebhook validates the URLs and event names. An empty events list subscribes to all events.
+func NewWebhook(urls, events []string, lowConfidence float64) (*Webhook, error) {
+	for _, raw := range urls {
+		u, err := url.Parse(raw)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("invalid webhook URL %q", raw)
+		}
+	}
+	if len(events) == 0 {
+		events = AllEvents
+	}
+	subscribed := make(map[string]bool, len(events))
+	for _, ev := range events {
+		if !isKnownEvent(ev) {
+			return nil, fmt.Errorf("unknown webhook event %q (expected one of %v)", ev, AllEvents)
+		}
+		subscribed[ev] = true
+	}
+
+	return &Webhook{
+		URLs:          urls,
+		LowConfidence: lowConfidence,
+		events:        subscribed,
+		client:        &http.Client{Timeout: deliveryTimeout},
+	}, nil
+}
+
+func isKnownEvent(ev string) bool {
+	for _, known := range AllEvents {
+		if ev == known {
+			return true
+		}
+	}
+	return false
+}
+
+// Subscribed reports whether the webhook wants events of the given type.
+func (w *Webhook) Subscribed(eventType string) bool {
+	return w != nil && w.events[eventType]
+}
+
+// Notify delivers ev in the background if the webhook is subscribed to its type.
+func (w *Webhook) Notify(ev Event) {
+	if !w.Subscribed(ev.Type) {
+		return
+	}
+	if ev.Time.IsZero() {
+		ev.Time = time.Now()
+	}
+
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		ctx, cancel := context.WithTimeout(context.Background(), deliveryTimeout)
+		defer cancel()
+		if err := w.Send(ctx, ev); err != nil {
+			log.Printf("[!] Webhook delivery failed for %s: %v", ev.Type, err)
+		}
+	}()
+}
+
+// Wait blocks until all background deliveries have finished.
+func (w *Webhook) Wait() {
+	if w != nil {
+		w.wg.Wait()
+	}
+}
+
+// Send posts ev to every URL synchronously, returning the first error encountered.
+func (w *Webhook) Send(ctx context.Context, ev Event) error {
+	body, err := json.Marshal(ev)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	var firstErr error
+	for _, u := range w.URLs {
+		if err := w.post(ctx, u, body); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func (w *Webhook) post(ctx context.Context, u string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, "POST", u, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "docs_organiser")
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s returned status %d", u, resp.StatusCode)
+	}
+	return nil
+}
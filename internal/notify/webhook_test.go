@@ -0,0 +1,98 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestNewWebhookValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		urls    []string
+		events  []string
+		wantErr bool
+	}{
+		{"defaults to all events", []string{"https://example.com/hook"}, nil, false},
+		{"explicit events", []string{"http://localhost:9000"}, []string{EventFileFailed}, false},
+		{"unknown event", []string{"https://example.com"}, []string{"file_moved"}, true},
+		{"missing scheme", []string{"example.com/hook"}, nil, true},
+		{"unsupported scheme", []string{"ftp://example.com"}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewWebhook(tt.urls, tt.events, 0.5)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewWebhook() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWebhookDeliversSubscribedEvents(t *testing.T) {
+	var mu sync.Mutex
+	var received []Event
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+			t.Errorf("Content-Type = %q", ct)
+		}
+		var ev Event
+		if err := json.NewDecoder(r.Body).Decode(&ev); err != nil {
+			t.Errorf("invalid body: %v", err)
+		}
+		mu.Lock()
+		received = append(received, ev)
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	w, err := NewWebhook([]string{srv.URL}, []string{EventRunCompleted, EventFileFailed}, 0.5)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w.Notify(Event{Type: EventFileFailed, File: "a.pdf", Error: "no text"})
+	w.Notify(Event{Type: EventLowConfidence, File: "b.pdf"}) // not subscribed
+	w.Notify(Event{Type: EventRunCompleted, Run: &RunSummary{Total: 2, Processed: 1, Failed: 1}})
+	w.Wait()
+
+	if len(received) != 2 {
+		t.Fatalf("received %d events, want 2: %+v", len(received), received)
+	}
+	for _, ev := range received {
+		if ev.Time.IsZero() {
+			t.Errorf("event %s has no timestamp", ev.Type)
+		}
+		if ev.Type == EventRunCompleted && (ev.Run == nil || ev.Run.Failed != 1) {
+			t.Errorf("run summary not delivered: %+v", ev.Run)
+		}
+	}
+}
+
+func TestWebhookSendReportsHTTPErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	w, err := NewWebhook([]string{srv.URL}, nil, 0.5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Send(context.Background(), Event{Type: EventRunCompleted}); err == nil {
+		t.Error("expected an error for a 500 response")
+	}
+}
+
+func TestNilWebhookIsInert(t *testing.T) {
+	var w *Webhook
+	if w.Subscribed(EventRunCompleted) {
+		t.Error("nil webhook should not be subscribed")
+	}
+	w.Notify(Event{Type: EventRunCompleted})
+	w.Wait()
+}
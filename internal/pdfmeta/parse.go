@@ -0,0 +1,590 @@
+package pdfmeta
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// maxDecodedStream bounds the size of a decompressed cross-reference or object stream.
+const maxDecodedStream = 64 << 20
+
+// entry is one key of a dictionary with its value kept as the original PDF syntax,
+// so unknown values (references, nested dictionaries) round-trip unchanged.
+type entry struct {
+	key string
+	raw []byte
+}
+
+type dict []entry
+
+func (d dict) get(key string) ([]byte, bool) {
+	for _, e := range d {
+		if e.key == key {
+			return e.raw, true
+		}
+	}
+	return nil, false
+}
+
+func (d *dict) set(key string, raw []byte) {
+	for i, e := range *d {
+		if e.key == key {
+			(*d)[i].raw = raw
+			return
+		}
+	}
+	*d = append(*d, entry{key, raw})
+}
+
+func (d dict) int(key string) (int, bool) {
+	raw, ok := d.get(key)
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.Atoi(string(raw))
+	return n, err == nil
+}
+
+func (d dict) bytes() []byte {
+	var b bytes.Buffer
+	b.WriteString("<<")
+	for _, e := range d {
+		b.WriteString(" /" + e.key + " ")
+		b.Write(e.raw)
+	}
+	b.WriteString(" >>")
+	return b.Bytes()
+}
+
+// xrefEntry locates an object: at a byte offset, or inside an object stream.
+type xrefEntry struct {
+	compressed bool
+	offset     int // byte offset, or the object stream's number when compressed
+	gen        int // generation, or the index within the object stream when compressed
+}
+
+// document is a parsed view of a PDF's cross-reference data, enough to read single objects.
+type document struct {
+	data       []byte
+	xref       map[int]xrefEntry
+	trailer    dict
+	startxref  int
+	xrefStream bool // the newest section is a cross-reference stream
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\n' || c == '\r' || c == '\t' || c == '\f' || c == 0
+}
+
+func isDelimiter(c byte) bool {
+	return bytes.IndexByte([]byte("()<>[]{}/%"), c) >= 0
+}
+
+// skipSpace skips whitespace and comments.
+func skipSpace(data []byte, i int) int {
+	for i < len(data) {
+		switch {
+		case isSpace(data[i]):
+			i++
+		case data[i] == '%':
+			for i < len(data) && data[i] != '\n' && data[i] != '\r' {
+				i++
+			}
+		default:
+			return i
+		}
+	}
+	return i
+}
+
+// token returns the end of the regular-character run starting at i.
+func token(data []byte, i int) int {
+	for i < len(data) && !isSpace(data[i]) && !isDelimiter(data[i]) {
+		i++
+	}
+	return i
+}
+
+var errSyntax = errors.New("malformed PDF object")
+
+// valueEnd returns the end of the object value starting at i (after whitespace).
+func valueEnd(data []byte, i int) (int, error) {
+	if i >= len(data) {
+		return 0, errSyntax
+	}
+	switch c := data[i]; {
+	case c == '<' && i+1 < len(data) && data[i+1] == '<':
+		_, end, err := parseDict(data, i)
+		return end, err
+	case c == '<':
+		end := bytes.IndexByte(data[i:], '>')
+		if end < 0 {
+			return 0, errSyntax
+		}
+		return i + end + 1, nil
+	case c == '[':
+		i++
+		for {
+			i = skipSpace(data, i)
+			if i >= len(data) {
+				return 0, errSyntax
+			}
+			if data[i] == ']' {
+				return i + 1, nil
+			}
+			end, err := valueEnd(data, i)
+			if err != nil {
+				return 0, err
+			}
+			i = end
+		}
+	case c == '(':
+		depth := 0
+		for ; i < len(data); i++ {
+			switch data[i] {
+			case '\\':
+				i++
+			case '(':
+				depth++
+			case ')':
+				depth--
+				if depth == 0 {
+					return i + 1, nil
+				}
+			}
+		}
+		return 0, errSyntax
+	case c == '/':
+		return token(data, i+1), nil
+	case isDelimiter(c):
+		return 0, errSyntax
+	}
+
+	// A number, keyword, or an indirect reference "num gen R"
+	end := token(data, i)
+	if end == i {
+		return 0, errSyntax
+	}
+	if _, err := strconv.Atoi(string(data[i:end])); err == nil {
+		j := skipSpace(data, end)
+		if k := token(data, j); k > j {
+			if _, err := strconv.Atoi(string(data[j:k])); err == nil {
+				r := skipSpace(data, k)
+				if r < len(data) && data[r] == 'R' && (r+1 == len(data) || isSpace(data[r+1]) || isDelimiter(data[r+1])) {
+					return r + 1, nil
+				}
+			}
+		}
+	}
+	return end, nil
+}
+
+// parseDict parses the dictionary starting at i and returns it with its end offset.
+func parseDict(data []byte, i int) (dict, int, error) {
+	if !bytes.HasPrefix(data[i:], []byte("<<")) {
+		return nil, 0, errSyntax
+	}
+	i += 2
+	var d dict
+	for {
+		i = skipSpace(data, i)
+		if i+1 < len(data) && data[i] == '>' && data[i+1] == '>' {
+			return d, i + 2, nil
+		}
+		if i >= len(data) || data[i] != '/' {
+			return nil, 0, errSyntax
+		}
+		keyEnd := token(data, i+1)
+		key := string(data[i+1 : keyEnd])
+		start := skipSpace(data, keyEnd)
+		end, err := valueEnd(data, start)
+		if err != nil {
+			return nil, 0, err
+		}
+		d = append(d, entry{key, data[start:end]})
+		i = end
+	}
+}
+
+// parseRef parses an indirect reference "num gen R".
+func parseRef(raw []byte) (num, gen int, ok bool) {
+	fields := bytes.Fields(raw)
+	if len(fields) != 3 || string(fields[2]) != "R" {
+		return 0, 0, false
+	}
+	num, err1 := strconv.Atoi(string(fields[0]))
+	gen, err2 := strconv.Atoi(string(fields[1]))
+	return num, gen, err1 == nil && err2 == nil
+}
+
+// parseInts parses an array of integers such as "[1 4 2]".
+func parseInts(raw []byte) ([]int, error) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) < 2 || raw[0] != '[' || raw[len(raw)-1] != ']' {
+		return nil, errSyntax
+	}
+	var out []int
+	for _, f := range bytes.Fields(raw[1 : len(raw)-1]) {
+		n, err := strconv.Atoi(string(f))
+		if err != nil {
+			return nil, errSyntax
+		}
+		out = append(out, n)
+	}
+	return out, nil
+}
+
+func parse(data []byte) (*document, error) {
+	doc := &document{data: data, xref: make(map[int]xrefEntry)}
+
+	idx := bytes.LastIndex(data, []byte("startxref"))
+	if idx < 0 {
+		return nil, fmt.Errorf("no startxref found")
+	}
+	i := skipSpace(data, idx+len("startxref"))
+	n, err := strconv.Atoi(string(data[i:token(data, i)]))
+	if err != nil || n <= 0 || n >= len(data) {
+		return nil, fmt.Errorf("invalid startxref offset")
+	}
+	doc.startxref = n
+
+	// Walk the update chain from newest to oldest; newer entries win
+	seen := make(map[int]bool)
+	for off, first := n, true; off > 0 && !seen[off]; first = false {
+		seen[off] = true
+
+		var trailer dict
+		stream := !bytes.HasPrefix(data[off:], []byte("xref"))
+		if stream {
+			trailer, err = doc.readXrefStream(off)
+		} else {
+			trailer, err = doc.readXrefTable(off)
+			// Hybrid files keep their compressed objects in a separate stream
+			if stm, ok := trailer.int("XRefStm"); ok && err == nil && !seen[stm] {
+				seen[stm] = true
+				if _, err := doc.readXrefStream(stm); err != nil {
+					return nil, err
+				}
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read cross-reference section at %d: %w", off, err)
+		}
+		if first {
+			doc.trailer = trailer
+			doc.xrefStream = stream
+		}
+		off, _ = trailer.int("Prev")
+	}
+	return doc, nil
+}
+
+func (doc *document) add(num int, e xrefEntry) {
+	if _, ok := doc.xref[num]; !ok {
+		doc.xref[num] = e
+	}
+}
+
+// readXrefTable reads a classic "xref ... trailer << >>" section.
+func (doc *document) readXrefTable(off int) (dict, error) {
+	data := doc.data
+	i := off + len("xref")
+	next := func() string {
+		i = skipSpace(data, i)
+		start := i
+		i = token(data, i)
+		return string(data[start:i])
+	}
+
+	for {
+		i = skipSpace(data, i)
+		if bytes.HasPrefix(data[i:], []byte("trailer")) {
+			d, _, err := parseDict(data, skipSpace(data, i+len("trailer")))
+			return d, err
+		}
+		start, err1 := strconv.Atoi(next())
+		count, err2 := strconv.Atoi(next())
+		if err1 != nil || err2 != nil || count < 0 {
+			return nil, errSyntax
+		}
+		for k := 0; k < count; k++ {
+			offset, err1 := strconv.Atoi(next())
+			gen, err2 := strconv.Atoi(next())
+			kind := next()
+			if err1 != nil || err2 != nil {
+				return nil, errSyntax
+			}
+			if kind == "n" {
+				doc.add(start+k, xrefEntry{offset: offset, gen: gen})
+			} else {
+				doc.add(start+k, xrefEntry{offset: -1})
+			}
+		}
+	}
+}
+
+// readXrefStream reads a cross-reference stream object and returns its dictionary.
+func (doc *document) readXrefStream(off int) (dict, error) {
+	d, body, err := doc.streamAt(off)
+	if err != nil {
+		return nil, err
+	}
+	w, err := parseInts(mustGet(d, "W"))
+	if err != nil || len(w) != 3 {
+		return nil, fmt.Errorf("invalid /W in cross-reference stream")
+	}
+	size, _ := d.int("Size")
+	index := []int{0, size}
+	if raw, ok := d.get("Index"); ok {
+		if index, err = parseInts(raw); err != nil || len(index)%2 != 0 {
+			return nil, fmt.Errorf("invalid /Index in cross-reference stream")
+		}
+	}
+
+	field := func(b []byte, def int) int {
+		if len(b) == 0 {
+			return def
+		}
+		v := 0
+		for _, c := range b {
+			v = v<<8 | int(c)
+		}
+		return v
+	}
+	rowLen := w[0] + w[1] + w[2]
+	pos := 0
+	for s := 0; s < len(index); s += 2 {
+		for k := 0; k < index[s+1]; k++ {
+			if pos+rowLen > len(body) {
+				return nil, fmt.Errorf("truncated cross-reference stream")
+			}
+			row := body[pos : pos+rowLen]
+			pos += rowLen
+			typ := field(row[:w[0]], 1)
+			a := field(row[w[0]:w[0]+w[1]], 0)
+			b := field(row[w[0]+w[1]:], 0)
+			switch typ {
+			case 1:
+				doc.add(index[s]+k, xrefEntry{offset: a, gen: b})
+			case 2:
+				doc.add(index[s]+k, xrefEntry{compressed: true, offset: a, gen: b})
+			default:
+				doc.add(index[s]+k, xrefEntry{offset: -1})
+			}
+		}
+	}
+	return d, nil
+}
+
+func mustGet(d dict, key string) []byte {
+	raw, _ := d.get(key)
+	return raw
+}
+
+// objectAt parses "num gen obj" at off and returns where its value starts and ends.
+func (doc *document) objectAt(off int) (start, end int, err error) {
+	data := doc.data
+	if off < 0 || off >= len(data) {
+		return 0, 0, errSyntax
+	}
+	i := off
+	for k := 0; k < 2; k++ {
+		i = skipSpace(data, i)
+		j := token(data, i)
+		if _, err := strconv.Atoi(string(data[i:j])); err != nil {
+			return 0, 0, errSyntax
+		}
+		i = j
+	}
+	i = skipSpace(data, i)
+	if !bytes.HasPrefix(data[i:], []byte("obj")) {
+		return 0, 0, errSyntax
+	}
+	start = skipSpace(data, i+3)
+	end, err = valueEnd(data, start)
+	return start, end, err
+}
+
+// streamAt reads the stream object at off, returning its dictionary and decoded data.
+func (doc *document) streamAt(off int) (dict, []byte, error) {
+	start, end, err := doc.objectAt(off)
+	if err != nil {
+		return nil, nil, err
+	}
+	d, _, err := parseDict(doc.data, start)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	i := skipSpace(doc.data, end)
+	if !bytes.HasPrefix(doc.data[i:], []byte("stream")) {
+		return nil, nil, fmt.Errorf("expected stream")
+	}
+	i += len("stream")
+	if bytes.HasPrefix(doc.data[i:], []byte("\r\n")) {
+		i += 2
+	} else if i < len(doc.data) && (doc.data[i] == '\n' || doc.data[i] == '\r') {
+		i++
+	}
+
+	length, ok := d.int("Length")
+	if !ok {
+		// The length may itself be an indirect object
+		raw, err := doc.object(mustGet(d, "Length"))
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid stream /Length")
+		}
+		if length, err = strconv.Atoi(string(bytes.TrimSpace(raw))); err != nil {
+			return nil, nil, fmt.Errorf("invalid stream /Length")
+		}
+	}
+	if length < 0 || i+length > len(doc.data) {
+		return nil, nil, fmt.Errorf("stream extends past end of file")
+	}
+	body, err := decodeStream(d, doc.data[i:i+length])
+	return d, body, err
+}
+
+// object returns the value of the object referenced by ref ("num gen R").
+func (doc *document) object(ref []byte) ([]byte, error) {
+	num, _, ok := parseRef(ref)
+	if !ok {
+		return nil, fmt.Errorf("not a reference: %q", ref)
+	}
+	e, ok := doc.xref[num]
+	if !ok || e.offset < 0 {
+		return nil, fmt.Errorf("object %d not found", num)
+	}
+	if !e.compressed {
+		start, end, err := doc.objectAt(e.offset)
+		if err != nil {
+			return nil, err
+		}
+		return doc.data[start:end], nil
+	}
+
+	// Compressed objects live in an object stream: N pairs of "num offset", then the objects
+	stmEntry, ok := doc.xref[e.offset]
+	if !ok || stmEntry.compressed || stmEntry.offset < 0 {
+		return nil, fmt.Errorf("object stream %d not found", e.offset)
+	}
+	d, body, err := doc.streamAt(stmEntry.offset)
+	if err != nil {
+		return nil, err
+	}
+	n, _ := d.int("N")
+	first, _ := d.int("First")
+	header := bytes.Fields(body[:min(first, len(body))])
+	if e.gen >= n || 2*e.gen+1 >= len(header) {
+		return nil, fmt.Errorf("object %d missing from its object stream", num)
+	}
+	rel, err := strconv.Atoi(string(header[2*e.gen+1]))
+	if err != nil || first+rel >= len(body) {
+		return nil, errSyntax
+	}
+	start := skipSpace(body, first+rel)
+	end, err := valueEnd(body, start)
+	if err != nil {
+		return nil, err
+	}
+	return body[start:end], nil
+}
+
+// decodeStream applies the stream's filters; only FlateDecode (with PNG predictors) is supported.
+func decodeStream(d dict, raw []byte) ([]byte, error) {
+	filter := string(bytes.Trim(bytes.TrimSpace(mustGet(d, "Filter")), "[] "))
+	switch filter {
+	case "":
+		return raw, nil
+	case "/FlateDecode":
+	default:
+		return nil, fmt.Errorf("unsupported stream filter %s", filter)
+	}
+
+	zr, err := zlib.NewReader(bytes.NewReader(raw))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	body, err := io.ReadAll(io.LimitReader(zr, maxDecodedStream+1))
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, err
+	}
+	if len(body) > maxDecodedStream {
+		return nil, fmt.Errorf("stream too large")
+	}
+
+	parms := bytes.Trim(bytes.TrimSpace(mustGet(d, "DecodeParms")), "[]")
+	if len(parms) == 0 {
+		return body, nil
+	}
+	pd, _, err := parseDict(bytes.TrimSpace(parms), 0)
+	if err != nil {
+		return nil, err
+	}
+	predictor, _ := pd.int("Predictor")
+	if predictor < 10 {
+		return body, nil
+	}
+	columns, ok := pd.int("Columns")
+	if !ok {
+		columns = 1
+	}
+	return unpredictPNG(body, columns)
+}
+
+// unpredictPNG reverses PNG row filters (one filter byte per row, one byte per pixel).
+func unpredictPNG(data []byte, columns int) ([]byte, error) {
+	rowLen := columns + 1
+	if columns <= 0 || len(data)%rowLen != 0 {
+		return nil, fmt.Errorf("invalid predictor data")
+	}
+	out := make([]byte, 0, len(data)/rowLen*columns)
+	prev := make([]byte, columns)
+	for r := 0; r < len(data); r += rowLen {
+		filter, row := data[r], append([]byte(nil), data[r+1:r+rowLen]...)
+		for i := range row {
+			var left, upLeft byte
+			if i > 0 {
+				left, upLeft = row[i-1], prev[i-1]
+			}
+			up := prev[i]
+			switch filter {
+			case 1:
+				row[i] += left
+			case 2:
+				row[i] += up
+			case 3:
+				row[i] += byte((int(left) + int(up)) / 2)
+			case 4:
+				row[i] += paeth(left, up, upLeft)
+			}
+		}
+		out = append(out, row...)
+		prev = row
+	}
+	return out, nil
+}
+
+func paeth(a, b, c byte) byte {
+	p := int(a) + int(b) - int(c)
+	pa, pb, pc := abs(p-int(a)), abs(p-int(b)), abs(p-int(c))
+	switch {
+	case pa <= pb && pa <= pc:
+		return a
+	case pb <= pc:
+		return b
+	}
+	return c
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,285 @@
+// Package pdfmeta writes classification results into a PDF's document information
+// dictionary and XMP metadata without rewriting the document.
+package pdfmeta
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// ErrEncrypted is returned for encrypted PDFs, whose strings would need re-encrypting.
+var ErrEncrypted = errors.New("encrypted PDFs are not modified")
+
+// Metadata is the classification stamped into a PDF.
+type Metadata struct {
+	Title    string
+	Subject  string // the category
+	Keywords []string
+}
+
+// Stamp appends an incremental update to the PDF at path that sets the document
+// information Title, Subject, and Keywords, keeping every other entry. An XMP packet
+// carrying the same values is added when the document has none; an existing packet is
+// left untouched. The original bytes are preserved, so the update can be stripped again.
+func Stamp(path string, meta Metadata, now time.Time) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	out, err := stamp(data, meta, now)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, out)
+}
+
+func stamp(data []byte, meta Metadata, now time.Time) ([]byte, error) {
+	doc, err := parse(data)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := doc.trailer.get("Encrypt"); ok {
+		return nil, ErrEncrypted
+	}
+	size, ok := doc.trailer.int("Size")
+	if !ok {
+		return nil, fmt.Errorf("trailer has no /Size")
+	}
+	rootRef, ok := doc.trailer.get("Root")
+	rootNum, rootGen, isRef := parseRef(rootRef)
+	if !ok || !isRef {
+		return nil, fmt.Errorf("trailer has no /Root")
+	}
+
+	// Keep existing info entries (Author, CreationDate, Producer, ...)
+	var info dict
+	if ref, ok := doc.trailer.get("Info"); ok {
+		if raw, err := doc.object(ref); err == nil {
+			info, _, _ = parseDict(raw, 0)
+		}
+	}
+	if meta.Title != "" {
+		info.set("Title", textString(meta.Title))
+	}
+	if meta.Subject != "" {
+		info.set("Subject", textString(meta.Subject))
+	}
+	if len(meta.Keywords) > 0 {
+		info.set("Keywords", textString(strings.Join(meta.Keywords, ", ")))
+	}
+	info.set("ModDate", textString(pdfDate(now)))
+
+	var buf bytes.Buffer
+	buf.Write(data)
+	if !bytes.HasSuffix(data, []byte("\n")) {
+		buf.WriteByte('\n')
+	}
+
+	type written struct{ offset, gen int }
+	objects := make(map[int]written)
+	next := size
+	writeObject := func(num, gen int, body []byte) {
+		objects[num] = written{buf.Len(), gen}
+		fmt.Fprintf(&buf, "%d %d obj\n", num, gen)
+		buf.Write(body)
+		buf.WriteString("\nendobj\n")
+	}
+
+	infoNum := next
+	next++
+	writeObject(infoNum, 0, info.bytes())
+
+	catalogRaw, err := doc.object(rootRef)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read document catalog: %w", err)
+	}
+	catalog, _, err := parseDict(catalogRaw, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read document catalog: %w", err)
+	}
+	if _, ok := catalog.get("Metadata"); !ok {
+		packet := xmpPacket(meta, now)
+		xmpNum := next
+		next++
+		writeObject(xmpNum, 0, []byte(fmt.Sprintf("<< /Type /Metadata /Subtype /XML /Length %d >>\nstream\n%s\nendstream", len(packet), packet)))
+		catalog.set("Metadata", []byte(fmt.Sprintf("%d 0 R", xmpNum)))
+		writeObject(rootNum, rootGen, catalog.bytes())
+	}
+
+	trailer := dict{
+		{"Size", nil},
+		{"Root", rootRef},
+		{"Info", []byte(fmt.Sprintf("%d 0 R", infoNum))},
+		{"Prev", []byte(strconv.Itoa(doc.startxref))},
+	}
+	if id, ok := doc.trailer.get("ID"); ok {
+		trailer.set("ID", id)
+	}
+
+	nums := make([]int, 0, len(objects)+1)
+	for num := range objects {
+		nums = append(nums, num)
+	}
+	xrefOffset := buf.Len()
+
+	if !doc.xrefStream {
+		sort.Ints(nums)
+		trailer.set("Size", []byte(strconv.Itoa(next)))
+		buf.WriteString("xref\n")
+		for _, run := range runs(nums) {
+			fmt.Fprintf(&buf, "%d %d\n", run[0], len(run))
+			for _, num := range run {
+				fmt.Fprintf(&buf, "%010d %05d n\r\n", objects[num].offset, objects[num].gen)
+			}
+		}
+		buf.WriteString("trailer\n")
+		buf.Write(trailer.bytes())
+	} else {
+		// Cross-reference streams must be updated with another stream; it lists itself too
+		xrefNum := next
+		next++
+		objects[xrefNum] = written{xrefOffset, 0}
+		nums = append(nums, xrefNum)
+		sort.Ints(nums)
+
+		var rows bytes.Buffer
+		var index []string
+		for _, run := range runs(nums) {
+			index = append(index, strconv.Itoa(run[0]), strconv.Itoa(len(run)))
+			for _, num := range run {
+				rows.WriteByte(1)
+				binary.Write(&rows, binary.BigEndian, uint64(objects[num].offset))
+				binary.Write(&rows, binary.BigEndian, uint16(objects[num].gen))
+			}
+		}
+		trailer.set("Size", []byte(strconv.Itoa(next)))
+		trailer = append(dict{
+			{"Type", []byte("/XRef")},
+			{"W", []byte("[1 8 2]")},
+			{"Index", []byte("[" + strings.Join(index, " ") + "]")},
+			{"Length", []byte(strconv.Itoa(rows.Len()))},
+		}, trailer...)
+		fmt.Fprintf(&buf, "%d 0 obj\n", xrefNum)
+		buf.Write(trailer.bytes())
+		buf.WriteString("\nstream\n")
+		buf.Write(rows.Bytes())
+		buf.WriteString("\nendstream\nendobj")
+	}
+	fmt.Fprintf(&buf, "\nstartxref\n%d\n%%%%EOF\n", xrefOffset)
+	return buf.Bytes(), nil
+}
+
+// runs splits sorted object numbers into consecutive runs, one per xref subsection.
+func runs(nums []int) [][]int {
+	var out [][]int
+	for i, n := range nums {
+		if i > 0 && n == nums[i-1]+1 {
+			out[len(out)-1] = append(out[len(out)-1], n)
+		} else {
+			out = append(out, []int{n})
+		}
+	}
+	return out
+}
+
+// textString encodes s as a PDF text string: a literal for printable ASCII,
+// otherwise UTF-16BE with a byte order mark.
+func textString(s string) []byte {
+	ascii := true
+	for _, r := range s {
+		if r < 0x20 || r > 0x7e {
+			ascii = false
+			break
+		}
+	}
+	if ascii {
+		r := strings.NewReplacer(`\`, `\\`, "(", `\(`, ")", `\)`)
+		return []byte("(" + r.Replace(s) + ")")
+	}
+
+	units := utf16.Encode([]rune(s))
+	b := make([]byte, 2, 2+2*len(units))
+	b[0], b[1] = 0xFE, 0xFF
+	for _, u := range units {
+		b = append(b, byte(u>>8), byte(u))
+	}
+	return []byte("<" + strings.ToUpper(hex.EncodeToString(b)) + ">")
+}
+
+// pdfDate formats t as a PDF date string (D:YYYYMMDDHHmmSS+HH'mm').
+func pdfDate(t time.Time) string {
+	_, offset := t.Zone()
+	sign := '+'
+	if offset < 0 {
+		sign, offset = '-', -offset
+	}
+	return fmt.Sprintf("D:%s%c%02d'%02d'", t.Format("20060102150405"), sign, offset/3600, offset/60%60)
+}
+
+// xmpPacket builds an XMP packet mirroring the info dictionary entries.
+func xmpPacket(meta Metadata, now time.Time) string {
+	esc := func(s string) string {
+		var b bytes.Buffer
+		xml.EscapeText(&b, []byte(s))
+		return b.String()
+	}
+
+	var b strings.Builder
+	b.WriteString("<?xpacket begin=\"\uFEFF\" id=\"W5M0MpCehiHzreSzNTczkc9d\"?>\n")
+	b.WriteString("<x:xmpmeta xmlns:x=\"adobe:ns:meta/\">\n<rdf:RDF xmlns:rdf=\"http://www.w3.org/1999/02/22-rdf-syntax-ns#\">\n")
+	b.WriteString("<rdf:Description rdf:about=\"\" xmlns:dc=\"http://purl.org/dc/elements/1.1/\"" +
+		" xmlns:pdf=\"http://ns.adobe.com/pdf/1.3/\" xmlns:xmp=\"http://ns.adobe.com/xap/1.0/\">\n")
+	if meta.Title != "" {
+		b.WriteString("<dc:title><rdf:Alt><rdf:li xml:lang=\"x-default\">" + esc(meta.Title) + "</rdf:li></rdf:Alt></dc:title>\n")
+	}
+	if meta.Subject != "" {
+		b.WriteString("<dc:description><rdf:Alt><rdf:li xml:lang=\"x-default\">" + esc(meta.Subject) + "</rdf:li></rdf:Alt></dc:description>\n")
+	}
+	if len(meta.Keywords) > 0 {
+		b.WriteString("<dc:subject><rdf:Bag>")
+		for _, k := range meta.Keywords {
+			b.WriteString("<rdf:li>" + esc(k) + "</rdf:li>")
+		}
+		b.WriteString("</rdf:Bag></dc:subject>\n")
+		b.WriteString("<pdf:Keywords>" + esc(strings.Join(meta.Keywords, ", ")) + "</pdf:Keywords>\n")
+	}
+	date := now.Format(time.RFC3339)
+	b.WriteString("<xmp:ModifyDate>" + date + "</xmp:ModifyDate>\n<xmp:MetadataDate>" + date + "</xmp:MetadataDate>\n")
+	b.WriteString("</rdf:Description>\n</rdf:RDF>\n</x:xmpmeta>\n<?xpacket end=\"w\"?>")
+	return b.String()
+}
+
+// writeFileAtomic replaces path with data via a temporary file, keeping its permissions.
+func writeFileAtomic(path string, data []byte) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".pdfmeta-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), info.Mode().Perm()); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
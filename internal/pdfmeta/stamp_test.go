@@ -0,0 +1,225 @@
+package pdfmeta
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ledongthuc/pdf"
+)
+
+var pageObjects = []string{
+	"<< /Type /Catalog /Pages 2 0 R >>",
+	"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+	"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] >>",
+}
+
+// classicPDF builds a PDF with a cross-reference table and the given trailer extras.
+func classicPDF(objects []string, trailerExtra string) []byte {
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = b.Len()
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	xref := b.Len()
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f\r\n", len(objects)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&b, "%010d 00000 n\r\n", off)
+	}
+	fmt.Fprintf(&b, "trailer\n<< /Size %d /Root 1 0 R%s >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, trailerExtra, xref)
+	return b.Bytes()
+}
+
+func deflate(data []byte) []byte {
+	var b bytes.Buffer
+	zw := zlib.NewWriter(&b)
+	zw.Write(data)
+	zw.Close()
+	return b.Bytes()
+}
+
+// streamPDF builds a PDF 1.5 file whose catalog and pages sit in a compressed object
+// stream and whose cross-reference data is a stream with PNG "up" prediction.
+func streamPDF() []byte {
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.5\n")
+
+	var header, body strings.Builder
+	for i, obj := range pageObjects {
+		fmt.Fprintf(&header, "%d %d ", i+1, body.Len())
+		body.WriteString(obj + "\n")
+	}
+	objstm := header.String() + body.String()
+	stmOffset := b.Len()
+	packed := deflate([]byte(objstm))
+	fmt.Fprintf(&b, "4 0 obj\n<< /Type /ObjStm /N 3 /First %d /Filter /FlateDecode /Length %d >>\nstream\n",
+		header.Len(), len(packed))
+	b.Write(packed)
+	b.WriteString("\nendstream\nendobj\n")
+
+	xrefOffset := b.Len()
+	rows := [][]byte{
+		{0, 0, 0, 0, 0xff, 0xff},
+		{2, 0, 0, 0, 4, 0},
+		{2, 0, 0, 0, 4, 1},
+		{2, 0, 0, 0, 4, 2},
+		{1, 0, 0, 0, 0, 0},
+		{1, 0, 0, 0, 0, 0},
+	}
+	binary.BigEndian.PutUint32(rows[4][1:5], uint32(stmOffset))
+	binary.BigEndian.PutUint32(rows[5][1:5], uint32(xrefOffset))
+	var predicted []byte
+	prev := make([]byte, 6)
+	for _, row := range rows {
+		predicted = append(predicted, 2)
+		for i := range row {
+			predicted = append(predicted, row[i]-prev[i])
+		}
+		prev = row
+	}
+	packed = deflate(predicted)
+	fmt.Fprintf(&b, "5 0 obj\n<< /Type /XRef /Size 6 /W [1 4 1] /Root 1 0 R /ID [<0102> <0102>]"+
+		" /Filter /FlateDecode /DecodeParms << /Predictor 12 /Columns 6 >> /Length %d >>\nstream\n", len(packed))
+	b.Write(packed)
+	fmt.Fprintf(&b, "\nendstream\nendobj\nstartxref\n%d\n%%%%EOF\n", xrefOffset)
+	return b.Bytes()
+}
+
+// readInfo opens data with an independent PDF reader and returns the info dictionary.
+func readInfo(t *testing.T, data []byte) pdf.Value {
+	t.Helper()
+	r, err := pdf.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("stamped PDF does not open: %v", err)
+	}
+	if n := r.NumPage(); n != 1 {
+		t.Errorf("stamped PDF has %d pages, want 1", n)
+	}
+	return r.Trailer().Key("Info")
+}
+
+func TestStamp(t *testing.T) {
+	now := time.Date(2024, 3, 5, 10, 30, 0, 0, time.UTC)
+	meta := Metadata{Title: "Electricity Bill (March)", Subject: "Finance/Utilities", Keywords: []string{"Finance", "Utilities", "electricity"}}
+
+	withInfo := append(append([]string(nil), pageObjects...), "<< /Author (Jane Doe) /Title (scan0001) >>")
+	withXMP := append(append([]string(nil), pageObjects...), "<< /Type /Metadata /Subtype /XML /Length 0 >>\nstream\n\nendstream")
+	withXMP[0] = "<< /Type /Catalog /Pages 2 0 R /Metadata 4 0 R >>"
+
+	tests := []struct {
+		name       string
+		pdf        []byte
+		meta       Metadata
+		wantAuthor string
+		wantXMP    bool
+	}{
+		{"classic xref", classicPDF(pageObjects, ""), meta, "", true},
+		{"existing info kept", classicPDF(withInfo, " /Info 4 0 R"), meta, "Jane Doe", true},
+		{"existing XMP left alone", classicPDF(withXMP, ""), meta, "", false},
+		{"xref and object streams", streamPDF(), meta, "", true},
+		{"unicode title", classicPDF(pageObjects, ""), Metadata{Title: "Reçu – Café", Subject: "Receipts"}, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := stamp(tt.pdf, tt.meta, now)
+			if err != nil {
+				t.Fatalf("stamp: %v", err)
+			}
+			if !bytes.HasPrefix(out, tt.pdf) {
+				t.Error("original bytes were not preserved")
+			}
+
+			info := readInfo(t, out)
+			if got := info.Key("Title").Text(); got != tt.meta.Title {
+				t.Errorf("Title = %q, want %q", got, tt.meta.Title)
+			}
+			if got := info.Key("Subject").Text(); got != tt.meta.Subject {
+				t.Errorf("Subject = %q, want %q", got, tt.meta.Subject)
+			}
+			if got, want := info.Key("Keywords").Text(), strings.Join(tt.meta.Keywords, ", "); got != want {
+				t.Errorf("Keywords = %q, want %q", got, want)
+			}
+			if got := info.Key("Author").Text(); got != tt.wantAuthor {
+				t.Errorf("Author = %q, want %q", got, tt.wantAuthor)
+			}
+			if got := info.Key("ModDate").Text(); got != "D:20240305103000+00'00'" {
+				t.Errorf("ModDate = %q", got)
+			}
+
+			// Our own parser must follow the new section too, so files can be stamped again
+			doc, err := parse(out)
+			if err != nil {
+				t.Fatalf("re-parse: %v", err)
+			}
+			catalog, err := doc.object(mustGet(doc.trailer, "Root"))
+			if err != nil {
+				t.Fatalf("catalog: %v", err)
+			}
+			d, _, _ := parseDict(catalog, 0)
+			ref, _ := d.get("Metadata")
+			_, body, err := doc.streamAt(doc.xref[mustInt(t, ref)].offset)
+			if err != nil {
+				t.Fatalf("metadata stream: %v", err)
+			}
+			if hasTitle := bytes.Contains(body, []byte("<dc:title>")); hasTitle != tt.wantXMP {
+				t.Errorf("XMP packet written = %v, want %v", hasTitle, tt.wantXMP)
+			}
+			if tt.wantXMP && !bytes.Contains(body, []byte(tt.meta.Title)) {
+				t.Errorf("XMP packet lacks the title: %s", body)
+			}
+
+			if _, err := stamp(out, Metadata{Title: "Second"}, now); err != nil {
+				t.Errorf("stamping again: %v", err)
+			}
+		})
+	}
+}
+
+func mustInt(t *testing.T, ref []byte) int {
+	t.Helper()
+	num, _, ok := parseRef(ref)
+	if !ok {
+		t.Fatalf("not a reference: %q", ref)
+	}
+	return num
+}
+
+func TestStampRejectsEncrypted(t *testing.T) {
+	encrypted := classicPDF(append(append([]string(nil), pageObjects...), "<< /Filter /Standard /V 1 /R 2 >>"), " /Encrypt 4 0 R")
+	if _, err := stamp(encrypted, Metadata{Title: "x"}, time.Now()); !errors.Is(err, ErrEncrypted) {
+		t.Errorf("stamp(encrypted) = %v, want ErrEncrypted", err)
+	}
+	if _, err := stamp([]byte("not a pdf"), Metadata{Title: "x"}, time.Now()); err == nil {
+		t.Error("stamp(garbage) succeeded")
+	}
+}
+
+func TestStampFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "doc.pdf")
+	if err := os.WriteFile(path, classicPDF(pageObjects, ""), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := Stamp(path, Metadata{Title: "Lease"}, time.Now()); err != nil {
+		t.Fatalf("Stamp: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	if got := readInfo(t, data).Key("Title").Text(); got != "Lease" {
+		t.Errorf("Title = %q, want Lease", got)
+	}
+	if fi, _ := os.Stat(path); fi.Mode().Perm() != 0600 {
+		t.Errorf("mode = %v, want 0600", fi.Mode().Perm())
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(path)); len(entries) != 1 {
+		t.Errorf("temporary files left behind: %v", entries)
+	}
+}
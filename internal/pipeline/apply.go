@@ -0,0 +1,176 @@
+package pipeline
+
+import (
+	"context"
+	"docs_organiser/internal/ai"
+	"docs_organiser/internal/audit"
+	"docs_organiser/internal/fileops"
+	"docs_organiser/internal/remote"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Decision is where one file should go, as listed in a CSV for Apply.
+type Decision struct {
+	File     string
+	Category string
+	// Title is the new file name; empty keeps the current one.
+	Title string
+}
+
+// LoadDecisions reads a CSV of file,category[,title] rows. A header row naming the file
+// and category columns (as in the results CSV of a classify-only run) may put them in any
+// order; other columns are ignored. Relative paths are resolved against the directory of
+// the CSV, and rows without a category, such as files that failed, are left out.
+func LoadDecisions(path string) ([]Decision, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	reader := csv.NewReader(f)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("invalid decisions file %s: %w", path, err)
+	}
+
+	fileCol, categoryCol, titleCol := 0, 1, 2
+	if len(rows) > 0 {
+		header := make([]string, len(rows[0]))
+		for i, name := range rows[0] {
+			header[i] = strings.ToLower(strings.TrimSpace(name))
+		}
+		if slices.Contains(header, "file") && slices.Contains(header, "category") {
+			fileCol, categoryCol, titleCol = slices.Index(header, "file"), slices.Index(header, "category"), slices.Index(header, "title")
+			rows = rows[1:]
+		}
+	}
+	cell := func(row []string, i int) string {
+		if i < 0 || i >= len(row) {
+			return ""
+		}
+		return strings.TrimSpace(row[i])
+	}
+
+	dir := filepath.Dir(path)
+	var decisions []Decision
+	for i, row := range rows {
+		d := Decision{File: cell(row, fileCol), Category: cell(row, categoryCol), Title: cell(row, titleCol)}
+		if d.File == "" {
+			return nil, fmt.Errorf("decisions file %s: row %d has no file", path, i+1)
+		}
+		if d.Category == "" {
+			continue
+		}
+		if !filepath.IsAbs(d.File) {
+			d.File = filepath.Join(dir, d.File)
+		}
+		decisions = append(decisions, d)
+	}
+	return decisions, nil
+}
+
+// Apply moves the files of decisions into their categories and names, as a run would
+// after classifying them: collisions, sidecars, notes, and the audit log work the same,
+// but nothing is classified. Files must be in the (local) source; those no longer there
+// are skipped.
+func (p *Pipeline) Apply(ctx context.Context, decisions []Decision) error {
+	if remote.IsRemote(p.SourceDir) {
+		return fmt.Errorf("applying decisions requires a local source directory")
+	}
+	source, err := filepath.EvalSymlinks(p.SourceDir)
+	if err == nil {
+		source, err = filepath.Abs(source)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open source: %w", err)
+	}
+
+	p.stats = newRunStats()
+	defer p.logRun(time.Now(), atomic.LoadInt32(&p.TotalFiles),
+		atomic.LoadInt32(&p.ProcessedFiles), atomic.LoadInt32(&p.FailedFiles))
+	if p.FailuresFile != "" {
+		defer func() {
+			if err := p.writeFailures(p.FailuresFile); err != nil {
+				log.Printf("[!] Failed to write the failures file: %v", err)
+			}
+		}()
+	}
+	defer p.removeVacatedDirs()
+
+	log.Printf("[*] Applying %d decisions", len(decisions))
+	for _, d := range decisions {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		path, err := filepath.EvalSymlinks(d.File)
+		if err == nil {
+			path, err = filepath.Abs(path)
+		}
+		if errors.Is(err, os.ErrNotExist) {
+			log.Printf("[*] Not applying %s: it is no longer in the source", d.File)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if !isWithin(path, source) {
+			log.Printf("[!] Not applying %s: it is outside the source", d.File)
+			continue
+		}
+		if info, err := os.Stat(path); err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+
+		atomic.AddInt32(&p.TotalFiles, 1)
+		fileCtx, cancel := context.WithTimeout(ctx, fileTimeout)
+		p.applyDecision(fileCtx, path, d)
+		cancel()
+		p.updateProgressDisplay()
+	}
+	return nil
+}
+
+// applyDecision organises the local file at path as d says. With an overlapping source
+// and destination, a row naming the file's current folder and title leaves it in place.
+func (p *Pipeline) applyDecision(ctx context.Context, path string, d Decision) {
+	job, name := FileJob{Path: path}, filepath.Base(path)
+	rec := audit.Record{Source: path}
+	defer func() {
+		p.stats.fail(job, rec)
+		p.recordFile(rec)
+	}()
+
+	category := ai.SanitizeCategory(d.Category)
+	if slices.Contains(strings.Split(category, "/"), "..") {
+		log.Printf("[!] Not applying %s: invalid category %q", name, d.Category)
+		atomic.AddInt32(&p.FailedFiles, 1)
+		rec.Status = audit.StatusMoveFailed
+		rec.Error = fmt.Sprintf("invalid category %q", d.Category)
+		return
+	}
+	rec.Category, rec.Title = p.Layout.folder(category, p.sourceDir(FileJob{Path: path})), name
+	if d.Title != "" {
+		ext := filepath.Ext(name)
+		title := d.Title
+		if strings.EqualFold(filepath.Ext(title), ext) {
+			title = strings.TrimSuffix(title, filepath.Ext(title))
+		}
+		rec.Title = ai.SanitizeFilename(title) + ext
+	}
+	rec.Title = fileops.TruncateName(rec.Title, p.MaxFilenameBytes)
+
+	log.Printf("[*] Applying: %s -> %s/%s", name, rec.Category, rec.Title)
+	p.organise(ctx, &rec, path, name, nil, "")
+}
@@ -0,0 +1,139 @@
+package pipeline
+
+import (
+	"context"
+	"docs_organiser/internal/aitest"
+	"docs_organiser/internal/audit"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadDecisions(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name string
+		csv  string
+		want []Decision
+	}{
+		{
+			name: "positional",
+			csv:  "a.pdf,Finance\n/abs/b.pdf,Work/Reports,Q3 Report\n",
+			want: []Decision{
+				{File: filepath.Join(dir, "a.pdf"), Category: "Finance"},
+				{File: "/abs/b.pdf", Category: "Work/Reports", Title: "Q3 Report"},
+			},
+		},
+		{
+			name: "results CSV",
+			csv: "file,category,title,confidence,status,destination,fallback,error\n" +
+				"/src/a.pdf,Finance,Invoice.pdf,0.92,classified,,false,\n" +
+				"/src/b.pdf,,,,extraction_failed,,false,no text\n",
+			want: []Decision{{File: "/src/a.pdf", Category: "Finance", Title: "Invoice.pdf"}},
+		},
+		{
+			name: "header in another order",
+			csv:  "Category,File\nTax,/src/c.pdf\n",
+			want: []Decision{{File: "/src/c.pdf", Category: "Tax"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, "decisions.csv")
+			if err := os.WriteFile(path, []byte(tt.csv), 0644); err != nil {
+				t.Fatal(err)
+			}
+			got, err := LoadDecisions(path)
+			if err != nil {
+				t.Fatalf("LoadDecisions: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("LoadDecisions() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	src, dst := writeSource(t, map[string]string{
+		"scan1.pdf": "first",
+		"scan2.txt": "second",
+		"scan3.txt": "third",
+		"keep.txt":  "not listed",
+	})
+	outside := filepath.Join(t.TempDir(), "outside.txt")
+	if err := os.WriteFile(outside, []byte("outside"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(dst, "Finance"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dst, "Finance", "Invoice.txt"), []byte("taken"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	engine := aitest.Engine(t, "http://127.0.0.1:0/v1", nil)
+	p := NewPipeline(src, dst, engine, 1, 0)
+	var got []audit.Record
+	p.OnFile = func(rec audit.Record) { got = append(got, rec) }
+	err := p.Apply(context.Background(), []Decision{
+		{File: filepath.Join(src, "scan1.pdf"), Category: "Finance/Invoices", Title: "ACME March.pdf"},
+		{File: filepath.Join(src, "scan2.txt"), Category: "Finance", Title: "Invoice"},
+		{File: filepath.Join(src, "scan3.txt"), Category: "Finance/../../escape"},
+		{File: filepath.Join(src, "gone.txt"), Category: "Finance"},
+		{File: outside, Category: "Finance"},
+	})
+	if err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dst, "Finance", "Invoices", "ACME March.pdf")); err != nil {
+		t.Errorf("scan1.pdf was not moved under its title: %v", err)
+	}
+	// The name is taken, so the collision policy adds the hash suffix
+	if matches, _ := filepath.Glob(filepath.Join(dst, "Finance", "Invoice_*.txt")); len(matches) != 1 {
+		t.Errorf("scan2.txt was not moved beside the existing Invoice.txt: %v", matches)
+	}
+	for _, name := range []string{"scan3.txt", "keep.txt"} {
+		if _, err := os.Stat(filepath.Join(src, name)); err != nil {
+			t.Errorf("%s left the source: %v", name, err)
+		}
+	}
+	if _, err := os.Stat(outside); err != nil {
+		t.Errorf("a file outside the source was moved: %v", err)
+	}
+	if len(got) != 3 || got[0].Status != audit.StatusMoved || got[2].Status != audit.StatusMoveFailed {
+		t.Errorf("records = %+v, want two moves and one invalid category", got)
+	}
+	if p.TotalFiles != 3 || p.ProcessedFiles != 2 || p.FailedFiles != 1 {
+		t.Errorf("counters = %d/%d/%d, want 3 total, 2 processed, 1 failed", p.TotalFiles, p.ProcessedFiles, p.FailedFiles)
+	}
+}
+
+func TestApply_CurrentLocation(t *testing.T) {
+	// The source contains the destination, so a row may name a file's own folder and title
+	dir := t.TempDir()
+	organised := filepath.Join(dir, "Finance", "Invoice.txt")
+	if err := os.MkdirAll(filepath.Dir(organised), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(organised, []byte("invoice"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	engine := aitest.Engine(t, "http://127.0.0.1:0/v1", nil)
+	p := NewPipeline(dir, dir, engine, 1, 0)
+	var got []audit.Record
+	p.OnFile = func(rec audit.Record) { got = append(got, rec) }
+	if err := p.Apply(context.Background(), []Decision{{File: organised, Category: "Finance", Title: "Invoice"}}); err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+
+	if data, err := os.ReadFile(organised); err != nil || string(data) != "invoice" {
+		t.Fatalf("the file was not left alone: %q, %v", data, err)
+	}
+	if len(got) != 1 || got[0].Status != audit.StatusMoved || got[0].Duplicate || got[0].Destination != organised {
+		t.Errorf("records = %+v, want the file recorded in place", got)
+	}
+}
@@ -0,0 +1,95 @@
+package pipeline
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// junkFiles are desktop metadata files that don't keep a directory from counting as empty.
+var junkFiles = map[string]bool{".DS_Store": true, "Thumbs.db": true, "desktop.ini": true}
+
+// vacate notes that a file was moved out of the local directory holding path.
+func (p *Pipeline) vacate(path string) {
+	if !p.RemoveEmptyDirs {
+		return
+	}
+	p.vacatedMu.Lock()
+	defer p.vacatedMu.Unlock()
+	if p.vacated == nil {
+		p.vacated = make(map[string]bool)
+	}
+	p.vacated[filepath.Dir(path)] = true
+}
+
+// removeVacatedDirs removes the source directories emptied during the run.
+func (p *Pipeline) removeVacatedDirs() {
+	p.vacatedMu.Lock()
+	dirs := make([]string, 0, len(p.vacated))
+	for dir := range p.vacated {
+		dirs = append(dirs, dir)
+	}
+	p.vacated = nil
+	p.vacatedMu.Unlock()
+
+	if n := removeEmptyDirs(p.SourceDir, dirs); n > 0 {
+		log.Printf("[*] Removed %d empty directories from %s", n, p.SourceDir)
+	}
+}
+
+// removeEmptyDirs removes each of dirs, and then its parents, while they are empty (or
+// hold only junkFiles), deepest first. root itself and directories outside it are kept.
+// It returns how many directories were removed.
+func removeEmptyDirs(root string, dirs []string) int {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return 0
+	}
+	candidates := make(map[string]bool)
+	for _, dir := range dirs {
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			continue
+		}
+		for abs != absRoot && isWithin(abs, absRoot) && !candidates[abs] {
+			candidates[abs] = true
+			abs = filepath.Dir(abs)
+		}
+	}
+	ordered := make([]string, 0, len(candidates))
+	for dir := range candidates {
+		ordered = append(ordered, dir)
+	}
+	sort.Slice(ordered, func(i, j int) bool {
+		return strings.Count(ordered[i], string(os.PathSeparator)) > strings.Count(ordered[j], string(os.PathSeparator))
+	})
+
+	removed := 0
+	for _, dir := range ordered {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		empty := true
+		for _, e := range entries {
+			if !junkFiles[e.Name()] || e.IsDir() {
+				empty = false
+				break
+			}
+		}
+		if !empty {
+			continue
+		}
+		for _, e := range entries {
+			os.Remove(filepath.Join(dir, e.Name()))
+		}
+		if err := os.Remove(dir); err != nil {
+			log.Printf("[!] Failed to remove empty directory %s: %v", dir, err)
+			continue
+		}
+		removed++
+	}
+	return removed
+}
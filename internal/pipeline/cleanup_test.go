@@ -0,0 +1,51 @@
+package pipeline
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRemoveEmptyDirs(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{"a/b/c", "a/keep", "junk", "untouched"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(root, "a", "keep", "doc.pdf"), []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "junk", ".DS_Store"), []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	outside := t.TempDir()
+	n := removeEmptyDirs(root, []string{
+		filepath.Join(root, "a", "b", "c"),
+		filepath.Join(root, "a", "keep"),
+		filepath.Join(root, "junk"),
+		root,
+		outside,
+	})
+	if n != 3 {
+		t.Errorf("removed %d directories, want 3 (a/b/c, a/b, junk)", n)
+	}
+
+	for dir, want := range map[string]bool{
+		"a/b":       false,
+		"a":         true, // still holds keep/
+		"a/keep":    true,
+		"junk":      false,
+		"untouched": true, // empty, but nothing was moved out of it
+		".":         true,
+	} {
+		_, err := os.Stat(filepath.Join(root, dir))
+		if exists := err == nil; exists != want {
+			t.Errorf("%s exists = %v, want %v", dir, exists, want)
+		}
+	}
+	if _, err := os.Stat(outside); err != nil {
+		t.Errorf("directory outside the root was removed: %v", err)
+	}
+}
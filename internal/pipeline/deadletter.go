@@ -0,0 +1,73 @@
+package pipeline
+
+import (
+	"log"
+	"os"
+	"path"
+	"sync"
+	"time"
+)
+
+// UnprocessedDir is the destination folder that unprocessable files are moved to, under
+// their directory relative to the source, when DeadLetterAfter is set.
+const UnprocessedDir = "_Unprocessed"
+
+// attempt is a file's count of failed extractions. A file whose size or modification
+// time changed since is counted afresh.
+type attempt struct {
+	Failures int       `json:"failures"`
+	Size     int64     `json:"size"`
+	ModTime  time.Time `json:"mod_time"`
+}
+
+// attemptCounter keeps the failures in memory when the pipeline has no Attempts store.
+type attemptCounter struct {
+	mu     sync.Mutex
+	counts map[string]attempt
+}
+
+// unprocessedFolder is the folder a file from job is dead-lettered to.
+func (p *Pipeline) unprocessedFolder(job FileJob) string {
+	return path.Join(UnprocessedDir, p.sourceDir(job))
+}
+
+// countFailure records a failed extraction of job's file, staged at local and recorded as
+// source, and reports whether it has now failed DeadLetterAfter times.
+func (p *Pipeline) countFailure(job FileJob, local, source string) bool {
+	size, modTime := job.size, job.modTime
+	if job.Key == "" {
+		if info, err := os.Stat(local); err == nil {
+			size, modTime = info.Size(), info.ModTime()
+		}
+	}
+	key := "attempts:" + source
+
+	var prev attempt
+	if p.Attempts != nil {
+		if _, err := p.Attempts.Load(key, &prev); err != nil {
+			log.Printf("[!] Failed to read the failure count of %s: %v", source, err)
+		}
+	} else {
+		p.attempts.mu.Lock()
+		prev = p.attempts.counts[key]
+		p.attempts.mu.Unlock()
+	}
+	next := attempt{Failures: 1, Size: size, ModTime: modTime}
+	if prev.Size == size && prev.ModTime.Equal(modTime) {
+		next.Failures = prev.Failures + 1
+	}
+
+	if p.Attempts != nil {
+		if err := p.Attempts.Save(key, next); err != nil {
+			log.Printf("[!] Failed to save the failure count of %s: %v", source, err)
+		}
+	} else {
+		p.attempts.mu.Lock()
+		if p.attempts.counts == nil {
+			p.attempts.counts = make(map[string]attempt)
+		}
+		p.attempts.counts[key] = next
+		p.attempts.mu.Unlock()
+	}
+	return next.Failures >= p.DeadLetterAfter
+}
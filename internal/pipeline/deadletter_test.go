@@ -0,0 +1,72 @@
+package pipeline
+
+import (
+	"context"
+	"docs_organiser/internal/aitest"
+	"docs_organiser/internal/storage"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRun_DeadLetter(t *testing.T) {
+	srv := aitest.NewServer(t, "mock-model")
+	srv.Respond(func(r aitest.Request) aitest.Reply {
+		if strings.Contains(r.User(), "Invoice") {
+			return aitest.Category("Finance", "Invoice_ACME", 0.9)
+		}
+		return aitest.Failure(500)
+	})
+
+	src, dst := writeSource(t, map[string]string{"invoice.txt": "Invoice 2024-001"})
+	if err := os.Mkdir(filepath.Join(src, "2023"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{"2023/broken.pdf": "not a PDF", "2023/garbled.txt": "???"} {
+		if err := os.WriteFile(filepath.Join(src, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	store, err := storage.NewBadgerStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	// Each run is a new process; the failures are counted in the store
+	run := func() *Pipeline {
+		p := NewPipeline(src, dst, srv.Engine(t, "Finance", "Misc"), 1, 0)
+		p.DeadLetterAfter, p.Attempts = 2, store
+		if err := p.Run(context.Background()); err != nil {
+			t.Fatalf("Run: %v", err)
+		}
+		return p
+	}
+
+	p := run()
+	if _, err := os.Stat(filepath.Join(dst, UnprocessedDir, "2023", "garbled.txt")); err != nil {
+		t.Errorf("unclassifiable file was not moved to %s: %v", UnprocessedDir, err)
+	}
+	if _, err := os.Stat(filepath.Join(src, "2023", "broken.pdf")); err != nil || p.FailedFiles != 1 {
+		t.Errorf("first extraction failure: failed %d, stat %v; want the file left in the source", p.FailedFiles, err)
+	}
+
+	p = run()
+	if _, err := os.Stat(filepath.Join(dst, UnprocessedDir, "2023", "broken.pdf")); err != nil {
+		t.Errorf("file failing extraction twice was not moved to %s: %v", UnprocessedDir, err)
+	}
+	if p.FailedFiles != 0 {
+		t.Errorf("failed %d, want the dead-lettered file counted as processed", p.FailedFiles)
+	}
+
+	categories, err := p.discoverCategories()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range categories {
+		if strings.HasPrefix(c, UnprocessedDir) {
+			t.Errorf("discovered %s as a category", c)
+		}
+	}
+}
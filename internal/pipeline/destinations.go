@@ -0,0 +1,93 @@
+package pipeline
+
+import (
+	"docs_organiser/internal/ai"
+	"docs_organiser/internal/remote"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// ParseDestinations validates a mapping of categories, or category prefixes, to the
+// destination roots their files go to instead of the destination. Categories are
+// sanitized like the model's answers; a prefix covers its sub-categories, so "Finance"
+// also routes "Finance/Tax".
+func ParseDestinations(m map[string]string) (map[string]string, error) {
+	if len(m) == 0 {
+		return nil, nil
+	}
+	roots := make(map[string]string, len(m))
+	for category, root := range m {
+		sanitized := ai.SanitizeCategory(category)
+		if sanitized == "" || slices.Contains(strings.Split(sanitized, "/"), "..") {
+			return nil, fmt.Errorf("invalid category %q in category_destinations", category)
+		}
+		if strings.TrimSpace(root) == "" {
+			return nil, fmt.Errorf("category_destinations.%s has no destination", category)
+		}
+		roots[sanitized] = root
+	}
+	return roots, nil
+}
+
+// destRoot returns the destination root files filed into folder go to: the root mapped
+// to the longest category prefix of folder, matched ignoring case, or DestDir.
+func (p *Pipeline) destRoot(folder string) string {
+	root, matched := p.DestDir, -1
+	for prefix, r := range p.Destinations {
+		if len(prefix) > matched && (strings.EqualFold(folder, prefix) ||
+			len(folder) > len(prefix) && strings.EqualFold(folder[:len(prefix)], prefix) && folder[len(prefix)] == '/') {
+			root, matched = r, len(prefix)
+		}
+	}
+	return root
+}
+
+// destRoots returns DestDir and every other destination root, once each, in the order of
+// their categories.
+func (p *Pipeline) destRoots() []string {
+	roots := []string{p.DestDir}
+	for _, category := range slices.Sorted(maps.Keys(p.Destinations)) {
+		if root := p.Destinations[category]; !slices.Contains(roots, root) {
+			roots = append(roots, root)
+		}
+	}
+	return roots
+}
+
+// sourceExclusions returns the source directories the walk must skip because local
+// destination roots lie in them (see overlapExclusions). Besides the categories, the
+// folders the photo and document path templates start with are skipped, so routed files
+// aren't routed again: their static root, or every folder their first one expands to.
+func (p *Pipeline) sourceExclusions() ([]string, error) {
+	folders := slices.Clone(p.AI.GetCategories())
+	var heads []*regexp.Regexp
+	var templates []string
+	if p.Photos != nil {
+		templates = append(templates, p.Photos.Path)
+	}
+	if p.Documents != nil {
+		templates = append(templates, p.Documents.Path)
+	}
+	for _, tmpl := range templates {
+		if root := templateRoot(tmpl); root != "" {
+			folders = append(folders, root)
+		} else if head := templateHead(tmpl); head != nil {
+			heads = append(heads, head)
+		}
+	}
+	var excluded []string
+	for _, root := range p.destRoots() {
+		if remote.IsRemote(root) {
+			continue
+		}
+		dirs, err := overlapExclusions(p.SourceDir, root, folders, heads)
+		if err != nil {
+			return nil, err
+		}
+		excluded = append(excluded, dirs...)
+	}
+	return excluded, nil
+}
@@ -0,0 +1,82 @@
+package pipeline
+
+import (
+	"context"
+	"docs_organiser/internal/aitest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDestRoot(t *testing.T) {
+	destinations, err := ParseDestinations(map[string]string{
+		"finance":     "/vault",
+		"Finance/Tax": "/tax",
+		"Medical/":    "s3://health/docs",
+	})
+	if err != nil {
+		t.Fatalf("ParseDestinations: %v", err)
+	}
+	p := &Pipeline{DestDir: "/archive", Destinations: destinations}
+	tests := map[string]string{
+		"Finance":            "/vault",
+		"Finance/Invoices":   "/vault",
+		"finance/tax/2023":   "/tax",
+		"Medical":            "s3://health/docs",
+		"Financial_Planning": "/archive",
+		"Misc":               "/archive",
+	}
+	for folder, want := range tests {
+		if got := p.destRoot(folder); got != want {
+			t.Errorf("destRoot(%q) = %q, want %q", folder, got, want)
+		}
+	}
+
+	for _, bad := range []map[string]string{{"Finance/../escape": "/vault"}, {"Finance": " "}} {
+		if _, err := ParseDestinations(bad); err == nil {
+			t.Errorf("ParseDestinations(%v) accepted", bad)
+		}
+	}
+}
+
+func TestRun_CategoryDestinations(t *testing.T) {
+	srv := aitest.NewServer(t, "mock-model")
+	srv.Respond(func(r aitest.Request) aitest.Reply {
+		if strings.Contains(r.User(), "Invoice") {
+			return aitest.Category("Finance/Invoices", "Invoice_ACME", 0.9)
+		}
+		return aitest.Category("Work", "Notes", 0.9)
+	})
+
+	src, dst := writeSource(t, map[string]string{
+		"invoice.txt": "Invoice 2024-001 from ACME Corp",
+		"notes.txt":   "Meeting notes",
+	})
+	vault := filepath.Join(t.TempDir(), "vault")
+	p := NewPipeline(src, dst, srv.Engine(t, "Finance/Invoices", "Work", "Misc"), 1, 0)
+	p.Destinations = map[string]string{"Finance": vault}
+	if err := p.Run(context.Background()); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	for _, path := range []string{
+		filepath.Join(vault, "Finance", "Invoices", "Invoice_ACME.txt"),
+		filepath.Join(dst, "Work", "Notes.txt"),
+	} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("%v", err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dst, "Finance")); !os.IsNotExist(err) {
+		t.Errorf("Finance was filed into the default destination: %v", err)
+	}
+
+	categories, err := p.discoverCategories()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(categories, ","); got != "Work,Finance,Finance/Invoices,Misc" {
+		t.Errorf("discovered categories = %s", got)
+	}
+}
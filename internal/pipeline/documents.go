@@ -0,0 +1,115 @@
+package pipeline
+
+import (
+	"docs_organiser/internal/ai"
+	"docs_organiser/internal/audit"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// DocumentRouting files classified documents by templates over the model's answer and
+// the document date, e.g. "{{category}}/{{year}}" and "{{date}} {{title}}". Templates use
+// {{name}} placeholders; see documentVariables for the list.
+type DocumentRouting struct {
+	// Path is the destination folder; empty keeps the category.
+	Path string
+	// Name is the file name without its extension; empty keeps the model's title.
+	Name string
+}
+
+// documentVariables are the placeholders a document template may use.
+var documentVariables = []string{"category", "title", "year", "month", "day", "date", "name"}
+
+// Validate reports templates that reference unknown placeholders or escape the destination.
+func (r DocumentRouting) Validate() error {
+	for _, tmpl := range []string{r.Path, r.Name} {
+		for _, m := range placeholder.FindAllStringSubmatch(tmpl, -1) {
+			if !slices.Contains(documentVariables, m[1]) {
+				return fmt.Errorf("unknown placeholder {{%s}} in %q (available: %s)", m[1], tmpl, strings.Join(documentVariables, ", "))
+			}
+		}
+	}
+	for _, segment := range strings.Split(filepath.ToSlash(r.Path), "/") {
+		if segment == ".." {
+			return fmt.Errorf("document path template %q must stay inside the destination", r.Path)
+		}
+	}
+	if strings.ContainsAny(r.Name, `/\`) {
+		return fmt.Errorf("document name template %q must not contain path separators", r.Name)
+	}
+	return nil
+}
+
+// route returns the folder and file name for the classified file at path, originally
+// called name. Documents without a document_date use their modification time.
+func (r DocumentRouting) route(path, name string, rec audit.Record) (folder, filename string) {
+	folder, filename = rec.Category, rec.Title
+	var dated time.Time
+	if rec.Classification != nil && rec.Classification.Analysis != nil {
+		dated, _ = time.Parse(time.DateOnly, rec.Classification.Analysis.DocumentDate)
+	}
+	if dated.IsZero() {
+		if fi, err := os.Stat(path); err == nil {
+			dated = fi.ModTime()
+		} else {
+			dated = time.Now()
+		}
+	}
+
+	ext := filepath.Ext(rec.Title)
+	vars := map[string]string{
+		"title": strings.TrimSuffix(rec.Title, ext),
+		"year":  dated.Format("2006"),
+		"month": dated.Format("01"),
+		"day":   dated.Format("02"),
+		"date":  dated.Format("2006-01-02"),
+		"name":  strings.TrimSuffix(name, filepath.Ext(name)),
+	}
+	expand := func(tmpl string) string {
+		return placeholder.ReplaceAllStringFunc(tmpl, func(m string) string {
+			key := placeholder.FindStringSubmatch(m)[1]
+			if key == "category" {
+				// Already a sanitized path; its slashes are meant as folders
+				return rec.Category
+			}
+			return ai.SanitizeFilename(vars[key])
+		})
+	}
+
+	if r.Path != "" {
+		var segments []string
+		for _, segment := range strings.Split(filepath.ToSlash(expand(r.Path)), "/") {
+			if segment = strings.TrimSpace(segment); segment != "" && segment != "." {
+				segments = append(segments, segment)
+			}
+		}
+		if len(segments) > 0 {
+			folder = strings.Join(segments, "/")
+		}
+	}
+	if r.Name != "" {
+		if stem := strings.TrimSpace(expand(r.Name)); stem != "" {
+			filename = stem + ext
+		}
+	}
+	return folder, filename
+}
+
+// isInvoiceCategory reports whether documents in category get a second request for their
+// billing details: it is one of InvoiceCategories or below one (case-insensitively).
+func (p *Pipeline) isInvoiceCategory(category string) bool {
+	if !p.usesModel() {
+		return false
+	}
+	for _, c := range p.InvoiceCategories {
+		c = strings.Trim(c, "/")
+		if strings.EqualFold(category, c) || len(category) > len(c) && strings.EqualFold(category[:len(c)+1], c+"/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,86 @@
+package pipeline
+
+import (
+	"docs_organiser/internal/ai"
+	"docs_organiser/internal/audit"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDocumentRoutingValidate(t *testing.T) {
+	tests := []struct {
+		routing DocumentRouting
+		wantErr bool
+	}{
+		{DocumentRouting{Path: "{{category}}/{{year}}"}, false},
+		{DocumentRouting{Name: "{{ date }} {{title}}"}, false},
+		{DocumentRouting{Path: "{{category}}/{{camera}}"}, true},
+		{DocumentRouting{Path: "../{{category}}"}, true},
+		{DocumentRouting{Name: "{{year}}/{{title}}"}, true},
+	}
+	for _, tt := range tests {
+		if err := tt.routing.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%+v.Validate() = %v, wantErr %v", tt.routing, err, tt.wantErr)
+		}
+	}
+}
+
+func TestDocumentRoute(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scan 12.pdf")
+	if err := os.WriteFile(path, []byte("%PDF"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	mtime := time.Date(2023, 7, 14, 9, 5, 3, 0, time.Local)
+	if err := os.Chtimes(path, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+	classified := func(date string) audit.Record {
+		return audit.Record{
+			Category:       "Finance/Bank",
+			Title:          "HDFC_Statement.pdf",
+			Classification: &ai.CategorizationResult{Analysis: &ai.AnalysisResult{DocumentDate: date}},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		routing    DocumentRouting
+		rec        audit.Record
+		wantFolder string
+		wantName   string
+	}{
+		{"dated folder", DocumentRouting{Path: "{{category}}/{{year}}"}, classified("2024-03-31"), "Finance/Bank/2024", "HDFC_Statement.pdf"},
+		{"date prefix", DocumentRouting{Name: "{{date}} {{title}}"}, classified("2024-03-31"), "Finance/Bank", "2024-03-31 HDFC_Statement.pdf"},
+		{"undated uses modification time", DocumentRouting{Path: "{{year}}/{{month}}/{{category}}", Name: "{{name}}"}, classified(""), "2023/07/Finance/Bank", "scan 12.pdf"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			folder, name := tt.routing.route(path, filepath.Base(path), tt.rec)
+			if folder != tt.wantFolder || name != tt.wantName {
+				t.Errorf("route = %q, %q; want %q, %q", folder, name, tt.wantFolder, tt.wantName)
+			}
+		})
+	}
+}
+
+func TestIsInvoiceCategory(t *testing.T) {
+	p := &Pipeline{InvoiceCategories: []string{"Receipts", "Finance/"}}
+	tests := map[string]bool{
+		"Receipts":       true,
+		"receipts":       true,
+		"Finance/Bills":  true,
+		"FinanceArchive": false,
+		"Travel":         false,
+	}
+	for category, want := range tests {
+		if got := p.isInvoiceCategory(category); got != want {
+			t.Errorf("isInvoiceCategory(%q) = %v, want %v", category, got, want)
+		}
+	}
+	p.NoLLM = true
+	if p.isInvoiceCategory("Receipts") {
+		t.Error("no-LLM runs should not request invoice details")
+	}
+}
@@ -0,0 +1,52 @@
+package pipeline
+
+import (
+	"context"
+	"docs_organiser/internal/aitest"
+	"docs_organiser/internal/config"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// drainOnFirstFile drains the pipeline as soon as the first file event is written.
+type drainOnFirstFile struct{ p *Pipeline }
+
+func (d drainOnFirstFile) Write(b []byte) (int, error) {
+	if strings.Contains(string(b), `"event":"file"`) {
+		d.p.Drain()
+	}
+	return len(b), nil
+}
+
+func TestRun_Drain(t *testing.T) {
+	root := t.TempDir()
+	src, dst := filepath.Join(root, "src"), filepath.Join(root, "dst")
+	if err := os.MkdirAll(src, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 20; i++ {
+		if err := os.WriteFile(filepath.Join(src, fmt.Sprintf("invoice%02d.txt", i)), []byte("Invoice for payment"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	engine := aitest.Engine(t, "http://localhost:8080/v1", []config.ModelDefinition{{Name: "m"}}, "Finance", "Misc")
+	p := NewPipeline(src, dst, engine, 1, 0)
+	p.NoLLM = true
+	p.Events = NewEventWriter(drainOnFirstFile{p})
+
+	if err := p.Run(context.Background()); err != context.Canceled {
+		t.Errorf("Run = %v, want context.Canceled", err)
+	}
+	if p.ProcessedFiles != 1 || p.FailedFiles != 0 {
+		t.Errorf("processed %d, failed %d; want the in-flight file finished and nothing else started", p.ProcessedFiles, p.FailedFiles)
+	}
+	left, _ := os.ReadDir(src)
+	moved, _ := os.ReadDir(filepath.Join(dst, "Finance"))
+	if len(left) != 19 || len(moved) != 1 {
+		t.Errorf("%d files left in the source and %d moved, want 19 and 1", len(left), len(moved))
+	}
+}
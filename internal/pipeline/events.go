@@ -0,0 +1,69 @@
+package pipeline
+
+import (
+	"docs_organiser/internal/audit"
+	"docs_organiser/internal/notify"
+	"encoding/json"
+	"io"
+	"log"
+	"sync"
+	"time"
+)
+
+// Event types written by an EventWriter.
+const (
+	EventRunStarted   = "run_started"
+	EventProgress     = "progress"
+	EventFile         = "file"
+	EventRunCompleted = "run_completed"
+)
+
+// Event is one line of machine-readable output (--output json).
+type Event struct {
+	Type string    `json:"event"`
+	Time time.Time `json:"time"`
+
+	// Source and Dest are the run's directories (run_started)
+	Source string `json:"source,omitempty"`
+	Dest   string `json:"dest,omitempty"`
+	// Progress holds the pipeline's counters after each file (progress)
+	Progress *Progress `json:"progress,omitempty"`
+
+	// File holds the audit record of a finished file.
+	File *audit.Record `json:"file,omitempty"`
+	// Run summarizes a finished run; Error is set when it failed.
+	Run   *notify.RunSummary `json:"run,omitempty"`
+	Error string             `json:"error,omitempty"`
+}
+
+// Progress counts the files the pipeline has seen and finished.
+type Progress struct {
+	Total     int32   `json:"total"`
+	Completed int32   `json:"completed"`
+	Processed int32   `json:"processed"`
+	Failed    int32   `json:"failed"`
+	Percent   float64 `json:"percent"`
+}
+
+// EventWriter writes events as newline-delimited JSON, so GUIs and scripts can follow a
+// run without scraping the terminal progress line. It is safe for concurrent use.
+type EventWriter struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// NewEventWriter writes events to w.
+func NewEventWriter(w io.Writer) *EventWriter {
+	return &EventWriter{enc: json.NewEncoder(w)}
+}
+
+func (w *EventWriter) emit(ev Event) {
+	if ev.Time.IsZero() {
+		ev.Time = time.Now().UTC()
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := w.enc.Encode(ev); err != nil {
+		log.Printf("[!] Failed to write %s event: %v", ev.Type, err)
+	}
+}
@@ -0,0 +1,37 @@
+package pipeline
+
+import (
+	"bufio"
+	"bytes"
+	"docs_organiser/internal/audit"
+	"encoding/json"
+	"testing"
+)
+
+func TestEventWriter(t *testing.T) {
+	var buf bytes.Buffer
+	p := &Pipeline{Events: NewEventWriter(&buf), TotalFiles: 3, ProcessedFiles: 1}
+
+	p.recordFile(audit.Record{Source: "/in/a.pdf", Status: audit.StatusMoved, Category: "Finance"})
+	p.updateProgressDisplay()
+
+	var events []Event
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		var ev Event
+		if err := json.Unmarshal(scanner.Bytes(), &ev); err != nil {
+			t.Fatalf("line %q is not an event: %v", scanner.Text(), err)
+		}
+		events = append(events, ev)
+	}
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want 2:\n%s", len(events), buf.String())
+	}
+	if ev := events[0]; ev.Type != EventFile || ev.File == nil || ev.File.Category != "Finance" || ev.Time.IsZero() {
+		t.Errorf("file event = %+v", ev)
+	}
+	want := Progress{Total: 3, Completed: 1, Processed: 1, Percent: 33.3}
+	if ev := events[1]; ev.Type != EventProgress || ev.Progress == nil || *ev.Progress != want {
+		t.Errorf("progress event = %+v, want progress %+v", ev, want)
+	}
+}
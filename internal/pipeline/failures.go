@@ -0,0 +1,122 @@
+package pipeline
+
+import (
+	"context"
+	"docs_organiser/internal/audit"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Failure is a file that could not be organised, as listed in a failures file.
+type Failure struct {
+	// File is the local path, or the URL of a file in a remote source.
+	File string `json:"file"`
+	// Key identifies a file in a remote source.
+	Key    string `json:"key,omitempty"`
+	Status string `json:"status"`
+	Error  string `json:"error"`
+}
+
+// FailureList is the content of a failures file: the files that failed in one run.
+// Run writes it to FailuresFile, and processes only its files when it is set as Retry.
+type FailureList struct {
+	Source   string    `json:"source"`
+	Finished time.Time `json:"finished"`
+	Files    []Failure `json:"files"`
+}
+
+// LoadFailures reads a failures file written by an earlier run.
+func LoadFailures(path string) (*FailureList, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var list FailureList
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("%s is not a failures file: %w", path, err)
+	}
+	return &list, nil
+}
+
+// writeFailures replaces path with the failures of the current run.
+func (p *Pipeline) writeFailures(path string) error {
+	return writeList(path, FailureList{Source: p.SourceDir, Finished: time.Now(), Files: p.stats.failedFiles()})
+}
+
+// writeList replaces path with list. The file is renamed into place, so a run retrying
+// from the same path reads the whole previous list.
+func writeList(path string, list FailureList) error {
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".failures-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// checkSource rejects a list of files from another source.
+func (list *FailureList) checkSource(source string) error {
+	if filepath.Clean(list.Source) != filepath.Clean(source) {
+		return fmt.Errorf("the failures file lists files of %s, not of the source %s", list.Source, source)
+	}
+	return nil
+}
+
+// scanRetry enqueues the files of list in place of scanning the source. Local files that
+// are gone or outside the source are skipped; the filters and ignore file don't apply.
+func (p *Pipeline) scanRetry(ctx context.Context, list *FailureList, isRemote bool, enqueue func(FileJob) error) error {
+	for _, f := range list.Files {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if isRemote {
+			if f.Key == "" {
+				continue
+			}
+			if err := enqueue(FileJob{Key: f.Key}); err != nil {
+				return err
+			}
+			continue
+		}
+		if !isWithin(f.File, p.SourceDir) {
+			log.Printf("[!] Not retrying %s: it is outside the source", f.File)
+			continue
+		}
+		info, err := os.Stat(f.File)
+		if errors.Is(err, os.ErrNotExist) {
+			log.Printf("[*] Not retrying %s: it is no longer in the source", f.File)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			continue
+		}
+		if err := enqueue(FileJob{Path: f.File, size: info.Size(), modTime: info.ModTime()}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// failed reports whether rec is an outcome listed in the failures file.
+func failed(rec audit.Record) bool {
+	return rec.Status == audit.StatusExtractionFailed || rec.Status == audit.StatusMoveFailed
+}
@@ -0,0 +1,66 @@
+package pipeline
+
+import (
+	"context"
+	"docs_organiser/internal/aitest"
+	"docs_organiser/internal/audit"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRun_FailuresFileAndRetry(t *testing.T) {
+	srv := aitest.NewServer(t, "mock-model")
+	srv.Respond(func(aitest.Request) aitest.Reply {
+		return aitest.Category("Finance", "Invoice_ACME", 0.9)
+	})
+
+	src, dst := writeSource(t, map[string]string{
+		"invoice.txt": "Invoice 2024-001 from ACME Corp",
+		"broken.pdf":  "not a PDF",
+	})
+	failures := filepath.Join(t.TempDir(), "failures.json")
+	p := NewPipeline(src, dst, srv.Engine(t, "Finance", "Misc"), 1, 0)
+	p.FailuresFile = failures
+	if err := p.Run(context.Background()); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	list, err := LoadFailures(failures)
+	if err != nil {
+		t.Fatalf("LoadFailures: %v", err)
+	}
+	broken := filepath.Join(src, "broken.pdf")
+	if list.Source != src || len(list.Files) != 1 || list.Files[0].File != broken ||
+		list.Files[0].Status != audit.StatusExtractionFailed || list.Files[0].Error == "" {
+		t.Fatalf("failures file = %+v, want broken.pdf with its error", list)
+	}
+
+	// The retry only processes the listed files that are still in the source
+	if err := os.WriteFile(filepath.Join(src, "new.txt"), []byte("Invoice"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	list.Files = append(list.Files, Failure{File: filepath.Join(src, "gone.pdf"), Status: audit.StatusMoveFailed})
+	p.Retry = list
+	if err := p.Run(context.Background()); err != nil {
+		t.Fatalf("retry Run: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(src, "new.txt")); err != nil {
+		t.Errorf("retry processed a file that was not listed: %v", err)
+	}
+	retried, err := LoadFailures(failures)
+	if err != nil {
+		t.Fatalf("LoadFailures after retry: %v", err)
+	}
+	if len(retried.Files) != 1 || retried.Files[0].File != broken {
+		t.Errorf("failures after retry = %+v, want only broken.pdf", retried.Files)
+	}
+
+	p.Retry = &FailureList{Source: dst}
+	if err := p.Run(context.Background()); err == nil {
+		t.Error("retry accepted a failures file of another source")
+	}
+	if kept, err := LoadFailures(failures); err != nil || len(kept.Files) != 1 {
+		t.Errorf("a rejected retry replaced the failures file: %+v, %v", kept, err)
+	}
+}
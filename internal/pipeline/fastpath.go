@@ -0,0 +1,75 @@
+package pipeline
+
+import (
+	"context"
+	"docs_organiser/internal/ai"
+	"docs_organiser/internal/extractor"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// classifyByName asks the model about the file's name, folder, and metadata only. It
+// returns nil when that fails or the confidence is below the fast path threshold, so the
+// caller reads the text instead.
+func (p *Pipeline) classifyByName(ctx context.Context, job FileJob, localPath, name string) *ai.CategorizationResult {
+	info := ai.FileInfo{Name: name, Folder: p.sourceFolder(job)}
+	if stat, err := os.Stat(localPath); err == nil {
+		info.Size, info.Modified = stat.Size(), stat.ModTime()
+	}
+	metadata, err := extractor.Metadata(localPath)
+	if err != nil {
+		log.Printf("[!] Failed to read metadata of %s: %v", name, err)
+	}
+	info.Metadata = metadata
+
+	result, err := p.AI.CategorizeFileInfo(ctx, info)
+	if err != nil {
+		log.Printf("[!] Fast path failed for %s, reading its text: %v", name, err)
+		return nil
+	}
+	if result.Analysis.ConfidenceScore < p.FastPath {
+		log.Printf("[*] Fast path unsure about %s (confidence %.2f), reading its text", name, result.Analysis.ConfidenceScore)
+		return nil
+	}
+	log.Printf("[+] Fast path: %s | Category: %s | Confidence: %.2f | Tokens: %d",
+		name, result.Analysis.Category, result.Analysis.ConfidenceScore, result.Metadata.TotalTokens)
+	return result
+}
+
+// sourceFolder is the name of the folder holding the job's file, or "" for files at the
+// top of the source, whose folder says nothing about them.
+func (p *Pipeline) sourceFolder(job FileJob) string {
+	if job.Key != "" {
+		if dir := path.Dir(job.Key); dir != "." && dir != "/" {
+			return path.Base(dir)
+		}
+		return ""
+	}
+	dir := filepath.Dir(job.Path)
+	if dir == "." || filepath.Clean(dir) == filepath.Clean(p.SourceDir) {
+		return ""
+	}
+	return filepath.Base(dir)
+}
+
+// sourceDir is the directory holding the job's file relative to the source root, with
+// forward slashes, or "" for files at the top of the source.
+func (p *Pipeline) sourceDir(job FileJob) string {
+	if job.Key != "" {
+		if dir := strings.Trim(path.Dir(job.Key), "/"); dir != "." {
+			return dir
+		}
+		return ""
+	}
+	if p.SourceDir == "" {
+		return ""
+	}
+	rel, err := filepath.Rel(p.SourceDir, filepath.Dir(job.Path))
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return ""
+	}
+	return filepath.ToSlash(rel)
+}
@@ -0,0 +1,49 @@
+package pipeline
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestSourceFolder(t *testing.T) {
+	p := &Pipeline{SourceDir: filepath.Join("home", "inbox")}
+	tests := []struct {
+		name string
+		job  FileJob
+		want string
+	}{
+		{"top of source", FileJob{Path: filepath.Join("home", "inbox", "a.pdf")}, ""},
+		{"sub-folder", FileJob{Path: filepath.Join("home", "inbox", "Taxes 2023", "a.pdf")}, "Taxes 2023"},
+		{"remote top", FileJob{Key: "a.pdf"}, ""},
+		{"remote sub-folder", FileJob{Key: "scans/bank/a.pdf"}, "bank"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := p.sourceFolder(tt.job); got != tt.want {
+				t.Errorf("sourceFolder = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSourceDir(t *testing.T) {
+	p := &Pipeline{SourceDir: filepath.Join("home", "inbox")}
+	tests := []struct {
+		name string
+		job  FileJob
+		want string
+	}{
+		{"top of source", FileJob{Path: filepath.Join("home", "inbox", "a.pdf")}, ""},
+		{"nested", FileJob{Path: filepath.Join("home", "inbox", "Bank", "2024", "a.pdf")}, "Bank/2024"},
+		{"outside the source", FileJob{Path: filepath.Join("elsewhere", "a.pdf")}, ""},
+		{"remote top", FileJob{Key: "a.pdf"}, ""},
+		{"remote nested", FileJob{Key: "scans/bank/a.pdf"}, "scans/bank"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := p.sourceDir(tt.job); got != tt.want {
+				t.Errorf("sourceDir = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package pipeline
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// IdleMonitor releases heavyweight resources once no pipeline run has been active for Timeout.
+// Resources are re-acquired lazily by the next run (connections redial, caches refill), which
+// first warms the model up again (see Begin).
+type IdleMonitor struct {
+	Timeout time.Duration
+	Release func(ctx context.Context)
+
+	mu         sync.Mutex
+	active     int
+	lastActive time.Time
+	released   bool
+	now        func() time.Time // time.Now when nil
+}
+
+// Begin marks a run as active, cancelling any pending release. It reports whether the
+// resources were released since the last run, so the model needs warming up again.
+func (m *IdleMonitor) Begin() (resumed bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.active++
+	resumed, m.released = m.released, false
+	return resumed
+}
+
+// End marks a run as finished and restarts the idle clock.
+func (m *IdleMonitor) End() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.active > 0 {
+		m.active--
+	}
+	m.lastActive = m.clock()
+}
+
+// Run checks for idleness until ctx is cancelled.
+func (m *IdleMonitor) Run(ctx context.Context) {
+	if m.Timeout <= 0 || m.Release == nil {
+		return
+	}
+
+	m.mu.Lock()
+	if m.lastActive.IsZero() {
+		m.lastActive = m.clock()
+	}
+	m.mu.Unlock()
+
+	interval := m.Timeout / 4
+	if interval > 30*time.Second {
+		interval = 30 * time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if m.shouldRelease() {
+				m.Release(ctx)
+			}
+		}
+	}
+}
+
+// shouldRelease reports whether the idle timeout has elapsed, marking resources released if so.
+func (m *IdleMonitor) shouldRelease() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.active > 0 || m.released || m.clock().Sub(m.lastActive) < m.Timeout {
+		return false
+	}
+	m.released = true
+	return true
+}
+
+func (m *IdleMonitor) clock() time.Time {
+	if m.now != nil {
+		return m.now()
+	}
+	return time.Now()
+}
@@ -0,0 +1,61 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestIdleMonitor(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	releases := 0
+	m := &IdleMonitor{
+		Timeout: time.Minute,
+		Release: func(ctx context.Context) { releases++ },
+		now:     func() time.Time { return now },
+	}
+	check := func() {
+		if m.shouldRelease() {
+			m.Release(context.Background())
+		}
+	}
+
+	// No release while a run is active, however long it takes
+	if m.Begin() {
+		t.Error("the first run reports released resources")
+	}
+	now = now.Add(time.Hour)
+	check()
+	if releases != 0 {
+		t.Fatalf("released %d times during an active run, want 0", releases)
+	}
+
+	// Exactly one release once idle for the timeout
+	m.End()
+	now = now.Add(59 * time.Second)
+	check()
+	if releases != 0 {
+		t.Fatalf("released %d times before the timeout, want 0", releases)
+	}
+	now = now.Add(time.Second)
+	check()
+	check()
+	if releases != 1 {
+		t.Fatalf("released %d times after the timeout, want 1", releases)
+	}
+
+	// The next run learns the resources are gone, and re-arms the monitor
+	if !m.Begin() {
+		t.Error("the run after a release doesn't report it")
+	}
+	m.End()
+	if m.Begin() {
+		t.Error("a run without a release in between reports one")
+	}
+	m.End()
+	now = now.Add(time.Minute)
+	check()
+	if releases != 2 {
+		t.Fatalf("released %d times after the second idle period, want 2", releases)
+	}
+}
@@ -0,0 +1,142 @@
+package pipeline
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// IgnoreFileName is the file in the source root whose gitignore-style patterns exclude
+// paths from scanning.
+const IgnoreFileName = ".docsorganiserignore"
+
+// ignoreRule is one pattern line of an ignore file.
+type ignoreRule struct {
+	re      *regexp.Regexp
+	negate  bool // "!pattern" re-includes what an earlier pattern excluded
+	dirOnly bool // "pattern/" only matches directories
+}
+
+// IgnoreRules are the patterns of an ignore file, in gitignore syntax: "#" starts a
+// comment, "!" negates, a trailing "/" matches directories only, a pattern containing
+// another "/" is anchored to the source root, and "**" spans directories. The last
+// matching pattern decides; like the include and exclude patterns, matching ignores case.
+type IgnoreRules struct {
+	rules []ignoreRule
+}
+
+// LoadIgnoreFile reads the ignore file at path. A missing file yields nil rules, which
+// ignore nothing.
+func LoadIgnoreFile(path string) (*IgnoreRules, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var ig IgnoreRules
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		rule, ok, err := parseIgnoreLine(scanner.Text())
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, n, err)
+		}
+		if ok {
+			ig.rules = append(ig.rules, rule)
+		}
+	}
+	return &ig, scanner.Err()
+}
+
+// parseIgnoreLine compiles one line; ok is false for blank lines and comments.
+func parseIgnoreLine(line string) (rule ignoreRule, ok bool, err error) {
+	line = strings.TrimSuffix(line, "\r")
+	// Trailing spaces are dropped unless escaped
+	for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, "\\ ") {
+		line = line[:len(line)-1]
+	}
+	if line == "" || strings.HasPrefix(line, "#") {
+		return rule, false, nil
+	}
+	if rest, negated := strings.CutPrefix(line, "!"); negated {
+		rule.negate, line = true, rest
+	} else if strings.HasPrefix(line, "\\#") || strings.HasPrefix(line, "\\!") {
+		line = line[1:]
+	}
+	if rest, dir := strings.CutSuffix(line, "/"); dir {
+		rule.dirOnly, line = true, rest
+	}
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+	if line == "" {
+		return rule, false, nil
+	}
+
+	expr := "(?i)^"
+	if !anchored {
+		expr += "(?:.*/)?"
+	}
+	expr += globToRegexp(line) + "$"
+	if rule.re, err = regexp.Compile(expr); err != nil {
+		return rule, false, fmt.Errorf("invalid pattern %q", line)
+	}
+	return rule, true, nil
+}
+
+// globToRegexp translates gitignore glob syntax to a regular expression.
+func globToRegexp(glob string) string {
+	var b strings.Builder
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; {
+		case strings.HasPrefix(glob[i:], "**/"):
+			b.WriteString("(?:.*/)?") // zero or more directories
+			i += 2
+		case strings.HasPrefix(glob[i:], "**") && i+2 == len(glob):
+			b.WriteString(".*") // everything inside
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		case c == '\\' && i+1 < len(glob):
+			i++
+			b.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return b.String()
+}
+
+// Ignored reports whether rel (relative to the source root, slash-separated) is excluded.
+// Files inside an ignored directory are not re-included; the walk never enters it.
+func (ig *IgnoreRules) Ignored(rel string, isDir bool) bool {
+	if ig == nil {
+		return false
+	}
+	ignored := false
+	for _, r := range ig.rules {
+		if (!r.dirOnly || isDir) && r.re.MatchString(rel) {
+			ignored = !r.negate
+		}
+	}
+	return ignored
+}
@@ -0,0 +1,92 @@
+package pipeline
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIgnoreRules(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, IgnoreFileName)
+	rules := `# junk from the shared drive
+node_modules/
+*.tmp
+/Inbox/drafts
+Archive/**/old_*.pdf
+**/cache/**
+\#literal.txt
+build
+!build/keep.pdf
+`
+	if err := os.WriteFile(file, []byte(rules), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ig, err := LoadIgnoreFile(file)
+	if err != nil {
+		t.Fatalf("LoadIgnoreFile: %v", err)
+	}
+
+	tests := []struct {
+		rel   string
+		isDir bool
+		want  bool
+	}{
+		{"node_modules", true, true},
+		{"a/b/node_modules", true, true},
+		{"node_modules", false, false}, // a file of that name
+		{"notes.tmp", false, true},
+		{"a/b/Notes.TMP", false, true},
+		{"Inbox/drafts", true, true},
+		{"Other/Inbox/drafts", true, false},
+		{"Archive/old_bill.pdf", false, true},
+		{"Archive/2019/03/old_bill.pdf", false, true},
+		{"Archive/2019/bill.pdf", false, false},
+		{"x/cache/y/z.pdf", false, true},
+		{"#literal.txt", false, true},
+		{"build", true, true},
+		{"build/keep.pdf", false, false},
+		{"report.pdf", false, false},
+	}
+	for _, tt := range tests {
+		if got := ig.Ignored(tt.rel, tt.isDir); got != tt.want {
+			t.Errorf("Ignored(%q, dir=%v) = %v, want %v", tt.rel, tt.isDir, got, tt.want)
+		}
+	}
+
+	if ig, err := LoadIgnoreFile(filepath.Join(dir, "missing")); err != nil || ig.Ignored("a.pdf", false) {
+		t.Errorf("missing ignore file: %v, %v", ig, err)
+	}
+}
+
+func TestScanLocal_IgnoreFile(t *testing.T) {
+	src := t.TempDir()
+	for _, rel := range []string{"keep.pdf", "Junk/skip.pdf", "a/draft.tmp.pdf", "a/b/report.pdf"} {
+		f := filepath.Join(src, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(f), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(f, []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(src, IgnoreFileName), []byte("Junk/\n*.tmp.pdf\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	p := &Pipeline{SourceDir: src, Filter: ScanFilter{Include: []string{"*"}}}
+	var got []string
+	err := p.scanLocal(context.Background(), nil, func(job FileJob) error {
+		rel, _ := filepath.Rel(src, job.Path)
+		got = append(got, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(got, ",") != "a/b/report.pdf,keep.pdf" {
+		t.Errorf("scanned %v, want a/b/report.pdf and keep.pdf", got)
+	}
+}
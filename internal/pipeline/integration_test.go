@@ -0,0 +1,244 @@
+package pipeline
+
+import (
+	"context"
+	"docs_organiser/internal/aitest"
+	"docs_organiser/internal/audit"
+	"docs_organiser/internal/notify"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeSource creates a source and destination under a temp directory, with the given
+// files in the source.
+func writeSource(t *testing.T, files map[string]string) (src, dst string) {
+	t.Helper()
+	root := t.TempDir()
+	src, dst = filepath.Join(root, "src"), filepath.Join(root, "dst")
+	if err := os.MkdirAll(src, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(src, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return src, dst
+}
+
+func TestRun_EndToEnd(t *testing.T) {
+	srv := aitest.NewServer(t, "mock-model")
+	srv.Respond(func(r aitest.Request) aitest.Reply {
+		switch {
+		case strings.Contains(r.User(), "Invoice"):
+			return aitest.Category("Finance", "Invoice_ACME", 0.9)
+		case strings.Contains(r.User(), "Boarding"):
+			return aitest.Category("Travel", "Boarding_Pass_LH123", 0.8)
+		case strings.Contains(r.User(), "Payslip"):
+			// Not one of the categories, so every attempt is rejected
+			return aitest.Category("Salary", "Payslip", 0.7)
+		}
+		return aitest.Failure(500)
+	})
+
+	src, dst := writeSource(t, map[string]string{
+		"scan1.txt": "Invoice 2024-001 from ACME Corp",
+		"scan2.txt": "Boarding pass LH123 Frankfurt",
+		"scan3.txt": "Payslip for March",
+		"scan4.txt": "Unreadable",
+	})
+	p := NewPipeline(src, dst, srv.Engine(t, "Finance", "Travel", "Misc"), 2, 0)
+	if err := p.Prepare(context.Background()); err != nil {
+		t.Fatalf("Prepare: %v", err)
+	}
+	if err := p.Run(context.Background()); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	for _, want := range []string{
+		"Finance/Invoice_ACME.txt",
+		"Travel/Boarding_Pass_LH123.txt",
+		// Files the model cannot classify keep their name in Misc
+		"Misc/scan3.txt",
+		"Misc/scan4.txt",
+	} {
+		if _, err := os.Stat(filepath.Join(dst, want)); err != nil {
+			t.Errorf("%s was not organised: %v", want, err)
+		}
+	}
+	if left, _ := os.ReadDir(src); len(left) != 0 {
+		t.Errorf("%d files left in the source", len(left))
+	}
+	if p.ProcessedFiles != 4 || p.FailedFiles != 0 {
+		t.Errorf("processed %d, failed %d; want 4 and 0", p.ProcessedFiles, p.FailedFiles)
+	}
+}
+
+func TestRun_ASCIINames(t *testing.T) {
+	srv := aitest.NewServer(t, "mock-model")
+	srv.Respond(func(aitest.Request) aitest.Reply {
+		return aitest.Category("Re\u00e7us", "Re\u00e7u Caf\u00e9 M\u00fcller", 0.9)
+	})
+
+	src, dst := writeSource(t, map[string]string{"scan.txt": "Receipt"})
+	p := NewPipeline(src, dst, srv.Engine(t, "Re\u00e7us", "Misc"), 1, 0)
+	p.ASCIINames = true
+	if err := p.Run(context.Background()); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "Recus", "Recu Cafe Muller.txt")); err != nil {
+		t.Errorf("file was not organised under an ASCII name: %v", err)
+	}
+}
+
+func TestRun_ClassifyOnly(t *testing.T) {
+	srv := aitest.NewServer(t, "mock-model")
+	srv.Respond(func(aitest.Request) aitest.Reply {
+		return aitest.Category("Finance", "Invoice_ACME", 0.9)
+	})
+
+	src, dst := writeSource(t, map[string]string{"scan.txt": "Invoice 2024-001 from ACME Corp"})
+	results, err := audit.OpenCSV(filepath.Join(t.TempDir(), "results.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer results.Close()
+	p := NewPipeline(src, dst, srv.Engine(t, "Finance", "Misc"), 1, 0)
+	p.ClassifyOnly = true
+	p.Results = results
+	var got []audit.Record
+	p.OnFile = func(rec audit.Record) { got = append(got, rec) }
+	if err := p.Run(context.Background()); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(src, "scan.txt")); err != nil {
+		t.Errorf("classify-only run moved the file: %v", err)
+	}
+	if entries, _ := os.ReadDir(filepath.Join(dst, "Finance")); len(entries) > 0 {
+		t.Errorf("classify-only run wrote to the destination: %v", entries)
+	}
+	if len(got) != 1 || got[0].Status != audit.StatusClassified || got[0].Category != "Finance" ||
+		got[0].Title != "Invoice_ACME.txt" || got[0].Destination != "" {
+		t.Errorf("records = %+v, want scan.txt classified into Finance", got)
+	}
+	if p.ProcessedFiles != 1 || p.FailedFiles != 0 {
+		t.Errorf("processed %d, failed %d; want 1 and 0", p.ProcessedFiles, p.FailedFiles)
+	}
+}
+
+func TestRun_MirrorLayout(t *testing.T) {
+	srv := aitest.NewServer(t, "mock-model")
+	srv.Respond(func(aitest.Request) aitest.Reply {
+		return aitest.Category("Work", "Contract", 0.9)
+	})
+
+	src, dst := writeSource(t, map[string]string{"memo.txt": "Service agreement draft"})
+	nested := filepath.Join(src, "ClientA", "2024")
+	if err := os.MkdirAll(nested, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(nested, "contract.txt"), []byte("Service agreement with ClientA"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	p := NewPipeline(src, dst, srv.Engine(t, "Work", "Misc"), 1, 0)
+	p.Layout = LayoutMirror
+	if err := p.Run(context.Background()); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	for _, name := range []string{"Work/ClientA/2024/Contract.txt", "Work/Contract.txt"} {
+		if _, err := os.Stat(filepath.Join(dst, name)); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+}
+
+func TestRun_Hooks(t *testing.T) {
+	srv := aitest.NewServer(t, "mock-model")
+	srv.Respond(func(aitest.Request) aitest.Reply {
+		return aitest.Category("Finance", "Invoice_ACME", 0.9)
+	})
+
+	src, dst := writeSource(t, map[string]string{"scan.txt": "Invoice 2024-001 from ACME Corp"})
+	summary := filepath.Join(t.TempDir(), "summary.json")
+	p := NewPipeline(src, dst, srv.Engine(t, "Finance", "Misc"), 1, 0)
+	p.PreRunHook = &notify.Hook{Command: []string{"sh", "-c", "echo backup volume missing >&2; exit 1"}}
+	p.PostRunHook = &notify.Hook{Command: []string{"sh", "-c", `cat > "$0"`, summary}}
+
+	if err := p.Run(context.Background()); err == nil || !strings.Contains(err.Error(), "backup volume missing") {
+		t.Fatalf("Run with a failing pre-run hook = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(src, "scan.txt")); err != nil {
+		t.Errorf("the run went ahead: %v", err)
+	}
+	if _, err := os.Stat(summary); !os.IsNotExist(err) {
+		t.Errorf("post-run hook ran for a skipped run: %v", err)
+	}
+
+	p.PreRunHook.Command = []string{"true"}
+	if err := p.Run(context.Background()); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	data, err := os.ReadFile(summary)
+	if err != nil {
+		t.Fatalf("post-run hook did not run: %v", err)
+	}
+	var ev notify.Event
+	if err := json.Unmarshal(data, &ev); err != nil {
+		t.Fatal(err)
+	}
+	if ev.Type != notify.EventRunCompleted || ev.Run == nil || ev.Run.Processed != 1 || ev.Run.Categories["Finance"] != 1 {
+		t.Errorf("post-run hook got %s", data)
+	}
+}
+
+func TestRun_CancelledWhileClassifying(t *testing.T) {
+	srv := aitest.NewServer(t, "mock-model")
+	srv.Respond(func(aitest.Request) aitest.Reply {
+		return aitest.Reply{Delay: time.Minute}
+	})
+
+	src, dst := writeSource(t, map[string]string{"slow.txt": "Invoice"})
+	p := NewPipeline(src, dst, srv.Engine(t, "Finance", "Misc"), 1, 0)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	time.AfterFunc(200*time.Millisecond, cancel)
+
+	start := time.Now()
+	p.Run(ctx)
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("Run took %s after cancellation", elapsed)
+	}
+	if _, err := os.Stat(filepath.Join(src, "slow.txt")); err != nil {
+		t.Errorf("a cancelled file should stay in the source: %v", err)
+	}
+	if _, err := os.Stat(dst); !os.IsNotExist(err) {
+		t.Errorf("nothing should have been written to the destination (stat: %v)", err)
+	}
+}
+
+func TestPrepare_WarmUp(t *testing.T) {
+	srv := aitest.NewServer(t, "mock-model")
+	srv.Script(aitest.Failure(503))
+	srv.Respond(func(aitest.Request) aitest.Reply {
+		return aitest.Category("Finance", "OK", 0.9)
+	})
+
+	p := NewPipeline(t.TempDir(), t.TempDir(), srv.Engine(t, "Finance", "Misc"), 1, 0)
+	p.WarmUp = true
+	for i := 1; i <= 2; i++ {
+		// A failed warm-up is only a warning
+		if err := p.Prepare(context.Background()); err != nil {
+			t.Fatalf("Prepare %d: %v", i, err)
+		}
+		requests := srv.Requests()
+		if len(requests) != i || requests[i-1].Model != "mock-model" || requests[i-1].User() != "Reply with OK." {
+			t.Fatalf("after Prepare %d, requests = %+v; want one warm-up request each", i, requests)
+		}
+	}
+}
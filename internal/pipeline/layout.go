@@ -0,0 +1,40 @@
+package pipeline
+
+import (
+	"docs_organiser/internal/ai"
+	"fmt"
+)
+
+// Layout decides how the folders of the source carry over into the destination.
+type Layout string
+
+const (
+	// LayoutFlat files every document directly into its category folder: "Work/contract.pdf".
+	LayoutFlat Layout = "flat"
+	// LayoutMirror keeps the file's folder relative to the source root under its category:
+	// "ClientA/contract.pdf" becomes "Work/ClientA/contract.pdf".
+	LayoutMirror Layout = "mirror"
+)
+
+// ParseLayout validates a configured layout; "" means LayoutFlat.
+func ParseLayout(s string) (Layout, error) {
+	switch layout := Layout(s); layout {
+	case "":
+		return LayoutFlat, nil
+	case LayoutFlat, LayoutMirror:
+		return layout, nil
+	}
+	return "", fmt.Errorf("unknown layout %q (want flat or mirror)", s)
+}
+
+// folder returns the destination folder for a file filed into category whose directory
+// relative to the source root is dir (slash-separated, "" for the root).
+func (l Layout) folder(category, dir string) string {
+	if l != LayoutMirror || dir == "" {
+		return category
+	}
+	if sub := ai.SanitizeCategory(dir); sub != "" {
+		return category + "/" + sub
+	}
+	return category
+}
@@ -0,0 +1,69 @@
+package pipeline
+
+import (
+	"errors"
+	"math/rand/v2"
+	"path/filepath"
+	"strings"
+)
+
+// extractLimitFor returns the extraction limit for the file name: its extension's entry
+// in ExtractLimits, or ExtractLimit (0 sizes it from the context window).
+func (p *Pipeline) extractLimitFor(name string) int {
+	if limit, ok := p.ExtractLimits[strings.ToLower(strings.TrimPrefix(filepath.Ext(name), "."))]; ok {
+		return limit
+	}
+	return p.ExtractLimit
+}
+
+// textLimit returns how many characters are extracted from the file name: the configured
+// limit, or without one ten per token of the context window, a generous upper bound that
+// leaves truncation and summarizing to the model engine.
+func (p *Pipeline) textLimit(name string) int {
+	if limit := p.extractLimitFor(name); limit > 0 {
+		return limit
+	}
+	return p.AI.ContextWindow() * 10
+}
+
+// errEnoughFiles stops the scan once a run has all the files it will process.
+var errEnoughFiles = errors.New("file limit reached")
+
+// limitJobs passes the first n jobs to enqueue, then stops the scan with errEnoughFiles.
+func limitJobs(n int, enqueue func(FileJob) error) func(FileJob) error {
+	seen := 0
+	return func(job FileJob) error {
+		if seen >= n {
+			return errEnoughFiles
+		}
+		seen++
+		return enqueue(job)
+	}
+}
+
+// reservoir keeps a uniform random sample of the jobs it is offered (algorithm R), so a
+// sample of a large archive needs memory for the sample only.
+type reservoir struct {
+	jobs []FileJob
+	size int
+	seen int
+	rand *rand.Rand
+}
+
+func newReservoir(size int, r *rand.Rand) *reservoir {
+	if r == nil {
+		r = rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64()))
+	}
+	return &reservoir{size: size, rand: r}
+}
+
+// add offers job to the sample. It never fails; the signature matches enqueue.
+func (s *reservoir) add(job FileJob) error {
+	s.seen++
+	if len(s.jobs) < s.size {
+		s.jobs = append(s.jobs, job)
+	} else if i := s.rand.IntN(s.seen); i < s.size {
+		s.jobs[i] = job
+	}
+	return nil
+}
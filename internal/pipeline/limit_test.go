@@ -0,0 +1,71 @@
+package pipeline
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"testing"
+)
+
+func TestExtractLimitFor(t *testing.T) {
+	p := &Pipeline{ExtractLimit: 1000, ExtractLimits: map[string]int{"pdf": 50000, "log": 2000}}
+	tests := map[string]int{
+		"report.pdf":   50000,
+		"REPORT.PDF":   50000,
+		"server.log":   2000,
+		"notes.md":     1000,
+		"no_extension": 1000,
+	}
+	for name, want := range tests {
+		if got := p.extractLimitFor(name); got != want {
+			t.Errorf("extractLimitFor(%q) = %d, want %d", name, got, want)
+		}
+	}
+}
+
+func TestLimitJobs(t *testing.T) {
+	var got []FileJob
+	enqueue := limitJobs(2, func(job FileJob) error {
+		got = append(got, job)
+		return nil
+	})
+	for i := 0; i < 3; i++ {
+		err := enqueue(FileJob{Path: fmt.Sprint(i)})
+		if wantErr := i == 2; (err == errEnoughFiles) != wantErr {
+			t.Errorf("job %d: err = %v", i, err)
+		}
+	}
+	if len(got) != 2 {
+		t.Errorf("enqueued %d jobs, want 2", len(got))
+	}
+}
+
+func TestReservoir(t *testing.T) {
+	// Every file should be picked about equally often
+	const files, size, rounds = 10, 3, 20000
+	counts := make(map[string]int)
+	r := rand.New(rand.NewPCG(1, 2))
+	for round := 0; round < rounds; round++ {
+		s := newReservoir(size, r)
+		for i := 0; i < files; i++ {
+			s.add(FileJob{Path: fmt.Sprint(i)})
+		}
+		if len(s.jobs) != size {
+			t.Fatalf("sample has %d jobs, want %d", len(s.jobs), size)
+		}
+		for _, job := range s.jobs {
+			counts[job.Path]++
+		}
+	}
+	want := rounds * size / files
+	for path, n := range counts {
+		if n < want*9/10 || n > want*11/10 {
+			t.Errorf("file %s picked %d times, want about %d", path, n, want)
+		}
+	}
+
+	small := newReservoir(5, r)
+	small.add(FileJob{Path: "only"})
+	if len(small.jobs) != 1 {
+		t.Errorf("sample of a smaller source has %d jobs, want 1", len(small.jobs))
+	}
+}
@@ -0,0 +1,65 @@
+package pipeline
+
+import (
+	"context"
+	"os"
+	"sync"
+)
+
+// memoryBudget bounds the memory that the files being processed may use together.
+// Each file reserves an estimate before it starts, so a few very large PDFs wait for
+// each other instead of running side by side, while small files run in parallel as usual.
+type memoryBudget struct {
+	limit int64
+
+	mu       sync.Mutex
+	reserved int64
+	released chan struct{} // closed and replaced whenever memory is released
+}
+
+func newMemoryBudget(limit int64) *memoryBudget {
+	return &memoryBudget{limit: limit, released: make(chan struct{})}
+}
+
+// acquire reserves n bytes, waiting until they fit. A reservation larger than the whole
+// budget is reduced to it, so that file runs alone rather than never. It returns the
+// function that releases the reservation, or ctx's error if ctx ends first.
+func (b *memoryBudget) acquire(ctx context.Context, n int64) (func(), error) {
+	n = min(max(n, 0), b.limit)
+	for {
+		b.mu.Lock()
+		if b.reserved == 0 || b.reserved+n <= b.limit {
+			b.reserved += n
+			b.mu.Unlock()
+			return func() { b.release(n) }, nil
+		}
+		released := b.released
+		b.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-released:
+		}
+	}
+}
+
+func (b *memoryBudget) release(n int64) {
+	b.mu.Lock()
+	b.reserved -= n
+	close(b.released)
+	b.released = make(chan struct{})
+	b.mu.Unlock()
+}
+
+// memoryEstimate is what processing job is expected to hold at its peak: the file
+// itself, which the PDF reader may load in large parts, and the extracted text.
+func (p *Pipeline) memoryEstimate(job FileJob) int64 {
+	estimate := int64(p.textLimit(job.name()))
+	if job.Key == "" {
+		if info, err := os.Stat(job.Path); err == nil {
+			estimate += info.Size()
+		}
+	}
+	return estimate
+}
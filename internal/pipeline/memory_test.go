@@ -0,0 +1,58 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryBudget(t *testing.T) {
+	b := newMemoryBudget(100)
+	ctx := context.Background()
+
+	releaseA, err := b.acquire(ctx, 60)
+	if err != nil {
+		t.Fatal(err)
+	}
+	releaseSmall, err := b.acquire(ctx, 40)
+	if err != nil {
+		t.Fatalf("a reservation that fits should not wait: %v", err)
+	}
+
+	acquired := make(chan func())
+	go func() {
+		release, _ := b.acquire(ctx, 61)
+		acquired <- release
+	}()
+	select {
+	case <-acquired:
+		t.Fatal("acquired beyond the budget")
+	case <-time.After(50 * time.Millisecond):
+	}
+	releaseA()
+	select {
+	case <-acquired:
+		t.Fatal("acquired while 40 + 61 exceed the budget")
+	case <-time.After(50 * time.Millisecond):
+	}
+	releaseSmall()
+	var releaseB func()
+	select {
+	case releaseB = <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("waiting reservation not granted after releases")
+	}
+	releaseB()
+
+	// Larger than the whole budget: runs alone instead of never
+	releaseHuge, err := b.acquire(ctx, 500)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cancelled, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	if _, err := b.acquire(cancelled, 1); err == nil {
+		t.Error("acquired next to a reservation of the whole budget")
+	}
+	releaseHuge()
+}
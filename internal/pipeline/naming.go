@@ -0,0 +1,48 @@
+package pipeline
+
+import (
+	"docs_organiser/internal/ai"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// NamingMode decides what classified files are called in their category folder.
+type NamingMode string
+
+const (
+	// NamingRename names the file after the model's title: "Invoice_ACME_March.pdf".
+	NamingRename NamingMode = "rename"
+	// NamingKeep keeps the original name and only files the document into its folder.
+	NamingKeep NamingMode = "keep"
+	// NamingPrefix puts the title in front of the original name: "Invoice_ACME_March_scan0042.pdf".
+	NamingPrefix NamingMode = "prefix"
+)
+
+// ParseNamingMode validates a configured mode; "" means NamingRename.
+func ParseNamingMode(s string) (NamingMode, error) {
+	switch mode := NamingMode(s); mode {
+	case "":
+		return NamingRename, nil
+	case NamingRename, NamingKeep, NamingPrefix:
+		return mode, nil
+	}
+	return "", fmt.Errorf("unknown naming mode %q (want rename, keep, or prefix)", s)
+}
+
+// fileName returns the name for a file originally called name that the model titled
+// title (already sanitized, without extension).
+func (m NamingMode) fileName(title, name string) string {
+	switch m {
+	case NamingKeep:
+		return name
+	case NamingPrefix:
+		ext := filepath.Ext(name)
+		original := ai.SanitizeFilename(strings.TrimSuffix(name, ext))
+		if strings.HasPrefix(original, title) {
+			return original + ext // already prefixed by an earlier run
+		}
+		return title + "_" + original + ext
+	}
+	return title + filepath.Ext(name)
+}
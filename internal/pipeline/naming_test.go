@@ -0,0 +1,58 @@
+package pipeline
+
+import "testing"
+
+func TestNamingMode(t *testing.T) {
+	tests := []struct {
+		mode        string
+		title, name string
+		want        string
+	}{
+		{"", "Invoice_ACME", "scan0042.pdf", "Invoice_ACME.pdf"},
+		{"rename", "Invoice_ACME", "scan0042.pdf", "Invoice_ACME.pdf"},
+		{"keep", "Invoice_ACME", "scan 0042 (1).pdf", "scan 0042 (1).pdf"},
+		{"prefix", "Invoice_ACME", "scan0042.pdf", "Invoice_ACME_scan0042.pdf"},
+		{"prefix", "Invoice_ACME", "Invoice_ACME_scan0042.pdf", "Invoice_ACME_scan0042.pdf"},
+		{"prefix", "Notes", "meeting:notes.txt", "Notes_meeting_notes.txt"},
+	}
+	for _, tt := range tests {
+		mode, err := ParseNamingMode(tt.mode)
+		if err != nil {
+			t.Fatalf("ParseNamingMode(%q): %v", tt.mode, err)
+		}
+		if got := mode.fileName(tt.title, tt.name); got != tt.want {
+			t.Errorf("%s: fileName(%q, %q) = %q, want %q", mode, tt.title, tt.name, got, tt.want)
+		}
+	}
+
+	if _, err := ParseNamingMode("original"); err == nil {
+		t.Error("unknown naming mode accepted")
+	}
+}
+
+func TestLayout(t *testing.T) {
+	tests := []struct {
+		layout        string
+		category, dir string
+		want          string
+	}{
+		{"", "Work", "ClientA", "Work"},
+		{"flat", "Work", "ClientA", "Work"},
+		{"mirror", "Work", "ClientA/2024", "Work/ClientA/2024"},
+		{"mirror", "Work", "", "Work"},
+		{"mirror", "Work", "Scans|2024", "Work/Scans_2024"},
+	}
+	for _, tt := range tests {
+		layout, err := ParseLayout(tt.layout)
+		if err != nil {
+			t.Fatalf("ParseLayout(%q): %v", tt.layout, err)
+		}
+		if got := layout.folder(tt.category, tt.dir); got != tt.want {
+			t.Errorf("%s: folder(%q, %q) = %q, want %q", layout, tt.category, tt.dir, got, tt.want)
+		}
+	}
+
+	if _, err := ParseLayout("nested"); err == nil {
+		t.Error("unknown layout accepted")
+	}
+}
@@ -0,0 +1,94 @@
+package pipeline
+
+import (
+	"docs_organiser/internal/audit"
+	"docs_organiser/internal/remote"
+	"docs_organiser/internal/simhash"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// NearDuplicateMode decides what happens to documents whose text nearly repeats an
+// organised one, such as a rescan of the same page or a new version of a contract.
+type NearDuplicateMode string
+
+const (
+	// NearDuplicatesOff doesn't look for near-duplicates.
+	NearDuplicatesOff NearDuplicateMode = "off"
+	// NearDuplicatesFlag files the document as classified and names the file it nearly
+	// repeats in its audit record and the run summary.
+	NearDuplicatesFlag NearDuplicateMode = "flag"
+	// NearDuplicatesGroup also files the document into the folder of that file.
+	NearDuplicatesGroup NearDuplicateMode = "group"
+)
+
+// ParseNearDuplicateMode validates a configured mode; "" means NearDuplicatesOff.
+func ParseNearDuplicateMode(s string) (NearDuplicateMode, error) {
+	switch mode := NearDuplicateMode(s); mode {
+	case "":
+		return NearDuplicatesOff, nil
+	case NearDuplicatesOff, NearDuplicatesFlag, NearDuplicatesGroup:
+		return mode, nil
+	}
+	return "", fmt.Errorf("unknown near_duplicates mode %q (want off, flag, or group)", s)
+}
+
+// ValidateNearDuplicateDistance checks a configured near_duplicate_distance, the number of
+// the 64 fingerprint bits that may differ.
+func ValidateNearDuplicateDistance(distance int) error {
+	if distance < 0 || distance > 32 {
+		return fmt.Errorf("near_duplicate_distance must be between 0 and 32 bits, got %d", distance)
+	}
+	return nil
+}
+
+// nearDuplicate fingerprints text and looks for the organised file it nearly repeats,
+// which is noted in rec and, with NearDuplicatesGroup, gives rec its folder. Local files
+// that are gone since they were organised are dropped from the index. It returns the
+// fingerprint, for addFingerprint, and false when text is too short for one.
+func (p *Pipeline) nearDuplicate(rec *audit.Record, name, text string) (uint64, bool) {
+	fingerprint, ok := simhash.Fingerprint(text)
+	if !ok {
+		return 0, false
+	}
+	for {
+		match, distance, found, err := p.NearDuplicates.Nearest(fingerprint, p.NearDuplicateDistance)
+		if err != nil {
+			log.Printf("[!] Failed to look for near-duplicates of %s: %v", name, err)
+			return fingerprint, true
+		}
+		if !found || match.Path == rec.Source { // the file organised again from the destination
+			return fingerprint, true
+		}
+		if !remote.IsRemote(match.Path) {
+			if _, err := os.Stat(match.Path); errors.Is(err, fs.ErrNotExist) {
+				if err := p.NearDuplicates.Remove(match.Path); err != nil {
+					log.Printf("[!] Failed to drop %s from the near-duplicate index: %v", match.Path, err)
+					return fingerprint, true
+				}
+				continue
+			}
+		}
+		rec.NearDuplicateOf = match.Path
+		if p.NearDuplicateMode == NearDuplicatesGroup && rec.Category != match.Category {
+			log.Printf("[*] %s nearly repeats %s (%d bits apart); filing it beside it in %s", name, filepath.Base(match.Path), distance, match.Category)
+			rec.Category = match.Category
+		} else {
+			log.Printf("[*] %s nearly repeats %s (%d bits apart)", name, match.Path, distance)
+		}
+		return fingerprint, true
+	}
+}
+
+// addFingerprint adds the organised file of rec to the near-duplicate index. Failures
+// are logged and leave the file out.
+func (p *Pipeline) addFingerprint(rec audit.Record, fingerprint uint64) {
+	err := p.NearDuplicates.Add(simhash.Entry{Path: rec.Destination, Category: rec.Category, Fingerprint: fingerprint})
+	if err != nil {
+		log.Printf("[!] Failed to add %s to the near-duplicate index: %v", filepath.Base(rec.Destination), err)
+	}
+}
@@ -0,0 +1,88 @@
+package pipeline
+
+import (
+	"context"
+	"docs_organiser/internal/aitest"
+	"docs_organiser/internal/audit"
+	"docs_organiser/internal/simhash"
+	"docs_organiser/internal/storage"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const leaseText = `This tenancy agreement is made between Jane Smith, the landlord, and John Doe, the tenant,
+for the property at 12 Elm Street. The tenant agrees to pay a monthly rent of 1200 EUR on the
+first day of each month, and a deposit of two months' rent before moving in. The tenancy starts
+on 1 March 2022 and runs for twelve months.`
+
+func TestRun_NearDuplicates(t *testing.T) {
+	srv := aitest.NewServer(t, "mock-model")
+	srv.Respond(func(r aitest.Request) aitest.Reply {
+		switch {
+		case strings.Contains(r.User(), "REVISED"):
+			return aitest.Category("Legal", "Contract", 0.9)
+		case strings.Contains(r.User(), "tenancy"):
+			return aitest.Category("Housing", "Lease", 0.9)
+		}
+		return aitest.Category("Finance", "Invoice", 0.9)
+	})
+	store, err := storage.NewBadgerStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	src, dst := writeSource(t, map[string]string{"lease.txt": leaseText})
+	p := NewPipeline(src, dst, srv.Engine(t, "Housing", "Legal", "Finance", "Misc"), 1, 0)
+	p.NearDuplicates, p.NearDuplicateMode, p.NearDuplicateDistance = simhash.NewIndex(store), NearDuplicatesGroup, 6
+	var mu sync.Mutex
+	records := map[string]audit.Record{}
+	p.OnFile = func(rec audit.Record) {
+		mu.Lock()
+		defer mu.Unlock()
+		records[filepath.Base(rec.Source)] = rec
+	}
+	if err := p.Run(context.Background()); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	original := filepath.Join(dst, "Housing", "Lease.txt")
+	if records["lease.txt"].NearDuplicateOf != "" {
+		t.Errorf("the first lease is flagged as a near-duplicate of %s", records["lease.txt"].NearDuplicateOf)
+	}
+
+	// A later version the model files elsewhere joins the first
+	for name, text := range map[string]string{
+		"lease_v2.txt": "REVISED " + strings.Replace(leaseText, "1200", "1250", 1),
+		"invoice.txt":  "Invoice 2023-0042 from ACME Supplies Ltd for four standing desks, four chairs, and two filing cabinets; total due 4046 EUR within thirty days by bank transfer.",
+	} {
+		if err := os.WriteFile(filepath.Join(src, name), []byte(text), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := p.Run(context.Background()); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if rec := records["lease_v2.txt"]; rec.NearDuplicateOf != original || rec.Category != "Housing" {
+		t.Errorf("lease_v2.txt: near duplicate of %q in %s, want %s in Housing", rec.NearDuplicateOf, rec.Category, original)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "Housing", "Contract.txt")); err != nil {
+		t.Errorf("the revised lease is not beside the first: %v", err)
+	}
+	if rec := records["invoice.txt"]; rec.NearDuplicateOf != "" || rec.Category != "Finance" {
+		t.Errorf("invoice.txt: near duplicate of %q in %s, want none in Finance", rec.NearDuplicateOf, rec.Category)
+	}
+}
+
+func TestParseNearDuplicateMode(t *testing.T) {
+	for in, want := range map[string]NearDuplicateMode{"": NearDuplicatesOff, "flag": NearDuplicatesFlag, "group": NearDuplicatesGroup} {
+		if got, err := ParseNearDuplicateMode(in); err != nil || got != want {
+			t.Errorf("ParseNearDuplicateMode(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := ParseNearDuplicateMode("merge"); err == nil {
+		t.Error("ParseNearDuplicateMode(merge) = nil error")
+	}
+}
@@ -0,0 +1,52 @@
+package pipeline
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+)
+
+// JobOrder decides the order in which scanned files are processed.
+type JobOrder string
+
+const (
+	// OrderWalk processes files as the scan finds them, starting at once.
+	OrderWalk JobOrder = "walk"
+	// OrderSmallest processes the smallest files first, so quick results land early.
+	OrderSmallest JobOrder = "smallest"
+	// OrderLargest processes the largest files first, so no giant is left for the end.
+	OrderLargest JobOrder = "largest"
+	// OrderOldest processes the least recently modified files first.
+	OrderOldest JobOrder = "oldest"
+	// OrderNewest processes the most recently modified files first.
+	OrderNewest JobOrder = "newest"
+)
+
+// ParseJobOrder validates a configured order; "" means OrderWalk.
+func ParseJobOrder(s string) (JobOrder, error) {
+	switch order := JobOrder(s); order {
+	case "":
+		return OrderWalk, nil
+	case OrderWalk, OrderSmallest, OrderLargest, OrderOldest, OrderNewest:
+		return order, nil
+	}
+	return "", fmt.Errorf("unknown job order %q (want walk, smallest, largest, oldest, or newest)", s)
+}
+
+// sort orders jobs in place; ties keep their walk order.
+func (o JobOrder) sort(jobs []FileJob) {
+	var compare func(a, b FileJob) int
+	switch o {
+	case OrderSmallest:
+		compare = func(a, b FileJob) int { return cmp.Compare(a.size, b.size) }
+	case OrderLargest:
+		compare = func(a, b FileJob) int { return cmp.Compare(b.size, a.size) }
+	case OrderOldest:
+		compare = func(a, b FileJob) int { return a.modTime.Compare(b.modTime) }
+	case OrderNewest:
+		compare = func(a, b FileJob) int { return b.modTime.Compare(a.modTime) }
+	default:
+		return
+	}
+	slices.SortStableFunc(jobs, compare)
+}
@@ -0,0 +1,47 @@
+package pipeline
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestJobOrder(t *testing.T) {
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	jobs := []FileJob{
+		{Path: "a", size: 300, modTime: day.AddDate(0, 0, 2)},
+		{Path: "b", size: 100, modTime: day},
+		{Path: "c", size: 200, modTime: day.AddDate(0, 0, 1)},
+		{Path: "d", size: 100, modTime: day.AddDate(0, 0, 3)},
+	}
+	tests := []struct {
+		order string
+		want  []string
+	}{
+		{"", []string{"a", "b", "c", "d"}},
+		{"walk", []string{"a", "b", "c", "d"}},
+		{"smallest", []string{"b", "d", "c", "a"}},
+		{"largest", []string{"a", "c", "b", "d"}},
+		{"oldest", []string{"b", "c", "a", "d"}},
+		{"newest", []string{"d", "a", "c", "b"}},
+	}
+	for _, tt := range tests {
+		order, err := ParseJobOrder(tt.order)
+		if err != nil {
+			t.Fatalf("ParseJobOrder(%q): %v", tt.order, err)
+		}
+		sorted := slices.Clone(jobs)
+		order.sort(sorted)
+		var got []string
+		for _, job := range sorted {
+			got = append(got, job.Path)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s: order = %v, want %v", order, got, tt.want)
+		}
+	}
+
+	if _, err := ParseJobOrder("alphabetical"); err == nil {
+		t.Error("unknown job order accepted")
+	}
+}
@@ -0,0 +1,122 @@
+package pipeline
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// overlapExclusions returns the absolute directories the source walk must skip so that
+// files already moved into the destination are not picked up again.
+// If dst lies inside src, the whole destination tree is excluded. If src lies inside
+// (or equals) dst, only the category folders that fall within the source are excluded,
+// since excluding dst itself would exclude everything, along with the folders of dst
+// whose names match one of heads (see templateHead).
+func overlapExclusions(src, dst string, categories []string, heads []*regexp.Regexp) ([]string, error) {
+	absSrc, err := filepath.Abs(src)
+	if err != nil {
+		return nil, err
+	}
+	absDst, err := filepath.Abs(dst)
+	if err != nil {
+		return nil, err
+	}
+
+	if absDst != absSrc && isWithin(absDst, absSrc) {
+		return []string{absDst}, nil
+	}
+
+	if !isWithin(absSrc, absDst) {
+		return nil, nil
+	}
+
+	var excluded []string
+	for _, c := range append([]string{"Misc", UnprocessedDir}, categories...) {
+		dir := filepath.Join(absDst, filepath.FromSlash(c))
+		if dir != absSrc && isWithin(dir, absSrc) {
+			excluded = append(excluded, dir)
+		}
+	}
+	if len(heads) == 0 {
+		return excluded, nil
+	}
+	entries, err := os.ReadDir(absDst)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for _, entry := range entries {
+		dir := filepath.Join(absDst, entry.Name())
+		if !entry.IsDir() || dir == absSrc || !isWithin(dir, absSrc) || slices.Contains(excluded, dir) {
+			continue
+		}
+		if slices.ContainsFunc(heads, func(head *regexp.Regexp) bool { return head.MatchString(entry.Name()) }) {
+			excluded = append(excluded, dir)
+		}
+	}
+	return excluded, nil
+}
+
+// placeholderPatterns match what the date placeholders of a path template expand to;
+// the others can expand to any name.
+var placeholderPatterns = map[string]string{
+	"year":  `\d{4}`,
+	"month": `\d{2}`,
+	"day":   `\d{2}`,
+	"date":  `\d{4}-\d{2}-\d{2}`,
+}
+
+// templateHead returns a pattern matching the folder names the first folder of a path
+// template expands to, such as 2024 for "{{year}}/{{category}}". It returns nil when that
+// folder holds no placeholder (templateRoot covers it) or is just {{category}}, whose
+// folders are excluded as categories.
+func templateHead(tmpl string) *regexp.Regexp {
+	segments := templateSegments(tmpl)
+	if len(segments) == 0 || !placeholder.MatchString(segments[0]) {
+		return nil
+	}
+	head := segments[0]
+	if m := placeholder.FindStringSubmatch(head); m[0] == head && m[1] == "category" {
+		return nil
+	}
+	var pattern strings.Builder
+	last := 0
+	for _, loc := range placeholder.FindAllStringSubmatchIndex(head, -1) {
+		pattern.WriteString(regexp.QuoteMeta(head[last:loc[0]]))
+		if p, ok := placeholderPatterns[head[loc[2]:loc[3]]]; ok {
+			pattern.WriteString(p)
+		} else {
+			pattern.WriteString(`.+`)
+		}
+		last = loc[1]
+	}
+	pattern.WriteString(regexp.QuoteMeta(head[last:]))
+	return regexp.MustCompile(`(?i)^` + pattern.String() + `$`)
+}
+
+// isWithin reports whether path is dir itself or lies beneath it. Both must be absolute and clean.
+func isWithin(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(os.PathSeparator))
+}
+
+// isExcludedDir reports whether path (as yielded by the walk) is one of the absolute excluded directories.
+func isExcludedDir(path string, excluded []string) bool {
+	if len(excluded) == 0 {
+		return false
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	for _, dir := range excluded {
+		if abs == dir {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,126 @@
+package pipeline
+
+import (
+	"context"
+	"docs_organiser/internal/aitest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestOverlapExclusions(t *testing.T) {
+	root := t.TempDir()
+	join := func(parts ...string) string { return filepath.Join(append([]string{root}, parts...)...) }
+
+	tests := []struct {
+		name       string
+		src, dst   string
+		categories []string
+		want       []string
+	}{
+		{"Disjoint", join("in"), join("out"), []string{"Work"}, nil},
+		{"Destination inside source", join("in"), join("in", "sorted"), []string{"Work"}, []string{join("in", "sorted")}},
+		{"Source inside destination", join("archive", "Inbox"), join("archive"), []string{"Work", "Inbox/Scans"}, []string{join("archive", "Inbox", "Scans")}},
+		{"Same directory", join("docs"), join("docs"), []string{"Work"}, []string{join("docs", "Misc"), join("docs", UnprocessedDir), join("docs", "Work")}},
+		{"Sibling prefix is not overlap", join("in"), join("inbox"), []string{"Work"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := overlapExclusions(tt.src, tt.dst, tt.categories, nil)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("overlapExclusions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRun_OverlapPhotos(t *testing.T) {
+	srv := aitest.NewServer(t, "mock-model")
+	dir := t.TempDir()
+	mtime := time.Date(2023, 7, 14, 9, 5, 3, 0, time.Local)
+	routed := filepath.Join(dir, "Photos", "2023", "07", "IMG_0042.JPG")
+	incoming := filepath.Join(dir, "IMG_0043.JPG")
+	for _, path := range []string{routed, incoming} {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte{0xFF, 0xD8, 0xFF, 0xD9}, 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The source is the destination: photos routed by an earlier run must not be rescanned
+	p := NewPipeline(dir, dir, srv.Engine(t, "Finance", "Misc"), 1, 0)
+	p.Photos = &PhotoRouting{Path: "Photos/{{year}}/{{month}}"}
+	p.Filter.Extensions = []string{".jpg"}
+	if err := p.Run(context.Background()); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	for _, name := range []string{"IMG_0042.JPG", "IMG_0043.JPG"} {
+		if _, err := os.Stat(filepath.Join(dir, "Photos", "2023", "07", name)); err != nil {
+			t.Errorf("%s is not in Photos/2023/07: %v", name, err)
+		}
+	}
+	if p.TotalFiles != 1 {
+		t.Errorf("TotalFiles = %d, want only the incoming photo", p.TotalFiles)
+	}
+	if root := (PhotoRouting{Path: "./Photos/ Camera /{{camera}}/{{year}}"}).staticRoot(); root != "Photos/Camera" {
+		t.Errorf("staticRoot = %q, want Photos/Camera", root)
+	}
+}
+
+func TestRun_OverlapDocumentTemplate(t *testing.T) {
+	srv := aitest.NewServer(t, "mock-model")
+	srv.Respond(func(aitest.Request) aitest.Reply {
+		return aitest.Category("Finance", "Invoice_ACME", 0.9)
+	})
+	dir := t.TempDir()
+	for _, path := range []string{
+		filepath.Join(dir, "2023", "Finance", "Invoice_Old.txt"), // filed by an earlier run
+		filepath.Join(dir, "Projects", "notes.txt"),
+		filepath.Join(dir, "scan.txt"),
+	} {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("Invoice from ACME"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The source is the destination and the template starts with the document's year
+	p := NewPipeline(dir, dir, srv.Engine(t, "Finance", "Misc"), 1, 0)
+	p.Documents = &DocumentRouting{Path: "{{year}}/{{category}}"}
+	if err := p.Run(context.Background()); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "2023", "Finance", "Invoice_Old.txt")); err != nil {
+		t.Errorf("the file filed earlier was moved again: %v", err)
+	}
+	if p.TotalFiles != 2 {
+		t.Errorf("TotalFiles = %d, want scan.txt and Projects/notes.txt", p.TotalFiles)
+	}
+
+	for tmpl, want := range map[string]string{
+		"{{year}}/{{category}}":       "2024",
+		"Scans {{date}}/{{category}}": "scans 2024-03-01",
+		"{{category}}/{{year}}":       "",
+		"Archive/{{year}}":            "",
+	} {
+		head := templateHead(tmpl)
+		if (head == nil) != (want == "") || head != nil && (!head.MatchString(want) || head.MatchString("Projects")) {
+			t.Errorf("templateHead(%q) = %v, want a pattern matching %q only", tmpl, head, want)
+		}
+	}
+}
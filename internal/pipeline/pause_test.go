@@ -0,0 +1,33 @@
+package pipeline
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTogglePause(t *testing.T) {
+	p := NewPipeline("src", "dst", nil, 1, 0)
+	if !p.TogglePause() || !p.IsPaused() {
+		t.Fatal("first toggle should pause")
+	}
+
+	resumed := make(chan struct{})
+	go func() {
+		p.waitIfPaused()
+		close(resumed)
+	}()
+	select {
+	case <-resumed:
+		t.Fatal("waitIfPaused returned while paused")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	if p.TogglePause() || p.IsPaused() {
+		t.Fatal("second toggle should resume")
+	}
+	select {
+	case <-resumed:
+	case <-time.After(time.Second):
+		t.Fatal("waiting worker was not woken on resume")
+	}
+}
@@ -0,0 +1,61 @@
+package pipeline
+
+import (
+	"docs_organiser/internal/audit"
+	"docs_organiser/internal/pdfmeta"
+	"errors"
+	"log"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// pdfMetadata returns the classification to stamp into rec's file, or nil when the
+// file is not a PDF, stamping is disabled, or the AI gave no usable answer.
+func (p *Pipeline) pdfMetadata(rec audit.Record) *pdfmeta.Metadata {
+	ext := filepath.Ext(rec.Title)
+	if !p.PDFMetadata || rec.Fallback || !strings.EqualFold(ext, ".pdf") {
+		return nil
+	}
+
+	// Category segments first, then the AI's tags, without case-insensitive repeats
+	var keywords []string
+	seen := make(map[string]bool)
+	add := func(k string) {
+		k = strings.TrimSpace(k)
+		if k != "" && !seen[strings.ToLower(k)] {
+			seen[strings.ToLower(k)] = true
+			keywords = append(keywords, k)
+		}
+	}
+	for _, segment := range strings.Split(rec.Category, "/") {
+		add(segment)
+	}
+	if rec.Classification != nil && rec.Classification.Analysis != nil {
+		for _, tag := range rec.Classification.Analysis.Tags {
+			add(tag)
+		}
+	}
+
+	return &pdfmeta.Metadata{
+		Title:    strings.TrimSuffix(rec.Title, ext),
+		Subject:  rec.Category,
+		Keywords: keywords,
+	}
+}
+
+// stampPDF writes meta into the PDF at path and reports whether it did. Failures only
+// cost the metadata: the file is organised unchanged.
+func stampPDF(path string, meta *pdfmeta.Metadata) bool {
+	if meta == nil {
+		return false
+	}
+	err := pdfmeta.Stamp(path, *meta, time.Now())
+	switch {
+	case errors.Is(err, pdfmeta.ErrEncrypted):
+		log.Printf("[*] Not writing metadata into %s: the PDF is encrypted", filepath.Base(path))
+	case err != nil:
+		log.Printf("[!] Failed to write metadata into %s: %v", filepath.Base(path), err)
+	}
+	return err == nil
+}
@@ -0,0 +1,68 @@
+package pipeline
+
+import (
+	"context"
+	"docs_organiser/internal/ai"
+	"docs_organiser/internal/audit"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestPDFMetadata(t *testing.T) {
+	analysed := &ai.CategorizationResult{Analysis: &ai.AnalysisResult{Tags: []string{"electricity", "finance", "March"}}}
+
+	tests := []struct {
+		name    string
+		enabled bool
+		rec     audit.Record
+		want    []string // keywords; nil means no metadata
+	}{
+		{"pdf", true, audit.Record{Title: "Power Bill.pdf", Category: "Finance/Utilities", Classification: analysed},
+			[]string{"Finance", "Utilities", "electricity", "March"}},
+		{"uppercase extension", true, audit.Record{Title: "Scan.PDF", Category: "Misc"}, []string{"Misc"}},
+		{"disabled", false, audit.Record{Title: "Power Bill.pdf", Category: "Finance"}, nil},
+		{"not a pdf", true, audit.Record{Title: "Notes.docx", Category: "Work"}, nil},
+		{"fallback", true, audit.Record{Title: "scan.pdf", Category: "Misc", Fallback: true}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Pipeline{PDFMetadata: tt.enabled}
+			meta := p.pdfMetadata(tt.rec)
+			if tt.want == nil {
+				if meta != nil {
+					t.Errorf("got %+v, want no metadata", meta)
+				}
+				return
+			}
+			if meta == nil {
+				t.Fatal("got no metadata")
+			}
+			if meta.Subject != tt.rec.Category || filepath.Ext(meta.Title) != "" {
+				t.Errorf("Title %q, Subject %q", meta.Title, meta.Subject)
+			}
+			if !reflect.DeepEqual(meta.Keywords, tt.want) {
+				t.Errorf("Keywords = %q, want %q", meta.Keywords, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeliverKeepsUnstampablePDF(t *testing.T) {
+	p := &Pipeline{DestDir: t.TempDir(), PDFMetadata: true}
+	local := filepath.Join(t.TempDir(), "scan.pdf")
+	if err := os.WriteFile(local, []byte("not really a PDF"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	meta := p.pdfMetadata(audit.Record{Title: "Invoice.pdf", Category: "Finance"})
+	dest, _, _, err := p.deliver(context.Background(), local, "Finance", "Invoice.pdf", nil, "", meta)
+	if err != nil {
+		t.Fatalf("deliver: %v", err)
+	}
+	if data, _ := os.ReadFile(dest); string(data) != "not really a PDF" {
+		t.Errorf("delivered file changed: %q", data)
+	}
+}
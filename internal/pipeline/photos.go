@@ -0,0 +1,139 @@
+package pipeline
+
+import (
+	"docs_organiser/internal/ai"
+	"docs_organiser/internal/exif"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// PhotoRouting files images by their EXIF capture date and camera instead of asking
+// the model. Templates use {{name}} placeholders; see photoVariables for the list.
+type PhotoRouting struct {
+	// Path is the destination folder, e.g. "Photos/{{year}}/{{month}}".
+	Path string
+	// Name is the file name without its extension, e.g. "{{date}} {{time}}";
+	// empty keeps the original name.
+	Name string
+}
+
+var placeholder = regexp.MustCompile(`\{\{\s*([a-z_]+)\s*\}\}`)
+
+// photoVariables are the placeholders a photo template may use.
+var photoVariables = []string{"year", "month", "day", "date", "time", "make", "model", "camera", "name"}
+
+// Validate reports templates that reference unknown placeholders or escape the destination.
+func (r PhotoRouting) Validate() error {
+	if r.Path == "" {
+		return fmt.Errorf("photo path template is empty")
+	}
+	for _, tmpl := range []string{r.Path, r.Name} {
+		for _, m := range placeholder.FindAllStringSubmatch(tmpl, -1) {
+			if !slices.Contains(photoVariables, m[1]) {
+				return fmt.Errorf("unknown placeholder {{%s}} in %q (available: %s)", m[1], tmpl, strings.Join(photoVariables, ", "))
+			}
+		}
+	}
+	for _, segment := range strings.Split(filepath.ToSlash(r.Path), "/") {
+		if segment == ".." {
+			return fmt.Errorf("photo path template %q must stay inside the destination", r.Path)
+		}
+	}
+	if strings.ContainsAny(r.Name, `/\`) {
+		return fmt.Errorf("photo name template %q must not contain path separators", r.Name)
+	}
+	return nil
+}
+
+// staticRoot returns the leading folders of the path template that hold no placeholder,
+// slash-separated: "Photos" for "Photos/{{year}}/{{month}}", and "" when the first one
+// already has a placeholder.
+func (r PhotoRouting) staticRoot() string {
+	return templateRoot(r.Path)
+}
+
+// templateRoot returns the leading folders of a path template that hold no placeholder,
+// slash-separated, or "" when the first one already has a placeholder.
+func templateRoot(tmpl string) string {
+	var segments []string
+	for _, segment := range templateSegments(tmpl) {
+		if placeholder.MatchString(segment) {
+			break
+		}
+		segments = append(segments, segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// templateSegments returns the folders of a path template, trimmed, without empty and
+// "." ones.
+func templateSegments(tmpl string) []string {
+	var segments []string
+	for _, segment := range strings.Split(filepath.ToSlash(tmpl), "/") {
+		if segment = strings.TrimSpace(segment); segment != "" && segment != "." {
+			segments = append(segments, segment)
+		}
+	}
+	return segments
+}
+
+// route returns the folder and file name for the image at path, originally called name.
+// Images without a capture date fall back to their modification time.
+func (r PhotoRouting) route(path, name string) (folder, filename string, info exif.Info) {
+	// Missing or unreadable metadata only costs the camera name; the date falls back below
+	info, _ = exif.Read(path)
+	taken := info.Time
+	if taken.IsZero() {
+		if fi, err := os.Stat(path); err == nil {
+			taken = fi.ModTime()
+		} else {
+			taken = time.Now()
+		}
+	}
+
+	ext := filepath.Ext(name)
+	vars := map[string]string{
+		"year":   taken.Format("2006"),
+		"month":  taken.Format("01"),
+		"day":    taken.Format("02"),
+		"date":   taken.Format("2006-01-02"),
+		"time":   taken.Format("150405"),
+		"make":   orUnknown(info.Make),
+		"model":  orUnknown(info.Model),
+		"camera": orUnknown(info.Camera()),
+		"name":   strings.TrimSuffix(name, ext),
+	}
+	expand := func(tmpl string) string {
+		return placeholder.ReplaceAllStringFunc(tmpl, func(m string) string {
+			return ai.SanitizeFilename(vars[placeholder.FindStringSubmatch(m)[1]])
+		})
+	}
+
+	var segments []string
+	for _, segment := range strings.Split(filepath.ToSlash(expand(r.Path)), "/") {
+		if segment = strings.TrimSpace(segment); segment != "" && segment != "." {
+			segments = append(segments, segment)
+		}
+	}
+	folder = strings.Join(segments, "/")
+
+	filename = ai.SanitizeFilename(name)
+	if r.Name != "" {
+		if stem := strings.TrimSpace(expand(r.Name)); stem != "" {
+			filename = stem + strings.ToLower(ext)
+		}
+	}
+	return folder, filename, info
+}
+
+func orUnknown(s string) string {
+	if s == "" {
+		return "Unknown"
+	}
+	return s
+}
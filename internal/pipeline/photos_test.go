@@ -0,0 +1,58 @@
+package pipeline
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPhotoRoutingValidate(t *testing.T) {
+	tests := []struct {
+		routing PhotoRouting
+		wantErr bool
+	}{
+		{PhotoRouting{Path: "Photos/{{year}}/{{month}}"}, false},
+		{PhotoRouting{Path: "Photos/{{ camera }}", Name: "{{date}} {{time}} {{name}}"}, false},
+		{PhotoRouting{}, true},
+		{PhotoRouting{Path: "Photos/{{season}}"}, true},
+		{PhotoRouting{Path: "../Photos/{{year}}"}, true},
+		{PhotoRouting{Path: "Photos", Name: "{{year}}/{{name}}"}, true},
+	}
+	for _, tt := range tests {
+		if err := tt.routing.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%+v.Validate() = %v, wantErr %v", tt.routing, err, tt.wantErr)
+		}
+	}
+}
+
+func TestPhotoRoute(t *testing.T) {
+	// Without EXIF data the modification time and "Unknown" camera are used
+	path := filepath.Join(t.TempDir(), "IMG_0042.JPG")
+	if err := os.WriteFile(path, []byte{0xFF, 0xD8, 0xFF, 0xD9}, 0644); err != nil {
+		t.Fatal(err)
+	}
+	mtime := time.Date(2023, 7, 14, 9, 5, 3, 0, time.Local)
+	if err := os.Chtimes(path, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		routing    PhotoRouting
+		wantFolder string
+		wantName   string
+	}{
+		{PhotoRouting{Path: "Photos/{{year}}/{{month}}"}, "Photos/2023/07", "IMG_0042.JPG"},
+		{PhotoRouting{Path: "Photos/{{camera}}/{{date}}", Name: "{{date}} {{time}} {{name}}"}, "Photos/Unknown/2023-07-14", "2023-07-14 090503 IMG_0042.jpg"},
+		{PhotoRouting{Path: "{{year}}//{{day}}/"}, "2023/14", "IMG_0042.JPG"},
+	}
+	for _, tt := range tests {
+		folder, name, info := tt.routing.route(path, filepath.Base(path))
+		if folder != tt.wantFolder || name != tt.wantName {
+			t.Errorf("%+v.route = %q, %q; want %q, %q", tt.routing, folder, name, tt.wantFolder, tt.wantName)
+		}
+		if !info.Time.IsZero() {
+			t.Errorf("capture time = %v, want zero for an image without EXIF", info.Time)
+		}
+	}
+}
@@ -3,16 +3,31 @@ package pipeline
 import (
 	"context"
 	"docs_organiser/internal/ai"
+	"docs_organiser/internal/audit"
 	"docs_organiser/internal/config"
+	"docs_organiser/internal/exif"
 	"docs_organiser/internal/extractor"
 	"docs_organiser/internal/fileops"
+	"docs_organiser/internal/logging"
+	"docs_organiser/internal/notes"
+	"docs_organiser/internal/notify"
 	"docs_organiser/internal/observability"
+	"docs_organiser/internal/plugin"
+	"docs_organiser/internal/privacy"
+	"docs_organiser/internal/remote"
+	"docs_organiser/internal/script"
+	"docs_organiser/internal/search"
+	"docs_organiser/internal/simhash"
+	"docs_organiser/internal/storage"
+	"errors"
 	"fmt"
 	"io/fs"
 	"log"
+	"math"
 	"os"
 	"path/filepath"
 	"runtime/debug"
+	"slices"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -25,21 +40,170 @@ type Pipeline struct {
 	AI           *ai.MLXEngine
 	Workers      int
 	ExtractLimit int
+	// ExtractLimits overrides ExtractLimit by lowercase extension without the dot.
+	ExtractLimits map[string]int
+	Filter        ScanFilter
+	Traversal     WalkOptions
+	Extraction    extractor.Options
+	RedactPII     bool
+	AutoContext   bool
+	Idle          *IdleMonitor
+	Remote        remote.Options
+
+	// Audit, when set, receives one record per processed file.
+	Audit *audit.Logger
+	// Results, when set, receives one CSV row per processed file.
+	Results *audit.CSVLogger
+	// ClassifyOnly classifies files and records the decisions without moving anything;
+	// their status is audit.StatusClassified.
+	ClassifyOnly bool
+	// Webhook, when set, is notified of run completion, failed files, and low-confidence results.
+	Webhook *notify.Webhook
+	// Summaries receive a plain-text report at the end of each run (Slack, Discord, email).
+	Summaries []notify.Channel
+	// PreRunHook runs before each run, which is skipped when it fails. PostRunHook runs
+	// after each run with the run_completed event, as webhooks receive it, on its stdin.
+	PreRunHook, PostRunHook *notify.Hook
+	// Events, when set, receives JSON progress and result events in place of the
+	// terminal progress line.
+	Events *EventWriter
+	// Notes, when set, receives a markdown note for every organised file.
+	Notes *notes.Vault
+	// Sidecar, when SidecarJSON or SidecarYAML, writes "<file>.<format>" with the full
+	// classification, original path, hash, and extraction metadata next to each organised file.
+	Sidecar string
+	// PDFMetadata stamps the title, category, and keywords into organised PDFs' document
+	// information and XMP metadata, so the classification travels with the file.
+	PDFMetadata bool
+	// Photos, when set, routes images by EXIF capture date and camera instead of the model.
+	Photos *PhotoRouting
+	// InvoiceCategories, when set, reads the vendor, amount, invoice number, and due date
+	// of documents classified into these categories (or below them) in a second request.
+	InvoiceCategories []string
+	// Documents, when set, builds the folder and file name of classified documents from
+	// templates over the category, title, and document date.
+	Documents *DocumentRouting
+	// NoLLM classifies with file name patterns and keyword dictionaries only (see
+	// ai.MLXEngine.ClassifyHeuristic), so no model server is needed.
+	NoLLM bool
+	// Classifier, when set, classifies documents in place of the model, which is then
+	// not needed either (see ai.MLXEngine.ClassifyWithPlugin).
+	Classifier *plugin.Plugin
+	// Script, when set, may override or veto each classification (see runScript).
+	Script *script.Script
+	// Search, when set, receives the embedding of every organised file, made with
+	// EmbeddingModel (the classification model when empty), for semantic search.
+	Search         *search.Index
+	EmbeddingModel string
+	// NearDuplicates, when set, keeps a simhash fingerprint of the text of every organised
+	// document, so that later documents within NearDuplicateDistance bits of one are
+	// flagged or grouped with it, as NearDuplicateMode says.
+	NearDuplicates        *simhash.Index
+	NearDuplicateMode     NearDuplicateMode
+	NearDuplicateDistance int
+	// Collisions decides what happens when a local destination name is taken by a
+	// different file; identical files are never stored twice.
+	Collisions fileops.CollisionPolicy
+	// CollisionHash computes the suffix of CollisionHash names, and of remote ones
+	// ("" means SHA-256); PartialHashBytes is the span fileops.HashPartial reads.
+	CollisionHash    fileops.HashScheme
+	PartialHashBytes int64
+	// Naming decides whether classified files take the model's title as their name, keep
+	// their original name, or get the title as a prefix ("" means NamingRename).
+	Naming NamingMode
+	// Layout decides whether files keep their source folder under their category
+	// ("" means LayoutFlat).
+	Layout Layout
+	// Destinations maps categories, and their sub-categories, to destination roots used
+	// instead of DestDir, e.g. Finance to an encrypted volume (see ParseDestinations).
+	Destinations map[string]string
+	// ASCIINames transliterates the folder and file names of organised files to ASCII,
+	// for destinations synced to systems or tools that mishandle accented names.
+	ASCIINames bool
+	// MaxFilenameBytes, when above zero, shortens generated file names to this many bytes
+	// (see fileops.TruncateName); ext4, APFS, and NTFS reject names over 255.
+	MaxFilenameBytes int
+	// DiskReserve is the free space, in bytes, a local destination must keep after each
+	// copied file. A destination that fills up ends the run instead of failing every
+	// remaining file.
+	DiskReserve int64
+	// Throttle, when set, limits the bandwidth and reads of files copied to a local
+	// destination on another volume, such as a NAS mount.
+	Throttle *fileops.Throttle
+	// MemoryLimit, when above zero, is the memory in bytes that the files being processed
+	// may use together: each reserves its size plus its text limit before it starts, and
+	// waits while the others hold too much. A larger file runs on its own.
+	MemoryLimit int64
+	// DeadLetterAfter, when above zero, moves files whose text extraction failed this
+	// many times, counted across runs, to UnprocessedDir in the destination, so the source
+	// can be drained; files the model could not classify go there instead of Misc.
+	// Attempts, when set, keeps the counts between processes (else they are kept in memory).
+	DeadLetterAfter int
+	Attempts        storage.Store
+	// RemoveEmptyDirs removes local source directories left empty once their files
+	// are organised, at the end of each run. The source root is kept.
+	RemoveEmptyDirs bool
+	// MaxFiles, when above zero, ends the scan after that many files. Sample instead
+	// processes that many files picked at random from the whole source (scanned first).
+	MaxFiles int
+	Sample   int
+	// FailuresFile, when set, is replaced at the end of each run with the files that
+	// failed and why (see FailureList), for a later run to retry.
+	FailuresFile string
+	// Retry, when set, makes Run process only the files it lists instead of scanning
+	// the source.
+	Retry *FailureList
+	// QueueFile, when set, lists the files of runs that find the model server down,
+	// instead of failing them, and those left in the source when it goes down mid-run
+	// (see SetServerDown). With ProcessQueue, Run processes only the files it lists.
+	QueueFile    string
+	ProcessQueue bool
+	// Order, unless OrderWalk (or ""), scans the whole source first and then processes
+	// the files by size or modification time; MaxFiles then takes the first in that order.
+	Order JobOrder
+	// FastPath, when above zero, first classifies each file from its name, folder, and
+	// metadata, and only extracts the text when the confidence is below this threshold.
+	FastPath float64
+	// WarmUp sends the model a tiny request before each run starts its workers, so the
+	// first file doesn't pay for loading the model within its timeout.
+	WarmUp bool
+	// OnFile and OnProgress, when set, are called from the workers with each file's
+	// outcome and the updated counters, for programs embedding the pipeline. OnProgress
+	// replaces the terminal progress line.
+	OnFile     func(audit.Record)
+	OnProgress func(Progress)
 
 	// Progress counters
 	TotalFiles     int32
 	ProcessedFiles int32
 	FailedFiles    int32
 	ActiveWorkers  int32
+	stats          *runStats
+	results        resultHub
+	remotes        map[string]remote.Backend
+	remotesMu      sync.Mutex
+	vacated        map[string]bool // local source directories files were moved out of
+	vacatedMu      sync.Mutex
+	stopIntake     context.CancelFunc // ends the current run's scan and job intake (see Drain)
+	stopIntakeMu   sync.Mutex
+	abortErr       error // why the current run stopped early (guarded by stopIntakeMu)
+	attempts       attemptCounter
 
 	// Flow Control
-	isPaused  bool
-	pauseMu   sync.Mutex
-	pauseCond *sync.Cond
+	isPaused   bool
+	serverDown bool // held by SetServerDown, independently of Pause
+	pauseMu    sync.Mutex
+	pauseCond  *sync.Cond
 }
 
 type FileJob struct {
 	Path string
+	// Key identifies the file in a remote source; the worker downloads it before processing.
+	Key string
+
+	// Set by the scan, for Order
+	size    int64
+	modTime time.Time
 }
 
 func NewPipeline(src, dst string, aiEngine *ai.MLXEngine, workers, extractLimit int) *Pipeline {
@@ -71,35 +235,145 @@ func (p *Pipeline) Resume() {
 	p.pauseCond.Broadcast()
 }
 
+// TogglePause pauses a running pipeline, or resumes a paused one, and reports whether it
+// is now paused. Files in progress finish before the workers stop.
+func (p *Pipeline) TogglePause() bool {
+	p.pauseMu.Lock()
+	p.isPaused = !p.isPaused
+	paused := p.isPaused
+	p.pauseMu.Unlock()
+	if !paused {
+		p.pauseCond.Broadcast()
+	}
+	return paused
+}
+
 func (p *Pipeline) IsPaused() bool {
 	p.pauseMu.Lock()
 	defer p.pauseMu.Unlock()
 	return p.isPaused
 }
 
+// SetServerDown holds the workers before their next file while the model server is down,
+// as reported by the AI engine's circuit breaker, and releases them once it is back.
+// Unlike Pause, it doesn't change IsPaused.
+func (p *Pipeline) SetServerDown(down bool) {
+	p.pauseMu.Lock()
+	p.serverDown = down
+	p.pauseMu.Unlock()
+	if !down {
+		p.pauseCond.Broadcast()
+	}
+}
+
 func (p *Pipeline) waitIfPaused() {
 	p.pauseMu.Lock()
-	for p.isPaused {
+	for p.isPaused || p.serverDown {
 		p.pauseCond.Wait()
 	}
 	p.pauseMu.Unlock()
 }
 
-func (p *Pipeline) Run(ctx context.Context) error {
-	var err error
-	if len(p.AI.GetCategories()) == 0 {
-		var discoveredCategories []string
-		discoveredCategories, err = p.discoverCategories()
+func (p *Pipeline) Run(ctx context.Context) (err error) {
+	warmUp := p.WarmUp
+	if p.Idle != nil {
+		if p.Idle.Begin() {
+			warmUp = true // the model may have been unloaded while idle
+		}
+		defer p.Idle.End()
+	}
+	if p.PreRunHook != nil {
+		if err := p.PreRunHook.Run(ctx, nil); err != nil {
+			return fmt.Errorf("pre-run hook failed: %w", err)
+		}
+	}
+	p.stats = newRunStats()
+	defer p.logRun(time.Now(), atomic.LoadInt32(&p.TotalFiles),
+		atomic.LoadInt32(&p.ProcessedFiles), atomic.LoadInt32(&p.FailedFiles))
+	if p.Events != nil {
+		p.Events.emit(Event{Type: EventRunStarted, Source: p.SourceDir, Dest: p.DestDir})
+	}
+	if p.Webhook != nil || len(p.Summaries) > 0 || p.Events != nil || p.PostRunHook != nil {
+		defer p.reportRun(time.Now(), atomic.LoadInt32(&p.TotalFiles),
+			atomic.LoadInt32(&p.ProcessedFiles), atomic.LoadInt32(&p.FailedFiles), &err)
+	}
+
+	if err := p.prepare(ctx, warmUp); err != nil {
+		if p.QueueFile != "" && !p.ProcessQueue && p.Retry == nil && errors.Is(err, ErrModelUnavailable) {
+			return p.queueSource(ctx, err)
+		}
+		return err
+	}
+
+	src, err := p.remoteFor(p.SourceDir)
+	if err != nil {
+		return fmt.Errorf("failed to open source: %w", err)
+	}
+	for _, root := range p.destRoots() {
+		if _, err := p.remoteFor(root); err != nil {
+			return fmt.Errorf("failed to open destination: %w", err)
+		}
+	}
+
+	retry := p.Retry
+	if p.ProcessQueue {
+		queue, err := LoadFailures(p.QueueFile)
+		if errors.Is(err, os.ErrNotExist) {
+			log.Printf("[*] No files are queued in %s", p.QueueFile)
+			return nil
+		}
 		if err != nil {
-			log.Printf("[!] Warning: Category discovery failed: %v. Using defaults.", err)
-		} else if len(discoveredCategories) > 0 {
-			log.Printf("[*] Discovered %d categories in %s", len(discoveredCategories), p.DestDir)
-			p.AI.SetCategories(discoveredCategories)
+			return err
+		}
+		log.Printf("[*] Processing %d files queued by the run at %s", len(queue.Files), queue.Finished.Format(time.RFC3339))
+		retry = queue
+	} else if retry != nil {
+		log.Printf("[*] Retrying %d files that failed in the run finished at %s", len(retry.Files), retry.Finished.Format(time.RFC3339))
+	}
+	if retry != nil {
+		if err := retry.checkSource(p.SourceDir); err != nil {
+			return err
+		}
+	}
+	if p.QueueFile != "" {
+		defer func() {
+			if err := p.updateQueue(); err != nil {
+				log.Printf("[!] Failed to update the queue file: %v", err)
+			}
+		}()
+	}
+	if p.FailuresFile != "" {
+		defer func() {
+			if err := p.writeFailures(p.FailuresFile); err != nil {
+				log.Printf("[!] Failed to write the failures file: %v", err)
+			}
+		}()
+	}
+
+	var excludedDirs []string
+	if src == nil {
+		excludedDirs, err = p.sourceExclusions()
+		if err != nil {
+			return fmt.Errorf("failed to resolve source/destination overlap: %w", err)
+		}
+		for _, dir := range excludedDirs {
+			log.Printf("[*] Destination overlaps source, skipping %s while scanning", dir)
 		}
 	}
 
+	// Draining ends intake; files already being processed keep ctx and finish
+	intake, stopIntake := context.WithCancel(ctx)
+	defer stopIntake()
+	p.stopIntakeMu.Lock()
+	p.stopIntake, p.abortErr = stopIntake, nil
+	p.stopIntakeMu.Unlock()
+
 	jobs := make(chan FileJob, p.Workers*2)
 	var wg sync.WaitGroup
+	var budget *memoryBudget
+	if p.MemoryLimit > 0 {
+		budget = newMemoryBudget(p.MemoryLimit)
+	}
 
 	// Step 1: Start workers
 	for i := 0; i < p.Workers; i++ {
@@ -116,21 +390,32 @@ func (p *Pipeline) Run(ctx context.Context) error {
 			for {
 				p.waitIfPaused()
 				select {
-				case <-ctx.Done():
+				case <-intake.Done():
 					return
 				case job, ok := <-jobs:
-					if !ok {
+					if !ok || intake.Err() != nil {
 						return
 					}
+					release := func() {}
+					if budget != nil {
+						// Waiting here also holds back the scan once the queue is full
+						var err error
+						if release, err = budget.acquire(intake, p.memoryEstimate(job)); err != nil {
+							return
+						}
+					}
 
 					// Log the file being processed to identify "killer files"
-					log.Printf("[*] Processing: %s", filepath.Base(job.Path))
+					log.Printf("[*] Processing: %s", job.name())
 
 					atomic.AddInt32(&p.ActiveWorkers, 1)
 					observability.ActiveWorkersGauge.Inc()
 					// Use a per-file timeout to prevent hanging workers
-					fileCtx, cancel := context.WithTimeout(ctx, 2*time.Minute)
-					p.processFile(fileCtx, job.Path)
+					fileCtx, cancel := context.WithTimeout(ctx, fileTimeout)
+					func() {
+						defer release() // also when processing panics
+						p.processFile(fileCtx, job)
+					}()
 					cancel()
 					observability.ActiveWorkersGauge.Dec()
 					atomic.AddInt32(&p.ActiveWorkers, -1)
@@ -147,8 +432,185 @@ func (p *Pipeline) Run(ctx context.Context) error {
 	}
 
 	// Step 2: Scan and feed jobs in a stream
-	fmt.Println("[*] Scanning source directory...")
-	err = filepath.Walk(p.SourceDir, func(path string, info os.FileInfo, err error) error {
+	enqueue := func(job FileJob) error {
+		atomic.AddInt32(&p.TotalFiles, 1)
+		select {
+		case <-intake.Done():
+			return intake.Err()
+		case jobs <- job:
+			return nil
+		}
+	}
+
+	if p.Events == nil {
+		fmt.Println("[*] Scanning source directory...")
+	}
+	feed := enqueue
+	var sample *reservoir
+	var scanned []FileJob // the whole scan, to be ordered
+	ordered := p.Order != "" && p.Order != OrderWalk
+	switch {
+	case p.Sample > 0:
+		size := p.Sample
+		if p.MaxFiles > 0 {
+			size = min(size, p.MaxFiles)
+		}
+		sample = newReservoir(size, nil)
+		feed = sample.add
+	case ordered:
+		feed = func(job FileJob) error {
+			scanned = append(scanned, job)
+			return nil
+		}
+	case p.MaxFiles > 0:
+		feed = limitJobs(p.MaxFiles, enqueue)
+	}
+	if retry != nil {
+		err = p.scanRetry(intake, retry, src != nil, feed)
+	} else if src != nil {
+		err = p.scanRemote(intake, src, feed)
+	} else {
+		err = p.scanLocal(intake, excludedDirs, feed)
+	}
+	if errors.Is(err, errEnoughFiles) {
+		log.Printf("[*] Reached max_files (%d); the rest of the source is left for later runs", p.MaxFiles)
+		err = nil
+	}
+	if sample != nil && err == nil {
+		log.Printf("[*] Processing a random sample of %d of %d files", len(sample.jobs), sample.seen)
+		scanned = sample.jobs
+	}
+	if err == nil && scanned != nil {
+		p.Order.sort(scanned)
+		if sample == nil && p.MaxFiles > 0 && len(scanned) > p.MaxFiles {
+			log.Printf("[*] Processing the first %d of %d files (%s first); the rest are left for later runs", p.MaxFiles, len(scanned), p.Order)
+			scanned = scanned[:p.MaxFiles]
+		}
+		for _, job := range scanned {
+			if err = enqueue(job); err != nil {
+				break
+			}
+		}
+	}
+
+	// Close jobs channel after scanning is done
+	close(jobs)
+
+	// Step 3: Wait for workers to finish
+	wg.Wait()
+	if p.Events == nil {
+		fmt.Println() // New line after final progress
+	}
+
+	if src == nil {
+		p.removeVacatedDirs()
+	}
+
+	if err != nil && err != context.Canceled {
+		return err
+	}
+	p.stopIntakeMu.Lock()
+	abortErr := p.abortErr
+	p.stopIntakeMu.Unlock()
+	if abortErr != nil && ctx.Err() == nil {
+		return abortErr
+	}
+	return intake.Err()
+}
+
+// abort drains the current run because of err, which Run then returns. Only the first
+// reason is kept.
+func (p *Pipeline) abort(err error) {
+	p.stopIntakeMu.Lock()
+	first := p.abortErr == nil
+	if first {
+		p.abortErr = err
+	}
+	p.stopIntakeMu.Unlock()
+	if first {
+		log.Printf("[!] Stopping the run: %v. Remaining files stay in the source.", err)
+		p.Drain()
+	}
+}
+
+// Drain stops the current run from starting any more files: scanning ends and queued
+// files are dropped, while files already in progress finish (moved and recorded). Run
+// then returns context.Canceled. Cancelling the run's context instead interrupts them.
+func (p *Pipeline) Drain() {
+	p.stopIntakeMu.Lock()
+	stop := p.stopIntake
+	p.stopIntakeMu.Unlock()
+	if stop != nil {
+		stop()
+	}
+}
+
+// usesModel reports whether documents are classified by the model server, rather than
+// offline or by a classifier plugin.
+func (p *Pipeline) usesModel() bool {
+	return !p.NoLLM && p.Classifier == nil
+}
+
+// Prepare checks the model server and, unless categories are configured, discovers them
+// from the destination. Run calls it first; callers using ProcessFile outside a run call it themselves.
+func (p *Pipeline) Prepare(ctx context.Context) error {
+	return p.prepare(ctx, p.WarmUp)
+}
+
+// prepare is Prepare, sending the warm-up request when warmUp is set.
+func (p *Pipeline) prepare(ctx context.Context, warmUp bool) error {
+	if p.usesModel() {
+		model, err := p.AI.Preflight(ctx)
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrModelUnavailable, err)
+		}
+		if warmUp {
+			warmCtx, cancel := context.WithTimeout(ctx, fileTimeout)
+			elapsed, err := p.AI.WarmUp(warmCtx, model)
+			cancel()
+			if err != nil {
+				log.Printf("[!] Warning: %v", err)
+			} else {
+				log.Printf("[+] Model warmed up in %s", elapsed.Round(time.Millisecond))
+			}
+		}
+		log.Printf("[+] Model server ready: %s", model)
+		if p.AutoContext {
+			p.AI.AutoSizeContext(ctx, model)
+		}
+	}
+
+	dst, err := p.remoteFor(p.DestDir)
+	if err != nil {
+		return fmt.Errorf("failed to open destination: %w", err)
+	}
+
+	if len(p.AI.GetCategories()) == 0 {
+		var discoveredCategories []string
+		if dst != nil {
+			discoveredCategories, err = discoverRemoteCategories(ctx, dst)
+		} else {
+			discoveredCategories, err = p.discoverCategories()
+		}
+		if err != nil {
+			log.Printf("[!] Warning: Category discovery failed: %v. Using defaults.", err)
+		} else if len(discoveredCategories) > 0 {
+			log.Printf("[*] Discovered %d categories in %s", len(discoveredCategories), p.DestDir)
+			p.AI.SetCategories(discoveredCategories)
+		}
+	}
+	return nil
+}
+
+// scanLocal walks the source directory and enqueues every accepted file. Paths matched by
+// the ignore file in the source root, reread on every run, are skipped.
+func (p *Pipeline) scanLocal(ctx context.Context, excludedDirs []string, enqueue func(FileJob) error) error {
+	ignoreFile := filepath.Join(p.SourceDir, IgnoreFileName)
+	ignore, err := LoadIgnoreFile(ignoreFile)
+	if err != nil {
+		log.Printf("[!] Not applying %s: %v", IgnoreFileName, err)
+	}
+	return walkTree(p.SourceDir, p.Traversal, func(path string, info os.FileInfo, err error) error {
 		p.waitIfPaused()
 		if err != nil {
 			return err
@@ -156,89 +618,468 @@ func (p *Pipeline) Run(ctx context.Context) error {
 		if ctx.Err() != nil {
 			return ctx.Err()
 		}
-		if !info.IsDir() {
-			ext := strings.ToLower(filepath.Ext(path))
-			if ext == ".pdf" || ext == ".txt" || ext == ".md" {
-				atomic.AddInt32(&p.TotalFiles, 1)
-				select {
-				case <-ctx.Done():
-					return ctx.Err()
-				case jobs <- FileJob{Path: path}:
-				}
+		rel, relErr := filepath.Rel(p.SourceDir, path)
+		if relErr != nil {
+			rel = path
+		}
+		if info.IsDir() {
+			if path != p.SourceDir && (p.Filter.SkipDir(rel) || isExcludedDir(path, excludedDirs) || ignore.Ignored(filepath.ToSlash(rel), true)) {
+				return filepath.SkipDir
+			}
+		} else {
+			if path == ignoreFile || ignore.Ignored(filepath.ToSlash(rel), false) {
+				return nil
+			}
+			if p.Filter.Accept(rel) && p.Filter.AcceptModTime(info.ModTime()) {
+				return enqueue(FileJob{Path: path, size: info.Size(), modTime: info.ModTime()})
 			}
 		}
 		return nil
 	})
+}
 
-	// Close jobs channel after scanning is done
-	close(jobs)
+func (p *Pipeline) processFile(ctx context.Context, job FileJob) (rec audit.Record) {
+	path, name := job.Path, job.name()
+	rec = audit.Record{Source: path}
+	defer func() {
+		if p.stats != nil {
+			p.stats.fail(job, rec)
+			p.stats.settle(job, rec)
+		}
+		p.recordFile(rec)
+	}()
 
-	// Step 3: Wait for workers to finish
-	wg.Wait()
-	fmt.Println() // New line after final progress
+	// Remote files are staged locally for extraction and delivery
+	var src remote.Backend
+	if job.Key != "" {
+		var err error
+		if src, err = p.remoteFor(p.SourceDir); err == nil {
+			rec.Source = src.URL(job.Key)
+			path, err = remote.Fetch(ctx, src, job.Key)
+		}
+		if err != nil {
+			log.Printf("[!] Failed to download %s: %v", name, err)
+			observability.ErrorsTotal.WithLabelValues("download").Inc()
+			atomic.AddInt32(&p.FailedFiles, 1)
+			rec.Status = audit.StatusExtractionFailed
+			rec.Error = err.Error()
+			return
+		}
+		defer os.Remove(path)
+	}
 
-	if err != nil && err != context.Canceled {
-		return err
+	if text, ok := p.classifyFile(ctx, &rec, job, path, name); ok {
+		if p.Documents != nil && rec.Photo == nil && !rec.Fallback {
+			rec.Category, rec.Title = p.Documents.route(path, name, rec)
+		}
+		if !rec.Unprocessed {
+			rec.Category = p.Layout.folder(rec.Category, p.sourceDir(job))
+		}
+		if p.ASCIINames {
+			ext := filepath.Ext(rec.Title)
+			rec.Category = ai.SanitizeCategory(ai.Transliterate(rec.Category))
+			rec.Title = ai.SanitizeFilename(ai.Transliterate(strings.TrimSuffix(rec.Title, ext))) + ext
+		}
+		rec.Title = fileops.TruncateName(rec.Title, p.MaxFilenameBytes)
+		var fingerprint uint64
+		var fingerprinted bool
+		if p.NearDuplicates != nil && !rec.Unprocessed {
+			fingerprint, fingerprinted = p.nearDuplicate(&rec, name, text)
+		}
+		if p.ClassifyOnly {
+			log.Printf("[*] Classified %s as %s/%s; leaving it in place", name, rec.Category, rec.Title)
+			atomic.AddInt32(&p.ProcessedFiles, 1)
+			rec.Status = audit.StatusClassified
+		} else {
+			p.organise(ctx, &rec, path, name, src, job.Key)
+			if p.Search != nil && rec.Status == audit.StatusMoved && !rec.Unprocessed {
+				p.indexFile(ctx, rec, text)
+			}
+			if fingerprinted && rec.Status == audit.StatusMoved && !rec.Duplicate {
+				p.addFingerprint(rec, fingerprint)
+			}
+		}
+	} else if rec.Status == audit.StatusExtractionFailed && p.DeadLetterAfter > 0 && !p.ClassifyOnly && p.countFailure(job, path, rec.Source) {
+		log.Printf("[*] Moving %s to %s: extraction failed %d times", name, UnprocessedDir, p.DeadLetterAfter)
+		atomic.AddInt32(&p.FailedFiles, -1) // delivered after all
+		rec.Unprocessed = true
+		rec.Category, rec.Title = p.unprocessedFolder(job), name
+		p.organise(ctx, &rec, path, name, src, job.Key)
 	}
-	return ctx.Err()
+	return rec
 }
 
-func (p *Pipeline) processFile(ctx context.Context, path string) {
-	effectiveLimit := p.ExtractLimit
-	if effectiveLimit <= 0 {
-		// Heuristic: 1 token is roughly 4 characters, but for extraction we can be more generous
-		// and let the AI truncate/summarize later. 10 chars per token is a safe upper bound.
-		effectiveLimit = p.AI.ContextWindow() * 10
+// classifyFile decides rec.Category and rec.Title for the local file at path, from EXIF
+// data, the fast path, or the extracted text. It returns false, with rec.Status set, when
+// the file can't be organised (extraction failed or ctx ended). The extracted text is
+// returned too; it is empty when the decision was made without it.
+func (p *Pipeline) classifyFile(ctx context.Context, rec *audit.Record, job FileJob, path, name string) (string, bool) {
+	effectiveLimit := p.textLimit(name)
+
+	// Photos are filed by their EXIF data; the model has nothing to read in them
+	if p.Photos != nil && exif.IsImage(name) {
+		var info exif.Info
+		rec.Category, rec.Title, info = p.Photos.route(path, name)
+		rec.Photo = &info
+		log.Printf("[+] EXIF: %s | Taken: %s | Camera: %s", name, info.Time.Format(time.DateTime), info.Camera())
+		return "", true
+	}
+
+	if p.FastPath > 0 && p.usesModel() {
+		if result := p.classifyByName(ctx, job, path, name); result != nil {
+			rec.Classification = result
+			rec.Category = result.Analysis.Category
+			rec.Title = p.Naming.fileName(result.Analysis.Title, name)
+			return "", p.runScript(ctx, rec, job, path, name, "")
+		}
 	}
 
-	text, err := extractor.ExtractText(path, effectiveLimit)
+	extractStart := time.Now()
+	text, err := p.Extraction.Extract(ctx, path, effectiveLimit)
+	rec.Extraction.Duration = time.Since(extractStart)
+	rec.Extraction.Chars = len(text)
 	if err != nil {
-		log.Printf("[!] Failed to extract text from %s: %v", filepath.Base(path), err)
+		log.Printf("[!] Failed to extract text from %s: %v", name, err)
 		observability.ErrorsTotal.WithLabelValues("extraction").Inc()
 		atomic.AddInt32(&p.FailedFiles, 1)
-		return
+		rec.Status = audit.StatusExtractionFailed
+		rec.Error = err.Error()
+		return "", false
 	}
 
 	if ctx.Err() != nil {
-		return
+		rec.Status = audit.StatusCancelled
+		return "", false
+	}
+
+	if p.RedactPII {
+		var redactions int
+		text, redactions = privacy.Redact(text)
+		if redactions > 0 {
+			log.Printf("[*] Redacted %d PII matches from %s", redactions, name)
+		}
+		rec.Extraction.Redactions = redactions
 	}
 
-	result, err := p.AI.Categorize(ctx, text)
+	var result *ai.CategorizationResult
+	if p.NoLLM {
+		result, err = p.AI.ClassifyHeuristic(name, text)
+	} else {
+		source := ai.Source{Name: name, Dir: p.sourceDir(job)}
+		if p.RedactPII {
+			source.Name, _ = privacy.Redact(source.Name)
+			source.Dir, _ = privacy.Redact(source.Dir)
+		}
+		if p.Classifier != nil {
+			result, err = p.AI.ClassifyWithPlugin(ctx, *p.Classifier, path, source, text)
+		} else {
+			result, err = p.AI.CategorizeSource(ctx, source, text)
+		}
+	}
+	if err != nil && (errors.Is(ctx.Err(), context.Canceled) || errors.Is(err, ai.ErrServerDown)) {
+		// Interrupted, or the model server went down, not unclassifiable (a per-file
+		// timeout still falls back to Misc): leave the file for the next run
+		if errors.Is(err, ai.ErrServerDown) {
+			log.Printf("[!] Leaving %s in the source: %v", name, err)
+			if p.QueueFile != "" && p.stats != nil {
+				p.stats.queue(Failure{File: rec.Source, Key: job.Key, Status: queuedStatus, Error: err.Error()})
+			}
+		}
+		rec.Status = audit.StatusCancelled
+		return "", false
+	}
+	rec.Classification = result
 
 	targetFolder := "Misc"
-	targetName := ai.SanitizeFilename(filepath.Base(path))
+	targetName := ai.SanitizeFilename(name)
 
 	if err == nil {
 		targetFolder = result.Analysis.Category
-		targetName = result.Analysis.Title + filepath.Ext(path)
+		targetName = p.Naming.fileName(result.Analysis.Title, name)
 
 		// Log detailed metadata for observability
-		log.Printf("[+] AI: %s | Latency: %v | Tokens: %d (%d/%d) | Trunc: %s | Attempts: %d",
+		log.Printf("[+] AI: %s | Latency: %v | Tokens: %d (%d/%d) | Trunc: %s | Attempts: %d | Lang: %s",
 			result.Metadata.Model,
 			result.Metadata.Latency,
 			result.Metadata.TotalTokens,
 			result.Metadata.PromptTokens,
 			result.Metadata.ResponseTokens,
 			result.Metadata.TruncationType,
-			result.Metadata.Attempts)
+			result.Metadata.Attempts,
+			result.Metadata.Language)
+	} else {
+		rec.Fallback = true
+		rec.Error = err.Error()
+		if p.DeadLetterAfter > 0 {
+			targetFolder, targetName = p.unprocessedFolder(job), name
+			rec.Unprocessed = true
+		}
+	}
+	rec.Category = targetFolder
+	rec.Title = targetName
+	if !rec.Unprocessed && !p.runScript(ctx, rec, job, path, name, text) {
+		return "", false
+	}
+	targetFolder, targetName = rec.Category, rec.Title
+	if err == nil && p.isInvoiceCategory(targetFolder) {
+		invoice, invoiceErr := p.AI.ExtractInvoice(ctx, text)
+		if invoiceErr != nil {
+			log.Printf("[!] Failed to read invoice details of %s: %v", name, invoiceErr)
+		}
+		rec.Invoice = invoice
+	}
+	if err == nil {
+		logging.Verbosef("[*] Decision: %s -> %s/%s (confidence %.2f)", name, targetFolder, targetName, result.Analysis.ConfidenceScore)
+	} else {
+		logging.Verbosef("[*] Decision: %s -> %s/%s (fallback: %v)", name, targetFolder, targetName, err)
 	}
+	return text, true
+}
 
-	finalDestDir := filepath.Join(p.DestDir, targetFolder)
+// organise moves the file at path to rec.Category/rec.Title and records the outcome in rec.
+// For remote sources, src and key identify the original, which is removed once delivered.
+func (p *Pipeline) organise(ctx context.Context, rec *audit.Record, path, name string, src remote.Backend, key string) {
+	// The sidecar describes the file as it was before the move
+	var hash string
+	var size int64
+	if p.Sidecar != "" {
+		if info, statErr := os.Stat(path); statErr == nil {
+			size = info.Size()
+		}
+		var hashErr error
+		if hash, hashErr = fileops.HashFile(path); hashErr != nil {
+			log.Printf("[!] Failed to hash %s for its sidecar: %v", name, hashErr)
+		}
+	}
 
-	if err := fileops.MoveFile(path, finalDestDir, targetName); err != nil {
-		log.Printf("[!] Failed to move %s to %s/%s: %v", filepath.Base(path), targetFolder, targetName, err)
+	dest, outcome, consumed, err := p.deliver(ctx, path, rec.Category, rec.Title, src, key, p.pdfMetadata(*rec))
+	switch {
+	case err != nil:
+		log.Printf("[!] Failed to move %s to %s/%s: %v", name, rec.Category, rec.Title, err)
 		observability.ErrorsTotal.WithLabelValues("move").Inc()
 		atomic.AddInt32(&p.FailedFiles, 1)
-	} else {
+		rec.Status = audit.StatusMoveFailed
+		rec.Error = err.Error()
+		if errors.Is(err, fileops.ErrDiskFull) {
+			p.abort(err)
+		}
+	case outcome == fileops.Skipped:
+		// The original stays in the source, remote ones included
+		log.Printf("[*] Skipped %s: %s already exists", name, dest)
+		atomic.AddInt32(&p.ProcessedFiles, 1)
+		rec.Status = audit.StatusSkipped
+		rec.Destination = dest
+	case outcome == fileops.InPlace:
+		log.Printf("[*] %s is already organised as %s", name, dest)
 		atomic.AddInt32(&p.ProcessedFiles, 1)
+		rec.Status = audit.StatusMoved
+		rec.Destination = dest
+	case outcome == fileops.Duplicate:
+		log.Printf("[*] %s is already organised as %s; removed the duplicate", name, dest)
+		atomic.AddInt32(&p.ProcessedFiles, 1)
+		rec.Status = audit.StatusMoved
+		rec.Duplicate = true
+		rec.Destination = dest
+		if src == nil {
+			p.vacate(path)
+		} else if !consumed {
+			if err := src.Delete(ctx, key); err != nil {
+				log.Printf("[!] Could not remove the duplicate original of %s: %v", name, err)
+				rec.Error = err.Error()
+			}
+		}
+	default:
+		atomic.AddInt32(&p.ProcessedFiles, 1)
+		rec.Status = audit.StatusMoved
+		rec.Destination = dest
+		if src == nil {
+			p.vacate(path)
+		}
+		if !rec.Unprocessed {
+			p.writeNote(*rec)
+		}
+		if p.Sidecar != "" && !rec.Unprocessed {
+			if err := p.writeSidecar(ctx, *rec, hash, size); err != nil {
+				log.Printf("[!] Failed to write sidecar for %s: %v", name, err)
+			}
+		}
+		if src != nil && !consumed {
+			if err := src.Delete(ctx, key); err != nil {
+				log.Printf("[!] Organised %s but could not remove the original: %v", name, err)
+				rec.Error = err.Error()
+			}
+		}
 	}
 }
 
+// writeNote adds the knowledge-base note for a moved file, when a vault is configured.
+func (p *Pipeline) writeNote(rec audit.Record) {
+	if p.Notes == nil {
+		return
+	}
+	note := notes.Note{
+		Title:    strings.TrimSuffix(rec.Title, filepath.Ext(rec.Title)),
+		Category: rec.Category,
+		File:     rec.Destination,
+		Source:   rec.Source,
+		Created:  time.Now(),
+	}
+	if rec.Classification != nil && rec.Classification.Analysis != nil && !rec.Fallback {
+		note.Tags = rec.Classification.Analysis.Tags
+		note.Summary = rec.Classification.Analysis.Summary
+		note.Confidence = rec.Classification.Analysis.ConfidenceScore
+	}
+	if rec.Invoice != nil {
+		note.Invoice = rec.Invoice
+	}
+	if _, err := p.Notes.Write(note); err != nil {
+		log.Printf("[!] Failed to write note for %s: %v", filepath.Base(rec.Destination), err)
+	}
+}
+
+// recordFile writes the outcome of a file to the audit log and webhook, when configured.
+func (p *Pipeline) recordFile(rec audit.Record) {
+	if p.stats != nil {
+		p.stats.record(rec)
+	}
+	p.results.publish(rec)
+	if p.OnFile != nil {
+		p.OnFile(rec)
+	}
+	if p.Events != nil {
+		p.Events.emit(Event{Type: EventFile, File: &rec})
+	}
+	if p.Audit != nil {
+		if err := p.Audit.Write(rec); err != nil {
+			log.Printf("[!] Failed to write audit record for %s: %v", filepath.Base(rec.Source), err)
+		}
+	}
+	if p.Results != nil {
+		if err := p.Results.Write(rec); err != nil {
+			log.Printf("[!] Failed to write the results row for %s: %v", filepath.Base(rec.Source), err)
+		}
+	}
+
+	if p.Webhook == nil {
+		return
+	}
+	ev := notify.Event{File: rec.Source, Category: rec.Category, Title: rec.Title, Error: rec.Error}
+	if rec.Classification != nil && rec.Classification.Analysis != nil {
+		ev.Confidence = rec.Classification.Analysis.ConfidenceScore
+	}
+	switch {
+	case rec.Status == audit.StatusExtractionFailed || rec.Status == audit.StatusMoveFailed:
+		ev.Type = notify.EventFileFailed
+	case rec.Status == audit.StatusMoved && rec.Photo == nil && (rec.Fallback || ev.Confidence < p.Webhook.LowConfidence):
+		ev.Type = notify.EventLowConfidence
+	default:
+		return
+	}
+	p.Webhook.Notify(ev)
+}
+
+// logRun logs the counters accumulated since the run started; it is printed at every log level.
+func (p *Pipeline) logRun(start time.Time, total, processed, failed int32) {
+	done := "organised"
+	if p.ClassifyOnly {
+		done = "classified"
+	}
+	logging.Summaryf("[+] Run finished in %s: %d %s, %d failed of %d files",
+		time.Since(start).Round(time.Second),
+		atomic.LoadInt32(&p.ProcessedFiles)-processed, done,
+		atomic.LoadInt32(&p.FailedFiles)-failed,
+		atomic.LoadInt32(&p.TotalFiles)-total)
+}
+
+// reportRun sends the counters accumulated since the run started to the webhook, summary
+// channels, event output, and post-run hook.
+func (p *Pipeline) reportRun(start time.Time, total, processed, failed int32, runErr *error) {
+	summary := notify.RunSummary{
+		Source:    p.SourceDir,
+		Dest:      p.DestDir,
+		Total:     int(atomic.LoadInt32(&p.TotalFiles) - total),
+		Processed: int(atomic.LoadInt32(&p.ProcessedFiles) - processed),
+		Failed:    int(atomic.LoadInt32(&p.FailedFiles) - failed),
+		Duration:  time.Since(start),
+		Cancelled: *runErr == context.Canceled,
+	}
+	p.stats.fill(&summary)
+
+	ev := notify.Event{Type: notify.EventRunCompleted, Run: &summary}
+	if *runErr != nil && !summary.Cancelled {
+		ev.Error = (*runErr).Error()
+	}
+	if p.Webhook != nil {
+		p.Webhook.Notify(ev)
+	}
+	if p.Events != nil {
+		p.Events.emit(Event{Type: EventRunCompleted, Run: &summary, Error: ev.Error})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	for _, ch := range p.Summaries {
+		if err := ch.SendSummary(ctx, summary); err != nil {
+			log.Printf("[!] Failed to send %s summary: %v", ch.Name(), err)
+		}
+	}
+
+	if p.PostRunHook != nil {
+		ev.Time = time.Now()
+		if err := p.PostRunHook.Run(context.Background(), ev); err != nil {
+			log.Printf("[!] Post-run hook failed: %v", err)
+		}
+	}
+}
+
+// discoverCategories lists the category folders of the destination and of the other
+// local destination roots, where only the categories routed to them count.
 func (p *Pipeline) discoverCategories() ([]string, error) {
+	var categories []string
+	exists := false
+	for _, root := range p.destRoots() {
+		if remote.IsRemote(root) {
+			continue
+		}
+		found, err := discoverCategoriesIn(root)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		exists = true
+		for _, c := range found {
+			if (root == p.DestDir || p.destRoot(c) == root) && !slices.Contains(categories, c) {
+				categories = append(categories, c)
+			}
+		}
+	}
+	if !exists {
+		return nil, nil
+	}
+
+	// Always include "Misc" if not already there
+	hasMisc := false
+	for _, c := range categories {
+		if c == "Misc" {
+			hasMisc = true
+			break
+		}
+	}
+	if !hasMisc {
+		categories = append(categories, "Misc")
+	}
+
+	return categories, nil
+}
+
+// discoverCategoriesIn lists the folders of the local destination root, up to three levels deep.
+func discoverCategoriesIn(root string) ([]string, error) {
 	var categories []string
 	maxDepth := 3
 
-	err := filepath.WalkDir(p.DestDir, func(path string, d fs.DirEntry, err error) error {
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
@@ -246,13 +1087,13 @@ func (p *Pipeline) discoverCategories() ([]string, error) {
 			return nil
 		}
 
-		// Skip the root DestDir itself
-		if path == p.DestDir {
+		// Skip the root itself
+		if path == root {
 			return nil
 		}
 
-		// Calculate depth relative to DestDir
-		rel, err := filepath.Rel(p.DestDir, path)
+		// Calculate depth relative to the root
+		rel, err := filepath.Rel(root, path)
 		if err != nil {
 			return nil
 		}
@@ -262,8 +1103,8 @@ func (p *Pipeline) discoverCategories() ([]string, error) {
 			return filepath.SkipDir
 		}
 
-		// Skip hidden directories
-		if strings.HasPrefix(d.Name(), ".") {
+		// Skip hidden directories and the dead-letter folder
+		if strings.HasPrefix(d.Name(), ".") || rel == UnprocessedDir {
 			return filepath.SkipDir
 		}
 
@@ -273,34 +1114,28 @@ func (p *Pipeline) discoverCategories() ([]string, error) {
 	})
 
 	if err != nil {
-		if os.IsNotExist(err) {
-			return nil, nil
-		}
 		return nil, err
 	}
-
-	// Always include "Misc" if not already there
-	hasMisc := false
-	for _, c := range categories {
-		if c == "Misc" {
-			hasMisc = true
-			break
-		}
-	}
-	if !hasMisc {
-		categories = append(categories, "Misc")
-	}
-
 	return categories, nil
 }
 
 func (p *Pipeline) updateProgressDisplay() {
 	processed := atomic.LoadInt32(&p.ProcessedFiles)
 	failed := atomic.LoadInt32(&p.FailedFiles)
-	total := p.TotalFiles
+	total := atomic.LoadInt32(&p.TotalFiles)
 	completed := processed + failed
 
 	percentage := float64(completed) / float64(total) * 100
+	if (p.Events != nil || p.OnProgress != nil) && total > 0 {
+		progress := Progress{Total: total, Completed: completed, Processed: processed, Failed: failed, Percent: math.Round(percentage*10) / 10}
+		if p.Events != nil {
+			p.Events.emit(Event{Type: EventProgress, Progress: &progress})
+		}
+		if p.OnProgress != nil {
+			p.OnProgress(progress)
+		}
+		return
+	}
 	// Using \r to refresh the same line for a clean terminal experience
 	fmt.Printf("\r[Progress] %d/%d files (%.1f%%) | Success: %d | Failed: %d   ",
 		completed, total, percentage, processed, failed)
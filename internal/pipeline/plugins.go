@@ -0,0 +1,63 @@
+package pipeline
+
+import (
+	"docs_organiser/internal/config"
+	"docs_organiser/internal/plugin"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ParsePlugins validates the configured plugins and returns the extractor plugins by
+// lowercase extension (without the dot) and the classifier plugin, if any. Each call of
+// a plugin is stopped after timeout.
+func ParsePlugins(defs []config.PluginDefinition, timeout time.Duration) (map[string]plugin.Plugin, *plugin.Plugin, error) {
+	if timeout < 0 {
+		return nil, nil, fmt.Errorf("plugin_timeout must not be negative")
+	}
+	extractors := make(map[string]plugin.Plugin)
+	var classifier *plugin.Plugin
+	names := make(map[string]bool, len(defs))
+	for i, def := range defs {
+		name := strings.TrimSpace(def.Name)
+		if name == "" {
+			return nil, nil, fmt.Errorf("plugins[%d] has no name", i)
+		}
+		if names[name] {
+			return nil, nil, fmt.Errorf("plugin %s is defined twice", name)
+		}
+		names[name] = true
+		if len(def.Command) == 0 || strings.TrimSpace(def.Command[0]) == "" {
+			return nil, nil, fmt.Errorf("plugin %s has no command", name)
+		}
+		pl := plugin.Plugin{Name: name, Command: def.Command, Timeout: timeout}
+
+		switch strings.ToLower(def.Type) {
+		case plugin.KindExtractor:
+			if len(def.Extensions) == 0 {
+				return nil, nil, fmt.Errorf("extractor plugin %s has no extensions", name)
+			}
+			for _, ext := range def.Extensions {
+				ext = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(ext), "."))
+				if ext == "" {
+					return nil, nil, fmt.Errorf("extractor plugin %s has an empty extension", name)
+				}
+				if other, ok := extractors[ext]; ok {
+					return nil, nil, fmt.Errorf("plugins %s and %s both extract .%s", other.Name, name, ext)
+				}
+				extractors[ext] = pl
+			}
+		case plugin.KindClassifier:
+			if len(def.Extensions) > 0 {
+				return nil, nil, fmt.Errorf("classifier plugin %s classifies every file; remove its extensions", name)
+			}
+			if classifier != nil {
+				return nil, nil, fmt.Errorf("plugins %s and %s are both classifiers; only one is supported", classifier.Name, name)
+			}
+			classifier = &pl
+		default:
+			return nil, nil, fmt.Errorf("plugin %s has invalid type %q (must be extractor or classifier)", name, def.Type)
+		}
+	}
+	return extractors, classifier, nil
+}
@@ -0,0 +1,97 @@
+package pipeline
+
+import (
+	"context"
+	"docs_organiser/internal/aitest"
+	"docs_organiser/internal/config"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParsePlugins(t *testing.T) {
+	cmd := []string{"python3", "plugin.py"}
+	tests := []struct {
+		name    string
+		defs    []config.PluginDefinition
+		wantErr string
+	}{
+		{name: "none"},
+		{name: "extractor and classifier", defs: []config.PluginDefinition{
+			{Name: "ocr", Type: "extractor", Command: cmd, Extensions: []string{".PNG", "tiff"}},
+			{Name: "rules", Type: "Classifier", Command: cmd},
+		}},
+		{name: "no name", defs: []config.PluginDefinition{{Type: "classifier", Command: cmd}}, wantErr: "plugins[0] has no name"},
+		{name: "no command", defs: []config.PluginDefinition{{Name: "ocr", Type: "extractor", Extensions: []string{"png"}}}, wantErr: "has no command"},
+		{name: "bad type", defs: []config.PluginDefinition{{Name: "ocr", Type: "ocr", Command: cmd}}, wantErr: `invalid type "ocr"`},
+		{name: "no extensions", defs: []config.PluginDefinition{{Name: "ocr", Type: "extractor", Command: cmd}}, wantErr: "has no extensions"},
+		{name: "same extension", defs: []config.PluginDefinition{
+			{Name: "a", Type: "extractor", Command: cmd, Extensions: []string{"png"}},
+			{Name: "b", Type: "extractor", Command: cmd, Extensions: []string{"PNG"}},
+		}, wantErr: "both extract .png"},
+		{name: "two classifiers", defs: []config.PluginDefinition{
+			{Name: "a", Type: "classifier", Command: cmd},
+			{Name: "b", Type: "classifier", Command: cmd},
+		}, wantErr: "only one is supported"},
+		{name: "duplicate name", defs: []config.PluginDefinition{
+			{Name: "a", Type: "classifier", Command: cmd},
+			{Name: "a", Type: "extractor", Command: cmd, Extensions: []string{"png"}},
+		}, wantErr: "defined twice"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			extractors, classifier, err := ParsePlugins(tt.defs, 0)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("ParsePlugins error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParsePlugins: %v", err)
+			}
+			if tt.defs == nil {
+				if len(extractors) != 0 || classifier != nil {
+					t.Errorf("ParsePlugins(nil) = %v, %v", extractors, classifier)
+				}
+				return
+			}
+			if extractors["png"].Name != "ocr" || extractors["tiff"].Name != "ocr" || classifier == nil || classifier.Name != "rules" {
+				t.Errorf("ParsePlugins = %v, %v", extractors, classifier)
+			}
+		})
+	}
+}
+
+func TestRun_Plugins(t *testing.T) {
+	srv := aitest.NewServer(t, "mock-model")
+	srv.Respond(func(aitest.Request) aitest.Reply {
+		t.Error("the model server was asked with a classifier plugin")
+		return aitest.Category("Misc", "Model", 0.9)
+	})
+
+	src, dst := writeSource(t, map[string]string{"scan.txt": "unreadable", "notes.txt": "unreadable"})
+	p := NewPipeline(src, dst, srv.Engine(t, "Finance", "Misc"), 1, 0)
+	extractors, classifier, err := ParsePlugins([]config.PluginDefinition{
+		{Name: "ocr", Type: "extractor", Extensions: []string{"txt"}, Command: []string{"sh", "-c",
+			`case "$(cat)" in *scan.txt*) echo '{"text": "Invoice 2024-001 from ACME"}';; *) echo '{"text": "Shopping list"}';; esac`}},
+		{Name: "rules", Type: "classifier", Command: []string{"sh", "-c",
+			`case "$(cat)" in *Invoice*) echo '{"category": "finance", "title": "ACME Invoice", "confidence_score": 0.8}';; *) echo '{"category": "Recipes", "title": "List", "confidence_score": 0.8}';; esac`}},
+	}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Extraction.Plugins, p.Classifier = extractors, classifier
+
+	if err := p.Run(context.Background()); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "Finance", "ACME Invoice.txt")); err != nil {
+		t.Errorf("the plugin's answer was not used: %v", err)
+	}
+	// Recipes is not a category, so the answer is rejected like an invalid model answer
+	if _, err := os.Stat(filepath.Join(dst, "Misc", "notes.txt")); err != nil {
+		t.Errorf("an invalid answer should fall back to Misc: %v", err)
+	}
+}
@@ -0,0 +1,102 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+)
+
+// queuedStatus is the status of the files listed in a queue file.
+const queuedStatus = "queued"
+
+// ErrModelUnavailable is returned by Prepare when no model server passes the pre-flight check.
+var ErrModelUnavailable = errors.New("pre-flight check failed")
+
+// queueID identifies a file across runs: its key in a remote source, else its path.
+func (f Failure) queueID() string {
+	if f.Key != "" {
+		return f.Key
+	}
+	return f.File
+}
+
+func (job FileJob) queueID() string {
+	if job.Key != "" {
+		return job.Key
+	}
+	return job.Path
+}
+
+// queueSource lists the files a run would have processed in the queue file, because the
+// model server is unreachable (cause), instead of failing them one by one. The source is
+// scanned as usual, so the filters and the ignore file apply.
+func (p *Pipeline) queueSource(ctx context.Context, cause error) error {
+	log.Printf("[!] %v", cause)
+	src, err := p.remoteFor(p.SourceDir)
+	if err != nil {
+		return fmt.Errorf("failed to open source: %w", err)
+	}
+	queue := func(job FileJob) error {
+		entry := Failure{File: job.Path, Key: job.Key, Status: queuedStatus, Error: cause.Error()}
+		if src != nil {
+			entry.File = src.URL(job.Key)
+		}
+		p.stats.queue(entry)
+		return nil
+	}
+	if src != nil {
+		err = p.scanRemote(ctx, src, queue)
+	} else {
+		var excludedDirs []string
+		if excludedDirs, err = p.sourceExclusions(); err != nil {
+			return fmt.Errorf("failed to resolve source/destination overlap: %w", err)
+		}
+		err = p.scanLocal(ctx, excludedDirs, queue)
+	}
+	if err != nil {
+		return err
+	}
+	return p.updateQueue()
+}
+
+// updateQueue rewrites the queue file: the files listed before that this run did not
+// settle, then the files it left in the source because the model server was down. An
+// empty queue is removed, and a queue of another source is replaced.
+func (p *Pipeline) updateQueue() error {
+	queued, settled := p.stats.queuedFiles()
+	prev, err := LoadFailures(p.QueueFile)
+	if errors.Is(err, os.ErrNotExist) {
+		if len(queued) == 0 {
+			return nil
+		}
+		prev = &FailureList{}
+	} else if err != nil {
+		return err
+	}
+
+	list := FailureList{Source: p.SourceDir, Finished: time.Now()}
+	if prev.checkSource(p.SourceDir) == nil {
+		seen := make(map[string]bool, len(queued))
+		for _, f := range queued {
+			seen[f.queueID()] = true
+		}
+		for _, f := range prev.Files {
+			if id := f.queueID(); !settled[id] && !seen[id] {
+				list.Files = append(list.Files, f)
+			}
+		}
+	}
+	list.Files = append(list.Files, queued...)
+
+	if len(list.Files) == 0 {
+		log.Printf("[+] No files left in the queue; removing %s", p.QueueFile)
+		return os.Remove(p.QueueFile)
+	}
+	if len(queued) > 0 {
+		log.Printf("[*] Queued %d files in %s; run with --process-queue once the model server is back", len(queued), p.QueueFile)
+	}
+	return writeList(p.QueueFile, list)
+}
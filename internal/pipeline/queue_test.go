@@ -0,0 +1,79 @@
+package pipeline
+
+import (
+	"context"
+	"docs_organiser/internal/aitest"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+)
+
+func TestRun_QueueWhileServerDown(t *testing.T) {
+	srv := aitest.NewServer(t, "mock-model")
+	srv.Respond(func(aitest.Request) aitest.Reply {
+		return aitest.Category("Finance", "Invoice_ACME", 0.9)
+	})
+	var down atomic.Bool
+	handler := srv.Config.Handler
+	srv.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if down.Load() {
+			http.Error(w, "starting up", http.StatusServiceUnavailable)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+
+	src, dst := writeSource(t, map[string]string{
+		"a.txt": "Invoice 2024-001 from ACME Corp",
+		"b.txt": "Invoice 2024-002 from ACME Corp",
+	})
+	queueFile := filepath.Join(t.TempDir(), "queue.json")
+	p := NewPipeline(src, dst, srv.Engine(t, "Finance", "Misc"), 1, 0)
+	p.QueueFile = queueFile
+
+	down.Store(true)
+	if err := p.Run(context.Background()); err != nil {
+		t.Fatalf("Run with the server down: %v", err)
+	}
+	queue, err := LoadFailures(queueFile)
+	if err != nil {
+		t.Fatalf("LoadFailures: %v", err)
+	}
+	if queue.Source != src || len(queue.Files) != 2 || queue.Files[0].Status != queuedStatus {
+		t.Fatalf("queue = %+v, want both files queued", queue)
+	}
+	if p.FailedFiles != 0 {
+		t.Errorf("FailedFiles = %d, want queued files not counted as failed", p.FailedFiles)
+	}
+
+	// Processing the queue while the server is still down keeps it
+	p.ProcessQueue = true
+	if err := p.Run(context.Background()); err == nil {
+		t.Fatal("processing the queue succeeded with the server down")
+	}
+	if _, err := os.Stat(queueFile); err != nil {
+		t.Fatalf("queue lost while the server was down: %v", err)
+	}
+
+	// Only queued files are processed, and the settled queue is removed
+	if err := os.WriteFile(filepath.Join(src, "new.txt"), []byte("Invoice"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	down.Store(false)
+	if err := p.Run(context.Background()); err != nil {
+		t.Fatalf("Run processing the queue: %v", err)
+	}
+	for _, name := range []string{"a.txt", "b.txt"} {
+		if _, err := os.Stat(filepath.Join(src, name)); !os.IsNotExist(err) {
+			t.Errorf("queued %s was not organised", name)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(src, "new.txt")); err != nil {
+		t.Errorf("a file that was not queued was processed: %v", err)
+	}
+	if _, err := os.Stat(queueFile); !os.IsNotExist(err) {
+		t.Errorf("queue file kept after every file was settled: %v", err)
+	}
+}
@@ -0,0 +1,177 @@
+package pipeline
+
+import (
+	"context"
+	"docs_organiser/internal/fileops"
+	"docs_organiser/internal/pdfmeta"
+	"docs_organiser/internal/remote"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// name returns the file's base name, used for logging and fallback titles.
+func (j FileJob) name() string {
+	if j.Key != "" {
+		return path.Base(j.Key)
+	}
+	return filepath.Base(j.Path)
+}
+
+// remoteFor returns the backend for a remote location, or nil for local paths.
+// Backends are opened once and reused across runs.
+func (p *Pipeline) remoteFor(location string) (remote.Backend, error) {
+	if !remote.IsRemote(location) {
+		return nil, nil
+	}
+
+	p.remotesMu.Lock()
+	defer p.remotesMu.Unlock()
+	if b, ok := p.remotes[location]; ok {
+		return b, nil
+	}
+	b, err := remote.Open(location, p.Remote)
+	if err != nil {
+		return nil, err
+	}
+	if p.remotes == nil {
+		p.remotes = make(map[string]remote.Backend)
+	}
+	p.remotes[location] = b
+	return b, nil
+}
+
+// deliver moves the local file at path into folder/name under the destination root of
+// folder, uploading it when that root is remote. For remote sources, src and key identify
+// the original; consumed reports whether it was already relocated by a server-side move.
+// A non-nil meta is stamped into the delivered PDF. Local destinations resolve name
+// collisions with the pipeline's policy (outcome); remote ones append a content hash.
+func (p *Pipeline) deliver(ctx context.Context, path, folder, name string, src remote.Backend, key string, meta *pdfmeta.Metadata) (dest string, outcome fileops.Outcome, consumed bool, err error) {
+	root := p.destRoot(folder)
+	dst, err := p.remoteFor(root)
+	if err != nil {
+		return "", fileops.Moved, false, err
+	}
+	if dst == nil {
+		// Reuse an existing category folder that differs only in case ("finance" -> "Finance")
+		folder = fileops.ResolveFold(root, folder)
+		dest, outcome, err = fileops.Move(path, filepath.Join(root, folder), name, fileops.MoveOptions{
+			Collisions:       p.Collisions,
+			Hash:             p.CollisionHash,
+			PartialHashBytes: p.PartialHashBytes,
+			Reserve:          p.DiskReserve,
+			Throttle:         p.Throttle,
+		})
+		if err == nil && (outcome == fileops.Moved || outcome == fileops.Replaced) {
+			stampPDF(dest, meta)
+		}
+		return dest, outcome, false, err
+	}
+
+	// Remote copies are stamped before upload; a stamped file can't take the server-side move
+	stamped := stampPDF(path, meta)
+
+	hash, err := p.CollisionHash.Sum(path, p.PartialHashBytes)
+	if err != nil {
+		return "", fileops.Moved, false, err
+	}
+	dstKey, err := remote.FreeKey(ctx, dst, strings.TrimPrefix(folder+"/"+name, "/"), hash)
+	if err != nil {
+		return "", fileops.Moved, false, err
+	}
+
+	// Prefer a server-side move when source and destination live in the same service
+	if mover, ok := src.(remote.Mover); ok && !stamped {
+		moved, err := mover.Move(ctx, key, dst, dstKey)
+		if err != nil {
+			return "", fileops.Moved, false, err
+		}
+		if moved {
+			return dst.URL(dstKey), fileops.Moved, true, nil
+		}
+	}
+
+	if err := dst.Upload(ctx, dstKey, path); err != nil {
+		return "", fileops.Moved, false, err
+	}
+	// Local sources are moved, not copied; staged downloads are cleaned up by the caller
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return "", fileops.Moved, false, err
+	}
+	return dst.URL(dstKey), fileops.Moved, false, nil
+}
+
+// scanRemote lists the remote source and enqueues every accepted object.
+func (p *Pipeline) scanRemote(ctx context.Context, src remote.Backend, enqueue func(FileJob) error) error {
+	objects, err := src.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Objects already under a destination (same bucket, nested prefix) are skipped
+	var destRoots []string
+	for _, root := range p.destRoots() {
+		destRoots = append(destRoots, strings.TrimSuffix(root, "/")+"/")
+	}
+
+	for _, obj := range objects {
+		p.waitIfPaused()
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		url := src.URL(obj.Key)
+		if slices.ContainsFunc(destRoots, func(root string) bool { return strings.HasPrefix(url, root) }) || !p.acceptRemote(obj) {
+			continue
+		}
+		if err := enqueue(FileJob{Key: obj.Key, size: obj.Size, modTime: obj.ModTime}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// acceptRemote applies the scan filter and depth limit to a remote object,
+// treating each key prefix as a directory.
+func (p *Pipeline) acceptRemote(obj remote.Object) bool {
+	segments := strings.Split(obj.Key, "/")
+	if p.Traversal.MaxDepth > 0 && len(segments) > p.Traversal.MaxDepth {
+		return false
+	}
+	for i := 1; i < len(segments); i++ {
+		if p.Filter.SkipDir(strings.Join(segments[:i], "/")) {
+			return false
+		}
+	}
+	return p.Filter.Accept(obj.Key) && p.Filter.AcceptModTime(obj.ModTime)
+}
+
+// discoverRemoteCategories derives category folders from the key prefixes in a remote destination.
+func discoverRemoteCategories(ctx context.Context, dst remote.Backend) ([]string, error) {
+	objects, err := dst.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{}
+	var categories []string
+	for _, obj := range objects {
+		segments := strings.Split(obj.Key, "/")
+		for i := 1; i < len(segments) && i <= 3; i++ {
+			if strings.HasPrefix(segments[i-1], ".") || (i == 1 && segments[0] == UnprocessedDir) {
+				break
+			}
+			dir := strings.Join(segments[:i], "/")
+			if !seen[dir] {
+				seen[dir] = true
+				categories = append(categories, dir)
+			}
+		}
+	}
+
+	if !seen["Misc"] {
+		categories = append(categories, "Misc")
+	}
+	return categories, nil
+}
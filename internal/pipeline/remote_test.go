@@ -0,0 +1,124 @@
+package pipeline
+
+import (
+	"context"
+	"docs_organiser/internal/remote"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// memoryBackend is an in-memory remote.Backend rooted at "mem://root/".
+type memoryBackend struct {
+	objects map[string][]byte
+}
+
+func (m *memoryBackend) List(ctx context.Context) ([]remote.Object, error) {
+	var out []remote.Object
+	for k, v := range m.objects {
+		out = append(out, remote.Object{Key: k, Size: int64(len(v)), ModTime: time.Now()})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Key < out[j].Key })
+	return out, nil
+}
+
+func (m *memoryBackend) Download(ctx context.Context, key string, w io.Writer) error {
+	_, err := w.Write(m.objects[key])
+	return err
+}
+
+func (m *memoryBackend) Upload(ctx context.Context, key, localPath string) error {
+	data, err := os.ReadFile(localPath)
+	m.objects[key] = data
+	return err
+}
+
+func (m *memoryBackend) Exists(ctx context.Context, key string) (bool, error) {
+	_, ok := m.objects[key]
+	return ok, nil
+}
+
+func (m *memoryBackend) Delete(ctx context.Context, key string) error {
+	delete(m.objects, key)
+	return nil
+}
+
+func (m *memoryBackend) URL(key string) string { return "s3://bucket/inbox/" + key }
+
+func TestScanRemote(t *testing.T) {
+	src := &memoryBackend{objects: map[string][]byte{
+		"a.pdf":                nil,
+		"notes.md":             nil,
+		"image.png":            nil,
+		"node_modules/x.txt":   nil,
+		"deep/er/still/y.txt":  nil,
+		"sorted/Finance/z.pdf": nil,
+	}}
+	p := &Pipeline{
+		SourceDir: "s3://bucket/inbox",
+		DestDir:   "s3://bucket/inbox/sorted",
+		Filter:    ScanFilter{Exclude: []string{"node_modules"}},
+		Traversal: WalkOptions{MaxDepth: 3},
+	}
+
+	var got []string
+	err := p.scanRemote(context.Background(), src, func(job FileJob) error {
+		got = append(got, job.Key)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"a.pdf", "notes.md"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("enqueued %v, want %v", got, want)
+	}
+}
+
+func TestDeliverRemote(t *testing.T) {
+	dst := &memoryBackend{objects: map[string][]byte{"Finance/Invoice.pdf": []byte("old")}}
+	p := &Pipeline{DestDir: "s3://bucket/sorted"}
+	p.remotes = map[string]remote.Backend{p.DestDir: dst}
+
+	local := filepath.Join(t.TempDir(), "scan.pdf")
+	if err := os.WriteFile(local, []byte("new"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	dest, _, _, err := p.deliver(context.Background(), local, "Finance", "Invoice.pdf", nil, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(dest, "s3://bucket/inbox/Finance/Invoice_") {
+		t.Errorf("collision not resolved: %s", dest)
+	}
+	if _, err := os.Stat(local); !os.IsNotExist(err) {
+		t.Error("local file should be removed after upload")
+	}
+	if len(dst.objects) != 2 {
+		t.Errorf("destination has %d objects, want 2", len(dst.objects))
+	}
+}
+
+func TestDiscoverRemoteCategories(t *testing.T) {
+	dst := &memoryBackend{objects: map[string][]byte{
+		"Finance/Taxes/2023/a.pdf": nil,
+		"Health/b.pdf":             nil,
+		".trash/c.pdf":             nil,
+		"loose.pdf":                nil,
+	}}
+	got, err := discoverRemoteCategories(context.Background(), dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(got)
+	want := []string{"Finance", "Finance/Taxes", "Finance/Taxes/2023", "Health", "Misc"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("categories = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,272 @@
+package pipeline
+
+import (
+	"context"
+	"docs_organiser/internal/ai"
+	"docs_organiser/internal/audit"
+	"docs_organiser/internal/fileops"
+	"docs_organiser/internal/logging"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Proposal is a file in the destination that classifying it again would move to another
+// category.
+type Proposal struct {
+	File       string  `json:"file"`
+	From       string  `json:"from"` // "" for a file in the destination root
+	To         string  `json:"to"`
+	Confidence float64 `json:"confidence"`
+}
+
+// PlanReorganize classifies every file already in the (local) destination again, as after
+// changing the taxonomy or the model, and returns the files that would now go to another
+// category, by path. Nothing is moved; ApplyReorganize moves the files of a plan. Hidden
+// folders, the dead-letter folder, and sidecars are skipped, and so are files the model
+// couldn't classify, which would only fall back to Misc.
+func (p *Pipeline) PlanReorganize(ctx context.Context) ([]Proposal, error) {
+	dest, err := p.localDest()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.Prepare(ctx); err != nil {
+		return nil, err
+	}
+
+	var files []string
+	err = filepath.WalkDir(dest, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == dest {
+			return nil
+		}
+		if strings.HasPrefix(d.Name(), ".") || (d.IsDir() && d.Name() == UnprocessedDir && filepath.Dir(path) == dest) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.Type().IsRegular() && !isSidecar(path) {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the destination: %w", err)
+	}
+
+	log.Printf("[*] Classifying %d organised files again", len(files))
+	var (
+		mu        sync.Mutex
+		proposals []Proposal
+		wg        sync.WaitGroup
+	)
+	next := make(chan string)
+	for range max(p.Workers, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for path := range next {
+				rec := p.Classify(ctx, path)
+				if rec.Status != "" || rec.Fallback {
+					continue
+				}
+				from := categoryOf(dest, path)
+				if strings.EqualFold(from, rec.Category) {
+					continue
+				}
+				proposal := Proposal{File: path, From: from, To: rec.Category}
+				if c := rec.Classification; c != nil && c.Analysis != nil {
+					proposal.Confidence = c.Analysis.ConfidenceScore
+				}
+				logging.Verbosef("[*] Proposal: %s -> %s", path, rec.Category)
+				mu.Lock()
+				proposals = append(proposals, proposal)
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, path := range files {
+		if ctx.Err() != nil {
+			break
+		}
+		next <- path
+	}
+	close(next)
+	wg.Wait()
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	slices.SortFunc(proposals, func(a, b Proposal) int { return strings.Compare(a.File, b.File) })
+	return proposals, nil
+}
+
+// WritePlan writes proposals to the CSV at path, with file,from,category,confidence
+// columns. LoadDecisions reads it back, so rows may be removed or their category changed
+// before the plan is applied.
+func WritePlan(path string, proposals []Proposal) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w := csv.NewWriter(f)
+	w.Write([]string{"file", "from", "category", "confidence"})
+	for _, pr := range proposals {
+		w.Write([]string{pr.File, pr.From, pr.To, strconv.FormatFloat(pr.Confidence, 'f', 2, 64)})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// ApplyReorganize moves the files of decisions, which must be in the (local) destination,
+// into their new categories. Collisions are handled as in a run, sidecars move along, and
+// each move is written to the audit log. With RemoveEmptyDirs, category folders the moves
+// empty are removed.
+func (p *Pipeline) ApplyReorganize(ctx context.Context, decisions []Decision) error {
+	dest, err := p.localDest()
+	if err != nil {
+		return err
+	}
+
+	start, total, processed, failed := time.Now(), atomic.LoadInt32(&p.TotalFiles),
+		atomic.LoadInt32(&p.ProcessedFiles), atomic.LoadInt32(&p.FailedFiles)
+	var vacated []string
+	defer func() {
+		if p.RemoveEmptyDirs {
+			if n := removeEmptyDirs(dest, vacated); n > 0 {
+				log.Printf("[*] Removed %d empty category folders from %s", n, p.DestDir)
+			}
+		}
+		p.logRun(start, total, processed, failed)
+	}()
+
+	log.Printf("[*] Reorganising %d files", len(decisions))
+	for _, d := range decisions {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		path, err := filepath.EvalSymlinks(d.File)
+		if err == nil {
+			path, err = filepath.Abs(path)
+		}
+		if errors.Is(err, os.ErrNotExist) {
+			log.Printf("[*] Not moving %s: it is no longer in the destination", d.File)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if !isWithin(path, dest) {
+			log.Printf("[!] Not moving %s: it is outside the destination", d.File)
+			continue
+		}
+		if info, err := os.Stat(path); err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+
+		atomic.AddInt32(&p.TotalFiles, 1)
+		fileCtx, cancel := context.WithTimeout(ctx, fileTimeout)
+		if p.reorganizeFile(fileCtx, path, d) {
+			vacated = append(vacated, filepath.Dir(path))
+		}
+		cancel()
+		p.updateProgressDisplay()
+	}
+	return nil
+}
+
+// reorganizeFile moves the file at path, in the destination root, as d says and reports
+// whether it left its folder.
+func (p *Pipeline) reorganizeFile(ctx context.Context, path string, d Decision) bool {
+	name := filepath.Base(path)
+	category := ai.SanitizeCategory(d.Category)
+	if slices.Contains(strings.Split(category, "/"), "..") {
+		log.Printf("[!] Not moving %s: invalid category %q", name, d.Category)
+		atomic.AddInt32(&p.FailedFiles, 1)
+		return false
+	}
+	title := name
+	if d.Title != "" {
+		ext := filepath.Ext(name)
+		title = ai.SanitizeFilename(strings.TrimSuffix(d.Title, ext)) + ext
+	}
+	title = fileops.TruncateName(title, p.MaxFilenameBytes)
+
+	dest, outcome, _, err := p.deliver(ctx, path, category, title, nil, "", nil)
+	switch {
+	case err != nil:
+		log.Printf("[!] Failed to move %s to %s/%s: %v", name, category, title, err)
+		atomic.AddInt32(&p.FailedFiles, 1)
+		return false
+	case outcome == fileops.Skipped:
+		log.Printf("[*] Skipped %s: %s already exists", name, dest)
+		atomic.AddInt32(&p.ProcessedFiles, 1)
+		return false
+	case outcome == fileops.InPlace:
+		log.Printf("[*] %s is already in %s", name, category)
+		atomic.AddInt32(&p.ProcessedFiles, 1)
+		return false
+	}
+	log.Printf("[*] Reorganised: %s -> %s", path, dest)
+	atomic.AddInt32(&p.ProcessedFiles, 1)
+	if outcome != fileops.Duplicate {
+		p.moveSidecar(path, dest)
+	} else if p.Sidecar != "" {
+		os.Remove(path + "." + p.Sidecar)
+	}
+	p.auditReview(audit.Record{Source: path, Category: category, Title: title,
+		Status: audit.StatusMoved, Destination: dest, Duplicate: outcome == fileops.Duplicate})
+	return true
+}
+
+// localDest returns the absolute, symlink-free path of the destination, which must be local.
+func (p *Pipeline) localDest() (string, error) {
+	if dst, err := p.remoteFor(p.DestDir); err != nil || dst != nil {
+		return "", fmt.Errorf("reorganising requires a local destination directory")
+	}
+	dest, err := filepath.EvalSymlinks(p.DestDir)
+	if err == nil {
+		dest, err = filepath.Abs(dest)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to open destination: %w", err)
+	}
+	return dest, nil
+}
+
+// categoryOf returns the category folder of the file at path in the destination root,
+// slash-separated; "" for the root itself.
+func categoryOf(root, path string) string {
+	rel, err := filepath.Rel(root, filepath.Dir(path))
+	if err != nil || rel == "." {
+		return ""
+	}
+	return filepath.ToSlash(rel)
+}
+
+// isSidecar reports whether path is the sidecar of a file next to it.
+func isSidecar(path string) bool {
+	ext := filepath.Ext(path)
+	if ext != ".json" && ext != ".yaml" {
+		return false
+	}
+	info, err := os.Stat(strings.TrimSuffix(path, ext))
+	return err == nil && info.Mode().IsRegular()
+}
@@ -0,0 +1,86 @@
+package pipeline
+
+import (
+	"context"
+	"docs_organiser/internal/aitest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReorganize(t *testing.T) {
+	srv := aitest.NewServer(t, "mock-model")
+	srv.Respond(func(r aitest.Request) aitest.Reply {
+		if strings.Contains(r.User(), "Tax return") {
+			return aitest.Category("Tax", "Return", 0.9)
+		}
+		return aitest.Category("Finance", "Invoice", 0.8)
+	})
+
+	_, dst := writeSource(t, nil)
+	files := map[string]string{
+		"Finance/Return_2023.txt":      "Tax return 2023",
+		"Finance/Return_2023.txt.json": "{}",
+		"Finance/Invoice.txt":          "Invoice from ACME",
+		"Finance/Receipt.txt":          "Invoice paid, ACME",
+		"loose.txt":                    "Invoice from Initech",
+		".trash/old.txt":               "Tax return 2019",
+	}
+	for name, content := range files {
+		path := filepath.Join(dst, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	p := NewPipeline(t.TempDir(), dst, srv.Engine(t, "Finance", "Tax", "Misc"), 2, 0)
+	p.Sidecar = "json"
+	p.RemoveEmptyDirs = true
+	proposals, err := p.PlanReorganize(context.Background())
+	if err != nil {
+		t.Fatalf("PlanReorganize: %v", err)
+	}
+	if len(proposals) != 2 {
+		t.Fatalf("proposals = %+v, want the tax return and the loose file", proposals)
+	}
+	if pr := proposals[0]; pr.File != filepath.Join(dst, "Finance", "Return_2023.txt") || pr.From != "Finance" || pr.To != "Tax" || pr.Confidence != 0.9 {
+		t.Errorf("first proposal = %+v", pr)
+	}
+	if pr := proposals[1]; pr.From != "" || pr.To != "Finance" {
+		t.Errorf("second proposal = %+v", pr)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "Finance", "Return_2023.txt")); err != nil {
+		t.Errorf("planning moved a file: %v", err)
+	}
+
+	plan := filepath.Join(t.TempDir(), "plan.csv")
+	if err := WritePlan(plan, proposals); err != nil {
+		t.Fatalf("WritePlan: %v", err)
+	}
+	decisions, err := LoadDecisions(plan)
+	if err != nil {
+		t.Fatalf("LoadDecisions: %v", err)
+	}
+	// A reviewer sends the loose file elsewhere and adds a move out of the destination,
+	// and one onto the file's own path
+	decisions[1].Category = "Archive"
+	decisions = append(decisions,
+		Decision{File: filepath.Join(dst, "Finance", "Invoice.txt"), Category: "Finance/../../escape"},
+		Decision{File: filepath.Join(dst, "Finance", "Receipt.txt"), Category: "finance", Title: "Receipt"})
+	if err := p.ApplyReorganize(context.Background(), decisions); err != nil {
+		t.Fatalf("ApplyReorganize: %v", err)
+	}
+
+	for _, name := range []string{"Tax/Return_2023.txt", "Tax/Return_2023.txt.json", "Archive/loose.txt", "Finance/Invoice.txt", "Finance/Receipt.txt"} {
+		if _, err := os.Stat(filepath.Join(dst, name)); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dst, "Finance", "Return_2023.txt.json")); !os.IsNotExist(err) {
+		t.Errorf("the sidecar stayed behind: %v", err)
+	}
+}
@@ -0,0 +1,116 @@
+package pipeline
+
+import (
+	"context"
+	"docs_organiser/internal/audit"
+	"docs_organiser/internal/remote"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// fileTimeout bounds the processing of a single file.
+const fileTimeout = 2 * time.Minute
+
+// resultHub fans file outcomes out to subscribers. Slow subscribers miss results
+// rather than stalling the workers.
+type resultHub struct {
+	mu   sync.Mutex
+	subs map[chan audit.Record]struct{}
+}
+
+func (h *resultHub) publish(rec audit.Record) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.subs {
+		select {
+		case ch <- rec:
+		default:
+		}
+	}
+}
+
+// Subscribe returns a channel receiving the outcome of every file processed from now on,
+// and a function that cancels the subscription and closes the channel.
+func (p *Pipeline) Subscribe(buffer int) (<-chan audit.Record, func()) {
+	ch := make(chan audit.Record, buffer)
+
+	h := &p.results
+	h.mu.Lock()
+	if h.subs == nil {
+		h.subs = make(map[chan audit.Record]struct{})
+	}
+	h.subs[ch] = struct{}{}
+	h.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			h.mu.Lock()
+			delete(h.subs, ch)
+			h.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+// SubmitFile processes a single file inside the source directory outside of a run.
+func (p *Pipeline) SubmitFile(ctx context.Context, path string) (audit.Record, error) {
+	if remote.IsRemote(p.SourceDir) {
+		return audit.Record{}, fmt.Errorf("path submissions require a local source directory")
+	}
+
+	// Resolve symlinks so a link inside the source cannot reach files outside it
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return audit.Record{}, err
+	}
+	resolved, err = filepath.Abs(resolved)
+	if err != nil {
+		return audit.Record{}, err
+	}
+	src, err := filepath.EvalSymlinks(p.SourceDir)
+	if err != nil {
+		return audit.Record{}, err
+	}
+	src, err = filepath.Abs(src)
+	if err != nil {
+		return audit.Record{}, err
+	}
+	if !isWithin(resolved, src) {
+		return audit.Record{}, fmt.Errorf("%s is outside the source directory", path)
+	}
+	return p.ProcessFile(ctx, resolved)
+}
+
+// Classify extracts and classifies the local file at path without moving it or recording
+// it in the audit log. rec.Status is only set when the file could not be classified.
+func (p *Pipeline) Classify(ctx context.Context, path string) audit.Record {
+	rec := audit.Record{Source: path}
+	fileCtx, cancel := context.WithTimeout(ctx, fileTimeout)
+	defer cancel()
+	p.classifyFile(fileCtx, &rec, FileJob{Path: path}, path, filepath.Base(path))
+	return rec
+}
+
+// ProcessFile classifies and moves the regular file at path, updating the progress counters.
+func (p *Pipeline) ProcessFile(ctx context.Context, path string) (audit.Record, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return audit.Record{}, err
+	}
+	if !info.Mode().IsRegular() {
+		return audit.Record{}, fmt.Errorf("%s is not a regular file", path)
+	}
+
+	atomic.AddInt32(&p.TotalFiles, 1)
+	atomic.AddInt32(&p.ActiveWorkers, 1)
+	defer atomic.AddInt32(&p.ActiveWorkers, -1)
+
+	fileCtx, cancel := context.WithTimeout(ctx, fileTimeout)
+	defer cancel()
+	return p.processFile(fileCtx, FileJob{Path: path}), nil
+}
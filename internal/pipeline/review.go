@@ -0,0 +1,122 @@
+package pipeline
+
+import (
+	"context"
+	"docs_organiser/internal/ai"
+	"docs_organiser/internal/audit"
+	"docs_organiser/internal/fileops"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// reviewable rejects records whose file a reviewer can't move: only files moved into a
+// local destination can be.
+func (p *Pipeline) reviewable(rec audit.Record) error {
+	if rec.Status != audit.StatusMoved || rec.Destination == "" {
+		return fmt.Errorf("%s was not moved", filepath.Base(rec.Source))
+	}
+	if dst, err := p.remoteFor(p.DestDir); err != nil || dst != nil {
+		return fmt.Errorf("reviewing moves requires a local destination directory")
+	}
+	if _, err := os.Stat(rec.Destination); err != nil {
+		return fmt.Errorf("%s is no longer in the destination: %w", rec.Destination, err)
+	}
+	return nil
+}
+
+// Override moves the file organised as rec into category, named title (without
+// extension; empty keeps the current name), as a reviewer correcting the decision would.
+// Collisions are handled as in a run, and a sidecar moves along. The updated record is
+// written to the audit log and returned; its Source is still the original file, so the
+// move can be undone.
+func (p *Pipeline) Override(ctx context.Context, rec audit.Record, category, title string) (audit.Record, error) {
+	if err := p.reviewable(rec); err != nil {
+		return rec, err
+	}
+	sanitized := ai.SanitizeCategory(category)
+	if slices.Contains(strings.Split(sanitized, "/"), "..") {
+		return rec, fmt.Errorf("invalid category %q", category)
+	}
+	name := filepath.Base(rec.Destination)
+	if title != "" {
+		ext := filepath.Ext(name)
+		name = ai.SanitizeFilename(strings.TrimSuffix(title, ext)) + ext
+	}
+	name = fileops.TruncateName(name, p.MaxFilenameBytes)
+
+	dest, outcome, _, err := p.deliver(ctx, rec.Destination, sanitized, name, nil, "", nil)
+	if err != nil {
+		return rec, err
+	}
+	if outcome == fileops.Skipped {
+		return rec, fmt.Errorf("%s already exists", dest)
+	}
+	log.Printf("[*] Review: moved %s to %s", rec.Destination, dest)
+	p.moveSidecar(rec.Destination, dest)
+
+	updated := rec
+	updated.Category, updated.Title, updated.Destination = sanitized, name, dest
+	updated.Duplicate = outcome == fileops.Duplicate
+	updated.Overridden = true
+	p.auditReview(updated)
+	return updated, nil
+}
+
+// Undo moves the file organised as rec back to its source path, which must be free.
+func (p *Pipeline) Undo(ctx context.Context, rec audit.Record) (audit.Record, error) {
+	if err := p.reviewable(rec); err != nil {
+		return rec, err
+	}
+	if rec.Duplicate {
+		return rec, fmt.Errorf("%s duplicated %s and was removed; there is nothing to move back", filepath.Base(rec.Source), rec.Destination)
+	}
+	if _, err := os.Lstat(rec.Source); err == nil {
+		return rec, fmt.Errorf("%s exists again", rec.Source)
+	}
+
+	_, outcome, err := fileops.Move(rec.Destination, filepath.Dir(rec.Source), filepath.Base(rec.Source),
+		fileops.MoveOptions{Collisions: fileops.CollisionSkip, Throttle: p.Throttle})
+	if err != nil {
+		return rec, err
+	}
+	if outcome == fileops.Skipped {
+		return rec, fmt.Errorf("%s exists again", rec.Source)
+	}
+	log.Printf("[*] Review: moved %s back to %s", rec.Destination, rec.Source)
+	if p.Sidecar != "" {
+		os.Remove(rec.Destination + "." + p.Sidecar)
+	}
+
+	updated := rec
+	updated.Status = audit.StatusUndone
+	updated.Destination = ""
+	p.auditReview(updated)
+	return updated, nil
+}
+
+// moveSidecar renames the sidecar of the file moved from old to dest, if it has one.
+func (p *Pipeline) moveSidecar(old, dest string) {
+	if p.Sidecar == "" {
+		return
+	}
+	from, to := old+"."+p.Sidecar, dest+"."+p.Sidecar
+	if err := os.Rename(from, to); err != nil && !os.IsNotExist(err) {
+		log.Printf("[!] Failed to move the sidecar of %s: %v", filepath.Base(dest), err)
+	}
+}
+
+// auditReview writes the record of a reviewed file, stamped now, to the audit log.
+func (p *Pipeline) auditReview(rec audit.Record) {
+	if p.Audit == nil {
+		return
+	}
+	rec.Time = time.Now()
+	if err := p.Audit.Write(rec); err != nil {
+		log.Printf("[!] Failed to write audit record for %s: %v", filepath.Base(rec.Source), err)
+	}
+}
@@ -0,0 +1,62 @@
+package pipeline
+
+import (
+	"context"
+	"docs_organiser/internal/aitest"
+	"docs_organiser/internal/audit"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOverrideAndUndo(t *testing.T) {
+	srv := aitest.NewServer(t, "mock-model")
+	srv.Respond(func(aitest.Request) aitest.Reply {
+		return aitest.Category("Finance", "Invoice_ACME", 0.3)
+	})
+
+	src, dst := writeSource(t, map[string]string{"scan.txt": "Tax return 2023"})
+	p := NewPipeline(src, dst, srv.Engine(t, "Finance", "Tax", "Misc"), 1, 0)
+	p.Sidecar = "json"
+	var recs []audit.Record
+	p.OnFile = func(rec audit.Record) { recs = append(recs, rec) }
+	if err := p.Run(context.Background()); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if len(recs) != 1 || recs[0].Status != audit.StatusMoved {
+		t.Fatalf("records = %+v, want one move", recs)
+	}
+
+	rec, err := p.Override(context.Background(), recs[0], "Tax", "Tax_Return_2023")
+	if err != nil {
+		t.Fatalf("Override: %v", err)
+	}
+	moved := filepath.Join(dst, "Tax", "Tax_Return_2023.txt")
+	if rec.Destination != moved || !rec.Overridden || rec.Source != filepath.Join(src, "scan.txt") {
+		t.Errorf("overridden record = %+v", rec)
+	}
+	for _, path := range []string{moved, moved + ".json"} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("override did not move %s: %v", filepath.Base(path), err)
+		}
+	}
+	if _, err := p.Override(context.Background(), rec, "Tax/../../etc", ""); err == nil {
+		t.Error("override accepted a category outside the destination")
+	}
+
+	if rec, err = p.Undo(context.Background(), rec); err != nil {
+		t.Fatalf("Undo: %v", err)
+	}
+	if rec.Status != audit.StatusUndone {
+		t.Errorf("undone record status = %q", rec.Status)
+	}
+	if _, err := os.Stat(filepath.Join(src, "scan.txt")); err != nil {
+		t.Errorf("undo did not restore the source: %v", err)
+	}
+	if _, err := os.Stat(moved + ".json"); !os.IsNotExist(err) {
+		t.Errorf("undo left the sidecar behind: %v", err)
+	}
+	if _, err := p.Undo(context.Background(), rec); err == nil {
+		t.Error("undoing twice succeeded")
+	}
+}
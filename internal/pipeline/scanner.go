@@ -0,0 +1,159 @@
+package pipeline
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultExtensions are the file types scanned when no include patterns are configured.
+var defaultExtensions = map[string]bool{
+	".pdf": true,
+	".txt": true,
+	".md":  true,
+}
+
+// ScanFilter decides which paths the source walk visits and enqueues.
+// Patterns use path.Match syntax and are matched case-insensitively.
+// A pattern without a slash matches the base name at any depth (e.g. "node_modules", "*invoice*.pdf");
+// a pattern with a slash matches the path relative to the source root, and a leading "**/" lets it float.
+type ScanFilter struct {
+	Include []string
+	Exclude []string
+
+	// Extensions adds file types (e.g. ".docx") to the default whitelist, typically those
+	// handled by external extractor commands. Ignored when Include is set.
+	Extensions []string
+
+	// ModifiedAfter, when set, skips files last modified before this instant.
+	ModifiedAfter time.Time
+}
+
+// Validate reports the first malformed pattern, if any.
+func (f ScanFilter) Validate() error {
+	for _, patterns := range [][]string{f.Include, f.Exclude} {
+		for _, p := range patterns {
+			if _, err := path.Match(strings.TrimPrefix(filepath.ToSlash(p), "**/"), ""); err != nil {
+				return fmt.Errorf("invalid glob pattern %q: %w", p, err)
+			}
+		}
+	}
+	return nil
+}
+
+// SkipDir reports whether the directory at rel (relative to the source root) should be pruned.
+func (f ScanFilter) SkipDir(rel string) bool {
+	return matchAny(f.Exclude, rel)
+}
+
+// Accept reports whether the file at rel (relative to the source root) should be processed.
+func (f ScanFilter) Accept(rel string) bool {
+	if matchAny(f.Exclude, rel) {
+		return false
+	}
+	if len(f.Include) == 0 {
+		ext := strings.ToLower(filepath.Ext(rel))
+		if defaultExtensions[ext] {
+			return true
+		}
+		for _, e := range f.Extensions {
+			if ext == strings.ToLower(e) {
+				return true
+			}
+		}
+		return false
+	}
+	return matchAny(f.Include, rel)
+}
+
+// AcceptModTime reports whether a file modified at t passes the modification-time filter.
+func (f ScanFilter) AcceptModTime(t time.Time) bool {
+	return f.ModifiedAfter.IsZero() || !t.Before(f.ModifiedAfter)
+}
+
+// ParseModifiedAfter combines a relative age ("30d", "2w", "36h") and an absolute date
+// ("2024-01-01" or RFC 3339) into a single cutoff, keeping the later of the two.
+// Empty inputs are ignored; a zero time means no cutoff.
+func ParseModifiedAfter(newerThan, since string, now time.Time) (time.Time, error) {
+	var cutoff time.Time
+
+	if newerThan != "" {
+		age, err := parseAge(newerThan)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid newer-than value %q: %w", newerThan, err)
+		}
+		cutoff = now.Add(-age)
+	}
+
+	if since != "" {
+		t, err := time.ParseInLocation("2006-01-02", since, time.Local)
+		if err != nil {
+			t, err = time.Parse(time.RFC3339, since)
+		}
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid since value %q: expected YYYY-MM-DD or RFC 3339", since)
+		}
+		if t.After(cutoff) {
+			cutoff = t
+		}
+	}
+
+	return cutoff, nil
+}
+
+// parseAge extends time.ParseDuration with day ("d") and week ("w") units.
+func parseAge(s string) (time.Duration, error) {
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if n, ok := strings.CutSuffix(s, suffix); ok {
+			v, err := strconv.ParseFloat(n, 64)
+			if err != nil || v < 0 {
+				return 0, fmt.Errorf("expected a non-negative number before %q", suffix)
+			}
+			return time.Duration(v * float64(unit)), nil
+		}
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, err
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("age must not be negative")
+	}
+	return d, nil
+}
+
+func matchAny(patterns []string, rel string) bool {
+	rel = strings.ToLower(filepath.ToSlash(rel))
+	base := path.Base(rel)
+
+	for _, p := range patterns {
+		p = strings.ToLower(filepath.ToSlash(p))
+
+		if !strings.Contains(p, "/") {
+			if ok, _ := path.Match(p, base); ok {
+				return true
+			}
+			continue
+		}
+
+		if rest, floating := strings.CutPrefix(p, "**/"); floating {
+			// Try the pattern against every suffix of the relative path
+			segments := strings.Split(rel, "/")
+			for i := range segments {
+				if ok, _ := path.Match(rest, strings.Join(segments[i:], "/")); ok {
+					return true
+				}
+			}
+			continue
+		}
+
+		if ok, _ := path.Match(p, rel); ok {
+			return true
+		}
+	}
+	return false
+}
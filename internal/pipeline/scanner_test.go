@@ -0,0 +1,108 @@
+package pipeline
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScanFilter_Accept(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter ScanFilter
+		rel    string
+		want   bool
+	}{
+		{"Default whitelist pdf", ScanFilter{}, "docs/report.PDF", true},
+		{"Default whitelist rejects docx", ScanFilter{}, "docs/report.docx", false},
+		{"Include base name glob", ScanFilter{Include: []string{"*invoice*.pdf"}}, "2024/March_Invoice_12.pdf", true},
+		{"Include replaces whitelist", ScanFilter{Include: []string{"*invoice*.pdf"}}, "notes.txt", false},
+		{"Extra extension joins whitelist", ScanFilter{Extensions: []string{".docx"}}, "letter.DOCX", true},
+		{"Extra extension ignored with include", ScanFilter{Include: []string{"*.pdf"}, Extensions: []string{".docx"}}, "letter.docx", false},
+		{"Include non-default extension", ScanFilter{Include: []string{"*.log"}}, "server.log", true},
+		{"Exclude base name", ScanFilter{Exclude: []string{"*.tmp.pdf"}}, "a/b.tmp.pdf", false},
+		{"Exclude wins over include", ScanFilter{Include: []string{"*.pdf"}, Exclude: []string{"draft*"}}, "draft_1.pdf", false},
+		{"Anchored relative path", ScanFilter{Include: []string{"inbox/*.pdf"}}, "inbox/a.pdf", true},
+		{"Anchored relative path miss", ScanFilter{Include: []string{"inbox/*.pdf"}}, "old/inbox/a.pdf", false},
+		{"Floating relative path", ScanFilter{Include: []string{"**/inbox/*.pdf"}}, "old/inbox/a.pdf", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.Accept(tt.rel); got != tt.want {
+				t.Errorf("Accept(%q) = %v, want %v", tt.rel, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScanFilter_SkipDir(t *testing.T) {
+	f := ScanFilter{Exclude: []string{"node_modules", "tmp*"}}
+
+	if !f.SkipDir("project/node_modules") {
+		t.Error("expected nested node_modules to be skipped")
+	}
+	if !f.SkipDir("TMP_Uploads") {
+		t.Error("expected case-insensitive match on TMP_Uploads")
+	}
+	if f.SkipDir("project/src") {
+		t.Error("did not expect project/src to be skipped")
+	}
+}
+
+func TestScanFilter_Validate(t *testing.T) {
+	if err := (ScanFilter{Include: []string{"*.pdf"}}).Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := (ScanFilter{Exclude: []string{"[abc"}}).Validate(); err == nil {
+		t.Error("expected error for malformed pattern")
+	}
+}
+
+func TestParseModifiedAfter(t *testing.T) {
+	now := time.Date(2024, 6, 30, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		newerThan string
+		since     string
+		want      time.Time
+		wantErr   bool
+	}{
+		{name: "No filters", want: time.Time{}},
+		{name: "Days", newerThan: "30d", want: now.AddDate(0, 0, -30)},
+		{name: "Weeks", newerThan: "2w", want: now.AddDate(0, 0, -14)},
+		{name: "Go duration", newerThan: "36h", want: now.Add(-36 * time.Hour)},
+		{name: "RFC 3339 since", since: "2024-06-01T00:00:00Z", want: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)},
+		{name: "Later cutoff wins", newerThan: "90d", since: "2024-06-20T00:00:00Z", want: time.Date(2024, 6, 20, 0, 0, 0, 0, time.UTC)},
+		{name: "Bad age", newerThan: "soon", wantErr: true},
+		{name: "Negative age", newerThan: "-3d", wantErr: true},
+		{name: "Bad date", since: "01/02/2024", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseModifiedAfter(tt.newerThan, tt.since, now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseModifiedAfter() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !got.Equal(tt.want) {
+				t.Errorf("ParseModifiedAfter() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScanFilter_AcceptModTime(t *testing.T) {
+	cutoff := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	f := ScanFilter{ModifiedAfter: cutoff}
+
+	if f.AcceptModTime(cutoff.Add(-time.Second)) {
+		t.Error("expected file older than cutoff to be rejected")
+	}
+	if !f.AcceptModTime(cutoff) {
+		t.Error("expected file at cutoff to be accepted")
+	}
+	if !(ScanFilter{}).AcceptModTime(time.Time{}) {
+		t.Error("expected empty filter to accept everything")
+	}
+}
@@ -0,0 +1,72 @@
+package pipeline
+
+import (
+	"context"
+	"docs_organiser/internal/ai"
+	"docs_organiser/internal/audit"
+	"docs_organiser/internal/logging"
+	"docs_organiser/internal/script"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync/atomic"
+)
+
+// runScript passes the decision in rec for the local file at path, with its extracted
+// text, to the routing script, which may replace the category or title. It returns false,
+// with rec.Status set, when the script vetoes the file, which then stays in the source.
+// When the script fails or answers with an unusable category, the decision stands.
+func (p *Pipeline) runScript(ctx context.Context, rec *audit.Record, job FileJob, path, name, text string) bool {
+	if p.Script == nil {
+		return true
+	}
+	doc := script.Document{Name: name, Path: path, Dir: p.sourceDir(job), Text: text,
+		Category: rec.Category, Title: rec.Title, Fallback: rec.Fallback}
+	if info, err := os.Stat(path); err == nil {
+		doc.Size = info.Size()
+	}
+	if c := rec.Classification; c != nil {
+		if a := c.Analysis; a != nil {
+			doc.Confidence, doc.DocumentDate, doc.Tags, doc.Summary = a.ConfidenceScore, a.DocumentDate, a.Tags, a.Summary
+		}
+		if c.Metadata != nil {
+			doc.Model = c.Metadata.Model
+		}
+	}
+
+	d, err := p.Script.Route(ctx, doc)
+	switch {
+	case err != nil:
+		log.Printf("[!] Routing script failed for %s: %v", name, err)
+		return true
+	case d.Veto:
+		reason := ""
+		if d.Reason != "" {
+			reason = " (" + d.Reason + ")"
+		}
+		log.Printf("[*] Routing script vetoed %s -> %s/%s%s; leaving it in the source", name, rec.Category, rec.Title, reason)
+		atomic.AddInt32(&p.ProcessedFiles, 1)
+		rec.Status = audit.StatusVetoed
+		return false
+	}
+
+	if d.Category != "" {
+		category := ai.SanitizeCategory(d.Category)
+		if category == "" || slices.Contains(strings.Split(category, "/"), "..") {
+			log.Printf("[!] Routing script returned invalid category %q for %s", d.Category, name)
+		} else if category != rec.Category {
+			logging.Verbosef("[*] Routing script: %s -> %s (was %s)", name, category, rec.Category)
+			rec.Category = category
+		}
+	}
+	if d.Title != "" {
+		ext := filepath.Ext(name)
+		if title := ai.SanitizeFilename(strings.TrimSuffix(d.Title, ext)) + ext; title != rec.Title {
+			logging.Verbosef("[*] Routing script: %s named %s (was %s)", name, title, rec.Title)
+			rec.Title = title
+		}
+	}
+	return true
+}
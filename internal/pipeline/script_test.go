@@ -0,0 +1,68 @@
+package pipeline
+
+import (
+	"context"
+	"docs_organiser/internal/aitest"
+	"docs_organiser/internal/audit"
+	"docs_organiser/internal/script"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRun_RoutingScript(t *testing.T) {
+	srv := aitest.NewServer(t, "mock-model")
+	srv.Respond(func(r aitest.Request) aitest.Reply {
+		if strings.Contains(r.User(), "Statement") {
+			return aitest.Category("Finance", "Bank_Statement", 0.9)
+		}
+		return aitest.Category("Work", "Notes", 0.9)
+	})
+
+	src, dst := writeSource(t, map[string]string{
+		"joint.txt":   "Statement for the joint account",
+		"private.txt": "Statement, private",
+		"notes.txt":   "Meeting notes",
+		"draft.txt":   "Meeting notes, draft",
+	})
+	path := filepath.Join(t.TempDir(), "route.star")
+	if err := os.WriteFile(path, []byte(`
+def route(doc):
+    if "draft" in doc.text:
+        return {"veto": "still a draft"}
+    if doc.category == "Finance" and matches(r"(?i)joint account", doc.text):
+        return {"category": "Finance/Joint", "title": "Joint " + doc.title}
+    if doc.name == "notes.txt":
+        return {"category": "Work/../../escape"}
+    return None
+`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s, err := script.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := NewPipeline(src, dst, srv.Engine(t, "Finance", "Work", "Misc"), 1, 0)
+	p.Script = s
+	var statuses []string
+	p.OnFile = func(rec audit.Record) { statuses = append(statuses, filepath.Base(rec.Source)+":"+rec.Status) }
+	if err := p.Run(context.Background()); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	for _, path := range []string{
+		filepath.Join(dst, "Finance", "Joint", "Joint Bank_Statement.txt"),
+		filepath.Join(dst, "Finance", "Bank_Statement.txt"),
+		filepath.Join(dst, "Work", "Notes.txt"), // the invalid category is ignored
+		filepath.Join(src, "draft.txt"),
+	} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("%v", err)
+		}
+	}
+	if !strings.Contains(strings.Join(statuses, " "), "draft.txt:"+audit.StatusVetoed) {
+		t.Errorf("statuses = %v, want draft.txt vetoed", statuses)
+	}
+}
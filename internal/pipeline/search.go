@@ -0,0 +1,105 @@
+package pipeline
+
+import (
+	"context"
+	"docs_organiser/internal/audit"
+	"docs_organiser/internal/remote"
+	"docs_organiser/internal/search"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// indexFile adds the embedding of the organised file of rec to the search index. The
+// embedded text is its category and title, the summary and tags when described, and the
+// start of text; files decided without their text are indexed by name alone. Failures are
+// logged and leave the file unindexed.
+func (p *Pipeline) indexFile(ctx context.Context, rec audit.Record, text string) {
+	input := embeddingInput(rec, text)
+	vectors, model, err := p.AI.Embed(ctx, p.EmbeddingModel, []string{input})
+	if err == nil {
+		err = p.Search.Add(search.Entry{
+			Path:     rec.Destination,
+			Category: rec.Category,
+			Title:    rec.Title,
+			Model:    model,
+			Vector:   toFloat32(vectors[0]),
+			Indexed:  time.Now(),
+		})
+	}
+	if err != nil {
+		log.Printf("[!] Failed to index %s for search: %v", filepath.Base(rec.Destination), err)
+	}
+}
+
+// SearchQuery embeds query with the index's model and returns the limit best-matching
+// organised files. Local files that are gone since they were indexed are dropped from
+// the index.
+func (p *Pipeline) SearchQuery(ctx context.Context, query string, limit int) ([]search.Match, error) {
+	if p.Search == nil {
+		return nil, fmt.Errorf("no search index")
+	}
+	vectors, model, err := p.AI.Embed(ctx, p.EmbeddingModel, []string{query})
+	if err != nil {
+		return nil, err
+	}
+	matching, total, err := p.Search.Count(model)
+	if err != nil {
+		return nil, err
+	}
+	if matching == 0 {
+		if total > 0 {
+			return nil, fmt.Errorf("none of the %d indexed files were embedded with %s; search with the embedding_model they were indexed with", total, model)
+		}
+		return nil, fmt.Errorf("the search index is empty; organise files with semantic_index enabled first")
+	}
+	matches, err := p.Search.Search(model, vectors[0], 0)
+	if err != nil {
+		return nil, err
+	}
+	found := matches[:0]
+	for _, m := range matches {
+		if limit > 0 && len(found) == limit {
+			break
+		}
+		if !remote.IsRemote(m.Path) {
+			if _, err := os.Stat(m.Path); errors.Is(err, fs.ErrNotExist) {
+				if err := p.Search.Remove(m.Path); err != nil {
+					log.Printf("[!] Failed to drop %s from the search index: %v", m.Path, err)
+				}
+				continue
+			}
+		}
+		found = append(found, m)
+	}
+	return found, nil
+}
+
+// embeddingInput is the text embedded for an organised file.
+func embeddingInput(rec audit.Record, text string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s/%s\n", rec.Category, strings.TrimSuffix(rec.Title, filepath.Ext(rec.Title)))
+	if rec.Classification != nil && rec.Classification.Analysis != nil && !rec.Fallback {
+		if summary := rec.Classification.Analysis.Summary; summary != "" {
+			b.WriteString(summary + "\n")
+		}
+		if tags := rec.Classification.Analysis.Tags; len(tags) > 0 {
+			b.WriteString(strings.Join(tags, ", ") + "\n")
+		}
+	}
+	b.WriteString(firstRunes(text, embedChars))
+	return b.String()
+}
+
+func toFloat32(v []float64) []float32 {
+	out := make([]float32, len(v))
+	for i, x := range v {
+		out[i] = float32(x)
+	}
+	return out
+}
@@ -0,0 +1,88 @@
+package pipeline
+
+import (
+	"context"
+	"docs_organiser/internal/aitest"
+	"docs_organiser/internal/search"
+	"docs_organiser/internal/storage"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRun_SemanticIndex(t *testing.T) {
+	srv := aitest.NewServer(t, "mock-model")
+	srv.Respond(func(r aitest.Request) aitest.Reply {
+		if strings.Contains(r.User(), "tenancy") {
+			return aitest.Category("Housing", "Rental_Agreement", 0.9)
+		}
+		return aitest.Category("Finance", "Invoice", 0.9)
+	})
+	srv.Embed(func(input string) []float64 {
+		input = strings.ToLower(input)
+		v := []float64{0.1, 0.1}
+		if strings.Contains(input, "rent") || strings.Contains(input, "tenancy") {
+			v[0] = 1
+		}
+		if strings.Contains(input, "invoice") {
+			v[1] = 1
+		}
+		return v
+	})
+	store, err := storage.NewBadgerStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	src, dst := writeSource(t, map[string]string{
+		"lease.txt":   "This tenancy agreement starts on 1 May 2022",
+		"invoice.txt": "Invoice 1001, total due 500 EUR",
+	})
+	p := NewPipeline(src, dst, srv.Engine(t, "Housing", "Finance", "Misc"), 1, 0)
+	p.Search = search.NewIndex(store)
+	if err := p.Run(context.Background()); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	matches, err := p.SearchQuery(context.Background(), "rental agreement 2022", 1)
+	if err != nil {
+		t.Fatalf("SearchQuery: %v", err)
+	}
+	want := filepath.Join(dst, "Housing", "Rental_Agreement.txt")
+	if len(matches) != 1 || matches[0].Path != want || matches[0].Category != "Housing" {
+		t.Fatalf("SearchQuery = %+v, want %s", matches, want)
+	}
+
+	// Files moved away since they were indexed are dropped
+	if err := os.Remove(want); err != nil {
+		t.Fatal(err)
+	}
+	matches, err = p.SearchQuery(context.Background(), "rental agreement 2022", 5)
+	if err != nil {
+		t.Fatalf("SearchQuery: %v", err)
+	}
+	if len(matches) != 1 || matches[0].Path != filepath.Join(dst, "Finance", "Invoice.txt") {
+		t.Errorf("SearchQuery after removal = %+v, want only the invoice", matches)
+	}
+	if n, total, _ := p.Search.Count("mock-model"); n != 1 || total != 1 {
+		t.Errorf("index holds %d/%d entries, want 1", n, total)
+	}
+}
+
+func TestSearchQuery_EmptyIndex(t *testing.T) {
+	srv := aitest.NewServer(t, "mock-model")
+	srv.Embed(func(string) []float64 { return []float64{1, 0} })
+	store, err := storage.NewBadgerStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	src, dst := writeSource(t, nil)
+	p := NewPipeline(src, dst, srv.Engine(t, "Misc"), 1, 0)
+	p.Search = search.NewIndex(store)
+	if _, err := p.SearchQuery(context.Background(), "anything", 5); err == nil || !strings.Contains(err.Error(), "semantic_index") {
+		t.Errorf("SearchQuery error = %v, want the empty index explained", err)
+	}
+}
@@ -0,0 +1,120 @@
+package pipeline
+
+import (
+	"context"
+	"docs_organiser/internal/ai"
+	"docs_organiser/internal/audit"
+	"docs_organiser/internal/exif"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// Sidecar formats accepted by Pipeline.Sidecar.
+const (
+	SidecarJSON = "json"
+	SidecarYAML = "yaml"
+)
+
+// ValidateSidecarFormat reports whether format is empty (disabled) or supported.
+func ValidateSidecarFormat(format string) error {
+	switch format {
+	case "", SidecarJSON, SidecarYAML:
+		return nil
+	}
+	return fmt.Errorf("unknown sidecar format %q (expected json or yaml)", format)
+}
+
+// sidecar is the metadata written next to an organised file for downstream tools.
+type sidecar struct {
+	File        string                     `json:"file"`
+	Source      string                     `json:"source"`
+	SHA256      string                     `json:"sha256"`
+	Size        int64                      `json:"size"`
+	OrganisedAt time.Time                  `json:"organised_at"`
+	Fallback    bool                       `json:"fallback,omitempty"`
+	Analysis    *ai.AnalysisResult         `json:"analysis,omitempty"`
+	Model       *ai.CategorizationMetadata `json:"model,omitempty"`
+	Extraction  audit.Extraction           `json:"extraction"`
+	Photo       *exif.Info                 `json:"photo,omitempty"`
+	Invoice     *ai.Invoice                `json:"invoice,omitempty"`
+}
+
+// encodeSidecar renders the sidecar for a moved file in the configured format.
+func (p *Pipeline) encodeSidecar(rec audit.Record, hash string, size int64) ([]byte, error) {
+	sc := sidecar{
+		File:        rec.Destination,
+		Source:      rec.Source,
+		SHA256:      hash,
+		Size:        size,
+		OrganisedAt: time.Now(),
+		Fallback:    rec.Fallback,
+		Extraction:  rec.Extraction,
+		Photo:       rec.Photo,
+		Invoice:     rec.Invoice,
+	}
+	if rec.Classification != nil {
+		sc.Analysis = rec.Classification.Analysis
+		sc.Model = rec.Classification.Metadata
+	}
+
+	data, err := json.MarshalIndent(sc, "", "  ")
+	if err != nil || p.Sidecar != SidecarYAML {
+		return data, err
+	}
+	return jsonToYAML(data)
+}
+
+// writeSidecar stores the sidecar as "<file>.<format>" beside the organised file,
+// uploading it when the destination is remote.
+func (p *Pipeline) writeSidecar(ctx context.Context, rec audit.Record, hash string, size int64) error {
+	data, err := p.encodeSidecar(rec, hash, size)
+	if err != nil {
+		return err
+	}
+
+	dst, err := p.remoteFor(p.destRoot(rec.Category))
+	if err != nil {
+		return err
+	}
+	if dst == nil {
+		return os.WriteFile(rec.Destination+"."+p.Sidecar, data, 0644)
+	}
+
+	tmp, err := os.CreateTemp("", "docs-organiser-sidecar-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	key := strings.TrimPrefix(rec.Destination, dst.URL("")) + "." + p.Sidecar
+	return dst.Upload(ctx, key, tmp.Name())
+}
+
+// jsonToYAML re-encodes a JSON document as block-style YAML, keeping the JSON field
+// names and order so both sidecar formats share one schema.
+func jsonToYAML(data []byte) ([]byte, error) {
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return nil, err
+	}
+	var clearStyle func(n *yaml.Node)
+	clearStyle = func(n *yaml.Node) {
+		n.Style = 0
+		for _, c := range n.Content {
+			clearStyle(c)
+		}
+	}
+	clearStyle(&node)
+	return yaml.Marshal(&node)
+}
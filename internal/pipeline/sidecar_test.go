@@ -0,0 +1,73 @@
+package pipeline
+
+import (
+	"context"
+	"docs_organiser/internal/ai"
+	"docs_organiser/internal/audit"
+	"docs_organiser/internal/remote"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func sidecarRecord(dest string) audit.Record {
+	return audit.Record{
+		Source:      "/inbox/scan.pdf",
+		Destination: dest,
+		Status:      audit.StatusMoved,
+		Extraction:  audit.Extraction{Chars: 1200, Duration: time.Second},
+		Classification: &ai.CategorizationResult{
+			Analysis: &ai.AnalysisResult{Category: "Finance", Title: "Invoice", ConfidenceScore: 0.9, Summary: "true"},
+			Metadata: &ai.CategorizationMetadata{Model: "test-model", Attempts: 1, Success: true},
+		},
+	}
+}
+
+func TestWriteSidecar(t *testing.T) {
+	dest := filepath.Join(t.TempDir(), "Invoice.pdf")
+
+	tests := []struct {
+		format string
+		want   []string
+	}{
+		{SidecarJSON, []string{`"sha256": "abc123"`, `"source": "/inbox/scan.pdf"`, `"confidence_score": 0.9`, `"model": "test-model"`, `"chars": 1200`}},
+		{SidecarYAML, []string{"sha256: abc123\n", "source: /inbox/scan.pdf\n", "    confidence_score: 0.9\n", `    summary: "true"`, "    chars: 1200\n"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			p := &Pipeline{Sidecar: tt.format}
+			if err := p.writeSidecar(context.Background(), sidecarRecord(dest), "abc123", 42); err != nil {
+				t.Fatal(err)
+			}
+			data, err := os.ReadFile(dest + "." + tt.format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(string(data), want) {
+					t.Errorf("sidecar missing %q:\n%s", want, data)
+				}
+			}
+		})
+	}
+}
+
+func TestWriteSidecarRemote(t *testing.T) {
+	dst := &memoryBackend{objects: map[string][]byte{}}
+	p := &Pipeline{DestDir: "s3://bucket/inbox", Sidecar: SidecarJSON}
+	p.remotes = map[string]remote.Backend{p.DestDir: dst}
+
+	if err := p.writeSidecar(context.Background(), sidecarRecord(dst.URL("Finance/Invoice.pdf")), "abc123", 42); err != nil {
+		t.Fatal(err)
+	}
+	var sc sidecar
+	if err := json.Unmarshal(dst.objects["Finance/Invoice.pdf.json"], &sc); err != nil {
+		t.Fatalf("sidecar not uploaded: %v (objects %v)", err, dst.objects)
+	}
+	if sc.SHA256 != "abc123" || sc.Size != 42 || sc.Analysis.Category != "Finance" {
+		t.Errorf("sidecar = %+v", sc)
+	}
+}
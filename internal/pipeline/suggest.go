@@ -0,0 +1,176 @@
+package pipeline
+
+import (
+	"context"
+	"docs_organiser/internal/cluster"
+	"docs_organiser/internal/remote"
+	"docs_organiser/internal/taxonomy"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"slices"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// Runes of each document that are embedded, and shown to the model when naming clusters.
+const (
+	embedChars   = 2000
+	excerptChars = 600
+	// namingExcerpts is how many of the documents closest to a cluster's centre are shown
+	// to the model to name it.
+	namingExcerpts = 5
+)
+
+// SuggestOptions configures SuggestCategories.
+type SuggestOptions struct {
+	// Samples is how many source files are read at most; they are picked at random.
+	Samples int
+	// Clusters is the number of categories to propose; 0 picks one from the sample size.
+	Clusters int
+	// EmbeddingModel is the model the documents are embedded with; "" uses the
+	// classification model.
+	EmbeddingModel string
+}
+
+// Suggestion is a category proposed for a cluster of similar source documents.
+type Suggestion struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Examples    []string `json:"examples,omitempty"`
+	// Files is how many sampled documents the cluster holds, and Sample names those
+	// closest to its centre.
+	Files  int      `json:"files"`
+	Sample []string `json:"sample"`
+}
+
+// SuggestCategories proposes categories for the source folder: it embeds a random sample
+// of the documents, clusters the embeddings, and asks the model to name each cluster.
+// Clusters the model gives the same name are merged. Nothing is moved.
+func (p *Pipeline) SuggestCategories(ctx context.Context, opts SuggestOptions) ([]Suggestion, error) {
+	if remote.IsRemote(p.SourceDir) {
+		return nil, fmt.Errorf("suggest-categories requires a local source directory")
+	}
+	excludedDirs, err := p.sourceExclusions()
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve source/destination overlap: %w", err)
+	}
+	sample := newReservoir(max(opts.Samples, 2), nil)
+	if err := p.scanLocal(ctx, excludedDirs, sample.add); err != nil {
+		return nil, err
+	}
+	slices.SortFunc(sample.jobs, func(a, b FileJob) int { return strings.Compare(a.Path, b.Path) })
+
+	var names, texts []string
+	for _, job := range sample.jobs {
+		name := job.name()
+		text, err := p.Extraction.Extract(ctx, job.Path, p.textLimit(name))
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if err != nil {
+			log.Printf("[!] Skipping %s: %v", name, err)
+			continue
+		}
+		if text = strings.TrimSpace(text); text == "" {
+			continue
+		}
+		names = append(names, name)
+		texts = append(texts, text)
+	}
+	if len(texts) < 2 {
+		return nil, fmt.Errorf("found %d document(s) with text in %s; at least 2 are needed", len(texts), p.SourceDir)
+	}
+
+	fmt.Printf("[*] Embedding %d document(s)...\n", len(texts))
+	inputs := make([]string, len(texts))
+	for i, text := range texts {
+		inputs[i] = names[i] + "\n" + firstRunes(text, embedChars)
+	}
+	vectors, _, err := p.AI.Embed(ctx, opts.EmbeddingModel, inputs)
+	if err != nil {
+		return nil, err
+	}
+
+	k := opts.Clusters
+	if k <= 0 {
+		k = cluster.DefaultK(len(texts))
+	}
+	clusters := cluster.KMeans(vectors, k, 1)
+	fmt.Printf("[*] Naming %d cluster(s)...\n", len(clusters))
+
+	var suggestions []Suggestion
+	var taken []string
+	for _, c := range clusters {
+		var excerpts, closest []string
+		for _, i := range c.Members[:min(len(c.Members), namingExcerpts)] {
+			excerpts = append(excerpts, fmt.Sprintf("File: %s\n%s", names[i], firstRunes(texts[i], excerptChars)))
+			closest = append(closest, names[i])
+		}
+		named, err := p.AI.NameCluster(ctx, excerpts, taken)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			log.Printf("[!] Could not name the cluster of %s: %v", strings.Join(closest, ", "), err)
+			continue
+		}
+		if i := slices.IndexFunc(suggestions, func(s Suggestion) bool { return strings.EqualFold(s.Name, named.Name) }); i >= 0 {
+			suggestions[i].Files += len(c.Members)
+			continue
+		}
+		taken = append(taken, named.Name)
+		suggestions = append(suggestions, Suggestion{
+			Name:        named.Name,
+			Description: named.Description,
+			Examples:    named.Examples,
+			Files:       len(c.Members),
+			Sample:      closest,
+		})
+	}
+	if len(suggestions) == 0 {
+		return nil, errors.New("the model named none of the clusters")
+	}
+	slices.SortStableFunc(suggestions, func(a, b Suggestion) int { return b.Files - a.Files })
+	return suggestions, nil
+}
+
+// WriteTaxonomy writes suggestions as a taxonomy file (see taxonomy_file), noting the
+// sampled files of each category in comments.
+func WriteTaxonomy(w io.Writer, suggestions []Suggestion) error {
+	var files int
+	for _, s := range suggestions {
+		files += s.Files
+	}
+	if _, err := fmt.Fprintf(w, "# Categories suggested from %d sampled document(s). Review the names and\n# descriptions, then set taxonomy_file to this file.\ncategories:\n", files); err != nil {
+		return err
+	}
+	for _, s := range suggestions {
+		out, err := yaml.Marshal([]taxonomy.Category{{Name: s.Name, Description: s.Description, Examples: s.Examples}})
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "\n  # %d file(s), e.g. %s\n", s.Files, strings.Join(s.Sample, ", ")); err != nil {
+			return err
+		}
+		for _, line := range strings.SplitAfter(strings.TrimRight(string(out), "\n"), "\n") {
+			if _, err := io.WriteString(w, "  "+strings.TrimRight(line, "\n")+"\n"); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// firstRunes returns s cut to at most n runes.
+func firstRunes(s string, n int) string {
+	for i := range s {
+		if n == 0 {
+			return s[:i]
+		}
+		n--
+	}
+	return s
+}
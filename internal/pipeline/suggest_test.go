@@ -0,0 +1,92 @@
+package pipeline
+
+import (
+	"bytes"
+	"context"
+	"docs_organiser/internal/aitest"
+	"docs_organiser/internal/taxonomy"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSuggestCategories(t *testing.T) {
+	srv := aitest.NewServer(t, "mock-model")
+	srv.Embed(func(input string) []float64 {
+		input = strings.ToLower(input)
+		v := []float64{0.1, 0.1}
+		if strings.Contains(input, "invoice") {
+			v[0] = 1
+		}
+		if strings.Contains(input, "recipe") {
+			v[1] = 1
+		}
+		return v
+	})
+	srv.Respond(func(r aitest.Request) aitest.Reply {
+		if !strings.Contains(r.System(), "grouped together") {
+			return aitest.Failure(500)
+		}
+		if strings.Contains(r.User(), "Invoice") {
+			return aitest.Reply{Content: `{"name": "Invoices", "description": "Bills from suppliers.", "examples": ["invoices", "bills"]}`}
+		}
+		return aitest.Reply{Content: `Here you go: {"name": "Recipes", "description": "Cooking instructions.", "examples": ["recipes"]}`}
+	})
+
+	src, dst := writeSource(t, map[string]string{
+		"a.txt": "Invoice 1001 for consulting, total due 500 EUR",
+		"b.txt": "Invoice 1002 for hosting, total due 20 EUR",
+		"c.txt": "Invoice 1003, payment within 30 days",
+		"d.txt": "Recipe: pancakes. Mix flour, eggs, and milk",
+		"e.txt": "Recipe: tomato soup with basil",
+		"f.txt": "",
+	})
+	p := NewPipeline(src, dst, srv.Engine(t, "Misc"), 1, 0)
+	suggestions, err := p.SuggestCategories(context.Background(), SuggestOptions{Samples: 10, Clusters: 2})
+	if err != nil {
+		t.Fatalf("SuggestCategories: %v", err)
+	}
+	if len(suggestions) != 2 {
+		t.Fatalf("suggestions = %+v, want 2", suggestions)
+	}
+	if s := suggestions[0]; s.Name != "Invoices" || s.Files != 3 || len(s.Sample) != 3 || s.Description != "Bills from suppliers." {
+		t.Errorf("first suggestion = %+v, want Invoices with 3 files", s)
+	}
+	if s := suggestions[1]; s.Name != "Recipes" || s.Files != 2 {
+		t.Errorf("second suggestion = %+v, want Recipes with 2 files", s)
+	}
+	for _, name := range []string{"a.txt", "d.txt"} {
+		if _, err := os.Stat(filepath.Join(src, name)); err != nil {
+			t.Errorf("source file moved: %v", err)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := WriteTaxonomy(&buf, suggestions); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "taxonomy.yaml")
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tax, err := taxonomy.Load(path)
+	if err != nil {
+		t.Fatalf("suggested taxonomy does not load: %v\n%s", err, buf.String())
+	}
+	if got := strings.Join(tax.Paths(), ","); got != "Invoices,Recipes,Misc" {
+		t.Errorf("taxonomy paths = %s, want Invoices,Recipes,Misc", got)
+	}
+	if !strings.Contains(buf.String(), "# 3 file(s), e.g. ") {
+		t.Errorf("taxonomy lacks the sample comment:\n%s", buf.String())
+	}
+}
+
+func TestSuggestCategories_TooFewDocuments(t *testing.T) {
+	srv := aitest.NewServer(t, "mock-model")
+	src, dst := writeSource(t, map[string]string{"a.txt": "Invoice 1001"})
+	p := NewPipeline(src, dst, srv.Engine(t, "Misc"), 1, 0)
+	if _, err := p.SuggestCategories(context.Background(), SuggestOptions{Samples: 10}); err == nil || !strings.Contains(err.Error(), "at least 2") {
+		t.Errorf("SuggestCategories error = %v, want too few documents", err)
+	}
+}
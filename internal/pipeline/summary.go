@@ -0,0 +1,112 @@
+package pipeline
+
+import (
+	"docs_organiser/internal/audit"
+	"docs_organiser/internal/notify"
+	"maps"
+	"sync"
+)
+
+// maxSummaryFailures caps how many failed files, and near-duplicates, a run summary lists
+// by name.
+const maxSummaryFailures = 50
+
+// runStats accumulates per-category counts and failures for a single run.
+type runStats struct {
+	mu                sync.Mutex
+	categories        map[string]int
+	failures          []notify.FileFailure
+	omitted           int
+	nearDuplicates    []notify.NearDuplicate
+	omittedDuplicates int
+	failed            []Failure // every failure, for the failures file
+	queued            []Failure // files left for the queue file
+	settled           map[string]bool
+}
+
+func newRunStats() *runStats {
+	return &runStats{categories: make(map[string]int), settled: make(map[string]bool)}
+}
+
+// record folds a file outcome into the stats.
+func (s *runStats) record(rec audit.Record) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if rec.NearDuplicateOf != "" && !rec.Duplicate {
+		if len(s.nearDuplicates) < maxSummaryFailures {
+			s.nearDuplicates = append(s.nearDuplicates, notify.NearDuplicate{File: rec.Source, Of: rec.NearDuplicateOf})
+		} else {
+			s.omittedDuplicates++
+		}
+	}
+	switch {
+	case rec.Status == audit.StatusMoved:
+		s.categories[rec.Category]++
+	case failed(rec):
+		if len(s.failures) < maxSummaryFailures {
+			s.failures = append(s.failures, notify.FileFailure{File: rec.Source, Error: rec.Error})
+		} else {
+			s.omitted++
+		}
+	}
+}
+
+// fail adds job, which failed with rec, to the failures file.
+func (s *runStats) fail(job FileJob, rec audit.Record) {
+	if !failed(rec) {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failed = append(s.failed, Failure{File: rec.Source, Key: job.Key, Status: rec.Status, Error: rec.Error})
+}
+
+// settle notes that job had an outcome, so it is no longer queued. Interrupted files
+// stay queued.
+func (s *runStats) settle(job FileJob, rec audit.Record) {
+	if rec.Status == audit.StatusCancelled {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.settled[job.queueID()] = true
+}
+
+// queue adds entry to the queue file.
+func (s *runStats) queue(entry Failure) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.queued = append(s.queued, entry)
+}
+
+// queuedFiles returns the files queued so far, and the IDs of those settled.
+func (s *runStats) queuedFiles() ([]Failure, map[string]bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Failure{}, s.queued...), maps.Clone(s.settled)
+}
+
+// failedFiles returns every failure recorded so far.
+func (s *runStats) failedFiles() []Failure {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Failure{}, s.failed...)
+}
+
+// fill copies the accumulated stats into summary.
+func (s *runStats) fill(summary *notify.RunSummary) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.categories) > 0 {
+		summary.Categories = make(map[string]int, len(s.categories))
+		for name, n := range s.categories {
+			summary.Categories[name] = n
+		}
+	}
+	summary.Failures = append([]notify.FileFailure(nil), s.failures...)
+	summary.OmittedFailures = s.omitted
+	summary.NearDuplicates = append([]notify.NearDuplicate(nil), s.nearDuplicates...)
+	summary.OmittedNearDuplicates = s.omittedDuplicates
+}
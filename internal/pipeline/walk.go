@@ -0,0 +1,100 @@
+package pipeline
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// WalkOptions control how the source tree is traversed.
+type WalkOptions struct {
+	// MaxDepth limits recursion; 1 visits only files directly in the root. 0 means unlimited.
+	MaxDepth int
+	// FollowSymlinks descends into symlinked directories, guarding against cycles.
+	FollowSymlinks bool
+}
+
+// walkTree behaves like filepath.Walk (lexical order, SkipDir/SkipAll support) but honors
+// WalkOptions. Symlinked directories are skipped unless FollowSymlinks is set, in which case
+// fn receives the target's FileInfo so they are treated like regular directories.
+func walkTree(root string, opts WalkOptions, fn filepath.WalkFunc) error {
+	info, err := os.Stat(root)
+	if err != nil {
+		err = fn(root, nil, err)
+	} else {
+		w := &treeWalker{opts: opts, fn: fn, visited: make(map[string]bool)}
+		err = w.walk(root, info, 0)
+	}
+	if err == filepath.SkipDir || err == filepath.SkipAll {
+		return nil
+	}
+	return err
+}
+
+type treeWalker struct {
+	opts    WalkOptions
+	fn      filepath.WalkFunc
+	visited map[string]bool
+}
+
+func (w *treeWalker) walk(path string, info os.FileInfo, depth int) error {
+	if !info.IsDir() {
+		return w.fn(path, info, nil)
+	}
+
+	if err := w.fn(path, info, nil); err != nil {
+		return err
+	}
+	if w.opts.MaxDepth > 0 && depth >= w.opts.MaxDepth {
+		return nil
+	}
+
+	// Symlink cycles are only possible when following links
+	if w.opts.FollowSymlinks {
+		if real, err := filepath.EvalSymlinks(path); err == nil {
+			if w.visited[real] {
+				return nil
+			}
+			w.visited[real] = true
+		}
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		if err := w.fn(path, info, err); err != nil && err != filepath.SkipDir {
+			return err
+		}
+		return nil
+	}
+
+	for _, entry := range entries {
+		child := filepath.Join(path, entry.Name())
+		childInfo, err := entry.Info()
+		if err != nil {
+			if err := w.fn(child, nil, err); err != nil && err != filepath.SkipDir {
+				return err
+			}
+			continue
+		}
+
+		if childInfo.Mode()&os.ModeSymlink != 0 {
+			if target, err := os.Stat(child); err == nil && target.IsDir() {
+				if !w.opts.FollowSymlinks {
+					continue
+				}
+				childInfo = target
+			}
+		}
+
+		if err := w.walk(child, childInfo, depth+1); err != nil {
+			if err == filepath.SkipDir {
+				if childInfo.IsDir() {
+					continue
+				}
+				// SkipDir on a file skips the rest of its directory
+				return nil
+			}
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package pipeline
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestWalkTree(t *testing.T) {
+	root := t.TempDir()
+	src := filepath.Join(root, "src")
+	external := filepath.Join(root, "external")
+
+	for _, f := range []string{
+		filepath.Join(src, "top.pdf"),
+		filepath.Join(src, "a", "mid.pdf"),
+		filepath.Join(src, "a", "b", "deep.pdf"),
+		filepath.Join(external, "linked.pdf"),
+	} {
+		if err := os.MkdirAll(filepath.Dir(f), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(f, []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink(external, filepath.Join(src, "link")); err != nil {
+		t.Skipf("symlinks unsupported: %v", err)
+	}
+	// A cycle back to the root must not loop forever
+	if err := os.Symlink(src, filepath.Join(src, "a", "loop")); err != nil {
+		t.Fatal(err)
+	}
+
+	collect := func(opts WalkOptions) []string {
+		var files []string
+		err := walkTree(src, opts, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if !info.IsDir() {
+				rel, _ := filepath.Rel(src, path)
+				files = append(files, filepath.ToSlash(rel))
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("walkTree() error = %v", err)
+		}
+		sort.Strings(files)
+		return files
+	}
+
+	tests := []struct {
+		name string
+		opts WalkOptions
+		want []string
+	}{
+		{"Unlimited without symlinks", WalkOptions{}, []string{"a/b/deep.pdf", "a/mid.pdf", "top.pdf"}},
+		{"Top level only", WalkOptions{MaxDepth: 1}, []string{"top.pdf"}},
+		{"Two levels", WalkOptions{MaxDepth: 2}, []string{"a/mid.pdf", "top.pdf"}},
+		{"Follow symlinks", WalkOptions{FollowSymlinks: true}, []string{"a/b/deep.pdf", "a/mid.pdf", "link/linked.pdf", "top.pdf"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := collect(tt.opts); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("walkTree() files = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+// Package plugin runs extractors and classifiers written as external programs, in any
+// language, without changes to the organiser. The protocol is one process per call: the
+// request is written to the program's stdin as a JSON object, stdin is closed, and the
+// program prints one JSON response object to stdout and exits zero. Anything it writes
+// to stderr is quoted in the error when it fails. A response with a non-empty "error"
+// field fails the call with that message.
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Plugin kinds.
+const (
+	KindExtractor  = "extractor"
+	KindClassifier = "classifier"
+)
+
+// DefaultTimeout bounds a call when Plugin.Timeout is not set.
+const DefaultTimeout = 2 * time.Minute
+
+// maxResponseBytes bounds the stdout read from a plugin.
+const maxResponseBytes = 16 << 20
+
+// ExtractRequest asks an extractor plugin for the text of the file at Path. Limit is the
+// number of characters the organiser keeps; more is discarded.
+type ExtractRequest struct {
+	Type  string `json:"type"` // "extract"
+	Path  string `json:"path"`
+	Limit int    `json:"limit"`
+}
+
+// ExtractResponse is an extractor plugin's answer.
+type ExtractResponse struct {
+	Text  string `json:"text"`
+	Error string `json:"error,omitempty"`
+}
+
+// ClassifyRequest asks a classifier plugin to choose one of Categories for a document.
+type ClassifyRequest struct {
+	Type       string   `json:"type"` // "classify"
+	Path       string   `json:"path"`
+	Name       string   `json:"name"`
+	Dir        string   `json:"dir,omitempty"` // folder relative to the source root
+	Text       string   `json:"text"`
+	Categories []string `json:"categories"`
+}
+
+// ClassifyResponse is a classifier plugin's answer, in the fields the model answers with.
+type ClassifyResponse struct {
+	Category        string   `json:"category"`
+	Title           string   `json:"title"`
+	ConfidenceScore float64  `json:"confidence_score"`
+	DocumentDate    string   `json:"document_date,omitempty"`
+	Tags            []string `json:"tags,omitempty"`
+	Summary         string   `json:"summary,omitempty"`
+	Error           string   `json:"error,omitempty"`
+}
+
+// Plugin is an external program speaking the protocol: Command[0] is the program and
+// the rest its arguments, run without a shell.
+type Plugin struct {
+	Name    string
+	Command []string
+	Timeout time.Duration
+}
+
+// Extract runs an extractor plugin on the file at path.
+func (p Plugin) Extract(ctx context.Context, path string, limit int) (string, error) {
+	var resp ExtractResponse
+	if err := p.call(ctx, ExtractRequest{Type: "extract", Path: path, Limit: limit}, &resp); err != nil {
+		return "", err
+	}
+	if resp.Error != "" {
+		return "", fmt.Errorf("plugin %s: %s", p.Name, resp.Error)
+	}
+	if limit > 0 && len(resp.Text) > limit {
+		resp.Text = strings.ToValidUTF8(resp.Text[:limit], "")
+	}
+	return resp.Text, nil
+}
+
+// Classify runs a classifier plugin. The answer is not validated here.
+func (p Plugin) Classify(ctx context.Context, req ClassifyRequest) (ClassifyResponse, error) {
+	req.Type = "classify"
+	var resp ClassifyResponse
+	if err := p.call(ctx, req, &resp); err != nil {
+		return resp, err
+	}
+	if resp.Error != "" {
+		return resp, fmt.Errorf("plugin %s: %s", p.Name, resp.Error)
+	}
+	return resp, nil
+}
+
+// call runs the program with req on stdin and decodes its stdout into resp.
+func (p Plugin) call(ctx context.Context, req, resp any) error {
+	if len(p.Command) == 0 {
+		return fmt.Errorf("plugin %s has no command", p.Name)
+	}
+	timeout := p.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	stdout, stderr := &limitedBuffer{max: maxResponseBytes}, &limitedBuffer{max: 4096}
+	cmd := exec.CommandContext(ctx, p.Command[0], p.Command[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = bytes.NewReader(body), stdout, stderr
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			err = fmt.Errorf("timed out after %s", timeout)
+		}
+		if msg := strings.TrimSpace(stderr.buf.String()); msg != "" {
+			return fmt.Errorf("plugin %s: %w: %s", p.Name, err, msg)
+		}
+		return fmt.Errorf("plugin %s: %w", p.Name, err)
+	}
+	if stdout.overflow {
+		return fmt.Errorf("plugin %s: response larger than %d MB", p.Name, maxResponseBytes>>20)
+	}
+	if err := json.Unmarshal(bytes.TrimSpace(stdout.buf.Bytes()), resp); err != nil {
+		return fmt.Errorf("plugin %s: invalid response: %w", p.Name, err)
+	}
+	return nil
+}
+
+// limitedBuffer keeps the first max bytes written to it and notes whether more came.
+type limitedBuffer struct {
+	buf      bytes.Buffer
+	max      int
+	overflow bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	room := b.max - b.buf.Len()
+	if len(p) > room {
+		b.overflow = true
+		p = p[:max(room, 0)]
+	}
+	b.buf.Write(p)
+	return len(p), nil
+}
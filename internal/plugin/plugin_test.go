@@ -0,0 +1,63 @@
+package plugin
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExtract(t *testing.T) {
+	tests := []struct {
+		name    string
+		script  string
+		limit   int
+		want    string
+		wantErr string
+	}{
+		{name: "text", script: `echo '{"text": "Invoice 2024-001"}'`, want: "Invoice 2024-001"},
+		{name: "request on stdin", script: `in=$(cat); case "$in" in *'"type":"extract"'*'"limit":7'*) echo '{"text": "ok"}';; esac`, limit: 7, want: "ok"},
+		{name: "limit", script: `echo '{"text": "0123456789"}'`, limit: 4, want: "0123"},
+		{name: "error field", script: `echo '{"error": "unsupported scan"}'`, wantErr: "plugin test: unsupported scan"},
+		{name: "exit status", script: "echo tesseract missing >&2; exit 2", wantErr: "exit status 2: tesseract missing"},
+		{name: "not json", script: "echo plain text", wantErr: "invalid response"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := Plugin{Name: "test", Command: []string{"sh", "-c", tt.script}}
+			got, err := p.Extract(context.Background(), "/tmp/scan.png", tt.limit)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Extract error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Extract: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Extract = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClassify(t *testing.T) {
+	p := Plugin{Name: "test", Command: []string{"sh", "-c",
+		`grep -q '"categories":\["Finance","Misc"\]' && echo '{"category": "Finance", "title": "Invoice", "confidence_score": 0.8}'`}}
+	got, err := p.Classify(context.Background(), ClassifyRequest{Name: "scan.txt", Text: "Invoice", Categories: []string{"Finance", "Misc"}})
+	if err != nil {
+		t.Fatalf("Classify: %v", err)
+	}
+	if got.Category != "Finance" || got.Title != "Invoice" || got.ConfidenceScore != 0.8 {
+		t.Errorf("Classify = %+v", got)
+	}
+
+	p = Plugin{Name: "slow", Command: []string{"sleep", "5"}, Timeout: 50 * time.Millisecond}
+	if _, err := p.Classify(context.Background(), ClassifyRequest{}); err == nil || !strings.Contains(err.Error(), "timed out after 50ms") {
+		t.Errorf("Classify error = %v, want a timeout", err)
+	}
+	if _, err := (Plugin{Name: "empty"}).Classify(context.Background(), ClassifyRequest{}); err == nil {
+		t.Error("Classify without a command should fail")
+	}
+}
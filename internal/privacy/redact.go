@@ -0,0 +1,137 @@
+package privacy
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Placeholders substituted for detected PII.
+const (
+	EmailPlaceholder = "[EMAIL]"
+	CardPlaceholder  = "[CARD]"
+	IDPlaceholder    = "[ID]"
+	PhonePlaceholder = "[PHONE]"
+)
+
+var (
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+
+	// 13-19 digits, optionally grouped by spaces or dashes; confirmed with a Luhn check.
+	cardPattern = regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`)
+
+	idPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`\b\d{3}-\d{2}-\d{4}\b`),                                       // US SSN
+		regexp.MustCompile(`\b[A-Z]{2}\d{2}(?: ?[A-Z0-9]{4}){2,7}(?: ?[A-Z0-9]{1,3})?\b`), // IBAN
+		regexp.MustCompile(`\b\d{4} \d{4} \d{4}\b`),                                       // Aadhaar
+		regexp.MustCompile(`\b[A-Z]{5}\d{4}[A-Z]\b`),                                      // Indian PAN
+		regexp.MustCompile(`\b[A-CEGHJ-PR-TW-Z]{2} ?\d{2} ?\d{2} ?\d{2} ?[A-D]\b`),        // UK NINO
+	}
+
+	// International or local numbers split into groups; at least 8 digits are required below.
+	phonePattern = regexp.MustCompile(`(?:\+\d{1,3}[\s.-]?)?(?:\(\d{1,4}\)[\s.-]?)?\d{2,4}(?:[\s.-]\d{2,4}){1,4}`)
+
+	// Dates look like phone numbers to the pattern above but carry classification signal.
+	datePattern = regexp.MustCompile(`^(?:\d{4}[-./ ]\d{1,2}[-./ ]\d{1,2}|\d{1,2}[-./ ]\d{1,2}[-./ ]\d{4})$`)
+)
+
+// Redact masks emails, payment card numbers, national IDs, and phone numbers in text,
+// returning the masked text and the number of replacements made.
+func Redact(text string) (string, int) {
+	count := 0
+
+	text = emailPattern.ReplaceAllStringFunc(text, func(string) string {
+		count++
+		return EmailPlaceholder
+	})
+
+	text = cardPattern.ReplaceAllStringFunc(text, func(m string) string {
+		if !luhnValid(digitsOnly(m)) {
+			return m
+		}
+		count++
+		return CardPlaceholder
+	})
+
+	for _, re := range idPatterns {
+		text = replaceStandalone(text, re, IDPlaceholder, &count)
+	}
+
+	text = phonePattern.ReplaceAllStringFunc(text, func(m string) string {
+		if n := len(digitsOnly(m)); n < 8 || n > 15 || datePattern.MatchString(m) {
+			return m
+		}
+		count++
+		return PhonePlaceholder
+	})
+
+	return text, count
+}
+
+// replaceStandalone replaces matches of re that are not part of a longer digit group
+// (e.g. the first 12 digits of a 16-digit reference number).
+func replaceStandalone(text string, re *regexp.Regexp, placeholder string, count *int) string {
+	matches := re.FindAllStringIndex(text, -1)
+	if matches == nil {
+		return text
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		start, end := m[0], m[1]
+		if continuesDigits(text[:start], true) || continuesDigits(text[end:], false) {
+			continue
+		}
+		b.WriteString(text[last:start])
+		b.WriteString(placeholder)
+		last = end
+		*count++
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// continuesDigits reports whether s ends (before) or starts (after) with a separator followed by a digit.
+func continuesDigits(s string, before bool) bool {
+	if len(s) < 2 {
+		return false
+	}
+	if before {
+		return (s[len(s)-1] == ' ' || s[len(s)-1] == '-') && isDigit(s[len(s)-2])
+	}
+	return (s[0] == ' ' || s[0] == '-') && isDigit(s[1])
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func digitsOnly(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, s)
+}
+
+// luhnValid reports whether digits passes the Luhn checksum used by payment cards.
+func luhnValid(digits string) bool {
+	if len(digits) < 13 || len(digits) > 19 {
+		return false
+	}
+	sum := 0
+	double := false
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
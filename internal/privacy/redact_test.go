@@ -0,0 +1,52 @@
+package privacy
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRedact(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantHit int
+	}{
+		{"Email", "Contact john.doe+bills@example.co.uk today", "Contact [EMAIL] today", 1},
+		{"Valid card", "Card: 4111 1111 1111 1111 charged", "Card: [CARD] charged", 1},
+		{"Luhn-invalid digits kept as card", "Order 1234 5678 9012 3456", "Order 1234 5678 9012 3456", 0},
+		{"US SSN", "SSN 123-45-6789", "SSN [ID]", 1},
+		{"IBAN", "IBAN DE89 3704 0044 0532 0130 00", "IBAN [ID]", 1},
+		{"Indian PAN", "PAN ABCDE1234F", "PAN [ID]", 1},
+		{"Phone international", "Call +1 415-555-0132 now", "Call [PHONE] now", 1},
+		{"Phone local", "Tel: (020) 7946 0958", "Tel: [PHONE]", 1},
+		{"ISO date kept", "Due 2024-03-15", "Due 2024-03-15", 0},
+		{"European date kept", "Datum 15.03.2024", "Datum 15.03.2024", 0},
+		{"Short numbers kept", "Invoice 2024-17, total 1 250", "Invoice 2024-17, total 1 250", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, hits := Redact(tt.input)
+			if got != tt.want {
+				t.Errorf("Redact() = %q, want %q", got, tt.want)
+			}
+			if hits != tt.wantHit {
+				t.Errorf("Redact() hits = %d, want %d", hits, tt.wantHit)
+			}
+		})
+	}
+}
+
+func TestRedact_Mixed(t *testing.T) {
+	in := "From: a@b.com\nPhone: +44 20 7946 0958\nCard 5555555555554444"
+	got, hits := Redact(in)
+	for _, leak := range []string{"a@b.com", "7946", "5555"} {
+		if strings.Contains(got, leak) {
+			t.Errorf("redacted text still contains %q: %q", leak, got)
+		}
+	}
+	if hits != 3 {
+		t.Errorf("expected 3 redactions, got %d", hits)
+	}
+}
@@ -0,0 +1,344 @@
+package remote
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	dropboxAPI     = "https://api.dropboxapi.com"
+	dropboxContent = "https://content.dropboxapi.com"
+	// dropboxMaxRetries bounds retries of rate-limited requests.
+	dropboxMaxRetries = 3
+)
+
+// DropboxOptions configure access to Dropbox. Either a long-lived access token or a
+// refresh token with the app key (and secret, for confidential apps) is required.
+// Each defaults to the matching DROPBOX_* environment variable.
+type DropboxOptions struct {
+	AccessToken  string
+	RefreshToken string
+	AppKey       string
+	AppSecret    string
+}
+
+type dropboxBackend struct {
+	root       string // "" for the Dropbox root, otherwise "/Folder/Sub"
+	opts       DropboxOptions
+	apiURL     string
+	contentURL string
+	client     *http.Client
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+type dropboxError struct {
+	Status  int
+	Summary string
+}
+
+func (e *dropboxError) Error() string {
+	return fmt.Sprintf("dropbox: %s (status %d)", e.Summary, e.Status)
+}
+
+func newDropbox(location string, opts DropboxOptions) (*dropboxBackend, error) {
+	root := "/" + strings.Trim(strings.TrimPrefix(location, "dropbox://"), "/")
+	if root == "/" {
+		root = ""
+	}
+
+	opts.AccessToken = firstNonEmpty(opts.AccessToken, os.Getenv("DROPBOX_ACCESS_TOKEN"))
+	opts.RefreshToken = firstNonEmpty(opts.RefreshToken, os.Getenv("DROPBOX_REFRESH_TOKEN"))
+	opts.AppKey = firstNonEmpty(opts.AppKey, os.Getenv("DROPBOX_APP_KEY"))
+	opts.AppSecret = firstNonEmpty(opts.AppSecret, os.Getenv("DROPBOX_APP_SECRET"))
+	if opts.AccessToken == "" && (opts.RefreshToken == "" || opts.AppKey == "") {
+		return nil, fmt.Errorf("dropbox credentials missing: set DROPBOX_ACCESS_TOKEN, or DROPBOX_REFRESH_TOKEN and DROPBOX_APP_KEY")
+	}
+
+	return &dropboxBackend{
+		root:       root,
+		opts:       opts,
+		apiURL:     dropboxAPI,
+		contentURL: dropboxContent,
+		client:     &http.Client{Timeout: 5 * time.Minute},
+	}, nil
+}
+
+func (d *dropboxBackend) URL(key string) string {
+	return "dropbox://" + strings.TrimPrefix(d.fullPath(key), "/")
+}
+
+func (d *dropboxBackend) fullPath(key string) string {
+	return d.root + "/" + key
+}
+
+// accessToken returns a valid token, refreshing it when using a refresh token.
+func (d *dropboxBackend) accessToken(ctx context.Context) (string, error) {
+	if d.opts.RefreshToken == "" {
+		return d.opts.AccessToken, nil
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.token != "" && time.Now().Before(d.expires) {
+		return d.token, nil
+	}
+
+	form := url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {d.opts.RefreshToken},
+		"client_id":     {d.opts.AppKey},
+	}
+	if d.opts.AppSecret != "" {
+		form.Set("client_secret", d.opts.AppSecret)
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", d.apiURL+"/oauth2/token", strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("dropbox: failed to refresh token: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("dropbox: failed to refresh token (status %d)", resp.StatusCode)
+	}
+
+	var tok struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tok); err != nil {
+		return "", fmt.Errorf("dropbox: invalid token response: %w", err)
+	}
+	d.token = tok.AccessToken
+	// Refresh a minute early to avoid using a token that expires mid-request
+	d.expires = time.Now().Add(time.Duration(tok.ExpiresIn)*time.Second - time.Minute)
+	return d.token, nil
+}
+
+// call performs an RPC or content request. For content endpoints, arg travels in the
+// Dropbox-API-Arg header and body is the file content; otherwise arg is the JSON body.
+func (d *dropboxBackend) call(ctx context.Context, content bool, endpoint string, arg interface{}, body func() (io.Reader, error)) (*http.Response, error) {
+	base := d.apiURL
+	if content {
+		base = d.contentURL
+	}
+	argJSON, err := json.Marshal(arg)
+	if err != nil {
+		return nil, err
+	}
+
+	for attempt := 0; ; attempt++ {
+		token, err := d.accessToken(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		var reqBody io.Reader
+		if body != nil {
+			if reqBody, err = body(); err != nil {
+				return nil, err
+			}
+		} else if !content {
+			reqBody = bytes.NewReader(argJSON)
+		}
+
+		req, err := http.NewRequestWithContext(ctx, "POST", base+endpoint, reqBody)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+		if !content {
+			req.Header.Set("Content-Type", "application/json")
+		} else {
+			req.Header.Set("Dropbox-API-Arg", asciiJSON(argJSON))
+			if body != nil {
+				req.Header.Set("Content-Type", "application/octet-stream")
+			}
+		}
+
+		resp, err := d.client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode == http.StatusOK {
+			return resp, nil
+		}
+
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+		resp.Body.Close()
+
+		if resp.StatusCode == http.StatusTooManyRequests && attempt < dropboxMaxRetries {
+			wait := time.Second << attempt
+			if s, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+				wait = time.Duration(s) * time.Second
+			}
+			select {
+			case <-time.After(wait):
+				continue
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+
+		var e struct {
+			Summary string `json:"error_summary"`
+		}
+		if json.Unmarshal(data, &e) != nil || e.Summary == "" {
+			e.Summary = strings.TrimSpace(string(data))
+		}
+		return nil, &dropboxError{Status: resp.StatusCode, Summary: e.Summary}
+	}
+}
+
+// rpc performs a JSON request and decodes the JSON response into out (if non-nil).
+func (d *dropboxBackend) rpc(ctx context.Context, endpoint string, arg, out interface{}) error {
+	resp, err := d.call(ctx, false, endpoint, arg, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+type dropboxListResult struct {
+	Entries []struct {
+		Tag            string    `json:".tag"`
+		PathDisplay    string    `json:"path_display"`
+		PathLower      string    `json:"path_lower"`
+		Size           int64     `json:"size"`
+		ServerModified time.Time `json:"server_modified"`
+	} `json:"entries"`
+	Cursor  string `json:"cursor"`
+	HasMore bool   `json:"has_more"`
+}
+
+func (d *dropboxBackend) List(ctx context.Context) ([]Object, error) {
+	var page dropboxListResult
+	err := d.rpc(ctx, "/2/files/list_folder", map[string]interface{}{"path": d.root, "recursive": true}, &page)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", d.URL(""), err)
+	}
+
+	rootLower := strings.ToLower(d.root) + "/"
+	var objects []Object
+	for {
+		for _, e := range page.Entries {
+			if e.Tag != "file" || !strings.HasPrefix(e.PathLower, rootLower) {
+				continue
+			}
+			// Keep the display casing for keys; Dropbox paths are case-insensitive
+			key := e.PathDisplay[len(rootLower):]
+			objects = append(objects, Object{Key: key, Size: e.Size, ModTime: e.ServerModified})
+		}
+		if !page.HasMore {
+			return objects, nil
+		}
+		cursor := page.Cursor
+		page = dropboxListResult{}
+		if err := d.rpc(ctx, "/2/files/list_folder/continue", map[string]string{"cursor": cursor}, &page); err != nil {
+			return nil, fmt.Errorf("failed to list %s: %w", d.URL(""), err)
+		}
+	}
+}
+
+func (d *dropboxBackend) Download(ctx context.Context, key string, w io.Writer) error {
+	resp, err := d.call(ctx, true, "/2/files/download", map[string]string{"path": d.fullPath(key)}, nil)
+	if err != nil {
+		return fmt.Errorf("failed to download %s: %w", d.URL(key), err)
+	}
+	defer resp.Body.Close()
+	_, err = io.Copy(w, resp.Body)
+	return err
+}
+
+func (d *dropboxBackend) Upload(ctx context.Context, key string, localPath string) error {
+	var f *os.File
+	defer func() {
+		if f != nil {
+			f.Close()
+		}
+	}()
+	open := func() (io.Reader, error) {
+		if f != nil {
+			f.Close()
+		}
+		var err error
+		f, err = os.Open(localPath)
+		return f, err
+	}
+
+	arg := map[string]interface{}{"path": d.fullPath(key), "mode": "overwrite", "mute": true}
+	resp, err := d.call(ctx, true, "/2/files/upload", arg, open)
+	if err != nil {
+		return fmt.Errorf("failed to upload %s: %w", d.URL(key), err)
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (d *dropboxBackend) Exists(ctx context.Context, key string) (bool, error) {
+	err := d.rpc(ctx, "/2/files/get_metadata", map[string]string{"path": d.fullPath(key)}, nil)
+	if err == nil {
+		return true, nil
+	}
+	if e, ok := err.(*dropboxError); ok && e.Status == http.StatusConflict && strings.Contains(e.Summary, "not_found") {
+		return false, nil
+	}
+	return false, err
+}
+
+func (d *dropboxBackend) Delete(ctx context.Context, key string) error {
+	if err := d.rpc(ctx, "/2/files/delete_v2", map[string]string{"path": d.fullPath(key)}, nil); err != nil {
+		return fmt.Errorf("failed to delete %s: %w", d.URL(key), err)
+	}
+	return nil
+}
+
+// Move relocates key server-side when dst is also in Dropbox, avoiding a re-upload.
+func (d *dropboxBackend) Move(ctx context.Context, key string, dst Backend, dstKey string) (bool, error) {
+	target, ok := dst.(*dropboxBackend)
+	if !ok {
+		return false, nil
+	}
+	arg := map[string]interface{}{"from_path": d.fullPath(key), "to_path": target.fullPath(dstKey), "autorename": false}
+	if err := d.rpc(ctx, "/2/files/move_v2", arg, nil); err != nil {
+		return true, fmt.Errorf("failed to move %s to %s: %w", d.URL(key), target.URL(dstKey), err)
+	}
+	return true, nil
+}
+
+// asciiJSON escapes non-ASCII characters, which HTTP headers cannot carry.
+func asciiJSON(data []byte) string {
+	var b strings.Builder
+	for _, r := range string(data) {
+		if r < 0x80 {
+			b.WriteRune(r)
+		} else if r > 0xFFFF {
+			r -= 0x10000
+			fmt.Fprintf(&b, `\u%04x\u%04x`, 0xD800+(r>>10), 0xDC00+(r&0x3FF))
+		} else {
+			fmt.Fprintf(&b, `\u%04x`, r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,215 @@
+package remote
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeDropbox implements the subset of the Dropbox API used by the backend.
+type fakeDropbox struct {
+	mu        sync.Mutex
+	files     map[string][]byte // lower-cased path -> content
+	display   map[string]string // lower-cased path -> display path
+	throttled bool
+}
+
+func (f *fakeDropbox) put(p string, data []byte) {
+	f.files[strings.ToLower(p)] = data
+	f.display[strings.ToLower(p)] = p
+}
+
+func (f *fakeDropbox) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.URL.Path == "/oauth2/token" {
+		json.NewEncoder(w).Encode(map[string]interface{}{"access_token": "fresh", "expires_in": 14400})
+		return
+	}
+	if r.Header.Get("Authorization") != "Bearer fresh" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	if !f.throttled {
+		f.throttled = true
+		w.Header().Set("Retry-After", "0")
+		w.WriteHeader(http.StatusTooManyRequests)
+		return
+	}
+
+	var arg map[string]interface{}
+	if h := r.Header.Get("Dropbox-API-Arg"); h != "" {
+		json.Unmarshal([]byte(h), &arg)
+	} else {
+		json.NewDecoder(r.Body).Decode(&arg)
+	}
+	lower := func(key string) string { s, _ := arg[key].(string); return strings.ToLower(s) }
+	notFound := func() {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]string{"error_summary": "path/not_found/.."})
+	}
+
+	switch r.URL.Path {
+	case "/2/files/list_folder", "/2/files/list_folder/continue":
+		var paths []string
+		for p := range f.files {
+			paths = append(paths, p)
+		}
+		sort.Strings(paths)
+		// Serve one entry per page to exercise pagination
+		start := 0
+		if r.URL.Path == "/2/files/list_folder/continue" {
+			start = len(arg["cursor"].(string))
+		}
+		entries := []map[string]interface{}{{".tag": "folder", "path_lower": "/scans", "path_display": "/Scans"}}
+		if start < len(paths) {
+			p := paths[start]
+			entries = append(entries, map[string]interface{}{
+				".tag": "file", "path_lower": p, "path_display": f.display[p], "size": len(f.files[p]),
+				"server_modified": "2024-01-02T03:04:05Z",
+			})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"entries": entries, "cursor": strings.Repeat("x", start+1), "has_more": start+1 < len(paths),
+		})
+	case "/2/files/download":
+		data, ok := f.files[lower("path")]
+		if !ok {
+			notFound()
+			return
+		}
+		w.Write(data)
+	case "/2/files/upload":
+		data, _ := io.ReadAll(r.Body)
+		f.put(arg["path"].(string), data)
+		json.NewEncoder(w).Encode(map[string]string{})
+	case "/2/files/get_metadata":
+		if _, ok := f.files[lower("path")]; !ok {
+			notFound()
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{".tag": "file"})
+	case "/2/files/delete_v2":
+		delete(f.files, lower("path"))
+		json.NewEncoder(w).Encode(map[string]string{})
+	case "/2/files/move_v2":
+		from := lower("from_path")
+		data, ok := f.files[from]
+		if !ok {
+			notFound()
+			return
+		}
+		delete(f.files, from)
+		f.put(arg["to_path"].(string), data)
+		json.NewEncoder(w).Encode(map[string]string{})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func newFakeDropbox(t *testing.T, location string) (*fakeDropbox, *dropboxBackend) {
+	t.Helper()
+	fake := &fakeDropbox{files: map[string][]byte{}, display: map[string]string{}}
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+
+	b, err := newDropbox(location, DropboxOptions{RefreshToken: "refresh", AppKey: "key"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.apiURL, b.contentURL = srv.URL, srv.URL
+	return fake, b
+}
+
+func TestDropboxBackend(t *testing.T) {
+	fake, b := newFakeDropbox(t, "dropbox://Scans/Inbox")
+	fake.put("/Scans/Inbox/Receipt 1.pdf", []byte("one"))
+	fake.put("/Scans/Inbox/sub/Notes.txt", []byte("two"))
+	fake.put("/Other/skip.pdf", []byte("x"))
+	ctx := context.Background()
+
+	objects, err := b.List(ctx)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	var keys []string
+	for _, o := range objects {
+		keys = append(keys, o.Key)
+	}
+	if got := strings.Join(keys, ","); got != "Receipt 1.pdf,sub/Notes.txt" {
+		t.Errorf("List keys = %s", got)
+	}
+
+	local, err := Fetch(ctx, b, "Receipt 1.pdf")
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	defer os.Remove(local)
+	if data, _ := os.ReadFile(local); string(data) != "one" {
+		t.Errorf("downloaded %q", data)
+	}
+
+	key, err := Put(ctx, b, local, "Finance/Receipt.pdf", "0123456789")
+	if err != nil || key != "Finance/Receipt.pdf" {
+		t.Fatalf("Put = %q, %v", key, err)
+	}
+	if key, err = Put(ctx, b, local, "Finance/Receipt.pdf", "0123456789"); err != nil || key != "Finance/Receipt_01234567.pdf" {
+		t.Errorf("colliding Put = %q, %v", key, err)
+	}
+
+	if exists, err := b.Exists(ctx, "missing.pdf"); err != nil || exists {
+		t.Errorf("Exists(missing) = %v, %v", exists, err)
+	}
+	if err := b.Delete(ctx, "sub/Notes.txt"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, ok := fake.files["/scans/inbox/sub/notes.txt"]; ok {
+		t.Error("file not deleted")
+	}
+}
+
+func TestDropboxServerSideMove(t *testing.T) {
+	fake, src := newFakeDropbox(t, "dropbox://Inbox")
+	dst, err := newDropbox("dropbox://Sorted", DropboxOptions{AccessToken: "fresh"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dst.apiURL, dst.contentURL = src.apiURL, src.contentURL
+	fake.put("/Inbox/scan.pdf", []byte("pdf"))
+
+	moved, err := src.Move(context.Background(), "scan.pdf", dst, "Tax/Return.pdf")
+	if err != nil || !moved {
+		t.Fatalf("Move = %v, %v", moved, err)
+	}
+	if _, ok := fake.files["/sorted/tax/return.pdf"]; !ok {
+		t.Error("file not at destination")
+	}
+
+	// A non-Dropbox destination must fall back to copying
+	s3 := &s3Backend{}
+	if moved, err := src.Move(context.Background(), "x.pdf", s3, "y.pdf"); moved || err != nil {
+		t.Errorf("cross-service Move = %v, %v", moved, err)
+	}
+}
+
+func TestDropboxCredentialsRequired(t *testing.T) {
+	t.Setenv("DROPBOX_ACCESS_TOKEN", "")
+	t.Setenv("DROPBOX_REFRESH_TOKEN", "")
+	if _, err := Open("dropbox://Inbox", Options{}); err == nil {
+		t.Error("expected an error without credentials")
+	}
+}
+
+func TestASCIIJSON(t *testing.T) {
+	if got := asciiJSON([]byte(`{"path":"/Résumé 📄.pdf"}`)); got != `{"path":"/R\u00e9sum\u00e9 \ud83d\udcc4.pdf"}` {
+		t.Errorf("asciiJSON = %s", got)
+	}
+}
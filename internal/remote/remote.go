@@ -0,0 +1,111 @@
+package remote
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+// Object is a file stored in a remote backend. Keys are relative to the backend's root
+// and always use forward slashes.
+type Object struct {
+	Key     string
+	Size    int64
+	ModTime time.Time
+}
+
+// Backend is a remote file store used as a pipeline source or destination.
+type Backend interface {
+	// List returns every object below the root.
+	List(ctx context.Context) ([]Object, error)
+	// Download writes the object's content to w.
+	Download(ctx context.Context, key string, w io.Writer) error
+	// Upload stores the content of the local file at key, replacing any existing object.
+	Upload(ctx context.Context, key string, localPath string) error
+	// Exists reports whether an object is stored at key.
+	Exists(ctx context.Context, key string) (bool, error)
+	// Delete removes the object at key.
+	Delete(ctx context.Context, key string) error
+	// URL renders key as a user-facing location for logs and audit records;
+	// it is always URL("") followed by key.
+	URL(key string) string
+}
+
+// Mover is implemented by backends that can relocate objects server-side.
+type Mover interface {
+	// Move relocates key to dstKey in dst. It reports false, without error, when dst
+	// cannot be reached server-side and the object must be copied instead.
+	Move(ctx context.Context, key string, dst Backend, dstKey string) (bool, error)
+}
+
+// Options configure remote backends.
+type Options struct {
+	S3      S3Options
+	Dropbox DropboxOptions
+}
+
+// IsRemote reports whether location names a remote store rather than a local path.
+func IsRemote(location string) bool {
+	return strings.HasPrefix(location, "s3://") || strings.HasPrefix(location, "dropbox://")
+}
+
+// Open returns the backend for a remote location, or nil for local paths.
+func Open(location string, opts Options) (Backend, error) {
+	switch {
+	case strings.HasPrefix(location, "s3://"):
+		return newS3(location, opts.S3)
+	case strings.HasPrefix(location, "dropbox://"):
+		return newDropbox(location, opts.Dropbox)
+	default:
+		return nil, nil
+	}
+}
+
+// FreeKey returns key, or key with a short content hash appended if an object already
+// exists there, mirroring local collision handling.
+func FreeKey(ctx context.Context, b Backend, key, hash string) (string, error) {
+	exists, err := b.Exists(ctx, key)
+	if err != nil {
+		return "", fmt.Errorf("failed to check for existing object: %w", err)
+	}
+	if exists {
+		ext := path.Ext(key)
+		key = fmt.Sprintf("%s_%s%s", strings.TrimSuffix(key, ext), hash[:8], ext)
+	}
+	return key, nil
+}
+
+// Put uploads the local file to a free key derived from key and returns the final key.
+func Put(ctx context.Context, b Backend, localPath, key, hash string) (string, error) {
+	key, err := FreeKey(ctx, b, key, hash)
+	if err != nil {
+		return "", err
+	}
+	if err := b.Upload(ctx, key, localPath); err != nil {
+		return "", err
+	}
+	return key, nil
+}
+
+// Fetch downloads key into a new temporary file that keeps the key's extension,
+// so extractors can dispatch on it. The caller removes the file.
+func Fetch(ctx context.Context, b Backend, key string) (string, error) {
+	f, err := os.CreateTemp("", "docs_organiser-*"+path.Ext(key))
+	if err != nil {
+		return "", err
+	}
+	if err := b.Download(ctx, key, f); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
@@ -0,0 +1,253 @@
+package remote
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// S3Options configure access to S3 and S3-compatible stores (MinIO, R2, Wasabi, ...).
+// Credentials default to the standard AWS_* environment variables.
+type S3Options struct {
+	// Endpoint overrides the AWS endpoint, e.g. "http://localhost:9000" for MinIO.
+	Endpoint string
+	Region   string
+	// PathStyle addresses buckets as endpoint/bucket instead of bucket.endpoint.
+	// It is implied when a custom endpoint is set.
+	PathStyle bool
+
+	AccessKey    string
+	SecretKey    string
+	SessionToken string
+}
+
+type s3Backend struct {
+	bucket string
+	prefix string // "" or ends with "/"
+	base   *url.URL
+	path   bool
+	region string
+	creds  credentials
+	client *http.Client
+}
+
+func newS3(location string, opts S3Options) (*s3Backend, error) {
+	rest := strings.TrimPrefix(location, "s3://")
+	bucket, prefix, _ := strings.Cut(rest, "/")
+	if bucket == "" {
+		return nil, fmt.Errorf("invalid S3 location %q: missing bucket", location)
+	}
+	prefix = strings.Trim(prefix, "/")
+	if prefix != "" {
+		prefix += "/"
+	}
+
+	region := firstNonEmpty(opts.Region, os.Getenv("AWS_REGION"), os.Getenv("AWS_DEFAULT_REGION"), "us-east-1")
+	creds := credentials{
+		AccessKey:    firstNonEmpty(opts.AccessKey, os.Getenv("AWS_ACCESS_KEY_ID")),
+		SecretKey:    firstNonEmpty(opts.SecretKey, os.Getenv("AWS_SECRET_ACCESS_KEY")),
+		SessionToken: firstNonEmpty(opts.SessionToken, os.Getenv("AWS_SESSION_TOKEN")),
+	}
+	if creds.AccessKey == "" || creds.SecretKey == "" {
+		return nil, fmt.Errorf("S3 credentials missing: set AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY")
+	}
+
+	endpoint := opts.Endpoint
+	pathStyle := opts.PathStyle || endpoint != ""
+	if endpoint == "" {
+		endpoint = "https://s3." + region + ".amazonaws.com"
+	}
+	base, err := url.Parse(endpoint)
+	if err != nil || base.Host == "" {
+		return nil, fmt.Errorf("invalid S3 endpoint %q", endpoint)
+	}
+	if !pathStyle {
+		base.Host = bucket + "." + base.Host
+	}
+
+	return &s3Backend{
+		bucket: bucket,
+		prefix: prefix,
+		base:   base,
+		path:   pathStyle,
+		region: region,
+		creds:  creds,
+		client: &http.Client{Timeout: 5 * time.Minute},
+	}, nil
+}
+
+func (s *s3Backend) URL(key string) string {
+	return "s3://" + s.bucket + "/" + s.prefix + key
+}
+
+// objectURL builds the request URL for a full object key ("" addresses the bucket).
+func (s *s3Backend) objectURL(fullKey string, query url.Values) *url.URL {
+	u := *s.base
+	p := strings.TrimSuffix(u.Path, "/")
+	if s.path {
+		p += "/" + s.bucket
+	}
+	u.Path = p + "/" + fullKey
+	// Send exactly the encoding that is signed
+	segments := strings.Split(u.Path, "/")
+	for i, seg := range segments {
+		segments[i] = awsEscape(seg)
+	}
+	u.RawPath = strings.Join(segments, "/")
+	u.RawQuery = query.Encode()
+	return &u
+}
+
+func (s *s3Backend) do(ctx context.Context, method string, u *url.URL, body io.Reader, size int64, payloadHash string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.ContentLength = size
+	}
+	signV4(req, payloadHash, s.creds, s.region, "s3", time.Now())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		return resp, s3Error(resp)
+	}
+	return resp, nil
+}
+
+func s3Error(resp *http.Response) error {
+	var e struct {
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if xml.Unmarshal(data, &e) == nil && e.Code != "" {
+		return fmt.Errorf("s3: %s: %s (status %d)", e.Code, e.Message, resp.StatusCode)
+	}
+	return fmt.Errorf("s3: unexpected status %d", resp.StatusCode)
+}
+
+type listBucketResult struct {
+	Contents []struct {
+		Key          string    `xml:"Key"`
+		Size         int64     `xml:"Size"`
+		LastModified time.Time `xml:"LastModified"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+func (s *s3Backend) List(ctx context.Context) ([]Object, error) {
+	var objects []Object
+	token := ""
+	for {
+		q := url.Values{"list-type": {"2"}}
+		if s.prefix != "" {
+			q.Set("prefix", s.prefix)
+		}
+		if token != "" {
+			q.Set("continuation-token", token)
+		}
+
+		resp, err := s.do(ctx, "GET", s.objectURL("", q), nil, 0, emptyPayloadHash)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s: %w", s.URL(""), err)
+		}
+		var page listBucketResult
+		err = xml.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse listing: %w", err)
+		}
+
+		for _, c := range page.Contents {
+			key := strings.TrimPrefix(c.Key, s.prefix)
+			// Skip "folder" placeholder objects
+			if key == "" || strings.HasSuffix(key, "/") {
+				continue
+			}
+			objects = append(objects, Object{Key: key, Size: c.Size, ModTime: c.LastModified})
+		}
+
+		if !page.IsTruncated || page.NextContinuationToken == "" {
+			return objects, nil
+		}
+		token = page.NextContinuationToken
+	}
+}
+
+func (s *s3Backend) Download(ctx context.Context, key string, w io.Writer) error {
+	resp, err := s.do(ctx, "GET", s.objectURL(s.prefix+key, nil), nil, 0, emptyPayloadHash)
+	if err != nil {
+		return fmt.Errorf("failed to download %s: %w", s.URL(key), err)
+	}
+	defer resp.Body.Close()
+	_, err = io.Copy(w, resp.Body)
+	return err
+}
+
+func (s *s3Backend) Upload(ctx context.Context, key string, localPath string) error {
+	f, err := os.Open(localPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	size, err := io.Copy(h, f)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	resp, err := s.do(ctx, "PUT", s.objectURL(s.prefix+key, nil), f, size, hex.EncodeToString(h.Sum(nil)))
+	if err != nil {
+		return fmt.Errorf("failed to upload %s: %w", s.URL(key), err)
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (s *s3Backend) Exists(ctx context.Context, key string) (bool, error) {
+	resp, err := s.do(ctx, "HEAD", s.objectURL(s.prefix+key, nil), nil, 0, emptyPayloadHash)
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return false, nil
+		}
+		return false, err
+	}
+	resp.Body.Close()
+	return true, nil
+}
+
+func (s *s3Backend) Delete(ctx context.Context, key string) error {
+	resp, err := s.do(ctx, "DELETE", s.objectURL(s.prefix+key, nil), nil, 0, emptyPayloadHash)
+	if err != nil {
+		return fmt.Errorf("failed to delete %s: %w", s.URL(key), err)
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,174 @@
+package remote
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeS3 is a minimal path-style S3 server backed by a map.
+type fakeS3 struct {
+	mu      sync.Mutex
+	bucket  string
+	objects map[string][]byte
+	pageLen int
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=test/") {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	key, ok := strings.CutPrefix(r.URL.Path, "/"+f.bucket+"/")
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch {
+	case r.Method == "GET" && key == "":
+		f.list(w, r)
+	case r.Method == "GET" || r.Method == "HEAD":
+		data, ok := f.objects[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, "<Error><Code>NoSuchKey</Code><Message>missing</Message></Error>")
+			return
+		}
+		if r.Method == "GET" {
+			w.Write(data)
+		}
+	case r.Method == "PUT":
+		data, _ := io.ReadAll(r.Body)
+		f.objects[key] = data
+	case r.Method == "DELETE":
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (f *fakeS3) list(w http.ResponseWriter, r *http.Request) {
+	prefix := r.URL.Query().Get("prefix")
+	var keys []string
+	for k := range f.objects {
+		if strings.HasPrefix(k, prefix) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	start := 0
+	if token := r.URL.Query().Get("continuation-token"); token != "" {
+		fmt.Sscanf(token, "%d", &start)
+	}
+	end := start + f.pageLen
+	if end > len(keys) {
+		end = len(keys)
+	}
+
+	var out listBucketResult
+	for _, k := range keys[start:end] {
+		out.Contents = append(out.Contents, struct {
+			Key          string    `xml:"Key"`
+			Size         int64     `xml:"Size"`
+			LastModified time.Time `xml:"LastModified"`
+		}{Key: k, Size: int64(len(f.objects[k]))})
+	}
+	if end < len(keys) {
+		out.IsTruncated = true
+		out.NextContinuationToken = fmt.Sprint(end)
+	}
+	xml.NewEncoder(w).Encode(out)
+}
+
+func newFakeS3(t *testing.T, objects map[string][]byte) (*fakeS3, Options) {
+	t.Helper()
+	fake := &fakeS3{bucket: "docs", objects: objects, pageLen: 2}
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+	return fake, Options{S3: S3Options{Endpoint: srv.URL, AccessKey: "test", SecretKey: "secret"}}
+}
+
+func TestS3Backend(t *testing.T) {
+	fake, opts := newFakeS3(t, map[string][]byte{
+		"inbox/scan 1.pdf":  []byte("one"),
+		"inbox/a/b.txt":     []byte("two"),
+		"inbox/folder/":     nil,
+		"inbox/notes.md":    []byte("three"),
+		"archive/other.pdf": []byte("x"),
+	})
+
+	b, err := Open("s3://docs/inbox", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	objects, err := b.List(ctx)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	var keys []string
+	for _, o := range objects {
+		keys = append(keys, o.Key)
+	}
+	if got := strings.Join(keys, ","); got != "a/b.txt,notes.md,scan 1.pdf" {
+		t.Errorf("List keys = %s", got)
+	}
+
+	local, err := Fetch(ctx, b, "scan 1.pdf")
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	defer os.Remove(local)
+	if data, _ := os.ReadFile(local); string(data) != "one" || filepath.Ext(local) != ".pdf" {
+		t.Errorf("Fetch wrote %q to %s", data, local)
+	}
+
+	// First upload lands at the key, the second collides and gets a hash suffix
+	for i, want := range []string{"Finance/Invoice.pdf", "Finance/Invoice_abcdef12.pdf"} {
+		got, err := Put(ctx, b, local, "Finance/Invoice.pdf", "abcdef1234")
+		if err != nil {
+			t.Fatalf("Put %d: %v", i, err)
+		}
+		if got != want {
+			t.Errorf("Put %d key = %q, want %q", i, got, want)
+		}
+	}
+	if !bytes.Equal(fake.objects["inbox/Finance/Invoice.pdf"], []byte("one")) {
+		t.Error("uploaded content missing")
+	}
+
+	if err := b.Delete(ctx, "scan 1.pdf"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if exists, err := b.Exists(ctx, "scan 1.pdf"); err != nil || exists {
+		t.Errorf("Exists after delete = %v, %v", exists, err)
+	}
+	if got := b.URL("a/b.txt"); got != "s3://docs/inbox/a/b.txt" {
+		t.Errorf("URL = %q", got)
+	}
+}
+
+func TestOpen(t *testing.T) {
+	if b, err := Open("/local/path", Options{}); b != nil || err != nil {
+		t.Errorf("local path should not open a backend: %v, %v", b, err)
+	}
+	if _, err := Open("s3://", Options{S3: S3Options{AccessKey: "a", SecretKey: "b"}}); err == nil {
+		t.Error("expected an error for a missing bucket")
+	}
+}
@@ -0,0 +1,189 @@
+// Package script runs the user's routing script, written in Starlark (a small, sandboxed
+// dialect of Python), to override or veto the decision for each document with logic too
+// specific for the configuration. The script defines route(doc), which receives the
+// document's file details, extracted text, and classification, and returns None to keep
+// the decision or a dict with any of "category", "title", and "veto".
+package script
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+	"go.starlark.net/syntax"
+)
+
+// maxSteps bounds the work of one route call, so a runaway loop fails the call instead
+// of stalling a worker.
+const maxSteps = 50_000_000
+
+// fileOptions allow the statements a Python user expects.
+var fileOptions = &syntax.FileOptions{Set: true, While: true, TopLevelControl: true, GlobalReassign: true}
+
+// Document is what route(doc) receives, as a struct with these fields in snake_case.
+type Document struct {
+	Name         string   // original file name
+	Path         string   // local path of the file being processed
+	Dir          string   // folder relative to the source root, "" at the top
+	Size         int64    // in bytes
+	Text         string   // extracted text; "" when classified from file details alone
+	Category     string   // the classifier's category
+	Title        string   // the file name it chose, with extension
+	Confidence   float64  // its confidence
+	Model        string   // the model, "heuristic", or "plugin:<name>"
+	DocumentDate string   // YYYY-MM-DD, if found
+	Tags         []string // when requested
+	Summary      string   // when requested
+	Fallback     bool     // classification failed and the file falls back to Misc
+}
+
+// Decision is the script's answer: a new category and/or title ("" keeps it), or a veto,
+// which leaves the file in the source.
+type Decision struct {
+	Category string
+	Title    string
+	Veto     bool
+	Reason   string // why the file was vetoed, if the script said
+}
+
+// Script is a loaded routing script. It is safe for concurrent use.
+type Script struct {
+	name  string
+	route starlark.Callable
+}
+
+// Load reads and runs the script at path and checks that it defines route(doc).
+func Load(path string) (*Script, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read routing script: %w", err)
+	}
+	s := &Script{name: filepath.Base(path)}
+	thread := s.thread()
+	globals, err := starlark.ExecFileOptions(fileOptions, thread, path, src, builtins)
+	if err != nil {
+		return nil, fmt.Errorf("routing script: %w", describe(err)) // the error names the file
+	}
+	fn, ok := globals["route"].(*starlark.Function)
+	if !ok || fn.NumParams() != 1 {
+		return nil, fmt.Errorf("routing script %s must define route(doc)", path)
+	}
+	globals.Freeze()
+	s.route = fn
+	return s, nil
+}
+
+// Route calls route(doc) for doc.
+func (s *Script) Route(ctx context.Context, doc Document) (Decision, error) {
+	thread := s.thread()
+	thread.SetMaxExecutionSteps(maxSteps)
+	stop := context.AfterFunc(ctx, func() { thread.Cancel(ctx.Err().Error()) })
+	defer stop()
+
+	result, err := starlark.Call(thread, s.route, starlark.Tuple{doc.value()}, nil)
+	if err != nil {
+		return Decision{}, describe(err)
+	}
+	return decode(result)
+}
+
+// thread returns a thread whose print() goes to the log.
+func (s *Script) thread() *starlark.Thread {
+	return &starlark.Thread{
+		Name:  s.name,
+		Print: func(_ *starlark.Thread, msg string) { log.Printf("[*] %s: %s", s.name, msg) },
+	}
+}
+
+// value converts doc to the struct route receives.
+func (doc Document) value() starlark.Value {
+	tags := make([]starlark.Value, len(doc.Tags))
+	for i, tag := range doc.Tags {
+		tags[i] = starlark.String(tag)
+	}
+	return starlarkstruct.FromStringDict(starlarkstruct.Default, starlark.StringDict{
+		"name":          starlark.String(doc.Name),
+		"path":          starlark.String(doc.Path),
+		"dir":           starlark.String(doc.Dir),
+		"ext":           starlark.String(filepath.Ext(doc.Name)),
+		"size":          starlark.MakeInt64(doc.Size),
+		"text":          starlark.String(doc.Text),
+		"category":      starlark.String(doc.Category),
+		"title":         starlark.String(doc.Title),
+		"confidence":    starlark.Float(doc.Confidence),
+		"model":         starlark.String(doc.Model),
+		"document_date": starlark.String(doc.DocumentDate),
+		"tags":          starlark.Tuple(tags),
+		"summary":       starlark.String(doc.Summary),
+		"fallback":      starlark.Bool(doc.Fallback),
+	})
+}
+
+// decode converts route's return value into a Decision.
+func decode(v starlark.Value) (Decision, error) {
+	var d Decision
+	if v == starlark.None {
+		return d, nil
+	}
+	dict, ok := v.(*starlark.Dict)
+	if !ok {
+		return d, fmt.Errorf("route returned %s; want None or a dict", v.Type())
+	}
+	for _, item := range dict.Items() {
+		key, _ := starlark.AsString(item[0])
+		switch key {
+		case "category", "title":
+			s, ok := starlark.AsString(item[1])
+			if !ok {
+				return d, fmt.Errorf("route returned %s for %q; want a string", item[1].Type(), key)
+			}
+			if key == "category" {
+				d.Category = s
+			} else {
+				d.Title = s
+			}
+		case "veto":
+			// True, or the reason as a string
+			if reason, ok := starlark.AsString(item[1]); ok {
+				d.Veto, d.Reason = reason != "", reason
+			} else {
+				d.Veto = bool(item[1].Truth())
+			}
+		default:
+			return d, fmt.Errorf("route returned unknown key %s (want category, title, or veto)", item[0])
+		}
+	}
+	return d, nil
+}
+
+// builtins are predeclared for the script besides Starlark's own.
+var builtins = starlark.StringDict{
+	"matches": starlark.NewBuiltin("matches", matches),
+}
+
+// matches(pattern, s) reports whether the regular expression pattern (Go syntax) matches
+// anywhere in s, since Starlark has no re module.
+func matches(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var pattern, s string
+	if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 2, &pattern, &s); err != nil {
+		return nil, err
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", b.Name(), err)
+	}
+	return starlark.Bool(re.MatchString(s)), nil
+}
+
+// describe adds the Starlark backtrace to evaluation errors, so they point at the line.
+func describe(err error) error {
+	if evalErr, ok := err.(*starlark.EvalError); ok {
+		return fmt.Errorf("%s", evalErr.Backtrace())
+	}
+	return err
+}
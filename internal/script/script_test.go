@@ -0,0 +1,120 @@
+package script
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeScript(t *testing.T, src string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "route.star")
+	if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		wantErr string
+	}{
+		{name: "valid", src: "def route(doc):\n    return None\n"},
+		{name: "syntax error", src: "def route(doc)\n    return None\n", wantErr: "route.star:2:1"},
+		{name: "no route", src: "x = 1\n", wantErr: "must define route(doc)"},
+		{name: "wrong arity", src: "def route():\n    return None\n", wantErr: "must define route(doc)"},
+		{name: "top-level error", src: "x = 1 // 0\ndef route(doc):\n    return None\n", wantErr: "division by zero"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(writeScript(t, tt.src))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Load: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Load error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.star")); err == nil {
+		t.Error("Load of a missing file should fail")
+	}
+}
+
+func TestRoute(t *testing.T) {
+	s, err := Load(writeScript(t, `
+def route(doc):
+    if doc.dir.startswith("Pending"):
+        return {"veto": "pending review"}
+    if doc.category == "Finance" and matches(r"(?i)joint account", doc.text):
+        return {"category": "Finance/Joint", "title": "Joint " + doc.title}
+    if doc.fallback and doc.ext == ".eml" and "mail" in doc.tags:
+        return {"category": "Mail"}
+    if doc.size > 1000:
+        return {"veto": True}
+    if doc.name == "bad.txt":
+        return {"folder": "x"}
+    if doc.name == "crash.txt":
+        return doc.missing
+    return None
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		doc     Document
+		want    Decision
+		wantErr string
+	}{
+		{name: "keep", doc: Document{Name: "a.txt", Category: "Work"}},
+		{name: "veto with reason", doc: Document{Name: "a.txt", Dir: "Pending/2024"}, want: Decision{Veto: true, Reason: "pending review"}},
+		{name: "veto", doc: Document{Name: "a.txt", Size: 2000}, want: Decision{Veto: true}},
+		{name: "override", doc: Document{Name: "s.pdf", Category: "Finance", Title: "Statement.pdf", Text: "Joint Account 1234"},
+			want: Decision{Category: "Finance/Joint", Title: "Joint Statement.pdf"}},
+		{name: "fallback", doc: Document{Name: "m.eml", Fallback: true, Tags: []string{"mail"}}, want: Decision{Category: "Mail"}},
+		{name: "unknown key", doc: Document{Name: "bad.txt"}, wantErr: "unknown key"},
+		{name: "runtime error", doc: Document{Name: "crash.txt"}, wantErr: "route.star:14"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.Route(context.Background(), tt.doc)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Route error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Route: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Route = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRoute_Cancelled(t *testing.T) {
+	s, err := Load(writeScript(t, "def route(doc):\n    while True:\n        pass\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := s.Route(ctx, Document{}); err == nil {
+		t.Error("Route of an endless loop should fail")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Route took %s after cancellation", elapsed)
+	}
+}
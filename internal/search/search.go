@@ -0,0 +1,171 @@
+// Package search finds organised files, by the words of their names or, through the
+// embeddings kept in the store, by what they are about.
+package search
+
+import (
+	"docs_organiser/internal/storage"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"math"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// keyPrefix marks the index entries in the store.
+const keyPrefix = "semantic:"
+
+// Entry is an organised file in the index.
+type Entry struct {
+	Path     string    `json:"path"`
+	Category string    `json:"category"`
+	Title    string    `json:"title"`
+	Model    string    `json:"model"` // embedding model the vector is from
+	Vector   []float32 `json:"vector"`
+	Indexed  time.Time `json:"indexed"`
+}
+
+// Match is a search result; Score is the cosine similarity for semantic search and the
+// share of query words found for name search.
+type Match struct {
+	Path     string  `json:"path"`
+	Category string  `json:"category,omitempty"`
+	Score    float64 `json:"score"`
+}
+
+// Index keeps the embedding of every organised file in a store, keyed by its path.
+type Index struct {
+	store storage.Store
+}
+
+// NewIndex returns the index kept in store.
+func NewIndex(store storage.Store) *Index {
+	return &Index{store: store}
+}
+
+// Add stores entry, replacing an earlier one for the same path.
+func (ix *Index) Add(entry Entry) error {
+	return ix.store.Save(keyPrefix+entry.Path, entry)
+}
+
+// Remove drops the entry of path.
+func (ix *Index) Remove(path string) error {
+	return ix.store.Delete(keyPrefix + path)
+}
+
+// Search returns the limit entries embedded with model whose vectors are the most
+// similar to query, best first.
+func (ix *Index) Search(model string, query []float64, limit int) ([]Match, error) {
+	q := make([]float32, len(query))
+	for i, x := range query {
+		q[i] = float32(x)
+	}
+	var matches []Match
+	err := ix.store.Scan(keyPrefix, func(key string, value []byte) error {
+		var entry Entry
+		if err := json.Unmarshal(value, &entry); err != nil {
+			return fmt.Errorf("corrupt index entry %s: %w", strings.TrimPrefix(key, keyPrefix), err)
+		}
+		// Vectors of other models don't compare
+		if entry.Model != model || len(entry.Vector) != len(q) {
+			return nil
+		}
+		matches = append(matches, Match{Path: entry.Path, Category: entry.Category, Score: cosine(q, entry.Vector)})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return best(matches, limit), nil
+}
+
+// Count returns the number of entries embedded with model, and of all entries.
+func (ix *Index) Count(model string) (matching, total int, err error) {
+	err = ix.store.Scan(keyPrefix, func(_ string, value []byte) error {
+		var entry struct {
+			Model string `json:"model"`
+		}
+		if json.Unmarshal(value, &entry) == nil && entry.Model == model {
+			matching++
+		}
+		total++
+		return nil
+	})
+	return matching, total, err
+}
+
+// Names returns the limit files under dir whose paths, relative to dir, contain the most
+// of the words of query, ignoring case. Hidden files and folders are skipped.
+func Names(dir, query string, limit int) ([]Match, error) {
+	words := strings.Fields(strings.ToLower(query))
+	if len(words) == 0 {
+		return nil, nil
+	}
+	var matches []Match
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path != dir && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		name := strings.ToLower(filepath.ToSlash(rel))
+		found := 0
+		for _, w := range words {
+			if strings.Contains(name, w) {
+				found++
+			}
+		}
+		if found > 0 {
+			matches = append(matches, Match{Path: path, Category: filepath.ToSlash(filepath.Dir(rel)), Score: float64(found) / float64(len(words))})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return best(matches, limit), nil
+}
+
+// best sorts matches by score, then path, and keeps the first limit (all if limit <= 0).
+func best(matches []Match, limit int) []Match {
+	slices.SortFunc(matches, func(a, b Match) int {
+		if a.Score != b.Score {
+			if a.Score > b.Score {
+				return -1
+			}
+			return 1
+		}
+		return strings.Compare(a.Path, b.Path)
+	})
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
+
+// cosine returns the cosine similarity of a and b, or 0 if either is zero.
+func cosine(a, b []float32) float64 {
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / math.Sqrt(na*nb)
+}
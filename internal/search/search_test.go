@@ -0,0 +1,76 @@
+package search
+
+import (
+	"docs_organiser/internal/storage"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIndex(t *testing.T) {
+	store, err := storage.NewBadgerStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	ix := NewIndex(store)
+
+	for _, e := range []Entry{
+		{Path: "/dst/Housing/Rental_Agreement.pdf", Category: "Housing", Model: "embed", Vector: []float32{1, 0.1, 0}},
+		{Path: "/dst/Finance/Invoice.pdf", Category: "Finance", Model: "embed", Vector: []float32{0, 1, 0}},
+		{Path: "/dst/Housing/Lease_2021.pdf", Category: "Housing", Model: "embed", Vector: []float32{1, 0.5, 0}},
+		{Path: "/dst/Other/Model.pdf", Model: "other", Vector: []float32{1, 0, 0}},
+		{Path: "/dst/Other/Short.pdf", Model: "embed", Vector: []float32{1, 0}},
+	} {
+		if err := ix.Add(e); err != nil {
+			t.Fatalf("Add: %v", err)
+		}
+	}
+	if err := ix.Remove("/dst/Finance/Invoice.pdf"); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+
+	matches, err := ix.Search("embed", []float64{1, 0, 0}, 5)
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(matches) != 2 || matches[0].Path != "/dst/Housing/Rental_Agreement.pdf" || matches[1].Path != "/dst/Housing/Lease_2021.pdf" {
+		t.Fatalf("Search = %+v, want the rental agreement, then the lease", matches)
+	}
+	if matches[0].Score <= matches[1].Score || matches[0].Score > 1 {
+		t.Errorf("scores = %v, %v; want descending cosine similarities", matches[0].Score, matches[1].Score)
+	}
+	if matches, _ := ix.Search("embed", []float64{1, 0, 0}, 1); len(matches) != 1 {
+		t.Errorf("Search with limit 1 returned %d matches", len(matches))
+	}
+
+	matching, total, err := ix.Count("embed")
+	if err != nil || matching != 3 || total != 4 {
+		t.Errorf("Count = %d, %d, %v; want 3, 4", matching, total, err)
+	}
+}
+
+func TestNames(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"Housing/Rental_Agreement_2022.pdf", "Housing/Rental_Receipt.pdf", "Finance/Invoice_2022.pdf", ".trash/Rental_2022.pdf"} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	matches, err := Names(dir, "rental 2022", 10)
+	if err != nil {
+		t.Fatalf("Names: %v", err)
+	}
+	var got []string
+	for _, m := range matches {
+		got = append(got, filepath.ToSlash(m.Path[len(dir)+1:]))
+	}
+	want := []string{"Housing/Rental_Agreement_2022.pdf", "Finance/Invoice_2022.pdf", "Housing/Rental_Receipt.pdf"}
+	if len(got) != len(want) || got[0] != want[0] || matches[0].Score != 1 || matches[0].Category != "Housing" {
+		t.Errorf("Names = %v (%+v), want %v first with score 1", got, matches, want)
+	}
+}
@@ -0,0 +1,155 @@
+// Package simhash fingerprints document text so that near-duplicates, such as rescans
+// of the same page or two versions of a contract, can be found by comparing 64 bits.
+package simhash
+
+import (
+	"docs_organiser/internal/storage"
+	"encoding/json"
+	"fmt"
+	"math/bits"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/cespare/xxhash/v2"
+)
+
+// MinWords is the number of words a text needs for a fingerprint; shorter texts share too
+// few phrases for their fingerprints to mean anything.
+const MinWords = 20
+
+// shingleWords is the length of the word sequences the fingerprint is built from.
+const shingleWords = 3
+
+// keyPrefix marks the fingerprints in the store.
+const keyPrefix = "simhash:"
+
+// Fingerprint returns the simhash of text over its lowercased three-word sequences, and
+// false when text has fewer than MinWords words. Texts that share most of their phrases
+// get fingerprints differing in few bits, whatever their punctuation and spacing.
+func Fingerprint(text string) (uint64, bool) {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(words) < MinWords {
+		return 0, false
+	}
+	var votes [64]int
+	for i := 0; i+shingleWords <= len(words); i++ {
+		h := xxhash.Sum64String(strings.Join(words[i:i+shingleWords], " "))
+		for bit := range votes {
+			if h&(1<<bit) != 0 {
+				votes[bit]++
+			} else {
+				votes[bit]--
+			}
+		}
+	}
+	var fingerprint uint64
+	for bit, v := range votes {
+		if v > 0 {
+			fingerprint |= 1 << bit
+		}
+	}
+	return fingerprint, true
+}
+
+// Distance returns the number of bits in which a and b differ, from 0 (the same
+// phrases) to 64.
+func Distance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// Entry is the fingerprint of an organised file.
+type Entry struct {
+	Path string `json:"path"`
+	// Category is the folder the file was filed into.
+	Category    string `json:"category"`
+	Fingerprint uint64 `json:"fingerprint"`
+}
+
+// Index keeps the fingerprint of every organised file in a store, keyed by its path. The
+// entries are read from the store on first use and then kept in memory. It is safe for
+// concurrent use.
+type Index struct {
+	store   storage.Store
+	mu      sync.Mutex
+	entries map[string]Entry
+}
+
+// NewIndex returns the index kept in store.
+func NewIndex(store storage.Store) *Index {
+	return &Index{store: store}
+}
+
+// Add stores entry, replacing an earlier one for the same path.
+func (ix *Index) Add(entry Entry) error {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	if err := ix.load(); err != nil {
+		return err
+	}
+	if err := ix.store.Save(keyPrefix+entry.Path, entry); err != nil {
+		return err
+	}
+	ix.entries[entry.Path] = entry
+	return nil
+}
+
+// Remove drops the entry of path.
+func (ix *Index) Remove(path string) error {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	if err := ix.load(); err != nil {
+		return err
+	}
+	if err := ix.store.Delete(keyPrefix + path); err != nil {
+		return err
+	}
+	delete(ix.entries, path)
+	return nil
+}
+
+// Nearest returns the entry whose fingerprint differs from fingerprint in the fewest bits,
+// at most maxDistance, and that distance; ties go to the first path in sort order. It
+// returns false when no entry is that close.
+func (ix *Index) Nearest(fingerprint uint64, maxDistance int) (Entry, int, bool, error) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	if err := ix.load(); err != nil {
+		return Entry{}, 0, false, err
+	}
+	var best Entry
+	bestDistance := -1
+	for _, e := range ix.entries {
+		d := Distance(fingerprint, e.Fingerprint)
+		if d > maxDistance {
+			continue
+		}
+		if bestDistance < 0 || d < bestDistance || (d == bestDistance && e.Path < best.Path) {
+			best, bestDistance = e, d
+		}
+	}
+	return best, bestDistance, bestDistance >= 0, nil
+}
+
+// load reads the entries from the store, once. ix.mu must be held.
+func (ix *Index) load() error {
+	if ix.entries != nil {
+		return nil
+	}
+	entries := make(map[string]Entry)
+	err := ix.store.Scan(keyPrefix, func(key string, value []byte) error {
+		var e Entry
+		if err := json.Unmarshal(value, &e); err != nil {
+			return fmt.Errorf("corrupt fingerprint %s: %w", strings.TrimPrefix(key, keyPrefix), err)
+		}
+		entries[e.Path] = e
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	ix.entries = entries
+	return nil
+}
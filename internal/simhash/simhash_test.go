@@ -0,0 +1,78 @@
+package simhash
+
+import (
+	"docs_organiser/internal/storage"
+	"strings"
+	"testing"
+)
+
+const contract = `This tenancy agreement is made between Jane Smith, the landlord, and John Doe, the tenant,
+for the property at 12 Elm Street. The tenant agrees to pay a monthly rent of 1200 EUR on the
+first day of each month, and a deposit of two months' rent before moving in. The tenancy starts
+on 1 March 2022 and runs for twelve months, after which it continues from month to month until
+either party gives two months' written notice. The tenant keeps the property clean and in good
+repair, and does not sublet any part of it without the landlord's written consent.`
+
+const invoice = `Invoice 2023-0042 from ACME Supplies Ltd to Example Corp for office furniture delivered on
+14 June 2023: four standing desks, four ergonomic chairs, and two filing cabinets. Subtotal
+3400 EUR, VAT 646 EUR, total due 4046 EUR within thirty days by bank transfer to the account
+listed below. Please quote the invoice number with your payment.`
+
+func TestFingerprint(t *testing.T) {
+	original, ok := Fingerprint(contract)
+	if !ok {
+		t.Fatal("Fingerprint(contract) = false, want a fingerprint")
+	}
+	// A rescan: different line breaks and case, an OCR slip, and the rent changed
+	rescan := strings.NewReplacer("\n", " ", "Elm", "ELM", "deposit", "dep0sit", "1200", "1250").Replace(contract)
+	near, _ := Fingerprint(rescan)
+	other, _ := Fingerprint(invoice)
+
+	if d := Distance(original, near); d > 6 {
+		t.Errorf("Distance(contract, rescan) = %d, want at most 6", d)
+	}
+	if d := Distance(original, other); d <= 20 {
+		t.Errorf("Distance(contract, invoice) = %d, want more than 20", d)
+	}
+	if again, _ := Fingerprint(contract); again != original {
+		t.Errorf("Fingerprint is not deterministic: %x, then %x", original, again)
+	}
+	if _, ok := Fingerprint("Receipt: 3 coffees, 9.60 EUR"); ok {
+		t.Error("Fingerprint of a short text = true, want false")
+	}
+}
+
+func TestIndex(t *testing.T) {
+	store, err := storage.NewBadgerStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	ix := NewIndex(store)
+	for _, e := range []Entry{
+		{Path: "/dst/Housing/Lease.pdf", Category: "Housing", Fingerprint: 0b1111},
+		{Path: "/dst/Finance/Invoice.pdf", Category: "Finance", Fingerprint: 0b1111 << 32},
+		{Path: "/dst/Housing/Lease_Old.pdf", Category: "Housing", Fingerprint: 0b0111},
+	} {
+		if err := ix.Add(e); err != nil {
+			t.Fatalf("Add: %v", err)
+		}
+	}
+	if err := ix.Remove("/dst/Housing/Lease.pdf"); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+
+	// A fresh index reads what the first one stored
+	ix = NewIndex(store)
+	e, d, ok, err := ix.Nearest(0b1111, 3)
+	if err != nil {
+		t.Fatalf("Nearest: %v", err)
+	}
+	if !ok || e.Path != "/dst/Housing/Lease_Old.pdf" || e.Category != "Housing" || d != 1 {
+		t.Errorf("Nearest = %+v, %d, %v; want Lease_Old.pdf at distance 1", e, d, ok)
+	}
+	if _, _, ok, _ := ix.Nearest(0b1111<<16, 3); ok {
+		t.Error("Nearest found an entry more than 3 bits away")
+	}
+}
@@ -0,0 +1,158 @@
+// Package stats reports what an organised destination holds, category by category.
+package stats
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// rootCategory names the files directly in the destination, outside any category.
+const rootCategory = "(root)"
+
+// sidecarExts are the extensions of the sidecars written next to organised files.
+var sidecarExts = []string{".json", ".yaml"}
+
+// Document is one file of a category.
+type Document struct {
+	Name    string    `json:"name"`
+	ModTime time.Time `json:"mod_time"`
+}
+
+// CategoryStats describes one category folder. Files and Bytes count the files directly
+// in it, not those of its sub-categories; Oldest and Newest are by modification time and
+// unset for an empty folder.
+type CategoryStats struct {
+	Category string    `json:"category"`
+	Files    int       `json:"files"`
+	Bytes    int64     `json:"bytes"`
+	Oldest   *Document `json:"oldest,omitempty"`
+	Newest   *Document `json:"newest,omitempty"`
+}
+
+// Report summarizes a destination.
+type Report struct {
+	Dir        string          `json:"dir"`
+	Files      int             `json:"files"`
+	Bytes      int64           `json:"bytes"`
+	Categories []CategoryStats `json:"categories"`
+}
+
+// Collect walks the destination dir and counts the files of every category folder,
+// nested ones included. Hidden files and folders, and sidecars next to the files they
+// describe, are not counted.
+func Collect(dir string) (Report, error) {
+	report := Report{Dir: dir}
+	var walk func(path, category string) error
+	walk = func(path, category string) error {
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return err
+		}
+		names := make(map[string]bool, len(entries))
+		for _, e := range entries {
+			names[e.Name()] = true
+		}
+
+		stats := CategoryStats{Category: category}
+		var subdirs []string
+		for _, e := range entries {
+			if strings.HasPrefix(e.Name(), ".") {
+				continue
+			}
+			if e.IsDir() {
+				subdirs = append(subdirs, e.Name())
+				continue
+			}
+			if !e.Type().IsRegular() || isSidecar(e.Name(), names) {
+				continue
+			}
+			info, err := e.Info()
+			if err != nil {
+				continue // removed since listing
+			}
+			stats.Files++
+			stats.Bytes += info.Size()
+			doc := &Document{Name: e.Name(), ModTime: info.ModTime()}
+			if stats.Oldest == nil || doc.ModTime.Before(stats.Oldest.ModTime) {
+				stats.Oldest = doc
+			}
+			if stats.Newest == nil || doc.ModTime.After(stats.Newest.ModTime) {
+				stats.Newest = doc
+			}
+		}
+		if category != rootCategory || stats.Files > 0 {
+			report.Categories = append(report.Categories, stats)
+			report.Files += stats.Files
+			report.Bytes += stats.Bytes
+		}
+
+		for _, sub := range subdirs {
+			child := sub
+			if category != rootCategory {
+				child = category + "/" + sub
+			}
+			if err := walk(filepath.Join(path, sub), child); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := walk(dir, rootCategory); err != nil {
+		return Report{}, err
+	}
+	sort.Slice(report.Categories, func(i, j int) bool { return report.Categories[i].Category < report.Categories[j].Category })
+	return report, nil
+}
+
+// isSidecar reports whether name is the sidecar of another file among names.
+func isSidecar(name string, names map[string]bool) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	for _, sidecar := range sidecarExts {
+		if ext == sidecar && names[strings.TrimSuffix(name, filepath.Ext(name))] {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteText prints the report as a table, one row per category. Empty folders are
+// marked, as candidates for removal.
+func (r Report) WriteText(w io.Writer) error {
+	fmt.Fprintf(w, "%s: %d files, %s in %d categories\n\n", r.Dir, r.Files, formatBytes(r.Bytes), len(r.Categories))
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "Category\tFiles\tSize\tOldest\tNewest")
+	for _, c := range r.Categories {
+		if c.Files == 0 {
+			fmt.Fprintf(tw, "%s\t0\t-\t(empty)\t\n", c.Category)
+			continue
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%s\n", c.Category, c.Files, formatBytes(c.Bytes), c.Oldest, c.Newest)
+	}
+	return tw.Flush()
+}
+
+// String formats the document as its date and name.
+func (d *Document) String() string {
+	return d.ModTime.Format(time.DateOnly) + " " + d.Name
+}
+
+// formatBytes formats n with a binary unit: 512 B, 1.5 KB, 2.0 GB.
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,86 @@
+package stats
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCollect(t *testing.T) {
+	dir := t.TempDir()
+	old := time.Date(2019, 5, 1, 12, 0, 0, 0, time.UTC)
+	files := map[string]string{
+		"Finance/Invoice.pdf":      "12345",
+		"Finance/Invoice.pdf.json": "{}",
+		"Finance/Receipt.pdf":      "123",
+		"Finance/Tax/Return.pdf":   "1234567",
+		"Finance/.DS_Store":        "x",
+		"loose.txt":                "1",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Chtimes(filepath.Join(dir, "Finance", "Receipt.pdf"), old, old); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "Archive"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, ".trash"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := Collect(dir)
+	if err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+	if report.Files != 4 || report.Bytes != 16 {
+		t.Errorf("totals = %d files, %d bytes; want 4 and 16", report.Files, report.Bytes)
+	}
+	var names []string
+	for _, c := range report.Categories {
+		names = append(names, c.Category)
+	}
+	if got := strings.Join(names, ","); got != "(root),Archive,Finance,Finance/Tax" {
+		t.Fatalf("categories = %s", got)
+	}
+
+	finance := report.Categories[2]
+	if finance.Files != 2 || finance.Bytes != 8 {
+		t.Errorf("Finance = %d files, %d bytes; want 2 and 8 (sidecar and hidden file excluded)", finance.Files, finance.Bytes)
+	}
+	if finance.Oldest.Name != "Receipt.pdf" || finance.Newest.Name != "Invoice.pdf" {
+		t.Errorf("Finance oldest/newest = %s/%s", finance.Oldest.Name, finance.Newest.Name)
+	}
+	if archive := report.Categories[1]; archive.Files != 0 || archive.Oldest != nil {
+		t.Errorf("Archive = %+v, want empty", archive)
+	}
+
+	var out bytes.Buffer
+	if err := report.WriteText(&out); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"4 files, 16 B in 4 categories", "(empty)", "2019-05-01 Receipt.pdf"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("report does not contain %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := map[int64]string{0: "0 B", 1023: "1023 B", 1536: "1.5 KB", 5 << 30: "5.0 GB"}
+	for n, want := range tests {
+		if got := formatBytes(n); got != want {
+			t.Errorf("formatBytes(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
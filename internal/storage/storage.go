@@ -11,6 +11,10 @@ import (
 type Store interface {
 	Save(key string, value interface{}) error
 	Load(key string, target interface{}) (bool, error)
+	// Scan calls fn with the key and JSON value of every entry whose key starts with
+	// prefix, in key order, until fn returns an error.
+	Scan(prefix string, fn func(key string, value []byte) error) error
+	Delete(key string) error
 	Close() error
 }
 
@@ -70,6 +74,32 @@ func (s *BadgerStore) Load(key string, target interface{}) (bool, error) {
 	return true, nil
 }
 
+func (s *BadgerStore) Scan(prefix string, fn func(key string, value []byte) error) error {
+	return s.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = []byte(prefix)
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		for it.Rewind(); it.Valid(); it.Next() {
+			item := it.Item()
+			value, err := item.ValueCopy(nil)
+			if err != nil {
+				return fmt.Errorf("failed to read %s: %w", item.Key(), err)
+			}
+			if err := fn(string(item.Key()), value); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *BadgerStore) Delete(key string) error {
+	return s.db.Update(func(txn *badger.Txn) error {
+		return txn.Delete([]byte(key))
+	})
+}
+
 func (s *BadgerStore) Close() error {
 	return s.db.Close()
 }
@@ -51,3 +51,30 @@ func TestBadgerStore(t *testing.T) {
 		t.Fatal("Key should not exist")
 	}
 }
+
+func TestBadgerStore_ScanDelete(t *testing.T) {
+	store, err := NewBadgerStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	for key, value := range map[string]int{"a:1": 1, "a:2": 2, "b:1": 3} {
+		if err := store.Save(key, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store.Delete("a:1"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	var keys []string
+	if err := store.Scan("a:", func(key string, value []byte) error {
+		keys = append(keys, key+"="+string(value))
+		return nil
+	}); err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+	if len(keys) != 1 || keys[0] != "a:2=2" {
+		t.Errorf("Scan = %v, want [a:2=2]", keys)
+	}
+}
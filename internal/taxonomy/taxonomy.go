@@ -0,0 +1,184 @@
+// Package taxonomy loads a hierarchical category tree, with descriptions and example
+// document types, from YAML.
+package taxonomy
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// Fallback is the category unclassifiable files go to; it is always allowed.
+const Fallback = "Misc"
+
+// Category is one folder in the tree. Prompt, TitlePattern, and RequireDate form the
+// category's profile: extra rules for documents filed there, inherited by sub-folders
+// that don't set their own.
+type Category struct {
+	Name         string     `yaml:"name"`
+	Description  string     `yaml:"description,omitempty"`
+	Examples     []string   `yaml:"examples,omitempty"`
+	Prompt       string     `yaml:"prompt,omitempty"`        // instructions added to the system prompt
+	TitlePattern string     `yaml:"title_pattern,omitempty"` // regular expression the title must match
+	RequireDate  bool       `yaml:"require_date,omitempty"`  // document_date becomes required
+	Children     []Category `yaml:"children,omitempty"`
+}
+
+// Taxonomy is the root of the tree.
+type Taxonomy struct {
+	Categories []Category `yaml:"categories"`
+}
+
+// Entry is a category flattened to its full path, e.g. "Finance/Taxes".
+type Entry struct {
+	Path        string
+	Depth       int // 0 for top-level categories
+	Description string
+	Examples    []string
+	// Profile, with unset fields inherited from the nearest ancestor
+	Prompt       string
+	TitlePattern string
+	RequireDate  bool
+}
+
+// HasProfile reports whether the entry adds rules beyond its description.
+func (e Entry) HasProfile() bool {
+	return e.Prompt != "" || e.TitlePattern != "" || e.RequireDate
+}
+
+// Load reads and validates the taxonomy at path. Unknown keys are rejected so that
+// typos ("childs", "example") don't silently drop part of the tree.
+func Load(path string) (*Taxonomy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	var t Taxonomy
+	if err := dec.Decode(&t); err != nil {
+		return nil, fmt.Errorf("failed to parse taxonomy %s: %w", path, err)
+	}
+	if err := t.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid taxonomy %s: %w", path, err)
+	}
+	return &t, nil
+}
+
+// Validate checks that every category has a usable, unique name among its siblings.
+func (t *Taxonomy) Validate() error {
+	if len(t.Categories) == 0 {
+		return fmt.Errorf("no categories defined")
+	}
+	return validate(t.Categories, "")
+}
+
+func validate(categories []Category, parent string) error {
+	seen := make(map[string]bool)
+	for _, c := range categories {
+		name := strings.TrimSpace(c.Name)
+		where := "top level"
+		if parent != "" {
+			where = parent
+		}
+		switch {
+		case name == "":
+			return fmt.Errorf("category without a name under %s", where)
+		case strings.ContainsAny(name, `/\`) || name == "." || name == "..":
+			return fmt.Errorf("category name %q under %s must be a single folder name", name, where)
+		case seen[strings.ToLower(name)]:
+			return fmt.Errorf("duplicate category %q under %s", name, where)
+		}
+		if c.TitlePattern != "" {
+			if _, err := regexp.Compile(c.TitlePattern); err != nil {
+				return fmt.Errorf("invalid title_pattern for %q: %w", join(parent, name), err)
+			}
+		}
+		seen[strings.ToLower(name)] = true
+		if err := validate(c.Children, join(parent, name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func join(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "/" + name
+}
+
+// Entries returns every category depth-first, parents before their children.
+func (t *Taxonomy) Entries() []Entry {
+	var out []Entry
+	var walk func([]Category, Entry)
+	walk = func(categories []Category, parent Entry) {
+		for _, c := range categories {
+			entry := Entry{
+				Path:         join(parent.Path, strings.TrimSpace(c.Name)),
+				Depth:        parent.Depth + 1,
+				Description:  strings.TrimSpace(c.Description),
+				Examples:     c.Examples,
+				Prompt:       strings.TrimSpace(c.Prompt),
+				TitlePattern: c.TitlePattern,
+				RequireDate:  c.RequireDate || parent.RequireDate,
+			}
+			if entry.Prompt == "" {
+				entry.Prompt = parent.Prompt
+			}
+			if entry.TitlePattern == "" {
+				entry.TitlePattern = parent.TitlePattern
+			}
+			out = append(out, entry)
+			walk(c.Children, entry)
+		}
+	}
+	walk(t.Categories, Entry{Depth: -1})
+	return out
+}
+
+// Paths returns the allowed category paths, including Fallback.
+func (t *Taxonomy) Paths() []string {
+	var paths []string
+	hasFallback := false
+	for _, e := range t.Entries() {
+		paths = append(paths, e.Path)
+		hasFallback = hasFallback || e.Path == Fallback
+	}
+	if !hasFallback {
+		paths = append(paths, Fallback)
+	}
+	return paths
+}
+
+// Resolve maps a category name from a model answer to its full path: an exact path,
+// the same path in different case, or a trailing part of exactly one path ("Taxes" or
+// "taxes" for "Finance/Taxes"). ok is false when the name is unknown or ambiguous.
+func (t *Taxonomy) Resolve(name string) (path string, ok bool) {
+	name = strings.Trim(strings.TrimSpace(name), "/")
+	if name == "" {
+		return "", false
+	}
+	var matches []string
+	for _, p := range t.Paths() {
+		switch {
+		case p == name:
+			return p, true
+		case strings.EqualFold(p, name), hasSuffixFold(p, "/"+name):
+			matches = append(matches, p)
+		}
+	}
+	if len(matches) != 1 {
+		return "", false
+	}
+	return matches[0], true
+}
+
+func hasSuffixFold(s, suffix string) bool {
+	return len(s) >= len(suffix) && strings.EqualFold(s[len(s)-len(suffix):], suffix)
+}
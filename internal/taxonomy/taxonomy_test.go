@@ -0,0 +1,124 @@
+package taxonomy
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const sample = `
+categories:
+  - name: Finance
+    description: Money in and out.
+    examples: [bank statements, invoices]
+    children:
+      - name: Taxes
+        examples: [tax returns]
+      - name: Insurance
+  - name: Personal
+    children:
+      - name: Taxes
+  - name: Receipts
+`
+
+func load(t *testing.T, content string) (*Taxonomy, error) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "taxonomy.yaml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return Load(path)
+}
+
+func TestLoad(t *testing.T) {
+	tax, err := load(t, sample)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	want := []string{"Finance", "Finance/Taxes", "Finance/Insurance", "Personal", "Personal/Taxes", "Receipts", "Misc"}
+	if got := tax.Paths(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Paths() = %v, want %v", got, want)
+	}
+	entries := tax.Entries()
+	if entries[1].Depth != 1 || entries[0].Description != "Money in and out." || len(entries[1].Examples) != 1 {
+		t.Errorf("unexpected entries: %+v", entries[:2])
+	}
+
+	invalid := []struct {
+		name, content, wantErr string
+	}{
+		{"empty", "categories: []", "no categories"},
+		{"unknown key", "categories:\n  - name: A\n    childs: []", "childs"},
+		{"missing name", "categories:\n  - description: x", "without a name"},
+		{"slash in name", "categories:\n  - name: A/B", "single folder name"},
+		{"duplicate sibling", "categories:\n  - name: A\n    children:\n      - name: B\n      - name: b", "duplicate category"},
+		{"invalid title pattern", "categories:\n  - name: A\n    children:\n      - name: B\n        title_pattern: '('", `title_pattern for "A/B"`},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := load(t, tt.content); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Load = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestEntries_Profiles(t *testing.T) {
+	tax, err := load(t, `
+categories:
+  - name: Receipts
+    prompt: Start the title with the vendor.
+    title_pattern: '_\d{4}-\d{2}-\d{2}$'
+    require_date: true
+    children:
+      - name: Online
+      - name: Fuel
+        prompt: Include the station.
+  - name: Work
+`)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	entries := tax.Entries()
+	receipts, online, fuel, work := entries[0], entries[1], entries[2], entries[3]
+	if !receipts.RequireDate || receipts.Prompt != "Start the title with the vendor." || receipts.TitlePattern == "" {
+		t.Errorf("Receipts = %+v", receipts)
+	}
+	if online.Prompt != receipts.Prompt || online.TitlePattern != receipts.TitlePattern || !online.RequireDate {
+		t.Errorf("Receipts/Online did not inherit the profile: %+v", online)
+	}
+	if fuel.Prompt != "Include the station." || fuel.TitlePattern != receipts.TitlePattern {
+		t.Errorf("Receipts/Fuel = %+v", fuel)
+	}
+	if work.HasProfile() || work.Depth != 0 {
+		t.Errorf("Work = %+v, want no profile at depth 0", work)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	tax, err := load(t, sample)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name, want string
+		ok         bool
+	}{
+		{"Finance/Taxes", "Finance/Taxes", true},
+		{"finance/taxes", "Finance/Taxes", true},
+		{"Insurance", "Finance/Insurance", true},
+		{"receipts", "Receipts", true},
+		{"Misc", "Misc", true},
+		{"Taxes", "", false}, // under Finance and Personal
+		{"Travel", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := tax.Resolve(tt.name)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("Resolve(%q) = %q, %v; want %q, %v", tt.name, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -1,116 +1,16 @@
 package main
 
 import (
-	"context"
-	"fmt"
-	"log"
-	"net/http"
 	"os"
-	"os/signal"
-	"syscall"
-
-	"docs_organiser/internal/ai"
-	"docs_organiser/internal/api"
-	"docs_organiser/internal/config"
-	"docs_organiser/internal/observability"
-	"docs_organiser/internal/pipeline"
-	"docs_organiser/internal/storage"
+	"time"
 )
 
-func main() {
-	// Load configuration using Viper
-	cfg, err := config.LoadConfig()
-	if err != nil {
-		log.Fatalf("Failed to load configuration: %v", err)
-	}
-
-	// Initialize Storage
-	store, err := storage.NewBadgerStore(cfg.DBPath)
-	if err != nil {
-		log.Fatalf("Failed to initialize storage: %v", err)
-	}
-	defer store.Close()
-
-	// Load persistent settings
-	var persisted config.Config
-	exists, err := store.Load("user_settings", &persisted)
-	if err != nil {
-		log.Printf("[!] Failed to load persistent settings: %v", err)
-	}
-	if exists {
-		cfg.SourceDir = persisted.SourceDir
-		cfg.DestDir = persisted.DestDir
-		cfg.AllowedModels = persisted.AllowedModels
-		cfg.DefaultModelName = persisted.DefaultModelName
-		cfg.Workers = persisted.Workers
-		cfg.ExtractLimit = persisted.ExtractLimit
-		cfg.Categories = persisted.Categories
-		fmt.Println("[+] Persistent settings loaded from Badger KV.")
-	} else {
-		// Initial save of defaults
-		if err := store.Save("user_settings", cfg); err != nil {
-			log.Printf("[!] Failed to save initial settings: %v", err)
-		}
-	}
-
-	// Signal handling for graceful shutdown
-	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
-	defer stop()
-
-	fmt.Println("=== MLX File Mover (Production Ready) ===")
-	fmt.Printf("Source:         %s\n", cfg.SourceDir)
-	fmt.Printf("Destination:    %s\n", cfg.DestDir)
-	fmt.Printf("API URL:        %s\n", cfg.APIURL)
-	fmt.Printf("Model Pool:     %d models configured\n", len(cfg.AllowedModels))
-	for _, m := range cfg.AllowedModels {
-		fmt.Printf("  - %s (%s)\n", m.Name, m.URL)
-	}
-	fmt.Printf("Context:        %d tokens\n", cfg.ContextWindow)
-	fmt.Printf("Limit:          %d characters\n", cfg.ExtractLimit)
-	fmt.Printf("Workers:        %d\n", cfg.Workers)
-	fmt.Printf("DB Path:        %s\n", cfg.DBPath)
-	fmt.Println("-----------------------------------------")
+// shutdownTimeout bounds how long a signal waits for in-flight files before exiting.
+// It matches the stop timeout in the generated service definitions.
+const shutdownTimeout = 150 * time.Second
 
-	// Initialize AI Engine
-	fmt.Println("[*] Initializing AI Engine...")
-	aiEngine, err := ai.NewMLXEngine(cfg.APIURL, cfg.AllowedModels, cfg.ContextWindow, cfg.Encoding)
-	if err != nil {
-		log.Fatalf("Failed to initialize AI engine: %v", err)
-	}
-	if len(cfg.Categories) > 0 {
-		aiEngine.SetCategories(cfg.Categories)
-		fmt.Printf("[*] Using %d manual categories from config.\n", len(cfg.Categories))
-	}
-	fmt.Println("[+] AI Engine initialized.")
-
-	// Initialize and Run Pipeline
-	if cfg.DefaultModelName != "" {
-		aiEngine.SetDefaultModel(cfg.DefaultModelName)
-	}
-	p := pipeline.NewPipeline(cfg.SourceDir, cfg.DestDir, aiEngine, cfg.Workers, cfg.ExtractLimit)
-
-	// Start Observability
-	if cfg.MetricsEnabled {
-		go func() {
-			fmt.Printf("[*] Starting Metrics Server on :%d/metrics\n", cfg.MetricsPort)
-			if err := observability.StartMetricsServer(cfg.MetricsPort); err != nil {
-				log.Printf("[!] Metrics server failed: %v", err)
-			}
-		}()
-	}
-
-	// Start App Server
-	srv := api.NewServer(cfg, p, store)
-	fmt.Printf("[*] Starting App Server on :%d\n", cfg.ServerPort)
-
-	// In a real app we'd use ctx to shutdown srv,
-	// for now we just log it to satisfy lint and keep architecture simple.
-	go func() {
-		<-ctx.Done()
-		fmt.Println("\n[*] Shutting down...")
-	}()
-
-	if err := srv.Start(); err != nil && err != http.ErrServerClosed {
-		log.Fatalf("Failed to start app server: %v", err)
+func main() {
+	if err := newRootCommand().Execute(); err != nil {
+		os.Exit(1)
 	}
 }
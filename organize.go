@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+func newOrganizeCommand(flags *pflag.FlagSet) *cobra.Command {
+	return &cobra.Command{
+		Use:   "organize",
+		Short: "Organise the source folder once and exit",
+		Args:  cobra.NoArgs,
+		Run:   action(flags, runOrganize),
+	}
+}
+
+func newPlanCommand(flags *pflag.FlagSet) *cobra.Command {
+	return &cobra.Command{
+		Use:   "plan <results.csv>",
+		Short: "Classify the source without moving anything, writing the decisions to a CSV",
+		Long: `plan runs organize in classify-only mode and writes its decisions to the CSV it
+is given, replacing an earlier plan there. Correct the categories or titles in a
+spreadsheet if needed, then move the files with: docs_organiser apply <results.csv>`,
+		Args: cobra.ExactArgs(1),
+		Run:  action(flags, runPlan),
+	}
+}
+
+// runPlan classifies the source once without moving anything, writing the decisions to
+// the results CSV named by args.
+func runPlan(a *app, args []string) {
+	path := args[0]
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		log.Fatalf("Failed to replace %s: %v", path, err)
+	}
+	a.cfg.ClassifyOnly = true
+	a.cfg.ResultsCSV = path
+	runOrganize(a, nil)
+	log.Printf("[+] Review %s, then run: docs_organiser apply %s", path, path)
+}
+
+// runOrganize organises the source once and returns when the run is over. The first
+// signal drains the run; a second one cancels the files still in progress.
+func runOrganize(a *app, _ []string) {
+	p := a.openPipeline()
+	a.preflight()
+	ctx := a.context()
+	if p.SourceDir == "" || p.DestDir == "" {
+		log.Fatalf("No source/destination configured; set src and dst in the config file or the dashboard")
+	}
+
+	runCtx := a.hardStop()
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			fmt.Println("\n[*] Stopping, waiting for in-flight files (interrupt again to cancel them)...")
+			p.Drain()
+			p.Resume() // paused workers only notice the drain once woken
+		case <-done:
+		}
+	}()
+
+	err := p.Run(runCtx)
+	close(done)
+	if err != nil && err != context.Canceled {
+		log.Fatalf("Run failed: %v", err)
+	}
+	p.Webhook.Wait()
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+
+	"docs_organiser/internal/daemon"
+	"docs_organiser/internal/pipeline"
+)
+
+// watchPauseSignals toggles pausing p on each pause signal (SIGUSR1) until ctx ends.
+func watchPauseSignals(ctx context.Context, p *pipeline.Pipeline) {
+	sigs := daemon.PauseSignals()
+	if len(sigs) == 0 {
+		return
+	}
+	toggles := make(chan os.Signal, 1)
+	signal.Notify(toggles, sigs...)
+	go func() {
+		defer signal.Stop(toggles)
+		for {
+			select {
+			case <-toggles:
+				reportPause(p.TogglePause())
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// watchPauseKey toggles pausing p whenever "p" is entered on the terminal.
+func watchPauseKey(in io.Reader, p *pipeline.Pipeline) {
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		if strings.EqualFold(strings.TrimSpace(scanner.Text()), "p") {
+			reportPause(p.TogglePause())
+		}
+	}
+}
+
+func reportPause(paused bool) {
+	if paused {
+		log.Printf("[*] Paused: files in progress finish, then no more model requests until resumed (SIGUSR1 or p + Enter)")
+	} else {
+		log.Printf("[*] Resumed")
+	}
+}
@@ -0,0 +1,258 @@
+// Package organiser embeds the document organiser in other Go programs: it scans a source
+// directory, classifies each file with an OpenAI-compatible model (or offline heuristics),
+// and moves it into a category folder of the destination.
+//
+//	o := organiser.New(organiser.Config{Source: "./inbox", Dest: "./archive", APIURL: "http://localhost:8080/v1"})
+//	defer o.Close()
+//	summary, err := o.Run(ctx)
+//
+// Progress and per-file results are reported through Hooks. Diagnostics are written with
+// the standard log package; redirect them with log.SetOutput.
+package organiser
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"docs_organiser/internal/ai"
+	"docs_organiser/internal/audit"
+	"docs_organiser/internal/config"
+	"docs_organiser/internal/fileops"
+	"docs_organiser/internal/pipeline"
+)
+
+// Config configures an Organiser. Only Source and Dest are required; everything else has
+// the same defaults as the command line tool.
+type Config struct {
+	Source string
+	Dest   string
+	// APIURL is the OpenAI-compatible endpoint (default http://localhost:8080/v1).
+	APIURL string
+	// Models are the models to use; by default the endpoint's only model is adopted.
+	Models []Model
+	// Categories to classify into. When empty they are discovered from the destination's
+	// existing folders.
+	Categories []string
+	// Workers is how many files are processed at once (default 5).
+	Workers int
+	// ContextWindow is the model's context size in tokens (default 4096).
+	ContextWindow int
+	// Encoding is the tiktoken encoding used to count tokens (default cl100k_base).
+	Encoding string
+	// ExtractLimit caps the characters extracted per file; 0 sizes it from the context.
+	ExtractLimit int
+	// NoLLM classifies by file name patterns and keywords only, without a model server.
+	NoLLM bool
+	// Collisions is what happens when a destination name is taken by a different file:
+	// hash (default), sequence, skip, overwrite, or newest.
+	Collisions string
+	// RemoveEmptyDirs removes source directories left empty at the end of each run.
+	RemoveEmptyDirs bool
+	// MaxFiles stops a run after that many files; Sample instead picks that many at random.
+	MaxFiles int
+	Sample   int
+	// AuditLog, when set, appends a JSON line per processed file to this path.
+	AuditLog string
+
+	Hooks Hooks
+}
+
+// Model is a model served at URL.
+type Model struct {
+	Name string
+	URL  string
+}
+
+// Hooks are called from the worker goroutines, so they must be safe for concurrent use
+// and should return quickly.
+type Hooks struct {
+	// OnFile receives the outcome of every file.
+	OnFile func(FileResult)
+	// OnProgress receives the counters after every file.
+	OnProgress func(Progress)
+}
+
+// FileResult is the outcome of one file.
+type FileResult struct {
+	Source      string
+	Destination string
+	Category    string
+	Title       string
+	Confidence  float64
+	// Status is moved, skipped, extraction_failed, move_failed, or cancelled.
+	Status string
+	Error  string
+	// Duplicate reports that an identical file was already organised; the source was removed.
+	Duplicate bool
+	// Fallback reports that the model gave no usable answer and the file went to Misc.
+	Fallback bool
+}
+
+// Progress counts the files of the current run.
+type Progress struct {
+	Total     int
+	Completed int
+	Processed int
+	Failed    int
+	Percent   float64
+}
+
+// Summary counts the files of a finished run.
+type Summary struct {
+	Total     int
+	Processed int
+	Failed    int
+	Duration  time.Duration
+}
+
+// Organiser organises a source directory into a destination. Its methods are safe for
+// concurrent use, but only one Run may be in progress at a time.
+type Organiser struct {
+	p        *pipeline.Pipeline
+	auditLog *audit.Logger
+	err      error // from New, returned by every call
+}
+
+// New creates an Organiser. Configuration errors are returned by Run and Classify, so a
+// run can be started in one expression: organiser.New(cfg).Run(ctx).
+func New(cfg Config) *Organiser {
+	o := &Organiser{}
+	o.p, o.auditLog, o.err = build(cfg)
+	return o
+}
+
+func build(cfg Config) (*pipeline.Pipeline, *audit.Logger, error) {
+	if cfg.Source == "" || cfg.Dest == "" {
+		return nil, nil, fmt.Errorf("organiser: Source and Dest are required")
+	}
+	if cfg.APIURL == "" {
+		cfg.APIURL = "http://localhost:8080/v1"
+	}
+	if cfg.ContextWindow <= 0 {
+		cfg.ContextWindow = 4096
+	}
+	if cfg.Encoding == "" {
+		cfg.Encoding = "cl100k_base"
+	}
+	models := make([]config.ModelDefinition, len(cfg.Models))
+	for i, m := range cfg.Models {
+		models[i] = config.ModelDefinition{Name: m.Name, URL: m.URL}
+		if models[i].URL == "" {
+			models[i].URL = cfg.APIURL
+		}
+	}
+	if len(models) == 0 {
+		// Preflight adopts whichever model the endpoint serves
+		models = []config.ModelDefinition{{Name: "default", URL: cfg.APIURL}}
+	}
+	collisions, err := fileops.ParseCollisionPolicy(cfg.Collisions)
+	if err != nil {
+		return nil, nil, fmt.Errorf("organiser: %w", err)
+	}
+
+	engine, err := ai.NewMLXEngine(cfg.APIURL, models, cfg.ContextWindow, cfg.Encoding)
+	if err != nil {
+		return nil, nil, fmt.Errorf("organiser: %w", err)
+	}
+	if len(cfg.Categories) > 0 {
+		engine.SetCategories(cfg.Categories)
+	}
+
+	p := pipeline.NewPipeline(cfg.Source, cfg.Dest, engine, cfg.Workers, cfg.ExtractLimit)
+	p.NoLLM = cfg.NoLLM
+	p.Collisions = collisions
+	p.RemoveEmptyDirs = cfg.RemoveEmptyDirs
+	p.MaxFiles, p.Sample = cfg.MaxFiles, cfg.Sample
+	if fn := cfg.Hooks.OnFile; fn != nil {
+		p.OnFile = func(rec audit.Record) { fn(fileResult(rec)) }
+	}
+	if fn := cfg.Hooks.OnProgress; fn != nil {
+		p.OnProgress = func(pr pipeline.Progress) {
+			fn(Progress{Total: int(pr.Total), Completed: int(pr.Completed), Processed: int(pr.Processed), Failed: int(pr.Failed), Percent: pr.Percent})
+		}
+	}
+
+	var auditLog *audit.Logger
+	if cfg.AuditLog != "" {
+		if auditLog, err = audit.Open(cfg.AuditLog); err != nil {
+			return nil, nil, fmt.Errorf("organiser: %w", err)
+		}
+		p.Audit = auditLog
+	}
+	return p, auditLog, nil
+}
+
+// Run organises the source once and returns its counters. Cancelling ctx interrupts the
+// files in progress; Drain instead lets them finish.
+func (o *Organiser) Run(ctx context.Context) (Summary, error) {
+	if o.err != nil {
+		return Summary{}, o.err
+	}
+	total, processed, failed := atomic.LoadInt32(&o.p.TotalFiles), atomic.LoadInt32(&o.p.ProcessedFiles), atomic.LoadInt32(&o.p.FailedFiles)
+	start := time.Now()
+	err := o.p.Run(ctx)
+	return Summary{
+		Total:     int(atomic.LoadInt32(&o.p.TotalFiles) - total),
+		Processed: int(atomic.LoadInt32(&o.p.ProcessedFiles) - processed),
+		Failed:    int(atomic.LoadInt32(&o.p.FailedFiles) - failed),
+		Duration:  time.Since(start),
+	}, err
+}
+
+// Classify extracts and classifies the file at path without moving it. The result's
+// Destination is empty; its Status is only set when the file could not be classified.
+func (o *Organiser) Classify(ctx context.Context, path string) (FileResult, error) {
+	if o.err != nil {
+		return FileResult{}, o.err
+	}
+	return fileResult(o.p.Classify(ctx, path)), nil
+}
+
+// Drain stops the current run from starting new files; those in progress finish and
+// Run returns context.Canceled.
+func (o *Organiser) Drain() {
+	if o.err == nil {
+		o.p.Drain()
+	}
+}
+
+// Pause stops the workers from starting new files until Resume.
+func (o *Organiser) Pause() {
+	if o.err == nil {
+		o.p.Pause()
+	}
+}
+
+// Resume continues a paused run.
+func (o *Organiser) Resume() {
+	if o.err == nil {
+		o.p.Resume()
+	}
+}
+
+// Close releases the audit log. The Organiser must not be used afterwards.
+func (o *Organiser) Close() error {
+	if o.auditLog != nil {
+		return o.auditLog.Close()
+	}
+	return nil
+}
+
+func fileResult(rec audit.Record) FileResult {
+	r := FileResult{
+		Source:      rec.Source,
+		Destination: rec.Destination,
+		Category:    rec.Category,
+		Title:       rec.Title,
+		Status:      rec.Status,
+		Error:       rec.Error,
+		Duplicate:   rec.Duplicate,
+		Fallback:    rec.Fallback,
+	}
+	if rec.Classification != nil && rec.Classification.Analysis != nil {
+		r.Confidence = rec.Classification.Analysis.ConfidenceScore
+	}
+	return r
+}
@@ -0,0 +1,106 @@
+package organiser
+
+import (
+	"context"
+	"docs_organiser/internal/aitest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	srv := aitest.NewServer(t, "mock-model")
+	srv.Respond(func(r aitest.Request) aitest.Reply {
+		if strings.Contains(r.User(), "Invoice") {
+			return aitest.Category("Finance", "Invoice_ACME", 0.9)
+		}
+		return aitest.Category("Misc", "Note", 0.4)
+	})
+
+	root := t.TempDir()
+	src, dst := filepath.Join(root, "src"), filepath.Join(root, "dst")
+	if err := os.MkdirAll(src, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{"a.txt": "Invoice from ACME", "b.txt": "Shopping list"} {
+		if err := os.WriteFile(filepath.Join(src, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var mu sync.Mutex
+	var results []FileResult
+	var last Progress
+	o := New(Config{
+		Source:     src,
+		Dest:       dst,
+		APIURL:     srv.APIURL(),
+		Categories: []string{"Finance", "Misc"},
+		Workers:    1,
+		AuditLog:   filepath.Join(root, "audit.jsonl"),
+		Hooks: Hooks{
+			OnFile: func(r FileResult) {
+				mu.Lock()
+				defer mu.Unlock()
+				results = append(results, r)
+			},
+			OnProgress: func(p Progress) {
+				mu.Lock()
+				defer mu.Unlock()
+				last = p
+			},
+		},
+	})
+	defer o.Close()
+	summary, err := o.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	if summary.Total != 2 || summary.Processed != 2 || summary.Failed != 0 {
+		t.Errorf("summary = %+v, want 2 files processed", summary)
+	}
+	if last.Completed != 2 || last.Percent != 100 {
+		t.Errorf("last progress = %+v, want 2 completed", last)
+	}
+	if len(results) != 2 {
+		t.Fatalf("OnFile called %d times, want 2", len(results))
+	}
+	for _, r := range results {
+		if r.Status != "moved" {
+			t.Errorf("%s: status %q, want moved", r.Source, r.Status)
+		}
+		if filepath.Base(r.Source) == "a.txt" && (r.Destination != filepath.Join(dst, "Finance", "Invoice_ACME.txt") || r.Confidence != 0.9) {
+			t.Errorf("a.txt result = %+v", r)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(root, "audit.jsonl")); err != nil {
+		t.Errorf("audit log not written: %v", err)
+	}
+}
+
+func TestNew_InvalidConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+	}{
+		{"missing source", Config{Dest: "dst"}},
+		{"unknown collision policy", Config{Source: "src", Dest: "dst", Collisions: "rename"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := New(tt.cfg)
+			if _, err := o.Run(context.Background()); err == nil {
+				t.Error("Run: expected a configuration error")
+			}
+			if _, err := o.Classify(context.Background(), "x.txt"); err == nil {
+				t.Error("Classify: expected a configuration error")
+			}
+			o.Drain()
+			o.Pause()
+			o.Resume()
+		})
+	}
+}
@@ -0,0 +1,5 @@
+// Package organiserpb contains the generated gRPC bindings for the organiser service
+// defined in proto/organiser/v1/organiser.proto.
+package organiserpb
+
+//go:generate protoc -I ../../proto --go_out=../.. --go_opt=module=docs_organiser --go-grpc_out=../.. --go-grpc_opt=module=docs_organiser organiser/v1/organiser.proto
@@ -0,0 +1,594 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.8
+// 	protoc        v5.29.3
+// source: organiser/v1/organiser.proto
+
+package organiserpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type FileResult_Outcome int32
+
+const (
+	FileResult_OUTCOME_UNSPECIFIED       FileResult_Outcome = 0
+	FileResult_OUTCOME_MOVED             FileResult_Outcome = 1
+	FileResult_OUTCOME_EXTRACTION_FAILED FileResult_Outcome = 2
+	FileResult_OUTCOME_MOVE_FAILED       FileResult_Outcome = 3
+	FileResult_OUTCOME_CANCELLED         FileResult_Outcome = 4
+)
+
+// Enum value maps for FileResult_Outcome.
+var (
+	FileResult_Outcome_name = map[int32]string{
+		0: "OUTCOME_UNSPECIFIED",
+		1: "OUTCOME_MOVED",
+		2: "OUTCOME_EXTRACTION_FAILED",
+		3: "OUTCOME_MOVE_FAILED",
+		4: "OUTCOME_CANCELLED",
+	}
+	FileResult_Outcome_value = map[string]int32{
+		"OUTCOME_UNSPECIFIED":       0,
+		"OUTCOME_MOVED":             1,
+		"OUTCOME_EXTRACTION_FAILED": 2,
+		"OUTCOME_MOVE_FAILED":       3,
+		"OUTCOME_CANCELLED":         4,
+	}
+)
+
+func (x FileResult_Outcome) Enum() *FileResult_Outcome {
+	p := new(FileResult_Outcome)
+	*p = x
+	return p
+}
+
+func (x FileResult_Outcome) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (FileResult_Outcome) Descriptor() protoreflect.EnumDescriptor {
+	return file_organiser_v1_organiser_proto_enumTypes[0].Descriptor()
+}
+
+func (FileResult_Outcome) Type() protoreflect.EnumType {
+	return &file_organiser_v1_organiser_proto_enumTypes[0]
+}
+
+func (x FileResult_Outcome) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use FileResult_Outcome.Descriptor instead.
+func (FileResult_Outcome) EnumDescriptor() ([]byte, []int) {
+	return file_organiser_v1_organiser_proto_rawDescGZIP(), []int{2, 0}
+}
+
+type SubmitDocumentRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Source:
+	//
+	//	*SubmitDocumentRequest_Path
+	//	*SubmitDocumentRequest_Upload
+	Source        isSubmitDocumentRequest_Source `protobuf_oneof:"source"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubmitDocumentRequest) Reset() {
+	*x = SubmitDocumentRequest{}
+	mi := &file_organiser_v1_organiser_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubmitDocumentRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubmitDocumentRequest) ProtoMessage() {}
+
+func (x *SubmitDocumentRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_organiser_v1_organiser_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubmitDocumentRequest.ProtoReflect.Descriptor instead.
+func (*SubmitDocumentRequest) Descriptor() ([]byte, []int) {
+	return file_organiser_v1_organiser_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *SubmitDocumentRequest) GetSource() isSubmitDocumentRequest_Source {
+	if x != nil {
+		return x.Source
+	}
+	return nil
+}
+
+func (x *SubmitDocumentRequest) GetPath() string {
+	if x != nil {
+		if x, ok := x.Source.(*SubmitDocumentRequest_Path); ok {
+			return x.Path
+		}
+	}
+	return ""
+}
+
+func (x *SubmitDocumentRequest) GetUpload() *Upload {
+	if x != nil {
+		if x, ok := x.Source.(*SubmitDocumentRequest_Upload); ok {
+			return x.Upload
+		}
+	}
+	return nil
+}
+
+type isSubmitDocumentRequest_Source interface {
+	isSubmitDocumentRequest_Source()
+}
+
+type SubmitDocumentRequest_Path struct {
+	// Path of a file on the server, inside the configured source directory.
+	Path string `protobuf:"bytes,1,opt,name=path,proto3,oneof"`
+}
+
+type SubmitDocumentRequest_Upload struct {
+	// Document bytes uploaded by the client.
+	Upload *Upload `protobuf:"bytes,2,opt,name=upload,proto3,oneof"`
+}
+
+func (*SubmitDocumentRequest_Path) isSubmitDocumentRequest_Source() {}
+
+func (*SubmitDocumentRequest_Upload) isSubmitDocumentRequest_Source() {}
+
+type Upload struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Original file name; its extension selects the extractor.
+	Filename      string `protobuf:"bytes,1,opt,name=filename,proto3" json:"filename,omitempty"`
+	Content       []byte `protobuf:"bytes,2,opt,name=content,proto3" json:"content,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Upload) Reset() {
+	*x = Upload{}
+	mi := &file_organiser_v1_organiser_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Upload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Upload) ProtoMessage() {}
+
+func (x *Upload) ProtoReflect() protoreflect.Message {
+	mi := &file_organiser_v1_organiser_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Upload.ProtoReflect.Descriptor instead.
+func (*Upload) Descriptor() ([]byte, []int) {
+	return file_organiser_v1_organiser_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Upload) GetFilename() string {
+	if x != nil {
+		return x.Filename
+	}
+	return ""
+}
+
+func (x *Upload) GetContent() []byte {
+	if x != nil {
+		return x.Content
+	}
+	return nil
+}
+
+type FileResult struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Source      string                 `protobuf:"bytes,1,opt,name=source,proto3" json:"source,omitempty"`
+	Outcome     FileResult_Outcome     `protobuf:"varint,2,opt,name=outcome,proto3,enum=organiser.v1.FileResult_Outcome" json:"outcome,omitempty"`
+	Category    string                 `protobuf:"bytes,3,opt,name=category,proto3" json:"category,omitempty"`
+	Title       string                 `protobuf:"bytes,4,opt,name=title,proto3" json:"title,omitempty"`
+	Destination string                 `protobuf:"bytes,5,opt,name=destination,proto3" json:"destination,omitempty"`
+	Confidence  float64                `protobuf:"fixed64,6,opt,name=confidence,proto3" json:"confidence,omitempty"`
+	// Fallback is set when classification failed and the file was routed to Misc.
+	Fallback      bool   `protobuf:"varint,7,opt,name=fallback,proto3" json:"fallback,omitempty"`
+	Model         string `protobuf:"bytes,8,opt,name=model,proto3" json:"model,omitempty"`
+	Attempts      int32  `protobuf:"varint,9,opt,name=attempts,proto3" json:"attempts,omitempty"`
+	Error         string `protobuf:"bytes,10,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FileResult) Reset() {
+	*x = FileResult{}
+	mi := &file_organiser_v1_organiser_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FileResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FileResult) ProtoMessage() {}
+
+func (x *FileResult) ProtoReflect() protoreflect.Message {
+	mi := &file_organiser_v1_organiser_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FileResult.ProtoReflect.Descriptor instead.
+func (*FileResult) Descriptor() ([]byte, []int) {
+	return file_organiser_v1_organiser_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *FileResult) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *FileResult) GetOutcome() FileResult_Outcome {
+	if x != nil {
+		return x.Outcome
+	}
+	return FileResult_OUTCOME_UNSPECIFIED
+}
+
+func (x *FileResult) GetCategory() string {
+	if x != nil {
+		return x.Category
+	}
+	return ""
+}
+
+func (x *FileResult) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *FileResult) GetDestination() string {
+	if x != nil {
+		return x.Destination
+	}
+	return ""
+}
+
+func (x *FileResult) GetConfidence() float64 {
+	if x != nil {
+		return x.Confidence
+	}
+	return 0
+}
+
+func (x *FileResult) GetFallback() bool {
+	if x != nil {
+		return x.Fallback
+	}
+	return false
+}
+
+func (x *FileResult) GetModel() string {
+	if x != nil {
+		return x.Model
+	}
+	return ""
+}
+
+func (x *FileResult) GetAttempts() int32 {
+	if x != nil {
+		return x.Attempts
+	}
+	return 0
+}
+
+func (x *FileResult) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type GetStatusRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetStatusRequest) Reset() {
+	*x = GetStatusRequest{}
+	mi := &file_organiser_v1_organiser_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetStatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetStatusRequest) ProtoMessage() {}
+
+func (x *GetStatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_organiser_v1_organiser_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetStatusRequest.ProtoReflect.Descriptor instead.
+func (*GetStatusRequest) Descriptor() ([]byte, []int) {
+	return file_organiser_v1_organiser_proto_rawDescGZIP(), []int{3}
+}
+
+type Status struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Paused         bool                   `protobuf:"varint,1,opt,name=paused,proto3" json:"paused,omitempty"`
+	TotalFiles     int32                  `protobuf:"varint,2,opt,name=total_files,json=totalFiles,proto3" json:"total_files,omitempty"`
+	ProcessedFiles int32                  `protobuf:"varint,3,opt,name=processed_files,json=processedFiles,proto3" json:"processed_files,omitempty"`
+	FailedFiles    int32                  `protobuf:"varint,4,opt,name=failed_files,json=failedFiles,proto3" json:"failed_files,omitempty"`
+	ActiveWorkers  int32                  `protobuf:"varint,5,opt,name=active_workers,json=activeWorkers,proto3" json:"active_workers,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *Status) Reset() {
+	*x = Status{}
+	mi := &file_organiser_v1_organiser_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Status) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Status) ProtoMessage() {}
+
+func (x *Status) ProtoReflect() protoreflect.Message {
+	mi := &file_organiser_v1_organiser_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Status.ProtoReflect.Descriptor instead.
+func (*Status) Descriptor() ([]byte, []int) {
+	return file_organiser_v1_organiser_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Status) GetPaused() bool {
+	if x != nil {
+		return x.Paused
+	}
+	return false
+}
+
+func (x *Status) GetTotalFiles() int32 {
+	if x != nil {
+		return x.TotalFiles
+	}
+	return 0
+}
+
+func (x *Status) GetProcessedFiles() int32 {
+	if x != nil {
+		return x.ProcessedFiles
+	}
+	return 0
+}
+
+func (x *Status) GetFailedFiles() int32 {
+	if x != nil {
+		return x.FailedFiles
+	}
+	return 0
+}
+
+func (x *Status) GetActiveWorkers() int32 {
+	if x != nil {
+		return x.ActiveWorkers
+	}
+	return 0
+}
+
+type StreamResultsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamResultsRequest) Reset() {
+	*x = StreamResultsRequest{}
+	mi := &file_organiser_v1_organiser_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamResultsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamResultsRequest) ProtoMessage() {}
+
+func (x *StreamResultsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_organiser_v1_organiser_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamResultsRequest.ProtoReflect.Descriptor instead.
+func (*StreamResultsRequest) Descriptor() ([]byte, []int) {
+	return file_organiser_v1_organiser_proto_rawDescGZIP(), []int{5}
+}
+
+var File_organiser_v1_organiser_proto protoreflect.FileDescriptor
+
+const file_organiser_v1_organiser_proto_rawDesc = "" +
+	"\n" +
+	"\x1corganiser/v1/organiser.proto\x12\forganiser.v1\"g\n" +
+	"\x15SubmitDocumentRequest\x12\x14\n" +
+	"\x04path\x18\x01 \x01(\tH\x00R\x04path\x12.\n" +
+	"\x06upload\x18\x02 \x01(\v2\x14.organiser.v1.UploadH\x00R\x06uploadB\b\n" +
+	"\x06source\">\n" +
+	"\x06Upload\x12\x1a\n" +
+	"\bfilename\x18\x01 \x01(\tR\bfilename\x12\x18\n" +
+	"\acontent\x18\x02 \x01(\fR\acontent\"\xbf\x03\n" +
+	"\n" +
+	"FileResult\x12\x16\n" +
+	"\x06source\x18\x01 \x01(\tR\x06source\x12:\n" +
+	"\aoutcome\x18\x02 \x01(\x0e2 .organiser.v1.FileResult.OutcomeR\aoutcome\x12\x1a\n" +
+	"\bcategory\x18\x03 \x01(\tR\bcategory\x12\x14\n" +
+	"\x05title\x18\x04 \x01(\tR\x05title\x12 \n" +
+	"\vdestination\x18\x05 \x01(\tR\vdestination\x12\x1e\n" +
+	"\n" +
+	"confidence\x18\x06 \x01(\x01R\n" +
+	"confidence\x12\x1a\n" +
+	"\bfallback\x18\a \x01(\bR\bfallback\x12\x14\n" +
+	"\x05model\x18\b \x01(\tR\x05model\x12\x1a\n" +
+	"\battempts\x18\t \x01(\x05R\battempts\x12\x14\n" +
+	"\x05error\x18\n" +
+	" \x01(\tR\x05error\"\x84\x01\n" +
+	"\aOutcome\x12\x17\n" +
+	"\x13OUTCOME_UNSPECIFIED\x10\x00\x12\x11\n" +
+	"\rOUTCOME_MOVED\x10\x01\x12\x1d\n" +
+	"\x19OUTCOME_EXTRACTION_FAILED\x10\x02\x12\x17\n" +
+	"\x13OUTCOME_MOVE_FAILED\x10\x03\x12\x15\n" +
+	"\x11OUTCOME_CANCELLED\x10\x04\"\x12\n" +
+	"\x10GetStatusRequest\"\xb4\x01\n" +
+	"\x06Status\x12\x16\n" +
+	"\x06paused\x18\x01 \x01(\bR\x06paused\x12\x1f\n" +
+	"\vtotal_files\x18\x02 \x01(\x05R\n" +
+	"totalFiles\x12'\n" +
+	"\x0fprocessed_files\x18\x03 \x01(\x05R\x0eprocessedFiles\x12!\n" +
+	"\ffailed_files\x18\x04 \x01(\x05R\vfailedFiles\x12%\n" +
+	"\x0eactive_workers\x18\x05 \x01(\x05R\ractiveWorkers\"\x16\n" +
+	"\x14StreamResultsRequest2\xf0\x01\n" +
+	"\tOrganiser\x12O\n" +
+	"\x0eSubmitDocument\x12#.organiser.v1.SubmitDocumentRequest\x1a\x18.organiser.v1.FileResult\x12A\n" +
+	"\tGetStatus\x12\x1e.organiser.v1.GetStatusRequest\x1a\x14.organiser.v1.Status\x12O\n" +
+	"\rStreamResults\x12\".organiser.v1.StreamResultsRequest\x1a\x18.organiser.v1.FileResult0\x01B,Z*docs_organiser/pkg/organiserpb;organiserpbb\x06proto3"
+
+var (
+	file_organiser_v1_organiser_proto_rawDescOnce sync.Once
+	file_organiser_v1_organiser_proto_rawDescData []byte
+)
+
+func file_organiser_v1_organiser_proto_rawDescGZIP() []byte {
+	file_organiser_v1_organiser_proto_rawDescOnce.Do(func() {
+		file_organiser_v1_organiser_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_organiser_v1_organiser_proto_rawDesc), len(file_organiser_v1_organiser_proto_rawDesc)))
+	})
+	return file_organiser_v1_organiser_proto_rawDescData
+}
+
+var file_organiser_v1_organiser_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_organiser_v1_organiser_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
+var file_organiser_v1_organiser_proto_goTypes = []any{
+	(FileResult_Outcome)(0),       // 0: organiser.v1.FileResult.Outcome
+	(*SubmitDocumentRequest)(nil), // 1: organiser.v1.SubmitDocumentRequest
+	(*Upload)(nil),                // 2: organiser.v1.Upload
+	(*FileResult)(nil),            // 3: organiser.v1.FileResult
+	(*GetStatusRequest)(nil),      // 4: organiser.v1.GetStatusRequest
+	(*Status)(nil),                // 5: organiser.v1.Status
+	(*StreamResultsRequest)(nil),  // 6: organiser.v1.StreamResultsRequest
+}
+var file_organiser_v1_organiser_proto_depIdxs = []int32{
+	2, // 0: organiser.v1.SubmitDocumentRequest.upload:type_name -> organiser.v1.Upload
+	0, // 1: organiser.v1.FileResult.outcome:type_name -> organiser.v1.FileResult.Outcome
+	1, // 2: organiser.v1.Organiser.SubmitDocument:input_type -> organiser.v1.SubmitDocumentRequest
+	4, // 3: organiser.v1.Organiser.GetStatus:input_type -> organiser.v1.GetStatusRequest
+	6, // 4: organiser.v1.Organiser.StreamResults:input_type -> organiser.v1.StreamResultsRequest
+	3, // 5: organiser.v1.Organiser.SubmitDocument:output_type -> organiser.v1.FileResult
+	5, // 6: organiser.v1.Organiser.GetStatus:output_type -> organiser.v1.Status
+	3, // 7: organiser.v1.Organiser.StreamResults:output_type -> organiser.v1.FileResult
+	5, // [5:8] is the sub-list for method output_type
+	2, // [2:5] is the sub-list for method input_type
+	2, // [2:2] is the sub-list for extension type_name
+	2, // [2:2] is the sub-list for extension extendee
+	0, // [0:2] is the sub-list for field type_name
+}
+
+func init() { file_organiser_v1_organiser_proto_init() }
+func file_organiser_v1_organiser_proto_init() {
+	if File_organiser_v1_organiser_proto != nil {
+		return
+	}
+	file_organiser_v1_organiser_proto_msgTypes[0].OneofWrappers = []any{
+		(*SubmitDocumentRequest_Path)(nil),
+		(*SubmitDocumentRequest_Upload)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_organiser_v1_organiser_proto_rawDesc), len(file_organiser_v1_organiser_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   6,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_organiser_v1_organiser_proto_goTypes,
+		DependencyIndexes: file_organiser_v1_organiser_proto_depIdxs,
+		EnumInfos:         file_organiser_v1_organiser_proto_enumTypes,
+		MessageInfos:      file_organiser_v1_organiser_proto_msgTypes,
+	}.Build()
+	File_organiser_v1_organiser_proto = out.File
+	file_organiser_v1_organiser_proto_goTypes = nil
+	file_organiser_v1_organiser_proto_depIdxs = nil
+}
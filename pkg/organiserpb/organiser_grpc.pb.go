@@ -0,0 +1,213 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v5.29.3
+// source: organiser/v1/organiser.proto
+
+package organiserpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Organiser_SubmitDocument_FullMethodName = "/organiser.v1.Organiser/SubmitDocument"
+	Organiser_GetStatus_FullMethodName      = "/organiser.v1.Organiser/GetStatus"
+	Organiser_StreamResults_FullMethodName  = "/organiser.v1.Organiser/StreamResults"
+)
+
+// OrganiserClient is the client API for Organiser service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Organiser exposes the document pipeline to other services.
+type OrganiserClient interface {
+	// SubmitDocument classifies and moves a single document, returning the outcome.
+	SubmitDocument(ctx context.Context, in *SubmitDocumentRequest, opts ...grpc.CallOption) (*FileResult, error)
+	// GetStatus reports pipeline progress counters.
+	GetStatus(ctx context.Context, in *GetStatusRequest, opts ...grpc.CallOption) (*Status, error)
+	// StreamResults streams the outcome of every file processed from now on,
+	// whether submitted over gRPC or picked up by a pipeline run.
+	StreamResults(ctx context.Context, in *StreamResultsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[FileResult], error)
+}
+
+type organiserClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewOrganiserClient(cc grpc.ClientConnInterface) OrganiserClient {
+	return &organiserClient{cc}
+}
+
+func (c *organiserClient) SubmitDocument(ctx context.Context, in *SubmitDocumentRequest, opts ...grpc.CallOption) (*FileResult, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(FileResult)
+	err := c.cc.Invoke(ctx, Organiser_SubmitDocument_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *organiserClient) GetStatus(ctx context.Context, in *GetStatusRequest, opts ...grpc.CallOption) (*Status, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Status)
+	err := c.cc.Invoke(ctx, Organiser_GetStatus_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *organiserClient) StreamResults(ctx context.Context, in *StreamResultsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[FileResult], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Organiser_ServiceDesc.Streams[0], Organiser_StreamResults_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamResultsRequest, FileResult]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Organiser_StreamResultsClient = grpc.ServerStreamingClient[FileResult]
+
+// OrganiserServer is the server API for Organiser service.
+// All implementations must embed UnimplementedOrganiserServer
+// for forward compatibility.
+//
+// Organiser exposes the document pipeline to other services.
+type OrganiserServer interface {
+	// SubmitDocument classifies and moves a single document, returning the outcome.
+	SubmitDocument(context.Context, *SubmitDocumentRequest) (*FileResult, error)
+	// GetStatus reports pipeline progress counters.
+	GetStatus(context.Context, *GetStatusRequest) (*Status, error)
+	// StreamResults streams the outcome of every file processed from now on,
+	// whether submitted over gRPC or picked up by a pipeline run.
+	StreamResults(*StreamResultsRequest, grpc.ServerStreamingServer[FileResult]) error
+	mustEmbedUnimplementedOrganiserServer()
+}
+
+// UnimplementedOrganiserServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedOrganiserServer struct{}
+
+func (UnimplementedOrganiserServer) SubmitDocument(context.Context, *SubmitDocumentRequest) (*FileResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SubmitDocument not implemented")
+}
+func (UnimplementedOrganiserServer) GetStatus(context.Context, *GetStatusRequest) (*Status, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetStatus not implemented")
+}
+func (UnimplementedOrganiserServer) StreamResults(*StreamResultsRequest, grpc.ServerStreamingServer[FileResult]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamResults not implemented")
+}
+func (UnimplementedOrganiserServer) mustEmbedUnimplementedOrganiserServer() {}
+func (UnimplementedOrganiserServer) testEmbeddedByValue()                   {}
+
+// UnsafeOrganiserServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to OrganiserServer will
+// result in compilation errors.
+type UnsafeOrganiserServer interface {
+	mustEmbedUnimplementedOrganiserServer()
+}
+
+func RegisterOrganiserServer(s grpc.ServiceRegistrar, srv OrganiserServer) {
+	// If the following call pancis, it indicates UnimplementedOrganiserServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Organiser_ServiceDesc, srv)
+}
+
+func _Organiser_SubmitDocument_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SubmitDocumentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OrganiserServer).SubmitDocument(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Organiser_SubmitDocument_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OrganiserServer).SubmitDocument(ctx, req.(*SubmitDocumentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Organiser_GetStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OrganiserServer).GetStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Organiser_GetStatus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OrganiserServer).GetStatus(ctx, req.(*GetStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Organiser_StreamResults_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamResultsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(OrganiserServer).StreamResults(m, &grpc.GenericServerStream[StreamResultsRequest, FileResult]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Organiser_StreamResultsServer = grpc.ServerStreamingServer[FileResult]
+
+// Organiser_ServiceDesc is the grpc.ServiceDesc for Organiser service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Organiser_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "organiser.v1.Organiser",
+	HandlerType: (*OrganiserServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "SubmitDocument",
+			Handler:    _Organiser_SubmitDocument_Handler,
+		},
+		{
+			MethodName: "GetStatus",
+			Handler:    _Organiser_GetStatus_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamResults",
+			Handler:       _Organiser_StreamResults_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "organiser/v1/organiser.proto",
+}
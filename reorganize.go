@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"text/tabwriter"
+
+	"docs_organiser/internal/pipeline"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+func newReorganizeCommand(flags *pflag.FlagSet) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "reorganize",
+		Short: "Classify the destination again and move files whose category changed",
+	}
+	cmd.AddCommand(
+		&cobra.Command{
+			Use:   "plan <plan.csv>",
+			Short: "Classify the organised files again and write the moves they need to a CSV",
+			Args:  cobra.ExactArgs(1),
+			Run:   action(flags, runReorganizePlan),
+		},
+		&cobra.Command{
+			Use:   "apply <plan.csv>",
+			Short: "Move the organised files as a plan CSV says",
+			Args:  cobra.ExactArgs(1),
+			Run:   action(flags, runReorganizeApply),
+		},
+	)
+	return cmd
+}
+
+// runReorganizePlan classifies the files in the destination again and writes the moves
+// they need to the plan CSV named by args, listing them on stdout (as JSON with JSON
+// output).
+func runReorganizePlan(a *app, args []string) {
+	p := a.openPipeline()
+	a.preflight()
+	path := args[0]
+	if p.DestDir == "" {
+		log.Fatalf("No destination configured; set dst in the config file")
+	}
+	proposals, err := p.PlanReorganize(a.context())
+	if err != nil {
+		log.Fatalf("Reorganize failed: %v", err)
+	}
+	if err := pipeline.WritePlan(path, proposals); err != nil {
+		log.Fatalf("Failed to write the plan: %v", err)
+	}
+
+	if a.asJSON() {
+		err = json.NewEncoder(a.stdout).Encode(proposals)
+	} else {
+		w := tabwriter.NewWriter(a.stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(w, "FILE\tFROM\tTO\tCONFIDENCE\n")
+		for _, pr := range proposals {
+			from := pr.From
+			if from == "" {
+				from = "(root)"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%.2f\n", pr.File, from, pr.To, pr.Confidence)
+		}
+		err = w.Flush()
+	}
+	if err != nil {
+		log.Fatalf("Failed to write the plan: %v", err)
+	}
+	log.Printf("[+] %d file(s) would move; review %s, then run: docs_organiser reorganize apply %s\n", len(proposals), path, path)
+}
+
+// runReorganizeApply moves the destination files listed in the plan CSV named by args.
+// A signal stops it after the file being moved.
+func runReorganizeApply(a *app, args []string) {
+	p := a.openPipeline()
+	if p.DestDir == "" {
+		log.Fatalf("No destination configured; set dst in the config file")
+	}
+	decisions, err := pipeline.LoadDecisions(args[0])
+	if err != nil {
+		log.Fatalf("Failed to load the plan: %v", err)
+	}
+	if err := p.ApplyReorganize(a.context(), decisions); err != nil && err != context.Canceled {
+		log.Fatalf("Reorganize failed: %v", err)
+	}
+}
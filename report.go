@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"time"
+
+	"docs_organiser/internal/audit"
+	"docs_organiser/internal/pipeline"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+func newReportCommand(flags *pflag.FlagSet) *cobra.Command {
+	return &cobra.Command{
+		Use:   "report",
+		Short: "Summarize the audit log: file outcomes, categories, and failures",
+		Long: `report reads audit_log and counts what happened to each file it records, by the
+file's latest record: the statuses, the categories moved files went to, fallbacks,
+duplicates, and overrides, followed by the files that failed. --since and
+--newer-than limit it to the records of that period.`,
+		Args: cobra.NoArgs,
+		Run:  action(flags, runReport),
+	}
+}
+
+// runReport summarizes the audit log, or its records since the since or newer_than cutoff.
+func runReport(a *app, _ []string) {
+	records, _ := readAudit(a, "report")
+	report := audit.Summarize(records)
+	var err error
+	if a.asJSON() {
+		err = json.NewEncoder(a.stdout).Encode(report)
+	} else {
+		err = report.WriteText(a.stdout)
+	}
+	if err != nil {
+		log.Fatalf("Failed to write report: %v", err)
+	}
+}
+
+// readAudit reads the records of audit_log stamped after the since or newer_than cutoff,
+// which it returns too (zero without one). command names what needs the log.
+func readAudit(a *app, command string) ([]audit.Record, time.Time) {
+	cfg := a.cfg
+	if cfg.AuditLog == "" {
+		log.Fatalf("%s reads the audit log; set audit_log to the file runs record their moves in", command)
+	}
+	cutoff, err := pipeline.ParseModifiedAfter(cfg.NewerThan, cfg.Since, time.Now())
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+	records, err := audit.ReadFile(cfg.AuditLog)
+	if err != nil {
+		log.Fatalf("Failed to read the audit log: %v", err)
+	}
+	return audit.Since(records, cutoff), cutoff
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+
+	"docs_organiser/internal/remote"
+	"docs_organiser/internal/search"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+func newSearchCommand(flags *pflag.FlagSet) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "search [--semantic] <query>",
+		Short: "List the organised files whose names, or with --semantic contents, best match a query",
+		Args:  cobra.MinimumNArgs(1),
+	}
+	cmd.Flags().Bool("semantic", false, "Match by meaning through the embeddings of semantic_index")
+	cmd.Run = action(flags, func(a *app, args []string) {
+		semantic, _ := cmd.Flags().GetBool("semantic")
+		runSearch(a, strings.Join(args, " "), semantic)
+	})
+	return cmd
+}
+
+// runSearch lists the organised files best matching query: by the words of their names,
+// or with semantic by the embeddings in the search index.
+func runSearch(a *app, query string, semantic bool) {
+	p := a.openPipeline()
+	var matches []search.Match
+	var err error
+	if semantic {
+		a.preflight()
+		if p.Search == nil {
+			p.Search = search.NewIndex(a.store)
+		}
+		matches, err = p.SearchQuery(a.context(), query, a.cfg.SearchLimit)
+	} else {
+		if p.DestDir == "" {
+			log.Fatalf("No destination configured; set dst in the config file or the dashboard")
+		}
+		if remote.IsRemote(p.DestDir) {
+			log.Fatalf("search by name requires a local destination directory; use --semantic")
+		}
+		matches, err = search.Names(p.DestDir, query, a.cfg.SearchLimit)
+	}
+	if err != nil {
+		log.Fatalf("Search failed: %v", err)
+	}
+	out := a.stdout
+	if a.asJSON() {
+		if matches == nil {
+			matches = []search.Match{}
+		}
+		err = json.NewEncoder(out).Encode(matches)
+	} else if len(matches) == 0 {
+		_, err = fmt.Fprintln(out, "No matching files.")
+	} else {
+		for _, m := range matches {
+			if _, err = fmt.Fprintf(out, "%.2f  %s\n", m.Score, m.Path); err != nil {
+				break
+			}
+		}
+	}
+	if err != nil {
+		log.Fatalf("Failed to write results: %v", err)
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"docs_organiser/internal/api"
+	"docs_organiser/internal/daemon"
+	"docs_organiser/internal/schedule"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+func newServeCommand(flags *pflag.FlagSet) *cobra.Command {
+	return &cobra.Command{
+		Use:   "serve",
+		Short: "Serve the dashboard and API; runs are started from the dashboard",
+		Args:  cobra.NoArgs,
+		Run: action(flags, func(a *app, _ []string) {
+			serve(a, false, nil)
+		}),
+	}
+}
+
+func newDaemonCommand(flags *pflag.FlagSet) *cobra.Command {
+	return &cobra.Command{
+		Use:     "daemon",
+		Aliases: []string{"watch"},
+		Short:   "Organise now or on the schedule, and keep serving the dashboard",
+		Args:    cobra.NoArgs,
+		Run:     action(flags, runDaemon),
+	}
+}
+
+// runDaemon serves the dashboard and organises right away or on the schedule. It holds
+// a PID lock so only one instance organises the same folders.
+func runDaemon(a *app, _ []string) {
+	var sched *schedule.Schedule
+	if a.cfg.Schedule != "" {
+		var err error
+		if sched, err = schedule.Parse(a.cfg.Schedule); err != nil {
+			log.Fatalf("Invalid configuration: %v", err)
+		}
+	}
+
+	pidFile, err := daemon.AcquirePIDFile(a.cfg.PIDFile)
+	if err != nil {
+		log.Fatalf("Failed to start daemon: %v", err)
+	}
+	a.onClose(func() { pidFile.Release() })
+	fmt.Printf("[*] Daemon running with pid %d (lock: %s)\n", os.Getpid(), a.cfg.PIDFile)
+
+	serve(a, true, sched)
+}
+
+// serve serves the dashboard and API until a signal shuts it down. With organise set it
+// starts a run right away, or on every tick of sched when that is set.
+func serve(a *app, organise bool, sched *schedule.Schedule) {
+	p := a.openPipeline()
+	a.preflight()
+	ctx, hardStop := a.context(), a.hardStop()
+
+	// Start App Server
+	srv := api.NewServer(a.cfg, p, a.store)
+	fmt.Printf("[*] Starting App Server on :%d\n", a.cfg.ServerPort)
+
+	shutdownDone := make(chan struct{})
+	go func() {
+		defer close(shutdownDone)
+		<-ctx.Done()
+		fmt.Println("\n[*] Shutting down, waiting for in-flight files (interrupt again to cancel them)...")
+		shutdownCtx, cancel := context.WithTimeout(hardStop, shutdownTimeout)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			log.Printf("[!] Shutdown did not complete cleanly: %v", err)
+		}
+	}()
+
+	// Daemons start organising immediately (or on their schedule) rather than waiting for the dashboard
+	if organise {
+		startRun := func() {
+			if p.SourceDir == "" || p.DestDir == "" {
+				log.Printf("[!] No source/destination configured; waiting for a start request")
+			} else if err := srv.StartPipeline(); err != nil {
+				log.Printf("[!] Skipping run: %v", err)
+			}
+		}
+		if sched != nil {
+			fmt.Printf("[*] Scheduled runs: %s (next at %s)\n", sched, sched.Next(time.Now()).Format(time.RFC3339))
+			go sched.Run(ctx, startRun)
+		} else {
+			startRun()
+		}
+	}
+
+	if err := srv.Start(); err != nil && err != http.ErrServerClosed {
+		log.Fatalf("Failed to start app server: %v", err)
+	}
+	<-shutdownDone
+	p.Webhook.Wait()
+	fmt.Println("[+] Shutdown complete.")
+}
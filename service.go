@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"docs_organiser/internal/daemon"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+func newInstallServiceCommand(flags *pflag.FlagSet) *cobra.Command {
+	return &cobra.Command{
+		Use:   "install-service",
+		Short: "Install a systemd or launchd service that runs the daemon",
+		Args:  cobra.NoArgs,
+		Run:   action(flags, installService),
+	}
+}
+
+// installService writes a systemd user unit or launchd agent that runs the daemon at login.
+func installService(a *app, _ []string) {
+	exe, err := os.Executable()
+	if err != nil {
+		log.Fatalf("Failed to locate executable: %v", err)
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		log.Fatalf("Failed to resolve working directory: %v", err)
+	}
+
+	args := []string{"daemon"}
+	if a.cfg.ConfigFile != "" {
+		configFile, err := filepath.Abs(a.cfg.ConfigFile)
+		if err != nil {
+			log.Fatalf("Failed to resolve config path: %v", err)
+		}
+		args = append(args, "--config", configFile)
+	}
+
+	path, next, err := daemon.InstallService(runtime.GOOS, daemon.ServiceOptions{
+		Executable: exe,
+		Args:       args,
+		WorkingDir: wd,
+		LogPath:    filepath.Join(wd, "data", "docs_organiser.log"),
+	})
+	if err != nil {
+		log.Fatalf("Failed to install service: %v", err)
+	}
+
+	fmt.Printf("[+] Wrote %s\n", path)
+	fmt.Println("[*] Enable it with:")
+	for _, cmd := range next {
+		fmt.Printf("    %s\n", cmd)
+	}
+}
@@ -0,0 +1,466 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"maps"
+	"os"
+	"runtime/debug"
+	"slices"
+	"strings"
+	"time"
+
+	"docs_organiser/internal/ai"
+	"docs_organiser/internal/audit"
+	"docs_organiser/internal/buildinfo"
+	"docs_organiser/internal/config"
+	"docs_organiser/internal/exif"
+	"docs_organiser/internal/extractor"
+	"docs_organiser/internal/fileops"
+	"docs_organiser/internal/grpcapi"
+	"docs_organiser/internal/notes"
+	"docs_organiser/internal/notify"
+	"docs_organiser/internal/observability"
+	"docs_organiser/internal/pipeline"
+	"docs_organiser/internal/remote"
+	"docs_organiser/internal/script"
+	"docs_organiser/internal/search"
+	"docs_organiser/internal/simhash"
+	"docs_organiser/internal/storage"
+	"docs_organiser/internal/taxonomy"
+)
+
+// openPipeline opens the storage, builds the AI engine and the pipeline from the
+// configuration, and starts the services that run beside it: the idle monitor, the
+// metrics and gRPC servers, and the pause toggles.
+func (a *app) openPipeline() *pipeline.Pipeline {
+	if a.p != nil {
+		return a.p
+	}
+	cfg := a.cfg
+	store := a.openStore()
+
+	if cfg.LocalOnly {
+		urls := []string{cfg.APIURL}
+		for _, m := range cfg.AllowedModels {
+			urls = append(urls, m.URL)
+		}
+		for _, u := range urls {
+			if err := config.EnsureLoopback(u); err != nil {
+				log.Fatalf("Refusing to start in local-only mode: %v", err)
+			}
+		}
+	}
+	if cfg.Schedule != "" && a.command != "daemon" {
+		log.Printf("[!] schedule is only used in daemon mode; ignoring %q", cfg.Schedule)
+	}
+
+	// Signal handling for graceful shutdown
+	ctx := a.context()
+	printBanner(cfg)
+	aiEngine := newEngine(cfg, store)
+	p := a.newPipeline(aiEngine, store)
+	a.p = p
+
+	if cfg.IdleTimeout > 0 {
+		p.Idle = &pipeline.IdleMonitor{
+			Timeout: cfg.IdleTimeout,
+			Release: func(ctx context.Context) {
+				log.Printf("[*] Idle for %s, releasing resources", cfg.IdleTimeout)
+				aiEngine.ReleaseResources(ctx, cfg.IdleUnloadModel)
+				debug.FreeOSMemory()
+			},
+		}
+		go p.Idle.Run(ctx)
+	}
+
+	// Start Observability
+	if cfg.MetricsEnabled {
+		go func() {
+			fmt.Printf("[*] Starting Metrics Server on :%d/metrics\n", cfg.MetricsPort)
+			if err := observability.StartMetricsServer(cfg.MetricsPort); err != nil {
+				log.Printf("[!] Metrics server failed: %v", err)
+			}
+		}()
+	}
+
+	// Start gRPC Service
+	if cfg.GRPCPort > 0 {
+		grpcSrv := grpcapi.NewServer(p)
+		go func() {
+			fmt.Printf("[*] Starting gRPC Service on :%d\n", cfg.GRPCPort)
+			if err := grpcSrv.Start(cfg.GRPCPort); err != nil {
+				log.Printf("[!] gRPC service failed: %v", err)
+			}
+		}()
+		go func() {
+			<-ctx.Done()
+			grpcSrv.Stop()
+		}()
+	}
+
+	// Pause toggles let the model server be borrowed without restarting the scan
+	watchPauseSignals(ctx, p)
+	if a.events == nil && isTerminal(os.Stdin) {
+		go watchPauseKey(os.Stdin, p)
+	}
+	return p
+}
+
+// printBanner prints the settings a run starts with.
+func printBanner(cfg *config.Config) {
+	fmt.Println("=== MLX File Mover (Production Ready) ===")
+	fmt.Printf("Version:        %s\n", buildinfo.Get().Version)
+	if cfg.Profile != "" {
+		fmt.Printf("Profile:        %s\n", cfg.Profile)
+	}
+	fmt.Printf("Source:         %s\n", cfg.SourceDir)
+	fmt.Printf("Destination:    %s\n", cfg.DestDir)
+	for _, category := range slices.Sorted(maps.Keys(cfg.CategoryDestinations)) {
+		fmt.Printf("  - %s -> %s\n", category, cfg.CategoryDestinations[category])
+	}
+	fmt.Printf("API URL:        %s\n", cfg.APIURL)
+	fmt.Printf("Model Pool:     %d models configured\n", len(cfg.AllowedModels))
+	for _, m := range cfg.AllowedModels {
+		fmt.Printf("  - %s (%s)\n", m.Name, m.URL)
+	}
+	fmt.Printf("Context:        %d tokens\n", cfg.ContextWindow)
+	fmt.Printf("Limit:          %d characters\n", cfg.ExtractLimit)
+	fmt.Printf("Workers:        %d\n", cfg.Workers)
+	fmt.Printf("DB Path:        %s\n", cfg.DBPath)
+	if cfg.RedactPII {
+		fmt.Println("PII Redaction:  enabled")
+	}
+	if cfg.LocalOnly {
+		fmt.Println("Local Only:     enabled (loopback endpoints only)")
+	}
+	if len(cfg.Include) > 0 {
+		fmt.Printf("Include:        %s\n", strings.Join(cfg.Include, ", "))
+	}
+	if len(cfg.Exclude) > 0 {
+		fmt.Printf("Exclude:        %s\n", strings.Join(cfg.Exclude, ", "))
+	}
+	fmt.Println("-----------------------------------------")
+}
+
+// newEngine builds the AI engine the configuration describes.
+func newEngine(cfg *config.Config, store storage.Store) *ai.MLXEngine {
+	fmt.Println("[*] Initializing AI Engine...")
+	aiEngine, err := ai.NewMLXEngine(cfg.APIURL, cfg.AllowedModels, cfg.ContextWindow, cfg.Encoding)
+	if err != nil {
+		log.Fatalf("Failed to initialize AI engine: %v", err)
+	}
+	aiEngine.SetDebug(cfg.Debug)
+	aiEngine.SetDebugLLM(cfg.DebugLLM)
+	aiEngine.SetSummaryCache(store)
+	aiEngine.SetMaxConcurrentRequests(cfg.MaxConcurrentRequests)
+	aiEngine.SetChunkOverlap(cfg.ChunkOverlap)
+	if err := aiEngine.SetTruncationStrategy(cfg.Truncation); err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+	if err := aiEngine.ConfigureTLS(ai.TLSOptions{
+		CAFile:             cfg.TLSCAFile,
+		CertFile:           cfg.TLSCertFile,
+		KeyFile:            cfg.TLSKeyFile,
+		InsecureSkipVerify: cfg.TLSInsecureSkipVerify,
+	}); err != nil {
+		log.Fatalf("Invalid TLS configuration: %v", err)
+	}
+	if err := aiEngine.SetProxy(cfg.ProxyURL); err != nil {
+		log.Fatalf("Invalid proxy configuration: %v", err)
+	}
+	aiEngine.SetHeaders(cfg.Headers)
+	if cfg.HTTPMaxIdlePerHost < 0 || cfg.HTTPIdleTimeout < 0 || cfg.RequestTimeout < 0 {
+		log.Fatalf("Invalid configuration: http_max_idle_per_host, http_idle_timeout, and request_timeout must not be negative")
+	}
+	aiEngine.ConfigureTransport(ai.TransportOptions{
+		MaxIdleConnsPerHost: cfg.HTTPMaxIdlePerHost,
+		IdleConnTimeout:     cfg.HTTPIdleTimeout,
+		DisableKeepAlives:   !cfg.HTTPKeepAlive,
+		RequestTimeout:      cfg.RequestTimeout,
+	})
+	if cfg.TLSInsecureSkipVerify {
+		log.Printf("[!] Warning: TLS certificate verification is disabled for model endpoints")
+	}
+	if cfg.TaxonomyFile != "" {
+		tax, err := taxonomy.Load(cfg.TaxonomyFile)
+		if err != nil {
+			log.Fatalf("Invalid configuration: %v", err)
+		}
+		aiEngine.SetTaxonomy(tax)
+		fmt.Printf("[*] Using %d categories from taxonomy %s.\n", len(tax.Paths()), cfg.TaxonomyFile)
+	} else if len(cfg.Categories) > 0 {
+		aiEngine.SetCategories(cfg.Categories)
+		fmt.Printf("[*] Using %d manual categories from config.\n", len(cfg.Categories))
+	}
+	aiEngine.SetCategoryDescriptions(cfg.CategoryDescriptions)
+	if err := aiEngine.SetCategoryAliases(cfg.CategoryAliases); err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+	aiEngine.SetTwoStage(cfg.TwoStage)
+	if cfg.SystemPromptFile != "" {
+		tmpl, err := ai.LoadSystemPrompt(cfg.SystemPromptFile)
+		if err != nil {
+			log.Fatalf("Invalid configuration: %v", err)
+		}
+		aiEngine.SetSystemPrompt(tmpl)
+		fmt.Printf("[*] Using the system prompt from %s.\n", cfg.SystemPromptFile)
+	}
+	aiEngine.SetVoting(cfg.Votes, cfg.VoteTemperature)
+	if err := aiEngine.SetClarification(cfg.ClarifyMinConfidence, cfg.ClarifyMaxConfidence); err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+	if err := aiEngine.SetSampling(ai.Sampling{Temperature: cfg.Temperature, TopP: cfg.TopP, MaxTokens: cfg.MaxTokens, Stop: cfg.Stop}); err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+	aiEngine.SetLogprobs(cfg.Logprobs)
+	if err := aiEngine.SetHeuristicRules(cfg.Keywords, cfg.FilenamePatterns); err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+	if cfg.DefaultModelName != "" {
+		aiEngine.SetDefaultModel(cfg.DefaultModelName)
+	}
+	fmt.Println("[+] AI Engine initialized.")
+	return aiEngine
+}
+
+// newPipeline builds the pipeline the configuration describes around aiEngine. The
+// audit log and results CSV it opens are closed when the command returns.
+func (a *app) newPipeline(aiEngine *ai.MLXEngine, store storage.Store) *pipeline.Pipeline {
+	cfg := a.cfg
+	extractorPlugins, classifierPlugin, err := pipeline.ParsePlugins(cfg.Plugins, cfg.PluginTimeout)
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+
+	p := pipeline.NewPipeline(cfg.SourceDir, cfg.DestDir, aiEngine, cfg.Workers, cfg.ExtractLimit)
+	modifiedAfter, err := pipeline.ParseModifiedAfter(cfg.NewerThan, cfg.Since, time.Now())
+	if err != nil {
+		log.Fatalf("Invalid scanner filter: %v", err)
+	}
+	extractorCommands := make(map[string][]string, len(cfg.Extractors))
+	for ext, command := range cfg.Extractors {
+		ext = strings.ToLower(strings.TrimPrefix(ext, "."))
+		extractorCommands[ext] = command
+		a.extractorExts = append(a.extractorExts, "."+ext)
+		fmt.Printf("[*] External extractor for .%s: %s\n", ext, strings.Join(command, " "))
+	}
+	for _, ext := range slices.Sorted(maps.Keys(extractorPlugins)) {
+		if _, ok := extractorCommands[ext]; !ok {
+			a.extractorExts = append(a.extractorExts, "."+ext)
+		}
+		fmt.Printf("[*] Extractor plugin for .%s: %s\n", ext, extractorPlugins[ext].Name)
+	}
+	scanExts := a.extractorExts
+	if cfg.PhotoPath != "" {
+		p.Photos = &pipeline.PhotoRouting{Path: cfg.PhotoPath, Name: cfg.PhotoName}
+		if err := p.Photos.Validate(); err != nil {
+			log.Fatalf("Invalid configuration: %v", err)
+		}
+		scanExts = append(append([]string(nil), a.extractorExts...), exif.Extensions...)
+		fmt.Printf("[*] Routing photos by EXIF data to %s\n", cfg.PhotoPath)
+	}
+	if cfg.DocumentPath != "" || cfg.DocumentName != "" {
+		p.Documents = &pipeline.DocumentRouting{Path: cfg.DocumentPath, Name: cfg.DocumentName}
+		if err := p.Documents.Validate(); err != nil {
+			log.Fatalf("Invalid configuration: %v", err)
+		}
+	}
+	p.Filter = pipeline.ScanFilter{Include: cfg.Include, Exclude: cfg.Exclude, Extensions: scanExts, ModifiedAfter: modifiedAfter}
+	if err := p.Filter.Validate(); err != nil {
+		log.Fatalf("Invalid scanner filter: %v", err)
+	}
+	p.Traversal = pipeline.WalkOptions{MaxDepth: cfg.MaxDepth, FollowSymlinks: cfg.FollowSymlinks}
+	p.Extraction = extractor.Options{PDFToText: cfg.PDFToTextFallback, Commands: extractorCommands, Plugins: extractorPlugins}
+	p.ExtractLimits = make(map[string]int, len(cfg.ExtractLimits))
+	for ext, limit := range cfg.ExtractLimits {
+		if limit <= 0 {
+			log.Fatalf("Invalid configuration: extract_limits.%s must be a positive number of characters", ext)
+		}
+		p.ExtractLimits[strings.ToLower(strings.TrimPrefix(ext, "."))] = limit
+	}
+	p.Remote = remote.Options{S3: remote.S3Options{Endpoint: cfg.S3Endpoint, Region: cfg.S3Region, PathStyle: cfg.S3PathStyle}}
+	p.RedactPII = cfg.RedactPII
+	p.AutoContext = cfg.AutoContext
+	p.WarmUp = cfg.WarmUp
+	if cfg.CircuitBreaker < 0 || (cfg.CircuitBreaker > 0 && cfg.CircuitProbeInterval <= 0) {
+		log.Fatalf("Invalid configuration: circuit_breaker must not be negative, and circuit_probe_interval must be positive")
+	}
+	aiEngine.SetCircuitBreaker(cfg.CircuitBreaker, cfg.CircuitProbeInterval, p.SetServerDown)
+	p.NoLLM = cfg.NoLLM
+	p.Classifier = classifierPlugin
+	p.FastPath = cfg.FastPath
+	if cfg.RoutingScript != "" {
+		if p.Script, err = script.Load(cfg.RoutingScript); err != nil {
+			log.Fatalf("Invalid configuration: %v", err)
+		}
+		fmt.Printf("[*] Routing decisions through %s\n", cfg.RoutingScript)
+	}
+	p.RemoveEmptyDirs = cfg.RemoveEmptyDirs
+	if cfg.UnprocessedAfter < 0 {
+		log.Fatalf("Invalid configuration: unprocessed_after must not be negative")
+	}
+	p.DeadLetterAfter, p.Attempts = cfg.UnprocessedAfter, store
+	p.EmbeddingModel = cfg.EmbeddingModel
+	if cfg.SemanticIndex {
+		p.Search = search.NewIndex(store)
+	}
+	if p.NearDuplicateMode, err = pipeline.ParseNearDuplicateMode(cfg.NearDuplicates); err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+	if err := pipeline.ValidateNearDuplicateDistance(cfg.NearDuplicateDistance); err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+	if p.NearDuplicateMode != pipeline.NearDuplicatesOff {
+		p.NearDuplicates, p.NearDuplicateDistance = simhash.NewIndex(store), cfg.NearDuplicateDistance
+	}
+	if p.Naming, err = pipeline.ParseNamingMode(cfg.Naming); err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+	if p.Layout, err = pipeline.ParseLayout(cfg.Layout); err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+	if p.Destinations, err = pipeline.ParseDestinations(cfg.CategoryDestinations); err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+	p.ASCIINames = cfg.ASCIINames
+	if err := fileops.ValidateNameLimit(cfg.MaxFilenameBytes); err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+	p.MaxFilenameBytes = cfg.MaxFilenameBytes
+	if cfg.DiskReserveMB < 0 {
+		log.Fatalf("Invalid configuration: disk_reserve_mb must not be negative")
+	}
+	p.DiskReserve = int64(cfg.DiskReserveMB) << 20
+	if cfg.IOLimitMB < 0 || cfg.IOLimitOps < 0 {
+		log.Fatalf("Invalid configuration: io_limit_mb and io_limit_ops must not be negative")
+	}
+	if cfg.MemoryLimitMB < 0 {
+		log.Fatalf("Invalid configuration: memory_limit_mb must not be negative")
+	}
+	p.MemoryLimit = int64(cfg.MemoryLimitMB) << 20
+	p.Throttle = fileops.NewThrottle(int64(cfg.IOLimitMB*float64(1<<20)), cfg.IOLimitOps)
+	p.Events = a.events
+	p.MaxFiles, p.Sample = cfg.MaxFiles, cfg.Sample
+	if p.Order, err = pipeline.ParseJobOrder(cfg.Order); err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+	if p.Collisions, err = fileops.ParseCollisionPolicy(cfg.Collisions); err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+	if p.CollisionHash, err = fileops.ParseHashScheme(cfg.CollisionHash); err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+	if cfg.PartialHashMB < 0 {
+		log.Fatalf("Invalid configuration: partial_hash_mb must not be negative")
+	}
+	p.PartialHashBytes = int64(cfg.PartialHashMB) << 20
+	if cfg.AuditLog != "" {
+		auditLog, err := audit.Open(cfg.AuditLog)
+		if err != nil {
+			log.Fatalf("Failed to open audit log: %v", err)
+		}
+		a.onClose(func() { auditLog.Close() })
+		p.Audit = auditLog
+		fmt.Printf("[*] Writing audit records to %s\n", cfg.AuditLog)
+	}
+	if cfg.ResultsCSV != "" {
+		results, err := audit.OpenCSV(cfg.ResultsCSV)
+		if err != nil {
+			log.Fatalf("Failed to open results CSV: %v", err)
+		}
+		a.onClose(func() { results.Close() })
+		p.Results = results
+		fmt.Printf("[*] Writing classification results to %s\n", cfg.ResultsCSV)
+	}
+	if cfg.ClassifyOnly {
+		p.ClassifyOnly = true
+		fmt.Println("[*] Classify-only mode: files are left in place")
+		if cfg.AuditLog == "" && cfg.ResultsCSV == "" {
+			log.Printf("[!] classify_only without audit_log or results_csv only logs the decisions")
+		}
+	}
+	p.FailuresFile = cfg.FailuresFile
+	if cfg.RetryFrom != "" {
+		if a.command != "organize" {
+			log.Printf("[!] retry_from is only used by organize; ignoring %q", cfg.RetryFrom)
+		} else if p.Retry, err = pipeline.LoadFailures(cfg.RetryFrom); err != nil {
+			log.Fatalf("Failed to read failures file: %v", err)
+		}
+	}
+	p.QueueFile = cfg.QueueFile
+	if cfg.ProcessQueue {
+		if cfg.QueueFile == "" || cfg.RetryFrom != "" {
+			log.Fatalf("Invalid configuration: process_queue needs queue_file, and can't be combined with retry_from")
+		}
+		if a.command != "organize" {
+			log.Printf("[!] process_queue is only used by organize; ignoring it")
+		} else {
+			p.ProcessQueue = true
+		}
+	}
+	if err := pipeline.ValidateSidecarFormat(cfg.Sidecar); err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+	p.Sidecar = cfg.Sidecar
+	if len(cfg.InvoiceCategories) > 0 {
+		p.InvoiceCategories = cfg.InvoiceCategories
+		fmt.Printf("[*] Reading invoice details of documents in %s\n", strings.Join(cfg.InvoiceCategories, ", "))
+	}
+	if cfg.PDFMetadata {
+		p.PDFMetadata = true
+		aiEngine.SetDescribe(true) // tags become PDF keywords
+	}
+	if cfg.NotesDir != "" {
+		p.Notes = &notes.Vault{Dir: cfg.NotesDir}
+		aiEngine.SetDescribe(true)
+		fmt.Printf("[*] Writing document notes to %s\n", cfg.NotesDir)
+	}
+	if len(cfg.WebhookURLs) > 0 {
+		webhook, err := notify.NewWebhook(cfg.WebhookURLs, cfg.WebhookEvents, cfg.WebhookMinConfidence)
+		if err != nil {
+			log.Fatalf("Invalid webhook configuration: %v", err)
+		}
+		p.Webhook = webhook
+		fmt.Printf("[*] Sending webhook events to %d URL(s)\n", len(cfg.WebhookURLs))
+	}
+	if cfg.SlackWebhookURL != "" {
+		p.Summaries = append(p.Summaries, notify.Slack{WebhookURL: cfg.SlackWebhookURL})
+	}
+	if cfg.DiscordWebhookURL != "" {
+		p.Summaries = append(p.Summaries, notify.Discord{WebhookURL: cfg.DiscordWebhookURL})
+	}
+	if cfg.SMTPHost != "" {
+		email := notify.Email{
+			Host:     cfg.SMTPHost,
+			Port:     cfg.SMTPPort,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+			From:     cfg.EmailFrom,
+			To:       cfg.EmailTo,
+		}
+		if err := email.Validate(); err != nil {
+			log.Fatalf("Invalid email configuration: %v", err)
+		}
+		p.Summaries = append(p.Summaries, email)
+	}
+	for _, ch := range p.Summaries {
+		fmt.Printf("[*] Sending run summaries via %s\n", ch.Name())
+	}
+	if cfg.HookTimeout < 0 {
+		log.Fatalf("Invalid configuration: hook_timeout must not be negative")
+	}
+	if len(cfg.PreRunHook) > 0 {
+		p.PreRunHook = &notify.Hook{Command: cfg.PreRunHook, Timeout: cfg.HookTimeout}
+		fmt.Printf("[*] Running %s before each run\n", cfg.PreRunHook[0])
+	}
+	if len(cfg.PostRunHook) > 0 {
+		p.PostRunHook = &notify.Hook{Command: cfg.PostRunHook, Timeout: cfg.HookTimeout}
+		fmt.Printf("[*] Running %s after each run\n", cfg.PostRunHook[0])
+	}
+	if !modifiedAfter.IsZero() {
+		fmt.Printf("[*] Only processing files modified since %s\n", modifiedAfter.Format(time.RFC3339))
+	}
+	return p
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+
+	"docs_organiser/internal/remote"
+	"docs_organiser/internal/stats"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+func newStatsCommand(flags *pflag.FlagSet) *cobra.Command {
+	return &cobra.Command{
+		Use:   "stats",
+		Short: "Report file counts, sizes, and oldest and newest files per destination category",
+		Args:  cobra.NoArgs,
+		Run:   action(flags, runStats),
+	}
+}
+
+// runStats reports the files of each category in the destination.
+func runStats(a *app, _ []string) {
+	a.openStore()
+	dir := a.cfg.DestDir
+	if dir == "" {
+		log.Fatalf("No destination configured; set dst in the config file or the dashboard")
+	}
+	if remote.IsRemote(dir) {
+		log.Fatalf("stats requires a local destination directory")
+	}
+	report, err := stats.Collect(dir)
+	if err != nil {
+		log.Fatalf("Failed to read the destination: %v", err)
+	}
+	if a.asJSON() {
+		err = json.NewEncoder(a.stdout).Encode(report)
+	} else {
+		err = report.WriteText(a.stdout)
+	}
+	if err != nil {
+		log.Fatalf("Failed to write report: %v", err)
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+
+	"docs_organiser/internal/pipeline"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+func newSuggestCategoriesCommand(flags *pflag.FlagSet) *cobra.Command {
+	return &cobra.Command{
+		Use:   "suggest-categories",
+		Short: "Cluster a sample of the source documents and propose a taxonomy of categories",
+		Args:  cobra.NoArgs,
+		Run:   action(flags, runSuggestCategories),
+	}
+}
+
+// runSuggestCategories proposes categories for the source folder and prints them as a
+// taxonomy file.
+func runSuggestCategories(a *app, _ []string) {
+	p := a.openPipeline()
+	a.preflight()
+	if p.SourceDir == "" {
+		log.Fatalf("No source configured; set src in the config file or the dashboard")
+	}
+	suggestions, err := p.SuggestCategories(a.context(), pipeline.SuggestOptions{
+		Samples:        a.cfg.SuggestSamples,
+		Clusters:       a.cfg.SuggestClusters,
+		EmbeddingModel: a.cfg.EmbeddingModel,
+	})
+	if err != nil {
+		log.Fatalf("Failed to suggest categories: %v", err)
+	}
+	if a.asJSON() {
+		err = json.NewEncoder(a.stdout).Encode(suggestions)
+	} else {
+		err = pipeline.WriteTaxonomy(a.stdout, suggestions)
+	}
+	if err != nil {
+		log.Fatalf("Failed to write suggestions: %v", err)
+	}
+}
@@ -1,5 +1,5 @@
 import React, { useState, useEffect } from 'react';
-import { Play, Pause, Square, Activity, Folder, FileText, CheckCircle, XCircle, Settings, Plus, Trash2, RefreshCw, Cpu, Clock, Terminal } from 'lucide-react';
+import { Play, Pause, Square, Activity, Folder, FileText, CheckCircle, XCircle, Settings, Plus, Trash2, RefreshCw, Cpu, Clock, Terminal, Pencil, RotateCcw, Eye } from 'lucide-react';
 
 interface PipelineStatus {
   total: number;
@@ -10,6 +10,28 @@ interface PipelineStatus {
   is_paused: boolean;
 }
 
+interface Decision {
+  id: number;
+  time: string;
+  source: string;
+  status: string;
+  category?: string;
+  title?: string;
+  destination?: string;
+  confidence: number;
+  fallback?: boolean;
+  error?: string;
+  review?: 'pending' | 'approved' | 'overridden' | 'undone';
+}
+
+interface ReviewState {
+  threshold: number;
+  recent: Decision[];
+  pending: Decision[];
+}
+
+const baseName = (path: string) => path.split(/[\\/]/).pop() || path;
+
 interface ModelInfo {
   name: string;
   url: string;
@@ -29,6 +51,7 @@ const App: React.FC = () => {
   const [newModelURL, setNewModelURL] = useState('');
   const [workers, setWorkers] = useState(5);
   const [limit, setLimit] = useState(100000);
+  const [review, setReview] = useState<ReviewState>({ threshold: 0, recent: [], pending: [] });
 
   const [activePage, setActivePage] = useState<'dashboard' | 'models'>('dashboard');
 
@@ -57,6 +80,40 @@ const App: React.FC = () => {
     }
   };
 
+  const fetchReview = async (signal?: AbortSignal) => {
+    try {
+      const res = await fetch('/v1/review', { signal });
+      if (res.ok) {
+        setReview(await res.json());
+      }
+    } catch (e) {
+      console.error("Failed to fetch decisions", e);
+    }
+  };
+
+  const reviewDecision = async (d: Decision, action: 'approve' | 'override' | 'undo') => {
+    let body: string | undefined;
+    if (action === 'override') {
+      const category = prompt(`Move ${baseName(d.source)} to category:`, d.category);
+      if (!category) return;
+      const title = prompt('New name (without extension; leave empty to keep it):', '') || '';
+      body = JSON.stringify({ category, title });
+    }
+    try {
+      const res = await fetch(`/v1/review/${d.id}/${action}`, {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body
+      });
+      if (!res.ok) {
+        alert(`Failed to ${action} ${baseName(d.source)}: ${await res.text()}`);
+      }
+      fetchReview();
+    } catch (e) {
+      console.error(e);
+    }
+  };
+
   const fetchPipelineStatus = async (signal?: AbortSignal) => {
     try {
       const res = await fetch('/v1/pipeline/status', { signal });
@@ -93,7 +150,11 @@ const App: React.FC = () => {
     fetchModels();
     fetchConfig();
     fetchPipelineStatus(controller.signal);
-    const interval = setInterval(fetchModels, 10000);
+    fetchReview(controller.signal);
+    const interval = setInterval(() => {
+      fetchModels();
+      fetchReview(controller.signal);
+    }, 10000);
     return () => {
       controller.abort();
       clearInterval(interval);
@@ -104,7 +165,10 @@ const App: React.FC = () => {
     let interval: any;
     const controller = new AbortController();
     if (isRunning) {
-      interval = setInterval(() => fetchPipelineStatus(controller.signal), 1000);
+      interval = setInterval(() => {
+        fetchPipelineStatus(controller.signal);
+        fetchReview(controller.signal);
+      }, 1000);
     }
     return () => {
       controller.abort();
@@ -329,6 +393,71 @@ const App: React.FC = () => {
         </div>
       </div>
 
+      {/* Decisions Awaiting Review */}
+      <div className="card" style={{ gridColumn: 'span 12' }}>
+        <div className="card-header">
+          <div className="card-title"><Eye size={20} /> Awaiting Review ({review.pending.length})</div>
+          <div style={{ fontSize: '0.85rem', color: 'var(--text-secondary)' }}>
+            Confidence below {Math.round(review.threshold * 100)}% and fallbacks
+          </div>
+        </div>
+        {review.pending.length > 0 ? (
+          <div className="model-pool">
+            {review.pending.map(d => (
+              <div key={d.id} className="model-item">
+                <div className="model-info" style={{ flex: 1 }}>
+                  <div style={{ display: 'flex', alignItems: 'center', gap: '0.5rem' }}>
+                    <span className="model-name" style={{ fontWeight: 'bold' }}>{baseName(d.source)}</span>
+                    <span className={`model-badge ${d.fallback ? 'badge-offline' : 'badge-default'}`}>
+                      {d.fallback ? 'Fallback' : `${Math.round(d.confidence * 100)}%`}
+                    </span>
+                  </div>
+                  <div style={{ fontSize: '0.75rem', color: 'var(--text-secondary)', marginTop: '0.25rem' }}>
+                    {d.category}/{d.title}{d.error ? ` - ${d.error}` : ''}
+                  </div>
+                </div>
+                <div style={{ display: 'flex', gap: '0.5rem' }}>
+                  <button className="secondary" onClick={() => reviewDecision(d, 'approve')} style={{ padding: '8px' }} title="Approve">
+                    <CheckCircle size={18} />
+                  </button>
+                  <button className="secondary" onClick={() => reviewDecision(d, 'override')} style={{ padding: '8px' }} title="Move to another category">
+                    <Pencil size={18} />
+                  </button>
+                  <button className="delete-btn" onClick={() => reviewDecision(d, 'undo')} style={{ padding: '8px' }} title="Move back to the source">
+                    <RotateCcw size={18} />
+                  </button>
+                </div>
+              </div>
+            ))}
+          </div>
+        ) : (
+          <div style={{ color: 'var(--text-secondary)', fontSize: '0.9rem' }}>Nothing to review.</div>
+        )}
+      </div>
+
+      {/* Recent Decisions */}
+      <div className="card" style={{ gridColumn: 'span 12', height: '300px', display: 'flex', flexDirection: 'column' }}>
+        <div className="card-header">
+          <div className="card-title"><FileText size={20} /> Recent Decisions</div>
+        </div>
+        <div style={{ flex: 1, overflowY: 'auto', padding: '0.5rem', background: 'rgba(0,0,0,0.2)', borderRadius: '4px', fontFamily: 'monospace', fontSize: '0.8rem' }}>
+          {review.recent.length > 0 ? (
+            review.recent.map(d => (
+              <div key={d.id} style={{ padding: '0.2rem 0', borderBottom: '1px solid rgba(255,255,255,0.05)', display: 'flex', gap: '1rem' }}>
+                <span style={{ color: 'var(--text-secondary)', width: '80px' }}>[{new Date(d.time).toLocaleTimeString()}]</span>
+                <span style={{ color: d.status === 'moved' ? 'var(--success-color)' : 'var(--error-color)', width: '130px' }}>{d.status}</span>
+                <span style={{ flex: 1 }}>{baseName(d.source)}{d.category ? ` -> ${d.category}/${d.title}` : ''}</span>
+                {d.review && d.review !== 'pending' && <span style={{ opacity: 0.5 }}>{d.review}</span>}
+              </div>
+            ))
+          ) : (
+            <div style={{ display: 'flex', alignItems: 'center', justifyContent: 'center', height: '100%', color: 'var(--text-secondary)' }}>
+              No files organised yet...
+            </div>
+          )}
+        </div>
+      </div>
+
       {/* Recent Activity Feed */}
       <div className="card" style={{ gridColumn: 'span 12', height: '300px', display: 'flex', flexDirection: 'column' }}>
         <div className="card-header">
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"maps"
+	"path/filepath"
+	"slices"
+
+	"docs_organiser/internal/audit"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+func newUndoCommand(flags *pflag.FlagSet) *cobra.Command {
+	return &cobra.Command{
+		Use:   "undo [file...]",
+		Short: "Move organised files back to where they were found, as the audit log records",
+		Long: `undo reads audit_log and moves organised files back to their source paths,
+newest first. Name the files by their source or organised path, or undo every move
+recorded since --since or within --newer-than. Files whose source path is taken
+again, and duplicates that were removed rather than moved, are left alone.`,
+		Run: action(flags, runUndo),
+	}
+}
+
+// runUndo moves the files named by args, or all those moved since the cutoff, back to
+// their sources. A signal stops it after the file being moved.
+func runUndo(a *app, args []string) {
+	records, cutoff := readAudit(a, "undo")
+	if len(args) == 0 && cutoff.IsZero() {
+		log.Fatalf("Name the files to undo, or choose the moves with --since or --newer-than")
+	}
+	named := make(map[string]bool, len(args))
+	for _, arg := range args {
+		named[absPath(arg)] = true
+	}
+
+	var moves []audit.Record
+	found := make(map[string]bool, len(args))
+	for _, rec := range audit.Latest(records) {
+		if rec.Status != audit.StatusMoved {
+			continue
+		}
+		src, dst := absPath(rec.Source), absPath(rec.Destination)
+		if len(named) > 0 && !named[src] && !named[dst] {
+			continue
+		}
+		found[src], found[dst] = true, true
+		moves = append(moves, rec)
+	}
+	for _, path := range slices.Sorted(maps.Keys(named)) {
+		if !found[path] {
+			log.Printf("[!] The audit log records no move of %s to undo", path)
+		}
+	}
+	if len(moves) == 0 {
+		fmt.Println("[*] Nothing to undo.")
+		return
+	}
+
+	p := a.openPipeline()
+	ctx := a.context()
+	undone, failed := 0, 0
+	for _, rec := range slices.Backward(moves) {
+		if ctx.Err() != nil {
+			break
+		}
+		if _, err := p.Undo(ctx, rec); err != nil {
+			log.Printf("[!] Failed to undo %s: %v", rec.Source, err)
+			failed++
+			continue
+		}
+		undone++
+	}
+	log.Printf("[+] Moved %d file(s) back, %d failed", undone, failed)
+	if failed > 0 {
+		a.exit(1)
+	}
+}
+
+// absPath returns path made absolute, or path itself when that fails.
+func absPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}